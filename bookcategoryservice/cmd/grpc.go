@@ -5,12 +5,22 @@ import (
 	"log"
 	"net"
 
+	"github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/config"
 	"github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/internal/setup"
+	db "github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/pkg/datasource/postgres"
+	authpb "github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/proto/auth"
+	bookpb "github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/proto/book"
+	"github.com/sir-shalahuddin/grpc-learn/pkg/cache"
 	"google.golang.org/grpc"
 )
 
 // StartGRPCServer initializes and starts the gRPC server
-func StartGRPCServer(db *sql.DB, port string) {
+func StartGRPCServer(database *sql.DB, dbConfig config.DBConfig, authSvc authpb.AuthServiceClient, bookSvc bookpb.BookServiceClient, port string, jwtSecret string, authCache cache.Cache, cacheConfig config.CacheConfig) {
+	// Category changes are picked up over a dedicated LISTEN connection
+	listener, err := db.NewListener(dbConfig)
+	if err != nil {
+		log.Fatalf("failed to create database listener: %v", err)
+	}
 
 	lis, err := net.Listen("tcp", ":"+port)
 	if err != nil {
@@ -21,7 +31,7 @@ func StartGRPCServer(db *sql.DB, port string) {
 	grpcServer := grpc.NewServer()
 
 	// Register AuthService routes
-	setup.GRPCServer(grpcServer, db)
+	setup.GRPCServer(grpcServer, database, listener, authSvc, bookSvc, jwtSecret, authCache, cacheConfig)
 	log.Printf("gRPC server listening on %s", ":"+port)
 
 	// Start the gRPC server
@@ -3,12 +3,14 @@ package main
 import (
 	"log"
 	"strings"
+	"time"
 	_ "github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/docs"
 
 	"github.com/joho/godotenv"
 	"github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/config"
 	grpcclient "github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/pkg/datasource/grpc"
 	db "github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/pkg/datasource/postgres"
+	redisclient "github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/pkg/datasource/redis"
 )
 
 // @title Category Service API
@@ -55,7 +57,19 @@ func main() {
 	}
 
 	GRPCConfig := config.GRPCConfig{
-		AuthAddress: config.GetEnv("AUTH_ADDRESS"),
+		AuthAddress:  config.GetEnv("AUTH_ADDRESS"),
+		BookAddress:  config.GetEnv("BOOK_ADDRESS"),
+		ServiceToken: config.GetEnv("GRPC_SERVICE_TOKEN"),
+	}
+
+	CacheConfig := config.CacheConfig{
+		Backend:        config.GetEnvOrDefault("AUTH_CACHE_BACKEND", "memory"),
+		Size:           config.GetEnvAsInt("AUTH_CACHE_SIZE", 10000),
+		TTL:            config.GetEnvAsDuration("AUTH_CACHE_TTL", 30*time.Second),
+		NegativeTTL:    config.GetEnvAsDuration("AUTH_CACHE_NEGATIVE_TTL", 5*time.Second),
+		MemoryTokenTTL: config.GetEnvAsDuration("AUTH_CACHE_MEMORY_TOKEN_TTL", 5*time.Second),
+		RedisAddress:   config.GetEnvOrDefault("REDIS_ADDR", "localhost:6379"),
+		RedisPassword:  config.GetEnvOrDefault("REDIS_PASSWORD", ""),
 	}
 
 	db, err := db.NewDB(DBConfig)
@@ -68,14 +82,24 @@ func main() {
 		panic(err)
 	}
 
+	bookClients, err := grpcclient.NewBookClients(GRPCConfig.BookAddress, GRPCConfig.ServiceToken)
+	if err != nil {
+		panic(err)
+	}
+
+	authCache, err := redisclient.NewAuthCache(CacheConfig)
+	if err != nil {
+		panic(err)
+	}
+
 	mode := strings.ToLower(AppConfig.Mode)
 	// Start REST server in a separate goroutine
 	if mode == "rest" {
-		StartRESTServer(db, grpcClients, AppConfig.RESTPort, JWTConfig.Secret)
+		StartRESTServer(db, grpcClients, bookClients, AppConfig.RESTPort, JWTConfig.Secret, authCache, CacheConfig)
 	}
 
 	// Start gRPC server in a separate goroutine
 	if mode == "grpc" {
-		StartGRPCServer(db, AppConfig.GRPCPort)
+		StartGRPCServer(db, DBConfig, grpcClients, bookClients, AppConfig.GRPCPort, JWTConfig.Secret, authCache, CacheConfig)
 	}
 }
@@ -7,17 +7,20 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/config"
 	router "github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/internal/routes"
 	pb "github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/proto/auth"
+	bookpb "github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/proto/book"
+	"github.com/sir-shalahuddin/grpc-learn/pkg/cache"
 )
 
-func StartRESTServer(db *sql.DB, authSvc pb.AuthServiceClient, port string, jwtSecret string) {
+func StartRESTServer(db *sql.DB, authSvc pb.AuthServiceClient, bookSvc bookpb.BookServiceClient, port string, jwtSecret string, authCache cache.Cache, cacheConfig config.CacheConfig) {
 	app := fiber.New()
 
 	app.Use(cors.New())
 	app.Use(logger.New())
 
-	router.RegisterRoutes(app, db, authSvc, jwtSecret)
+	router.RegisterRoutes(app, db, authSvc, bookSvc, jwtSecret, authCache, cacheConfig)
 	err := (app.Listen(":" + port))
 	if err != nil {
 		log.Fatalf("failed to start REST server: %v", err)
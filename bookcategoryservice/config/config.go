@@ -3,7 +3,9 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type AppConfig struct {
@@ -28,6 +30,20 @@ type JWTConfig struct {
 
 type GRPCConfig struct {
 	AuthAddress string
+	BookAddress string
+	// ServiceToken is the secret bookservice expects for the gRPC methods
+	// meant only for other services
+	ServiceToken string
+}
+
+type CacheConfig struct {
+	Backend        string
+	Size           int
+	TTL            time.Duration
+	NegativeTTL    time.Duration
+	MemoryTokenTTL time.Duration
+	RedisAddress   string
+	RedisPassword  string
 }
 
 func GetEnv(key string) string {
@@ -48,3 +64,35 @@ func GetEnvAsBool(key string) bool {
 	}
 	return false
 }
+
+func GetEnvOrDefault(key, fallback string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+func GetEnvAsInt(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Fatalf("%s must be a number: %v", key, err)
+	}
+	return n
+}
+
+func GetEnvAsDuration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("%s must be a duration such as 30s: %v", key, err)
+	}
+	return d
+}
@@ -1,16 +1,76 @@
 package dto
 
-import "github.com/google/uuid"
+import (
+	"github.com/google/uuid"
+	"github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/models"
+)
 
 type CreateBookCategoryRequest struct {
-	Name string `json:"name" validate:"required"`
+	Name     string     `json:"name" validate:"required"`
+	ParentID *uuid.UUID `json:"parent_id"`
+	// Slug is generated from Name when left empty
+	Slug        string            `json:"slug"`
+	Description string            `json:"description"`
+	SortOrder   int               `json:"sort_order"`
+	Archived    bool              `json:"archived"`
+	Names       map[string]string `json:"names"` // translated names by locale
 }
 
 type CreateBookCategoryResponse struct {
-	ID   uuid.UUID `json:"id"`
-	Name string    `json:"name"`
+	ID       uuid.UUID  `json:"id"`
+	Name     string     `json:"name"`
+	ParentID *uuid.UUID `json:"parent_id,omitempty"`
+	Slug     string     `json:"slug"`
 }
 
+// UpdateBookCategoryRequest renames a category. Fields left out are kept;
+// names replaces every translation when present.
 type UpdateBookCategoryRequest struct {
-	Name string `json:"name" validate:"required"`
+	Name        string            `json:"name" validate:"required"`
+	Slug        *string           `json:"slug"`
+	Description *string           `json:"description"`
+	SortOrder   *int              `json:"sort_order"`
+	Archived    *bool             `json:"archived"`
+	Names       map[string]string `json:"names"`
+}
+
+// MoveBookCategoryRequest moves a category under ParentID, or to the top
+// level when ParentID is null.
+type MoveBookCategoryRequest struct {
+	ParentID *uuid.UUID `json:"parent_id"`
+}
+
+// ListBookCategoriesRequest selects a page of categories. Cursor is the
+// NextCursor of the previous page.
+type ListBookCategoriesRequest struct {
+	IncludeArchived bool
+	Cursor          string
+	PageSize        int
+	IncludeTotal    bool
+}
+
+// ListBookCategoriesResponse is a page of categories. NextCursor is empty on
+// the last page and Total is only set when it was asked for.
+type ListBookCategoriesResponse struct {
+	Categories []models.BookCategory `json:"categories"`
+	NextCursor string                `json:"next_cursor,omitempty"`
+	Total      *int64                `json:"total,omitempty"`
+}
+
+// DeleteBookCategoryResponse reports how many books were moved to the
+// reassignment target before the category was deleted.
+type DeleteBookCategoryResponse struct {
+	ReassignedBooks int64 `json:"reassigned_books"`
+}
+
+// MergeBookCategoryRequest names the category to fold another one into.
+type MergeBookCategoryRequest struct {
+	TargetID uuid.UUID `json:"target_id" validate:"required"`
+}
+
+// MergeBookCategoryResponse is the category merged into and how many books
+// were moved to it.
+type MergeBookCategoryResponse struct {
+	Category        models.BookCategory `json:"category"`
+	ReassignedBooks int64               `json:"reassigned_books"`
 }
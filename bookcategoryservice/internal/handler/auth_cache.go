@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/pkg/response"
+	"github.com/sir-shalahuddin/grpc-learn/pkg/cache"
+)
+
+type AuthCacheService interface {
+	CacheStats() cache.Stats
+}
+
+type authCacheHandler struct {
+	authService AuthCacheService
+}
+
+func NewAuthCacheHandler(authService AuthCacheService) *authCacheHandler {
+	return &authCacheHandler{authService: authService}
+}
+
+// CacheStats godoc
+// @Summary Get auth cache stats
+// @Description Super admin reads hit and miss counts of the token and user cache
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} response.Response{data=cache.Stats} "Auth cache stats retrieved successfully"
+// @Failure 401 {object} response.ErrorMessage "Unauthorized"
+// @Failure 403 {object} response.ErrorMessage "Forbidden"
+// @Security BearerAuth
+// @Router /auth/cache-stats [get]
+func (h *authCacheHandler) CacheStats(c *fiber.Ctx) error {
+	return response.HandleSuccess(c, "auth cache stats retrieved successfully", h.authService.CacheStats(), fiber.StatusOK)
+}
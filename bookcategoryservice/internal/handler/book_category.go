@@ -10,15 +10,22 @@ import (
 	"github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/dto"
 	"github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/internal/service"
 	"github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/models"
+	"github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/pkg/locale"
+	"github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/pkg/pagination"
 	"github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/pkg/response"
 )
 
 type bookCategoryService interface {
 	CreateCategory(ctx context.Context, req dto.CreateBookCategoryRequest) (dto.CreateBookCategoryResponse, error)
 	GetCategoryByID(ctx context.Context, id uuid.UUID) (*models.BookCategory, error)
-	GetAllCategories(ctx context.Context) ([]models.BookCategory, error)
-	UpdateCategory(ctx context.Context, category *models.BookCategory) error
-	DeleteCategory(ctx context.Context, id uuid.UUID) error
+	GetCategoryBySlug(ctx context.Context, slug string) (*models.BookCategory, error)
+	ListCategories(ctx context.Context, req dto.ListBookCategoriesRequest) (*dto.ListBookCategoriesResponse, error)
+	UpdateCategory(ctx context.Context, id uuid.UUID, req dto.UpdateBookCategoryRequest) error
+	DeleteCategory(ctx context.Context, id uuid.UUID, reassignTo *uuid.UUID) (int64, error)
+	MoveCategory(ctx context.Context, id uuid.UUID, parentID *uuid.UUID) (*models.BookCategory, error)
+	MergeCategory(ctx context.Context, sourceID, targetID uuid.UUID) (dto.MergeBookCategoryResponse, error)
+	GetCategoryTree(ctx context.Context, id uuid.UUID) (*models.BookCategoryNode, error)
+	GetCategoryAncestors(ctx context.Context, id uuid.UUID) ([]models.BookCategory, error)
 }
 
 type bookCategoryHandler struct {
@@ -44,7 +51,8 @@ func NewBookCategoryHandler(service bookCategoryService) *bookCategoryHandler {
 // @Param category body dto.CreateBookCategoryRequest true "Category details"
 // @Success 201 {object} response.Response "success to create category"
 // @Failure 400 {object} response.ErrorMessage "invalid payload"
-// @Failure 409 {object} response.ErrorMessage "category already exists"
+// @Failure 404 {object} response.ErrorMessage "parent category not found"
+// @Failure 409 {object} response.ErrorMessage "category or slug already exists"
 // @Failure 500 {object} response.ErrorMessage "failed to create book category"
 // @Router /categories [post]
 // @Security BearerAuth
@@ -61,9 +69,15 @@ func (h *bookCategoryHandler) CreateCategory(c *fiber.Ctx) error {
 
 	res, err := h.service.CreateCategory(context.Background(), req)
 	if err != nil {
-		if errors.Is(err, service.ErrDuplicateCategory) {
+		if errors.Is(err, service.ErrDuplicateCategory) || errors.Is(err, service.ErrDuplicateSlug) {
 			return response.HandleError(c, err, "", fiber.StatusConflict)
 		}
+		if errors.Is(err, service.ErrInvalidSlug) || errors.Is(err, service.ErrInvalidLocale) {
+			return response.HandleError(c, err, "", fiber.StatusBadRequest)
+		}
+		if errors.Is(err, service.ErrParentNotFound) {
+			return response.HandleError(c, err, "", fiber.StatusNotFound)
+		}
 		return response.HandleError(c, err, "failed to create book category", fiber.StatusInternalServerError)
 	}
 
@@ -77,6 +91,7 @@ func (h *bookCategoryHandler) CreateCategory(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param id path string true "Category ID"
+// @Param Accept-Language header string false "Preferred locales for the category name"
 // @Success 200 {object} response.Response "success retrieve category"
 // @Failure 400 {object} response.ErrorMessage "invalid category ID"
 // @Failure 404 {object} response.ErrorMessage "category not found"
@@ -98,25 +113,73 @@ func (h *bookCategoryHandler) GetCategoryByID(c *fiber.Ctx) error {
 		return response.HandleError(c, err, "category not found", fiber.StatusNotFound)
 	}
 
+	category.Localize(locale.ParseAcceptLanguage(c.Get(fiber.HeaderAcceptLanguage)))
+	return response.HandleSuccess(c, "success retrieve category", category, fiber.StatusOK)
+}
+
+// GetCategoryBySlug retrieves a book category by its slug.
+// @Summary Retrieve a book category by slug
+// @Description Get the details of a book category by its URL-safe slug
+// @Tags BookCategory
+// @Accept json
+// @Produce json
+// @Param slug path string true "Category slug"
+// @Param Accept-Language header string false "Preferred locales for the category name"
+// @Success 200 {object} response.Response "success retrieve category"
+// @Failure 404 {object} response.ErrorMessage "category not found"
+// @Failure 500 {object} response.ErrorMessage "failed to retrieve category"
+// @Router /categories/slug/{slug} [get]
+func (h *bookCategoryHandler) GetCategoryBySlug(c *fiber.Ctx) error {
+	category, err := h.service.GetCategoryBySlug(context.Background(), c.Params("slug"))
+	if err != nil {
+		return response.HandleError(c, err, "failed to retrieve category", fiber.StatusInternalServerError)
+	}
+
+	if category == nil {
+		return response.HandleError(c, err, "category not found", fiber.StatusNotFound)
+	}
+
+	category.Localize(locale.ParseAcceptLanguage(c.Get(fiber.HeaderAcceptLanguage)))
 	return response.HandleSuccess(c, "success retrieve category", category, fiber.StatusOK)
 }
 
-// GetAllCategories retrieves all book categories.
-// @Summary Retrieve all book categories
-// @Description Get a list of all book categories
+// GetAllCategories retrieves a page of book categories.
+// @Summary Retrieve book categories
+// @Description Get a page of book categories in display order
 // @Tags BookCategory
 // @Accept json
 // @Produce json
-// @Success 200 {object} response.Response "success to retrieve categories"
+// @Param include_archived query bool false "Include archived categories"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param page_size query int false "Categories per page, at most 200 (default 50)"
+// @Param include_total query bool false "Also count every category"
+// @Param Accept-Language header string false "Preferred locales for the category names"
+// @Success 200 {object} response.Response{data=dto.ListBookCategoriesResponse} "success to retrieve categories"
+// @Failure 400 {object} response.ErrorMessage "invalid cursor"
 // @Failure 500 {object} response.ErrorMessage "failed to retrieve categories"
 // @Router /categories [get]
 func (h *bookCategoryHandler) GetAllCategories(c *fiber.Ctx) error {
-	categories, err := h.service.GetAllCategories(context.Background())
+	req := dto.ListBookCategoriesRequest{
+		IncludeArchived: c.QueryBool("include_archived"),
+		Cursor:          c.Query("cursor"),
+		PageSize:        c.QueryInt("page_size"),
+		IncludeTotal:    c.QueryBool("include_total"),
+	}
+
+	page, err := h.service.ListCategories(context.Background(), req)
 	if err != nil {
+		if errors.Is(err, pagination.ErrInvalidCursor) {
+			return response.HandleError(c, err, "", fiber.StatusBadRequest)
+		}
 		return response.HandleError(c, err, "failed to retrieve categories", fiber.StatusInternalServerError)
 	}
 
-	return response.HandleSuccess(c, "success to retrive categories", categories, fiber.StatusOK)
+	locales := locale.ParseAcceptLanguage(c.Get(fiber.HeaderAcceptLanguage))
+	for i := range page.Categories {
+		page.Categories[i].Localize(locales)
+	}
+
+	return response.HandleSuccess(c, "success to retrive categories", page, fiber.StatusOK)
 }
 
 // UpdateCategory updates an existing book category by its ID.
@@ -129,6 +192,8 @@ func (h *bookCategoryHandler) GetAllCategories(c *fiber.Ctx) error {
 // @Param category body dto.UpdateBookCategoryRequest true "Updated category details"
 // @Success 200 {object} response.Response "category updated successfully"
 // @Failure 400 {object} response.ErrorMessage "invalid category ID or payload"
+// @Failure 404 {object} response.ErrorMessage "category not found"
+// @Failure 409 {object} response.ErrorMessage "name or slug already exists"
 // @Failure 500 {object} response.ErrorMessage "failed to update category"
 // @Router /categories/{id} [put]
 // @Security BearerAuth
@@ -149,12 +214,16 @@ func (h *bookCategoryHandler) UpdateCategory(c *fiber.Ctx) error {
 		return response.HandleError(c, err, "invalid payload", fiber.StatusBadRequest)
 	}
 
-	category := &models.BookCategory{
-		ID:   id,
-		Name: req.Name,
-	}
-
-	if err := h.service.UpdateCategory(context.Background(), category); err != nil {
+	if err := h.service.UpdateCategory(context.Background(), id, req); err != nil {
+		if errors.Is(err, service.ErrCategoryNotFound) {
+			return response.HandleError(c, err, "", fiber.StatusNotFound)
+		}
+		if errors.Is(err, service.ErrDuplicateCategory) || errors.Is(err, service.ErrDuplicateSlug) {
+			return response.HandleError(c, err, "", fiber.StatusConflict)
+		}
+		if errors.Is(err, service.ErrInvalidSlug) || errors.Is(err, service.ErrInvalidLocale) {
+			return response.HandleError(c, err, "", fiber.StatusBadRequest)
+		}
 		return response.HandleError(c, err, "failed to update category", fiber.StatusInternalServerError)
 	}
 
@@ -163,13 +232,16 @@ func (h *bookCategoryHandler) UpdateCategory(c *fiber.Ctx) error {
 
 // DeleteCategory deletes a book category by its ID.
 // @Summary Delete a book category
-// @Description Remove a book category by its ID
+// @Description Remove a book category by its ID. A category that books still use is only deleted when reassign_to names a category to move those books to.
 // @Tags BookCategory
 // @Accept json
 // @Produce json
 // @Param id path string true "Category ID"
+// @Param reassign_to query string false "Category ID to move the books of the deleted category to"
 // @Success 200 {object} response.Response "category deleted successfully"
 // @Failure 400 {object} response.ErrorMessage "invalid category ID"
+// @Failure 404 {object} response.ErrorMessage "category not found"
+// @Failure 409 {object} response.ErrorMessage "category still has subcategories or books"
 // @Failure 500 {object} response.ErrorMessage "failed to delete category"
 // @Router /categories/{id} [delete]
 // @Security BearerAuth
@@ -180,9 +252,184 @@ func (h *bookCategoryHandler) DeleteCategory(c *fiber.Ctx) error {
 		return response.HandleError(c, err, "invalid category ID", fiber.StatusBadRequest)
 	}
 
-	if err := h.service.DeleteCategory(context.Background(), id); err != nil {
-		return response.HandleError(c, err, "failed to update category", fiber.StatusInternalServerError)
+	var reassignTo *uuid.UUID
+	if param := c.Query("reassign_to"); param != "" {
+		target, err := uuid.Parse(param)
+		if err != nil {
+			return response.HandleError(c, err, "invalid reassign_to category ID", fiber.StatusBadRequest)
+		}
+		reassignTo = &target
+	}
+
+	reassigned, err := h.service.DeleteCategory(context.Background(), id, reassignTo)
+	if err != nil {
+		if errors.Is(err, service.ErrCategoryNotFound) || errors.Is(err, service.ErrReassignNotFound) {
+			return response.HandleError(c, err, "", fiber.StatusNotFound)
+		}
+		if errors.Is(err, service.ErrCategoryHasChildren) || errors.Is(err, service.ErrCategoryInUse) {
+			return response.HandleError(c, err, "", fiber.StatusConflict)
+		}
+		if errors.Is(err, service.ErrReassignToSelf) {
+			return response.HandleError(c, err, "", fiber.StatusBadRequest)
+		}
+		return response.HandleError(c, err, "failed to delete category", fiber.StatusInternalServerError)
+	}
+
+	res := dto.DeleteBookCategoryResponse{ReassignedBooks: reassigned}
+	return response.HandleSuccess(c, "category deleted successfully", res, fiber.StatusOK)
+}
+
+// MoveCategory moves a book category under another one.
+// @Summary Move a book category
+// @Description Place a category under a new parent, or at the top level when parent_id is null
+// @Tags BookCategory
+// @Accept json
+// @Produce json
+// @Param id path string true "Category ID"
+// @Param category body dto.MoveBookCategoryRequest true "New parent"
+// @Success 200 {object} response.Response "category moved successfully"
+// @Failure 400 {object} response.ErrorMessage "invalid category ID or payload"
+// @Failure 404 {object} response.ErrorMessage "category not found"
+// @Failure 409 {object} response.ErrorMessage "category cannot be moved under itself or one of its descendants"
+// @Failure 500 {object} response.ErrorMessage "failed to move category"
+// @Router /categories/{id}/parent [put]
+// @Security BearerAuth
+func (h *bookCategoryHandler) MoveCategory(c *fiber.Ctx) error {
+	idParam := c.Params("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		return response.HandleError(c, err, "invalid category ID", fiber.StatusBadRequest)
+	}
+
+	var req dto.MoveBookCategoryRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return response.HandleError(c, err, "invalid payload", fiber.StatusBadRequest)
+	}
+
+	category, err := h.service.MoveCategory(context.Background(), id, req.ParentID)
+	if err != nil {
+		if errors.Is(err, service.ErrCategoryNotFound) || errors.Is(err, service.ErrParentNotFound) {
+			return response.HandleError(c, err, "", fiber.StatusNotFound)
+		}
+		if errors.Is(err, service.ErrCategoryCycle) {
+			return response.HandleError(c, err, "", fiber.StatusConflict)
+		}
+		return response.HandleError(c, err, "failed to move category", fiber.StatusInternalServerError)
 	}
 
-	return response.HandleSuccess(c, "category deleted successfully", nil, fiber.StatusOK)
+	return response.HandleSuccess(c, "category moved successfully", category, fiber.StatusOK)
+}
+
+// MergeCategory folds a book category into another one.
+// @Summary Merge a book category into another
+// @Description Move the books and subcategories of a category to the target category and delete it. The ID and name of the merged category keep resolving to the target.
+// @Tags BookCategory
+// @Accept json
+// @Produce json
+// @Param id path string true "Category ID to merge away"
+// @Param category body dto.MergeBookCategoryRequest true "Category to merge into"
+// @Success 200 {object} response.Response "category merged successfully"
+// @Failure 400 {object} response.ErrorMessage "invalid category ID or payload"
+// @Failure 404 {object} response.ErrorMessage "category not found"
+// @Failure 409 {object} response.ErrorMessage "category cannot be merged into itself or one of its subcategories, or its name is already an alias"
+// @Failure 500 {object} response.ErrorMessage "failed to merge category"
+// @Router /categories/{id}/merge [post]
+// @Security BearerAuth
+func (h *bookCategoryHandler) MergeCategory(c *fiber.Ctx) error {
+	idParam := c.Params("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		return response.HandleError(c, err, "invalid category ID", fiber.StatusBadRequest)
+	}
+
+	var req dto.MergeBookCategoryRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return response.HandleError(c, err, "invalid payload", fiber.StatusBadRequest)
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		return response.HandleError(c, err, "invalid payload", fiber.StatusBadRequest)
+	}
+
+	res, err := h.service.MergeCategory(context.Background(), id, req.TargetID)
+	if err != nil {
+		if errors.Is(err, service.ErrCategoryNotFound) || errors.Is(err, service.ErrMergeTargetNotFound) {
+			return response.HandleError(c, err, "", fiber.StatusNotFound)
+		}
+		if errors.Is(err, service.ErrMergeIntoSelf) || errors.Is(err, service.ErrMergeNameTaken) {
+			return response.HandleError(c, err, "", fiber.StatusConflict)
+		}
+		return response.HandleError(c, err, "failed to merge category", fiber.StatusInternalServerError)
+	}
+
+	return response.HandleSuccess(c, "category merged successfully", res, fiber.StatusOK)
+}
+
+// GetCategorySubtree retrieves a book category with all of its subcategories.
+// @Summary Retrieve a category subtree
+// @Description Get a category with its subcategories nested below it
+// @Tags BookCategory
+// @Accept json
+// @Produce json
+// @Param id path string true "Category ID"
+// @Param Accept-Language header string false "Preferred locales for the category names"
+// @Success 200 {object} response.Response "success retrieve category subtree"
+// @Failure 400 {object} response.ErrorMessage "invalid category ID"
+// @Failure 404 {object} response.ErrorMessage "category not found"
+// @Failure 500 {object} response.ErrorMessage "failed to retrieve category subtree"
+// @Router /categories/{id}/subtree [get]
+func (h *bookCategoryHandler) GetCategorySubtree(c *fiber.Ctx) error {
+	idParam := c.Params("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		return response.HandleError(c, err, "invalid category ID", fiber.StatusBadRequest)
+	}
+
+	tree, err := h.service.GetCategoryTree(context.Background(), id)
+	if err != nil {
+		if errors.Is(err, service.ErrCategoryNotFound) {
+			return response.HandleError(c, err, "", fiber.StatusNotFound)
+		}
+		return response.HandleError(c, err, "failed to retrieve category subtree", fiber.StatusInternalServerError)
+	}
+
+	tree.Localize(locale.ParseAcceptLanguage(c.Get(fiber.HeaderAcceptLanguage)))
+	return response.HandleSuccess(c, "success retrieve category subtree", tree, fiber.StatusOK)
+}
+
+// GetCategoryAncestors retrieves the breadcrumb path to a book category.
+// @Summary Retrieve category breadcrumbs
+// @Description Get the categories from the top level down to and including the given category
+// @Tags BookCategory
+// @Accept json
+// @Produce json
+// @Param id path string true "Category ID"
+// @Param Accept-Language header string false "Preferred locales for the category names"
+// @Success 200 {object} response.Response "success retrieve category ancestors"
+// @Failure 400 {object} response.ErrorMessage "invalid category ID"
+// @Failure 404 {object} response.ErrorMessage "category not found"
+// @Failure 500 {object} response.ErrorMessage "failed to retrieve category ancestors"
+// @Router /categories/{id}/ancestors [get]
+func (h *bookCategoryHandler) GetCategoryAncestors(c *fiber.Ctx) error {
+	idParam := c.Params("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		return response.HandleError(c, err, "invalid category ID", fiber.StatusBadRequest)
+	}
+
+	ancestors, err := h.service.GetCategoryAncestors(context.Background(), id)
+	if err != nil {
+		if errors.Is(err, service.ErrCategoryNotFound) {
+			return response.HandleError(c, err, "", fiber.StatusNotFound)
+		}
+		return response.HandleError(c, err, "failed to retrieve category ancestors", fiber.StatusInternalServerError)
+	}
+
+	locales := locale.ParseAcceptLanguage(c.Get(fiber.HeaderAcceptLanguage))
+	for i := range ancestors {
+		ancestors[i].Localize(locales)
+	}
+	return response.HandleSuccess(c, "success retrieve category ancestors", ancestors, fiber.StatusOK)
 }
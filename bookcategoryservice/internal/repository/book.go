@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	pb "github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/proto/book"
+)
+
+// bookRepository reaches the books stored by bookservice.
+type bookRepository struct {
+	client pb.BookServiceClient
+}
+
+// NewBookRepository creates a new instance of bookRepository.
+func NewBookRepository(client pb.BookServiceClient) *bookRepository {
+	return &bookRepository{client: client}
+}
+
+// CountByCategory returns how many books are in a category.
+func (r *bookRepository) CountByCategory(ctx context.Context, categoryID uuid.UUID) (int64, error) {
+	resp, err := r.client.CountBooksByCategory(ctx, &pb.CountBooksByCategoryRequest{
+		CategoryId: categoryID.String(),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count books by category: %w", err)
+	}
+	return resp.GetCount(), nil
+}
+
+// ReassignCategory moves every book of fromID to toID and returns how many
+// were moved.
+func (r *bookRepository) ReassignCategory(ctx context.Context, fromID, toID uuid.UUID) (int64, error) {
+	resp, err := r.client.ReassignBooksCategory(ctx, &pb.ReassignBooksCategoryRequest{
+		FromCategoryId: fromID.String(),
+		ToCategoryId:   toID.String(),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to reassign books: %w", err)
+	}
+	return resp.GetUpdatedBooks(), nil
+}
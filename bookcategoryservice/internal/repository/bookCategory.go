@@ -6,11 +6,17 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"strconv"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/models"
 )
 
+// categoryColumns are the columns scanCategory reads, for queries that alias
+// book_categories as c.
+const categoryColumns = `c.id, c.name, c.parent_id, c.slug, c.description, c.sort_order, c.archived`
+
 type bookCategoryRepository struct {
 	db *sql.DB
 }
@@ -22,101 +28,607 @@ func NewBookCategoryRepository(db *sql.DB) *bookCategoryRepository {
 	}
 }
 
-func (r *bookCategoryRepository) Create(ctx context.Context, name string) (uuid.UUID, error) {
-	var id uuid.UUID
-	query := `INSERT INTO book_categories (name) VALUES ($1) returning id`
+func (r *bookCategoryRepository) Create(ctx context.Context, category *models.BookCategory) (uuid.UUID, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return uuid.UUID{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO book_categories (name, parent_id, slug, description, sort_order, archived)
+		VALUES ($1, $2, $3, $4, $5, $6) returning id`
 
-	if err := r.db.QueryRowContext(ctx, query, name).Scan(&id); err != nil {
+	err = tx.QueryRowContext(ctx, query, category.Name, category.ParentID, category.Slug, category.Description, category.SortOrder, category.Archived).Scan(&category.ID)
+	if err != nil {
 		log.Printf("[Repository - Create] Error creating book category: %v", err)
+		if isUniqueViolation(err, "book_categories_name_key") {
+			return uuid.UUID{}, models.ErrDuplicateName
+		}
 		return uuid.UUID{}, fmt.Errorf("failed to create book category: %w", err)
 	}
 
-	return id, nil
+	if err := replaceNames(ctx, tx, category.ID, category.Names); err != nil {
+		return uuid.UUID{}, err
+	}
+
+	event := models.BookCategoryEvent{Type: models.CategoryCreated, Category: *category}
+	if err := recordCategoryChange(ctx, tx, event); err != nil {
+		return uuid.UUID{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return uuid.UUID{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return category.ID, nil
 }
 
 func (r *bookCategoryRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.BookCategory, error) {
-	query := `SELECT id, name FROM book_categories WHERE id = $1`
+	query := `SELECT ` + categoryColumns + ` FROM book_categories c WHERE c.id = $1`
 
-	row := r.db.QueryRowContext(ctx, query, id)
+	return r.getCategory(ctx, "GetByID", query, id)
+}
 
-	var category models.BookCategory
-	err := row.Scan(&category.ID, &category.Name)
+func (r *bookCategoryRepository) GetByName(ctx context.Context, name string) (*models.BookCategory, error) {
+	query := `SELECT ` + categoryColumns + ` FROM book_categories c WHERE c.name = $1`
+
+	return r.getCategory(ctx, "GetByName", query, name)
+}
+
+func (r *bookCategoryRepository) GetBySlug(ctx context.Context, slug string) (*models.BookCategory, error) {
+	query := `SELECT ` + categoryColumns + ` FROM book_categories c WHERE c.slug = $1`
+
+	return r.getCategory(ctx, "GetBySlug", query, slug)
+}
+
+// GetByAlias returns the category an ID merged away now resolves to.
+func (r *bookCategoryRepository) GetByAlias(ctx context.Context, id uuid.UUID) (*models.BookCategory, error) {
+	query := `SELECT ` + categoryColumns + ` FROM book_category_aliases a
+		JOIN book_categories c ON c.id = a.target_id WHERE a.alias_id = $1`
+
+	return r.getCategory(ctx, "GetByAlias", query, id)
+}
+
+// GetByAliasName returns the category a name merged away now resolves to.
+func (r *bookCategoryRepository) GetByAliasName(ctx context.Context, name string) (*models.BookCategory, error) {
+	query := `SELECT ` + categoryColumns + ` FROM book_category_aliases a
+		JOIN book_categories c ON c.id = a.target_id WHERE a.alias_name = $1`
+
+	return r.getCategory(ctx, "GetByAliasName", query, name)
+}
+
+// GetAll returns categories in display order. Archived ones are only
+// included when asked for.
+func (r *bookCategoryRepository) GetAll(ctx context.Context, includeArchived bool) ([]models.BookCategory, error) {
+	query := `SELECT ` + categoryColumns + ` FROM book_categories c
+		WHERE $1 OR NOT c.archived
+		ORDER BY c.sort_order, c.name`
+
+	return r.queryCategories(ctx, "GetAll", query, includeArchived)
+}
+
+// categoryListKeys is the display order of categories as keyset sort keys.
+var categoryListKeys = []sortKey{{expr: "c.sort_order"}, {expr: "c.name"}, {expr: "c.id"}}
+
+// List returns a page of categories in display order, starting after the
+// category whose sort keys are after. Archived ones are only included when
+// asked for. It also returns the sort keys of the last category when more
+// categories follow.
+func (r *bookCategoryRepository) List(ctx context.Context, includeArchived bool, after []string, limit int) ([]models.BookCategory, []string, error) {
+	if err := checkCursor(categoryListKeys, after); err != nil {
+		return nil, nil, err
+	}
+
+	query := `SELECT ` + categoryColumns + ` FROM book_categories c WHERE ($1 OR NOT c.archived)`
+	args := []any{includeArchived}
+	if after != nil {
+		condition, afterArgs := keysetCondition(categoryListKeys, after, len(args)+1)
+		query += ` AND ` + condition
+		args = append(args, afterArgs...)
+	}
+
+	// One category more than asked for tells whether another page follows
+	query += fmt.Sprintf(` ORDER BY %s LIMIT $%d`, orderBy(categoryListKeys), len(args)+1)
+	args = append(args, limit+1)
+
+	categories, err := r.queryCategories(ctx, "List", query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(categories) <= limit {
+		return categories, nil, nil
+	}
+
+	categories = categories[:limit]
+	last := categories[limit-1]
+	return categories, []string{strconv.Itoa(last.SortOrder), last.Name, last.ID.String()}, nil
+}
+
+// Count returns how many categories there are. Archived ones are only
+// counted when asked for.
+func (r *bookCategoryRepository) Count(ctx context.Context, includeArchived bool) (int64, error) {
+	var count int64
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM book_categories WHERE $1 OR NOT archived`, includeArchived).Scan(&count)
+	if err != nil {
+		log.Printf("[Repository - Count] Error counting book categories: %v", err)
+		return 0, fmt.Errorf("failed to count book categories: %w", err)
+	}
+	return count, nil
+}
+
+func (r *bookCategoryRepository) Update(ctx context.Context, category *models.BookCategory) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE book_categories
+		SET name = $1, slug = $2, description = $3, sort_order = $4, archived = $5
+		WHERE id = $6
+		RETURNING parent_id`
+
+	err = tx.QueryRowContext(ctx, query, category.Name, category.Slug, category.Description, category.SortOrder, category.Archived, category.ID).Scan(&category.ParentID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		log.Printf("[Repository - Update] Error updating book category: %v", err)
+		if isUniqueViolation(err, "book_categories_name_key") {
+			return models.ErrDuplicateName
+		}
+		return fmt.Errorf("failed to update book category: %w", err)
+	}
+
+	// A nil map keeps the existing translations
+	if category.Names != nil {
+		if err := replaceNames(ctx, tx, category.ID, category.Names); err != nil {
+			return err
+		}
+	}
+
+	event := models.BookCategoryEvent{Type: models.CategoryUpdated, Category: *category}
+	if err := recordCategoryChange(ctx, tx, event); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *bookCategoryRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `DELETE FROM book_categories c WHERE c.id = $1 RETURNING ` + categoryColumns
+
+	category, err := scanCategory(tx.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		log.Printf("[Repository - Delete] Error deleting book category: %v", err)
+		return fmt.Errorf("failed to delete book category: %w", err)
+	}
+
+	event := models.BookCategoryEvent{Type: models.CategoryDeleted, Category: category}
+	if err := recordCategoryChange(ctx, tx, event); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Move re-parents a category, or makes it a root when parentID is nil. It
+// returns nil when the category doesn't exist or parentID is the category
+// itself or one of its descendants.
+func (r *bookCategoryRepository) Move(ctx context.Context, id uuid.UUID, parentID *uuid.UUID) (*models.BookCategory, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Moves check the tree before changing it, so two of them must not run
+	// at once or each could pass its check and together form a cycle
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, categoryChangeLock); err != nil {
+		log.Printf("[Repository - Move] Error locking categories: %v", err)
+		return nil, fmt.Errorf("failed to lock categories: %w", err)
+	}
+
+	query := `WITH RECURSIVE ancestors AS (
+			SELECT id, parent_id FROM book_categories WHERE id = $2
+			UNION ALL
+			SELECT p.id, p.parent_id FROM book_categories p JOIN ancestors a ON p.id = a.parent_id
+		)
+		UPDATE book_categories c SET parent_id = $2
+		WHERE c.id = $1 AND NOT EXISTS (SELECT 1 FROM ancestors WHERE id = $1)
+		RETURNING ` + categoryColumns
+
+	category, err := scanCategory(tx.QueryRowContext(ctx, query, id, parentID))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
 		}
-		log.Printf("[Repository - GetByID] Error getting book category by ID: %v", err)
-		return nil, fmt.Errorf("failed to get book category by ID: %w", err)
+		log.Printf("[Repository - Move] Error moving book category: %v", err)
+		return nil, fmt.Errorf("failed to move book category: %w", err)
+	}
+
+	event := models.BookCategoryEvent{Type: models.CategoryUpdated, Category: category}
+	if err := recordCategoryChange(ctx, tx, event); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
 	return &category, nil
 }
 
-func (r *bookCategoryRepository) GetByName(ctx context.Context, name string) (*models.BookCategory, error) {
-	query := `SELECT id, name FROM book_categories WHERE name = $1`
+// Merge folds source into target: its subcategories move under target, its
+// ID and name become aliases of target and it is deleted. It returns the
+// deleted source, or nil when source doesn't exist or target is source itself
+// or one of its descendants.
+func (r *bookCategoryRepository) Merge(ctx context.Context, sourceID, targetID uuid.UUID) (*models.BookCategory, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
 
-	row := r.db.QueryRowContext(ctx, query, name)
+	// Same as Move: the tree check below must not race another change
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, categoryChangeLock); err != nil {
+		log.Printf("[Repository - Merge] Error locking categories: %v", err)
+		return nil, fmt.Errorf("failed to lock categories: %w", err)
+	}
 
-	var category models.BookCategory
-	err := row.Scan(&category.ID, &category.Name)
+	query := `WITH RECURSIVE ancestors AS (
+			SELECT id, parent_id FROM book_categories WHERE id = $2
+			UNION ALL
+			SELECT p.id, p.parent_id FROM book_categories p JOIN ancestors a ON p.id = a.parent_id
+		)
+		SELECT EXISTS (SELECT 1 FROM ancestors WHERE id = $1)`
+
+	var below bool
+	if err := tx.QueryRowContext(ctx, query, sourceID, targetID).Scan(&below); err != nil {
+		log.Printf("[Repository - Merge] Error checking category tree: %v", err)
+		return nil, fmt.Errorf("failed to check category tree: %w", err)
+	}
+	if below {
+		return nil, nil
+	}
+
+	children, err := moveChildren(ctx, tx, sourceID, targetID)
+	if err != nil {
+		return nil, err
+	}
+	for _, child := range children {
+		event := models.BookCategoryEvent{Type: models.CategoryUpdated, Category: child}
+		if err := recordCategoryChange(ctx, tx, event); err != nil {
+			return nil, err
+		}
+	}
+
+	query = `DELETE FROM book_categories c WHERE c.id = $1 RETURNING ` + categoryColumns
+
+	source, err := scanCategory(tx.QueryRowContext(ctx, query, sourceID))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
 		}
-		log.Printf("[Repository - GetByName] Error getting book category by Nam: %v", err)
-		return nil, fmt.Errorf("failed to get book category by Name: %w", err)
+		log.Printf("[Repository - Merge] Error deleting book category: %v", err)
+		return nil, fmt.Errorf("failed to delete book category: %w", err)
 	}
 
-	return &category, nil
+	// Earlier merges into source now resolve to target as well
+	if _, err := tx.ExecContext(ctx, `UPDATE book_category_aliases SET target_id = $1 WHERE target_id = $2`, targetID, sourceID); err != nil {
+		log.Printf("[Repository - Merge] Error updating category aliases: %v", err)
+		return nil, fmt.Errorf("failed to update category aliases: %w", err)
+	}
+
+	query = `INSERT INTO book_category_aliases (alias_id, alias_name, target_id) VALUES ($1, $2, $3)`
+	if _, err := tx.ExecContext(ctx, query, source.ID, source.Name, targetID); err != nil {
+		log.Printf("[Repository - Merge] Error creating category alias: %v", err)
+		if isUniqueViolation(err, "book_category_aliases_alias_name_key") {
+			return nil, models.ErrDuplicateAlias
+		}
+		return nil, fmt.Errorf("failed to create category alias: %w", err)
+	}
+
+	event := models.BookCategoryEvent{Type: models.CategoryDeleted, Category: source}
+	if err := recordCategoryChange(ctx, tx, event); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &source, nil
 }
 
-func (r *bookCategoryRepository) GetAll(ctx context.Context) ([]models.BookCategory, error) {
-	query := `SELECT id, name FROM book_categories`
+// isUniqueViolation reports whether err comes from breaking the unique
+// constraint named constraint.
+func isUniqueViolation(err error, constraint string) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == constraint
+}
+
+// moveChildren re-parents the children of one category to another and
+// returns them as they are after the move.
+func moveChildren(ctx context.Context, tx *sql.Tx, fromID, toID uuid.UUID) ([]models.BookCategory, error) {
+	query := `UPDATE book_categories c SET parent_id = $1 WHERE c.parent_id = $2 RETURNING ` + categoryColumns
 
-	rows, err := r.db.QueryContext(ctx, query)
+	rows, err := tx.QueryContext(ctx, query, toID, fromID)
 	if err != nil {
-		log.Printf("[Repository - GetAll] Error getting all book categories: %v", err)
-		return nil, fmt.Errorf("failed to get all book categories: %w", err)
+		log.Printf("[Repository - moveChildren] Error moving subcategories: %v", err)
+		return nil, fmt.Errorf("failed to move subcategories: %w", err)
+	}
+	defer rows.Close()
+
+	var children []models.BookCategory
+	for rows.Next() {
+		child, err := scanCategory(rows)
+		if err != nil {
+			log.Printf("[Repository - moveChildren] Error scanning book category: %v", err)
+			return nil, fmt.Errorf("failed to scan book category: %w", err)
+		}
+		children = append(children, child)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("[Repository - moveChildren] Error during rows iteration: %v", err)
+		return nil, fmt.Errorf("error occurred during rows iteration: %w", err)
+	}
+
+	return children, nil
+}
+
+// GetSubtree returns a category and all of its descendants, parents before
+// their children.
+func (r *bookCategoryRepository) GetSubtree(ctx context.Context, id uuid.UUID) ([]models.BookCategory, error) {
+	query := `WITH RECURSIVE subtree AS (
+			SELECT id, 0 AS depth FROM book_categories WHERE id = $1
+			UNION ALL
+			SELECT p.id, s.depth + 1 FROM book_categories p JOIN subtree s ON p.parent_id = s.id
+		)
+		SELECT ` + categoryColumns + ` FROM subtree s JOIN book_categories c ON c.id = s.id
+		ORDER BY s.depth, c.sort_order, c.name`
+
+	return r.queryCategories(ctx, "GetSubtree", query, id)
+}
+
+// GetAncestors returns the path from the root down to and including the
+// category, as used for breadcrumbs.
+func (r *bookCategoryRepository) GetAncestors(ctx context.Context, id uuid.UUID) ([]models.BookCategory, error) {
+	query := `WITH RECURSIVE ancestors AS (
+			SELECT id, parent_id, 0 AS depth FROM book_categories WHERE id = $1
+			UNION ALL
+			SELECT p.id, p.parent_id, a.depth + 1 FROM book_categories p JOIN ancestors a ON p.id = a.parent_id
+		)
+		SELECT ` + categoryColumns + ` FROM ancestors a JOIN book_categories c ON c.id = a.id
+		ORDER BY a.depth DESC`
+
+	return r.queryCategories(ctx, "GetAncestors", query, id)
+}
+
+// HasChildren reports whether any category has id as its parent.
+func (r *bookCategoryRepository) HasChildren(ctx context.Context, id uuid.UUID) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM book_categories WHERE parent_id = $1)`
+
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, query, id).Scan(&exists); err != nil {
+		log.Printf("[Repository - HasChildren] Error checking child categories: %v", err)
+		return false, fmt.Errorf("failed to check child categories: %w", err)
+	}
+
+	return exists, nil
+}
+
+func (r *bookCategoryRepository) getCategory(ctx context.Context, op, query string, args ...any) (*models.BookCategory, error) {
+	category, err := scanCategory(r.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		log.Printf("[Repository - %s] Error getting book category: %v", op, err)
+		return nil, fmt.Errorf("failed to get book category: %w", err)
+	}
+
+	categories := []models.BookCategory{category}
+	if err := r.loadNames(ctx, categories); err != nil {
+		return nil, err
+	}
+
+	return &categories[0], nil
+}
+
+func (r *bookCategoryRepository) queryCategories(ctx context.Context, op, query string, args ...any) ([]models.BookCategory, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("[Repository - %s] Error getting book categories: %v", op, err)
+		return nil, fmt.Errorf("failed to get book categories: %w", err)
 	}
 	defer rows.Close()
 
 	var categories []models.BookCategory
 	for rows.Next() {
-		var category models.BookCategory
-		if err := rows.Scan(&category.ID, &category.Name); err != nil {
-			log.Printf("[Repository - GetAll] Error scanning book category: %v", err)
+		category, err := scanCategory(rows)
+		if err != nil {
+			log.Printf("[Repository - %s] Error scanning book category: %v", op, err)
 			return nil, fmt.Errorf("failed to scan book category: %w", err)
 		}
 		categories = append(categories, category)
 	}
 
 	if err := rows.Err(); err != nil {
-		log.Printf("[Repository - GetAll] Error during rows iteration: %v", err)
+		log.Printf("[Repository - %s] Error during rows iteration: %v", op, err)
 		return nil, fmt.Errorf("error occurred during rows iteration: %w", err)
 	}
 
+	if err := r.loadNames(ctx, categories); err != nil {
+		return nil, err
+	}
+
 	return categories, nil
 }
 
-func (r *bookCategoryRepository) Update(ctx context.Context, category *models.BookCategory) error {
-	query := `UPDATE book_categories SET name = $1 WHERE id = $2`
+// loadNames fills in the translated names of categories.
+func (r *bookCategoryRepository) loadNames(ctx context.Context, categories []models.BookCategory) error {
+	if len(categories) == 0 {
+		return nil
+	}
+
+	index := make(map[uuid.UUID]int, len(categories))
+	ids := make([]string, 0, len(categories))
+	for i := range categories {
+		index[categories[i].ID] = i
+		ids = append(ids, categories[i].ID.String())
+		categories[i].Names = map[string]string{}
+	}
+
+	query := `SELECT category_id, locale, name FROM book_category_translations WHERE category_id = ANY($1::uuid[])`
 
-	_, err := r.db.ExecContext(ctx, query, category.Name, category.ID)
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(ids))
 	if err != nil {
-		log.Printf("[Repository - Update] Error updating book category: %v", err)
-		return fmt.Errorf("failed to update book category: %w", err)
+		log.Printf("[Repository - loadNames] Error getting category translations: %v", err)
+		return fmt.Errorf("failed to get category translations: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			id           uuid.UUID
+			locale, name string
+		)
+		if err := rows.Scan(&id, &locale, &name); err != nil {
+			log.Printf("[Repository - loadNames] Error scanning category translation: %v", err)
+			return fmt.Errorf("failed to scan category translation: %w", err)
+		}
+		categories[index[id]].Names[locale] = name
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("[Repository - loadNames] Error during rows iteration: %v", err)
+		return fmt.Errorf("error occurred during rows iteration: %w", err)
 	}
 
 	return nil
 }
 
-func (r *bookCategoryRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	query := `DELETE FROM book_categories WHERE id = $1`
+// replaceNames swaps the translated names of a category for names.
+func replaceNames(ctx context.Context, tx *sql.Tx, id uuid.UUID, names map[string]string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM book_category_translations WHERE category_id = $1`, id); err != nil {
+		log.Printf("[Repository - replaceNames] Error clearing category translations: %v", err)
+		return fmt.Errorf("failed to clear category translations: %w", err)
+	}
+
+	query := `INSERT INTO book_category_translations (category_id, locale, name) VALUES ($1, $2, $3)`
+	for locale, name := range names {
+		if _, err := tx.ExecContext(ctx, query, id, locale, name); err != nil {
+			log.Printf("[Repository - replaceNames] Error saving category translation: %v", err)
+			return fmt.Errorf("failed to save category translation: %w", err)
+		}
+	}
+
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanCategory(row rowScanner) (models.BookCategory, error) {
+	var category models.BookCategory
+	err := row.Scan(&category.ID, &category.Name, &category.ParentID, &category.Slug, &category.Description, &category.SortOrder, &category.Archived)
+	return category, err
+}
 
-	_, err := r.db.ExecContext(ctx, query, id)
+// ListChanges returns up to limit changes with a revision above
+// afterRevision, oldest first.
+func (r *bookCategoryRepository) ListChanges(ctx context.Context, afterRevision int64, limit int) ([]models.BookCategoryEvent, error) {
+	query := `SELECT revision, change_type, category_id, name, parent_id, slug, archived, changed_at
+		FROM book_category_changes
+		WHERE revision > $1
+		ORDER BY revision
+		LIMIT $2`
+
+	rows, err := r.db.QueryContext(ctx, query, afterRevision, limit)
 	if err != nil {
-		log.Printf("[Repository - Delete] Error deleting book category: %v", err)
-		return fmt.Errorf("failed to delete book category: %w", err)
+		log.Printf("[Repository - ListChanges] Error listing category changes: %v", err)
+		return nil, fmt.Errorf("failed to list category changes: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []models.BookCategoryEvent
+	for rows.Next() {
+		var change models.BookCategoryEvent
+		if err := rows.Scan(&change.Revision, &change.Type, &change.Category.ID, &change.Category.Name, &change.Category.ParentID, &change.Category.Slug, &change.Category.Archived, &change.ChangedAt); err != nil {
+			log.Printf("[Repository - ListChanges] Error scanning category change: %v", err)
+			return nil, fmt.Errorf("failed to scan category change: %w", err)
+		}
+		changes = append(changes, change)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("[Repository - ListChanges] Error during rows iteration: %v", err)
+		return nil, fmt.Errorf("error occurred during rows iteration: %w", err)
+	}
+
+	return changes, nil
+}
+
+// GetLatestRevision returns the revision of the newest change, or 0 when
+// nothing has changed yet.
+func (r *bookCategoryRepository) GetLatestRevision(ctx context.Context) (int64, error) {
+	query := `SELECT COALESCE(MAX(revision), 0) FROM book_category_changes`
+
+	var revision int64
+	if err := r.db.QueryRowContext(ctx, query).Scan(&revision); err != nil {
+		log.Printf("[Repository - GetLatestRevision] Error getting latest revision: %v", err)
+		return 0, fmt.Errorf("failed to get latest category revision: %w", err)
+	}
+
+	return revision, nil
+}
+
+// categoryChangeLock is the advisory lock key serialising change log writes.
+// Holding it until commit makes revisions become visible in order, so a
+// reader that has seen revision N never misses a smaller one later.
+const categoryChangeLock = 7_264_901
+
+// recordCategoryChange appends event to the change log and wakes listeners on
+// CategoryEventChannel. Both only take effect when tx commits.
+func recordCategoryChange(ctx context.Context, tx *sql.Tx, event models.BookCategoryEvent) error {
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, categoryChangeLock); err != nil {
+		log.Printf("[Repository - recordCategoryChange] Error locking change log: %v", err)
+		return fmt.Errorf("failed to lock category change log: %w", err)
+	}
+
+	query := `INSERT INTO book_category_changes (change_type, category_id, name, parent_id, slug, archived)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING revision`
+
+	var revision int64
+	category := event.Category
+	if err := tx.QueryRowContext(ctx, query, event.Type, category.ID, category.Name, category.ParentID, category.Slug, category.Archived).Scan(&revision); err != nil {
+		log.Printf("[Repository - recordCategoryChange] Error recording category change: %v", err)
+		return fmt.Errorf("failed to record category change: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_notify($1, $2)`, CategoryEventChannel, strconv.FormatInt(revision, 10)); err != nil {
+		log.Printf("[Repository - recordCategoryChange] Error notifying category change: %v", err)
+		return fmt.Errorf("failed to notify category change: %w", err)
 	}
 
 	return nil
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/lib/pq"
+)
+
+// CategoryEventChannel is the Postgres NOTIFY channel that announces new
+// entries in the category change log. The payload is the new revision.
+const CategoryEventChannel = "book_category_events"
+
+type bookCategoryEventRepository struct {
+	listener *pq.Listener
+}
+
+// NewBookCategoryEventRepository returns a repository that waits for category
+// changes on listener.
+func NewBookCategoryEventRepository(listener *pq.Listener) *bookCategoryEventRepository {
+	return &bookCategoryEventRepository{listener: listener}
+}
+
+// Listen signals every time the change log may have grown, until ctx is done.
+// A reconnect of the listener is signalled too, since notifications sent
+// while it was down are lost.
+func (r *bookCategoryEventRepository) Listen(ctx context.Context) (<-chan struct{}, error) {
+	if err := r.listener.Listen(CategoryEventChannel); err != nil {
+		log.Printf("[Repository - Listen] Error listening on %s: %v", CategoryEventChannel, err)
+		return nil, fmt.Errorf("failed to listen for category events: %w", err)
+	}
+
+	signals := make(chan struct{})
+	go func() {
+		defer close(signals)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case _, ok := <-r.listener.Notify:
+				if !ok {
+					return
+				}
+
+				select {
+				case signals <- struct{}{}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return signals, nil
+}
@@ -0,0 +1,374 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/models"
+)
+
+// fakeResult adalah jawaban fakeDB untuk satu statement.
+type fakeResult struct {
+	columns int
+	rows    [][]driver.Value
+	err     error
+}
+
+type fakeStatement struct {
+	query string
+	args  []driver.Value
+}
+
+// fakeDB adalah database palsu yang mencatat setiap statement, termasuk
+// BEGIN, COMMIT dan ROLLBACK, dan menjawabnya lewat RespondFunc.
+type fakeDB struct {
+	RespondFunc func(query string) fakeResult
+
+	mu         sync.Mutex
+	statements []fakeStatement
+}
+
+func newFakeDB(t *testing.T, respond func(query string) fakeResult) (*fakeDB, *sql.DB) {
+	fake := &fakeDB{RespondFunc: respond}
+	db := sql.OpenDB(fake)
+	t.Cleanup(func() { db.Close() })
+	return fake, db
+}
+
+func (f *fakeDB) record(query string, args []driver.NamedValue) fakeResult {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+
+	f.mu.Lock()
+	f.statements = append(f.statements, fakeStatement{query: query, args: values})
+	f.mu.Unlock()
+
+	if f.RespondFunc == nil {
+		return fakeResult{}
+	}
+	return f.RespondFunc(query)
+}
+
+// Statements mengembalikan baris pertama setiap statement yang dijalankan.
+func (f *fakeDB) Statements() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	statements := make([]string, len(f.statements))
+	for i, statement := range f.statements {
+		statements[i], _, _ = strings.Cut(statement.query, "\n")
+	}
+	return statements
+}
+
+func (f *fakeDB) Args(prefix string) []driver.Value {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, statement := range f.statements {
+		if strings.HasPrefix(statement.query, prefix) {
+			return statement.args
+		}
+	}
+	return nil
+}
+
+func (f *fakeDB) Connect(ctx context.Context) (driver.Conn, error) {
+	return &fakeConn{db: f}, nil
+}
+
+func (f *fakeDB) Driver() driver.Driver {
+	return fakeDriver{db: f}
+}
+
+type fakeDriver struct {
+	db *fakeDB
+}
+
+func (d fakeDriver) Open(name string) (driver.Conn, error) {
+	return &fakeConn{db: d.db}, nil
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("fakeDB does not prepare statements")
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	c.db.record("BEGIN", nil)
+	return fakeTx{db: c.db}, nil
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	res := c.db.record(query, args)
+	if res.err != nil {
+		return nil, res.err
+	}
+	return driver.RowsAffected(len(res.rows)), nil
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	res := c.db.record(query, args)
+	if res.err != nil {
+		return nil, res.err
+	}
+	return &fakeRows{columns: res.columns, rows: res.rows}, nil
+}
+
+type fakeTx struct {
+	db *fakeDB
+}
+
+func (tx fakeTx) Commit() error {
+	tx.db.record("COMMIT", nil)
+	return nil
+}
+
+func (tx fakeTx) Rollback() error {
+	tx.db.record("ROLLBACK", nil)
+	return nil
+}
+
+type fakeRows struct {
+	columns int
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	return make([]string, r.columns)
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+// Test Update: Perubahan dicatat di change log di bawah advisory lock, dan
+// NOTIFY dikirim dalam transaksi yang sama sebelum commit
+func TestUpdate_RecordsChangeUnderLock(t *testing.T) {
+	fake, db := newFakeDB(t, func(query string) fakeResult {
+		switch {
+		case strings.HasPrefix(query, "UPDATE book_categories"):
+			return fakeResult{columns: 1, rows: [][]driver.Value{{nil}}}
+		case strings.HasPrefix(query, "INSERT INTO book_category_changes"):
+			return fakeResult{columns: 1, rows: [][]driver.Value{{int64(42)}}}
+		}
+		return fakeResult{}
+	})
+
+	category := &models.BookCategory{ID: uuid.New(), Name: "Poetry", Slug: "poetry"}
+	if err := NewBookCategoryRepository(db).Update(context.Background(), category); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	want := []string{
+		"BEGIN",
+		"UPDATE book_categories",
+		"SELECT pg_advisory_xact_lock($1)",
+		"INSERT INTO book_category_changes (change_type, category_id, name, parent_id, slug, archived)",
+		"SELECT pg_notify($1, $2)",
+		"COMMIT",
+	}
+	if got := fake.Statements(); !reflect.DeepEqual(got, want) {
+		t.Errorf("statements = %q, want %q", got, want)
+	}
+
+	if got := fake.Args("SELECT pg_advisory_xact_lock"); !reflect.DeepEqual(got, []driver.Value{int64(categoryChangeLock)}) {
+		t.Errorf("lock args = %v, want the change log lock", got)
+	}
+	if got := fake.Args("SELECT pg_notify"); !reflect.DeepEqual(got, []driver.Value{CategoryEventChannel, "42"}) {
+		t.Errorf("notify args = %v, want the new revision on %s", got, CategoryEventChannel)
+	}
+}
+
+// Test Update: Gagal mencatat perubahan membatalkan seluruh transaksi tanpa
+// NOTIFY
+func TestUpdate_ChangeLogFailureRollsBack(t *testing.T) {
+	fake, db := newFakeDB(t, func(query string) fakeResult {
+		switch {
+		case strings.HasPrefix(query, "UPDATE book_categories"):
+			return fakeResult{columns: 1, rows: [][]driver.Value{{nil}}}
+		case strings.HasPrefix(query, "INSERT INTO book_category_changes"):
+			return fakeResult{err: errors.New("disk full")}
+		}
+		return fakeResult{}
+	})
+
+	category := &models.BookCategory{ID: uuid.New(), Name: "Poetry", Slug: "poetry"}
+	if err := NewBookCategoryRepository(db).Update(context.Background(), category); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	for _, statement := range fake.Statements() {
+		if statement == "COMMIT" || strings.HasPrefix(statement, "SELECT pg_notify") {
+			t.Errorf("expected no %q after the change log failed", statement)
+		}
+	}
+	if got := fake.Statements(); got[len(got)-1] != "ROLLBACK" {
+		t.Errorf("expected the transaction to be rolled back, got %q", got)
+	}
+}
+
+// Test Move: Pemeriksaan siklus dan pemindahan berjalan di bawah advisory
+// lock; pemindahan yang ditolak tidak dicatat di change log
+func TestMove(t *testing.T) {
+	id, parentID := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name  string
+		moved bool
+		want  []string
+	}{
+		{"moved", true, []string{
+			"BEGIN",
+			"SELECT pg_advisory_xact_lock($1)",
+			"WITH RECURSIVE ancestors AS (",
+			"SELECT pg_advisory_xact_lock($1)",
+			"INSERT INTO book_category_changes (change_type, category_id, name, parent_id, slug, archived)",
+			"SELECT pg_notify($1, $2)",
+			"COMMIT",
+		}},
+		{"cycle refused", false, []string{
+			"BEGIN",
+			"SELECT pg_advisory_xact_lock($1)",
+			"WITH RECURSIVE ancestors AS (",
+			"ROLLBACK",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, db := newFakeDB(t, func(query string) fakeResult {
+				switch {
+				case strings.HasPrefix(query, "WITH RECURSIVE ancestors"):
+					if !tt.moved {
+						return fakeResult{columns: 7}
+					}
+					return fakeResult{columns: 7, rows: [][]driver.Value{{id.String(), "Fantasy", parentID.String(), "fantasy", "", int64(0), false}}}
+				case strings.HasPrefix(query, "INSERT INTO book_category_changes"):
+					return fakeResult{columns: 1, rows: [][]driver.Value{{int64(1)}}}
+				}
+				return fakeResult{}
+			})
+
+			category, err := NewBookCategoryRepository(db).Move(context.Background(), id, &parentID)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if tt.moved != (category != nil) {
+				t.Fatalf("Move() = %+v, want moved %v", category, tt.moved)
+			}
+			if tt.moved && (category.ParentID == nil || *category.ParentID != parentID) {
+				t.Errorf("expected the category under %s, got %v", parentID, category.ParentID)
+			}
+			if got := fake.Statements(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("statements = %q, want %q", got, tt.want)
+			}
+			if got := fake.Args("WITH RECURSIVE"); !reflect.DeepEqual(got, []driver.Value{id.String(), parentID.String()}) {
+				t.Errorf("move args = %v, want the category and its new parent", got)
+			}
+		})
+	}
+}
+
+// Test Merge: Alias dengan nama yang sudah dipakai alias lain menjadi
+// ErrDuplicateAlias, bukan error internal
+func TestMerge_AliasConflict(t *testing.T) {
+	sourceID, targetID := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name     string
+		aliasErr error
+		want     error
+	}{
+		{"merged", nil, nil},
+		{"alias name taken", &pq.Error{Code: "23505", Constraint: "book_category_aliases_alias_name_key"}, models.ErrDuplicateAlias},
+		{"other unique violation", &pq.Error{Code: "23505", Constraint: "book_category_aliases_pkey"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, db := newFakeDB(t, func(query string) fakeResult {
+				switch {
+				case strings.HasPrefix(query, "WITH RECURSIVE ancestors"):
+					return fakeResult{columns: 1, rows: [][]driver.Value{{false}}}
+				case strings.HasPrefix(query, "DELETE FROM book_categories"):
+					return fakeResult{columns: 7, rows: [][]driver.Value{{sourceID.String(), "Sci-Fi", nil, "sci-fi", "", int64(0), false}}}
+				case strings.HasPrefix(query, "INSERT INTO book_category_aliases"):
+					return fakeResult{err: tt.aliasErr}
+				case strings.HasPrefix(query, "INSERT INTO book_category_changes"):
+					return fakeResult{columns: 1, rows: [][]driver.Value{{int64(1)}}}
+				case strings.HasPrefix(query, "UPDATE book_categories c SET parent_id"):
+					return fakeResult{columns: 7}
+				}
+				return fakeResult{}
+			})
+
+			source, err := NewBookCategoryRepository(db).Merge(context.Background(), sourceID, targetID)
+			switch {
+			case tt.aliasErr == nil:
+				if err != nil || source == nil || source.Name != "Sci-Fi" {
+					t.Fatalf("Merge() = %+v, %v, want the deleted source", source, err)
+				}
+			case tt.want != nil:
+				if !errors.Is(err, tt.want) {
+					t.Fatalf("Merge() error = %v, want %v", err, tt.want)
+				}
+			default:
+				if err == nil || errors.Is(err, models.ErrDuplicateAlias) {
+					t.Fatalf("Merge() error = %v, want an internal error", err)
+				}
+			}
+
+			if got := fake.Args("INSERT INTO book_category_aliases"); !reflect.DeepEqual(got, []driver.Value{sourceID.String(), "Sci-Fi", targetID.String()}) {
+				t.Errorf("alias args = %v, want the source ID and name pointing at the target", got)
+			}
+			statements := fake.Statements()
+			if last := statements[len(statements)-1]; (last == "COMMIT") != (tt.aliasErr == nil) {
+				t.Errorf("expected a commit only when the alias was saved, got %q", statements)
+			}
+		})
+	}
+}
+
+// Test Update: Nama yang sudah dipakai kategori lain menjadi ErrDuplicateName
+func TestUpdate_NameConflict(t *testing.T) {
+	_, db := newFakeDB(t, func(query string) fakeResult {
+		if strings.HasPrefix(query, "UPDATE book_categories") {
+			return fakeResult{err: &pq.Error{Code: "23505", Constraint: "book_categories_name_key"}}
+		}
+		return fakeResult{}
+	})
+
+	category := &models.BookCategory{ID: uuid.New(), Name: "Drama", Slug: "poetry"}
+	if err := NewBookCategoryRepository(db).Update(context.Background(), category); !errors.Is(err, models.ErrDuplicateName) {
+		t.Errorf("Update() error = %v, want %v", err, models.ErrDuplicateName)
+	}
+}
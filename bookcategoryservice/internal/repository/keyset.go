@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/pkg/pagination"
+)
+
+// sortKey is one expression of a keyset sort order. The last key of an order
+// must be unique, usually the ID, so every row has a distinct position.
+type sortKey struct {
+	expr string
+	desc bool
+}
+
+// orderBy returns the ORDER BY list of keys.
+func orderBy(keys []sortKey) string {
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = key.expr
+		if key.desc {
+			parts[i] += " DESC"
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// keysetCondition returns the condition matching the rows that come after
+// the row whose sort keys are after, numbering its placeholders from
+// argIndex, along with their arguments.
+func keysetCondition(keys []sortKey, after []string, argIndex int) (string, []interface{}) {
+	args := make([]interface{}, len(after))
+	for i, value := range after {
+		args[i] = value
+	}
+
+	// (k1 > v1) OR (k1 = v1 AND k2 > v2) OR ...
+	var alternatives []string
+	for i, key := range keys {
+		var parts []string
+		for j := 0; j < i; j++ {
+			parts = append(parts, fmt.Sprintf("%s = $%d", keys[j].expr, argIndex+j))
+		}
+		op := ">"
+		if key.desc {
+			op = "<"
+		}
+		parts = append(parts, fmt.Sprintf("%s %s $%d", key.expr, op, argIndex+i))
+		alternatives = append(alternatives, "("+strings.Join(parts, " AND ")+")")
+	}
+	return "(" + strings.Join(alternatives, " OR ") + ")", args
+}
+
+// checkCursor makes sure a decoded cursor has one value per sort key.
+func checkCursor(keys []sortKey, after []string) error {
+	if after != nil && len(after) != len(keys) {
+		return pagination.ErrInvalidCursor
+	}
+	return nil
+}
@@ -1,35 +1,53 @@
 package router
 
 import (
+	"context"
 	"database/sql"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/swagger"
+	"github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/config"
 	"github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/internal/handler"
 	"github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/internal/repository"
 	"github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/internal/service"
 	pb "github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/proto/auth"
+	bookpb "github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/proto/book"
+	"github.com/sir-shalahuddin/grpc-learn/pkg/cache"
 )
 
 // RegisterRoutes sets up the Fiber routes for user management
-func RegisterRoutes(app *fiber.App, db *sql.DB, authSvc pb.AuthServiceClient, jwtSecret string) {
+func RegisterRoutes(app *fiber.App, db *sql.DB, authSvc pb.AuthServiceClient, bookSvc bookpb.BookServiceClient, jwtSecret string, authCache cache.Cache, cacheConfig config.CacheConfig) {
 
 	bookcategoryRepo := repository.NewBookCategoryRepository(db)
-	bookcategoryService := service.NewBookCategoryService(bookcategoryRepo)
+	bookRepo := repository.NewBookRepository(bookSvc)
+	bookcategoryService := service.NewBookCategoryService(bookcategoryRepo, bookRepo)
 	bookcategoryHandler := handler.NewBookCategoryHandler(bookcategoryService)
 
-	authRepo := repository.NewAuthRepository(authSvc)
+	authRepo := repository.NewAuthRepository(authSvc, authCache, cacheConfig.TTL, cacheConfig.NegativeTTL, cacheConfig.MemoryTokenTTL)
 	authService := service.NewAuthService(authRepo, jwtSecret)
 	authMiddleware := handler.NewAuthMiddleware(authService)
+	authCacheHandler := handler.NewAuthCacheHandler(authService)
+
+	// Drop cached tokens as soon as they are revoked instead of waiting for the TTL
+	if redisCache, ok := authCache.(*cache.Redis); ok {
+		redisCache.Subscribe(context.Background(), repository.RevocationChannel, authRepo.HandleRevocation)
+	}
 
 	books := app.Group("/categories")
 
 	// documentation
 	app.Get("/swagger/*", swagger.HandlerDefault)
 
+	app.Get("/auth/cache-stats", authMiddleware.Protected("super admin"), authCacheHandler.CacheStats)
+
 	// Define the routes
 	books.Post("/", authMiddleware.Protected("librarian"), bookcategoryHandler.CreateCategory)
+	books.Get("/slug/:slug", bookcategoryHandler.GetCategoryBySlug)
 	books.Get("/:id", bookcategoryHandler.GetCategoryByID)
+	books.Get("/:id/subtree", bookcategoryHandler.GetCategorySubtree)
+	books.Get("/:id/ancestors", bookcategoryHandler.GetCategoryAncestors)
+	books.Put("/:id/parent", authMiddleware.Protected("librarian"), bookcategoryHandler.MoveCategory)
+	books.Post("/:id/merge", authMiddleware.Protected("librarian"), bookcategoryHandler.MergeCategory)
 	books.Put("/:id", authMiddleware.Protected("librarian"), bookcategoryHandler.UpdateCategory)
 	books.Delete("/:id", authMiddleware.Protected("librarian"), bookcategoryHandler.DeleteCategory)
 	books.Get("/", bookcategoryHandler.GetAllCategories)
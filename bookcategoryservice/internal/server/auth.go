@@ -0,0 +1,52 @@
+package server
+
+import (
+	"context"
+	"strings"
+
+	pb "github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/proto/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type authService interface {
+	IntrospectToken(ctx context.Context, token string) (*pb.IntrospectTokenResponse, error)
+}
+
+// authorize checks that the bearer token in the "authorization" metadata of
+// the call may act as one of allowedRoles, as the REST middleware does.
+func authorize(ctx context.Context, auth authService, allowedRoles ...string) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return status.Error(codes.Unauthenticated, "missing or malformed token")
+	}
+
+	tokenString := strings.TrimPrefix(values[0], "Bearer ")
+	if tokenString == "" {
+		return status.Error(codes.Unauthenticated, "missing or malformed token")
+	}
+
+	token, err := auth.IntrospectToken(ctx, tokenString)
+	if err != nil {
+		return status.Error(codes.Unauthenticated, "invalid or expired token")
+	}
+
+	// Suspended and locked accounts can't be used, as over REST
+	if accountStatus := token.GetAccountStatus(); accountStatus != "" && accountStatus != "active" {
+		return status.Error(codes.PermissionDenied, "access forbidden: account is "+accountStatus)
+	}
+
+	for _, permission := range token.GetPermissions() {
+		if permission == "super admin" {
+			return nil
+		}
+		for _, role := range allowedRoles {
+			if permission == role {
+				return nil
+			}
+		}
+	}
+	return status.Error(codes.PermissionDenied, "access forbidden: insufficient permissions")
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	pb "github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/proto/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// MockAuthService adalah implementasi mock dari authService.
+type MockAuthService struct {
+	IntrospectTokenFunc func(ctx context.Context, token string) (*pb.IntrospectTokenResponse, error)
+}
+
+func (m *MockAuthService) IntrospectToken(ctx context.Context, token string) (*pb.IntrospectTokenResponse, error) {
+	return m.IntrospectTokenFunc(ctx, token)
+}
+
+// Test authorize: Token, status akun dan role diperiksa seperti di REST
+func TestAuthorize(t *testing.T) {
+	tokens := map[string]*pb.IntrospectTokenResponse{
+		"librarian":   {Permissions: []string{"librarian"}, AccountStatus: "active"},
+		"super-admin": {Permissions: []string{"super admin"}, AccountStatus: "active"},
+		"user":        {Permissions: []string{"user"}, AccountStatus: "active"},
+		"suspended":   {Permissions: []string{"librarian"}, AccountStatus: "suspended"},
+		"locked":      {Permissions: []string{"super admin"}, AccountStatus: "locked"},
+		"no-status":   {Permissions: []string{"librarian"}},
+	}
+	auth := &MockAuthService{
+		IntrospectTokenFunc: func(ctx context.Context, token string) (*pb.IntrospectTokenResponse, error) {
+			if resp, ok := tokens[token]; ok {
+				return resp, nil
+			}
+			return nil, errors.New("invalid token")
+		},
+	}
+
+	tests := []struct {
+		name     string
+		metadata metadata.MD
+		want     codes.Code
+	}{
+		{"librarian", metadata.Pairs("authorization", "Bearer librarian"), codes.OK},
+		{"super admin", metadata.Pairs("authorization", "Bearer super-admin"), codes.OK},
+		{"status not reported", metadata.Pairs("authorization", "Bearer no-status"), codes.OK},
+		{"missing metadata", nil, codes.Unauthenticated},
+		{"empty token", metadata.Pairs("authorization", "Bearer "), codes.Unauthenticated},
+		{"invalid token", metadata.Pairs("authorization", "Bearer nope"), codes.Unauthenticated},
+		{"insufficient role", metadata.Pairs("authorization", "Bearer user"), codes.PermissionDenied},
+		{"suspended account", metadata.Pairs("authorization", "Bearer suspended"), codes.PermissionDenied},
+		{"locked super admin", metadata.Pairs("authorization", "Bearer locked"), codes.PermissionDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.metadata != nil {
+				ctx = metadata.NewIncomingContext(ctx, tt.metadata)
+			}
+
+			err := authorize(ctx, auth, "librarian")
+			if got := status.Code(err); got != tt.want {
+				t.Errorf("authorize() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
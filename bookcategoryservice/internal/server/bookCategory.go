@@ -2,9 +2,13 @@ package server
 
 import (
 	"context"
+	"errors"
 
 	"github.com/google/uuid"
+	"github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/dto"
+	"github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/internal/service"
 	"github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/models"
+	"github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/pkg/locale"
 	pb "github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/proto/category"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -12,35 +16,48 @@ import (
 
 type bookCategoryService interface {
 	GetCategoryByID(ctx context.Context, id uuid.UUID) (*models.BookCategory, error)
-	GetAllCategories(ctx context.Context) ([]models.BookCategory, error)
+	GetCategoryBySlug(ctx context.Context, slug string) (*models.BookCategory, error)
+	GetAllCategories(ctx context.Context, includeArchived bool) ([]models.BookCategory, error)
+	GetLatestRevision(ctx context.Context) (int64, error)
+	GetCategorySubtree(ctx context.Context, id uuid.UUID) ([]models.BookCategory, error)
+	GetCategoryAncestors(ctx context.Context, id uuid.UUID) ([]models.BookCategory, error)
+	MoveCategory(ctx context.Context, id uuid.UUID, parentID *uuid.UUID) (*models.BookCategory, error)
+	MergeCategory(ctx context.Context, sourceID, targetID uuid.UUID) (dto.MergeBookCategoryResponse, error)
+}
+
+type bookCategoryWatcher interface {
+	Watch(ctx context.Context, fromRevision int64, send func(models.BookCategoryEvent) error) error
 }
 
 type bookCategoryGRPCServer struct {
 	pb.UnimplementedBookCategoryServiceServer // Embed to have forward compatible implementations.
 	service                                   bookCategoryService
+	watcher                                   bookCategoryWatcher
+	auth                                      authService
 }
 
 // NewBookCategoryGRPCServer creates a new instance of BookCategoryGRPCServer.
-func NewBookCategoryGRPCServer(service bookCategoryService) *bookCategoryGRPCServer {
-	return &bookCategoryGRPCServer{service: service}
+func NewBookCategoryGRPCServer(service bookCategoryService, watcher bookCategoryWatcher, auth authService) *bookCategoryGRPCServer {
+	return &bookCategoryGRPCServer{service: service, watcher: watcher, auth: auth}
 }
 
 // GetCategories retrieves all categories from the database.
 func (s *bookCategoryGRPCServer) GetCategories(ctx context.Context, req *pb.GetCategoriesRequest) (*pb.CategoryListResponse, error) {
-	categories, err := s.service.GetAllCategories(ctx)
+	// Read the revision first: a change landing in between is then replayed
+	// by WatchCategories rather than missed
+	revision, err := s.service.GetLatestRevision(ctx)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to retrieve category: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to retrieve category revision: %v", err)
 	}
 
-	var categoryList []*pb.CategoryResponse
-	for _, category := range categories {
-		categoryList = append(categoryList, &pb.CategoryResponse{
-			Id:   category.ID.String(),
-			Name: category.Name,
-		})
+	categories, err := s.service.GetAllCategories(ctx, req.GetIncludeArchived())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to retrieve category: %v", err)
 	}
 
-	return &pb.CategoryListResponse{Categories: categoryList}, nil
+	res := toCategoryListResponse(categories, req.GetLocale())
+	res.Revision = revision
+	return res, nil
 }
 
 // GetCategoryByID retrieves a category by its ID from the database.
@@ -63,8 +80,182 @@ func (s *bookCategoryGRPCServer) GetCategoryByID(ctx context.Context, req *pb.Ge
 	}
 
 	// Return the category in the response
-	return &pb.CategoryResponse{
-		Id:   category.ID.String(),
-		Name: category.Name,
+	category.Localize(locale.ParseAcceptLanguage(req.GetLocale()))
+	return toCategoryResponse(*category), nil
+}
+
+// GetCategoryBySlug retrieves a category by its slug.
+func (s *bookCategoryGRPCServer) GetCategoryBySlug(ctx context.Context, req *pb.GetCategoryBySlugRequest) (*pb.CategoryResponse, error) {
+	category, err := s.service.GetCategoryBySlug(ctx, req.GetSlug())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to retrieve category: %v", err)
+	}
+	if category == nil {
+		return nil, status.Error(codes.NotFound, "category not found")
+	}
+
+	category.Localize(locale.ParseAcceptLanguage(req.GetLocale()))
+	return toCategoryResponse(*category), nil
+}
+
+// GetCategorySubtree retrieves a category and all of its descendants.
+func (s *bookCategoryGRPCServer) GetCategorySubtree(ctx context.Context, req *pb.GetCategoryByIDRequest) (*pb.CategoryListResponse, error) {
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid category ID format: %v", err)
+	}
+
+	categories, err := s.service.GetCategorySubtree(ctx, id)
+	if err != nil {
+		return nil, categoryError(err)
+	}
+
+	return toCategoryListResponse(categories, req.GetLocale()), nil
+}
+
+// GetCategoryAncestors retrieves the breadcrumb path to a category.
+func (s *bookCategoryGRPCServer) GetCategoryAncestors(ctx context.Context, req *pb.GetCategoryByIDRequest) (*pb.CategoryListResponse, error) {
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid category ID format: %v", err)
+	}
+
+	categories, err := s.service.GetCategoryAncestors(ctx, id)
+	if err != nil {
+		return nil, categoryError(err)
+	}
+
+	return toCategoryListResponse(categories, req.GetLocale()), nil
+}
+
+// MoveCategory places a category under a new parent, or at the top level
+// when parent_id is empty. Only librarians may call it.
+func (s *bookCategoryGRPCServer) MoveCategory(ctx context.Context, req *pb.MoveCategoryRequest) (*pb.CategoryResponse, error) {
+	if err := authorize(ctx, s.auth, "librarian"); err != nil {
+		return nil, err
+	}
+
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid category ID format: %v", err)
+	}
+
+	var parentID *uuid.UUID
+	if req.GetParentId() != "" {
+		parsed, err := uuid.Parse(req.GetParentId())
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid parent ID format: %v", err)
+		}
+		parentID = &parsed
+	}
+
+	category, err := s.service.MoveCategory(ctx, id, parentID)
+	if err != nil {
+		return nil, categoryError(err)
+	}
+
+	return toCategoryResponse(*category), nil
+}
+
+// MergeCategory folds one category into another. Only librarians may call
+// it.
+func (s *bookCategoryGRPCServer) MergeCategory(ctx context.Context, req *pb.MergeCategoryRequest) (*pb.MergeCategoryResponse, error) {
+	if err := authorize(ctx, s.auth, "librarian"); err != nil {
+		return nil, err
+	}
+
+	sourceID, err := uuid.Parse(req.GetSourceId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid category ID format: %v", err)
+	}
+	targetID, err := uuid.Parse(req.GetTargetId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid target category ID format: %v", err)
+	}
+
+	res, err := s.service.MergeCategory(ctx, sourceID, targetID)
+	if err != nil {
+		return nil, categoryError(err)
+	}
+
+	return &pb.MergeCategoryResponse{
+		Category:        toCategoryResponse(res.Category),
+		ReassignedBooks: res.ReassignedBooks,
 	}, nil
 }
+
+// WatchCategories streams category changes after the requested revision
+// until the client goes away.
+func (s *bookCategoryGRPCServer) WatchCategories(req *pb.WatchCategoriesRequest, stream pb.BookCategoryService_WatchCategoriesServer) error {
+	if req.GetFromRevision() < 0 {
+		return status.Error(codes.InvalidArgument, "from_revision must not be negative")
+	}
+
+	err := s.watcher.Watch(stream.Context(), req.GetFromRevision(), func(event models.BookCategoryEvent) error {
+		return stream.Send(&pb.CategoryEvent{
+			Type:      categoryEventTypes[event.Type],
+			Category:  toCategoryResponse(event.Category),
+			Revision:  event.Revision,
+			ChangedAt: event.ChangedAt.Unix(),
+		})
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrRevisionAhead) {
+			return status.Error(codes.FailedPrecondition, err.Error())
+		}
+		if _, ok := status.FromError(err); ok {
+			return err
+		}
+		return status.Errorf(codes.Internal, "failed to watch categories: %v", err)
+	}
+
+	return nil
+}
+
+var categoryEventTypes = map[string]pb.CategoryEventType{
+	models.CategoryCreated: pb.CategoryEventType_CATEGORY_CREATED,
+	models.CategoryUpdated: pb.CategoryEventType_CATEGORY_UPDATED,
+	models.CategoryDeleted: pb.CategoryEventType_CATEGORY_DELETED,
+}
+
+func toCategoryResponse(category models.BookCategory) *pb.CategoryResponse {
+	res := &pb.CategoryResponse{
+		Id:          category.ID.String(),
+		Name:        category.Name,
+		Slug:        category.Slug,
+		Description: category.Description,
+		SortOrder:   int32(category.SortOrder),
+		Archived:    category.Archived,
+	}
+	if category.ParentID != nil {
+		res.ParentId = category.ParentID.String()
+	}
+	return res
+}
+
+// toCategoryListResponse converts categories, naming them in the first of
+// the requested locales they have a translation for.
+func toCategoryListResponse(categories []models.BookCategory, acceptLanguage string) *pb.CategoryListResponse {
+	locales := locale.ParseAcceptLanguage(acceptLanguage)
+	categoryList := make([]*pb.CategoryResponse, 0, len(categories))
+	for _, category := range categories {
+		category.Localize(locales)
+		categoryList = append(categoryList, toCategoryResponse(category))
+	}
+	return &pb.CategoryListResponse{Categories: categoryList}
+}
+
+// categoryError maps service errors to gRPC status errors.
+func categoryError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrCategoryNotFound), errors.Is(err, service.ErrParentNotFound),
+		errors.Is(err, service.ErrMergeTargetNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, service.ErrCategoryCycle), errors.Is(err, service.ErrMergeIntoSelf):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, service.ErrMergeNameTaken):
+		return status.Error(codes.AlreadyExists, err.Error())
+	default:
+		return status.Errorf(codes.Internal, "failed to process category: %v", err)
+	}
+}
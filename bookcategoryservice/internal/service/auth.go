@@ -6,11 +6,13 @@ import (
 
 	"github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/pkg/auth"
 	pb "github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/proto/auth"
+	"github.com/sir-shalahuddin/grpc-learn/pkg/cache"
 )
 
 type AuthRepository interface {
 	GetUserByID(ctx context.Context, userID string) (*pb.User, error)
-	ValidateToken(ctx context.Context, token string) (string, error)
+	IntrospectToken(ctx context.Context, token string) (*pb.IntrospectTokenResponse, error)
+	CacheStats() cache.Stats
 }
 
 type authService struct {
@@ -35,11 +37,24 @@ func (s *authService) GetUserByID(ctx context.Context, userID string) (*pb.User,
 	return user, nil
 }
 
-// ValidateToken validates a JWT token and retrieves the associated user.
-func (s *authService) ValidateToken(ctx context.Context, token string) (string, error) {
-	claims, err := auth.ValidateToken(token, s.jwtSecret)
+// IntrospectToken validates a JWT token or API key and retrieves the associated
+// user's role and permissions. JWTs are checked locally first so malformed or
+// expired tokens are rejected without a call to the AuthService.
+func (s *authService) IntrospectToken(ctx context.Context, token string) (*pb.IntrospectTokenResponse, error) {
+	if !auth.IsAPIKey(token) {
+		if _, err := auth.ValidateToken(token, s.jwtSecret); err != nil {
+			return nil, auth.ErrInvalidToken
+		}
+	}
+
+	res, err := s.authRepo.IntrospectToken(ctx, token)
 	if err != nil {
-		return "", auth.ErrInvalidToken
+		return nil, auth.ErrInvalidToken
 	}
-	return claims.String(), nil
+	return res, nil
+}
+
+// CacheStats returns hit and miss counts of the auth cache.
+func (s *authService) CacheStats() cache.Stats {
+	return s.authRepo.CacheStats()
 }
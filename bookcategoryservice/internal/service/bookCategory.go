@@ -3,64 +3,530 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"regexp"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/dto"
 	"github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/models"
+	"github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/pkg/pagination"
 )
 
 type bookCategoryRepository interface {
-	Create(ctx context.Context, name string) (uuid.UUID, error)
+	Create(ctx context.Context, category *models.BookCategory) (uuid.UUID, error)
 	GetByID(ctx context.Context, id uuid.UUID) (*models.BookCategory, error)
 	GetByName(ctx context.Context, name string) (*models.BookCategory, error)
-	GetAll(ctx context.Context) ([]models.BookCategory, error)
+	GetBySlug(ctx context.Context, slug string) (*models.BookCategory, error)
+	GetByAlias(ctx context.Context, id uuid.UUID) (*models.BookCategory, error)
+	GetByAliasName(ctx context.Context, name string) (*models.BookCategory, error)
+	GetAll(ctx context.Context, includeArchived bool) ([]models.BookCategory, error)
+	List(ctx context.Context, includeArchived bool, after []string, limit int) ([]models.BookCategory, []string, error)
+	Count(ctx context.Context, includeArchived bool) (int64, error)
 	Update(ctx context.Context, category *models.BookCategory) error
 	Delete(ctx context.Context, id uuid.UUID) error
+	GetLatestRevision(ctx context.Context) (int64, error)
+	Move(ctx context.Context, id uuid.UUID, parentID *uuid.UUID) (*models.BookCategory, error)
+	GetSubtree(ctx context.Context, id uuid.UUID) ([]models.BookCategory, error)
+	GetAncestors(ctx context.Context, id uuid.UUID) ([]models.BookCategory, error)
+	HasChildren(ctx context.Context, id uuid.UUID) (bool, error)
+	Merge(ctx context.Context, sourceID, targetID uuid.UUID) (*models.BookCategory, error)
+}
+
+type bookRepository interface {
+	CountByCategory(ctx context.Context, categoryID uuid.UUID) (int64, error)
+	ReassignCategory(ctx context.Context, fromID, toID uuid.UUID) (int64, error)
 }
 
 type bookCategoryService struct {
-	repo bookCategoryRepository
+	repo     bookCategoryRepository
+	bookRepo bookRepository
 }
 
 var (
-	ErrDuplicateCategory = errors.New("category already exist")
+	ErrDuplicateCategory   = errors.New("category already exist")
+	ErrCategoryNotFound    = errors.New("category not found")
+	ErrParentNotFound      = errors.New("parent category not found")
+	ErrCategoryCycle       = errors.New("category cannot be moved under itself or one of its descendants")
+	ErrCategoryHasChildren = errors.New("category still has subcategories")
+	ErrDuplicateSlug       = errors.New("slug is already used by another category")
+	ErrInvalidSlug         = errors.New("slug may only contain lower case letters, digits and single dashes")
+	ErrInvalidLocale       = errors.New("names must be keyed by a locale such as en or pt-BR")
+	ErrCategoryInUse       = errors.New("category still has books, give a category to reassign them to")
+	ErrReassignNotFound    = errors.New("category to reassign books to not found")
+	ErrReassignToSelf      = errors.New("books cannot be reassigned to the category being deleted")
+	ErrMergeTargetNotFound = errors.New("category to merge into not found")
+	ErrMergeIntoSelf       = errors.New("category cannot be merged into itself or one of its subcategories")
+	ErrMergeNameTaken      = errors.New("a category merged earlier already goes by the name of this one, rename it first")
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+
+	// categoryListSort names the display order in list cursors
+	categoryListSort = "display"
+)
+
+var (
+	slugPattern   = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+	nonSlugChars  = regexp.MustCompile(`[^a-z0-9]+`)
+	localePattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})*$`)
 )
 
+// maxSlugAttempts bounds how far a generated slug is numbered.
+const maxSlugAttempts = 100
+
 // NewBookCategoryService returns a new instance of BookCategoryService.
-func NewBookCategoryService(repo bookCategoryRepository) *bookCategoryService {
+func NewBookCategoryService(repo bookCategoryRepository, bookRepo bookRepository) *bookCategoryService {
 	return &bookCategoryService{
-		repo: repo,
+		repo:     repo,
+		bookRepo: bookRepo,
 	}
 }
 
 func (s *bookCategoryService) CreateCategory(ctx context.Context, req dto.CreateBookCategoryRequest) (dto.CreateBookCategoryResponse, error) {
-	existCategory, err := s.repo.GetByName(ctx, req.Name)
+	if err := s.checkName(ctx, req.Name, uuid.Nil); err != nil {
+		return dto.CreateBookCategoryResponse{}, err
+	}
+
+	if req.ParentID != nil {
+		parent, err := s.repo.GetByID(ctx, *req.ParentID)
+		if err != nil {
+			return dto.CreateBookCategoryResponse{}, err
+		}
+		if parent == nil {
+			return dto.CreateBookCategoryResponse{}, ErrParentNotFound
+		}
+	}
+
+	names, err := normalizeNames(req.Names)
 	if err != nil {
 		return dto.CreateBookCategoryResponse{}, err
 	}
-	if existCategory != nil {
-		return dto.CreateBookCategoryResponse{}, ErrDuplicateCategory
+
+	slug := req.Slug
+	if slug == "" {
+		slug, err = s.generateSlug(ctx, req.Name)
+	} else {
+		err = s.checkSlug(ctx, slug, uuid.Nil)
+	}
+	if err != nil {
+		return dto.CreateBookCategoryResponse{}, err
 	}
 
-	res, err := s.repo.Create(ctx, req.Name)
+	category := &models.BookCategory{
+		Name:        req.Name,
+		ParentID:    req.ParentID,
+		Slug:        slug,
+		Description: req.Description,
+		SortOrder:   req.SortOrder,
+		Archived:    req.Archived,
+		Names:       names,
+	}
+
+	res, err := s.repo.Create(ctx, category)
+	if errors.Is(err, models.ErrDuplicateName) {
+		return dto.CreateBookCategoryResponse{}, ErrDuplicateCategory
+	}
 	if err != nil {
 		return dto.CreateBookCategoryResponse{}, err
 	}
-	return dto.CreateBookCategoryResponse{ID: res, Name: req.Name}, nil
+	return dto.CreateBookCategoryResponse{ID: res, Name: req.Name, ParentID: req.ParentID, Slug: slug}, nil
 }
 
+// checkName returns ErrDuplicateCategory when a category other than id has
+// name, or a merged category went by it.
+func (s *bookCategoryService) checkName(ctx context.Context, name string, id uuid.UUID) error {
+	existCategory, err := s.repo.GetByName(ctx, name)
+	if err != nil {
+		return err
+	}
+	if existCategory != nil && existCategory.ID != id {
+		return ErrDuplicateCategory
+	}
+
+	// The name of a merged category keeps pointing at the one it merged into
+	aliasedCategory, err := s.repo.GetByAliasName(ctx, name)
+	if err != nil {
+		return err
+	}
+	if aliasedCategory != nil {
+		return ErrDuplicateCategory
+	}
+	return nil
+}
+
+// GetCategoryByID retrieves a category by its ID. The ID of a merged
+// category resolves to the category it was merged into.
 func (s *bookCategoryService) GetCategoryByID(ctx context.Context, id uuid.UUID) (*models.BookCategory, error) {
-	return s.repo.GetByID(ctx, id)
+	category, err := s.repo.GetByID(ctx, id)
+	if err != nil || category != nil {
+		return category, err
+	}
+
+	return s.repo.GetByAlias(ctx, id)
+}
+
+// GetCategoryBySlug retrieves a category by its slug.
+func (s *bookCategoryService) GetCategoryBySlug(ctx context.Context, slug string) (*models.BookCategory, error) {
+	return s.repo.GetBySlug(ctx, slug)
+}
+
+// GetAllCategories returns categories in display order, leaving out archived
+// ones unless includeArchived is set.
+func (s *bookCategoryService) GetAllCategories(ctx context.Context, includeArchived bool) ([]models.BookCategory, error) {
+	return s.repo.GetAll(ctx, includeArchived)
+}
+
+// ListCategories returns a page of categories in display order, with their
+// total when asked for. Pages follow each other through NextCursor.
+func (s *bookCategoryService) ListCategories(ctx context.Context, req dto.ListBookCategoriesRequest) (*dto.ListBookCategoriesResponse, error) {
+	after, err := pagination.Decode(req.Cursor, categoryListSort)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := pagination.Limit(req.PageSize, defaultPageSize, maxPageSize)
+	categories, nextKeys, err := s.repo.List(ctx, req.IncludeArchived, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	if categories == nil {
+		categories = []models.BookCategory{}
+	}
+
+	page := &dto.ListBookCategoriesResponse{
+		Categories: categories,
+		NextCursor: pagination.Encode(categoryListSort, nextKeys),
+	}
+	if req.IncludeTotal {
+		total, err := s.repo.Count(ctx, req.IncludeArchived)
+		if err != nil {
+			return nil, err
+		}
+		page.Total = &total
+	}
+
+	return page, nil
+}
+
+// GetLatestRevision returns the revision of the newest category change.
+func (s *bookCategoryService) GetLatestRevision(ctx context.Context) (int64, error) {
+	return s.repo.GetLatestRevision(ctx)
+}
+
+// UpdateCategory renames a category and changes whichever of its other
+// fields are set in req. The slug stays the same on rename so existing links
+// keep working.
+func (s *bookCategoryService) UpdateCategory(ctx context.Context, id uuid.UUID, req dto.UpdateBookCategoryRequest) error {
+	category, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if category == nil {
+		return ErrCategoryNotFound
+	}
+
+	if req.Name != category.Name {
+		if err := s.checkName(ctx, req.Name, id); err != nil {
+			return err
+		}
+		category.Name = req.Name
+	}
+	if req.Slug != nil && *req.Slug != category.Slug {
+		if err := s.checkSlug(ctx, *req.Slug, id); err != nil {
+			return err
+		}
+		category.Slug = *req.Slug
+	}
+	if req.Description != nil {
+		category.Description = *req.Description
+	}
+	if req.SortOrder != nil {
+		category.SortOrder = *req.SortOrder
+	}
+	if req.Archived != nil {
+		category.Archived = *req.Archived
+	}
+
+	// Leave translations alone unless the request has a names object
+	category.Names = nil
+	if req.Names != nil {
+		if category.Names, err = normalizeNames(req.Names); err != nil {
+			return err
+		}
+	}
+
+	err = s.repo.Update(ctx, category)
+	if errors.Is(err, models.ErrDuplicateName) {
+		return ErrDuplicateCategory
+	}
+	return err
+}
+
+// DeleteCategory deletes a category that no book in bookservice uses. Books
+// still in it are first moved to reassignTo when given, otherwise the delete
+// is refused with ErrCategoryInUse. It returns how many books were moved.
+func (s *bookCategoryService) DeleteCategory(ctx context.Context, id uuid.UUID, reassignTo *uuid.UUID) (int64, error) {
+	category, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	if category == nil {
+		return 0, ErrCategoryNotFound
+	}
+
+	hasChildren, err := s.repo.HasChildren(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	if hasChildren {
+		return 0, ErrCategoryHasChildren
+	}
+
+	var reassigned int64
+	if reassignTo != nil {
+		if *reassignTo == id {
+			return 0, ErrReassignToSelf
+		}
+		target, err := s.repo.GetByID(ctx, *reassignTo)
+		if err != nil {
+			return 0, err
+		}
+		if target == nil {
+			return 0, ErrReassignNotFound
+		}
+
+		if reassigned, err = s.bookRepo.ReassignCategory(ctx, id, *reassignTo); err != nil {
+			return 0, err
+		}
+	} else {
+		count, err := s.bookRepo.CountByCategory(ctx, id)
+		if err != nil {
+			return 0, err
+		}
+		if count > 0 {
+			return 0, fmt.Errorf("%w (%d books)", ErrCategoryInUse, count)
+		}
+	}
+
+	return reassigned, s.repo.Delete(ctx, id)
+}
+
+// MergeCategory folds sourceID into targetID. Books of the source move to
+// the target in bookservice, its subcategories move under the target, and its
+// ID and name keep resolving to the target afterwards.
+func (s *bookCategoryService) MergeCategory(ctx context.Context, sourceID, targetID uuid.UUID) (dto.MergeBookCategoryResponse, error) {
+	if sourceID == targetID {
+		return dto.MergeBookCategoryResponse{}, ErrMergeIntoSelf
+	}
+
+	source, err := s.repo.GetByID(ctx, sourceID)
+	if err != nil {
+		return dto.MergeBookCategoryResponse{}, err
+	}
+	if source == nil {
+		return dto.MergeBookCategoryResponse{}, ErrCategoryNotFound
+	}
+
+	target, err := s.repo.GetByID(ctx, targetID)
+	if err != nil {
+		return dto.MergeBookCategoryResponse{}, err
+	}
+	if target == nil {
+		return dto.MergeBookCategoryResponse{}, ErrMergeTargetNotFound
+	}
+
+	// Its name becomes an alias, so it must not already be one
+	aliasedCategory, err := s.repo.GetByAliasName(ctx, source.Name)
+	if err != nil {
+		return dto.MergeBookCategoryResponse{}, err
+	}
+	if aliasedCategory != nil {
+		return dto.MergeBookCategoryResponse{}, ErrMergeNameTaken
+	}
+
+	// Books go first: if the merge then fails they are already where they
+	// belong and retrying finishes the job
+	reassigned, err := s.bookRepo.ReassignCategory(ctx, sourceID, targetID)
+	if err != nil {
+		return dto.MergeBookCategoryResponse{}, err
+	}
+
+	merged, err := s.repo.Merge(ctx, sourceID, targetID)
+	if errors.Is(err, models.ErrDuplicateAlias) {
+		return dto.MergeBookCategoryResponse{}, ErrMergeNameTaken
+	}
+	if err != nil {
+		return dto.MergeBookCategoryResponse{}, err
+	}
+	if merged == nil {
+		return dto.MergeBookCategoryResponse{}, ErrMergeIntoSelf
+	}
+
+	target, err = s.repo.GetByID(ctx, targetID)
+	if err != nil {
+		return dto.MergeBookCategoryResponse{}, err
+	}
+	if target == nil {
+		return dto.MergeBookCategoryResponse{}, ErrMergeTargetNotFound
+	}
+
+	return dto.MergeBookCategoryResponse{Category: *target, ReassignedBooks: reassigned}, nil
+}
+
+// MoveCategory places a category under parentID, or at the top level when
+// parentID is nil.
+func (s *bookCategoryService) MoveCategory(ctx context.Context, id uuid.UUID, parentID *uuid.UUID) (*models.BookCategory, error) {
+	category, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if category == nil {
+		return nil, ErrCategoryNotFound
+	}
+
+	if parentID != nil {
+		parent, err := s.repo.GetByID(ctx, *parentID)
+		if err != nil {
+			return nil, err
+		}
+		if parent == nil {
+			return nil, ErrParentNotFound
+		}
+	}
+
+	// The repository refuses the move if it would create a cycle
+	moved, err := s.repo.Move(ctx, id, parentID)
+	if err != nil {
+		return nil, err
+	}
+	if moved == nil {
+		return nil, ErrCategoryCycle
+	}
+
+	return moved, nil
+}
+
+// GetCategoryTree returns a category with all of its descendants nested
+// below it.
+func (s *bookCategoryService) GetCategoryTree(ctx context.Context, id uuid.UUID) (*models.BookCategoryNode, error) {
+	categories, err := s.GetCategorySubtree(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildCategoryTree(categories), nil
+}
+
+// GetCategorySubtree returns a category and all of its descendants, parents
+// before their children.
+func (s *bookCategoryService) GetCategorySubtree(ctx context.Context, id uuid.UUID) ([]models.BookCategory, error) {
+	categories, err := s.repo.GetSubtree(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(categories) == 0 {
+		return nil, ErrCategoryNotFound
+	}
+
+	return categories, nil
+}
+
+// GetCategoryAncestors returns the breadcrumb path from the root category
+// down to id.
+func (s *bookCategoryService) GetCategoryAncestors(ctx context.Context, id uuid.UUID) ([]models.BookCategory, error) {
+	categories, err := s.repo.GetAncestors(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(categories) == 0 {
+		return nil, ErrCategoryNotFound
+	}
+
+	return categories, nil
 }
 
-func (s *bookCategoryService) GetAllCategories(ctx context.Context) ([]models.BookCategory, error) {
-	return s.repo.GetAll(ctx)
+// buildCategoryTree nests a subtree listed parents first. The first category
+// is the root. Categories whose parent isn't listed before them are left out,
+// and so are repeats, so a cycle in the data can't nest forever.
+func buildCategoryTree(categories []models.BookCategory) *models.BookCategoryNode {
+	nodes := make(map[uuid.UUID]*models.BookCategoryNode, len(categories))
+	var root *models.BookCategoryNode
+	for _, category := range categories {
+		if _, ok := nodes[category.ID]; ok {
+			continue
+		}
+		node := &models.BookCategoryNode{BookCategory: category, Children: []*models.BookCategoryNode{}}
+
+		if root == nil {
+			root = node
+			nodes[category.ID] = node
+			continue
+		}
+		if category.ParentID == nil {
+			continue
+		}
+		if parent, ok := nodes[*category.ParentID]; ok {
+			parent.Children = append(parent.Children, node)
+			nodes[category.ID] = node
+		}
+	}
+
+	return root
 }
 
-func (s *bookCategoryService) UpdateCategory(ctx context.Context, category *models.BookCategory) error {
-	return s.repo.Update(ctx, category)
+// generateSlug derives a slug from name, numbering it when already taken.
+func (s *bookCategoryService) generateSlug(ctx context.Context, name string) (string, error) {
+	base := strings.Trim(nonSlugChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if base == "" {
+		base = "category"
+	}
+
+	slug := base
+	for n := 2; n <= maxSlugAttempts; n++ {
+		existing, err := s.repo.GetBySlug(ctx, slug)
+		if err != nil {
+			return "", err
+		}
+		if existing == nil {
+			return slug, nil
+		}
+		slug = fmt.Sprintf("%s-%d", base, n)
+	}
+
+	return "", ErrDuplicateSlug
 }
 
-func (s *bookCategoryService) DeleteCategory(ctx context.Context, id uuid.UUID) error {
-	return s.repo.Delete(ctx, id)
+// checkSlug validates a slug chosen by the client. id is the category that
+// may keep it, or uuid.Nil for a new category.
+func (s *bookCategoryService) checkSlug(ctx context.Context, slug string, id uuid.UUID) error {
+	if !slugPattern.MatchString(slug) {
+		return ErrInvalidSlug
+	}
+
+	existing, err := s.repo.GetBySlug(ctx, slug)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.ID != id {
+		return ErrDuplicateSlug
+	}
+	return nil
+}
+
+// normalizeNames lower cases locales and checks they look like language
+// tags.
+func normalizeNames(names map[string]string) (map[string]string, error) {
+	normalized := make(map[string]string, len(names))
+	for locale, name := range names {
+		locale = strings.ToLower(strings.TrimSpace(locale))
+		name = strings.TrimSpace(name)
+		if !localePattern.MatchString(locale) || name == "" {
+			return nil, ErrInvalidLocale
+		}
+		normalized[locale] = name
+	}
+	return normalized, nil
 }
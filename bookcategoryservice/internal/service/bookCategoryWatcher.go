@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/models"
+)
+
+var ErrRevisionAhead = errors.New("revision is ahead of the change log")
+
+type bookCategoryEventRepository interface {
+	Listen(ctx context.Context) (<-chan struct{}, error)
+}
+
+type bookCategoryChangeRepository interface {
+	ListChanges(ctx context.Context, afterRevision int64, limit int) ([]models.BookCategoryEvent, error)
+	GetLatestRevision(ctx context.Context) (int64, error)
+}
+
+const (
+	// changeBatchSize is how many changes are read from the log at once.
+	changeBatchSize = 100
+	// watchPollInterval rechecks the log even without a notification, in
+	// case one was lost.
+	watchPollInterval = 30 * time.Second
+)
+
+type bookCategoryWatcher struct {
+	events  bookCategoryEventRepository
+	changes bookCategoryChangeRepository
+
+	mu          sync.Mutex
+	subscribers map[chan struct{}]struct{}
+}
+
+// NewBookCategoryWatcher returns a watcher that streams the category change
+// log to every subscriber.
+func NewBookCategoryWatcher(events bookCategoryEventRepository, changes bookCategoryChangeRepository) *bookCategoryWatcher {
+	return &bookCategoryWatcher{
+		events:      events,
+		changes:     changes,
+		subscribers: make(map[chan struct{}]struct{}),
+	}
+}
+
+// Run wakes subscribers whenever the change log grows, until ctx is done.
+func (w *bookCategoryWatcher) Run(ctx context.Context) error {
+	signals, err := w.events.Listen(ctx)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for range signals {
+			w.wakeAll()
+		}
+		w.closeAll()
+	}()
+
+	return nil
+}
+
+// Watch calls send with every change after fromRevision, oldest first, until
+// ctx is done or send fails. A revision of 0 replays the whole log.
+func (w *bookCategoryWatcher) Watch(ctx context.Context, fromRevision int64, send func(models.BookCategoryEvent) error) error {
+	// Subscribe before reading so a change committed in between still wakes us
+	wake, cancel := w.subscribe()
+	defer cancel()
+
+	latest, err := w.changes.GetLatestRevision(ctx)
+	if err != nil {
+		return err
+	}
+	if fromRevision > latest {
+		return ErrRevisionAhead
+	}
+
+	ticker := time.NewTicker(watchPollInterval)
+	defer ticker.Stop()
+
+	last := fromRevision
+	for {
+		changes, err := w.changes.ListChanges(ctx, last, changeBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, change := range changes {
+			if err := send(change); err != nil {
+				return err
+			}
+			last = change.Revision
+		}
+		if len(changes) == changeBatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case _, ok := <-wake:
+			if !ok {
+				// Notifications stopped, keep going on the poll interval
+				wake = nil
+			}
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *bookCategoryWatcher) subscribe() (<-chan struct{}, func()) {
+	// One pending wake-up is enough, the subscriber reads everything new
+	ch := make(chan struct{}, 1)
+
+	w.mu.Lock()
+	w.subscribers[ch] = struct{}{}
+	w.mu.Unlock()
+
+	cancel := func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		w.remove(ch)
+	}
+	return ch, cancel
+}
+
+func (w *bookCategoryWatcher) wakeAll() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for ch := range w.subscribers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (w *bookCategoryWatcher) closeAll() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for ch := range w.subscribers {
+		w.remove(ch)
+	}
+}
+
+// remove must be called with mu held.
+func (w *bookCategoryWatcher) remove(ch chan struct{}) {
+	if _, ok := w.subscribers[ch]; ok {
+		delete(w.subscribers, ch)
+		close(ch)
+	}
+}
@@ -0,0 +1,279 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/models"
+)
+
+// MockBookCategoryEventRepository adalah implementasi mock dari bookCategoryEventRepository.
+type MockBookCategoryEventRepository struct {
+	ListenFunc func(ctx context.Context) (<-chan struct{}, error)
+}
+
+func (m *MockBookCategoryEventRepository) Listen(ctx context.Context) (<-chan struct{}, error) {
+	return m.ListenFunc(ctx)
+}
+
+// runningWatcher menjalankan watcher yang dibangunkan lewat signals.
+func runningWatcher(t *testing.T, changes bookCategoryChangeRepository) (*bookCategoryWatcher, chan struct{}) {
+	signals := make(chan struct{})
+	events := &MockBookCategoryEventRepository{
+		ListenFunc: func(ctx context.Context) (<-chan struct{}, error) {
+			return signals, nil
+		},
+	}
+
+	watcher := NewBookCategoryWatcher(events, changes)
+	if err := watcher.Run(context.Background()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	return watcher, signals
+}
+
+// Test Run: Setiap sinyal NOTIFY membangunkan semua subscriber, dan
+// subscriber ditutup saat sinyal berhenti
+func TestWatcherRun_WakesSubscribers(t *testing.T) {
+	watcher, signals := runningWatcher(t, nil)
+	first, cancelFirst := watcher.subscribe()
+	defer cancelFirst()
+	second, cancelSecond := watcher.subscribe()
+	defer cancelSecond()
+
+	signals <- struct{}{}
+	for _, ch := range []<-chan struct{}{first, second} {
+		select {
+		case <-ch:
+		case <-time.After(time.Second):
+			t.Fatal("expected every subscriber to be woken")
+		}
+	}
+
+	// Sinyal yang belum dibaca digabung, pengiriman tidak boleh macet
+	signals <- struct{}{}
+	signals <- struct{}{}
+	signals <- struct{}{}
+
+	close(signals)
+	for _, ch := range []<-chan struct{}{first, second} {
+		deadline := time.After(time.Second)
+		for open := true; open; {
+			select {
+			case _, open = <-ch:
+			case <-deadline:
+				t.Fatal("expected subscribers to be closed once notifications stop")
+			}
+		}
+	}
+}
+
+// Test subscribe: Subscriber yang berhenti tidak dibangunkan lagi
+func TestWatcherSubscribe_Cancel(t *testing.T) {
+	watcher := NewBookCategoryWatcher(nil, nil)
+	ch, cancel := watcher.subscribe()
+	cancel()
+	cancel()
+
+	watcher.wakeAll()
+	if _, ok := <-ch; ok {
+		t.Error("expected a cancelled subscriber to be closed")
+	}
+	if len(watcher.subscribers) != 0 {
+		t.Errorf("expected no subscribers left, got %d", len(watcher.subscribers))
+	}
+}
+
+// MockBookCategoryChangeRepository adalah implementasi mock dari bookCategoryChangeRepository.
+type MockBookCategoryChangeRepository struct {
+	ListChangesFunc       func(ctx context.Context, afterRevision int64, limit int) ([]models.BookCategoryEvent, error)
+	GetLatestRevisionFunc func(ctx context.Context) (int64, error)
+}
+
+func (m *MockBookCategoryChangeRepository) ListChanges(ctx context.Context, afterRevision int64, limit int) ([]models.BookCategoryEvent, error) {
+	return m.ListChangesFunc(ctx, afterRevision, limit)
+}
+
+func (m *MockBookCategoryChangeRepository) GetLatestRevision(ctx context.Context) (int64, error) {
+	return m.GetLatestRevisionFunc(ctx)
+}
+
+// changeLog adalah change log di memori dengan revisi 1 sampai n.
+type changeLog struct {
+	mu      sync.Mutex
+	changes []models.BookCategoryEvent
+	limits  []int
+}
+
+func newChangeLog(n int) *changeLog {
+	log := &changeLog{}
+	for i := 0; i < n; i++ {
+		log.append()
+	}
+	return log
+}
+
+func (l *changeLog) append() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	revision := int64(len(l.changes) + 1)
+	l.changes = append(l.changes, models.BookCategoryEvent{Revision: revision, Type: models.CategoryUpdated})
+}
+
+func (l *changeLog) repository() *MockBookCategoryChangeRepository {
+	return &MockBookCategoryChangeRepository{
+		ListChangesFunc: func(ctx context.Context, afterRevision int64, limit int) ([]models.BookCategoryEvent, error) {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			l.limits = append(l.limits, limit)
+
+			var changes []models.BookCategoryEvent
+			for _, change := range l.changes {
+				if change.Revision > afterRevision && len(changes) < limit {
+					changes = append(changes, change)
+				}
+			}
+			return changes, nil
+		},
+		GetLatestRevisionFunc: func(ctx context.Context) (int64, error) {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			return int64(len(l.changes)), nil
+		},
+	}
+}
+
+// Test Watch: Change log diputar ulang berurutan per batch mulai setelah
+// fromRevision
+func TestWatch_ReplaysInBatches(t *testing.T) {
+	tests := []struct {
+		name         string
+		size         int
+		fromRevision int64
+		wantFirst    int64
+		wantBatches  int
+	}{
+		{"whole log", 250, 0, 1, 3},
+		{"resume", 250, 120, 121, 2},
+		{"up to date", 5, 5, 0, 1},
+		{"empty log", 0, 0, 0, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := newChangeLog(tt.size)
+			watcher := NewBookCategoryWatcher(nil, log.repository())
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var got []int64
+			if tt.size == int(tt.fromRevision) {
+				cancel()
+			}
+			err := watcher.Watch(ctx, tt.fromRevision, func(change models.BookCategoryEvent) error {
+				got = append(got, change.Revision)
+				if change.Revision == int64(tt.size) {
+					cancel()
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if want := tt.size - int(tt.fromRevision); len(got) != want {
+				t.Fatalf("got %d changes, want %d", len(got), want)
+			}
+			for i, revision := range got {
+				if revision != tt.wantFirst+int64(i) {
+					t.Fatalf("change %d has revision %d, want %d", i, revision, tt.wantFirst+int64(i))
+				}
+			}
+			if len(log.limits) != tt.wantBatches {
+				t.Errorf("read the log %d times, want %d", len(log.limits), tt.wantBatches)
+			}
+			for _, limit := range log.limits {
+				if limit != changeBatchSize {
+					t.Errorf("limit = %d, want %d", limit, changeBatchSize)
+				}
+			}
+		})
+	}
+}
+
+// Test Watch: Revisi di depan change log ditolak
+func TestWatch_RevisionAhead(t *testing.T) {
+	watcher := NewBookCategoryWatcher(nil, newChangeLog(3).repository())
+
+	err := watcher.Watch(context.Background(), 4, func(models.BookCategoryEvent) error { return nil })
+	if !errors.Is(err, ErrRevisionAhead) {
+		t.Errorf("expected ErrRevisionAhead, got %v", err)
+	}
+}
+
+// Test Watch: NOTIFY membangunkan watcher untuk mengirim perubahan baru tanpa
+// menunggu poll
+func TestWatch_WakesOnNotify(t *testing.T) {
+	log := newChangeLog(1)
+	watcher, signals := runningWatcher(t, log.repository())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	received := make(chan int64, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- watcher.Watch(ctx, 1, func(change models.BookCategoryEvent) error {
+			received <- change.Revision
+			return nil
+		})
+	}()
+
+	// Tunggu sampai Watch berlangganan sebelum ada perubahan baru
+	for deadline := time.Now().Add(time.Second); ; {
+		watcher.mu.Lock()
+		subscribed := len(watcher.subscribers) > 0
+		watcher.mu.Unlock()
+		if subscribed {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected Watch to subscribe")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	log.append()
+	signals <- struct{}{}
+
+	select {
+	case revision := <-received:
+		if revision != 2 {
+			t.Errorf("revision = %d, want 2", revision)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the new change to be sent after NOTIFY")
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+// Test Watch: Error dari send menghentikan watch
+func TestWatch_SendError(t *testing.T) {
+	watcher := NewBookCategoryWatcher(nil, newChangeLog(3).repository())
+	sendErr := errors.New("stream closed")
+
+	calls := 0
+	err := watcher.Watch(context.Background(), 0, func(models.BookCategoryEvent) error {
+		calls++
+		return sendErr
+	})
+	if !errors.Is(err, sendErr) || calls != 1 {
+		t.Errorf("expected to stop after the first failed send, got %v after %d calls", err, calls)
+	}
+}
@@ -0,0 +1,575 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/dto"
+	"github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/models"
+)
+
+// MockBookCategoryRepository adalah implementasi mock dari bookCategoryRepository.
+// Method tanpa Func akan panic karena interface yang di-embed nil.
+type MockBookCategoryRepository struct {
+	bookCategoryRepository
+	CreateFunc         func(ctx context.Context, category *models.BookCategory) (uuid.UUID, error)
+	GetByIDFunc        func(ctx context.Context, id uuid.UUID) (*models.BookCategory, error)
+	GetByNameFunc      func(ctx context.Context, name string) (*models.BookCategory, error)
+	GetBySlugFunc      func(ctx context.Context, slug string) (*models.BookCategory, error)
+	GetByAliasNameFunc func(ctx context.Context, name string) (*models.BookCategory, error)
+	UpdateFunc         func(ctx context.Context, category *models.BookCategory) error
+	DeleteFunc         func(ctx context.Context, id uuid.UUID) error
+	HasChildrenFunc    func(ctx context.Context, id uuid.UUID) (bool, error)
+	MoveFunc           func(ctx context.Context, id uuid.UUID, parentID *uuid.UUID) (*models.BookCategory, error)
+	MergeFunc          func(ctx context.Context, sourceID, targetID uuid.UUID) (*models.BookCategory, error)
+}
+
+func (m *MockBookCategoryRepository) Create(ctx context.Context, category *models.BookCategory) (uuid.UUID, error) {
+	return m.CreateFunc(ctx, category)
+}
+
+func (m *MockBookCategoryRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.BookCategory, error) {
+	return m.GetByIDFunc(ctx, id)
+}
+
+func (m *MockBookCategoryRepository) GetByName(ctx context.Context, name string) (*models.BookCategory, error) {
+	return m.GetByNameFunc(ctx, name)
+}
+
+func (m *MockBookCategoryRepository) GetBySlug(ctx context.Context, slug string) (*models.BookCategory, error) {
+	return m.GetBySlugFunc(ctx, slug)
+}
+
+func (m *MockBookCategoryRepository) GetByAliasName(ctx context.Context, name string) (*models.BookCategory, error) {
+	return m.GetByAliasNameFunc(ctx, name)
+}
+
+func (m *MockBookCategoryRepository) Update(ctx context.Context, category *models.BookCategory) error {
+	return m.UpdateFunc(ctx, category)
+}
+
+func (m *MockBookCategoryRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return m.DeleteFunc(ctx, id)
+}
+
+func (m *MockBookCategoryRepository) HasChildren(ctx context.Context, id uuid.UUID) (bool, error) {
+	return m.HasChildrenFunc(ctx, id)
+}
+
+func (m *MockBookCategoryRepository) Move(ctx context.Context, id uuid.UUID, parentID *uuid.UUID) (*models.BookCategory, error) {
+	return m.MoveFunc(ctx, id, parentID)
+}
+
+func (m *MockBookCategoryRepository) Merge(ctx context.Context, sourceID, targetID uuid.UUID) (*models.BookCategory, error) {
+	return m.MergeFunc(ctx, sourceID, targetID)
+}
+
+// MockBookRepository adalah implementasi mock dari bookRepository.
+type MockBookRepository struct {
+	CountByCategoryFunc  func(ctx context.Context, categoryID uuid.UUID) (int64, error)
+	ReassignCategoryFunc func(ctx context.Context, fromID, toID uuid.UUID) (int64, error)
+}
+
+func (m *MockBookRepository) CountByCategory(ctx context.Context, categoryID uuid.UUID) (int64, error) {
+	return m.CountByCategoryFunc(ctx, categoryID)
+}
+
+func (m *MockBookRepository) ReassignCategory(ctx context.Context, fromID, toID uuid.UUID) (int64, error) {
+	return m.ReassignCategoryFunc(ctx, fromID, toID)
+}
+
+// categories mengembalikan GetByIDFunc yang mengenal categories.
+func categories(categories ...models.BookCategory) func(ctx context.Context, id uuid.UUID) (*models.BookCategory, error) {
+	return func(ctx context.Context, id uuid.UUID) (*models.BookCategory, error) {
+		for _, category := range categories {
+			if category.ID == id {
+				return &category, nil
+			}
+		}
+		return nil, nil
+	}
+}
+
+// noCategory adalah lookup yang tidak menemukan apa pun.
+func noCategory(ctx context.Context, key string) (*models.BookCategory, error) {
+	return nil, nil
+}
+
+// slugsTaken mengembalikan GetBySlugFunc untuk slug yang dipakai kategori
+// lain.
+func slugsTaken(slugs ...string) func(ctx context.Context, slug string) (*models.BookCategory, error) {
+	return func(ctx context.Context, slug string) (*models.BookCategory, error) {
+		for _, taken := range slugs {
+			if taken == slug {
+				return &models.BookCategory{ID: uuid.New(), Slug: slug}, nil
+			}
+		}
+		return nil, nil
+	}
+}
+
+func childOf(name string, parent *models.BookCategory) models.BookCategory {
+	category := models.BookCategory{ID: uuid.New(), Name: name}
+	if parent != nil {
+		category.ParentID = &parent.ID
+	}
+	return category
+}
+
+// treeNames menulis pohon sebagai "root(child(grandchild),child)".
+func treeNames(node *models.BookCategoryNode) string {
+	if node == nil {
+		return ""
+	}
+	s := node.Name
+	if len(node.Children) > 0 {
+		s += "("
+		for i, child := range node.Children {
+			if i > 0 {
+				s += ","
+			}
+			s += treeNames(child)
+		}
+		s += ")"
+	}
+	return s
+}
+
+// Test buildCategoryTree: Subtree disusun bertingkat, kategori yatim dan
+// pengulangan akibat siklus dilewati
+func TestBuildCategoryTree(t *testing.T) {
+	fiction := childOf("Fiction", nil)
+	fantasy := childOf("Fantasy", &fiction)
+	scifi := childOf("SciFi", &fiction)
+	epic := childOf("Epic", &fantasy)
+	elsewhere := childOf("Elsewhere", nil)
+	orphan := childOf("Orphan", &elsewhere)
+	orphanChild := childOf("OrphanChild", &orphan)
+	noParent := childOf("NoParent", nil)
+
+	// Fiction di bawah Epic membentuk siklus Fiction > Fantasy > Epic > Fiction
+	cycleRoot := fiction
+	cycleRoot.ParentID = &epic.ID
+
+	tests := []struct {
+		name       string
+		categories []models.BookCategory
+		want       string
+	}{
+		{"empty", nil, ""},
+		{"single", []models.BookCategory{fiction}, "Fiction"},
+		{"nested", []models.BookCategory{fiction, fantasy, scifi, epic}, "Fiction(Fantasy(Epic),SciFi)"},
+		{"root with a parent", []models.BookCategory{fantasy, epic}, "Fantasy(Epic)"},
+		{"orphans", []models.BookCategory{fiction, orphan, fantasy, orphanChild}, "Fiction(Fantasy)"},
+		{"child without parent", []models.BookCategory{fiction, noParent, scifi}, "Fiction(SciFi)"},
+		{"child before parent", []models.BookCategory{fiction, epic, fantasy}, "Fiction(Fantasy)"},
+		{"cycle", []models.BookCategory{cycleRoot, fantasy, epic, cycleRoot, fantasy}, "Fiction(Fantasy(Epic))"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := treeNames(buildCategoryTree(tt.categories)); got != tt.want {
+				t.Errorf("buildCategoryTree() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// Test MoveCategory: Kategori dan parent harus ada, dan pemindahan ke bawah
+// dirinya sendiri atau turunannya ditolak
+func TestMoveCategory(t *testing.T) {
+	fiction := childOf("Fiction", nil)
+	fantasy := childOf("Fantasy", &fiction)
+	missing := uuid.New()
+
+	tests := []struct {
+		name     string
+		id       uuid.UUID
+		parentID *uuid.UUID
+		want     error
+	}{
+		{"under another category", fantasy.ID, &fiction.ID, nil},
+		{"to the top level", fantasy.ID, nil, nil},
+		{"category not found", missing, &fiction.ID, ErrCategoryNotFound},
+		{"parent not found", fantasy.ID, &missing, ErrParentNotFound},
+		{"under itself", fiction.ID, &fiction.ID, ErrCategoryCycle},
+		{"under a descendant", fiction.ID, &fantasy.ID, ErrCategoryCycle},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			moved := false
+			repo := &MockBookCategoryRepository{
+				GetByIDFunc: categories(fiction, fantasy),
+				MoveFunc: func(ctx context.Context, id uuid.UUID, parentID *uuid.UUID) (*models.BookCategory, error) {
+					moved = true
+					// Repository menolak pemindahan yang membentuk siklus
+					if parentID != nil && (*parentID == id || (id == fiction.ID && *parentID == fantasy.ID)) {
+						return nil, nil
+					}
+					return &models.BookCategory{ID: id, ParentID: parentID}, nil
+				},
+			}
+			svc := NewBookCategoryService(repo, nil)
+
+			category, err := svc.MoveCategory(context.Background(), tt.id, tt.parentID)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("MoveCategory() error = %v, want %v", err, tt.want)
+			}
+			if tt.want == nil && category.ID != tt.id {
+				t.Errorf("expected the moved category back, got %+v", category)
+			}
+			if moved != (tt.want == nil || tt.want == ErrCategoryCycle) {
+				t.Errorf("repository Move called = %v", moved)
+			}
+		})
+	}
+}
+
+// Test CreateCategory: Slug dibuat dari nama dan diberi nomor pertama yang
+// belum dipakai
+func TestCreateCategory_GeneratedSlug(t *testing.T) {
+	allTaken := []string{"poetry"}
+	for n := 2; n < maxSlugAttempts; n++ {
+		allTaken = append(allTaken, fmt.Sprintf("poetry-%d", n))
+	}
+
+	tests := []struct {
+		name    string
+		taken   []string
+		want    string
+		wantErr error
+	}{
+		{"Science Fiction", nil, "science-fiction", nil},
+		{"  C++ & Go!  ", nil, "c-go", nil},
+		{"Sci--Fi", nil, "sci-fi", nil},
+		{"!!!", nil, "category", nil},
+		{"Poetry", []string{"poetry"}, "poetry-2", nil},
+		{"Poetry", []string{"poetry", "poetry-2", "poetry-3"}, "poetry-4", nil},
+		{"Poetry", []string{"poetry", "poetry-3"}, "poetry-2", nil},
+		{"Poetry", allTaken, "", ErrDuplicateSlug},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s with %d taken", tt.name, len(tt.taken)), func(t *testing.T) {
+			var created *models.BookCategory
+			repo := &MockBookCategoryRepository{
+				GetByNameFunc:      noCategory,
+				GetByAliasNameFunc: noCategory,
+				GetBySlugFunc:      slugsTaken(tt.taken...),
+				CreateFunc: func(ctx context.Context, category *models.BookCategory) (uuid.UUID, error) {
+					created = category
+					return uuid.New(), nil
+				},
+			}
+			svc := NewBookCategoryService(repo, nil)
+
+			res, err := svc.CreateCategory(context.Background(), dto.CreateBookCategoryRequest{Name: tt.name})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CreateCategory() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if created != nil {
+					t.Error("expected nothing to be created")
+				}
+				return
+			}
+			if res.Slug != tt.want || created.Slug != tt.want {
+				t.Errorf("slug = %q (saved %q), want %q", res.Slug, created.Slug, tt.want)
+			}
+		})
+	}
+}
+
+// Test CreateCategory dan UpdateCategory: Slug pilihan client harus valid dan
+// belum dipakai kategori lain
+func TestChosenSlug(t *testing.T) {
+	poetry := models.BookCategory{ID: uuid.New(), Name: "Poetry", Slug: "poetry"}
+
+	tests := []struct {
+		name string
+		slug string
+		want error
+	}{
+		{"free", "verse", nil},
+		{"own slug", "poetry", nil},
+		{"taken", "drama", ErrDuplicateSlug},
+		{"upper case", "Verse", ErrInvalidSlug},
+		{"space", "free verse", ErrInvalidSlug},
+		{"double dash", "free--verse", ErrInvalidSlug},
+		{"trailing dash", "verse-", ErrInvalidSlug},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &MockBookCategoryRepository{
+				GetByIDFunc:        categories(poetry),
+				GetByNameFunc:      noCategory,
+				GetByAliasNameFunc: noCategory,
+				GetBySlugFunc: func(ctx context.Context, slug string) (*models.BookCategory, error) {
+					switch slug {
+					case "poetry":
+						return &poetry, nil
+					case "drama":
+						return &models.BookCategory{ID: uuid.New(), Slug: slug}, nil
+					}
+					return nil, nil
+				},
+				CreateFunc: func(ctx context.Context, category *models.BookCategory) (uuid.UUID, error) {
+					return uuid.New(), nil
+				},
+				UpdateFunc: func(ctx context.Context, category *models.BookCategory) error {
+					return nil
+				},
+			}
+			svc := NewBookCategoryService(repo, nil)
+
+			slug := tt.slug
+			err := svc.UpdateCategory(context.Background(), poetry.ID, dto.UpdateBookCategoryRequest{Name: "Poetry", Slug: &slug})
+			if !errors.Is(err, tt.want) {
+				t.Errorf("UpdateCategory() error = %v, want %v", err, tt.want)
+			}
+
+			// Kategori baru tidak boleh memakai slug milik Poetry
+			want := tt.want
+			if tt.slug == "poetry" {
+				want = ErrDuplicateSlug
+			}
+			_, err = svc.CreateCategory(context.Background(), dto.CreateBookCategoryRequest{Name: "Verse", Slug: tt.slug})
+			if !errors.Is(err, want) {
+				t.Errorf("CreateCategory() error = %v, want %v", err, want)
+			}
+		})
+	}
+}
+
+// Test UpdateCategory: Rename tidak mengubah slug
+func TestUpdateCategory_RenameKeepsSlug(t *testing.T) {
+	poetry := models.BookCategory{ID: uuid.New(), Name: "Poetry", Slug: "poetry"}
+
+	var saved *models.BookCategory
+	repo := &MockBookCategoryRepository{
+		GetByIDFunc:        categories(poetry),
+		GetByNameFunc:      noCategory,
+		GetByAliasNameFunc: noCategory,
+		UpdateFunc: func(ctx context.Context, category *models.BookCategory) error {
+			saved = category
+			return nil
+		},
+	}
+	svc := NewBookCategoryService(repo, nil)
+
+	if err := svc.UpdateCategory(context.Background(), poetry.ID, dto.UpdateBookCategoryRequest{Name: "Poems"}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if saved.Name != "Poems" || saved.Slug != "poetry" {
+		t.Errorf("expected Poems to keep the slug poetry, got %q and %q", saved.Name, saved.Slug)
+	}
+}
+
+// Test DeleteCategory: Kategori yang masih dipakai buku hanya dihapus setelah
+// bukunya dipindahkan ke kategori lain
+func TestDeleteCategory(t *testing.T) {
+	poetry := childOf("Poetry", nil)
+	verse := childOf("Verse", nil)
+	parent := childOf("Parent", nil)
+	missing := uuid.New()
+
+	tests := []struct {
+		name           string
+		id             uuid.UUID
+		reassignTo     *uuid.UUID
+		books          int64
+		reassignErr    error
+		want           error
+		wantReassigned int64
+		wantDeleted    bool
+	}{
+		{"unused", poetry.ID, nil, 0, nil, nil, 0, true},
+		{"in use", poetry.ID, nil, 3, nil, ErrCategoryInUse, 0, false},
+		{"reassigned", poetry.ID, &verse.ID, 3, nil, nil, 3, true},
+		{"reassigned while unused", poetry.ID, &verse.ID, 0, nil, nil, 0, true},
+		{"not found", missing, nil, 0, nil, ErrCategoryNotFound, 0, false},
+		{"has subcategories", parent.ID, &verse.ID, 3, nil, ErrCategoryHasChildren, 0, false},
+		{"reassign to itself", poetry.ID, &poetry.ID, 3, nil, ErrReassignToSelf, 0, false},
+		{"reassign target not found", poetry.ID, &missing, 3, nil, ErrReassignNotFound, 0, false},
+		{"reassign failed", poetry.ID, &verse.ID, 3, errors.New("bookservice unavailable"), nil, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deleted := false
+			repo := &MockBookCategoryRepository{
+				GetByIDFunc: categories(poetry, verse, parent),
+				HasChildrenFunc: func(ctx context.Context, id uuid.UUID) (bool, error) {
+					return id == parent.ID, nil
+				},
+				DeleteFunc: func(ctx context.Context, id uuid.UUID) error {
+					deleted = true
+					return nil
+				},
+			}
+			books := &MockBookRepository{
+				CountByCategoryFunc: func(ctx context.Context, categoryID uuid.UUID) (int64, error) {
+					return tt.books, nil
+				},
+				ReassignCategoryFunc: func(ctx context.Context, fromID, toID uuid.UUID) (int64, error) {
+					if fromID != tt.id || toID != *tt.reassignTo {
+						t.Errorf("reassigned %s to %s, want %s to %s", fromID, toID, tt.id, *tt.reassignTo)
+					}
+					return tt.books, tt.reassignErr
+				},
+			}
+			svc := NewBookCategoryService(repo, books)
+
+			reassigned, err := svc.DeleteCategory(context.Background(), tt.id, tt.reassignTo)
+			switch {
+			case tt.reassignErr != nil:
+				if !errors.Is(err, tt.reassignErr) {
+					t.Fatalf("DeleteCategory() error = %v, want %v", err, tt.reassignErr)
+				}
+			case !errors.Is(err, tt.want):
+				t.Fatalf("DeleteCategory() error = %v, want %v", err, tt.want)
+			}
+			if reassigned != tt.wantReassigned {
+				t.Errorf("reassigned = %d, want %d", reassigned, tt.wantReassigned)
+			}
+			if deleted != tt.wantDeleted {
+				t.Errorf("deleted = %v, want %v", deleted, tt.wantDeleted)
+			}
+		})
+	}
+}
+
+// Test MergeCategory: Buku dipindahkan sebelum merge, dan merge ke dirinya
+// sendiri, ke subkategorinya atau dengan nama yang sudah menjadi alias ditolak
+func TestMergeCategory(t *testing.T) {
+	scifi := childOf("Sci-Fi", nil)
+	fiction := childOf("Science Fiction", nil)
+	below := childOf("Space Opera", &scifi)
+	aliased := childOf("Fantasy", nil)
+	missing := uuid.New()
+
+	tests := []struct {
+		name           string
+		source         uuid.UUID
+		target         uuid.UUID
+		mergeErr       error
+		want           error
+		wantReassigned bool
+	}{
+		{"merged", scifi.ID, fiction.ID, nil, nil, true},
+		{"into itself", scifi.ID, scifi.ID, nil, ErrMergeIntoSelf, false},
+		{"source not found", missing, fiction.ID, nil, ErrCategoryNotFound, false},
+		{"target not found", scifi.ID, missing, nil, ErrMergeTargetNotFound, false},
+		{"into a subcategory", scifi.ID, below.ID, nil, ErrMergeIntoSelf, true},
+		{"name already an alias", aliased.ID, fiction.ID, nil, ErrMergeNameTaken, false},
+		{"alias taken meanwhile", scifi.ID, fiction.ID, models.ErrDuplicateAlias, ErrMergeNameTaken, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reassigned := false
+			repo := &MockBookCategoryRepository{
+				GetByIDFunc: categories(scifi, fiction, below, aliased),
+				GetByAliasNameFunc: func(ctx context.Context, name string) (*models.BookCategory, error) {
+					// Kategori Fantasy lain pernah di-merge ke Science Fiction
+					if name == aliased.Name {
+						return &fiction, nil
+					}
+					return nil, nil
+				},
+				MergeFunc: func(ctx context.Context, sourceID, targetID uuid.UUID) (*models.BookCategory, error) {
+					if !reassigned {
+						t.Error("expected books to be reassigned before the merge")
+					}
+					if tt.mergeErr != nil {
+						return nil, tt.mergeErr
+					}
+					if targetID == below.ID {
+						return nil, nil
+					}
+					return &scifi, nil
+				},
+			}
+			books := &MockBookRepository{
+				ReassignCategoryFunc: func(ctx context.Context, fromID, toID uuid.UUID) (int64, error) {
+					reassigned = true
+					return 4, nil
+				},
+			}
+			svc := NewBookCategoryService(repo, books)
+
+			res, err := svc.MergeCategory(context.Background(), tt.source, tt.target)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("MergeCategory() error = %v, want %v", err, tt.want)
+			}
+			if reassigned != tt.wantReassigned {
+				t.Errorf("books reassigned = %v, want %v", reassigned, tt.wantReassigned)
+			}
+			if tt.want == nil && (res.Category.ID != fiction.ID || res.ReassignedBooks != 4) {
+				t.Errorf("expected Science Fiction with 4 reassigned books, got %+v", res)
+			}
+		})
+	}
+}
+
+// Test UpdateCategory: Rename ke nama kategori lain atau alias dari merge
+// ditolak
+func TestUpdateCategory_RenameConflicts(t *testing.T) {
+	poetry := models.BookCategory{ID: uuid.New(), Name: "Poetry", Slug: "poetry"}
+	drama := models.BookCategory{ID: uuid.New(), Name: "Drama", Slug: "drama"}
+
+	tests := []struct {
+		name      string
+		rename    string
+		updateErr error
+		want      error
+	}{
+		{"free name", "Poems", nil, nil},
+		{"same name", "Poetry", nil, nil},
+		{"name of another category", "Drama", nil, ErrDuplicateCategory},
+		{"alias of a merged category", "Verse", nil, ErrDuplicateCategory},
+		{"name taken meanwhile", "Poems", models.ErrDuplicateName, ErrDuplicateCategory},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated := false
+			repo := &MockBookCategoryRepository{
+				GetByIDFunc: categories(poetry, drama),
+				GetByNameFunc: func(ctx context.Context, name string) (*models.BookCategory, error) {
+					for _, category := range []models.BookCategory{poetry, drama} {
+						if category.Name == name {
+							return &category, nil
+						}
+					}
+					return nil, nil
+				},
+				GetByAliasNameFunc: func(ctx context.Context, name string) (*models.BookCategory, error) {
+					if name == "Verse" {
+						return &poetry, nil
+					}
+					return nil, nil
+				},
+				UpdateFunc: func(ctx context.Context, category *models.BookCategory) error {
+					updated = true
+					return tt.updateErr
+				},
+			}
+			svc := NewBookCategoryService(repo, nil)
+
+			err := svc.UpdateCategory(context.Background(), poetry.ID, dto.UpdateBookCategoryRequest{Name: tt.rename})
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("UpdateCategory() error = %v, want %v", err, tt.want)
+			}
+			if updated != (tt.want == nil || tt.updateErr != nil) {
+				t.Errorf("repository Update called = %v", updated)
+			}
+		})
+	}
+}
@@ -1,20 +1,42 @@
 package setup
 
 import (
+	"context"
 	"database/sql"
+	"log"
 
+	"github.com/lib/pq"
+
+	"github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/config"
 	"github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/internal/repository"
 	"github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/internal/server"
 	"github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/internal/service"
+	authpb "github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/proto/auth"
+	bookpb "github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/proto/book"
 	pb "github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/proto/category"
+	"github.com/sir-shalahuddin/grpc-learn/pkg/cache"
 	"google.golang.org/grpc"
 )
 
-func GRPCServer(grpc *grpc.Server, db *sql.DB) {
+func GRPCServer(grpc *grpc.Server, db *sql.DB, listener *pq.Listener, authSvc authpb.AuthServiceClient, bookSvc bookpb.BookServiceClient, jwtSecret string, authCache cache.Cache, cacheConfig config.CacheConfig) {
 	// Initialize repositories, services, and servers
 	categoryRepo := repository.NewBookCategoryRepository(db)
-	categoryService := service.NewBookCategoryService(categoryRepo)
-	categoryServer := server.NewBookCategoryGRPCServer(categoryService)
+	bookRepo := repository.NewBookRepository(bookSvc)
+	categoryService := service.NewBookCategoryService(categoryRepo, bookRepo)
+
+	categoryEventRepo := repository.NewBookCategoryEventRepository(listener)
+	categoryWatcher := service.NewBookCategoryWatcher(categoryEventRepo, categoryRepo)
+	if err := categoryWatcher.Run(context.Background()); err != nil {
+		log.Fatalf("failed to watch category events: %v", err)
+	}
+
+	authRepo := repository.NewAuthRepository(authSvc, authCache, cacheConfig.TTL, cacheConfig.NegativeTTL, cacheConfig.MemoryTokenTTL)
+	authService := service.NewAuthService(authRepo, jwtSecret)
+	if redisCache, ok := authCache.(*cache.Redis); ok {
+		redisCache.Subscribe(context.Background(), repository.RevocationChannel, authRepo.HandleRevocation)
+	}
+
+	categoryServer := server.NewBookCategoryGRPCServer(categoryService, categoryWatcher, authService)
 
 	// Register AuthService routes
 	pb.RegisterBookCategoryServiceServer(grpc, categoryServer)
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS book_category_changes;
\ No newline at end of file
@@ -0,0 +1,7 @@
+CREATE TABLE book_category_changes (
+    revision BIGSERIAL PRIMARY KEY,
+    change_type VARCHAR(16) NOT NULL,
+    category_id UUID NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    changed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
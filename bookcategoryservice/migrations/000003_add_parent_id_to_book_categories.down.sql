@@ -0,0 +1,5 @@
+ALTER TABLE book_category_changes DROP COLUMN IF EXISTS parent_id;
+
+DROP INDEX IF EXISTS idx_book_categories_parent_id;
+
+ALTER TABLE book_categories DROP COLUMN IF EXISTS parent_id;
\ No newline at end of file
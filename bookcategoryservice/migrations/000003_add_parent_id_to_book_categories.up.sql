@@ -0,0 +1,5 @@
+ALTER TABLE book_categories ADD COLUMN parent_id UUID REFERENCES book_categories(id) ON DELETE RESTRICT;
+
+CREATE INDEX idx_book_categories_parent_id ON book_categories (parent_id);
+
+ALTER TABLE book_category_changes ADD COLUMN parent_id UUID;
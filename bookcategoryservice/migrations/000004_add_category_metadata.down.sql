@@ -0,0 +1,11 @@
+ALTER TABLE book_category_changes
+    DROP COLUMN IF EXISTS archived,
+    DROP COLUMN IF EXISTS slug;
+
+DROP TABLE IF EXISTS book_category_translations;
+
+ALTER TABLE book_categories
+    DROP COLUMN IF EXISTS archived,
+    DROP COLUMN IF EXISTS sort_order,
+    DROP COLUMN IF EXISTS description,
+    DROP COLUMN IF EXISTS slug;
\ No newline at end of file
@@ -0,0 +1,55 @@
+ALTER TABLE book_categories
+    ADD COLUMN slug VARCHAR(255),
+    ADD COLUMN description TEXT NOT NULL DEFAULT '',
+    ADD COLUMN sort_order INT NOT NULL DEFAULT 0,
+    ADD COLUMN archived BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Backfill slugs from names the way the service generates them. The first
+-- category of each slug gets it as is; the rest take the first free numbered
+-- suffix, skipping slugs already taken (a name like "Poetry 2" slugifies to
+-- what a second "Poetry!" would be numbered as).
+UPDATE book_categories c
+SET slug = b.base
+FROM (
+    SELECT DISTINCT ON (base) id, base
+    FROM (
+        SELECT id, COALESCE(NULLIF(trim(both '-' FROM lower(regexp_replace(name, '[^a-zA-Z0-9]+', '-', 'g'))), ''), 'category') AS base
+        FROM book_categories
+    ) s
+    ORDER BY base, id
+) b
+WHERE c.id = b.id;
+
+DO $$
+DECLARE
+    category RECORD;
+    base TEXT;
+    candidate TEXT;
+    n INT;
+BEGIN
+    FOR category IN SELECT id, name FROM book_categories WHERE slug IS NULL ORDER BY id LOOP
+        base := COALESCE(NULLIF(trim(both '-' FROM lower(regexp_replace(category.name, '[^a-zA-Z0-9]+', '-', 'g'))), ''), 'category');
+        n := 2;
+        candidate := base || '-' || n;
+        WHILE EXISTS (SELECT 1 FROM book_categories WHERE slug = candidate) LOOP
+            n := n + 1;
+            candidate := base || '-' || n;
+        END LOOP;
+        UPDATE book_categories SET slug = candidate WHERE id = category.id;
+    END LOOP;
+END $$;
+
+ALTER TABLE book_categories
+    ALTER COLUMN slug SET NOT NULL,
+    ADD CONSTRAINT book_categories_slug_key UNIQUE (slug);
+
+CREATE TABLE book_category_translations (
+    category_id UUID REFERENCES book_categories(id) ON DELETE CASCADE,
+    locale VARCHAR(35) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    PRIMARY KEY (category_id, locale)
+);
+
+ALTER TABLE book_category_changes
+    ADD COLUMN slug VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN archived BOOLEAN NOT NULL DEFAULT FALSE;
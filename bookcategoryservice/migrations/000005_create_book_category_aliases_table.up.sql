@@ -0,0 +1,8 @@
+CREATE TABLE book_category_aliases (
+    alias_id UUID PRIMARY KEY,
+    alias_name VARCHAR(255) NOT NULL UNIQUE,
+    target_id UUID NOT NULL REFERENCES book_categories(id) ON DELETE CASCADE,
+    merged_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_book_category_aliases_target_id ON book_category_aliases (target_id);
@@ -1,8 +1,78 @@
 package models
 
-import "github.com/google/uuid"
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrDuplicateName is returned when a category is saved with the name of
+	// another category.
+	ErrDuplicateName = errors.New("another category has this name")
+	// ErrDuplicateAlias is returned when a category is merged away under a
+	// name an earlier merge already left behind.
+	ErrDuplicateAlias = errors.New("a merged category already goes by this name")
+)
 
 type BookCategory struct {
-	ID   uuid.UUID `db:"id"`
-	Name string    `db:"name"`
+	ID          uuid.UUID  `db:"id"`
+	Name        string     `db:"name"`
+	ParentID    *uuid.UUID `db:"parent_id"`
+	Slug        string     `db:"slug"`
+	Description string     `db:"description"`
+	SortOrder   int        `db:"sort_order"`
+	Archived    bool       `db:"archived"`
+	// Names holds the translated names keyed by lower case locale, e.g. "id"
+	// or "pt-br".
+	Names map[string]string `db:"-"`
+}
+
+// Localize replaces Name with the translation for the first of locales that
+// has one, trying "pt" after "pt-br". Name is kept when nothing matches.
+func (c *BookCategory) Localize(locales []string) {
+	for _, locale := range locales {
+		locale = strings.ToLower(locale)
+		if name, ok := c.Names[locale]; ok {
+			c.Name = name
+			return
+		}
+		if base, _, found := strings.Cut(locale, "-"); found {
+			if name, ok := c.Names[base]; ok {
+				c.Name = name
+				return
+			}
+		}
+	}
+}
+
+// Localize applies BookCategory.Localize to the node and every descendant.
+func (n *BookCategoryNode) Localize(locales []string) {
+	n.BookCategory.Localize(locales)
+	for _, child := range n.Children {
+		child.Localize(locales)
+	}
+}
+
+// BookCategoryNode is a category together with everything below it.
+type BookCategoryNode struct {
+	BookCategory
+	Children []*BookCategoryNode
+}
+
+const (
+	CategoryCreated = "created"
+	CategoryUpdated = "updated"
+	CategoryDeleted = "deleted"
+)
+
+// BookCategoryEvent describes a change to a single category. Revisions
+// increase with every change and are never reused.
+type BookCategoryEvent struct {
+	Revision  int64        `db:"revision"`
+	Type      string       `db:"change_type"`
+	Category  BookCategory `db:"category"`
+	ChangedAt time.Time    `db:"changed_at"`
 }
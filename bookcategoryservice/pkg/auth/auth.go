@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"strings"
 
 	"github.com/golang-jwt/jwt"
 	"github.com/google/uuid"
@@ -11,6 +12,14 @@ import (
 
 var ErrInvalidToken = errors.New("invalid or expired token")
 
+// APIKeyPrefix marks a bearer token as a userservice API key rather than a JWT.
+const APIKeyPrefix = "lib_"
+
+// IsAPIKey reports whether the token looks like an API key.
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, APIKeyPrefix)
+}
+
 func ValidateToken(tokenStr, jwtSecret string) (uuid.UUID, error) {
 	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
 		// Validate the signing method and return the secret
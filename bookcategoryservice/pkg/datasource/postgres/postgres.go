@@ -7,32 +7,14 @@ import (
 	"strconv"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"github.com/sir-shalahuddin/grpc-learn/bookcategoryservice/config"
 )
 
 func NewDB(config config.DBConfig) (*sql.DB, error) {
-	port, err := strconv.ParseUint(config.Port, 10, 32)
+	DB_URI, err := dataSourceName(config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse database port: %w", err)
-	}
-
-	var DB_URI string
-	if config.UseUnixSocket {
-		DB_URI = fmt.Sprintf(
-			"user=%s password=%s dbname=%s sslmode=disable host=/cloudsql/%s",
-			config.User,
-			config.Pass,
-			config.Name,
-			config.InstanceConnectionName)
-	} else {
-		DB_URI = fmt.Sprintf(
-			"host=%s port=%d user=%s password=%s dbname=%s sslmode=required",
-			config.Host,
-			port,
-			config.User,
-			config.Pass,
-			config.Name)
+		return nil, err
 	}
 
 	db, err := sql.Open("postgres", DB_URI)
@@ -59,3 +41,47 @@ func NewDB(config config.DBConfig) (*sql.DB, error) {
 
 	return db, nil
 }
+
+// NewListener opens a dedicated connection for LISTEN/NOTIFY. It connects in
+// the background and reconnects on its own when the connection drops.
+func NewListener(config config.DBConfig) (*pq.Listener, error) {
+	DB_URI, err := dataSourceName(config)
+	if err != nil {
+		return nil, err
+	}
+
+	listener := pq.NewListener(DB_URI, 10*time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("Database listener error: %v", err)
+		}
+	})
+
+	return listener, nil
+}
+
+func dataSourceName(config config.DBConfig) (string, error) {
+	port, err := strconv.ParseUint(config.Port, 10, 32)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse database port: %w", err)
+	}
+
+	var DB_URI string
+	if config.UseUnixSocket {
+		DB_URI = fmt.Sprintf(
+			"user=%s password=%s dbname=%s sslmode=disable host=/cloudsql/%s",
+			config.User,
+			config.Pass,
+			config.Name,
+			config.InstanceConnectionName)
+	} else {
+		DB_URI = fmt.Sprintf(
+			"host=%s port=%d user=%s password=%s dbname=%s sslmode=required",
+			config.Host,
+			port,
+			config.User,
+			config.Pass,
+			config.Name)
+	}
+
+	return DB_URI, nil
+}
@@ -0,0 +1,46 @@
+// Package locale reads the locales a client asked for.
+package locale
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ParseAcceptLanguage returns the locales of an Accept-Language header, most
+// preferred first and lower cased. Wildcards and locales with q=0 are left out.
+func ParseAcceptLanguage(header string) []string {
+	type weighted struct {
+		locale string
+		q      float64
+	}
+
+	var parsed []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if v, err := strconv.ParseFloat(value, 64); err == nil {
+				q = v
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+		parsed = append(parsed, weighted{locale: tag, q: q})
+	}
+
+	// Stable keeps the header order between equal weights
+	sort.SliceStable(parsed, func(i, j int) bool { return parsed[i].q > parsed[j].q })
+
+	locales := make([]string, 0, len(parsed))
+	for _, p := range parsed {
+		locales = append(locales, p.locale)
+	}
+	return locales
+}
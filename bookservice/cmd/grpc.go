@@ -0,0 +1,38 @@
+package main
+
+import (
+	"database/sql"
+	"log"
+	"net"
+
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/config"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/internal/server"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/internal/setup"
+	pb "github.com/sir-shalahuddin/grpc-learn/bookservice/proto/categoryservice"
+	"google.golang.org/grpc"
+)
+
+// StartGRPCServer initializes and starts the gRPC server
+func StartGRPCServer(db *sql.DB, ctgSvc pb.BookCategoryServiceClient, cacheConfig config.CacheConfig, serviceToken string, port string) {
+
+	lis, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		log.Fatalf("failed to listen: %v", err)
+	}
+
+	if serviceToken == "" {
+		log.Printf("GRPC_SERVICE_TOKEN is not set, calls meant for other services will be refused")
+	}
+
+	// Create a new gRPC server
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(server.ServiceTokenInterceptor(serviceToken)))
+
+	// Register BookService routes
+	setup.GRPCServer(grpcServer, db, ctgSvc, cacheConfig)
+	log.Printf("gRPC server listening on %s", ":"+port)
+
+	// Start the gRPC server
+	if err := grpcServer.Serve(lis); err != nil {
+		log.Fatalf("failed to serve: %v", err)
+	}
+}
@@ -2,11 +2,16 @@ package main
 
 import (
 	"log"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/sir-shalahuddin/grpc-learn/bookservice/config"
+	blobclient "github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/datasource/blob"
 	grpcclient "github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/datasource/grpc"
+	metadataclient "github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/datasource/metadata"
 	db "github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/datasource/postgres"
+	redisclient "github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/datasource/redis"
 	_ "github.com/sir-shalahuddin/grpc-learn/bookservice/docs"
 )
 
@@ -35,8 +40,9 @@ func main() {
 	}
 
 	AppConfig := config.AppConfig{
-		// GRPCPort: config.GetEnv("GRPC_PORT"),
+		GRPCPort: config.GetEnv("GRPC_PORT"),
 		RESTPort: config.GetEnv("REST_PORT"),
+		Mode:     config.GetEnvOrDefault("SERVER_MODE", "rest"),
 	}
 	DBConfig := config.DBConfig{
 		Host:                   config.GetEnv("DB_HOST"),
@@ -51,6 +57,54 @@ func main() {
 	GRPCConfig := config.GRPCConfig{
 		AuthAddress:     config.GetEnv("AUTH_ADDRESS"),
 		CategoryAddress: config.GetEnv("CTG_ADDRESS"),
+		ServiceToken:    config.GetEnv("GRPC_SERVICE_TOKEN"),
+	}
+
+	CacheConfig := config.CacheConfig{
+		Backend:        config.GetEnvOrDefault("AUTH_CACHE_BACKEND", "memory"),
+		Size:           config.GetEnvAsInt("AUTH_CACHE_SIZE", 10000),
+		TTL:            config.GetEnvAsDuration("AUTH_CACHE_TTL", 30*time.Second),
+		NegativeTTL:    config.GetEnvAsDuration("AUTH_CACHE_NEGATIVE_TTL", 5*time.Second),
+		MemoryTokenTTL: config.GetEnvAsDuration("AUTH_CACHE_MEMORY_TOKEN_TTL", 5*time.Second),
+		RedisAddress:   config.GetEnvOrDefault("REDIS_ADDR", "localhost:6379"),
+		RedisPassword:  config.GetEnvOrDefault("REDIS_PASSWORD", ""),
+		CategoryTTL:    config.GetEnvAsDuration("CATEGORY_CACHE_TTL", 5*time.Minute),
+	}
+
+	MetadataConfig := config.MetadataConfig{
+		Providers:      config.GetEnvOrDefault("METADATA_PROVIDERS", ""),
+		OpenLibraryURL: config.GetEnvOrDefault("OPENLIBRARY_URL", "https://openlibrary.org"),
+		File:           config.GetEnvOrDefault("METADATA_FILE", ""),
+		Timeout:        config.GetEnvAsDuration("METADATA_TIMEOUT", 5*time.Second),
+		CacheSize:      config.GetEnvAsInt("METADATA_CACHE_SIZE", 1000),
+		CacheTTL:       config.GetEnvAsDuration("METADATA_CACHE_TTL", 24*time.Hour),
+		NegativeTTL:    config.GetEnvAsDuration("METADATA_CACHE_NEGATIVE_TTL", time.Hour),
+	}
+
+	CoverConfig := config.CoverConfig{
+		Storage:     config.GetEnvOrDefault("COVER_STORAGE", "local"),
+		Dir:         config.GetEnvOrDefault("COVER_DIR", "./covers"),
+		MaxSize:     int64(config.GetEnvAsInt("COVER_MAX_SIZE", 2<<20)),
+		S3Endpoint:  config.GetEnvOrDefault("S3_ENDPOINT", ""),
+		S3Region:    config.GetEnvOrDefault("S3_REGION", "us-east-1"),
+		S3Bucket:    config.GetEnvOrDefault("S3_BUCKET", ""),
+		S3AccessKey: config.GetEnvOrDefault("S3_ACCESS_KEY", ""),
+		S3SecretKey: config.GetEnvOrDefault("S3_SECRET_KEY", ""),
+		Timeout:     config.GetEnvAsDuration("COVER_TIMEOUT", 10*time.Second),
+	}
+
+	RecommendationConfig := config.RecommendationConfig{
+		Interval:       config.GetEnvAsDuration("RECOMMEND_INTERVAL", 6*time.Hour),
+		Size:           config.GetEnvAsInt("RECOMMEND_SIZE", 20),
+		MinCoBorrowers: config.GetEnvAsInt("RECOMMEND_MIN_CO_BORROWERS", 2),
+	}
+
+	ImportConfig := config.ImportConfig{
+		MaxSize: int64(config.GetEnvAsInt("IMPORT_MAX_SIZE", 4<<20)),
+	}
+
+	ReportConfig := config.ReportConfig{
+		RefreshInterval: config.GetEnvAsDuration("REPORT_REFRESH_INTERVAL", time.Hour),
 	}
 
 	db, err := db.NewDB(DBConfig)
@@ -68,7 +122,30 @@ func main() {
 		panic(err)
 	}
 
+	mode := strings.ToLower(AppConfig.Mode)
+	if mode != "rest" && mode != "grpc" {
+		log.Fatalf("unknown SERVER_MODE %q, use rest or grpc", AppConfig.Mode)
+	}
+
 	// Start REST server in a separate goroutine
-	StartRESTServer(db, authClients, categoryClients, AppConfig.RESTPort)
+	if mode == "rest" {
+		authCache, err := redisclient.NewAuthCache(CacheConfig)
+		if err != nil {
+			panic(err)
+		}
+		metadataProvider, err := metadataclient.NewProvider(MetadataConfig)
+		if err != nil {
+			panic(err)
+		}
+		coverStore, err := blobclient.NewCoverStore(CoverConfig)
+		if err != nil {
+			panic(err)
+		}
+		StartRESTServer(db, authClients, categoryClients, authCache, CacheConfig, metadataProvider, MetadataConfig, coverStore, CoverConfig, RecommendationConfig, ReportConfig, ImportConfig, AppConfig.RESTPort)
+	}
 
+	// Start gRPC server in a separate goroutine
+	if mode == "grpc" {
+		StartGRPCServer(db, categoryClients, CacheConfig, GRPCConfig.ServiceToken, AppConfig.GRPCPort)
+	}
 }
@@ -6,17 +6,21 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/config"
 	router "github.com/sir-shalahuddin/grpc-learn/bookservice/internal/routes"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/blob"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/metadata"
 	"github.com/sir-shalahuddin/grpc-learn/bookservice/proto/authservice"
 	pb "github.com/sir-shalahuddin/grpc-learn/bookservice/proto/categoryservice"
+	"github.com/sir-shalahuddin/grpc-learn/pkg/cache"
 )
 
-func StartRESTServer(db *sql.DB, authSvc authservice.AuthServiceClient, ctgSvc pb.BookCategoryServiceClient, port string) {
+func StartRESTServer(db *sql.DB, authSvc authservice.AuthServiceClient, ctgSvc pb.BookCategoryServiceClient, authCache cache.Cache, cacheConfig config.CacheConfig, metadataProvider metadata.Provider, metadataConfig config.MetadataConfig, coverStore blob.Store, coverConfig config.CoverConfig, recommendationConfig config.RecommendationConfig, reportConfig config.ReportConfig, importConfig config.ImportConfig, port string) {
 	app := fiber.New()
 
 	app.Use(cors.New())
 
-	router.RegisterRoutes(app, db, authSvc, ctgSvc)
+	router.RegisterRoutes(app, db, authSvc, ctgSvc, authCache, cacheConfig, metadataProvider, metadataConfig, coverStore, coverConfig, recommendationConfig, reportConfig, importConfig)
 	err := (app.Listen(":" + port))
 	if err != nil {
 		log.Fatalf("failed to start REST server: %v", err)
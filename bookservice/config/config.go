@@ -3,7 +3,9 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type AppConfig struct {
@@ -29,6 +31,54 @@ type JWTConfig struct {
 type GRPCConfig struct {
 	AuthAddress     string
 	CategoryAddress string
+	// ServiceToken is the secret other services send to call the gRPC
+	// methods meant only for them
+	ServiceToken string
+}
+
+type CacheConfig struct {
+	Backend        string
+	Size           int
+	TTL            time.Duration
+	NegativeTTL    time.Duration
+	MemoryTokenTTL time.Duration
+	RedisAddress   string
+	RedisPassword  string
+	CategoryTTL    time.Duration
+}
+
+// MetadataConfig configures book metadata lookups by ISBN. Providers is a
+// comma separated list of "openlibrary" and "file", asked in order; lookups
+// are off unless some are named, so no ISBN leaves the service by default.
+type MetadataConfig struct {
+	Providers      string
+	OpenLibraryURL string
+	File           string
+	Timeout        time.Duration
+	CacheSize      int
+	CacheTTL       time.Duration
+	NegativeTTL    time.Duration
+}
+
+// CoverConfig configures where book covers are stored. Storage is "local",
+// keeping them below Dir, or "s3" for an S3-compatible bucket such as MinIO.
+// Uploads larger than MaxSize bytes are refused.
+type CoverConfig struct {
+	Storage     string
+	Dir         string
+	MaxSize     int64
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	Timeout     time.Duration
+}
+
+// ImportConfig limits catalogue file uploads to MaxSize bytes. Fiber refuses
+// request bodies over 4 MB before that.
+type ImportConfig struct {
+	MaxSize int64
 }
 
 func GetEnv(key string) string {
@@ -49,3 +99,51 @@ func GetEnvAsBool(key string) bool {
 	}
 	return false
 }
+
+func GetEnvOrDefault(key, fallback string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+func GetEnvAsInt(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Fatalf("%s must be a number: %v", key, err)
+	}
+	return n
+}
+
+func GetEnvAsDuration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("%s must be a duration such as 30s: %v", key, err)
+	}
+	return d
+}
+
+// RecommendationConfig configures the recommendations recomputed from the
+// borrowing history every Interval, or only on request when it is zero. Size
+// books are kept per book and per patron, and two books count as similar once
+// MinCoBorrowers patrons borrowed both.
+type RecommendationConfig struct {
+	Interval       time.Duration
+	Size           int
+	MinCoBorrowers int
+}
+
+// ReportConfig configures the circulation reports, whose materialized views
+// are refreshed every RefreshInterval, or never when it is zero.
+type ReportConfig struct {
+	RefreshInterval time.Duration
+}
@@ -0,0 +1,32 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/models"
+)
+
+// ListAuthorsRequest selects the authors whose name contains Name. Cursor is
+// the NextCursor of the previous page.
+type ListAuthorsRequest struct {
+	Name     string
+	Cursor   string
+	PageSize int
+}
+
+// ListAuthorsResponse is a page of authors. NextCursor is empty on the last
+// page.
+type ListAuthorsResponse struct {
+	Authors    []models.Author `json:"authors"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+type MergeAuthorRequest struct {
+	TargetID uuid.UUID `json:"target_id"`
+}
+
+// MergeAuthorResponse is the author a duplicate was merged into and how many
+// books were credited to it.
+type MergeAuthorResponse struct {
+	Author     models.Author `json:"author"`
+	BooksMoved int64         `json:"books_moved"`
+}
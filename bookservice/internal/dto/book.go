@@ -4,15 +4,38 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/models"
 )
 
+// AddBookRequest is a new book. With FillFromLookup, the title, author and
+// published date left empty are filled in from the metadata of its ISBN.
+// Contributors credit several people in their roles; without them the book
+// is credited to Author alone.
 type AddBookRequest struct {
-	Title         string     `json:"title" validate:"required"`
-	Author        string     `json:"author" validate:"required"`
-	ISBN          string     `json:"isbn"`
-	PublishedDate *time.Time `json:"published_date"`
-	CategoryID    uuid.UUID  `json:"category_id"`
-	Stock         int        `json:"stock"`
+	Title          string               `json:"title"`
+	Author         string               `json:"author"`
+	ISBN           string               `json:"isbn"`
+	PublishedDate  *time.Time           `json:"published_date"`
+	CategoryID     uuid.UUID            `json:"category_id"`
+	Stock          int                  `json:"stock"`
+	FillFromLookup bool                 `json:"fill_from_lookup,omitempty"`
+	Contributors   []ContributorRequest `json:"contributors,omitempty"`
+}
+
+// ContributorRequest credits a book to an existing author by AuthorID or to
+// anyone by Name, who is added as an author when not known yet. Role is
+// author (the default), editor, translator or illustrator.
+type ContributorRequest struct {
+	AuthorID uuid.UUID `json:"author_id,omitempty"`
+	Name     string    `json:"name,omitempty"`
+	Role     string    `json:"role,omitempty"`
+}
+
+// LookupBookResponse is a new book prefilled from the metadata of its ISBN
+// and the provider the details came from.
+type LookupBookResponse struct {
+	Draft  AddBookRequest `json:"draft"`
+	Source string         `json:"source"`
 }
 
 type GetBookResponse struct {
@@ -20,16 +43,121 @@ type GetBookResponse struct {
 	Title         string     `json:"title"`
 	Author        string     `json:"author"`
 	ISBN          string     `json:"isbn"`
+	ISBN13        string     `json:"isbn13,omitempty"`
 	PublishedDate *time.Time `json:"published_date"`
 	Category      string     `json:"category"`
 	Stock         int        `json:"stock"`
+	// Everyone credited on the book; Author holds the names of its authors
+	Contributors []models.BookContributor `json:"contributors,omitempty"`
+	Cover        *CoverURLs               `json:"cover,omitempty"` // nil when the book has no cover
+	Rating       BookRating               `json:"rating"`
+	// Only set when searching with a query
+	Rank      float32               `json:"rank,omitempty"`
+	Highlight *models.BookHighlight `json:"highlight,omitempty"`
+}
+
+// BookRating is the average rating of a book over Count reviews, hidden
+// ones left out. Books without reviews have an average of 0.
+type BookRating struct {
+	Average float64 `json:"average"`
+	Count   int     `json:"count"`
+}
+
+// ListBooksRequest holds the filters of a book listing. Q is a full-text
+// search over title, author and ISBN that orders results by relevance. With
+// IncludeSubcategories, Category also matches every category below it. Nil
+// pointers leave their filter out. Cursor is the NextCursor of the previous
+// page.
+type ListBooksRequest struct {
+	Title                string
+	Author               string
+	AuthorID             uuid.UUID
+	AuthorRole           string
+	Q                    string
+	Category             string
+	IncludeSubcategories bool
+	Available            *bool
+	PublishedFrom        *time.Time
+	PublishedTo          *time.Time
+	HasISBN              *bool
+	Sort                 string
+	Cursor               string
+	PageSize             int
+}
+
+// ListBooksResponse is a page of books with the total and facet counts over
+// every book matching the filters. NextCursor is empty on the last page.
+type ListBooksResponse struct {
+	Books      []*GetBookResponse `json:"books"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+	Total      int64              `json:"total"`
+	Facets     BookFacets         `json:"facets"`
+}
+
+type BookFacets struct {
+	Categories   []CategoryFacet   `json:"categories"`
+	Availability AvailabilityFacet `json:"availability"`
+}
+
+type CategoryFacet struct {
+	ID    uuid.UUID `json:"id"`
+	Name  string    `json:"name"`
+	Count int64     `json:"count"`
 }
 
+type AvailabilityFacet struct {
+	Available   int64 `json:"available"`
+	Unavailable int64 `json:"unavailable"`
+}
+
+// UpdateBookRequest replaces the details of a book. Contributors replace
+// everyone credited on it when set; otherwise a changed Author credits the
+// book to that single author.
 type UpdateBookRequest struct {
-	Title         string     `json:"title"`
-	Author        string     `json:"author"`
-	ISBN          string     `json:"isbn"`
-	PublishedDate *time.Time `json:"published_date"`
-	CategoryID    uuid.UUID  `json:"category_id"`
-	Stock         int        `json:"stock"`
+	Title         string               `json:"title"`
+	Author        string               `json:"author"`
+	ISBN          string               `json:"isbn"`
+	PublishedDate *time.Time           `json:"published_date"`
+	CategoryID    uuid.UUID            `json:"category_id"`
+	Stock         int                  `json:"stock"`
+	Contributors  []ContributorRequest `json:"contributors,omitempty"`
+}
+
+// StartImportRequest is a catalogue file to import. Format is csv, jsonl or
+// marc, taken from the extension of Filename when empty.
+type StartImportRequest struct {
+	Format   string
+	Filename string
+	DryRun   bool
+	Data     []byte
+}
+
+// ListImportRowsRequest selects the rows of an import. Status is created,
+// valid, invalid or duplicate, or empty for every row. Cursor is the
+// NextCursor of the previous page.
+type ListImportRowsRequest struct {
+	Status   string
+	Cursor   string
+	PageSize int
+}
+
+// ListImportRowsResponse is a page of the rows of an import. NextCursor is
+// empty on the last page.
+type ListImportRowsResponse struct {
+	Rows       []models.BookImportRow `json:"rows"`
+	NextCursor string                 `json:"next_cursor,omitempty"`
+}
+
+// CoverURLs are the URLs of a book cover in each size, relative to this
+// service.
+type CoverURLs struct {
+	Small    string `json:"small"`
+	Medium   string `json:"medium"`
+	Large    string `json:"large"`
+	Original string `json:"original"`
+}
+
+// RecommendationsResponse holds recommended books, best first.
+type RecommendationsResponse struct {
+	Books []models.RecommendedBook `json:"books"`
 }
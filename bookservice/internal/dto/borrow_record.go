@@ -2,8 +2,55 @@ package dto
 
 import (
 	"time"
+
+	"github.com/google/uuid"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/models"
 )
 
 type BorrowBookRequest struct {
 	DueDate *time.Time `json:"due_date"`
 }
+
+// ListBorrowingRecordsRequest holds the filters of a borrowing record
+// listing. Status is "borrowed", "returned" or "overdue" and Order sorts by
+// borrowing time, "asc" or "desc" (the default). Cursor is the NextCursor of
+// the previous page.
+type ListBorrowingRecordsRequest struct {
+	Title        string
+	Status       string
+	Order        string
+	Cursor       string
+	PageSize     int
+	IncludeTotal bool
+}
+
+// SearchRecordsRequest holds the filters of a staff search across the
+// borrowing records of every patron. Zero IDs match any book or user, Status
+// is "borrowed", "returned" or "overdue" and each date range includes both
+// days. Records are sorted by Sort, "borrowed_at" (the default) or
+// "due_date", in Order, "asc" or "desc" (the default).
+type SearchRecordsRequest struct {
+	BookID       uuid.UUID
+	UserID       uuid.UUID
+	Title        string
+	Status       string
+	BorrowedFrom *time.Time
+	BorrowedTo   *time.Time
+	DueFrom      *time.Time
+	DueTo        *time.Time
+	ReturnedFrom *time.Time
+	ReturnedTo   *time.Time
+	Sort         string
+	Order        string
+	Cursor       string
+	PageSize     int
+	IncludeTotal bool
+}
+
+// ListBorrowingRecordsResponse is a page of borrowing records. NextCursor is
+// empty on the last page and Total is only set when it was asked for.
+type ListBorrowingRecordsResponse struct {
+	Records    []models.BorrowingRecord `json:"records"`
+	NextCursor string                   `json:"next_cursor,omitempty"`
+	Total      *int64                   `json:"total,omitempty"`
+}
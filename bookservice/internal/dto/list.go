@@ -0,0 +1,62 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/models"
+)
+
+// ReadingListRequest creates a reading list or replaces its details. A
+// public list can be read by anyone through its slug.
+type ReadingListRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Public      bool   `json:"public"`
+}
+
+// AddListItemRequest puts a book at the end of a list. With Notify, the
+// patron is notified the next time it is back in stock.
+type AddListItemRequest struct {
+	BookID uuid.UUID `json:"book_id"`
+	Notify bool      `json:"notify"`
+}
+
+// ListItemNotifyRequest turns the availability notification of a book on a
+// list on or off.
+type ListItemNotifyRequest struct {
+	Notify bool `json:"notify"`
+}
+
+// ReorderListRequest holds every book of a list once, in the new order.
+type ReorderListRequest struct {
+	BookIDs []uuid.UUID `json:"book_ids"`
+}
+
+// SharedListResponse is a public reading list as anyone sees it, without
+// its owner.
+type SharedListResponse struct {
+	Name        string                   `json:"name"`
+	Description string                   `json:"description"`
+	UpdatedAt   time.Time                `json:"updated_at"`
+	Items       []models.ReadingListItem `json:"items"`
+}
+
+type ListReadingListsResponse struct {
+	Lists []models.ReadingList `json:"lists"`
+}
+
+// ListNotificationsRequest selects the notifications of a user, only the
+// unread ones with Unread. Cursor is the NextCursor of the previous page.
+type ListNotificationsRequest struct {
+	Unread   bool
+	Cursor   string
+	PageSize int
+}
+
+// ListNotificationsResponse is a page of notifications, newest first.
+// NextCursor is empty on the last page.
+type ListNotificationsResponse struct {
+	Notifications []models.Notification `json:"notifications"`
+	NextCursor    string                `json:"next_cursor,omitempty"`
+}
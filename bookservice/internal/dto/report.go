@@ -0,0 +1,46 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/models"
+)
+
+// ReportRequest selects the period of a report, both days included. It
+// defaults to the 30 days up to To, or up to today. Limit caps the books
+// listed and Order, "asc" or "desc" (the default), sorts the utilisation
+// report.
+type ReportRequest struct {
+	From  *time.Time
+	To    *time.Time
+	Limit int
+	Order string
+}
+
+// ReportPeriod heads every report with its period and when the figures it
+// was computed from were last refreshed.
+type ReportPeriod struct {
+	From        string    `json:"from"`
+	To          string    `json:"to"`
+	RefreshedAt time.Time `json:"refreshed_at"`
+}
+
+type CirculationReportResponse struct {
+	ReportPeriod
+	Summary models.CirculationSummary `json:"summary"`
+}
+
+type TopBooksReportResponse struct {
+	ReportPeriod
+	Books []models.BookLoans `json:"books"`
+}
+
+type CategoryReportResponse struct {
+	ReportPeriod
+	Categories []models.CategoryLoans `json:"categories"`
+}
+
+type UtilisationReportResponse struct {
+	ReportPeriod
+	Books []models.BookUtilisation `json:"books"`
+}
@@ -0,0 +1,37 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/models"
+)
+
+// ReviewRequest is a patron's rating of a book, from 1 to 5, and what they
+// thought of it.
+type ReviewRequest struct {
+	Rating int    `json:"rating"`
+	Text   string `json:"text"`
+}
+
+// ModerateReviewRequest sets the status of a review: visible, flagged or
+// hidden. Note tells other librarians why.
+type ModerateReviewRequest struct {
+	Status string `json:"status"`
+	Note   string `json:"note"`
+}
+
+// ListReviewsRequest selects reviews for moderation. BookID and Status are
+// left out when empty. Cursor is the NextCursor of the previous page.
+type ListReviewsRequest struct {
+	BookID   uuid.UUID
+	Status   string
+	Cursor   string
+	PageSize int
+}
+
+// ListReviewsResponse is a page of reviews, newest first. Listing the reviews
+// of one book also gives its rating. NextCursor is empty on the last page.
+type ListReviewsResponse struct {
+	Reviews    []models.Review `json:"reviews"`
+	Rating     *BookRating     `json:"rating,omitempty"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
@@ -11,8 +11,7 @@ import (
 )
 
 type AuthService interface {
-	GetUserByID(ctx context.Context, userID string) (*pb.User, error)
-	ValidateToken(ctx context.Context, token string) (string, error)
+	IntrospectToken(ctx context.Context, token string) (*pb.IntrospectTokenResponse, error)
 }
 
 // AuthMiddleware struct holds the user service and JWT config
@@ -39,20 +38,21 @@ func (h *authMiddleware) Protected(allowedRoles ...string) fiber.Handler {
 			return response.HandleError(c, nil, "Missing or malformed JWT", fiber.StatusUnauthorized)
 		}
 
-		// Parse and validate the JWT token
-		userIDStr, err := h.authService.ValidateToken(context.Background(), tokenString)
+		// Parse and validate the JWT token or API key and resolve its user
+		token, err := h.authService.IntrospectToken(context.Background(), tokenString)
 		if err != nil {
 			return response.HandleError(c, nil, "Invalid or expired JWT", fiber.StatusUnauthorized)
 		}
+		userIDStr := token.GetUserId()
 
-		// Retrieve the user
-		user, err := h.authService.GetUserByID(context.Background(), userIDStr)
-		if err != nil {
-			return response.HandleError(c, nil, "Failed to fetch user detail", fiber.StatusInternalServerError)
+		// Suspended and locked accounts can't be used; userservice versions
+		// without account statuses leave it empty
+		if status := token.GetAccountStatus(); status != "" && status != "active" {
+			return response.HandleError(c, nil, "Access forbidden: account is "+status, fiber.StatusForbidden)
 		}
 
-		// Check if the user has the required role
-		if !hasAccess(user.Role, allowedRoles) {
+		// Check if the token may act as one of the required roles
+		if !hasPermission(token.GetPermissions(), allowedRoles) {
 			return response.HandleError(c, nil, "Access forbidden: insufficient permissions", fiber.StatusForbidden)
 		}
 
@@ -63,6 +63,7 @@ func (h *authMiddleware) Protected(allowedRoles ...string) fiber.Handler {
 		}
 
 		c.Locals("id", userID) // Pass the user to the next handler
+		c.Locals("role", token.GetRole())
 		return c.Next()
 	}
 }
@@ -82,3 +83,13 @@ func hasAccess(userRole string, allowedRoles []string) bool {
 	}
 	return false
 }
+
+// hasPermission checks if any of the token's permissions is in the allowedRoles list
+func hasPermission(permissions []string, allowedRoles []string) bool {
+	for _, permission := range permissions {
+		if hasAccess(permission, allowedRoles) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/internal/dto"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/internal/service"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/models"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/pagination"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/response"
+)
+
+type AuthorService interface {
+	GetAuthor(ctx context.Context, id uuid.UUID) (*models.Author, error)
+	ListAuthors(ctx context.Context, req dto.ListAuthorsRequest) (*dto.ListAuthorsResponse, error)
+	MergeAuthor(ctx context.Context, id, targetID uuid.UUID) (*dto.MergeAuthorResponse, error)
+}
+
+type authorHandler struct {
+	authorService AuthorService
+	bookService   BookService
+}
+
+func NewAuthorHandler(authorService AuthorService, bookService BookService) *authorHandler {
+	return &authorHandler{authorService: authorService, bookService: bookService}
+}
+
+// ListAuthors godoc
+// @Summary List authors
+// @Description Retrieves a page of authors, editors, translators and illustrators by name, with how many books each is credited on
+// @Tags Authors
+// @Produce json
+// @Param name query string false "Part of the author's name"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param page_size query int false "Authors per page, at most 100 (default 20)"
+// @Success 200 {object} response.Response{data=dto.ListAuthorsResponse} "Authors retrieved successfully"
+// @Failure 400 {object} response.ErrorMessage "Invalid cursor"
+// @Failure 500 {object} response.ErrorMessage "Internal server error"
+// @Router /authors [get]
+func (h *authorHandler) ListAuthors(c *fiber.Ctx) error {
+	req := dto.ListAuthorsRequest{
+		Name:     c.Query("name"),
+		Cursor:   c.Query("cursor"),
+		PageSize: c.QueryInt("page_size"),
+	}
+
+	authors, err := h.authorService.ListAuthors(c.Context(), req)
+	if err != nil {
+		if errors.Is(err, pagination.ErrInvalidCursor) {
+			return response.HandleError(c, err, "", fiber.StatusBadRequest)
+		}
+		log.Println(err)
+		return response.HandleError(c, err, "failed to retrieve authors", fiber.StatusInternalServerError)
+	}
+
+	return response.HandleSuccess(c, "authors retrieved successfully", authors, fiber.StatusOK)
+}
+
+// GetAuthor godoc
+// @Summary Get an author
+// @Description Retrieves an author by ID. The ID of a merged author returns the author it was merged into.
+// @Tags Authors
+// @Produce json
+// @Param id path string true "Author ID"
+// @Success 200 {object} response.Response{data=models.Author} "Author retrieved successfully"
+// @Failure 400 {object} response.ErrorMessage "Invalid author ID"
+// @Failure 404 {object} response.ErrorMessage "Author not found"
+// @Failure 500 {object} response.ErrorMessage "Internal server error"
+// @Router /authors/{id} [get]
+func (h *authorHandler) GetAuthor(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.HandleError(c, err, "invalid author ID", fiber.StatusBadRequest)
+	}
+
+	author, err := h.authorService.GetAuthor(c.Context(), id)
+	if err != nil {
+		if errors.Is(err, service.ErrAuthorNotFound) {
+			return response.HandleError(c, err, "", fiber.StatusNotFound)
+		}
+		log.Println(err)
+		return response.HandleError(c, err, "failed to retrieve author", fiber.StatusInternalServerError)
+	}
+
+	return response.HandleSuccess(c, "author retrieved successfully", author, fiber.StatusOK)
+}
+
+// ListAuthorBooks godoc
+// @Summary List the books of an author
+// @Description Retrieves a page of the books an author is credited on, in any role or the one given
+// @Tags Authors
+// @Produce json
+// @Param id path string true "Author ID"
+// @Param role query string false "author, editor, translator or illustrator"
+// @Param sort query string false "Sort order: title, author, published_date (prefix - for descending) or newest"
+// @Param cursor query string false "next_cursor of the previous page, with the same sort"
+// @Param page_size query int false "Books per page, at most 100 (default 10)"
+// @Success 200 {object} response.Response{data=dto.ListBooksResponse} "Books retrieved successfully"
+// @Failure 400 {object} response.ErrorMessage "Invalid author ID, role, sort or cursor"
+// @Failure 404 {object} response.ErrorMessage "Author not found"
+// @Failure 500 {object} response.ErrorMessage "Internal server error"
+// @Router /authors/{id}/books [get]
+func (h *authorHandler) ListAuthorBooks(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.HandleError(c, err, "invalid author ID", fiber.StatusBadRequest)
+	}
+
+	author, err := h.authorService.GetAuthor(c.Context(), id)
+	if err != nil {
+		if errors.Is(err, service.ErrAuthorNotFound) {
+			return response.HandleError(c, err, "", fiber.StatusNotFound)
+		}
+		log.Println(err)
+		return response.HandleError(c, err, "failed to retrieve author", fiber.StatusInternalServerError)
+	}
+
+	req := dto.ListBooksRequest{
+		AuthorID:   author.ID,
+		AuthorRole: c.Query("role"),
+		Sort:       c.Query("sort"),
+		Cursor:     c.Query("cursor"),
+		PageSize:   c.QueryInt("page_size"),
+	}
+
+	books, err := h.bookService.ListBooks(c.Context(), req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRole) || errors.Is(err, service.ErrInvalidSort) || errors.Is(err, service.ErrSortNeedsQuery) || errors.Is(err, pagination.ErrInvalidCursor) {
+			return response.HandleError(c, err, "", fiber.StatusBadRequest)
+		}
+		log.Println(err)
+		return response.HandleError(c, err, "failed to retrieve books", fiber.StatusInternalServerError)
+	}
+
+	return response.HandleSuccess(c, "books retrieved successfully", books, fiber.StatusOK)
+}
+
+// MergeAuthor godoc
+// @Summary Merge a duplicate author
+// @Description Librarian folds a duplicate author into another. Its books are credited to the target and its ID keeps resolving to the target.
+// @Tags Authors
+// @Accept json
+// @Produce json
+// @Param id path string true "ID of the duplicate author"
+// @Param MergeAuthorRequest body dto.MergeAuthorRequest true "Author to merge into"
+// @Success 200 {object} response.Response{data=dto.MergeAuthorResponse} "Author merged successfully"
+// @Failure 400 {object} response.ErrorMessage "Invalid author ID or request payload, or merging an author into itself"
+// @Failure 404 {object} response.ErrorMessage "Author not found"
+// @Failure 500 {object} response.ErrorMessage "Internal server error"
+// @Security BearerAuth
+// @Router /authors/{id}/merge [post]
+func (h *authorHandler) MergeAuthor(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.HandleError(c, err, "invalid author ID", fiber.StatusBadRequest)
+	}
+
+	var req dto.MergeAuthorRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.HandleError(c, err, "invalid request payload", fiber.StatusBadRequest)
+	}
+
+	merged, err := h.authorService.MergeAuthor(c.Context(), id, req.TargetID)
+	if err != nil {
+		if errors.Is(err, service.ErrMergeSameAuthor) {
+			return response.HandleError(c, err, "", fiber.StatusBadRequest)
+		}
+		if errors.Is(err, service.ErrAuthorNotFound) {
+			return response.HandleError(c, err, "", fiber.StatusNotFound)
+		}
+		log.Println(err)
+		return response.HandleError(c, err, "failed to merge author", fiber.StatusInternalServerError)
+	}
+
+	return response.HandleSuccess(c, "author merged successfully", merged, fiber.StatusOK)
+}
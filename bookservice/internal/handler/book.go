@@ -4,11 +4,15 @@ import (
 	"context"
 	"errors"
 	"log"
+	"strconv"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"github.com/sir-shalahuddin/grpc-learn/bookservice/internal/dto"
 	"github.com/sir-shalahuddin/grpc-learn/bookservice/internal/service"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/metadata"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/pagination"
 	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/response"
 )
 
@@ -17,28 +21,30 @@ type BookService interface {
 	GetBookByID(ctx context.Context, id uuid.UUID) (*dto.GetBookResponse, error)
 	UpdateBook(ctx context.Context, req dto.UpdateBookRequest, bookID uuid.UUID) error
 	DeleteBook(ctx context.Context, id uuid.UUID) error
-	ListBooks(ctx context.Context, title, author, category string, page string) ([]*dto.GetBookResponse, error)
+	ListBooks(ctx context.Context, req dto.ListBooksRequest) (*dto.ListBooksResponse, error)
+	LookupBook(ctx context.Context, isbn string) (*dto.LookupBookResponse, error)
 }
 
 type bookHandler struct {
-	bookService BookService
+	bookService  BookService
+	coverService CoverService
 }
 
-func NewBookHandler(bookService BookService) *bookHandler {
-	return &bookHandler{bookService: bookService}
+func NewBookHandler(bookService BookService, coverService CoverService) *bookHandler {
+	return &bookHandler{bookService: bookService, coverService: coverService}
 }
 
 // AddBook godoc
 // @Summary Add a new book
-// @Description Librarian adds a new book
+// @Description Librarian adds a new book. Contributors credit several authors, editors, translators or illustrators; without them the book is credited to author. With fill_from_lookup, a missing title, author or published date is filled in from the metadata of the ISBN.
 // @Tags Books
 // @Accept json
 // @Produce json
 // @Param AddBookRequest body dto.AddBookRequest true "Add Book Request"
 // @Success 201 {object} response.Response "Book successfully added"
-// @Failure 400 {object} response.ErrorMessage "Invalid request payload"
+// @Failure 400 {object} response.ErrorMessage "Invalid request payload or ISBN, or missing title or author"
 // @Failure 409 {object} response.ErrorMessage "Duplicate book"
-// @Failure 404 {object} response.ErrorMessage "Category not found"
+// @Failure 404 {object} response.ErrorMessage "Category or author not found"
 // @Failure 500 {object} response.ErrorMessage "Internal server error"
 // @Security BearerAuth
 // @Router /books [post]
@@ -52,10 +58,13 @@ func (h *bookHandler) AddBook(c *fiber.Ctx) error {
 	}
 
 	if err := h.bookService.AddBook(c.Context(), req, userID); err != nil {
+		if errors.Is(err, service.ErrInvalidISBN) || errors.Is(err, service.ErrIncompleteBook) || errors.Is(err, service.ErrInvalidRole) {
+			return response.HandleError(c, err, "", fiber.StatusBadRequest)
+		}
 		if errors.Is(err, service.ErrBookDuplicate) {
 			return response.HandleError(c, err, "", fiber.StatusConflict)
 		}
-		if errors.Is(err, service.ErrCategoryNotFound) {
+		if errors.Is(err, service.ErrCategoryNotFound) || errors.Is(err, service.ErrAuthorNotFound) {
 			return response.HandleError(c, err, "", fiber.StatusNotFound)
 		}
 		log.Println(err)
@@ -65,6 +74,41 @@ func (h *bookHandler) AddBook(c *fiber.Ctx) error {
 	return response.HandleSuccess(c, "book successfully added", nil, fiber.StatusCreated)
 }
 
+// LookupBook godoc
+// @Summary Look up a book by ISBN
+// @Description Librarian gets a new book prefilled with the title, author and published date the metadata providers know for an ISBN, to complete and add
+// @Tags Books
+// @Produce json
+// @Param isbn query string true "ISBN-10 or ISBN-13"
+// @Success 200 {object} response.Response{data=dto.LookupBookResponse} "Book details found"
+// @Failure 400 {object} response.ErrorMessage "Invalid ISBN"
+// @Failure 404 {object} response.ErrorMessage "No details found for the ISBN"
+// @Failure 502 {object} response.ErrorMessage "Metadata provider failed"
+// @Failure 503 {object} response.ErrorMessage "No metadata providers configured"
+// @Security BearerAuth
+// @Router /books/lookup [post]
+func (h *bookHandler) LookupBook(c *fiber.Ctx) error {
+	draft, err := h.bookService.LookupBook(c.Context(), c.Query("isbn"))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidISBN) {
+			return response.HandleError(c, err, "", fiber.StatusBadRequest)
+		}
+		if errors.Is(err, service.ErrMetadataNotFound) {
+			return response.HandleError(c, err, "", fiber.StatusNotFound)
+		}
+		if errors.Is(err, metadata.ErrProviderFailed) {
+			return response.HandleError(c, err, "", fiber.StatusBadGateway)
+		}
+		if errors.Is(err, metadata.ErrNoProviders) {
+			return response.HandleError(c, err, "", fiber.StatusServiceUnavailable)
+		}
+		log.Println(err)
+		return response.HandleError(c, err, "failed to look up book", fiber.StatusInternalServerError)
+	}
+
+	return response.HandleSuccess(c, "book details found", draft, fiber.StatusOK)
+}
+
 // GetBookByID godoc
 // @Summary Get a book by ID
 // @Description Retrieves a book by its ID
@@ -96,14 +140,16 @@ func (h *bookHandler) GetBookByID(c *fiber.Ctx) error {
 
 // UpdateBook godoc
 // @Summary Update a book
-// @Description Librarian updates an existing book
+// @Description Librarian updates an existing book. Contributors replace everyone credited on it; without them, a changed author credits the book to that single author.
 // @Tags Books
 // @Accept json
 // @Produce json
 // @Param id path string true "Book ID"
 // @Param UpdateBookRequest body dto.UpdateBookRequest true "Update Book Request"
 // @Success 200 {object} response.Response "Book updated successfully"
-// @Failure 400 {object} response.ErrorMessage "Invalid request payload or book ID"
+// @Failure 400 {object} response.ErrorMessage "Invalid request payload, book ID, ISBN or role"
+// @Failure 404 {object} response.ErrorMessage "Book, category or author not found"
+// @Failure 409 {object} response.ErrorMessage "Another book has the ISBN"
 // @Failure 500 {object} response.ErrorMessage "Internal server error"
 // @Security BearerAuth
 // @Router /books/{id} [put]
@@ -121,6 +167,15 @@ func (h *bookHandler) UpdateBook(c *fiber.Ctx) error {
 
 	err = h.bookService.UpdateBook(c.Context(), req, id)
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidISBN) || errors.Is(err, service.ErrIncompleteBook) || errors.Is(err, service.ErrInvalidRole) {
+			return response.HandleError(c, err, "", fiber.StatusBadRequest)
+		}
+		if errors.Is(err, service.ErrBookDuplicate) {
+			return response.HandleError(c, err, "", fiber.StatusConflict)
+		}
+		if errors.Is(err, service.ErrBookNotFound) || errors.Is(err, service.ErrCategoryNotFound) || errors.Is(err, service.ErrAuthorNotFound) {
+			return response.HandleError(c, err, "", fiber.StatusNotFound)
+		}
 		log.Println(err)
 		return response.HandleError(c, err, "failed to update book", fiber.StatusInternalServerError)
 	}
@@ -130,7 +185,7 @@ func (h *bookHandler) UpdateBook(c *fiber.Ctx) error {
 
 // DeleteBook godoc
 // @Summary Delete a book
-// @Description Librarian deletes an existing book
+// @Description Librarian deletes an existing book together with its cover and thumbnails
 // @Tags Books
 // @Produce json
 // @Param id path string true "Book ID"
@@ -145,6 +200,13 @@ func (h *bookHandler) DeleteBook(c *fiber.Ctx) error {
 		return response.HandleError(c, err, "invalid book ID", fiber.StatusBadRequest)
 	}
 
+	// The cover record would go with the book, leaving its files behind
+	err = h.coverService.DeleteCover(c.Context(), id)
+	if err != nil && !errors.Is(err, service.ErrCoverNotFound) {
+		log.Println(err)
+		return response.HandleError(c, err, "failed to delete book", fiber.StatusInternalServerError)
+	}
+
 	err = h.bookService.DeleteBook(c.Context(), id)
 	if err != nil {
 		log.Println(err)
@@ -156,27 +218,87 @@ func (h *bookHandler) DeleteBook(c *fiber.Ctx) error {
 
 // ListBooks godoc
 // @Summary List books
-// @Description Retrieves a list of books, optionally filtered by title, author, or category
+// @Description Retrieves a list of books, optionally filtered by title, author, or category. With q, books are searched by title, author and ISBN, ordered by relevance and returned with their matches highlighted.
 // @Tags Books
 // @Produce json
+// @Param q query string false "Full-text search, every word also matches as a prefix"
 // @Param title query string false "Book title"
-// @Param author query string false "Book author"
+// @Param author query string false "Name of an author or other contributor"
 // @Param category query string false "Book category"
-// @Param page query string false "Page number"
-// @Success 200 {object} response.Response "Books retrieved successfully"
+// @Param include_subcategories query bool false "Also match books in subcategories of category"
+// @Param available query bool false "Only books with (true) or without (false) copies in stock"
+// @Param published_from query string false "Published on or after this date (YYYY-MM-DD)"
+// @Param published_to query string false "Published on or before this date (YYYY-MM-DD)"
+// @Param has_isbn query bool false "Only books with (true) or without (false) an ISBN"
+// @Param sort query string false "Sort order: title, author, published_date, rating (prefix - for descending), newest or relevance"
+// @Param cursor query string false "next_cursor of the previous page, with the same sort and, when sorting by relevance, the same q"
+// @Param page_size query int false "Books per page, at most 100 (default 10)"
+// @Success 200 {object} response.Response{data=dto.ListBooksResponse} "Books retrieved successfully"
+// @Failure 400 {object} response.ErrorMessage "Invalid filter, sort or cursor"
 // @Failure 500 {object} response.ErrorMessage "Internal server error"
 // @Router /books [get]
 func (h *bookHandler) ListBooks(c *fiber.Ctx) error {
-	title := c.Query("title")
-	author := c.Query("author")
-	category := c.Query("category")
-	page := c.Query("page", "1") // Default to page 1 if not provided
+	req := dto.ListBooksRequest{
+		Title:                c.Query("title"),
+		Author:               c.Query("author"),
+		Q:                    c.Query("q"),
+		Category:             c.Query("category"),
+		IncludeSubcategories: c.QueryBool("include_subcategories"),
+		Sort:                 c.Query("sort"),
+		Cursor:               c.Query("cursor"),
+		PageSize:             c.QueryInt("page_size"),
+	}
 
-	books, err := h.bookService.ListBooks(c.Context(), title, author, category, page)
+	var err error
+	if req.Available, err = queryOptionalBool(c, "available"); err != nil {
+		return response.HandleError(c, err, "invalid available filter", fiber.StatusBadRequest)
+	}
+	if req.HasISBN, err = queryOptionalBool(c, "has_isbn"); err != nil {
+		return response.HandleError(c, err, "invalid has_isbn filter", fiber.StatusBadRequest)
+	}
+	if req.PublishedFrom, err = queryOptionalDate(c, "published_from"); err != nil {
+		return response.HandleError(c, err, "invalid published_from date, use YYYY-MM-DD", fiber.StatusBadRequest)
+	}
+	if req.PublishedTo, err = queryOptionalDate(c, "published_to"); err != nil {
+		return response.HandleError(c, err, "invalid published_to date, use YYYY-MM-DD", fiber.StatusBadRequest)
+	}
+
+	books, err := h.bookService.ListBooks(c.Context(), req)
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidSort) || errors.Is(err, service.ErrSortNeedsQuery) || errors.Is(err, pagination.ErrInvalidCursor) {
+			return response.HandleError(c, err, "", fiber.StatusBadRequest)
+		}
 		log.Println(err)
 		return response.HandleError(c, err, "failed to retrieve books", fiber.StatusInternalServerError)
 	}
 
 	return response.HandleSuccess(c, "books retrieved successfully", books, fiber.StatusOK)
 }
+
+// queryOptionalBool parses a boolean query parameter, returning nil when it
+// is not given.
+func queryOptionalBool(c *fiber.Ctx, key string) (*bool, error) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// queryOptionalDate parses a YYYY-MM-DD query parameter, returning nil when
+// it is not given.
+func queryOptionalDate(c *fiber.Ctx, key string) (*time.Time, error) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
@@ -1,22 +1,30 @@
 package handler
 
 import (
+	"bufio"
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"log"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"github.com/sir-shalahuddin/grpc-learn/bookservice/internal/dto"
 	"github.com/sir-shalahuddin/grpc-learn/bookservice/internal/service"
-	"github.com/sir-shalahuddin/grpc-learn/bookservice/models"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/pagination"
 	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/response"
 )
 
 type BorrowingRecordService interface {
 	BorrowBook(ctx context.Context, req dto.BorrowBookRequest, bookID, userID uuid.UUID) error
 	ReturnBook(ctx context.Context, bookID, record_id uuid.UUID) error
-	ListBorrowingRecords(ctx context.Context, queries map[string]string, userID uuid.UUID) ([]models.BorrowingRecord, error)
+	ListBorrowingRecords(ctx context.Context, req dto.ListBorrowingRecordsRequest, userID uuid.UUID) (*dto.ListBorrowingRecordsResponse, error)
+	SearchRecords(ctx context.Context, req dto.SearchRecordsRequest) (*dto.ListBorrowingRecordsResponse, error)
+	CheckSearch(req dto.SearchRecordsRequest) error
+	ExportRecords(ctx context.Context, req dto.SearchRecordsRequest, w io.Writer) error
 }
 
 type borrowingRecordHandler struct {
@@ -102,25 +110,190 @@ func (h *borrowingRecordHandler) ReturnBook(c *fiber.Ctx) error {
 
 // ListBorrowingRecords godoc
 // @Summary List borrowing records
-// @Description Retrieve a list of borrowing records for the user
+// @Description Retrieve a page of borrowing records for the user, newest first unless asked otherwise
 // @Tags Borrowing
 // @Produce json
-// @Param limit query int false "Limit the number of records returned"
-// @Param offset query int false "Skip a number of records for pagination"
-// @Success 200 {object} response.Response "List of borrowing records"
+// @Param title query string false "Book title"
+// @Param status query string false "borrowed, returned or overdue"
+// @Param order query string false "Borrowing time order, asc or desc (default)"
+// @Param cursor query string false "next_cursor of the previous page, with the same order"
+// @Param page_size query int false "Records per page, at most 100 (default 20)"
+// @Param include_total query bool false "Also count every matching record"
+// @Success 200 {object} response.Response{data=dto.ListBorrowingRecordsResponse} "List of borrowing records"
+// @Failure 400 {object} response.ErrorMessage "Invalid order or cursor"
 // @Failure 500 {object} response.ErrorMessage "Failed to list borrowing records"
 // @Security BearerAuth
 // @Router /books/records [get]
 func (h *borrowingRecordHandler) ListBorrowingRecords(c *fiber.Ctx) error {
-	queries := c.Queries()
+	req := dto.ListBorrowingRecordsRequest{
+		Title:        c.Query("title"),
+		Status:       c.Query("status"),
+		Order:        c.Query("order"),
+		Cursor:       c.Query("cursor"),
+		PageSize:     c.QueryInt("page_size"),
+		IncludeTotal: c.QueryBool("include_total"),
+	}
 
 	userID := c.Locals("id").(uuid.UUID)
 
-	records, err := h.service.ListBorrowingRecords(c.Context(), queries, userID)
+	records, err := h.service.ListBorrowingRecords(c.Context(), req, userID)
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidRecordOrder) || errors.Is(err, pagination.ErrInvalidCursor) {
+			return response.HandleError(c, err, "", fiber.StatusBadRequest)
+		}
 		log.Println(err)
 		return response.HandleError(c, err, "failed to list borrowing records", fiber.StatusInternalServerError)
 	}
 
 	return response.HandleSuccess(c, "list of borrowing records", records, fiber.StatusOK)
 }
+
+// SearchRecords godoc
+// @Summary Search borrowing records
+// @Description Librarian searches the borrowing records of every patron, each with the name and email of its patron. With format=csv every matching record is downloaded instead of a page.
+// @Tags Borrowing
+// @Produce json
+// @Produce text/csv
+// @Param book_id query string false "Book ID"
+// @Param user_id query string false "Patron ID"
+// @Param title query string false "Book title"
+// @Param status query string false "borrowed, returned or overdue"
+// @Param borrowed_from query string false "Borrowed on or after this day (YYYY-MM-DD)"
+// @Param borrowed_to query string false "Borrowed on or before this day (YYYY-MM-DD)"
+// @Param due_from query string false "Due on or after this day (YYYY-MM-DD)"
+// @Param due_to query string false "Due on or before this day (YYYY-MM-DD)"
+// @Param returned_from query string false "Returned on or after this day (YYYY-MM-DD)"
+// @Param returned_to query string false "Returned on or before this day (YYYY-MM-DD)"
+// @Param sort query string false "borrowed_at (default) or due_date"
+// @Param order query string false "asc or desc (default)"
+// @Param cursor query string false "next_cursor of the previous page, with the same sort and order"
+// @Param page_size query int false "Records per page, at most 100 (default 20)"
+// @Param include_total query bool false "Also count every matching record"
+// @Param format query string false "json (default) or csv"
+// @Success 200 {object} response.Response{data=dto.ListBorrowingRecordsResponse} "List of borrowing records"
+// @Failure 400 {object} response.ErrorMessage "Invalid filter, sort, order, cursor or format"
+// @Failure 500 {object} response.ErrorMessage "Failed to search borrowing records"
+// @Security BearerAuth
+// @Router /books/records/search [get]
+func (h *borrowingRecordHandler) SearchRecords(c *fiber.Ctx) error {
+	req, err := searchRecordsRequest(c)
+	if err != nil {
+		return response.HandleError(c, err, "", fiber.StatusBadRequest)
+	}
+	return h.search(c, req, "borrowing-records.csv")
+}
+
+// OverdueRecords godoc
+// @Summary List overdue loans
+// @Description Librarian lists the loans past their due date across every patron, longest overdue first, each with the name and email of its patron. Takes the filters of /books/records/search but status; with format=csv every overdue loan is downloaded.
+// @Tags Borrowing
+// @Produce json
+// @Produce text/csv
+// @Param book_id query string false "Book ID"
+// @Param user_id query string false "Patron ID"
+// @Param title query string false "Book title"
+// @Param borrowed_from query string false "Borrowed on or after this day (YYYY-MM-DD)"
+// @Param borrowed_to query string false "Borrowed on or before this day (YYYY-MM-DD)"
+// @Param due_from query string false "Due on or after this day (YYYY-MM-DD)"
+// @Param due_to query string false "Due on or before this day (YYYY-MM-DD)"
+// @Param sort query string false "due_date (default) or borrowed_at"
+// @Param order query string false "asc (default) or desc"
+// @Param cursor query string false "next_cursor of the previous page, with the same sort and order"
+// @Param page_size query int false "Records per page, at most 100 (default 20)"
+// @Param include_total query bool false "Also count every overdue loan"
+// @Param format query string false "json (default) or csv"
+// @Success 200 {object} response.Response{data=dto.ListBorrowingRecordsResponse} "List of borrowing records"
+// @Failure 400 {object} response.ErrorMessage "Invalid filter, sort, order, cursor or format"
+// @Failure 500 {object} response.ErrorMessage "Failed to search borrowing records"
+// @Security BearerAuth
+// @Router /books/records/overdue [get]
+func (h *borrowingRecordHandler) OverdueRecords(c *fiber.Ctx) error {
+	req, err := searchRecordsRequest(c)
+	if err != nil {
+		return response.HandleError(c, err, "", fiber.StatusBadRequest)
+	}
+	return h.search(c, service.OverdueSearch(req), "overdue-loans.csv")
+}
+
+// search answers a staff search with a page of records, or every record as
+// a CSV download named filename.
+func (h *borrowingRecordHandler) search(c *fiber.Ctx, req dto.SearchRecordsRequest, filename string) error {
+	format := strings.ToLower(c.Query("format", "json"))
+	if format != "json" && format != "csv" {
+		return response.HandleError(c, errors.New("format must be json or csv"), "", fiber.StatusBadRequest)
+	}
+
+	if format == "csv" {
+		if err := h.service.CheckSearch(req); err != nil {
+			return response.HandleError(c, err, "", fiber.StatusBadRequest)
+		}
+
+		c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+		c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+filename+`"`)
+
+		// The stream is written after the handler returns, so it can't use the
+		// request context. An error halfway cuts the file short.
+		c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+			if err := h.service.ExportRecords(context.Background(), req, w); err != nil {
+				log.Printf("[Handler - ExportRecords] Error exporting borrowing records: %v", err)
+			}
+		})
+		return nil
+	}
+
+	records, err := h.service.SearchRecords(c.Context(), req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRecordStatus) || errors.Is(err, service.ErrInvalidRecordSort) ||
+			errors.Is(err, service.ErrInvalidRecordOrder) || errors.Is(err, pagination.ErrInvalidCursor) {
+			return response.HandleError(c, err, "", fiber.StatusBadRequest)
+		}
+		log.Println(err)
+		return response.HandleError(c, err, "failed to search borrowing records", fiber.StatusInternalServerError)
+	}
+
+	return response.HandleSuccess(c, "list of borrowing records", records, fiber.StatusOK)
+}
+
+// searchRecordsRequest reads the filters of a staff search.
+func searchRecordsRequest(c *fiber.Ctx) (dto.SearchRecordsRequest, error) {
+	req := dto.SearchRecordsRequest{
+		Title:        c.Query("title"),
+		Status:       c.Query("status"),
+		Sort:         c.Query("sort"),
+		Order:        c.Query("order"),
+		Cursor:       c.Query("cursor"),
+		PageSize:     c.QueryInt("page_size"),
+		IncludeTotal: c.QueryBool("include_total"),
+	}
+
+	var err error
+	if id := c.Query("book_id"); id != "" {
+		if req.BookID, err = uuid.Parse(id); err != nil {
+			return req, errors.New("invalid book_id")
+		}
+	}
+	if id := c.Query("user_id"); id != "" {
+		if req.UserID, err = uuid.Parse(id); err != nil {
+			return req, errors.New("invalid user_id")
+		}
+	}
+
+	dates := []struct {
+		key  string
+		dest **time.Time
+	}{
+		{"borrowed_from", &req.BorrowedFrom},
+		{"borrowed_to", &req.BorrowedTo},
+		{"due_from", &req.DueFrom},
+		{"due_to", &req.DueTo},
+		{"returned_from", &req.ReturnedFrom},
+		{"returned_to", &req.ReturnedTo},
+	}
+	for _, date := range dates {
+		if *date.dest, err = queryOptionalDate(c, date.key); err != nil {
+			return req, fmt.Errorf("invalid %s date, use YYYY-MM-DD", date.key)
+		}
+	}
+
+	return req, nil
+}
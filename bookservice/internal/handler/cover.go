@@ -0,0 +1,213 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/internal/dto"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/internal/service"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/models"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/blob"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/response"
+)
+
+const (
+	// Versioned cover URLs never change content, so they can be cached for a
+	// year; unversioned ones only briefly, as a new upload replaces them
+	versionedCoverCache   = "public, max-age=31536000, immutable"
+	unversionedCoverCache = "public, max-age=300"
+)
+
+type CoverService interface {
+	UploadCover(ctx context.Context, bookID uuid.UUID, data []byte) (*dto.CoverURLs, error)
+	GetCover(ctx context.Context, bookID uuid.UUID, size string) (*models.BookCover, error)
+	OpenCover(ctx context.Context, cover *models.BookCover, size string) (io.ReadCloser, *blob.Info, error)
+	DeleteCover(ctx context.Context, bookID uuid.UUID) error
+}
+
+type coverHandler struct {
+	coverService CoverService
+	maxSize      int64
+}
+
+func NewCoverHandler(coverService CoverService, maxSize int64) *coverHandler {
+	return &coverHandler{coverService: coverService, maxSize: maxSize}
+}
+
+// UploadCover godoc
+// @Summary Upload a book cover
+// @Description Librarian uploads a JPEG, PNG or GIF cover for a book, replacing any previous one. Small, medium and large JPEG thumbnails are made from it.
+// @Tags Covers
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "Book ID"
+// @Param cover formData file true "Cover image"
+// @Success 200 {object} response.Response{data=dto.CoverURLs} "Cover uploaded successfully"
+// @Failure 400 {object} response.ErrorMessage "Invalid book ID, missing or unreadable image"
+// @Failure 404 {object} response.ErrorMessage "Book not found"
+// @Failure 413 {object} response.ErrorMessage "Cover file is too large"
+// @Failure 415 {object} response.ErrorMessage "Not a JPEG, PNG or GIF image"
+// @Failure 500 {object} response.ErrorMessage "Internal server error"
+// @Security BearerAuth
+// @Router /books/{id}/cover [put]
+func (h *coverHandler) UploadCover(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.HandleError(c, err, "invalid book ID", fiber.StatusBadRequest)
+	}
+
+	fileHeader, err := c.FormFile("cover")
+	if err != nil {
+		return response.HandleError(c, err, "missing cover", fiber.StatusBadRequest)
+	}
+	if fileHeader.Size > h.maxSize {
+		return response.HandleError(c, service.ErrCoverTooLarge, "", fiber.StatusRequestEntityTooLarge)
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return response.HandleError(c, err, "failed to read cover", fiber.StatusBadRequest)
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, h.maxSize+1))
+	if err != nil {
+		return response.HandleError(c, err, "failed to read cover", fiber.StatusBadRequest)
+	}
+
+	urls, err := h.coverService.UploadCover(c.Context(), id, data)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCover) {
+			return response.HandleError(c, err, "", fiber.StatusBadRequest)
+		}
+		if errors.Is(err, service.ErrBookNotFound) {
+			return response.HandleError(c, err, "", fiber.StatusNotFound)
+		}
+		if errors.Is(err, service.ErrCoverTooLarge) {
+			return response.HandleError(c, err, "", fiber.StatusRequestEntityTooLarge)
+		}
+		if errors.Is(err, service.ErrUnsupportedCoverType) {
+			return response.HandleError(c, err, "", fiber.StatusUnsupportedMediaType)
+		}
+		log.Println(err)
+		return response.HandleError(c, err, "failed to upload cover", fiber.StatusInternalServerError)
+	}
+
+	return response.HandleSuccess(c, "cover uploaded successfully", urls, fiber.StatusOK)
+}
+
+// GetCover godoc
+// @Summary Get a book cover
+// @Description Serves a book cover in one size. Use the URLs of a book's cover, whose version lets them be cached for good.
+// @Tags Covers
+// @Produce image/jpeg
+// @Produce image/png
+// @Produce image/gif
+// @Param id path string true "Book ID"
+// @Param size query string false "small, medium, large or original (default)"
+// @Param v query string false "Cover version, from the cover URLs"
+// @Success 200 {file} file "Cover image"
+// @Success 304 "Not modified"
+// @Failure 400 {object} response.ErrorMessage "Invalid book ID or size"
+// @Failure 404 {object} response.ErrorMessage "Book has no cover"
+// @Failure 500 {object} response.ErrorMessage "Internal server error"
+// @Router /books/{id}/cover [get]
+func (h *coverHandler) GetCover(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.HandleError(c, err, "invalid book ID", fiber.StatusBadRequest)
+	}
+	size := c.Query("size", service.CoverOriginal)
+
+	cover, err := h.coverService.GetCover(c.Context(), id, size)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCoverSize) {
+			return response.HandleError(c, err, "", fiber.StatusBadRequest)
+		}
+		if errors.Is(err, service.ErrCoverNotFound) {
+			return response.HandleError(c, err, "", fiber.StatusNotFound)
+		}
+		log.Println(err)
+		return response.HandleError(c, err, "failed to retrieve cover", fiber.StatusInternalServerError)
+	}
+
+	version := service.CoverVersion(*cover)
+	etag := `"` + version + "-" + size + `"`
+	if c.Query("v") == version {
+		c.Set(fiber.HeaderCacheControl, versionedCoverCache)
+	} else {
+		c.Set(fiber.HeaderCacheControl, unversionedCoverCache)
+	}
+	c.Set(fiber.HeaderETag, etag)
+	c.Set(fiber.HeaderLastModified, cover.UpdatedAt.Format(http.TimeFormat))
+
+	if notModified(c, etag, cover.UpdatedAt) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	reader, info, err := h.coverService.OpenCover(c.Context(), cover, size)
+	if err != nil {
+		c.Set(fiber.HeaderCacheControl, "no-store")
+		if errors.Is(err, service.ErrCoverNotFound) {
+			return response.HandleError(c, err, "", fiber.StatusNotFound)
+		}
+		log.Println(err)
+		return response.HandleError(c, err, "failed to retrieve cover", fiber.StatusInternalServerError)
+	}
+
+	c.Set(fiber.HeaderContentType, info.ContentType)
+	// The stream is closed once sent
+	return c.SendStream(reader, int(info.Size))
+}
+
+// DeleteCover godoc
+// @Summary Delete a book cover
+// @Description Librarian removes the cover of a book and its thumbnails
+// @Tags Covers
+// @Produce json
+// @Param id path string true "Book ID"
+// @Success 200 {object} response.Response "Cover deleted successfully"
+// @Failure 400 {object} response.ErrorMessage "Invalid book ID"
+// @Failure 404 {object} response.ErrorMessage "Book has no cover"
+// @Failure 500 {object} response.ErrorMessage "Internal server error"
+// @Security BearerAuth
+// @Router /books/{id}/cover [delete]
+func (h *coverHandler) DeleteCover(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.HandleError(c, err, "invalid book ID", fiber.StatusBadRequest)
+	}
+
+	if err := h.coverService.DeleteCover(c.Context(), id); err != nil {
+		if errors.Is(err, service.ErrCoverNotFound) {
+			return response.HandleError(c, err, "", fiber.StatusNotFound)
+		}
+		log.Println(err)
+		return response.HandleError(c, err, "failed to delete cover", fiber.StatusInternalServerError)
+	}
+
+	return response.HandleSuccess(c, "cover deleted successfully", nil, fiber.StatusOK)
+}
+
+// notModified reports whether the client's copy is current. If-None-Match
+// takes precedence over If-Modified-Since, as RFC 9110 asks.
+func notModified(c *fiber.Ctx, etag string, modTime time.Time) bool {
+	if noneMatch := c.Get(fiber.HeaderIfNoneMatch); noneMatch != "" {
+		for _, candidate := range strings.Split(noneMatch, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == etag || candidate == "*" {
+				return true
+			}
+		}
+		return false
+	}
+
+	since, err := http.ParseTime(c.Get(fiber.HeaderIfModifiedSince))
+	return err == nil && !modTime.Truncate(time.Second).After(since)
+}
@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/internal/dto"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/internal/service"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/models"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/catalog"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/pagination"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/response"
+)
+
+type ImportService interface {
+	StartImport(ctx context.Context, req dto.StartImportRequest, userID uuid.UUID) (*models.BookImport, error)
+	GetImport(ctx context.Context, id uuid.UUID) (*models.BookImport, error)
+	ListImportRows(ctx context.Context, req dto.ListImportRowsRequest, importID uuid.UUID) (*dto.ListImportRowsResponse, error)
+	ExportBooks(ctx context.Context, format string, w io.Writer) error
+}
+
+type importHandler struct {
+	importService ImportService
+	maxSize       int64
+}
+
+func NewImportHandler(importService ImportService, maxSize int64) *importHandler {
+	return &importHandler{importService: importService, maxSize: maxSize}
+}
+
+// StartImport godoc
+// @Summary Import books from a file
+// @Description Librarian uploads a CSV, JSON Lines or MARC 21 catalogue file to add its books in the background. Categories are matched by name, slug or ID and rows with an invalid or already catalogued ISBN are skipped. Poll the returned import for progress.
+// @Tags Imports
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "Catalogue file"
+// @Param format formData string false "csv, jsonl or marc (default from the file extension)"
+// @Param dry_run formData bool false "Check every row without adding any book"
+// @Success 202 {object} response.Response{data=models.BookImport} "Import started"
+// @Failure 400 {object} response.ErrorMessage "Missing or unreadable file, or unknown format"
+// @Failure 413 {object} response.ErrorMessage "File larger than IMPORT_MAX_SIZE"
+// @Failure 500 {object} response.ErrorMessage "Internal server error"
+// @Security BearerAuth
+// @Router /books/imports [post]
+func (h *importHandler) StartImport(c *fiber.Ctx) error {
+	userID := c.Locals("id").(uuid.UUID)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return response.HandleError(c, err, "missing file", fiber.StatusBadRequest)
+	}
+	if fileHeader.Size > h.maxSize {
+		return response.HandleError(c, service.ErrImportFileTooLarge, "", fiber.StatusRequestEntityTooLarge)
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return response.HandleError(c, err, "failed to read file", fiber.StatusBadRequest)
+	}
+	defer file.Close()
+
+	// The upload is gone once the request ends, so keep it for the import
+	data, err := io.ReadAll(io.LimitReader(file, h.maxSize+1))
+	if err != nil {
+		return response.HandleError(c, err, "failed to read file", fiber.StatusBadRequest)
+	}
+	if int64(len(data)) > h.maxSize {
+		return response.HandleError(c, service.ErrImportFileTooLarge, "", fiber.StatusRequestEntityTooLarge)
+	}
+
+	req := dto.StartImportRequest{
+		Format:   c.FormValue("format"),
+		Filename: fileHeader.Filename,
+		DryRun:   strings.EqualFold(c.FormValue("dry_run"), "true"),
+		Data:     data,
+	}
+
+	job, err := h.importService.StartImport(c.Context(), req, userID)
+	if err != nil {
+		if errors.Is(err, catalog.ErrUnknownFormat) || errors.Is(err, service.ErrInvalidImportFile) {
+			return response.HandleError(c, err, "", fiber.StatusBadRequest)
+		}
+		log.Println(err)
+		return response.HandleError(c, err, "failed to start import", fiber.StatusInternalServerError)
+	}
+
+	return response.HandleSuccess(c, "import started", job, fiber.StatusAccepted)
+}
+
+// GetImport godoc
+// @Summary Get an import
+// @Description Librarian polls the status of an import and how many rows it has processed, added and skipped
+// @Tags Imports
+// @Produce json
+// @Param id path string true "Import ID"
+// @Success 200 {object} response.Response{data=models.BookImport} "Import retrieved successfully"
+// @Failure 400 {object} response.ErrorMessage "Invalid import ID"
+// @Failure 404 {object} response.ErrorMessage "Import not found"
+// @Failure 500 {object} response.ErrorMessage "Internal server error"
+// @Security BearerAuth
+// @Router /books/imports/{id} [get]
+func (h *importHandler) GetImport(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.HandleError(c, err, "invalid import ID", fiber.StatusBadRequest)
+	}
+
+	job, err := h.importService.GetImport(c.Context(), id)
+	if err != nil {
+		if errors.Is(err, service.ErrImportNotFound) {
+			return response.HandleError(c, err, "", fiber.StatusNotFound)
+		}
+		log.Println(err)
+		return response.HandleError(c, err, "failed to retrieve import", fiber.StatusInternalServerError)
+	}
+
+	return response.HandleSuccess(c, "import retrieved successfully", job, fiber.StatusOK)
+}
+
+// ListImportRows godoc
+// @Summary List the rows of an import
+// @Description Librarian lists the outcome of every row processed so far, in file order
+// @Tags Imports
+// @Produce json
+// @Param id path string true "Import ID"
+// @Param status query string false "created, valid, invalid or duplicate"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param page_size query int false "Rows per page, at most 1000 (default 100)"
+// @Success 200 {object} response.Response{data=dto.ListImportRowsResponse} "List of import rows"
+// @Failure 400 {object} response.ErrorMessage "Invalid import ID, status or cursor"
+// @Failure 404 {object} response.ErrorMessage "Import not found"
+// @Failure 500 {object} response.ErrorMessage "Internal server error"
+// @Security BearerAuth
+// @Router /books/imports/{id}/rows [get]
+func (h *importHandler) ListImportRows(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.HandleError(c, err, "invalid import ID", fiber.StatusBadRequest)
+	}
+
+	req := dto.ListImportRowsRequest{
+		Status:   c.Query("status"),
+		Cursor:   c.Query("cursor"),
+		PageSize: c.QueryInt("page_size"),
+	}
+
+	rows, err := h.importService.ListImportRows(c.Context(), req, id)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidImportStatus) || errors.Is(err, pagination.ErrInvalidCursor) {
+			return response.HandleError(c, err, "", fiber.StatusBadRequest)
+		}
+		if errors.Is(err, service.ErrImportNotFound) {
+			return response.HandleError(c, err, "", fiber.StatusNotFound)
+		}
+		log.Println(err)
+		return response.HandleError(c, err, "failed to list import rows", fiber.StatusInternalServerError)
+	}
+
+	return response.HandleSuccess(c, "list of import rows", rows, fiber.StatusOK)
+}
+
+// ExportBooks godoc
+// @Summary Export the catalogue
+// @Description Librarian downloads every book as CSV, JSON Lines or MARC 21, in the same layout imports read. The file is streamed as it is written.
+// @Tags Imports
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Produce application/marc
+// @Param format query string false "csv (default), jsonl or marc"
+// @Success 200 {file} file "Catalogue file"
+// @Failure 400 {object} response.ErrorMessage "Unknown format"
+// @Security BearerAuth
+// @Router /books/export [get]
+func (h *importHandler) ExportBooks(c *fiber.Ctx) error {
+	format := strings.ToLower(c.Query("format", catalog.FormatCSV))
+	if !catalog.IsFormat(format) {
+		return response.HandleError(c, catalog.ErrUnknownFormat, "", fiber.StatusBadRequest)
+	}
+
+	c.Set(fiber.HeaderContentType, catalog.ContentType(format))
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="books`+catalog.Extension(format)+`"`)
+
+	// The stream is written after the handler returns, so it can't use the
+	// request context. An error halfway cuts the file short.
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := h.importService.ExportBooks(context.Background(), format, w); err != nil {
+			log.Printf("[Handler - ExportBooks] Error exporting books: %v", err)
+		}
+	})
+	return nil
+}
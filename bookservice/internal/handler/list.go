@@ -0,0 +1,411 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/internal/dto"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/internal/service"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/models"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/pagination"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/response"
+)
+
+type ReadingListService interface {
+	CreateList(ctx context.Context, userID uuid.UUID, req dto.ReadingListRequest) (*models.ReadingList, error)
+	ListLists(ctx context.Context, userID uuid.UUID) (*dto.ListReadingListsResponse, error)
+	GetList(ctx context.Context, userID, listID uuid.UUID) (*models.ReadingList, error)
+	GetPublicList(ctx context.Context, slug string) (*dto.SharedListResponse, error)
+	UpdateList(ctx context.Context, userID, listID uuid.UUID, req dto.ReadingListRequest) (*models.ReadingList, error)
+	DeleteList(ctx context.Context, userID, listID uuid.UUID) error
+	AddItem(ctx context.Context, userID, listID uuid.UUID, req dto.AddListItemRequest) error
+	SetItemNotify(ctx context.Context, userID, listID, bookID uuid.UUID, notify bool) error
+	RemoveItem(ctx context.Context, userID, listID, bookID uuid.UUID) error
+	ReorderItems(ctx context.Context, userID, listID uuid.UUID, req dto.ReorderListRequest) (*models.ReadingList, error)
+	ListNotifications(ctx context.Context, userID uuid.UUID, req dto.ListNotificationsRequest) (*dto.ListNotificationsResponse, error)
+	MarkNotificationRead(ctx context.Context, userID, id uuid.UUID) error
+}
+
+type readingListHandler struct {
+	listService ReadingListService
+}
+
+func NewReadingListHandler(listService ReadingListService) *readingListHandler {
+	return &readingListHandler{listService: listService}
+}
+
+// CreateList godoc
+// @Summary Create a reading list
+// @Description Patron creates a named list of books, such as a wishlist. A public list can be read by anyone through its slug.
+// @Tags Reading Lists
+// @Accept json
+// @Produce json
+// @Param ReadingListRequest body dto.ReadingListRequest true "Reading list"
+// @Success 201 {object} response.Response{data=models.ReadingList} "Reading list created successfully"
+// @Failure 400 {object} response.ErrorMessage "Invalid name or too many lists"
+// @Failure 500 {object} response.ErrorMessage "Internal server error"
+// @Security BearerAuth
+// @Router /lists [post]
+func (h *readingListHandler) CreateList(c *fiber.Ctx) error {
+	userID := c.Locals("id").(uuid.UUID)
+
+	var req dto.ReadingListRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.HandleError(c, err, "invalid request payload", fiber.StatusBadRequest)
+	}
+
+	list, err := h.listService.CreateList(c.Context(), userID, req)
+	if err != nil {
+		return listError(c, err, "failed to create reading list")
+	}
+
+	return response.HandleSuccess(c, "reading list created successfully", list, fiber.StatusCreated)
+}
+
+// ListLists godoc
+// @Summary List reading lists
+// @Description Retrieves the patron's reading lists with how many books each holds
+// @Tags Reading Lists
+// @Produce json
+// @Success 200 {object} response.Response{data=dto.ListReadingListsResponse} "Reading lists retrieved successfully"
+// @Failure 500 {object} response.ErrorMessage "Internal server error"
+// @Security BearerAuth
+// @Router /lists [get]
+func (h *readingListHandler) ListLists(c *fiber.Ctx) error {
+	userID := c.Locals("id").(uuid.UUID)
+
+	lists, err := h.listService.ListLists(c.Context(), userID)
+	if err != nil {
+		return listError(c, err, "failed to retrieve reading lists")
+	}
+
+	return response.HandleSuccess(c, "reading lists retrieved successfully", lists, fiber.StatusOK)
+}
+
+// GetList godoc
+// @Summary Get a reading list
+// @Description Retrieves one of the patron's reading lists with its books in order and whether each is on the shelf now
+// @Tags Reading Lists
+// @Produce json
+// @Param id path string true "Reading list ID"
+// @Success 200 {object} response.Response{data=models.ReadingList} "Reading list retrieved successfully"
+// @Failure 400 {object} response.ErrorMessage "Invalid reading list ID"
+// @Failure 404 {object} response.ErrorMessage "Reading list not found"
+// @Failure 500 {object} response.ErrorMessage "Internal server error"
+// @Security BearerAuth
+// @Router /lists/{id} [get]
+func (h *readingListHandler) GetList(c *fiber.Ctx) error {
+	userID := c.Locals("id").(uuid.UUID)
+
+	listID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.HandleError(c, err, "invalid reading list ID", fiber.StatusBadRequest)
+	}
+
+	list, err := h.listService.GetList(c.Context(), userID, listID)
+	if err != nil {
+		return listError(c, err, "failed to retrieve reading list")
+	}
+
+	return response.HandleSuccess(c, "reading list retrieved successfully", list, fiber.StatusOK)
+}
+
+// GetSharedList godoc
+// @Summary Get a shared reading list
+// @Description Retrieves a public reading list by its slug, with its books and whether each is on the shelf now
+// @Tags Reading Lists
+// @Produce json
+// @Param slug path string true "Slug of the list"
+// @Success 200 {object} response.Response{data=dto.SharedListResponse} "Reading list retrieved successfully"
+// @Failure 404 {object} response.ErrorMessage "No public list has this slug"
+// @Failure 500 {object} response.ErrorMessage "Internal server error"
+// @Router /lists/shared/{slug} [get]
+func (h *readingListHandler) GetSharedList(c *fiber.Ctx) error {
+	list, err := h.listService.GetPublicList(c.Context(), c.Params("slug"))
+	if err != nil {
+		return listError(c, err, "failed to retrieve reading list")
+	}
+
+	return response.HandleSuccess(c, "reading list retrieved successfully", list, fiber.StatusOK)
+}
+
+// UpdateList godoc
+// @Summary Update a reading list
+// @Description Patron renames a list, changes its description or shares it. A list made private again keeps its slug for the next time it is shared.
+// @Tags Reading Lists
+// @Accept json
+// @Produce json
+// @Param id path string true "Reading list ID"
+// @Param ReadingListRequest body dto.ReadingListRequest true "Reading list"
+// @Success 200 {object} response.Response{data=models.ReadingList} "Reading list updated successfully"
+// @Failure 400 {object} response.ErrorMessage "Invalid reading list ID or name"
+// @Failure 404 {object} response.ErrorMessage "Reading list not found"
+// @Failure 500 {object} response.ErrorMessage "Internal server error"
+// @Security BearerAuth
+// @Router /lists/{id} [put]
+func (h *readingListHandler) UpdateList(c *fiber.Ctx) error {
+	userID := c.Locals("id").(uuid.UUID)
+
+	listID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.HandleError(c, err, "invalid reading list ID", fiber.StatusBadRequest)
+	}
+
+	var req dto.ReadingListRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.HandleError(c, err, "invalid request payload", fiber.StatusBadRequest)
+	}
+
+	list, err := h.listService.UpdateList(c.Context(), userID, listID, req)
+	if err != nil {
+		return listError(c, err, "failed to update reading list")
+	}
+
+	return response.HandleSuccess(c, "reading list updated successfully", list, fiber.StatusOK)
+}
+
+// DeleteList godoc
+// @Summary Delete a reading list
+// @Description Patron deletes one of their reading lists
+// @Tags Reading Lists
+// @Produce json
+// @Param id path string true "Reading list ID"
+// @Success 200 {object} response.Response "Reading list deleted successfully"
+// @Failure 400 {object} response.ErrorMessage "Invalid reading list ID"
+// @Failure 404 {object} response.ErrorMessage "Reading list not found"
+// @Failure 500 {object} response.ErrorMessage "Internal server error"
+// @Security BearerAuth
+// @Router /lists/{id} [delete]
+func (h *readingListHandler) DeleteList(c *fiber.Ctx) error {
+	userID := c.Locals("id").(uuid.UUID)
+
+	listID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.HandleError(c, err, "invalid reading list ID", fiber.StatusBadRequest)
+	}
+
+	if err := h.listService.DeleteList(c.Context(), userID, listID); err != nil {
+		return listError(c, err, "failed to delete reading list")
+	}
+
+	return response.HandleSuccess(c, "reading list deleted successfully", nil, fiber.StatusOK)
+}
+
+// AddItem godoc
+// @Summary Add a book to a reading list
+// @Description Patron puts a book at the end of a list. With notify, they are notified the next time the book is back in stock.
+// @Tags Reading Lists
+// @Accept json
+// @Produce json
+// @Param id path string true "Reading list ID"
+// @Param AddListItemRequest body dto.AddListItemRequest true "Book to add"
+// @Success 201 {object} response.Response "Book added to reading list"
+// @Failure 400 {object} response.ErrorMessage "Invalid reading list ID or the list is full"
+// @Failure 404 {object} response.ErrorMessage "Reading list or book not found"
+// @Failure 409 {object} response.ErrorMessage "Book is already on the list"
+// @Failure 500 {object} response.ErrorMessage "Internal server error"
+// @Security BearerAuth
+// @Router /lists/{id}/items [post]
+func (h *readingListHandler) AddItem(c *fiber.Ctx) error {
+	userID := c.Locals("id").(uuid.UUID)
+
+	listID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.HandleError(c, err, "invalid reading list ID", fiber.StatusBadRequest)
+	}
+
+	var req dto.AddListItemRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.HandleError(c, err, "invalid request payload", fiber.StatusBadRequest)
+	}
+
+	if err := h.listService.AddItem(c.Context(), userID, listID, req); err != nil {
+		return listError(c, err, "failed to add book to reading list")
+	}
+
+	return response.HandleSuccess(c, "book added to reading list", nil, fiber.StatusCreated)
+}
+
+// SetItemNotify godoc
+// @Summary Toggle an availability notification
+// @Description Patron asks to be notified, or no longer, the next time a book on their list is back in stock. It turns itself off once they are notified.
+// @Tags Reading Lists
+// @Accept json
+// @Produce json
+// @Param id path string true "Reading list ID"
+// @Param book_id path string true "Book ID"
+// @Param ListItemNotifyRequest body dto.ListItemNotifyRequest true "Whether to notify"
+// @Success 200 {object} response.Response "Notification updated"
+// @Failure 400 {object} response.ErrorMessage "Invalid reading list or book ID"
+// @Failure 404 {object} response.ErrorMessage "Reading list not found or book not on it"
+// @Failure 500 {object} response.ErrorMessage "Internal server error"
+// @Security BearerAuth
+// @Router /lists/{id}/items/{book_id} [put]
+func (h *readingListHandler) SetItemNotify(c *fiber.Ctx) error {
+	userID := c.Locals("id").(uuid.UUID)
+
+	listID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.HandleError(c, err, "invalid reading list ID", fiber.StatusBadRequest)
+	}
+	bookID, err := uuid.Parse(c.Params("book_id"))
+	if err != nil {
+		return response.HandleError(c, err, "invalid book ID", fiber.StatusBadRequest)
+	}
+
+	var req dto.ListItemNotifyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.HandleError(c, err, "invalid request payload", fiber.StatusBadRequest)
+	}
+
+	if err := h.listService.SetItemNotify(c.Context(), userID, listID, bookID, req.Notify); err != nil {
+		return listError(c, err, "failed to update notification")
+	}
+
+	return response.HandleSuccess(c, "notification updated", nil, fiber.StatusOK)
+}
+
+// RemoveItem godoc
+// @Summary Remove a book from a reading list
+// @Description Patron takes a book off one of their lists
+// @Tags Reading Lists
+// @Produce json
+// @Param id path string true "Reading list ID"
+// @Param book_id path string true "Book ID"
+// @Success 200 {object} response.Response "Book removed from reading list"
+// @Failure 400 {object} response.ErrorMessage "Invalid reading list or book ID"
+// @Failure 404 {object} response.ErrorMessage "Reading list not found or book not on it"
+// @Failure 500 {object} response.ErrorMessage "Internal server error"
+// @Security BearerAuth
+// @Router /lists/{id}/items/{book_id} [delete]
+func (h *readingListHandler) RemoveItem(c *fiber.Ctx) error {
+	userID := c.Locals("id").(uuid.UUID)
+
+	listID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.HandleError(c, err, "invalid reading list ID", fiber.StatusBadRequest)
+	}
+	bookID, err := uuid.Parse(c.Params("book_id"))
+	if err != nil {
+		return response.HandleError(c, err, "invalid book ID", fiber.StatusBadRequest)
+	}
+
+	if err := h.listService.RemoveItem(c.Context(), userID, listID, bookID); err != nil {
+		return listError(c, err, "failed to remove book from reading list")
+	}
+
+	return response.HandleSuccess(c, "book removed from reading list", nil, fiber.StatusOK)
+}
+
+// ReorderItems godoc
+// @Summary Reorder a reading list
+// @Description Patron puts the books of a list in a new order, giving every one of them once
+// @Tags Reading Lists
+// @Accept json
+// @Produce json
+// @Param id path string true "Reading list ID"
+// @Param ReorderListRequest body dto.ReorderListRequest true "Book IDs in the new order"
+// @Success 200 {object} response.Response{data=models.ReadingList} "Reading list reordered"
+// @Failure 400 {object} response.ErrorMessage "Invalid reading list ID or order"
+// @Failure 404 {object} response.ErrorMessage "Reading list not found"
+// @Failure 500 {object} response.ErrorMessage "Internal server error"
+// @Security BearerAuth
+// @Router /lists/{id}/order [put]
+func (h *readingListHandler) ReorderItems(c *fiber.Ctx) error {
+	userID := c.Locals("id").(uuid.UUID)
+
+	listID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.HandleError(c, err, "invalid reading list ID", fiber.StatusBadRequest)
+	}
+
+	var req dto.ReorderListRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.HandleError(c, err, "invalid request payload", fiber.StatusBadRequest)
+	}
+
+	list, err := h.listService.ReorderItems(c.Context(), userID, listID, req)
+	if err != nil {
+		return listError(c, err, "failed to reorder reading list")
+	}
+
+	return response.HandleSuccess(c, "reading list reordered", list, fiber.StatusOK)
+}
+
+// ListNotifications godoc
+// @Summary List notifications
+// @Description Retrieves a page of the patron's notifications, newest first, such as books they waited for coming back
+// @Tags Reading Lists
+// @Produce json
+// @Param unread query bool false "Only unread notifications"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param page_size query int false "Notifications per page, at most 100 (default 20)"
+// @Success 200 {object} response.Response{data=dto.ListNotificationsResponse} "Notifications retrieved successfully"
+// @Failure 400 {object} response.ErrorMessage "Invalid cursor"
+// @Failure 500 {object} response.ErrorMessage "Internal server error"
+// @Security BearerAuth
+// @Router /notifications [get]
+func (h *readingListHandler) ListNotifications(c *fiber.Ctx) error {
+	userID := c.Locals("id").(uuid.UUID)
+
+	req := dto.ListNotificationsRequest{
+		Unread:   c.QueryBool("unread"),
+		Cursor:   c.Query("cursor"),
+		PageSize: c.QueryInt("page_size"),
+	}
+
+	notifications, err := h.listService.ListNotifications(c.Context(), userID, req)
+	if err != nil {
+		return listError(c, err, "failed to retrieve notifications")
+	}
+
+	return response.HandleSuccess(c, "notifications retrieved successfully", notifications, fiber.StatusOK)
+}
+
+// MarkNotificationRead godoc
+// @Summary Mark a notification read
+// @Description Patron marks one of their notifications as read
+// @Tags Reading Lists
+// @Produce json
+// @Param id path string true "Notification ID"
+// @Success 200 {object} response.Response "Notification marked read"
+// @Failure 400 {object} response.ErrorMessage "Invalid notification ID"
+// @Failure 404 {object} response.ErrorMessage "Notification not found"
+// @Failure 500 {object} response.ErrorMessage "Internal server error"
+// @Security BearerAuth
+// @Router /notifications/{id}/read [put]
+func (h *readingListHandler) MarkNotificationRead(c *fiber.Ctx) error {
+	userID := c.Locals("id").(uuid.UUID)
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.HandleError(c, err, "invalid notification ID", fiber.StatusBadRequest)
+	}
+
+	if err := h.listService.MarkNotificationRead(c.Context(), userID, id); err != nil {
+		return listError(c, err, "failed to mark notification read")
+	}
+
+	return response.HandleSuccess(c, "notification marked read", nil, fiber.StatusOK)
+}
+
+// listError answers a failed reading list request with the status matching
+// err, or 500 with message.
+func listError(c *fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, service.ErrListNameRequired), errors.Is(err, service.ErrListNameTooLong),
+		errors.Is(err, service.ErrTooManyLists), errors.Is(err, service.ErrListFull),
+		errors.Is(err, service.ErrInvalidListOrder), errors.Is(err, pagination.ErrInvalidCursor):
+		return response.HandleError(c, err, "", fiber.StatusBadRequest)
+	case errors.Is(err, service.ErrListNotFound), errors.Is(err, service.ErrBookNotFound),
+		errors.Is(err, service.ErrBookNotListed), errors.Is(err, service.ErrNotificationNotFound):
+		return response.HandleError(c, err, "", fiber.StatusNotFound)
+	case errors.Is(err, service.ErrBookAlreadyListed):
+		return response.HandleError(c, err, "", fiber.StatusConflict)
+	}
+	log.Println(err)
+	return response.HandleError(c, err, message, fiber.StatusInternalServerError)
+}
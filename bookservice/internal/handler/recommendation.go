@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/internal/dto"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/internal/service"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/models"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/response"
+)
+
+type RecommendationService interface {
+	SimilarBooks(ctx context.Context, bookID uuid.UUID, limit int) (*dto.RecommendationsResponse, error)
+	RecommendedBooks(ctx context.Context, userID uuid.UUID, limit int) (*dto.RecommendationsResponse, error)
+	Recompute(ctx context.Context, userID uuid.UUID) (*models.RecommendationRun, error)
+	LatestRun(ctx context.Context) (*models.RecommendationRun, error)
+}
+
+type recommendationHandler struct {
+	recommendationService RecommendationService
+}
+
+func NewRecommendationHandler(recommendationService RecommendationService) *recommendationHandler {
+	return &recommendationHandler{recommendationService: recommendationService}
+}
+
+// SimilarBooks godoc
+// @Summary Readers also borrowed
+// @Description Retrieves the books most often borrowed by the patrons who borrowed this one, topped up with popular books of its category
+// @Tags Recommendations
+// @Produce json
+// @Param id path string true "Book ID"
+// @Param limit query int false "Number of books (default 10)"
+// @Success 200 {object} response.Response{data=dto.RecommendationsResponse} "Similar books retrieved successfully"
+// @Failure 400 {object} response.ErrorMessage "Invalid book ID"
+// @Failure 404 {object} response.ErrorMessage "Book not found"
+// @Failure 500 {object} response.ErrorMessage "Internal server error"
+// @Router /books/{id}/similar [get]
+func (h *recommendationHandler) SimilarBooks(c *fiber.Ctx) error {
+	bookID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.HandleError(c, err, "invalid book ID", fiber.StatusBadRequest)
+	}
+
+	books, err := h.recommendationService.SimilarBooks(c.Context(), bookID, c.QueryInt("limit"))
+	if err != nil {
+		if errors.Is(err, service.ErrBookNotFound) {
+			return response.HandleError(c, err, "", fiber.StatusNotFound)
+		}
+		log.Println(err)
+		return response.HandleError(c, err, "failed to retrieve similar books", fiber.StatusInternalServerError)
+	}
+
+	return response.HandleSuccess(c, "similar books retrieved successfully", books, fiber.StatusOK)
+}
+
+// RecommendedBooks godoc
+// @Summary Recommended books
+// @Description Retrieves books the patron hasn't borrowed, picked from what they borrowed before. Patrons with little history get popular books of the categories they read, or of the library.
+// @Tags Recommendations
+// @Produce json
+// @Param limit query int false "Number of books (default 10)"
+// @Success 200 {object} response.Response{data=dto.RecommendationsResponse} "Recommended books retrieved successfully"
+// @Failure 500 {object} response.ErrorMessage "Internal server error"
+// @Security BearerAuth
+// @Router /books/recommended [get]
+func (h *recommendationHandler) RecommendedBooks(c *fiber.Ctx) error {
+	userID := c.Locals("id").(uuid.UUID)
+
+	books, err := h.recommendationService.RecommendedBooks(c.Context(), userID, c.QueryInt("limit"))
+	if err != nil {
+		log.Println(err)
+		return response.HandleError(c, err, "failed to retrieve recommended books", fiber.StatusInternalServerError)
+	}
+
+	return response.HandleSuccess(c, "recommended books retrieved successfully", books, fiber.StatusOK)
+}
+
+// Recompute godoc
+// @Summary Recompute recommendations
+// @Description Librarian recomputes the recommendations from the borrowing history now instead of waiting for the schedule. It runs in the background; follow it at /books/recommendations/status.
+// @Tags Recommendations
+// @Produce json
+// @Success 202 {object} response.Response{data=models.RecommendationRun} "Recompute started"
+// @Failure 409 {object} response.ErrorMessage "Already recomputing"
+// @Failure 500 {object} response.ErrorMessage "Internal server error"
+// @Security BearerAuth
+// @Router /books/recommendations/recompute [post]
+func (h *recommendationHandler) Recompute(c *fiber.Ctx) error {
+	userID := c.Locals("id").(uuid.UUID)
+
+	run, err := h.recommendationService.Recompute(c.Context(), userID)
+	if err != nil {
+		if errors.Is(err, service.ErrRecomputeRunning) {
+			return response.HandleError(c, err, "", fiber.StatusConflict)
+		}
+		log.Println(err)
+		return response.HandleError(c, err, "failed to start recompute", fiber.StatusInternalServerError)
+	}
+
+	return response.HandleSuccess(c, "recompute started", run, fiber.StatusAccepted)
+}
+
+// RecomputeStatus godoc
+// @Summary Recommendation status
+// @Description Librarian checks the last recommendation run, scheduled or manual
+// @Tags Recommendations
+// @Produce json
+// @Success 200 {object} response.Response{data=models.RecommendationRun} "Last run retrieved successfully"
+// @Failure 404 {object} response.ErrorMessage "Recommendations were never computed"
+// @Failure 500 {object} response.ErrorMessage "Internal server error"
+// @Security BearerAuth
+// @Router /books/recommendations/status [get]
+func (h *recommendationHandler) RecomputeStatus(c *fiber.Ctx) error {
+	run, err := h.recommendationService.LatestRun(c.Context())
+	if err != nil {
+		log.Println(err)
+		return response.HandleError(c, err, "failed to retrieve recommendation status", fiber.StatusInternalServerError)
+	}
+	if run == nil {
+		return response.HandleError(c, nil, "recommendations were never computed", fiber.StatusNotFound)
+	}
+
+	return response.HandleSuccess(c, "last run retrieved successfully", run, fiber.StatusOK)
+}
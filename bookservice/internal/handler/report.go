@@ -0,0 +1,227 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/internal/dto"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/internal/service"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/csvsafe"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/response"
+)
+
+var errUnknownReportFormat = errors.New("format must be json or csv")
+
+type ReportService interface {
+	CirculationReport(ctx context.Context, req dto.ReportRequest) (*dto.CirculationReportResponse, error)
+	TopBooksReport(ctx context.Context, req dto.ReportRequest) (*dto.TopBooksReportResponse, error)
+	CategoryReport(ctx context.Context, req dto.ReportRequest) (*dto.CategoryReportResponse, error)
+	UtilisationReport(ctx context.Context, req dto.ReportRequest) (*dto.UtilisationReportResponse, error)
+}
+
+type reportHandler struct {
+	reportService ReportService
+}
+
+func NewReportHandler(reportService ReportService) *reportHandler {
+	return &reportHandler{reportService: reportService}
+}
+
+// CirculationReport godoc
+// @Summary Circulation summary
+// @Description Librarian sums up the loans started in a period: how many were returned, late or not, how many are overdue, their average length, the patrons who had a book out and the share of the stock on loan
+// @Tags Reports
+// @Produce json
+// @Produce text/csv
+// @Param from query string false "First day (YYYY-MM-DD), 30 days before to by default"
+// @Param to query string false "Last day (YYYY-MM-DD), today by default"
+// @Param format query string false "json (default) or csv"
+// @Success 200 {object} response.Response{data=dto.CirculationReportResponse} "Report retrieved successfully"
+// @Failure 400 {object} response.ErrorMessage "Invalid period or format"
+// @Failure 500 {object} response.ErrorMessage "Internal server error"
+// @Security BearerAuth
+// @Router /reports/circulation [get]
+func (h *reportHandler) CirculationReport(c *fiber.Ctx) error {
+	req, format, err := reportRequest(c)
+	if err != nil {
+		return response.HandleError(c, err, "", fiber.StatusBadRequest)
+	}
+
+	report, err := h.reportService.CirculationReport(c.Context(), req)
+	if err != nil {
+		return reportError(c, err)
+	}
+
+	if format == "csv" {
+		s := report.Summary
+		return sendCSV(c, "circulation", report.ReportPeriod, []string{"metric", "value"}, [][]string{
+			{"loans", strconv.FormatInt(s.Loans, 10)},
+			{"returned", strconv.FormatInt(s.Returned, 10)},
+			{"returned_late", strconv.FormatInt(s.ReturnedLate, 10)},
+			{"overdue", strconv.FormatInt(s.Overdue, 10)},
+			{"average_loan_days", formatFloat(s.AverageLoanDays)},
+			{"active_borrowers", strconv.FormatInt(s.ActiveBorrowers, 10)},
+			{"stock_utilisation", formatFloat(s.StockUtilisation)},
+		})
+	}
+	return response.HandleSuccess(c, "report retrieved successfully", report, fiber.StatusOK)
+}
+
+// TopBooksReport godoc
+// @Summary Most borrowed books
+// @Description Librarian lists the books borrowed most in a period
+// @Tags Reports
+// @Produce json
+// @Produce text/csv
+// @Param from query string false "First day (YYYY-MM-DD), 30 days before to by default"
+// @Param to query string false "Last day (YYYY-MM-DD), today by default"
+// @Param limit query int false "Number of books, at most 1000 (default 10)"
+// @Param format query string false "json (default) or csv"
+// @Success 200 {object} response.Response{data=dto.TopBooksReportResponse} "Report retrieved successfully"
+// @Failure 400 {object} response.ErrorMessage "Invalid period or format"
+// @Failure 500 {object} response.ErrorMessage "Internal server error"
+// @Security BearerAuth
+// @Router /reports/books [get]
+func (h *reportHandler) TopBooksReport(c *fiber.Ctx) error {
+	req, format, err := reportRequest(c)
+	if err != nil {
+		return response.HandleError(c, err, "", fiber.StatusBadRequest)
+	}
+
+	report, err := h.reportService.TopBooksReport(c.Context(), req)
+	if err != nil {
+		return reportError(c, err)
+	}
+
+	if format == "csv" {
+		rows := make([][]string, 0, len(report.Books))
+		for _, b := range report.Books {
+			rows = append(rows, []string{b.ID.String(), b.Title, b.Author, strconv.FormatInt(b.Loans, 10)})
+		}
+		return sendCSV(c, "books", report.ReportPeriod, []string{"id", "title", "author", "loans"}, rows)
+	}
+	return response.HandleSuccess(c, "report retrieved successfully", report, fiber.StatusOK)
+}
+
+// CategoryReport godoc
+// @Summary Loans per category
+// @Description Librarian counts the loans started in a period per category, with the number of its books borrowed
+// @Tags Reports
+// @Produce json
+// @Produce text/csv
+// @Param from query string false "First day (YYYY-MM-DD), 30 days before to by default"
+// @Param to query string false "Last day (YYYY-MM-DD), today by default"
+// @Param format query string false "json (default) or csv"
+// @Success 200 {object} response.Response{data=dto.CategoryReportResponse} "Report retrieved successfully"
+// @Failure 400 {object} response.ErrorMessage "Invalid period or format"
+// @Failure 500 {object} response.ErrorMessage "Internal server error"
+// @Security BearerAuth
+// @Router /reports/categories [get]
+func (h *reportHandler) CategoryReport(c *fiber.Ctx) error {
+	req, format, err := reportRequest(c)
+	if err != nil {
+		return response.HandleError(c, err, "", fiber.StatusBadRequest)
+	}
+
+	report, err := h.reportService.CategoryReport(c.Context(), req)
+	if err != nil {
+		return reportError(c, err)
+	}
+
+	if format == "csv" {
+		rows := make([][]string, 0, len(report.Categories))
+		for _, ctg := range report.Categories {
+			rows = append(rows, []string{ctg.CategoryID.String(), ctg.Name, strconv.FormatInt(ctg.Loans, 10), strconv.FormatInt(ctg.Books, 10)})
+		}
+		return sendCSV(c, "categories", report.ReportPeriod, []string{"category_id", "name", "loans", "books"}, rows)
+	}
+	return response.HandleSuccess(c, "report retrieved successfully", report, fiber.StatusOK)
+}
+
+// UtilisationReport godoc
+// @Summary Stock utilisation
+// @Description Librarian lists the books by the share of the period their copies spent on loan, most used first or least used with order=asc
+// @Tags Reports
+// @Produce json
+// @Produce text/csv
+// @Param from query string false "First day (YYYY-MM-DD), 30 days before to by default"
+// @Param to query string false "Last day (YYYY-MM-DD), today by default"
+// @Param order query string false "desc (default) or asc"
+// @Param limit query int false "Number of books, at most 1000 (default 10)"
+// @Param format query string false "json (default) or csv"
+// @Success 200 {object} response.Response{data=dto.UtilisationReportResponse} "Report retrieved successfully"
+// @Failure 400 {object} response.ErrorMessage "Invalid period, order or format"
+// @Failure 500 {object} response.ErrorMessage "Internal server error"
+// @Security BearerAuth
+// @Router /reports/utilisation [get]
+func (h *reportHandler) UtilisationReport(c *fiber.Ctx) error {
+	req, format, err := reportRequest(c)
+	if err != nil {
+		return response.HandleError(c, err, "", fiber.StatusBadRequest)
+	}
+	req.Order = c.Query("order")
+
+	report, err := h.reportService.UtilisationReport(c.Context(), req)
+	if err != nil {
+		return reportError(c, err)
+	}
+
+	if format == "csv" {
+		rows := make([][]string, 0, len(report.Books))
+		for _, b := range report.Books {
+			rows = append(rows, []string{b.ID.String(), b.Title, b.Author, strconv.FormatInt(b.Copies, 10), strconv.FormatInt(b.LoanDays, 10), formatFloat(b.Utilisation)})
+		}
+		return sendCSV(c, "utilisation", report.ReportPeriod, []string{"id", "title", "author", "copies", "loan_days", "utilisation"}, rows)
+	}
+	return response.HandleSuccess(c, "report retrieved successfully", report, fiber.StatusOK)
+}
+
+// reportRequest reads the period, limit and format shared by the reports.
+func reportRequest(c *fiber.Ctx) (dto.ReportRequest, string, error) {
+	format := strings.ToLower(c.Query("format", "json"))
+	if format != "json" && format != "csv" {
+		return dto.ReportRequest{}, "", errUnknownReportFormat
+	}
+
+	req := dto.ReportRequest{Limit: c.QueryInt("limit")}
+	var err error
+	if req.From, err = queryOptionalDate(c, "from"); err != nil {
+		return dto.ReportRequest{}, "", errors.New("invalid from date, use YYYY-MM-DD")
+	}
+	if req.To, err = queryOptionalDate(c, "to"); err != nil {
+		return dto.ReportRequest{}, "", errors.New("invalid to date, use YYYY-MM-DD")
+	}
+	return req, format, nil
+}
+
+func reportError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, service.ErrInvalidReportPeriod) || errors.Is(err, service.ErrInvalidReportOrder) {
+		return response.HandleError(c, err, "", fiber.StatusBadRequest)
+	}
+	log.Println(err)
+	return response.HandleError(c, err, "failed to retrieve report", fiber.StatusInternalServerError)
+}
+
+// sendCSV sends a report as a CSV download named after it and its period.
+func sendCSV(c *fiber.Ctx, name string, period dto.ReportPeriod, header []string, rows [][]string) error {
+	c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+name+`-`+period.From+`-`+period.To+`.csv"`)
+
+	w := csvsafe.NewWriter(c.Response().BodyWriter())
+	if err := w.Write(header); err != nil {
+		return err
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return err
+	}
+	c.Status(fiber.StatusOK)
+	return nil
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', 4, 64)
+}
@@ -0,0 +1,264 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/internal/dto"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/internal/service"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/models"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/pagination"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/response"
+)
+
+type ReviewService interface {
+	AddReview(ctx context.Context, bookID, userID uuid.UUID, req dto.ReviewRequest) (*models.Review, error)
+	UpdateReview(ctx context.Context, bookID, reviewID, userID uuid.UUID, req dto.ReviewRequest) (*models.Review, error)
+	DeleteReview(ctx context.Context, bookID, reviewID, userID uuid.UUID) error
+	ModerateReview(ctx context.Context, reviewID, moderatorID uuid.UUID, req dto.ModerateReviewRequest) (*models.Review, error)
+	ListBookReviews(ctx context.Context, bookID uuid.UUID, req dto.ListReviewsRequest) (*dto.ListReviewsResponse, error)
+	ListReviews(ctx context.Context, req dto.ListReviewsRequest) (*dto.ListReviewsResponse, error)
+}
+
+type reviewHandler struct {
+	reviewService ReviewService
+}
+
+func NewReviewHandler(reviewService ReviewService) *reviewHandler {
+	return &reviewHandler{reviewService: reviewService}
+}
+
+// AddReview godoc
+// @Summary Review a book
+// @Description Patrons who have borrowed and returned a book rate it from 1 to 5, with an optional text. Each patron reviews a book once.
+// @Tags Reviews
+// @Accept json
+// @Produce json
+// @Param id path string true "Book ID"
+// @Param ReviewRequest body dto.ReviewRequest true "Review"
+// @Success 201 {object} response.Response{data=models.Review} "Review added successfully"
+// @Failure 400 {object} response.ErrorMessage "Invalid book ID, rating or text"
+// @Failure 403 {object} response.ErrorMessage "The book was never returned by this patron"
+// @Failure 404 {object} response.ErrorMessage "Book not found"
+// @Failure 409 {object} response.ErrorMessage "The patron already reviewed this book"
+// @Failure 500 {object} response.ErrorMessage "Internal server error"
+// @Security BearerAuth
+// @Router /books/{id}/reviews [post]
+func (h *reviewHandler) AddReview(c *fiber.Ctx) error {
+	userID := c.Locals("id").(uuid.UUID)
+
+	bookID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.HandleError(c, err, "invalid book ID", fiber.StatusBadRequest)
+	}
+
+	var req dto.ReviewRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.HandleError(c, err, "invalid request payload", fiber.StatusBadRequest)
+	}
+
+	review, err := h.reviewService.AddReview(c.Context(), bookID, userID, req)
+	if err != nil {
+		return reviewError(c, err, "failed to add review")
+	}
+
+	return response.HandleSuccess(c, "review added successfully", review, fiber.StatusCreated)
+}
+
+// UpdateReview godoc
+// @Summary Update a review
+// @Description Patrons change the rating and text of their own review
+// @Tags Reviews
+// @Accept json
+// @Produce json
+// @Param id path string true "Book ID"
+// @Param review_id path string true "Review ID"
+// @Param ReviewRequest body dto.ReviewRequest true "Review"
+// @Success 200 {object} response.Response{data=models.Review} "Review updated successfully"
+// @Failure 400 {object} response.ErrorMessage "Invalid ID, rating or text"
+// @Failure 403 {object} response.ErrorMessage "Not the patron's review"
+// @Failure 404 {object} response.ErrorMessage "Review not found"
+// @Failure 500 {object} response.ErrorMessage "Internal server error"
+// @Security BearerAuth
+// @Router /books/{id}/reviews/{review_id} [put]
+func (h *reviewHandler) UpdateReview(c *fiber.Ctx) error {
+	userID := c.Locals("id").(uuid.UUID)
+
+	bookID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.HandleError(c, err, "invalid book ID", fiber.StatusBadRequest)
+	}
+	reviewID, err := uuid.Parse(c.Params("review_id"))
+	if err != nil {
+		return response.HandleError(c, err, "invalid review ID", fiber.StatusBadRequest)
+	}
+
+	var req dto.ReviewRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.HandleError(c, err, "invalid request payload", fiber.StatusBadRequest)
+	}
+
+	review, err := h.reviewService.UpdateReview(c.Context(), bookID, reviewID, userID, req)
+	if err != nil {
+		return reviewError(c, err, "failed to update review")
+	}
+
+	return response.HandleSuccess(c, "review updated successfully", review, fiber.StatusOK)
+}
+
+// DeleteReview godoc
+// @Summary Delete a review
+// @Description Patrons delete their own review
+// @Tags Reviews
+// @Produce json
+// @Param id path string true "Book ID"
+// @Param review_id path string true "Review ID"
+// @Success 200 {object} response.Response "Review deleted successfully"
+// @Failure 400 {object} response.ErrorMessage "Invalid ID"
+// @Failure 403 {object} response.ErrorMessage "Not the patron's review"
+// @Failure 404 {object} response.ErrorMessage "Review not found"
+// @Failure 500 {object} response.ErrorMessage "Internal server error"
+// @Security BearerAuth
+// @Router /books/{id}/reviews/{review_id} [delete]
+func (h *reviewHandler) DeleteReview(c *fiber.Ctx) error {
+	userID := c.Locals("id").(uuid.UUID)
+
+	bookID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.HandleError(c, err, "invalid book ID", fiber.StatusBadRequest)
+	}
+	reviewID, err := uuid.Parse(c.Params("review_id"))
+	if err != nil {
+		return response.HandleError(c, err, "invalid review ID", fiber.StatusBadRequest)
+	}
+
+	if err := h.reviewService.DeleteReview(c.Context(), bookID, reviewID, userID); err != nil {
+		return reviewError(c, err, "failed to delete review")
+	}
+
+	return response.HandleSuccess(c, "review deleted successfully", nil, fiber.StatusOK)
+}
+
+// ListBookReviews godoc
+// @Summary List the reviews of a book
+// @Description Retrieves a page of the reviews of a book, newest first, with its average rating. Reviews hidden by librarians are left out.
+// @Tags Reviews
+// @Produce json
+// @Param id path string true "Book ID"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param page_size query int false "Reviews per page, at most 100 (default 20)"
+// @Success 200 {object} response.Response{data=dto.ListReviewsResponse} "Reviews retrieved successfully"
+// @Failure 400 {object} response.ErrorMessage "Invalid book ID or cursor"
+// @Failure 404 {object} response.ErrorMessage "Book not found"
+// @Failure 500 {object} response.ErrorMessage "Internal server error"
+// @Router /books/{id}/reviews [get]
+func (h *reviewHandler) ListBookReviews(c *fiber.Ctx) error {
+	bookID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.HandleError(c, err, "invalid book ID", fiber.StatusBadRequest)
+	}
+
+	req := dto.ListReviewsRequest{
+		Cursor:   c.Query("cursor"),
+		PageSize: c.QueryInt("page_size"),
+	}
+
+	reviews, err := h.reviewService.ListBookReviews(c.Context(), bookID, req)
+	if err != nil {
+		return reviewError(c, err, "failed to retrieve reviews")
+	}
+
+	return response.HandleSuccess(c, "reviews retrieved successfully", reviews, fiber.StatusOK)
+}
+
+// ListReviews godoc
+// @Summary List reviews for moderation
+// @Description Librarian retrieves a page of reviews of every book or one, newest first, such as the flagged ones
+// @Tags Reviews
+// @Produce json
+// @Param book_id query string false "Book ID"
+// @Param status query string false "visible, flagged or hidden"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param page_size query int false "Reviews per page, at most 100 (default 20)"
+// @Success 200 {object} response.Response{data=dto.ListReviewsResponse} "Reviews retrieved successfully"
+// @Failure 400 {object} response.ErrorMessage "Invalid book ID, status or cursor"
+// @Failure 500 {object} response.ErrorMessage "Internal server error"
+// @Security BearerAuth
+// @Router /books/reviews [get]
+func (h *reviewHandler) ListReviews(c *fiber.Ctx) error {
+	req := dto.ListReviewsRequest{
+		Status:   c.Query("status"),
+		Cursor:   c.Query("cursor"),
+		PageSize: c.QueryInt("page_size"),
+	}
+	if bookID := c.Query("book_id"); bookID != "" {
+		id, err := uuid.Parse(bookID)
+		if err != nil {
+			return response.HandleError(c, err, "invalid book ID", fiber.StatusBadRequest)
+		}
+		req.BookID = id
+	}
+
+	reviews, err := h.reviewService.ListReviews(c.Context(), req)
+	if err != nil {
+		return reviewError(c, err, "failed to retrieve reviews")
+	}
+
+	return response.HandleSuccess(c, "reviews retrieved successfully", reviews, fiber.StatusOK)
+}
+
+// ModerateReview godoc
+// @Summary Moderate a review
+// @Description Librarian flags a review for a closer look, hides it from patrons and the book's rating, or makes it visible again
+// @Tags Reviews
+// @Accept json
+// @Produce json
+// @Param id path string true "Review ID"
+// @Param ModerateReviewRequest body dto.ModerateReviewRequest true "New status and note"
+// @Success 200 {object} response.Response{data=models.Review} "Review moderated successfully"
+// @Failure 400 {object} response.ErrorMessage "Invalid review ID or status"
+// @Failure 404 {object} response.ErrorMessage "Review not found"
+// @Failure 500 {object} response.ErrorMessage "Internal server error"
+// @Security BearerAuth
+// @Router /books/reviews/{id}/moderation [put]
+func (h *reviewHandler) ModerateReview(c *fiber.Ctx) error {
+	moderatorID := c.Locals("id").(uuid.UUID)
+
+	reviewID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.HandleError(c, err, "invalid review ID", fiber.StatusBadRequest)
+	}
+
+	var req dto.ModerateReviewRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.HandleError(c, err, "invalid request payload", fiber.StatusBadRequest)
+	}
+
+	review, err := h.reviewService.ModerateReview(c.Context(), reviewID, moderatorID, req)
+	if err != nil {
+		return reviewError(c, err, "failed to moderate review")
+	}
+
+	return response.HandleSuccess(c, "review moderated successfully", review, fiber.StatusOK)
+}
+
+// reviewError answers a failed review request with the status matching err,
+// or 500 with message.
+func reviewError(c *fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, service.ErrInvalidRating), errors.Is(err, service.ErrReviewTooLong),
+		errors.Is(err, service.ErrInvalidReviewStatus), errors.Is(err, pagination.ErrInvalidCursor):
+		return response.HandleError(c, err, "", fiber.StatusBadRequest)
+	case errors.Is(err, service.ErrReviewNotAllowed), errors.Is(err, service.ErrNotReviewAuthor):
+		return response.HandleError(c, err, "", fiber.StatusForbidden)
+	case errors.Is(err, service.ErrBookNotFound), errors.Is(err, service.ErrReviewNotFound):
+		return response.HandleError(c, err, "", fiber.StatusNotFound)
+	case errors.Is(err, service.ErrReviewExists):
+		return response.HandleError(c, err, "", fiber.StatusConflict)
+	}
+	log.Println(err)
+	return response.HandleError(c, err, message, fiber.StatusInternalServerError)
+}
@@ -2,22 +2,81 @@ package repository
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/sir-shalahuddin/grpc-learn/bookservice/proto/authservice"
+	"github.com/sir-shalahuddin/grpc-learn/pkg/cache"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
+// maxUserLookup is how many users userservice looks up in one GetUsersByIDs.
+const maxUserLookup = 500
+
+// RevocationChannel is the Redis channel revocation events are read from when
+// the Redis backend is used. Payload is a JSON RevocationEvent.
+const RevocationChannel = "auth:revocations"
+
+var errCachedInvalidToken = errors.New("token was rejected recently")
+
+// RevocationEvent tells the cache to forget a token or everything it knows
+// about a user.
+type RevocationEvent struct {
+	UserID    string `json:"user_id"`
+	TokenHash string `json:"token_hash"`
+}
+
+type cachedToken struct {
+	Valid      bool   `json:"valid"`
+	Generation string `json:"generation,omitempty"`
+	Response   []byte `json:"response,omitempty"`
+}
+
 type authRepository struct {
-	grpc authservice.AuthServiceClient
+	grpc        authservice.AuthServiceClient
+	cache       cache.Cache
+	ttl         time.Duration
+	negativeTTL time.Duration
+	counters    cache.Counters
+	// tokenTTL is how long accepted tokens are cached, see NewAuthRepository.
+	tokenTTL time.Duration
 }
 
-func NewAuthRepository(grpc authservice.AuthServiceClient) *authRepository {
-	return &authRepository{grpc: grpc}
+// NewAuthRepository creates an auth repository. A nil authCache disables caching.
+// Accepted tokens are cached for ttl in Redis, where HandleRevocation drops
+// them as soon as they are revoked. An in-process cache never hears about
+// revocations, so it keeps them for memoryTokenTTL at most, which is how long
+// a revoked token may still be accepted.
+func NewAuthRepository(grpc authservice.AuthServiceClient, authCache cache.Cache, ttl, negativeTTL, memoryTokenTTL time.Duration) *authRepository {
+	tokenTTL := ttl
+	if _, ok := authCache.(*cache.Redis); !ok && memoryTokenTTL < tokenTTL {
+		tokenTTL = memoryTokenTTL
+	}
+	return &authRepository{grpc: grpc, cache: authCache, ttl: ttl, negativeTTL: negativeTTL, tokenTTL: tokenTTL}
 }
 
 // GetUserByID retrieves a user by their ID using the AuthService gRPC client.
 func (r *authRepository) GetUserByID(ctx context.Context, userID string) (*authservice.User, error) {
+	key := userKey(userID)
+	if r.cache != nil {
+		if data, ok := r.cache.Get(ctx, key); ok {
+			user := &authservice.User{}
+			if err := proto.Unmarshal(data, user); err == nil {
+				r.counters.Hit()
+				return user, nil
+			}
+		}
+		r.counters.Miss()
+	}
+
 	// Prepare the gRPC request
 	req := &authservice.GetUserByIDRequest{
 		UserId: userID,
@@ -29,22 +88,227 @@ func (r *authRepository) GetUserByID(ctx context.Context, userID string) (*auths
 		return nil, fmt.Errorf("failed to get user by ID: %w", err)
 	}
 
+	if r.cache != nil && resp.User != nil {
+		if data, err := proto.Marshal(resp.User); err == nil {
+			r.cache.Set(ctx, key, data, r.ttl)
+		}
+	}
+
 	return resp.User, nil
 }
 
-// ValidateToken validates a JWT token using the AuthService gRPC client and returns the associated user ID if valid.
-func (r *authRepository) ValidateToken(ctx context.Context, token string) (string, error) {
+// GetUsersByIDs retrieves several users by ID, keyed by ID. Users missing
+// from the cache are looked up in as few GetUsersByIDs calls as possible;
+// unknown IDs are left out.
+func (r *authRepository) GetUsersByIDs(ctx context.Context, userIDs []string) (map[string]*authservice.User, error) {
+	users := make(map[string]*authservice.User, len(userIDs))
+	var missing []string
+	for _, id := range userIDs {
+		if _, ok := users[id]; ok {
+			continue
+		}
+		users[id] = nil
+		if r.cache != nil {
+			if data, ok := r.cache.Get(ctx, userKey(id)); ok {
+				user := &authservice.User{}
+				if err := proto.Unmarshal(data, user); err == nil {
+					r.counters.Hit()
+					users[id] = user
+					continue
+				}
+			}
+			r.counters.Miss()
+		}
+		missing = append(missing, id)
+	}
+
+	for start := 0; start < len(missing); start += maxUserLookup {
+		batch := missing[start:min(start+maxUserLookup, len(missing))]
+		resp, err := r.grpc.GetUsersByIDs(ctx, &authservice.GetUsersByIDsRequest{UserIds: batch})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get users by ID: %w", err)
+		}
+
+		for _, user := range resp.GetUsers() {
+			users[user.GetUserId()] = user
+			if r.cache != nil {
+				if data, err := proto.Marshal(user); err == nil {
+					r.cache.Set(ctx, userKey(user.GetUserId()), data, r.ttl)
+				}
+			}
+		}
+	}
+
+	for id, user := range users {
+		if user == nil {
+			delete(users, id)
+		}
+	}
+	return users, nil
+}
+
+// IntrospectToken validates a JWT token or API key using the AuthService gRPC client and returns the user's role and permissions if valid.
+// Answers are cached for a short while, rejected tokens for an even shorter one.
+func (r *authRepository) IntrospectToken(ctx context.Context, token string) (*authservice.IntrospectTokenResponse, error) {
+	key := tokenKey(HashToken(token))
+	if r.cache != nil {
+		if resp, ok, err := r.cachedIntrospection(ctx, key); ok {
+			return resp, err
+		}
+		r.counters.Miss()
+	}
+
 	// Prepare the gRPC request
-	req := &authservice.ValidateTokenRequest{
+	req := &authservice.IntrospectTokenRequest{
 		Token: token,
 	}
 
 	// Call the gRPC service
-	resp, err := r.grpc.ValidateToken(ctx, req)
+	resp, err := r.grpc.IntrospectToken(ctx, req)
 	if err != nil {
 		log.Println(err)
-		return "", fmt.Errorf("failed to validate token: %w", err)
+		if r.cache != nil && status.Code(err) == codes.Unauthenticated {
+			r.storeToken(ctx, key, cachedToken{Valid: false}, r.negativeTTL)
+		}
+		return nil, fmt.Errorf("failed to introspect token: %w", err)
+	}
+
+	if r.cache != nil {
+		data, err := proto.Marshal(resp)
+		if err == nil {
+			ttl := r.tokenTTL
+			if resp.GetExpiresAt() > 0 {
+				if untilExpiry := time.Until(time.Unix(resp.GetExpiresAt(), 0)); untilExpiry < ttl {
+					ttl = untilExpiry
+				}
+			}
+			entry := cachedToken{Valid: true, Generation: r.generation(ctx, resp.GetUserId()), Response: data}
+			r.storeToken(ctx, key, entry, ttl)
+		}
+	}
+
+	return resp, nil
+}
+
+// InvalidateToken drops the cached answer for a single token.
+func (r *authRepository) InvalidateToken(ctx context.Context, token string) {
+	r.invalidateTokenHash(ctx, HashToken(token))
+}
+
+// InvalidateUser drops the cached profile of a user and every cached token
+// that belongs to them.
+func (r *authRepository) InvalidateUser(ctx context.Context, userID string) {
+	if r.cache == nil || userID == "" {
+		return
+	}
+
+	// Tokens are keyed by hash, so instead of finding them all the user's
+	// generation is replaced and older entries stop matching.
+	r.cache.Set(ctx, generationKey(userID), []byte(newGeneration()), r.ttl)
+	r.cache.Delete(ctx, userKey(userID))
+	r.counters.Invalidation()
+}
+
+// HandleRevocation applies a revocation event received on RevocationChannel.
+func (r *authRepository) HandleRevocation(payload string) {
+	var event RevocationEvent
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		log.Printf("[AuthRepository - HandleRevocation] Error decoding event: %v", err)
+		return
+	}
+
+	ctx := context.Background()
+	if event.TokenHash != "" {
+		r.invalidateTokenHash(ctx, event.TokenHash)
+	}
+	if event.UserID != "" {
+		r.InvalidateUser(ctx, event.UserID)
+	}
+}
+
+// CacheStats reports how often the cache saved a call to userservice.
+func (r *authRepository) CacheStats() cache.Stats {
+	backend := "none"
+	if r.cache != nil {
+		backend = r.cache.Name()
+	}
+	return r.counters.Snapshot(backend)
+}
+
+// HashToken returns the key tokens are cached under, so raw tokens never
+// reach the cache backend.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (r *authRepository) cachedIntrospection(ctx context.Context, key string) (*authservice.IntrospectTokenResponse, bool, error) {
+	data, ok := r.cache.Get(ctx, key)
+	if !ok {
+		return nil, false, nil
+	}
+
+	var entry cachedToken
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, false, nil
 	}
+	if !entry.Valid {
+		r.counters.NegativeHit()
+		return nil, true, fmt.Errorf("failed to introspect token: %w", errCachedInvalidToken)
+	}
+
+	resp := &authservice.IntrospectTokenResponse{}
+	if err := proto.Unmarshal(entry.Response, resp); err != nil {
+		return nil, false, nil
+	}
+	if entry.Generation != r.generation(ctx, resp.GetUserId()) {
+		return nil, false, nil
+	}
+
+	r.counters.Hit()
+	return resp, true, nil
+}
+
+func (r *authRepository) storeToken(ctx context.Context, key string, entry cachedToken, ttl time.Duration) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	r.cache.Set(ctx, key, data, ttl)
+}
+
+func (r *authRepository) invalidateTokenHash(ctx context.Context, tokenHash string) {
+	if r.cache == nil {
+		return
+	}
+	r.cache.Delete(ctx, tokenKey(tokenHash))
+	r.counters.Invalidation()
+}
+
+func (r *authRepository) generation(ctx context.Context, userID string) string {
+	data, ok := r.cache.Get(ctx, generationKey(userID))
+	if !ok {
+		return ""
+	}
+	return string(data)
+}
+
+func newGeneration() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return time.Now().Format(time.RFC3339Nano)
+	}
+	return hex.EncodeToString(b)
+}
+
+func tokenKey(tokenHash string) string {
+	return "token:" + tokenHash
+}
+
+func userKey(userID string) string {
+	return "user:" + userID
+}
 
-	return resp.UserId, nil
+func generationKey(userID string) string {
+	return "user-gen:" + userID
 }
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/proto/authservice"
+	"github.com/sir-shalahuddin/grpc-learn/pkg/cache"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MockAuthServiceClient adalah implementasi mock dari AuthServiceClient yang
+// menghitung panggilan IntrospectToken.
+type MockAuthServiceClient struct {
+	authservice.AuthServiceClient
+	IntrospectTokenFunc func(token string) (*authservice.IntrospectTokenResponse, error)
+	Calls               int
+}
+
+func (m *MockAuthServiceClient) IntrospectToken(ctx context.Context, in *authservice.IntrospectTokenRequest, opts ...grpc.CallOption) (*authservice.IntrospectTokenResponse, error) {
+	m.Calls++
+	return m.IntrospectTokenFunc(in.GetToken())
+}
+
+func acceptingClient() *MockAuthServiceClient {
+	return &MockAuthServiceClient{
+		IntrospectTokenFunc: func(token string) (*authservice.IntrospectTokenResponse, error) {
+			return &authservice.IntrospectTokenResponse{UserId: "user-1", Role: "user", AccountStatus: "active"}, nil
+		},
+	}
+}
+
+// Test IntrospectToken: Token yang diterima di-cache di memori, paling lama
+// selama memoryTokenTTL
+func TestIntrospectToken_MemoryCache(t *testing.T) {
+	ctx := context.Background()
+	client := acceptingClient()
+	repo := NewAuthRepository(client, cache.NewLRU(10), time.Minute, time.Minute, 30*time.Millisecond)
+
+	for i := 0; i < 3; i++ {
+		if _, err := repo.IntrospectToken(ctx, "token"); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+	if client.Calls != 1 {
+		t.Errorf("expected 1 call to userservice, got %d", client.Calls)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if _, err := repo.IntrospectToken(ctx, "token"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if client.Calls != 2 {
+		t.Errorf("expected the token to be introspected again after memoryTokenTTL, got %d calls", client.Calls)
+	}
+
+	stats := repo.CacheStats()
+	if stats.Backend != "memory" || stats.Hits != 2 || stats.Misses != 2 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+// Test IntrospectToken: Token yang ditolak di-cache sebagai negative hit
+func TestIntrospectToken_NegativeCache(t *testing.T) {
+	ctx := context.Background()
+	client := &MockAuthServiceClient{
+		IntrospectTokenFunc: func(token string) (*authservice.IntrospectTokenResponse, error) {
+			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		},
+	}
+	repo := NewAuthRepository(client, cache.NewLRU(10), time.Minute, time.Minute, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		if _, err := repo.IntrospectToken(ctx, "bad"); err == nil {
+			t.Fatal("expected an error")
+		}
+	}
+	if client.Calls != 1 {
+		t.Errorf("expected 1 call to userservice, got %d", client.Calls)
+	}
+	if stats := repo.CacheStats(); stats.NegativeHits != 1 {
+		t.Errorf("NegativeHits = %d, want 1", stats.NegativeHits)
+	}
+}
+
+// Test IntrospectToken: Error selain Unauthenticated tidak di-cache
+func TestIntrospectToken_UnavailableNotCached(t *testing.T) {
+	ctx := context.Background()
+	client := &MockAuthServiceClient{
+		IntrospectTokenFunc: func(token string) (*authservice.IntrospectTokenResponse, error) {
+			return nil, status.Error(codes.Unavailable, "userservice is down")
+		},
+	}
+	repo := NewAuthRepository(client, cache.NewLRU(10), time.Minute, time.Minute, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		repo.IntrospectToken(ctx, "token")
+	}
+	if client.Calls != 2 {
+		t.Errorf("expected 2 calls to userservice, got %d", client.Calls)
+	}
+}
+
+// Test HandleRevocation: Token dan semua token milik user dilupakan
+func TestHandleRevocation(t *testing.T) {
+	ctx := context.Background()
+	client := acceptingClient()
+	repo := NewAuthRepository(client, cache.NewLRU(10), time.Minute, time.Minute, time.Minute)
+
+	repo.IntrospectToken(ctx, "token")
+	repo.HandleRevocation(`{"token_hash": "` + HashToken("token") + `"}`)
+	repo.IntrospectToken(ctx, "token")
+	if client.Calls != 2 {
+		t.Errorf("expected the revoked token to be introspected again, got %d calls", client.Calls)
+	}
+
+	repo.HandleRevocation(`{"user_id": "user-1"}`)
+	repo.IntrospectToken(ctx, "token")
+	if client.Calls != 3 {
+		t.Errorf("expected the tokens of a revoked user to be introspected again, got %d calls", client.Calls)
+	}
+
+	repo.HandleRevocation(`not json`)
+	repo.IntrospectToken(ctx, "token")
+	if client.Calls != 3 {
+		t.Errorf("expected a bad event to be ignored, got %d calls", client.Calls)
+	}
+}
+
+// Test NewAuthRepository: Tanpa cache setiap token ditanyakan ke userservice
+func TestIntrospectToken_NoCache(t *testing.T) {
+	client := acceptingClient()
+	repo := NewAuthRepository(client, nil, time.Minute, time.Minute, time.Minute)
+
+	repo.IntrospectToken(context.Background(), "token")
+	repo.IntrospectToken(context.Background(), "token")
+	if client.Calls != 2 {
+		t.Errorf("expected 2 calls to userservice, got %d", client.Calls)
+	}
+	if stats := repo.CacheStats(); stats.Backend != "none" {
+		t.Errorf("Backend = %q, want none", stats.Backend)
+	}
+}
@@ -0,0 +1,259 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/models"
+)
+
+// authorSortKeys orders authors by name, case-insensitively.
+var authorSortKeys = []sortKey{{expr: "lower(a.name)"}, {expr: "a.id"}}
+
+type AuthorRepository struct {
+	db *sql.DB
+}
+
+func NewAuthorRepository(db *sql.DB) *AuthorRepository {
+	return &AuthorRepository{db: db}
+}
+
+// GetAuthor returns an author with the number of books they are credited on.
+// The ID of a merged author returns the author it was merged into.
+func (r *AuthorRepository) GetAuthor(ctx context.Context, id uuid.UUID) (*models.Author, error) {
+	var author models.Author
+	err := r.db.QueryRowContext(ctx, `
+		SELECT a.id, a.name, a.created_at,
+			(SELECT COUNT(DISTINCT book_id) FROM book_authors WHERE author_id = a.id)
+		FROM authors a
+		WHERE a.id = COALESCE((SELECT target_id FROM author_aliases WHERE alias_id = $1), $1)`, id,
+	).Scan(&author.ID, &author.Name, &author.CreatedAt, &author.BookCount)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get author: %w", err)
+	}
+	return &author, nil
+}
+
+// ListAuthors lists a page of authors whose name contains filter.Name, by
+// name, starting after the author whose sort keys are filter.After. It also
+// returns the sort keys of the last author when more authors follow.
+func (r *AuthorRepository) ListAuthors(ctx context.Context, filter models.AuthorFilter) ([]models.Author, []string, error) {
+	if err := checkCursor(authorSortKeys, filter.After); err != nil {
+		return nil, nil, err
+	}
+
+	query := `
+		SELECT a.id, a.name, a.created_at,
+			(SELECT COUNT(DISTINCT book_id) FROM book_authors WHERE author_id = a.id),
+			` + keyColumn(authorSortKeys) + `
+		FROM authors a
+		WHERE TRUE`
+	args := []interface{}{}
+
+	if filter.Name != "" {
+		args = append(args, "%"+escapeLike(filter.Name)+"%")
+		query += fmt.Sprintf(" AND a.name ILIKE $%d", len(args))
+	}
+	if filter.After != nil {
+		condition, afterArgs := keysetCondition(authorSortKeys, filter.After, len(args)+1)
+		query += " AND " + condition
+		args = append(args, afterArgs...)
+	}
+
+	// One author more than asked for tells whether another page follows
+	query += fmt.Sprintf(" ORDER BY %s LIMIT $%d", orderBy(authorSortKeys), len(args)+1)
+	args = append(args, filter.Limit+1)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list authors: %w", err)
+	}
+	defer rows.Close()
+
+	authors := []models.Author{}
+	var lastKeys, nextKeys []string
+	for rows.Next() {
+		if len(authors) == filter.Limit {
+			nextKeys = lastKeys
+			break
+		}
+
+		var author models.Author
+		var rowKeys pq.StringArray
+		if err := rows.Scan(&author.ID, &author.Name, &author.CreatedAt, &author.BookCount, &rowKeys); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan author: %w", err)
+		}
+		authors = append(authors, author)
+		lastKeys = rowKeys
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to list authors: %w", err)
+	}
+
+	return authors, nextKeys, nil
+}
+
+// MergeAuthor credits every book of the author fromID to toID instead,
+// refreshes the author names of those books and deletes fromID, whose ID keeps
+// resolving to toID. It returns how many books were moved.
+func (r *AuthorRepository) MergeAuthor(ctx context.Context, fromID, toID uuid.UUID) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var bookIDs pq.StringArray
+	err = tx.QueryRowContext(ctx, `SELECT COALESCE(array_agg(DISTINCT book_id::text), '{}') FROM book_authors WHERE author_id = $1`, fromID).Scan(&bookIDs)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get books of author: %w", err)
+	}
+
+	// A book crediting both authors in the same role keeps a single credit
+	statements := []string{
+		`INSERT INTO book_authors (book_id, author_id, role, position)
+			SELECT book_id, $2, role, position FROM book_authors WHERE author_id = $1
+			ON CONFLICT DO NOTHING`,
+		`DELETE FROM book_authors WHERE author_id = $1`,
+		`UPDATE author_aliases SET target_id = $2 WHERE target_id = $1`,
+		`INSERT INTO author_aliases (alias_id, target_id) VALUES ($1, $2)`,
+		`DELETE FROM authors WHERE id = $1`,
+	}
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement, fromID, toID); err != nil {
+			return 0, fmt.Errorf("failed to merge author: %w", err)
+		}
+	}
+
+	if err := refreshAuthorNames(ctx, tx, bookIDs); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit author merge: %w", err)
+	}
+	return int64(len(bookIDs)), nil
+}
+
+// ListContributors returns the contributors of each book, in order.
+func (r *BookRepository) ListContributors(ctx context.Context, bookIDs []uuid.UUID) (map[uuid.UUID][]models.BookContributor, error) {
+	contributors := make(map[uuid.UUID][]models.BookContributor, len(bookIDs))
+	if len(bookIDs) == 0 {
+		return contributors, nil
+	}
+
+	ids := make([]string, len(bookIDs))
+	for i, id := range bookIDs {
+		ids[i] = id.String()
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT ba.book_id, a.id, a.name, ba.role, ba.position
+		FROM book_authors ba
+		JOIN authors a ON a.id = ba.author_id
+		WHERE ba.book_id = ANY($1::uuid[])
+		ORDER BY ba.book_id, ba.position, a.name`, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list contributors: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var bookID uuid.UUID
+		var contributor models.BookContributor
+		if err := rows.Scan(&bookID, &contributor.AuthorID, &contributor.Name, &contributor.Role, &contributor.Position); err != nil {
+			return nil, fmt.Errorf("failed to scan contributor: %w", err)
+		}
+		contributors[bookID] = append(contributors[bookID], contributor)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list contributors: %w", err)
+	}
+	return contributors, nil
+}
+
+// writeContributors replaces the contributors of a book, numbering them in
+// order, and refreshes its author names. Contributors without an AuthorID are
+// matched to an author by name, case-insensitively, or added as one.
+func writeContributors(ctx context.Context, tx *sql.Tx, bookID uuid.UUID, contributors []models.BookContributor) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM book_authors WHERE book_id = $1`, bookID); err != nil {
+		return fmt.Errorf("failed to clear contributors: %w", err)
+	}
+
+	for i, contributor := range contributors {
+		authorID := contributor.AuthorID
+		if authorID == uuid.Nil {
+			var err error
+			if authorID, err = findOrAddAuthor(ctx, tx, contributor.Name); err != nil {
+				return err
+			}
+		}
+
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO book_authors (book_id, author_id, role, position)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT DO NOTHING`,
+			bookID, authorID, contributor.Role, i,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to add contributor: %w", err)
+		}
+	}
+
+	return refreshAuthorNames(ctx, tx, []string{bookID.String()})
+}
+
+func findOrAddAuthor(ctx context.Context, tx *sql.Tx, name string) (uuid.UUID, error) {
+	name = strings.TrimSpace(name)
+
+	var id uuid.UUID
+	err := tx.QueryRowContext(ctx, `SELECT id FROM authors WHERE lower(name) = lower($1) ORDER BY created_at LIMIT 1`, name).Scan(&id)
+	if err == nil {
+		return id, nil
+	}
+	if err != sql.ErrNoRows {
+		return uuid.Nil, fmt.Errorf("failed to find author: %w", err)
+	}
+
+	if err := tx.QueryRowContext(ctx, `INSERT INTO authors (name) VALUES ($1) RETURNING id`, name).Scan(&id); err != nil {
+		return uuid.Nil, fmt.Errorf("failed to add author: %w", err)
+	}
+	return id, nil
+}
+
+// refreshAuthorNames sets the author column of books to the names of their
+// contributors in the author role, in order, so listings, sorting and search
+// keep working off a single column. Books with no author, such as edited
+// volumes, show their first contributor.
+func refreshAuthorNames(ctx context.Context, tx *sql.Tx, bookIDs []string) error {
+	if len(bookIDs) == 0 {
+		return nil
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		UPDATE books b
+		SET author = left(COALESCE(c.authors, c.first), 255)
+		FROM (
+			SELECT ba.book_id,
+				string_agg(a.name, ', ' ORDER BY ba.position) FILTER (WHERE ba.role = 'author') AS authors,
+				(array_agg(a.name ORDER BY ba.position))[1] AS first
+			FROM book_authors ba
+			JOIN authors a ON a.id = ba.author_id
+			WHERE ba.book_id = ANY($1::uuid[])
+			GROUP BY ba.book_id
+		) c
+		WHERE b.id = c.book_id`, pq.Array(bookIDs))
+	if err != nil {
+		return fmt.Errorf("failed to refresh author names: %w", err)
+	}
+	return nil
+}
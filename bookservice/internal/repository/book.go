@@ -3,11 +3,16 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"html"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/sir-shalahuddin/grpc-learn/bookservice/models"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/search"
 )
 
 type BookRepository struct {
@@ -18,19 +23,44 @@ func NewBookRepository(db *sql.DB) *BookRepository {
 	return &BookRepository{db: db}
 }
 
+// AddBook inserts a book with its contributors and sets its ID. A book
+// without contributors is credited to its Author.
 func (r *BookRepository) AddBook(ctx context.Context, book *models.Book) error {
-	_, err := r.db.ExecContext(ctx, `
-		INSERT INTO books (title, author, isbn, published_date, category_id, stock, added_by)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
-		book.Title, book.Author, book.ISBN, book.PublishedDate, book.CategoryID, book.Stock, book.AddedBy,
-	)
-	return err
+	contributors := book.Contributors
+	if len(contributors) == 0 {
+		contributors = []models.BookContributor{{Name: book.Author, Role: models.RoleAuthor}}
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO books (title, author, isbn, isbn13, published_date, category_id, stock, added_by)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, $8)
+		RETURNING id`,
+		book.Title, book.Author, book.ISBN, book.ISBN13, book.PublishedDate, book.CategoryID, book.Stock, book.AddedBy,
+	).Scan(&book.ID)
+	if err != nil {
+		if isISBNConflict(err) {
+			return models.ErrDuplicateISBN
+		}
+		return fmt.Errorf("failed to add book: %w", err)
+	}
+
+	if err := writeContributors(ctx, tx, book.ID, contributors); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 func (r *BookRepository) GetBookByID(ctx context.Context, id uuid.UUID) (*models.Book, error) {
-	row := r.db.QueryRowContext(ctx, `SELECT id, title, author, isbn, published_date, category_id, stock, added_by, created_at, updated_at, version FROM books WHERE id = $1`, id)
+	row := r.db.QueryRowContext(ctx, `SELECT id, title, author, isbn, COALESCE(isbn13, ''), published_date, category_id, stock, added_by, created_at, updated_at, version, rating_average, rating_count FROM books WHERE id = $1`, id)
 	var book models.Book
-	err := row.Scan(&book.ID, &book.Title, &book.Author, &book.ISBN, &book.PublishedDate, &book.CategoryID, &book.Stock, &book.AddedBy, &book.CreatedAt, &book.UpdatedAt, &book.Version)
+	err := row.Scan(&book.ID, &book.Title, &book.Author, &book.ISBN, &book.ISBN13, &book.PublishedDate, &book.CategoryID, &book.Stock, &book.AddedBy, &book.CreatedAt, &book.UpdatedAt, &book.Version, &book.RatingAverage, &book.RatingCount)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -40,10 +70,12 @@ func (r *BookRepository) GetBookByID(ctx context.Context, id uuid.UUID) (*models
 	return &book, nil
 }
 
-func (r *BookRepository) GetBookByISBN(ctx context.Context, isbn string) (*models.Book, error) {
-	row := r.db.QueryRowContext(ctx, `SELECT id, title, author, isbn, published_date, category_id, stock, added_by, created_at, updated_at, version FROM books WHERE isbn = $1`, isbn)
+// GetBookByISBN returns a book by the ISBN-13 form of its ISBN, so a book
+// entered with an ISBN-10 is found by its ISBN-13 too.
+func (r *BookRepository) GetBookByISBN(ctx context.Context, isbn13 string) (*models.Book, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT id, title, author, isbn, COALESCE(isbn13, ''), published_date, category_id, stock, added_by, created_at, updated_at, version, rating_average, rating_count FROM books WHERE isbn13 = $1`, isbn13)
 	var book models.Book
-	err := row.Scan(&book.ID, &book.Title, &book.Author, &book.ISBN, &book.PublishedDate, &book.CategoryID, &book.Stock, &book.AddedBy, &book.CreatedAt, &book.UpdatedAt, &book.Version)
+	err := row.Scan(&book.ID, &book.Title, &book.Author, &book.ISBN, &book.ISBN13, &book.PublishedDate, &book.CategoryID, &book.Stock, &book.AddedBy, &book.CreatedAt, &book.UpdatedAt, &book.Version, &book.RatingAverage, &book.RatingCount)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -53,22 +85,40 @@ func (r *BookRepository) GetBookByISBN(ctx context.Context, isbn string) (*model
 	return &book, nil
 }
 
+// UpdateBook updates a book, within tx when one is given. Its contributors
+// are only replaced when book.Contributors is set.
 func (r *BookRepository) UpdateBook(ctx context.Context, tx *sql.Tx, book *models.Book) error {
+	if tx == nil && book.Contributors != nil {
+		ownTx, err := r.db.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer ownTx.Rollback()
+
+		if err := r.UpdateBook(ctx, ownTx, book); err != nil {
+			return err
+		}
+		return ownTx.Commit()
+	}
+
 	query := `
 		UPDATE books
-		SET title = $1, author = $2, isbn = $3, published_date = $4, category_id = $5, stock = $6, added_by = $7, updated_at = $8, version = version + 1
-		WHERE id = $9 AND version = $10
+		SET title = $1, author = $2, isbn = $3, isbn13 = NULLIF($4, ''), published_date = $5, category_id = $6, stock = $7, added_by = $8, updated_at = $9, version = version + 1
+		WHERE id = $10 AND version = $11
 	`
 	var result sql.Result
 	var err error
 
 	if tx != nil {
-		result, err = tx.ExecContext(ctx, query, book.Title, book.Author, book.ISBN, book.PublishedDate, book.CategoryID, book.Stock, book.AddedBy, time.Now(), book.ID, book.Version)
+		result, err = tx.ExecContext(ctx, query, book.Title, book.Author, book.ISBN, book.ISBN13, book.PublishedDate, book.CategoryID, book.Stock, book.AddedBy, time.Now(), book.ID, book.Version)
 	} else {
-		result, err = r.db.ExecContext(ctx, query, book.Title, book.Author, book.ISBN, book.PublishedDate, book.CategoryID, book.Stock, book.AddedBy, time.Now(), book.ID, book.Version)
+		result, err = r.db.ExecContext(ctx, query, book.Title, book.Author, book.ISBN, book.ISBN13, book.PublishedDate, book.CategoryID, book.Stock, book.AddedBy, time.Now(), book.ID, book.Version)
 	}
 
 	if err != nil {
+		if isISBNConflict(err) {
+			return models.ErrDuplicateISBN
+		}
 		return fmt.Errorf("failed to update book: %w", err)
 	}
 
@@ -81,6 +131,10 @@ func (r *BookRepository) UpdateBook(ctx context.Context, tx *sql.Tx, book *model
 		return fmt.Errorf("conflict detected: book record was modified by another user")
 	}
 
+	if book.Contributors != nil {
+		return writeContributors(ctx, tx, book.ID, book.Contributors)
+	}
+
 	return nil
 }
 
@@ -92,47 +146,263 @@ func (r *BookRepository) DeleteBook(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
-func (r *BookRepository) ListBooks(ctx context.Context, title, author, category string, limit, offset int) ([]*models.Book, error) {
-	query := `SELECT id, title, author, isbn, published_date, category_id, stock, added_by, created_at, updated_at, version FROM books WHERE 1=1`
-	args := []interface{}{}
-	argIndex := 1
+// CountByCategory returns how many books are in a category.
+func (r *BookRepository) CountByCategory(ctx context.Context, categoryID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM books WHERE category_id = $1`, categoryID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count books by category: %w", err)
+	}
+	return count, nil
+}
+
+// ReassignCategory moves every book of one category to another and returns
+// how many were moved.
+func (r *BookRepository) ReassignCategory(ctx context.Context, fromID, toID uuid.UUID) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE books
+		SET category_id = $1, updated_at = $2, version = version + 1
+		WHERE category_id = $3`,
+		toID, time.Now(), fromID,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reassign book category: %w", err)
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	return updated, nil
+}
+
+// ListBooks lists a page of books matching the filter in the order it asks
+// for, starting after the book whose sort keys are filter.After. With a search
+// query, books also come with their rank and highlighted matches and are
+// ordered by relevance unless another order is given. It also returns the
+// sort keys of the last book when more books follow.
+func (r *BookRepository) ListBooks(ctx context.Context, filter models.BookFilter) ([]*models.Book, []string, error) {
+	where, args := bookConditions(filter, "")
 
-	if title != "" {
-		query += fmt.Sprintf(" AND title LIKE $%d", argIndex)
-		args = append(args, "%"+title+"%")
-		argIndex++
+	searching := search.PrefixQuery(filter.Query) != ""
+	sort := filter.Sort
+	if sort == "" && searching {
+		sort = models.SortRelevance
 	}
-	if author != "" {
-		query += fmt.Sprintf(" AND author LIKE $%d", argIndex)
-		args = append(args, "%"+author+"%")
-		argIndex++
+	keys := bookSortKeys[sort]
+	if err := checkCursor(keys, filter.After); err != nil {
+		return nil, nil, err
 	}
-	if category != "" {
-		query += fmt.Sprintf(" AND category_id = $%d", argIndex)
-		args = append(args, category)
-		argIndex++
+
+	query := `SELECT id, title, author, isbn, COALESCE(isbn13, ''), published_date, category_id, stock, added_by, created_at, updated_at, version, rating_average, rating_count`
+	if searching {
+		// bookConditions passes the search query as $1
+		args = append(args, highlightOptions)
+		query += fmt.Sprintf(`, `+rankExpr+`,
+			ts_headline('simple', title, to_tsquery('simple', $1), $%[1]d),
+			ts_headline('simple', author, to_tsquery('simple', $1), $%[1]d)`, len(args))
+	}
+	query += `, ` + keyColumn(keys) + ` FROM books WHERE ` + where
+
+	if filter.After != nil {
+		condition, afterArgs := keysetCondition(keys, filter.After, len(args)+1)
+		query += ` AND ` + condition
+		args = append(args, afterArgs...)
 	}
 
-	// Add pagination: LIMIT and OFFSET
-	query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argIndex, argIndex+1)
-	args = append(args, limit, offset)
+	// One book more than asked for tells whether another page follows
+	query += fmt.Sprintf(" ORDER BY %s LIMIT $%d", orderBy(keys), len(args)+1)
+	args = append(args, filter.Limit+1)
 
 	// Execute the query
 	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list books: %w", err)
+		return nil, nil, fmt.Errorf("failed to list books: %w", err)
 	}
 	defer rows.Close()
 
 	// Parse the result set
-	var books []*models.Book
+	var (
+		books    []*models.Book
+		lastKeys []string
+		nextKeys []string
+	)
 	for rows.Next() {
+		if len(books) == filter.Limit {
+			nextKeys = lastKeys
+			break
+		}
+
 		var book models.Book
-		if err := rows.Scan(&book.ID, &book.Title, &book.Author, &book.ISBN, &book.PublishedDate, &book.CategoryID, &book.Stock, &book.AddedBy, &book.CreatedAt, &book.UpdatedAt, &book.Version); err != nil {
-			return nil, fmt.Errorf("failed to scan book: %w", err)
+		var rowKeys pq.StringArray
+		dest := []interface{}{&book.ID, &book.Title, &book.Author, &book.ISBN, &book.ISBN13, &book.PublishedDate, &book.CategoryID, &book.Stock, &book.AddedBy, &book.CreatedAt, &book.UpdatedAt, &book.Version, &book.RatingAverage, &book.RatingCount}
+		if searching {
+			book.Highlight = &models.BookHighlight{}
+			dest = append(dest, &book.Rank, &book.Highlight.Title, &book.Highlight.Author)
+		}
+		dest = append(dest, &rowKeys)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan book: %w", err)
+		}
+		if book.Highlight != nil {
+			book.Highlight.Title = markHighlights(book.Highlight.Title)
+			book.Highlight.Author = markHighlights(book.Highlight.Author)
 		}
 		books = append(books, &book)
+		lastKeys = rowKeys
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to list books: %w", err)
+	}
+
+	return books, nextKeys, nil
+}
+
+// CountFacets counts the books matching the filter per category and per
+// availability. Each facet ignores its own filter, so the counts show what
+// choosing another category or availability would return.
+func (r *BookRepository) CountFacets(ctx context.Context, filter models.BookFilter) (*models.BookFacets, error) {
+	facets := &models.BookFacets{Categories: []models.CategoryFacet{}}
+
+	where, args := bookConditions(filter, facetCategory)
+	rows, err := r.db.QueryContext(ctx, `SELECT category_id, COUNT(*) FROM books WHERE `+where+` GROUP BY category_id ORDER BY COUNT(*) DESC`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count books by category: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var facet models.CategoryFacet
+		if err := rows.Scan(&facet.CategoryID, &facet.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan category facet: %w", err)
+		}
+		facets.Categories = append(facets.Categories, facet)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to count books by category: %w", err)
+	}
+
+	where, args = bookConditions(filter, facetAvailability)
+	query := `SELECT COUNT(*) FILTER (WHERE stock > 0), COUNT(*) FILTER (WHERE stock IS NULL OR stock <= 0) FROM books WHERE ` + where
+	if err := r.db.QueryRowContext(ctx, query, args...).Scan(&facets.Available, &facets.Unavailable); err != nil {
+		return nil, fmt.Errorf("failed to count books by availability: %w", err)
+	}
+
+	return facets, nil
+}
+
+// Facets CountFacets leaves out of the filter it counts over.
+const (
+	facetCategory     = "category"
+	facetAvailability = "availability"
+)
+
+// rankExpr is the relevance of a book to the search query, which
+// bookConditions passes as $1.
+const rankExpr = "ts_rank(search_vector, to_tsquery('simple', $1))"
+
+// bookSortKeys maps each models.BookSort to its keyset sort keys. Books
+// without a date sort last either way, and id comes last so books with equal
+// keys keep a stable order across pages.
+var bookSortKeys = map[string][]sortKey{
+	"":                           {{expr: "title"}, {expr: "id"}},
+	models.SortTitle:             {{expr: "title"}, {expr: "id"}},
+	models.SortTitleDesc:         {{expr: "title", desc: true}, {expr: "id"}},
+	models.SortAuthor:            {{expr: "author"}, {expr: "title"}, {expr: "id"}},
+	models.SortAuthorDesc:        {{expr: "author", desc: true}, {expr: "title"}, {expr: "id"}},
+	models.SortPublishedDate:     {{expr: "COALESCE(published_date, 'infinity'::date)"}, {expr: "id"}},
+	models.SortPublishedDateDesc: {{expr: "COALESCE(published_date, '-infinity'::date)", desc: true}, {expr: "id"}},
+	models.SortRating:            {{expr: "rating_average"}, {expr: "rating_count"}, {expr: "id"}},
+	models.SortRatingDesc:        {{expr: "rating_average", desc: true}, {expr: "rating_count", desc: true}, {expr: "id", desc: true}},
+	models.SortNewest:            {{expr: "COALESCE(created_at, '-infinity'::timestamp)", desc: true}, {expr: "id"}},
+	models.SortRelevance:         {{expr: rankExpr, desc: true}, {expr: "title"}, {expr: "id"}},
+}
+
+// bookConditions builds the WHERE clause for filter and its arguments. A
+// search query is always $1. skip names a facet whose filter is left out.
+func bookConditions(filter models.BookFilter, skip string) (string, []interface{}) {
+	conditions := []string{"TRUE"}
+	args := []interface{}{}
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
 	}
 
-	return books, nil
+	if tsQuery := search.PrefixQuery(filter.Query); tsQuery != "" {
+		add("search_vector @@ to_tsquery('simple', $%d)", tsQuery)
+	}
+	if filter.Title != "" {
+		add("title ILIKE $%d", "%"+escapeLike(filter.Title)+"%")
+	}
+	if filter.Author != "" {
+		// Editors and translators are not in the author column
+		add(`(author ILIKE $%[1]d OR id IN (
+			SELECT ba.book_id FROM book_authors ba JOIN authors a ON a.id = ba.author_id WHERE a.name ILIKE $%[1]d))`,
+			"%"+escapeLike(filter.Author)+"%")
+	}
+	if filter.AuthorID != uuid.Nil {
+		if filter.AuthorRole != "" {
+			args = append(args, filter.AuthorID, filter.AuthorRole)
+			conditions = append(conditions, fmt.Sprintf("id IN (SELECT book_id FROM book_authors WHERE author_id = $%d AND role = $%d)", len(args)-1, len(args)))
+		} else {
+			add("id IN (SELECT book_id FROM book_authors WHERE author_id = $%d)", filter.AuthorID)
+		}
+	}
+	if len(filter.CategoryIDs) > 0 && skip != facetCategory {
+		add("category_id = ANY($%d::uuid[])", pq.Array(filter.CategoryIDs))
+	}
+	if filter.Available != nil && skip != facetAvailability {
+		if *filter.Available {
+			conditions = append(conditions, "stock > 0")
+		} else {
+			conditions = append(conditions, "(stock IS NULL OR stock <= 0)")
+		}
+	}
+	if filter.PublishedFrom != nil {
+		add("published_date >= $%d", *filter.PublishedFrom)
+	}
+	if filter.PublishedTo != nil {
+		add("published_date <= $%d", *filter.PublishedTo)
+	}
+	if filter.HasISBN != nil {
+		if *filter.HasISBN {
+			conditions = append(conditions, "COALESCE(isbn, '') <> ''")
+		} else {
+			conditions = append(conditions, "COALESCE(isbn, '') = ''")
+		}
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+// highlightStart and highlightStop delimit matches in ts_headline results.
+// They are control characters so that nothing in a title or author can be
+// mistaken for them once the text is escaped.
+const (
+	highlightStart = "\x02"
+	highlightStop  = "\x03"
+)
+
+// highlightOptions configures ts_headline to mark every match in the whole
+// title or author rather than cutting out fragments.
+const highlightOptions = "StartSel=" + highlightStart + ", StopSel=" + highlightStop + ", HighlightAll=true"
+
+// markHighlights escapes a ts_headline result for HTML and wraps the matches
+// in <mark> tags, so the highlights can be shown as markup safely.
+func markHighlights(headline string) string {
+	marks := strings.NewReplacer(highlightStart, "<mark>", highlightStop, "</mark>")
+	return marks.Replace(html.EscapeString(headline))
+}
+
+// isISBNConflict reports whether err comes from saving a book with an ISBN
+// another book already has.
+func isISBNConflict(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "idx_books_isbn13"
+}
+
+// escapeLike makes % and _ in s match literally in a LIKE pattern.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
 }
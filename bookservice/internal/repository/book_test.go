@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lib/pq"
+
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/models"
+)
+
+// Test bookConditions: Facet kategori dan ketersediaan tidak difilter oleh
+// pilihannya sendiri, tapi tetap oleh filter lain
+func TestBookConditions_FacetSkip(t *testing.T) {
+	yes, no := true, false
+	categories := []string{"0b8f7c62-7f3c-4a4b-9d1e-2b2f5d6c7a10"}
+
+	tests := []struct {
+		name   string
+		filter models.BookFilter
+		skip   string
+		where  string
+		args   []interface{}
+	}{
+		{"no filter", models.BookFilter{}, "", "TRUE", []interface{}{}},
+		{"listing", models.BookFilter{CategoryIDs: categories, Available: &yes}, "",
+			"TRUE AND category_id = ANY($1::uuid[]) AND stock > 0", []interface{}{pq.Array(categories)}},
+		{"category facet", models.BookFilter{CategoryIDs: categories, Available: &no}, facetCategory,
+			"TRUE AND (stock IS NULL OR stock <= 0)", []interface{}{}},
+		{"availability facet", models.BookFilter{CategoryIDs: categories, Available: &yes}, facetAvailability,
+			"TRUE AND category_id = ANY($1::uuid[])", []interface{}{pq.Array(categories)}},
+		{"search stays $1", models.BookFilter{Query: "dune", Title: "100%", CategoryIDs: categories}, facetCategory,
+			"TRUE AND search_vector @@ to_tsquery('simple', $1) AND title ILIKE $2", []interface{}{"dune:*", `%100\%%`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			where, args := bookConditions(tt.filter, tt.skip)
+			if where != tt.where || !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("bookConditions() = %q, %v, want %q, %v", where, args, tt.where, tt.args)
+			}
+		})
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/sir-shalahuddin/grpc-learn/bookservice/models"
 )
 
@@ -54,56 +56,67 @@ func (r *BorrowingRecordRepository) DeleteBorrowingRecord(ctx context.Context, i
 	return err
 }
 
-func (r *BorrowingRecordRepository) ListBorrowingRecords(ctx context.Context, userID uuid.UUID, queries map[string]string) ([]models.BorrowingRecord, error) {
+// ListBorrowingRecords lists the borrowing records matching the filter,
+// starting after the record whose sort keys are filter.After. It also returns
+// the sort keys of the last record when more records follow. Records of
+// users who erased their data have a zero UserID.
+func (r *BorrowingRecordRepository) ListBorrowingRecords(ctx context.Context, filter models.BorrowingRecordFilter) ([]models.BorrowingRecord, []string, error) {
+	keys := recordSortKeys(filter.Sort, filter.Order)
+	if err := checkCursor(keys, filter.After); err != nil {
+		return nil, nil, err
+	}
+
+	where, args := recordConditions(filter)
 	baseQuery := `
         SELECT 
             br.id, br.user_id, br.borrowed_at, br.due_date, br.returned_at,
-            b.id, b.title, b.author, b.isbn, b.published_date, b.category_id, b.stock, b.added_by, b.created_at, b.updated_at, b.version
+            b.id, b.title, b.author, b.isbn, COALESCE(b.isbn13, ''), b.published_date, b.category_id, b.stock, b.added_by, b.created_at, b.updated_at, b.version,
+            ` + keyColumn(keys) + `
         FROM 
             borrowing_records br
         INNER JOIN 
             books b ON br.book_id = b.id
         WHERE 
-            br.user_id = $1
-    `
-	args := []interface{}{userID}
-	argPosition := 2
+            ` + where
 
-	// Tambahkan kondisi pencarian jika ada
-	if queries["title"] != "" {
-		baseQuery += fmt.Sprintf(" AND LOWER(b.title) LIKE LOWER($%d)", argPosition)
-		args = append(args, "%"+queries["title"]+"%")
-		argPosition++
+	if filter.After != nil {
+		condition, afterArgs := keysetCondition(keys, filter.After, len(args)+1)
+		baseQuery += " AND " + condition
+		args = append(args, afterArgs...)
 	}
 
-	if queries["status"] == "returned" {
-		baseQuery += " AND br.returned_at is not null"
-	} else if queries["status"] == "borrowed" {
-		baseQuery += "AND br.returned_at is null"
-	}
+	baseQuery += " ORDER BY " + orderBy(keys)
 
-	if queries["order"] != "" {
-		baseQuery += " ORDER BY br.borrowed_at " + queries["order"]
-	} else {
-		baseQuery += " ORDER BY br.borrowed_at desc"
+	// One record more than asked for tells whether another page follows
+	if filter.Limit > 0 {
+		baseQuery += fmt.Sprintf(" LIMIT $%d", len(args)+1)
+		args = append(args, filter.Limit+1)
 	}
 
 	rows, err := r.db.QueryContext(ctx, baseQuery, args...)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer rows.Close()
 
-	var records []models.BorrowingRecord
+	records := []models.BorrowingRecord{}
+	var lastKeys, nextKeys []string
 
 	for rows.Next() {
+		if filter.Limit > 0 && len(records) == filter.Limit {
+			nextKeys = lastKeys
+			break
+		}
+
 		var record models.BorrowingRecord
 		var book models.Book
+		var userID uuid.NullUUID
 		var returnedAt sql.NullTime
+		var rowKeys pq.StringArray
 
 		err := rows.Scan(
 			&record.ID,
-			&record.UserID,
+			&userID,
 			&record.BorrowedAt,
 			&record.DueDate,
 			&returnedAt,
@@ -111,6 +124,7 @@ func (r *BorrowingRecordRepository) ListBorrowingRecords(ctx context.Context, us
 			&book.Title,
 			&book.Author,
 			&book.ISBN,
+			&book.ISBN13,
 			&book.PublishedDate,
 			&book.CategoryID,
 			&book.Stock,
@@ -118,12 +132,14 @@ func (r *BorrowingRecordRepository) ListBorrowingRecords(ctx context.Context, us
 			&book.CreatedAt,
 			&book.UpdatedAt,
 			&book.Version,
+			&rowKeys,
 		)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
 		record.Book = book
+		record.UserID = userID.UUID
 
 		if returnedAt.Valid {
 			record.ReturnedAt = &returnedAt.Time
@@ -132,11 +148,116 @@ func (r *BorrowingRecordRepository) ListBorrowingRecords(ctx context.Context, us
 		}
 
 		records = append(records, record)
+		lastKeys = rowKeys
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	return records, nextKeys, nil
+}
+
+// CountBorrowingRecords counts the borrowing records matching the filter,
+// ignoring its cursor and limit.
+func (r *BorrowingRecordRepository) CountBorrowingRecords(ctx context.Context, filter models.BorrowingRecordFilter) (int64, error) {
+	where, args := recordConditions(filter)
+
+	var count int64
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM borrowing_records br
+		INNER JOIN books b ON br.book_id = b.id
+		WHERE `+where, args...).Scan(&count)
+	return count, err
+}
+
+// recordSortKeys returns the keyset sort keys of records sorted by
+// borrowing or due time in order, "asc" or "desc" (the default).
+func recordSortKeys(sort, order string) []sortKey {
+	expr := "COALESCE(br.borrowed_at, '-infinity'::timestamp)"
+	if sort == models.RecordSortDue {
+		expr = "br.due_date"
+	}
+	desc := order != "asc"
+	return []sortKey{{expr: expr, desc: desc}, {expr: "br.id", desc: desc}}
+}
+
+// recordConditions builds the WHERE clause selecting the records matching
+// filter and its arguments.
+func recordConditions(filter models.BorrowingRecordFilter) (string, []interface{}) {
+	conditions := []string{"TRUE"}
+	var args []interface{}
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if !filter.AllUsers {
+		add("br.user_id = $%d", filter.UserID)
+	}
+	if filter.BookID != uuid.Nil {
+		add("br.book_id = $%d", filter.BookID)
+	}
+	if filter.Title != "" {
+		add("b.title ILIKE $%d", "%"+escapeLike(filter.Title)+"%")
 	}
 
-	return records, nil
+	switch filter.Status {
+	case models.RecordReturned:
+		conditions = append(conditions, "br.returned_at IS NOT NULL")
+	case models.RecordBorrowed:
+		conditions = append(conditions, "br.returned_at IS NULL")
+	case models.RecordOverdue:
+		conditions = append(conditions, "br.returned_at IS NULL", "br.due_date < CURRENT_TIMESTAMP")
+	}
+
+	if filter.BorrowedFrom != nil {
+		add("br.borrowed_at >= $%d", *filter.BorrowedFrom)
+	}
+	if filter.BorrowedTo != nil {
+		add("br.borrowed_at < $%d", *filter.BorrowedTo)
+	}
+	if filter.DueFrom != nil {
+		add("br.due_date >= $%d", *filter.DueFrom)
+	}
+	if filter.DueTo != nil {
+		add("br.due_date < $%d", *filter.DueTo)
+	}
+	if filter.ReturnedFrom != nil {
+		add("br.returned_at >= $%d", *filter.ReturnedFrom)
+	}
+	if filter.ReturnedTo != nil {
+		add("br.returned_at < $%d", *filter.ReturnedTo)
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+// CountActiveBorrowingRecords counts the user's loans that have not been returned yet.
+func (r *BorrowingRecordRepository) CountActiveBorrowingRecords(ctx context.Context, userID uuid.UUID) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM borrowing_records WHERE user_id = $1 AND returned_at IS NULL`, userID).Scan(&count)
+	return count, err
+}
+
+// HasReturnedBook reports whether the user has borrowed the book and brought
+// it back at least once.
+func (r *BorrowingRecordRepository) HasReturnedBook(ctx context.Context, userID, bookID uuid.UUID) (bool, error) {
+	var returned bool
+	err := r.db.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM borrowing_records WHERE user_id = $1 AND book_id = $2 AND returned_at IS NOT NULL)`,
+		userID, bookID,
+	).Scan(&returned)
+	return returned, err
+}
+
+// AnonymizeBorrowingRecords detaches the user's borrowing history from them,
+// keeping the records for circulation statistics.
+func (r *BorrowingRecordRepository) AnonymizeBorrowingRecords(ctx context.Context, userID uuid.UUID) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `UPDATE borrowing_records SET user_id = NULL WHERE user_id = $1`, userID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
 }
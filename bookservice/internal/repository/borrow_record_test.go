@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/models"
+)
+
+// Test recordConditions: Tanpa AllUsers hanya record milik user yang cocok,
+// juga untuk user ID kosong
+func TestRecordConditions_User(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name   string
+		filter models.BorrowingRecordFilter
+		where  string
+		args   []interface{}
+	}{
+		{"user", models.BorrowingRecordFilter{UserID: userID}, "TRUE AND br.user_id = $1", []interface{}{userID}},
+		{"zero user", models.BorrowingRecordFilter{}, "TRUE AND br.user_id = $1", []interface{}{uuid.Nil}},
+		{"all users", models.BorrowingRecordFilter{AllUsers: true}, "TRUE", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			where, args := recordConditions(tt.filter)
+			if where != tt.where || !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("recordConditions() = %q, %v, want %q, %v", where, args, tt.where, tt.args)
+			}
+		})
+	}
+}
+
+// Test recordConditions: Batas bawah inklusif, batas atas eksklusif, status
+// overdue hanya untuk yang belum kembali
+func TestRecordConditions_Ranges(t *testing.T) {
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	where, args := recordConditions(models.BorrowingRecordFilter{
+		AllUsers:     true,
+		Title:        "50%_off",
+		Status:       models.RecordOverdue,
+		BorrowedFrom: &from,
+		BorrowedTo:   &to,
+		DueTo:        &to,
+	})
+
+	want := "TRUE AND b.title ILIKE $1 AND br.returned_at IS NULL AND br.due_date < CURRENT_TIMESTAMP" +
+		" AND br.borrowed_at >= $2 AND br.borrowed_at < $3 AND br.due_date < $4"
+	if where != want {
+		t.Errorf("where = %q, want %q", where, want)
+	}
+	wantArgs := []interface{}{`%50\%\_off%`, from, to, to}
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("args = %v, want %v", args, wantArgs)
+	}
+}
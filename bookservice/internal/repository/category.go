@@ -3,35 +3,72 @@ package repository
 import (
 	"context"
 	"fmt"
+	"log"
+	"sync"
+	"time"
 
 	pb "github.com/sir-shalahuddin/grpc-learn/bookservice/proto/categoryservice" // Adjust the import path as needed
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
+// maxWatchBackoff caps the wait between WatchCategories reconnects.
+const maxWatchBackoff = time.Minute
+
+// categoryRepository keeps every category in memory. The cache is reloaded
+// once ttl has passed and kept current in between by WatchCategories, which
+// resumes from the last applied revision after a reconnect. When
+// bookcategoryservice can't be reached the last loaded categories are served.
 type categoryRepository struct {
 	client pb.BookCategoryServiceClient
+	ttl    time.Duration
+
+	mu         sync.RWMutex
+	categories map[string]*pb.CategoryResponse
+	revision   int64
+	loaded     bool
+	expiresAt  time.Time
 }
 
 // NewCategoryRepository creates a new instance of categoryRepository.
-func NewCategoryRepository(client pb.BookCategoryServiceClient) *categoryRepository {
-	return &categoryRepository{client: client}
+func NewCategoryRepository(client pb.BookCategoryServiceClient, ttl time.Duration) *categoryRepository {
+	return &categoryRepository{
+		client:     client,
+		ttl:        ttl,
+		categories: make(map[string]*pb.CategoryResponse),
+	}
 }
 
-// GetCategories retrieves all categories using the gRPC client.
+// GetCategories retrieves all categories, from the cache when it is fresh.
 func (r *categoryRepository) GetCategories(ctx context.Context) ([]*pb.CategoryResponse, error) {
-	req := &pb.GetCategoriesRequest{}
-
-	resp, err := r.client.GetCategories(ctx, req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get categories: %w", err)
+	if err := r.load(ctx); err != nil {
+		return nil, err
 	}
 
-	return resp.Categories, nil
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	categories := make([]*pb.CategoryResponse, 0, len(r.categories))
+	for _, c := range r.categories {
+		categories = append(categories, c)
+	}
+	return categories, nil
 }
 
-// GetCategoryByID retrieves a category by ID using the gRPC client.
+// GetCategoryByID retrieves a category by ID, from the cache when possible.
 func (r *categoryRepository) GetCategoryByID(ctx context.Context, id string) (*pb.CategoryResponse, error) {
+	if err := r.load(ctx); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	cached, ok := r.categories[id]
+	r.mu.RUnlock()
+	if ok {
+		return cached, nil
+	}
+
+	// Not cached yet, e.g. created while the watch was reconnecting
 	req := &pb.GetCategoryByIDRequest{
 		Id: id,
 	}
@@ -46,6 +83,165 @@ func (r *categoryRepository) GetCategoryByID(ctx context.Context, id string) (*p
 		return nil, fmt.Errorf("failed to get category by ID: %w", err)
 	}
 
+	r.mu.Lock()
+	r.categories[resp.Id] = resp
+	r.mu.Unlock()
+
 	// Return the response from the gRPC server
 	return resp, nil
 }
+
+// GetDescendantIDs returns id followed by the IDs of every category below it.
+func (r *categoryRepository) GetDescendantIDs(ctx context.Context, id string) ([]string, error) {
+	if err := r.load(ctx); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	children := make(map[string][]string)
+	for _, c := range r.categories {
+		if c.ParentId != "" {
+			children[c.ParentId] = append(children[c.ParentId], c.Id)
+		}
+	}
+	r.mu.RUnlock()
+
+	ids := []string{id}
+	seen := map[string]bool{id: true}
+	for i := 0; i < len(ids); i++ {
+		for _, child := range children[ids[i]] {
+			if !seen[child] {
+				seen[child] = true
+				ids = append(ids, child)
+			}
+		}
+	}
+
+	return ids, nil
+}
+
+// Watch applies category changes streamed by bookcategoryservice until ctx is
+// done, reconnecting with backoff whenever the stream breaks.
+func (r *categoryRepository) Watch(ctx context.Context) {
+	backoff := time.Second
+	for {
+		received, err := r.watch(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if received {
+			backoff = time.Second
+		}
+		log.Printf("[CategoryRepository - Watch] Category watch ended, retrying in %s: %v", backoff, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if backoff < maxWatchBackoff {
+			backoff *= 2
+		}
+	}
+}
+
+// watch consumes a single WatchCategories stream. It reports whether any
+// event arrived so Watch can reset its backoff.
+func (r *categoryRepository) watch(ctx context.Context) (bool, error) {
+	// The stream resumes from the revision of what is cached, so load first
+	if err := r.load(ctx); err != nil {
+		return false, err
+	}
+
+	r.mu.RLock()
+	revision := r.revision
+	r.mu.RUnlock()
+
+	stream, err := r.client.WatchCategories(ctx, &pb.WatchCategoriesRequest{FromRevision: revision})
+	if err != nil {
+		return false, err
+	}
+
+	received := false
+	for {
+		event, err := stream.Recv()
+		if err != nil {
+			// Our revision is unknown to the server, e.g. its log was reset
+			if status.Code(err) == codes.FailedPrecondition {
+				r.expire()
+			}
+			return received, err
+		}
+		received = true
+		r.apply(event)
+	}
+}
+
+func (r *categoryRepository) load(ctx context.Context) error {
+	r.mu.RLock()
+	fresh := r.loaded && time.Now().Before(r.expiresAt)
+	r.mu.RUnlock()
+	if fresh {
+		return nil
+	}
+
+	// Books can still belong to archived categories, so cache those as well
+	resp, err := r.client.GetCategories(ctx, &pb.GetCategoriesRequest{IncludeArchived: true})
+	if err != nil {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if !r.loaded {
+			return fmt.Errorf("failed to get categories: %w", err)
+		}
+
+		// Keep serving what we have. The watch expires the cache again once
+		// bookcategoryservice is reachable.
+		log.Printf("[CategoryRepository - load] Serving cached categories, refresh failed: %v", err)
+		r.expiresAt = time.Now().Add(r.ttl)
+		return nil
+	}
+
+	categories := make(map[string]*pb.CategoryResponse, len(resp.Categories))
+	for _, c := range resp.Categories {
+		categories[c.Id] = c
+	}
+
+	r.mu.Lock()
+	r.categories = categories
+	r.revision = resp.Revision
+	r.loaded = true
+	r.expiresAt = time.Now().Add(r.ttl)
+	r.mu.Unlock()
+
+	return nil
+}
+
+func (r *categoryRepository) apply(event *pb.CategoryEvent) {
+	category := event.GetCategory()
+	if category == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// A reload may already include this change
+	if event.GetRevision() <= r.revision {
+		return
+	}
+	r.revision = event.GetRevision()
+
+	switch event.GetType() {
+	case pb.CategoryEventType_CATEGORY_CREATED, pb.CategoryEventType_CATEGORY_UPDATED:
+		r.categories[category.Id] = category
+	case pb.CategoryEventType_CATEGORY_DELETED:
+		delete(r.categories, category.Id)
+	}
+}
+
+func (r *categoryRepository) expire() {
+	r.mu.Lock()
+	r.expiresAt = time.Time{}
+	r.revision = 0
+	r.mu.Unlock()
+}
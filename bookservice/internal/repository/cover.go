@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/models"
+)
+
+type CoverRepository struct {
+	db *sql.DB
+}
+
+func NewCoverRepository(db *sql.DB) *CoverRepository {
+	return &CoverRepository{db: db}
+}
+
+func (r *CoverRepository) GetCover(ctx context.Context, bookID uuid.UUID) (*models.BookCover, error) {
+	var cover models.BookCover
+	err := r.db.QueryRowContext(ctx, `
+		SELECT book_id, content_type, width, height, updated_at FROM book_covers WHERE book_id = $1`, bookID,
+	).Scan(&cover.BookID, &cover.ContentType, &cover.Width, &cover.Height, &cover.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get cover: %w", err)
+	}
+	return &cover, nil
+}
+
+// SaveCover records the cover of a book, replacing the previous one.
+func (r *CoverRepository) SaveCover(ctx context.Context, cover *models.BookCover) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO book_covers (book_id, content_type, width, height, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (book_id) DO UPDATE
+		SET content_type = EXCLUDED.content_type, width = EXCLUDED.width, height = EXCLUDED.height, updated_at = EXCLUDED.updated_at`,
+		cover.BookID, cover.ContentType, cover.Width, cover.Height, cover.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save cover: %w", err)
+	}
+	return nil
+}
+
+func (r *CoverRepository) DeleteCover(ctx context.Context, bookID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM book_covers WHERE book_id = $1`, bookID)
+	if err != nil {
+		return fmt.Errorf("failed to delete cover: %w", err)
+	}
+	return nil
+}
+
+// ListCovers returns the covers of the books that have one.
+func (r *BookRepository) ListCovers(ctx context.Context, bookIDs []uuid.UUID) (map[uuid.UUID]models.BookCover, error) {
+	covers := make(map[uuid.UUID]models.BookCover, len(bookIDs))
+	if len(bookIDs) == 0 {
+		return covers, nil
+	}
+
+	ids := make([]string, len(bookIDs))
+	for i, id := range bookIDs {
+		ids[i] = id.String()
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT book_id, content_type, width, height, updated_at
+		FROM book_covers
+		WHERE book_id = ANY($1::uuid[])`, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list covers: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cover models.BookCover
+		if err := rows.Scan(&cover.BookID, &cover.ContentType, &cover.Width, &cover.Height, &cover.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan cover: %w", err)
+		}
+		covers[cover.BookID] = cover
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list covers: %w", err)
+	}
+	return covers, nil
+}
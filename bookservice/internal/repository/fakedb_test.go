@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeResult adalah jawaban fakeDB untuk satu statement.
+type fakeResult struct {
+	columns int
+	rows    [][]driver.Value
+	err     error
+}
+
+type fakeStatement struct {
+	query string
+	args  []driver.Value
+}
+
+// fakeDB adalah database palsu yang mencatat setiap statement, termasuk
+// BEGIN, COMMIT dan ROLLBACK, dan menjawabnya lewat RespondFunc.
+type fakeDB struct {
+	RespondFunc func(query string) fakeResult
+
+	mu         sync.Mutex
+	statements []fakeStatement
+}
+
+func newFakeDB(t *testing.T, respond func(query string) fakeResult) (*fakeDB, *sql.DB) {
+	fake := &fakeDB{RespondFunc: respond}
+	db := sql.OpenDB(fake)
+	t.Cleanup(func() { db.Close() })
+	return fake, db
+}
+
+func (f *fakeDB) record(query string, args []driver.NamedValue) fakeResult {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+
+	f.mu.Lock()
+	f.statements = append(f.statements, fakeStatement{query: query, args: values})
+	f.mu.Unlock()
+
+	if f.RespondFunc == nil {
+		return fakeResult{}
+	}
+	return f.RespondFunc(query)
+}
+
+// Statements mengembalikan baris pertama setiap statement yang dijalankan,
+// tanpa spasi di awal.
+func (f *fakeDB) Statements() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	statements := make([]string, len(f.statements))
+	for i, statement := range f.statements {
+		statements[i], _, _ = strings.Cut(strings.TrimSpace(statement.query), "\n")
+	}
+	return statements
+}
+
+// Args mengembalikan argumen statement pertama yang diawali prefix.
+func (f *fakeDB) Args(prefix string) []driver.Value {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, statement := range f.statements {
+		if strings.HasPrefix(strings.TrimSpace(statement.query), prefix) {
+			return statement.args
+		}
+	}
+	return nil
+}
+
+func (f *fakeDB) Connect(ctx context.Context) (driver.Conn, error) {
+	return &fakeConn{db: f}, nil
+}
+
+func (f *fakeDB) Driver() driver.Driver {
+	return fakeDriver{db: f}
+}
+
+type fakeDriver struct {
+	db *fakeDB
+}
+
+func (d fakeDriver) Open(name string) (driver.Conn, error) {
+	return &fakeConn{db: d.db}, nil
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("fakeDB does not prepare statements")
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	c.db.record("BEGIN", nil)
+	return fakeTx{db: c.db}, nil
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	res := c.db.record(query, args)
+	if res.err != nil {
+		return nil, res.err
+	}
+	return driver.RowsAffected(len(res.rows)), nil
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	res := c.db.record(query, args)
+	if res.err != nil {
+		return nil, res.err
+	}
+	return &fakeRows{columns: res.columns, rows: res.rows}, nil
+}
+
+type fakeTx struct {
+	db *fakeDB
+}
+
+func (tx fakeTx) Commit() error {
+	tx.db.record("COMMIT", nil)
+	return nil
+}
+
+func (tx fakeTx) Rollback() error {
+	tx.db.record("ROLLBACK", nil)
+	return nil
+}
+
+type fakeRows struct {
+	columns int
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	return make([]string, r.columns)
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
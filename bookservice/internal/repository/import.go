@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/models"
+)
+
+// importRowSortKeys orders the rows of an import by row number.
+var importRowSortKeys = []sortKey{{expr: "row_number"}}
+
+type ImportRepository struct {
+	db *sql.DB
+}
+
+func NewImportRepository(db *sql.DB) *ImportRepository {
+	return &ImportRepository{db: db}
+}
+
+// CreateImport inserts a pending import and sets its ID and creation time.
+func (r *ImportRepository) CreateImport(ctx context.Context, job *models.BookImport) error {
+	job.Status = models.ImportPending
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO book_imports (format, dry_run, status, created_by)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`,
+		job.Format, job.DryRun, job.Status, job.CreatedBy,
+	).Scan(&job.ID, &job.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create import: %w", err)
+	}
+	return nil
+}
+
+func (r *ImportRepository) GetImport(ctx context.Context, id uuid.UUID) (*models.BookImport, error) {
+	var job models.BookImport
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, format, dry_run, status, processed, succeeded, failed, error, created_by, created_at, finished_at
+		FROM book_imports WHERE id = $1`, id,
+	).Scan(&job.ID, &job.Format, &job.DryRun, &job.Status, &job.Processed, &job.Succeeded, &job.Failed, &job.Error, &job.CreatedBy, &job.CreatedAt, &job.FinishedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get import: %w", err)
+	}
+	return &job, nil
+}
+
+// SetImportStatus moves an import to status. Finished imports also record
+// when they finished and, when they failed, why. A finished import keeps its
+// status.
+func (r *ImportRepository) SetImportStatus(ctx context.Context, id uuid.UUID, status, reason string) error {
+	var finishedAt *time.Time
+	if status == models.ImportCompleted || status == models.ImportFailed {
+		now := time.Now()
+		finishedAt = &now
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE book_imports SET status = $1, error = $2, finished_at = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $4 AND status NOT IN ($5, $6)`,
+		status, reason, finishedAt, id, models.ImportCompleted, models.ImportFailed,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to set import status: %w", err)
+	}
+	return nil
+}
+
+// AddImportRow records the outcome of a row and counts it on its import in
+// the same statement, so the counts always match the rows.
+func (r *ImportRepository) AddImportRow(ctx context.Context, importID uuid.UUID, row *models.BookImportRow) error {
+	succeeded := row.Status == models.ImportRowCreated || row.Status == models.ImportRowValid
+	_, err := r.db.ExecContext(ctx, `
+		WITH inserted AS (
+			INSERT INTO book_import_rows (import_id, row_number, status, book_id, title, isbn, message)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+		)
+		UPDATE book_imports
+		SET processed = processed + 1,
+			succeeded = succeeded + CASE WHEN $8 THEN 1 ELSE 0 END,
+			failed = failed + CASE WHEN $8 THEN 0 ELSE 1 END,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1`,
+		importID, row.Row, row.Status, row.BookID, row.Title, row.ISBN, row.Message, succeeded,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to add import row: %w", err)
+	}
+	return nil
+}
+
+// FailStaleImports marks the imports that are still pending or running but
+// made no progress since before as failed with reason, and returns how many
+// there were.
+func (r *ImportRepository) FailStaleImports(ctx context.Context, before time.Time, reason string) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE book_imports
+		SET status = $1, error = $2, finished_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE status IN ($3, $4) AND updated_at < $5`,
+		models.ImportFailed, reason, models.ImportPending, models.ImportRunning, before,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fail stale imports: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// ListImportRows lists the rows of an import matching the filter in file
+// order, starting after the row whose number is filter.After. It also
+// returns the sort keys of the last row when more rows follow.
+func (r *ImportRepository) ListImportRows(ctx context.Context, importID uuid.UUID, filter models.BookImportRowFilter) ([]models.BookImportRow, []string, error) {
+	if err := checkCursor(importRowSortKeys, filter.After); err != nil {
+		return nil, nil, err
+	}
+
+	query := `SELECT row_number, status, book_id, title, isbn, message FROM book_import_rows WHERE import_id = $1`
+	args := []interface{}{importID}
+
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		query += fmt.Sprintf(" AND status = $%d", len(args))
+	}
+	if filter.After != nil {
+		condition, afterArgs := keysetCondition(importRowSortKeys, filter.After, len(args)+1)
+		query += " AND " + condition
+		args = append(args, afterArgs...)
+	}
+
+	// One row more than asked for tells whether another page follows
+	query += fmt.Sprintf(" ORDER BY %s LIMIT $%d", orderBy(importRowSortKeys), len(args)+1)
+	args = append(args, filter.Limit+1)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list import rows: %w", err)
+	}
+	defer rows.Close()
+
+	result := []models.BookImportRow{}
+	var nextKeys []string
+	for rows.Next() {
+		if len(result) == filter.Limit {
+			nextKeys = []string{fmt.Sprint(result[len(result)-1].Row)}
+			break
+		}
+
+		var row models.BookImportRow
+		if err := rows.Scan(&row.Row, &row.Status, &row.BookID, &row.Title, &row.ISBN, &row.Message); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan import row: %w", err)
+		}
+		result = append(result, row)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to list import rows: %w", err)
+	}
+
+	return result, nextKeys, nil
+}
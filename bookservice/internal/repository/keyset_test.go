@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/pagination"
+)
+
+// Test keysetCondition: Key yang sama dengan baris terakhir diteruskan ke key
+// berikutnya, sampai ID yang unik, dengan arah sesuai urutan
+func TestKeysetCondition(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     []sortKey
+		after    []string
+		argIndex int
+		want     string
+	}{
+		{"id only", []sortKey{{expr: "id"}}, []string{"1"}, 1, "((id > $1))"},
+		{"ascending", []sortKey{{expr: "title"}, {expr: "id"}}, []string{"Dune", "1"}, 1,
+			"((title > $1) OR (title = $1 AND id > $2))"},
+		{"descending", []sortKey{{expr: "title", desc: true}, {expr: "id"}}, []string{"Dune", "1"}, 1,
+			"((title < $1) OR (title = $1 AND id > $2))"},
+		{"after filter args", []sortKey{{expr: "rank", desc: true}, {expr: "title"}, {expr: "id"}}, []string{"0.5", "Dune", "1"}, 3,
+			"((rank < $3) OR (rank = $3 AND title > $4) OR (rank = $3 AND title = $4 AND id > $5))"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			condition, args := keysetCondition(tt.keys, tt.after, tt.argIndex)
+			if condition != tt.want {
+				t.Errorf("condition = %q, want %q", condition, tt.want)
+			}
+
+			want := make([]interface{}, len(tt.after))
+			for i, value := range tt.after {
+				want[i] = value
+			}
+			if !reflect.DeepEqual(args, want) {
+				t.Errorf("args = %v, want %v", args, want)
+			}
+		})
+	}
+}
+
+// Test orderBy dan keyColumn: Urutan dan key yang dipilih memakai ekspresi
+// yang sama
+func TestOrderByAndKeyColumn(t *testing.T) {
+	keys := []sortKey{{expr: "LOWER(author)", desc: true}, {expr: "title"}, {expr: "id"}}
+
+	if got, want := orderBy(keys), "LOWER(author) DESC, title, id"; got != want {
+		t.Errorf("orderBy() = %q, want %q", got, want)
+	}
+	if got, want := keyColumn(keys), "ARRAY[(LOWER(author))::text, (title)::text, (id)::text]"; got != want {
+		t.Errorf("keyColumn() = %q, want %q", got, want)
+	}
+}
+
+// Test checkCursor: Cursor harus punya satu nilai per key
+func TestCheckCursor(t *testing.T) {
+	keys := []sortKey{{expr: "title"}, {expr: "id"}}
+
+	tests := []struct {
+		name  string
+		after []string
+		want  error
+	}{
+		{"first page", nil, nil},
+		{"matching", []string{"Dune", "1"}, nil},
+		{"too few", []string{"1"}, pagination.ErrInvalidCursor},
+		{"too many", []string{"0.5", "Dune", "1"}, pagination.ErrInvalidCursor},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkCursor(keys, tt.after); !errors.Is(err, tt.want) {
+				t.Errorf("checkCursor() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
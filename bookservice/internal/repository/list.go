@@ -0,0 +1,325 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/models"
+)
+
+// notificationSortKeys orders notifications newest first.
+var notificationSortKeys = []sortKey{{expr: "n.created_at", desc: true}, {expr: "n.id", desc: true}}
+
+const listColumns = `l.id, l.user_id, l.name, l.description, l.is_public, COALESCE(l.slug, ''), l.created_at, l.updated_at,
+	(SELECT COUNT(*) FROM reading_list_items WHERE list_id = l.id)`
+
+type ReadingListRepository struct {
+	db *sql.DB
+}
+
+func NewReadingListRepository(db *sql.DB) *ReadingListRepository {
+	return &ReadingListRepository{db: db}
+}
+
+// CreateList inserts a reading list and sets its ID and timestamps.
+func (r *ReadingListRepository) CreateList(ctx context.Context, list *models.ReadingList) error {
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO reading_lists (user_id, name, description, is_public, slug)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''))
+		RETURNING id, created_at, updated_at`,
+		list.UserID, list.Name, list.Description, list.Public, list.Slug,
+	).Scan(&list.ID, &list.CreatedAt, &list.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create reading list: %w", err)
+	}
+	return nil
+}
+
+func (r *ReadingListRepository) GetList(ctx context.Context, id uuid.UUID) (*models.ReadingList, error) {
+	list, err := scanList(r.db.QueryRowContext(ctx, `SELECT `+listColumns+` FROM reading_lists l WHERE l.id = $1`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get reading list: %w", err)
+	}
+	return list, nil
+}
+
+// GetPublicList returns the public list shared under slug.
+func (r *ReadingListRepository) GetPublicList(ctx context.Context, slug string) (*models.ReadingList, error) {
+	list, err := scanList(r.db.QueryRowContext(ctx, `SELECT `+listColumns+` FROM reading_lists l WHERE l.slug = $1 AND l.is_public`, slug))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get reading list: %w", err)
+	}
+	return list, nil
+}
+
+// ListLists returns every reading list of the user, oldest first.
+func (r *ReadingListRepository) ListLists(ctx context.Context, userID uuid.UUID) ([]models.ReadingList, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+listColumns+` FROM reading_lists l WHERE l.user_id = $1 ORDER BY l.created_at, l.id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reading lists: %w", err)
+	}
+	defer rows.Close()
+
+	lists := []models.ReadingList{}
+	for rows.Next() {
+		list, err := scanList(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan reading list: %w", err)
+		}
+		lists = append(lists, *list)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list reading lists: %w", err)
+	}
+	return lists, nil
+}
+
+// UpdateList replaces the name, description and sharing of a list.
+func (r *ReadingListRepository) UpdateList(ctx context.Context, list *models.ReadingList) error {
+	list.UpdatedAt = time.Now()
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE reading_lists SET name = $1, description = $2, is_public = $3, slug = NULLIF($4, ''), updated_at = $5 WHERE id = $6`,
+		list.Name, list.Description, list.Public, list.Slug, list.UpdatedAt, list.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update reading list: %w", err)
+	}
+	return nil
+}
+
+func (r *ReadingListRepository) DeleteList(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM reading_lists WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete reading list: %w", err)
+	}
+	return nil
+}
+
+// ListItems returns the books on a list in their order, with their stock.
+func (r *ReadingListRepository) ListItems(ctx context.Context, listID uuid.UUID) ([]models.ReadingListItem, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT i.book_id, b.title, b.author, b.stock, i.notify, i.position, i.added_at
+		FROM reading_list_items i
+		INNER JOIN books b ON b.id = i.book_id
+		WHERE i.list_id = $1
+		ORDER BY i.position, i.added_at, i.book_id`, listID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reading list items: %w", err)
+	}
+	defer rows.Close()
+
+	items := []models.ReadingListItem{}
+	for rows.Next() {
+		var item models.ReadingListItem
+		if err := rows.Scan(&item.BookID, &item.Title, &item.Author, &item.Stock, &item.Notify, &item.Position, &item.AddedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan reading list item: %w", err)
+		}
+		item.Available = item.Stock > 0
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list reading list items: %w", err)
+	}
+	return items, nil
+}
+
+// AddItem puts a book at the end of a list. It returns false when the book
+// is already on it.
+func (r *ReadingListRepository) AddItem(ctx context.Context, listID, bookID uuid.UUID, notify bool) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO reading_list_items (list_id, book_id, position, notify)
+		SELECT $1, $2, COALESCE(MAX(position) + 1, 0), $3 FROM reading_list_items WHERE list_id = $1
+		ON CONFLICT (list_id, book_id) DO NOTHING`,
+		listID, bookID, notify,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to add book to reading list: %w", err)
+	}
+	added, err := res.RowsAffected()
+	return added > 0, err
+}
+
+// SetItemNotify turns the availability notification of a book on a list on
+// or off. It returns false when the book is not on the list.
+func (r *ReadingListRepository) SetItemNotify(ctx context.Context, listID, bookID uuid.UUID, notify bool) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `UPDATE reading_list_items SET notify = $1 WHERE list_id = $2 AND book_id = $3`, notify, listID, bookID)
+	if err != nil {
+		return false, fmt.Errorf("failed to update reading list item: %w", err)
+	}
+	updated, err := res.RowsAffected()
+	return updated > 0, err
+}
+
+// RemoveItem takes a book off a list. It returns false when the book was not
+// on it.
+func (r *ReadingListRepository) RemoveItem(ctx context.Context, listID, bookID uuid.UUID) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM reading_list_items WHERE list_id = $1 AND book_id = $2`, listID, bookID)
+	if err != nil {
+		return false, fmt.Errorf("failed to remove book from reading list: %w", err)
+	}
+	removed, err := res.RowsAffected()
+	return removed > 0, err
+}
+
+// ReorderItems puts the books of a list in the order of bookIDs.
+func (r *ReadingListRepository) ReorderItems(ctx context.Context, listID uuid.UUID, bookIDs []uuid.UUID) error {
+	ids := make([]string, len(bookIDs))
+	for i, id := range bookIDs {
+		ids[i] = id.String()
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE reading_list_items i
+		SET position = o.ord - 1
+		FROM unnest($2::uuid[]) WITH ORDINALITY AS o(book_id, ord)
+		WHERE i.list_id = $1 AND i.book_id = o.book_id`,
+		listID, pq.Array(ids),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to reorder reading list: %w", err)
+	}
+	return nil
+}
+
+// NotifyAvailable notifies everyone waiting for a book that a copy is back,
+// within tx, and turns their requests off. Someone waiting on several lists
+// is notified once. It returns how many users were notified.
+func (r *ReadingListRepository) NotifyAvailable(ctx context.Context, tx *sql.Tx, bookID uuid.UUID) (int64, error) {
+	res, err := tx.ExecContext(ctx, `
+		WITH waiting AS (
+			UPDATE reading_list_items i
+			SET notify = FALSE
+			FROM reading_lists l
+			WHERE l.id = i.list_id AND i.book_id = $1 AND i.notify
+			RETURNING l.user_id
+		)
+		INSERT INTO book_notifications (user_id, book_id, kind)
+		SELECT DISTINCT user_id, $1::uuid, $2 FROM waiting`,
+		bookID, models.NotificationAvailable,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to notify waiting users: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// ListNotifications lists a page of the user's notifications, newest first,
+// starting after the notification whose sort keys are filter.After. It also
+// returns the sort keys of the last notification when more follow.
+func (r *ReadingListRepository) ListNotifications(ctx context.Context, userID uuid.UUID, filter models.NotificationFilter) ([]models.Notification, []string, error) {
+	if err := checkCursor(notificationSortKeys, filter.After); err != nil {
+		return nil, nil, err
+	}
+
+	query := `
+		SELECT n.id, n.book_id, b.title, n.kind, n.created_at, n.read_at, ` + keyColumn(notificationSortKeys) + `
+		FROM book_notifications n
+		INNER JOIN books b ON b.id = n.book_id
+		WHERE n.user_id = $1`
+	args := []interface{}{userID}
+
+	if filter.Unread {
+		query += " AND n.read_at IS NULL"
+	}
+	if filter.After != nil {
+		condition, afterArgs := keysetCondition(notificationSortKeys, filter.After, len(args)+1)
+		query += " AND " + condition
+		args = append(args, afterArgs...)
+	}
+
+	// One notification more than asked for tells whether another page follows
+	query += fmt.Sprintf(" ORDER BY %s LIMIT $%d", orderBy(notificationSortKeys), len(args)+1)
+	args = append(args, filter.Limit+1)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+	defer rows.Close()
+
+	notifications := []models.Notification{}
+	var lastKeys, nextKeys []string
+	for rows.Next() {
+		if len(notifications) == filter.Limit {
+			nextKeys = lastKeys
+			break
+		}
+
+		var notification models.Notification
+		var readAt sql.NullTime
+		var rowKeys pq.StringArray
+		if err := rows.Scan(&notification.ID, &notification.BookID, &notification.Title, &notification.Kind, &notification.CreatedAt, &readAt, &rowKeys); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan notification: %w", err)
+		}
+		if readAt.Valid {
+			notification.ReadAt = &readAt.Time
+		}
+		notifications = append(notifications, notification)
+		lastKeys = rowKeys
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+	return notifications, nextKeys, nil
+}
+
+// MarkNotificationRead marks one of the user's notifications as read. It
+// returns false when the user has no such notification.
+func (r *ReadingListRepository) MarkNotificationRead(ctx context.Context, userID, id uuid.UUID) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE book_notifications SET read_at = COALESCE(read_at, $1) WHERE id = $2 AND user_id = $3`,
+		time.Now(), id, userID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark notification read: %w", err)
+	}
+	updated, err := res.RowsAffected()
+	return updated > 0, err
+}
+
+// DeleteUserLists deletes every reading list and notification of the user
+// and returns how many lists there were.
+func (r *ReadingListRepository) DeleteUserLists(ctx context.Context, userID uuid.UUID) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM book_notifications WHERE user_id = $1`, userID); err != nil {
+		return 0, fmt.Errorf("failed to delete notifications: %w", err)
+	}
+	res, err := tx.ExecContext(ctx, `DELETE FROM reading_lists WHERE user_id = $1`, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete reading lists: %w", err)
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return deleted, tx.Commit()
+}
+
+// scanList scans a row selecting listColumns.
+func scanList(row interface{ Scan(...interface{}) error }) (*models.ReadingList, error) {
+	var list models.ReadingList
+	err := row.Scan(&list.ID, &list.UserID, &list.Name, &list.Description, &list.Public, &list.Slug, &list.CreatedAt, &list.UpdatedAt, &list.ItemCount)
+	if err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/metadata"
+	"github.com/sir-shalahuddin/grpc-learn/pkg/cache"
+)
+
+// cachedMetadata wraps a record so books no provider knows can be cached too.
+type cachedMetadata struct {
+	Record *metadata.Record `json:"record,omitempty"`
+}
+
+type metadataRepository struct {
+	provider    metadata.Provider
+	cache       cache.Cache
+	ttl         time.Duration
+	negativeTTL time.Duration
+}
+
+// NewMetadataRepository creates a repository looking up book metadata from
+// provider. A nil provider disables lookups and a nil metadataCache disables
+// caching.
+func NewMetadataRepository(provider metadata.Provider, metadataCache cache.Cache, ttl, negativeTTL time.Duration) *metadataRepository {
+	return &metadataRepository{provider: provider, cache: metadataCache, ttl: ttl, negativeTTL: negativeTTL}
+}
+
+// LookupISBN returns what the providers know about the book with an ISBN-13,
+// or nil when none of them knows it. Books no provider knows are remembered
+// for the shorter negative TTL.
+func (r *metadataRepository) LookupISBN(ctx context.Context, isbn13 string) (*metadata.Record, error) {
+	if r.provider == nil {
+		return nil, metadata.ErrNoProviders
+	}
+
+	key := "metadata:isbn:" + isbn13
+	if r.cache != nil {
+		if data, ok := r.cache.Get(ctx, key); ok {
+			var cached cachedMetadata
+			if err := json.Unmarshal(data, &cached); err == nil {
+				return cached.Record, nil
+			}
+		}
+	}
+
+	record, err := r.provider.Lookup(ctx, isbn13)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.cache != nil {
+		ttl := r.ttl
+		if record == nil {
+			ttl = r.negativeTTL
+		}
+		if data, err := json.Marshal(cachedMetadata{Record: record}); err == nil {
+			r.cache.Set(ctx, key, data, ttl)
+		}
+	}
+
+	return record, nil
+}
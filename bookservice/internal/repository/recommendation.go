@@ -0,0 +1,277 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/models"
+)
+
+type RecommendationRepository struct {
+	db *sql.DB
+}
+
+func NewRecommendationRepository(db *sql.DB) *RecommendationRepository {
+	return &RecommendationRepository{db: db}
+}
+
+// Recompute rebuilds the recommendation tables from the borrowing history in
+// one transaction, so requests keep reading the previous tables until it
+// commits. Two books are similar by the cosine of the sets of patrons who
+// borrowed them, counting pairs borrowed by at least minCoBorrowers patrons.
+// Each book keeps its size most similar books and each patron the size books
+// scoring highest over what they borrowed. It fills in the counts of run and
+// returns false without doing anything while another instance recomputes.
+func (r *RecommendationRepository) Recompute(ctx context.Context, run *models.RecommendationRun, size, minCoBorrowers int) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var locked bool
+	if err := tx.QueryRowContext(ctx, `SELECT pg_try_advisory_xact_lock(hashtext('book_recommendations'))`).Scan(&locked); err != nil {
+		return false, fmt.Errorf("failed to lock recommendations: %w", err)
+	}
+	if !locked {
+		return false, nil
+	}
+
+	// DELETE rather than TRUNCATE, which would block readers until commit
+	if _, err := tx.ExecContext(ctx, `DELETE FROM book_similarities`); err != nil {
+		return false, fmt.Errorf("failed to clear book similarities: %w", err)
+	}
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO book_similarities (book_id, similar_book_id, score, co_borrowers)
+		WITH borrowed AS (
+			SELECT DISTINCT user_id, book_id FROM borrowing_records WHERE user_id IS NOT NULL
+		), borrowers AS (
+			SELECT book_id, COUNT(*) AS n FROM borrowed GROUP BY book_id
+		), pairs AS (
+			SELECT a.book_id, b.book_id AS similar_book_id, COUNT(*) AS co
+			FROM borrowed a
+			INNER JOIN borrowed b ON b.user_id = a.user_id AND b.book_id <> a.book_id
+			GROUP BY a.book_id, b.book_id
+			HAVING COUNT(*) >= $2
+		), scored AS (
+			SELECT p.book_id, p.similar_book_id, p.co, p.co / sqrt(na.n::float8 * nb.n) AS score
+			FROM pairs p
+			INNER JOIN borrowers na ON na.book_id = p.book_id
+			INNER JOIN borrowers nb ON nb.book_id = p.similar_book_id
+		), ranked AS (
+			SELECT *, ROW_NUMBER() OVER (PARTITION BY book_id ORDER BY score DESC, co DESC, similar_book_id) AS rn
+			FROM scored
+		)
+		SELECT book_id, similar_book_id, score, co FROM ranked WHERE rn <= $1`,
+		size, minCoBorrowers,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to compute book similarities: %w", err)
+	}
+	if run.Similarities, err = res.RowsAffected(); err != nil {
+		return false, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_recommendations`); err != nil {
+		return false, fmt.Errorf("failed to clear user recommendations: %w", err)
+	}
+	res, err = tx.ExecContext(ctx, `
+		INSERT INTO user_recommendations (user_id, book_id, score)
+		WITH borrowed AS (
+			SELECT DISTINCT user_id, book_id FROM borrowing_records WHERE user_id IS NOT NULL
+		), candidates AS (
+			SELECT b.user_id, s.similar_book_id AS book_id, SUM(s.score) AS score
+			FROM borrowed b
+			INNER JOIN book_similarities s ON s.book_id = b.book_id
+			WHERE NOT EXISTS (SELECT 1 FROM borrowed o WHERE o.user_id = b.user_id AND o.book_id = s.similar_book_id)
+			GROUP BY b.user_id, s.similar_book_id
+		), ranked AS (
+			SELECT *, ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY score DESC, book_id) AS rn
+			FROM candidates
+		)
+		SELECT user_id, book_id, score FROM ranked WHERE rn <= $1`,
+		size,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to compute user recommendations: %w", err)
+	}
+	if run.Recommendations, err = res.RowsAffected(); err != nil {
+		return false, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM popular_books`); err != nil {
+		return false, fmt.Errorf("failed to clear popular books: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO popular_books (book_id, category_id, borrows, category_rank)
+		SELECT b.id, b.category_id, COUNT(r.id),
+			ROW_NUMBER() OVER (PARTITION BY b.category_id ORDER BY COUNT(r.id) DESC, b.rating_average DESC, b.title, b.id)
+		FROM books b
+		LEFT JOIN borrowing_records r ON r.book_id = b.id
+		GROUP BY b.id`)
+	if err != nil {
+		return false, fmt.Errorf("failed to rank popular books: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit recommendations: %w", err)
+	}
+	return true, nil
+}
+
+// SimilarBooks returns up to limit books similar to a book: first those
+// borrowed by the same patrons, then the most borrowed of its category.
+func (r *RecommendationRepository) SimilarBooks(ctx context.Context, bookID uuid.UUID, limit int) ([]models.RecommendedBook, error) {
+	// Enough of the category for the limit after dropping the book itself and
+	// those already co-borrowed
+	return r.recommend(ctx, `
+		WITH candidates AS (
+			SELECT similar_book_id AS book_id, score, '`+models.ReasonCoBorrowed+`' AS reason, 0 AS tier,
+				ROW_NUMBER() OVER (ORDER BY score DESC, similar_book_id) AS ord
+			FROM book_similarities
+			WHERE book_id = $1
+			UNION ALL
+			SELECT p.book_id, 0, '`+models.ReasonCategory+`', 1, p.category_rank
+			FROM popular_books p
+			WHERE p.category_id = (SELECT category_id FROM books WHERE id = $1) AND p.category_rank <= 2 * $2 + 1
+		), best AS (
+			SELECT DISTINCT ON (book_id) * FROM candidates WHERE book_id <> $1 ORDER BY book_id, tier, ord
+		)
+		SELECT b.id, b.title, b.author, b.stock, best.score, best.reason
+		FROM best
+		INNER JOIN books b ON b.id = best.book_id
+		ORDER BY best.tier, best.ord
+		LIMIT $2`, bookID, limit)
+}
+
+// RecommendedBooks returns up to limit books for a patron who hasn't borrowed
+// them: first those scoring highest over what they borrowed, then the most
+// borrowed of the categories they borrow most from, then the most borrowed
+// of the library.
+func (r *RecommendationRepository) RecommendedBooks(ctx context.Context, userID uuid.UUID, limit int) ([]models.RecommendedBook, error) {
+	return r.recommend(ctx, `
+		WITH borrowed AS (
+			SELECT DISTINCT book_id FROM borrowing_records WHERE user_id = $1
+		), favourites AS (
+			SELECT b.category_id, COUNT(*) AS n
+			FROM borrowing_records r
+			INNER JOIN books b ON b.id = r.book_id
+			WHERE r.user_id = $1
+			GROUP BY b.category_id
+		), candidates AS (
+			SELECT book_id, score, '`+models.ReasonCoBorrowed+`' AS reason, 0 AS tier,
+				ROW_NUMBER() OVER (ORDER BY score DESC, book_id) AS ord
+			FROM user_recommendations
+			WHERE user_id = $1
+			UNION ALL
+			SELECT p.book_id, 0, '`+models.ReasonCategory+`', 1, ROW_NUMBER() OVER (ORDER BY f.n DESC, p.category_rank)
+			FROM popular_books p
+			INNER JOIN favourites f ON f.category_id = p.category_id
+			WHERE p.category_rank <= 2 * $2 + (SELECT COUNT(*) FROM borrowed)
+			UNION ALL
+			(SELECT book_id, 0, '`+models.ReasonPopular+`', 2, ROW_NUMBER() OVER (ORDER BY borrows DESC, book_id)
+			FROM popular_books
+			ORDER BY borrows DESC, book_id
+			LIMIT 2 * $2 + (SELECT COUNT(*) FROM borrowed))
+		), best AS (
+			SELECT DISTINCT ON (book_id) * FROM candidates
+			WHERE book_id NOT IN (SELECT book_id FROM borrowed)
+			ORDER BY book_id, tier, ord
+		)
+		SELECT b.id, b.title, b.author, b.stock, best.score, best.reason
+		FROM best
+		INNER JOIN books b ON b.id = best.book_id
+		ORDER BY best.tier, best.ord
+		LIMIT $2`, userID, limit)
+}
+
+func (r *RecommendationRepository) recommend(ctx context.Context, query string, args ...interface{}) ([]models.RecommendedBook, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recommendations: %w", err)
+	}
+	defer rows.Close()
+
+	books := []models.RecommendedBook{}
+	for rows.Next() {
+		var book models.RecommendedBook
+		if err := rows.Scan(&book.ID, &book.Title, &book.Author, &book.Stock, &book.Score, &book.Reason); err != nil {
+			return nil, fmt.Errorf("failed to scan recommendation: %w", err)
+		}
+		book.Available = book.Stock > 0
+		books = append(books, book)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list recommendations: %w", err)
+	}
+	return books, nil
+}
+
+// DeleteUserRecommendations deletes the books recommended to a user.
+func (r *RecommendationRepository) DeleteUserRecommendations(ctx context.Context, userID uuid.UUID) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM user_recommendations WHERE user_id = $1`, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete recommendations: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// CreateRun inserts a running recommendation run and sets its ID and start.
+func (r *RecommendationRepository) CreateRun(ctx context.Context, run *models.RecommendationRun) error {
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO recommendation_runs (trigger, status, requested_by)
+		VALUES ($1, $2, $3)
+		RETURNING id, started_at`,
+		run.Trigger, run.Status, run.RequestedBy,
+	).Scan(&run.ID, &run.StartedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create recommendation run: %w", err)
+	}
+	return nil
+}
+
+// FinishRun records the outcome of a run.
+func (r *RecommendationRepository) FinishRun(ctx context.Context, run *models.RecommendationRun) error {
+	finishedAt := time.Now()
+	run.FinishedAt = &finishedAt
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE recommendation_runs
+		SET status = $1, similarities = $2, recommendations = $3, error = $4, finished_at = $5
+		WHERE id = $6`,
+		run.Status, run.Similarities, run.Recommendations, run.Error, run.FinishedAt, run.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to finish recommendation run: %w", err)
+	}
+	return nil
+}
+
+// LatestRun returns the last run started, or nil before the first one.
+func (r *RecommendationRepository) LatestRun(ctx context.Context) (*models.RecommendationRun, error) {
+	var run models.RecommendationRun
+	var requestedBy uuid.NullUUID
+	var finishedAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, trigger, status, requested_by, similarities, recommendations, error, started_at, finished_at
+		FROM recommendation_runs
+		ORDER BY started_at DESC
+		LIMIT 1`,
+	).Scan(&run.ID, &run.Trigger, &run.Status, &requestedBy, &run.Similarities, &run.Recommendations, &run.Error, &run.StartedAt, &finishedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get recommendation run: %w", err)
+	}
+	if requestedBy.Valid {
+		run.RequestedBy = &requestedBy.UUID
+	}
+	if finishedAt.Valid {
+		run.FinishedAt = &finishedAt.Time
+	}
+	return &run, nil
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/models"
+)
+
+// Test Recompute: Tabel rekomendasi dibangun ulang dalam satu transaksi di
+// bawah advisory lock, dan dilewati saat instance lain sedang menghitung
+func TestRecompute_Lock(t *testing.T) {
+	tests := []struct {
+		name   string
+		locked bool
+		want   []string
+	}{
+		{"locked", true, []string{
+			"BEGIN",
+			"SELECT pg_try_advisory_xact_lock(hashtext('book_recommendations'))",
+			"DELETE FROM book_similarities",
+			"INSERT INTO book_similarities (book_id, similar_book_id, score, co_borrowers)",
+			"DELETE FROM user_recommendations",
+			"INSERT INTO user_recommendations (user_id, book_id, score)",
+			"DELETE FROM popular_books",
+			"INSERT INTO popular_books (book_id, category_id, borrows, category_rank)",
+			"COMMIT",
+		}},
+		{"another instance recomputing", false, []string{
+			"BEGIN",
+			"SELECT pg_try_advisory_xact_lock(hashtext('book_recommendations'))",
+			"ROLLBACK",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, db := newFakeDB(t, func(query string) fakeResult {
+				query = strings.TrimSpace(query)
+				switch {
+				case strings.HasPrefix(query, "SELECT pg_try_advisory_xact_lock"):
+					return fakeResult{columns: 1, rows: [][]driver.Value{{tt.locked}}}
+				case strings.HasPrefix(query, "INSERT INTO book_similarities"):
+					return fakeResult{rows: make([][]driver.Value, 6)}
+				case strings.HasPrefix(query, "INSERT INTO user_recommendations"):
+					return fakeResult{rows: make([][]driver.Value, 4)}
+				}
+				return fakeResult{}
+			})
+
+			run := &models.RecommendationRun{}
+			ran, err := NewRecommendationRepository(db).Recompute(context.Background(), run, 20, 3)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if ran != tt.locked {
+				t.Errorf("ran = %v, want %v", ran, tt.locked)
+			}
+			if got := fake.Statements(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("statements = %q, want %q", got, tt.want)
+			}
+			if !tt.locked {
+				return
+			}
+
+			if run.Similarities != 6 || run.Recommendations != 4 {
+				t.Errorf("run counts = %d similarities and %d recommendations, want 6 and 4", run.Similarities, run.Recommendations)
+			}
+			// Hanya pasangan yang dipinjam minimal minCoBorrowers patron yang dihitung
+			if got, want := fake.Args("INSERT INTO book_similarities"), []driver.Value{int64(20), int64(3)}; !reflect.DeepEqual(got, want) {
+				t.Errorf("similarity args = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+// Test RecommendedBooks: Patron tanpa riwayat mendapat buku populer, dengan
+// ketersediaan dari stok
+func TestRecommendedBooks_ColdStart(t *testing.T) {
+	popular, borrowedOut := uuid.New(), uuid.New()
+	fake, db := newFakeDB(t, func(query string) fakeResult {
+		return fakeResult{columns: 6, rows: [][]driver.Value{
+			{popular.String(), "Dune", "Frank Herbert", int64(2), float64(0), models.ReasonPopular},
+			{borrowedOut.String(), "Emma", "Jane Austen", int64(0), float64(0), models.ReasonPopular},
+		}}
+	})
+	userID := uuid.New()
+
+	books, err := NewRecommendationRepository(db).RecommendedBooks(context.Background(), userID, 10)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	want := []models.RecommendedBook{
+		{ID: popular, Title: "Dune", Author: "Frank Herbert", Stock: 2, Available: true, Reason: models.ReasonPopular},
+		{ID: borrowedOut, Title: "Emma", Author: "Jane Austen", Reason: models.ReasonPopular},
+	}
+	if !reflect.DeepEqual(books, want) {
+		t.Errorf("books = %+v, want %+v", books, want)
+	}
+	if got, want := fake.Args("WITH borrowed AS"), []driver.Value{userID.String(), int64(10)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("args = %v, want %v", got, want)
+	}
+}
+
+// Test recommendation queries: Buku co-borrowed didahulukan, lalu kategori,
+// lalu buku populer untuk patron, dan buku yang sudah dipinjam atau buku itu
+// sendiri tidak direkomendasikan
+func TestRecommendationQueries_Tiers(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   func(r *RecommendationRepository) error
+		reasons []string
+		exclude string
+	}{
+		{"similar books", func(r *RecommendationRepository) error {
+			_, err := r.SimilarBooks(context.Background(), uuid.New(), 5)
+			return err
+		}, []string{models.ReasonCoBorrowed, models.ReasonCategory}, "WHERE book_id <> $1"},
+		{"patron", func(r *RecommendationRepository) error {
+			_, err := r.RecommendedBooks(context.Background(), uuid.New(), 5)
+			return err
+		}, []string{models.ReasonCoBorrowed, models.ReasonCategory, models.ReasonPopular}, "WHERE book_id NOT IN (SELECT book_id FROM borrowed)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var query string
+			_, db := newFakeDB(t, func(q string) fakeResult {
+				query = q
+				return fakeResult{columns: 6}
+			})
+
+			if err := tt.query(NewRecommendationRepository(db)); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			for tier, reason := range tt.reasons {
+				if !strings.Contains(query, "'"+reason+"'") {
+					t.Errorf("expected %s recommendations", reason)
+				}
+				if next := tier + 1; next < len(tt.reasons) && strings.Index(query, "'"+reason+"'") > strings.Index(query, "'"+tt.reasons[next]+"'") {
+					t.Errorf("expected %s before %s", reason, tt.reasons[next])
+				}
+			}
+			if !strings.Contains(query, tt.exclude) || !strings.Contains(query, "ORDER BY best.tier, best.ord") {
+				t.Errorf("expected the query to leave out %q and order by tier", tt.exclude)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/models"
+)
+
+// ReportRepository reads circulation reports. Loan counts and stock
+// utilisation come from the circulation_daily and book_loan_days
+// materialized views as of their last refresh; the counts of loans still out
+// are read live. Periods run from from up to, not including, to.
+type ReportRepository struct {
+	db *sql.DB
+}
+
+func NewReportRepository(db *sql.DB) *ReportRepository {
+	return &ReportRepository{db: db}
+}
+
+// Refresh refreshes the report views and records when. Readers keep seeing
+// the previous data while it runs. It returns false without doing anything
+// while another instance refreshes them.
+func (r *ReportRepository) Refresh(ctx context.Context) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var locked bool
+	if err := tx.QueryRowContext(ctx, `SELECT pg_try_advisory_xact_lock(hashtext('circulation_reports'))`).Scan(&locked); err != nil {
+		return false, fmt.Errorf("failed to lock reports: %w", err)
+	}
+	if !locked {
+		return false, nil
+	}
+
+	for _, view := range []string{"circulation_daily", "book_loan_days"} {
+		if _, err := tx.ExecContext(ctx, `REFRESH MATERIALIZED VIEW CONCURRENTLY `+view); err != nil {
+			return false, fmt.Errorf("failed to refresh %s: %w", view, err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE report_refreshes SET refreshed_at = CURRENT_TIMESTAMP WHERE name = 'circulation'`); err != nil {
+		return false, fmt.Errorf("failed to record report refresh: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit report refresh: %w", err)
+	}
+	return true, nil
+}
+
+// RefreshedAt returns when the report views were last refreshed.
+func (r *ReportRepository) RefreshedAt(ctx context.Context) (time.Time, error) {
+	var refreshedAt time.Time
+	err := r.db.QueryRowContext(ctx, `SELECT refreshed_at FROM report_refreshes WHERE name = 'circulation'`).Scan(&refreshedAt)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get report refresh time: %w", err)
+	}
+	return refreshedAt, nil
+}
+
+// CirculationSummary sums up the loans started in a period of days days.
+func (r *ReportRepository) CirculationSummary(ctx context.Context, from, to time.Time, days int) (*models.CirculationSummary, error) {
+	var summary models.CirculationSummary
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(loans), 0), COALESCE(SUM(returned), 0), COALESCE(SUM(returned_late), 0),
+			COALESCE(SUM(returned_seconds) / NULLIF(SUM(returned), 0) / 86400, 0)
+		FROM circulation_daily
+		WHERE day >= $1 AND day < $2`,
+		from, to,
+	).Scan(&summary.Loans, &summary.Returned, &summary.ReturnedLate, &summary.AverageLoanDays)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum up loans: %w", err)
+	}
+
+	err = r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM borrowing_records
+		WHERE returned_at IS NULL AND due_date < CURRENT_TIMESTAMP AND borrowed_at >= $1 AND borrowed_at < $2`,
+		from, to,
+	).Scan(&summary.Overdue)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count overdue loans: %w", err)
+	}
+
+	// Erased patrons have no user_id and are left out
+	err = r.db.QueryRowContext(ctx, `
+		SELECT COUNT(DISTINCT user_id)
+		FROM borrowing_records
+		WHERE borrowed_at < $2 AND (returned_at IS NULL OR returned_at >= $1)`,
+		from, to,
+	).Scan(&summary.ActiveBorrowers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count active borrowers: %w", err)
+	}
+
+	err = r.db.QueryRowContext(ctx, `
+		SELECT COALESCE(
+			(SELECT SUM(copies_out) FROM book_loan_days WHERE day >= $1 AND day < $2)::float8
+			/ NULLIF(((SELECT COALESCE(SUM(stock), 0) FROM books) + (SELECT COUNT(*) FROM borrowing_records WHERE returned_at IS NULL)) * $3, 0),
+		0)`,
+		from, to, days,
+	).Scan(&summary.StockUtilisation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute stock utilisation: %w", err)
+	}
+
+	return &summary, nil
+}
+
+// TopBooks returns the limit books borrowed most in a period.
+func (r *ReportRepository) TopBooks(ctx context.Context, from, to time.Time, limit int) ([]models.BookLoans, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT b.id, b.title, b.author, SUM(d.loans) AS loans
+		FROM circulation_daily d
+		INNER JOIN books b ON b.id = d.book_id
+		WHERE d.day >= $1 AND d.day < $2
+		GROUP BY b.id
+		ORDER BY loans DESC, b.title, b.id
+		LIMIT $3`,
+		from, to, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list most borrowed books: %w", err)
+	}
+	defer rows.Close()
+
+	books := []models.BookLoans{}
+	for rows.Next() {
+		var book models.BookLoans
+		if err := rows.Scan(&book.ID, &book.Title, &book.Author, &book.Loans); err != nil {
+			return nil, fmt.Errorf("failed to scan book loans: %w", err)
+		}
+		books = append(books, book)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list most borrowed books: %w", err)
+	}
+	return books, nil
+}
+
+// CategoryLoans returns the loans of a period per category of the books,
+// most first. Names are left to the caller.
+func (r *ReportRepository) CategoryLoans(ctx context.Context, from, to time.Time) ([]models.CategoryLoans, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT b.category_id, SUM(d.loans) AS loans, COUNT(DISTINCT d.book_id)
+		FROM circulation_daily d
+		INNER JOIN books b ON b.id = d.book_id
+		WHERE d.day >= $1 AND d.day < $2
+		GROUP BY b.category_id
+		ORDER BY loans DESC, b.category_id`,
+		from, to,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count loans per category: %w", err)
+	}
+	defer rows.Close()
+
+	categories := []models.CategoryLoans{}
+	for rows.Next() {
+		var category models.CategoryLoans
+		if err := rows.Scan(&category.CategoryID, &category.Loans, &category.Books); err != nil {
+			return nil, fmt.Errorf("failed to scan category loans: %w", err)
+		}
+		categories = append(categories, category)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to count loans per category: %w", err)
+	}
+	return categories, nil
+}
+
+// Utilisation returns limit books ordered by how much of a period of days
+// days their copies spent on loan, most used first unless ascending.
+func (r *ReportRepository) Utilisation(ctx context.Context, from, to time.Time, days int, ascending bool, limit int) ([]models.BookUtilisation, error) {
+	direction := "DESC"
+	if ascending {
+		direction = "ASC"
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		WITH out_now AS (
+			SELECT book_id, COUNT(*) AS n FROM borrowing_records WHERE returned_at IS NULL GROUP BY book_id
+		), loan_days AS (
+			SELECT book_id, SUM(copies_out) AS n FROM book_loan_days WHERE day >= $1 AND day < $2 GROUP BY book_id
+		), usage AS (
+			SELECT b.id, b.title, b.author, COALESCE(b.stock, 0) + COALESCE(o.n, 0) AS copies, COALESCE(l.n, 0) AS loan_days
+			FROM books b
+			LEFT JOIN out_now o ON o.book_id = b.id
+			LEFT JOIN loan_days l ON l.book_id = b.id
+		)
+		SELECT id, title, author, copies, loan_days, COALESCE(loan_days::float8 / NULLIF(copies * $3, 0), 0) AS utilisation
+		FROM usage
+		ORDER BY utilisation `+direction+`, title, id
+		LIMIT $4`,
+		from, to, days, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute book utilisation: %w", err)
+	}
+	defer rows.Close()
+
+	books := []models.BookUtilisation{}
+	for rows.Next() {
+		var book models.BookUtilisation
+		if err := rows.Scan(&book.ID, &book.Title, &book.Author, &book.Copies, &book.LoanDays, &book.Utilisation); err != nil {
+			return nil, fmt.Errorf("failed to scan book utilisation: %w", err)
+		}
+		books = append(books, book)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to compute book utilisation: %w", err)
+	}
+	return books, nil
+}
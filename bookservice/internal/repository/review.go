@@ -0,0 +1,242 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/models"
+)
+
+// reviewSortKeys orders reviews newest first.
+var reviewSortKeys = []sortKey{{expr: "created_at", desc: true}, {expr: "id", desc: true}}
+
+const reviewColumns = `id, book_id, user_id, rating, body, status, moderation_note, moderated_by, moderated_at, created_at, updated_at`
+
+type ReviewRepository struct {
+	db *sql.DB
+}
+
+func NewReviewRepository(db *sql.DB) *ReviewRepository {
+	return &ReviewRepository{db: db}
+}
+
+func (r *ReviewRepository) GetReview(ctx context.Context, id uuid.UUID) (*models.Review, error) {
+	review, err := scanReview(r.db.QueryRowContext(ctx, `SELECT `+reviewColumns+` FROM book_reviews WHERE id = $1`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get review: %w", err)
+	}
+	return review, nil
+}
+
+// AddReview inserts a review and sets its ID and timestamps. It returns false
+// without adding anything when the user already reviewed the book.
+func (r *ReviewRepository) AddReview(ctx context.Context, review *models.Review) (bool, error) {
+	added := false
+	err := r.withRating(ctx, review.BookID, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO book_reviews (book_id, user_id, rating, body, status)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (book_id, user_id) DO NOTHING
+			RETURNING id, created_at, updated_at`,
+			review.BookID, review.UserID, review.Rating, review.Text, review.Status,
+		).Scan(&review.ID, &review.CreatedAt, &review.UpdatedAt)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to add review: %w", err)
+		}
+		added = true
+		return nil
+	})
+	return added, err
+}
+
+// UpdateReview replaces the rating and text of a review.
+func (r *ReviewRepository) UpdateReview(ctx context.Context, review *models.Review) error {
+	return r.withRating(ctx, review.BookID, func(tx *sql.Tx) error {
+		review.UpdatedAt = time.Now()
+		_, err := tx.ExecContext(ctx, `
+			UPDATE book_reviews SET rating = $1, body = $2, updated_at = $3 WHERE id = $4`,
+			review.Rating, review.Text, review.UpdatedAt, review.ID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update review: %w", err)
+		}
+		return nil
+	})
+}
+
+// ModerateReview records the status a librarian set on a review, with their
+// note.
+func (r *ReviewRepository) ModerateReview(ctx context.Context, review *models.Review) error {
+	return r.withRating(ctx, review.BookID, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE book_reviews SET status = $1, moderation_note = $2, moderated_by = $3, moderated_at = $4 WHERE id = $5`,
+			review.Status, review.ModerationNote, review.ModeratedBy, review.ModeratedAt, review.ID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to moderate review: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *ReviewRepository) DeleteReview(ctx context.Context, review *models.Review) error {
+	return r.withRating(ctx, review.BookID, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM book_reviews WHERE id = $1`, review.ID); err != nil {
+			return fmt.Errorf("failed to delete review: %w", err)
+		}
+		return nil
+	})
+}
+
+// DeleteUserReviews deletes every review of the user and returns how many
+// there were.
+func (r *ReviewRepository) DeleteUserReviews(ctx context.Context, userID uuid.UUID) (int64, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id, book_id FROM book_reviews WHERE user_id = $1`, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list reviews of user: %w", err)
+	}
+	var reviews []models.Review
+	for rows.Next() {
+		var review models.Review
+		if err := rows.Scan(&review.ID, &review.BookID); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan review: %w", err)
+		}
+		reviews = append(reviews, review)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to list reviews of user: %w", err)
+	}
+
+	// One book at a time, so each rating is refreshed under its own lock
+	for i := range reviews {
+		if err := r.DeleteReview(ctx, &reviews[i]); err != nil {
+			return int64(i), err
+		}
+	}
+	return int64(len(reviews)), nil
+}
+
+// ListReviews lists a page of reviews matching the filter, newest first,
+// starting after the review whose sort keys are filter.After. It also
+// returns the sort keys of the last review when more reviews follow.
+func (r *ReviewRepository) ListReviews(ctx context.Context, filter models.ReviewFilter) ([]models.Review, []string, error) {
+	if err := checkCursor(reviewSortKeys, filter.After); err != nil {
+		return nil, nil, err
+	}
+
+	query := `SELECT ` + reviewColumns + `, ` + keyColumn(reviewSortKeys) + ` FROM book_reviews WHERE TRUE`
+	args := []interface{}{}
+
+	if filter.BookID != uuid.Nil {
+		args = append(args, filter.BookID)
+		query += fmt.Sprintf(" AND book_id = $%d", len(args))
+	}
+	if len(filter.Statuses) > 0 {
+		args = append(args, pq.Array(filter.Statuses))
+		query += fmt.Sprintf(" AND status = ANY($%d)", len(args))
+	}
+	if filter.After != nil {
+		condition, afterArgs := keysetCondition(reviewSortKeys, filter.After, len(args)+1)
+		query += " AND " + condition
+		args = append(args, afterArgs...)
+	}
+
+	// One review more than asked for tells whether another page follows
+	query += fmt.Sprintf(" ORDER BY %s LIMIT $%d", orderBy(reviewSortKeys), len(args)+1)
+	args = append(args, filter.Limit+1)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list reviews: %w", err)
+	}
+	defer rows.Close()
+
+	reviews := []models.Review{}
+	var lastKeys, nextKeys []string
+	for rows.Next() {
+		if len(reviews) == filter.Limit {
+			nextKeys = lastKeys
+			break
+		}
+
+		var rowKeys pq.StringArray
+		review, err := scanReview(rows, &rowKeys)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to scan review: %w", err)
+		}
+		reviews = append(reviews, *review)
+		lastKeys = rowKeys
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to list reviews: %w", err)
+	}
+	return reviews, nextKeys, nil
+}
+
+// withRating runs fn in a transaction holding the book's row, then brings
+// its rating up to date with its reviews, leaving out hidden ones.
+func (r *ReviewRepository) withRating(ctx context.Context, bookID uuid.UUID, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Reviews written at the same time would otherwise each count without the other
+	if _, err := tx.ExecContext(ctx, `SELECT id FROM books WHERE id = $1 FOR UPDATE`, bookID); err != nil {
+		return fmt.Errorf("failed to lock book: %w", err)
+	}
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE books
+		SET rating_count = r.count, rating_average = r.average
+		FROM (
+			SELECT COUNT(*) AS count, COALESCE(ROUND(AVG(rating), 2), 0) AS average
+			FROM book_reviews
+			WHERE book_id = $1 AND status <> $2
+		) r
+		WHERE books.id = $1`, bookID, models.ReviewHidden)
+	if err != nil {
+		return fmt.Errorf("failed to update book rating: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// scanReview scans a row selecting reviewColumns, followed by extra.
+func scanReview(row interface{ Scan(...interface{}) error }, extra ...interface{}) (*models.Review, error) {
+	var review models.Review
+	var moderatedBy uuid.NullUUID
+	var moderatedAt sql.NullTime
+	dest := append([]interface{}{
+		&review.ID, &review.BookID, &review.UserID, &review.Rating, &review.Text, &review.Status,
+		&review.ModerationNote, &moderatedBy, &moderatedAt, &review.CreatedAt, &review.UpdatedAt,
+	}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	if moderatedBy.Valid {
+		review.ModeratedBy = &moderatedBy.UUID
+	}
+	if moderatedAt.Valid {
+		review.ModeratedAt = &moderatedAt.Time
+	}
+	return &review, nil
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/models"
+)
+
+// Test ModerateReview: Menyembunyikan dan menampilkan lagi review menghitung
+// ulang rating buku tanpa review tersembunyi, dengan buku dikunci
+func TestModerateReview_RecomputesRating(t *testing.T) {
+	tests := []struct {
+		name   string
+		status string
+	}{
+		{"hide", models.ReviewHidden},
+		{"unhide", models.ReviewVisible},
+		{"flag", models.ReviewFlagged},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, db := newFakeDB(t, nil)
+			moderator, now := uuid.New(), time.Now()
+			review := &models.Review{ID: uuid.New(), BookID: uuid.New(), Status: tt.status, ModeratedBy: &moderator, ModeratedAt: &now}
+
+			if err := NewReviewRepository(db).ModerateReview(context.Background(), review); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			want := []string{
+				"BEGIN",
+				"SELECT id FROM books WHERE id = $1 FOR UPDATE",
+				"UPDATE book_reviews SET status = $1, moderation_note = $2, moderated_by = $3, moderated_at = $4 WHERE id = $5",
+				"UPDATE books",
+				"COMMIT",
+			}
+			if got := fake.Statements(); !reflect.DeepEqual(got, want) {
+				t.Errorf("statements = %q, want %q", got, want)
+			}
+			if got := fake.Args("UPDATE book_reviews")[0]; got != tt.status {
+				t.Errorf("status = %v, want %s", got, tt.status)
+			}
+			// Rating selalu dihitung tanpa review tersembunyi, apa pun status barunya
+			wantArgs := []driver.Value{review.BookID.String(), models.ReviewHidden}
+			if got := fake.Args("UPDATE books"); !reflect.DeepEqual(got, wantArgs) {
+				t.Errorf("rating args = %v, want %v", got, wantArgs)
+			}
+		})
+	}
+}
+
+// Test withRating: Perubahan review yang gagal dibatalkan tanpa mengubah
+// rating
+func TestWithRating_FailureRollsBack(t *testing.T) {
+	fake, db := newFakeDB(t, func(query string) fakeResult {
+		if strings.HasPrefix(strings.TrimSpace(query), "DELETE FROM book_reviews") {
+			return fakeResult{err: errors.New("connection reset")}
+		}
+		return fakeResult{}
+	})
+
+	err := NewReviewRepository(db).DeleteReview(context.Background(), &models.Review{ID: uuid.New(), BookID: uuid.New()})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	want := []string{
+		"BEGIN",
+		"SELECT id FROM books WHERE id = $1 FOR UPDATE",
+		"DELETE FROM book_reviews WHERE id = $1",
+		"ROLLBACK",
+	}
+	if got := fake.Statements(); !reflect.DeepEqual(got, want) {
+		t.Errorf("statements = %q, want %q", got, want)
+	}
+}
+
+// Test AddReview: Review kedua dari user yang sama tidak ditambahkan
+func TestAddReview_OnePerUser(t *testing.T) {
+	tests := []struct {
+		name  string
+		rows  [][]driver.Value
+		added bool
+	}{
+		{"first review", [][]driver.Value{{uuid.New().String(), time.Now(), time.Now()}}, true},
+		{"already reviewed", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, db := newFakeDB(t, func(query string) fakeResult {
+				if strings.HasPrefix(strings.TrimSpace(query), "INSERT INTO book_reviews") {
+					return fakeResult{columns: 3, rows: tt.rows}
+				}
+				return fakeResult{}
+			})
+
+			added, err := NewReviewRepository(db).AddReview(context.Background(), &models.Review{BookID: uuid.New(), UserID: uuid.New(), Rating: 4})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if added != tt.added {
+				t.Errorf("added = %v, want %v", added, tt.added)
+			}
+			if statements := fake.Statements(); statements[len(statements)-1] != "COMMIT" {
+				t.Errorf("statements = %q, want a commit", statements)
+			}
+		})
+	}
+}
@@ -1,47 +1,149 @@
 package router
 
 import (
+	"context"
 	"database/sql"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/swagger"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/config"
 	"github.com/sir-shalahuddin/grpc-learn/bookservice/internal/handler"
 	"github.com/sir-shalahuddin/grpc-learn/bookservice/internal/repository"
 	"github.com/sir-shalahuddin/grpc-learn/bookservice/internal/service"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/blob"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/metadata"
 	"github.com/sir-shalahuddin/grpc-learn/bookservice/proto/authservice"
 	pb "github.com/sir-shalahuddin/grpc-learn/bookservice/proto/categoryservice"
+	"github.com/sir-shalahuddin/grpc-learn/pkg/cache"
 )
 
 // RegisterRoutes sets up the Fiber routes for user management
-func RegisterRoutes(app *fiber.App, db *sql.DB, authSvc authservice.AuthServiceClient, ctgSvc pb.BookCategoryServiceClient) {
+func RegisterRoutes(app *fiber.App, db *sql.DB, authSvc authservice.AuthServiceClient, ctgSvc pb.BookCategoryServiceClient, authCache cache.Cache, cacheConfig config.CacheConfig, metadataProvider metadata.Provider, metadataConfig config.MetadataConfig, coverStore blob.Store, coverConfig config.CoverConfig, recommendationConfig config.RecommendationConfig, reportConfig config.ReportConfig, importConfig config.ImportConfig) {
 	txRepo := repository.NewTxRepository(db)
 
 	bookRepo := repository.NewBookRepository(db)
-	ctgRepo := repository.NewCategoryRepository(ctgSvc)
-	bookService := service.NewBookService(bookRepo, ctgRepo)
-	bookHandler := handler.NewBookHandler(bookService)
+	ctgRepo := repository.NewCategoryRepository(ctgSvc, cacheConfig.CategoryTTL)
+	go ctgRepo.Watch(context.Background())
+	metadataRepo := repository.NewMetadataRepository(metadataProvider, cache.NewLRU(metadataConfig.CacheSize), metadataConfig.CacheTTL, metadataConfig.NegativeTTL)
+	authorRepo := repository.NewAuthorRepository(db)
+	bookService := service.NewBookService(bookRepo, ctgRepo, metadataRepo, authorRepo)
 
-	authRepo := repository.NewAuthRepository(authSvc)
+	authorService := service.NewAuthorService(authorRepo)
+	authorHandler := handler.NewAuthorHandler(authorService, bookService)
+
+	authRepo := repository.NewAuthRepository(authSvc, authCache, cacheConfig.TTL, cacheConfig.NegativeTTL, cacheConfig.MemoryTokenTTL)
 	authService := service.NewAuthService(authRepo)
 	authMiddleware := handler.NewAuthMiddleware(authService)
+	authCacheHandler := handler.NewAuthCacheHandler(authService)
+
+	// Drop cached tokens as soon as they are revoked instead of waiting for the TTL
+	if redisCache, ok := authCache.(*cache.Redis); ok {
+		redisCache.Subscribe(context.Background(), repository.RevocationChannel, authRepo.HandleRevocation)
+	}
+
+	listRepo := repository.NewReadingListRepository(db)
+	listService := service.NewReadingListService(listRepo, bookRepo)
+	listHandler := handler.NewReadingListHandler(listService)
 
 	borrowingRecordRepo := repository.NewBorrowingRecordRepository(db)
-	borrowingRecordService := service.NewBorrowingRecordService(borrowingRecordRepo, txRepo, bookRepo)
+	borrowingRecordService := service.NewBorrowingRecordService(borrowingRecordRepo, txRepo, bookRepo, listRepo, authRepo)
 	borrowingRecordHandler := handler.NewBorrowingRecordHandler(borrowingRecordService)
 
+	importRepo := repository.NewImportRepository(db)
+	importService := service.NewImportService(importRepo, bookRepo, ctgRepo)
+	// Imports left behind by a stopped instance are failed within minutes
+	go importService.Schedule(context.Background(), time.Minute)
+	importHandler := handler.NewImportHandler(importService, importConfig.MaxSize)
+
+	coverRepo := repository.NewCoverRepository(db)
+	coverService := service.NewCoverService(coverRepo, bookRepo, coverStore, coverConfig.MaxSize)
+	coverHandler := handler.NewCoverHandler(coverService, coverConfig.MaxSize)
+	bookHandler := handler.NewBookHandler(bookService, coverService)
+
+	reviewRepo := repository.NewReviewRepository(db)
+	reviewService := service.NewReviewService(reviewRepo, bookRepo, borrowingRecordRepo)
+	reviewHandler := handler.NewReviewHandler(reviewService)
+
+	recommendationRepo := repository.NewRecommendationRepository(db)
+	recommendationService := service.NewRecommendationService(recommendationRepo, bookRepo, recommendationConfig.Size, recommendationConfig.MinCoBorrowers)
+	go recommendationService.Schedule(context.Background(), recommendationConfig.Interval)
+	recommendationHandler := handler.NewRecommendationHandler(recommendationService)
+
+	reportService := service.NewReportService(repository.NewReportRepository(db), ctgRepo)
+	go reportService.Schedule(context.Background(), reportConfig.RefreshInterval)
+	reportHandler := handler.NewReportHandler(reportService)
+
 	// documentation
 	app.Get("/swagger/*", swagger.HandlerDefault)
 
+	app.Get("/auth/cache-stats", authMiddleware.Protected("super admin"), authCacheHandler.CacheStats)
+
 	books := app.Group("/books")
 
 	books.Post("/:id/borrow", authMiddleware.Protected("user"), borrowingRecordHandler.BorrowBook)
 	books.Put("/:book_id/records/:record_id", authMiddleware.Protected("user"), borrowingRecordHandler.ReturnBook)
 	books.Get("/records", authMiddleware.Protected("user"), borrowingRecordHandler.ListBorrowingRecords)
+	books.Get("/records/search", authMiddleware.Protected("librarian"), borrowingRecordHandler.SearchRecords)
+	books.Get("/records/overdue", authMiddleware.Protected("librarian"), borrowingRecordHandler.OverdueRecords)
+
+	books.Get("/reviews", authMiddleware.Protected("librarian"), reviewHandler.ListReviews)
+	books.Put("/reviews/:id/moderation", authMiddleware.Protected("librarian"), reviewHandler.ModerateReview)
+	books.Post("/:id/reviews", authMiddleware.Protected("user"), reviewHandler.AddReview)
+	books.Get("/:id/reviews", reviewHandler.ListBookReviews)
+	books.Put("/:id/reviews/:review_id", authMiddleware.Protected("user"), reviewHandler.UpdateReview)
+	books.Delete("/:id/reviews/:review_id", authMiddleware.Protected("user"), reviewHandler.DeleteReview)
+
+	books.Get("/recommended", authMiddleware.Protected("user"), recommendationHandler.RecommendedBooks)
+	books.Post("/recommendations/recompute", authMiddleware.Protected("librarian"), recommendationHandler.Recompute)
+	books.Get("/recommendations/status", authMiddleware.Protected("librarian"), recommendationHandler.RecomputeStatus)
+	books.Get("/:id/similar", recommendationHandler.SimilarBooks)
+
+	books.Post("/imports", authMiddleware.Protected("librarian"), importHandler.StartImport)
+	books.Get("/imports/:id", authMiddleware.Protected("librarian"), importHandler.GetImport)
+	books.Get("/imports/:id/rows", authMiddleware.Protected("librarian"), importHandler.ListImportRows)
+	books.Get("/export", authMiddleware.Protected("librarian"), importHandler.ExportBooks)
 
 	books.Post("/", authMiddleware.Protected("librarian"), bookHandler.AddBook)
+	books.Post("/lookup", authMiddleware.Protected("librarian"), bookHandler.LookupBook)
+	books.Put("/:id/cover", authMiddleware.Protected("librarian"), coverHandler.UploadCover)
+	books.Get("/:id/cover", coverHandler.GetCover)
+	books.Delete("/:id/cover", authMiddleware.Protected("librarian"), coverHandler.DeleteCover)
 	books.Get("/:id", bookHandler.GetBookByID)
 	books.Put("/:id", authMiddleware.Protected("librarian"), bookHandler.UpdateBook)
 	books.Delete("/:id", authMiddleware.Protected("librarian"), bookHandler.DeleteBook)
 	books.Get("/", bookHandler.ListBooks)
 
+	lists := app.Group("/lists")
+
+	lists.Get("/shared/:slug", listHandler.GetSharedList)
+	lists.Get("/", authMiddleware.Protected("user"), listHandler.ListLists)
+	lists.Post("/", authMiddleware.Protected("user"), listHandler.CreateList)
+	lists.Get("/:id", authMiddleware.Protected("user"), listHandler.GetList)
+	lists.Put("/:id", authMiddleware.Protected("user"), listHandler.UpdateList)
+	lists.Delete("/:id", authMiddleware.Protected("user"), listHandler.DeleteList)
+	lists.Post("/:id/items", authMiddleware.Protected("user"), listHandler.AddItem)
+	lists.Put("/:id/items/:book_id", authMiddleware.Protected("user"), listHandler.SetItemNotify)
+	lists.Delete("/:id/items/:book_id", authMiddleware.Protected("user"), listHandler.RemoveItem)
+	lists.Put("/:id/order", authMiddleware.Protected("user"), listHandler.ReorderItems)
+
+	notifications := app.Group("/notifications")
+
+	notifications.Get("/", authMiddleware.Protected("user"), listHandler.ListNotifications)
+	notifications.Put("/:id/read", authMiddleware.Protected("user"), listHandler.MarkNotificationRead)
+
+	reports := app.Group("/reports")
+
+	reports.Get("/circulation", authMiddleware.Protected("librarian"), reportHandler.CirculationReport)
+	reports.Get("/books", authMiddleware.Protected("librarian"), reportHandler.TopBooksReport)
+	reports.Get("/categories", authMiddleware.Protected("librarian"), reportHandler.CategoryReport)
+	reports.Get("/utilisation", authMiddleware.Protected("librarian"), reportHandler.UtilisationReport)
+
+	authors := app.Group("/authors")
+
+	authors.Get("/", authorHandler.ListAuthors)
+	authors.Get("/:id", authorHandler.GetAuthor)
+	authors.Get("/:id/books", authorHandler.ListAuthorBooks)
+	authors.Post("/:id/merge", authMiddleware.Protected("librarian"), authorHandler.MergeAuthor)
+
 }
@@ -0,0 +1,193 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/internal/dto"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/internal/service"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/models"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/pagination"
+	pb "github.com/sir-shalahuddin/grpc-learn/bookservice/proto/bookservice"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type borrowingRecordService interface {
+	ExportUserRecords(ctx context.Context, userID uuid.UUID) ([]models.BorrowingRecord, error)
+	EraseUserRecords(ctx context.Context, userID uuid.UUID) (int64, error)
+}
+
+type reviewService interface {
+	EraseUserReviews(ctx context.Context, userID uuid.UUID) (int64, error)
+}
+
+type readingListService interface {
+	EraseUserLists(ctx context.Context, userID uuid.UUID) (int64, error)
+}
+
+type recommendationService interface {
+	EraseUserRecommendations(ctx context.Context, userID uuid.UUID) (int64, error)
+}
+
+type bookService interface {
+	ListBooks(ctx context.Context, req dto.ListBooksRequest) (*dto.ListBooksResponse, error)
+	CountBooksByCategory(ctx context.Context, categoryID uuid.UUID) (int64, error)
+	ReassignBooksCategory(ctx context.Context, fromID, toID uuid.UUID) (int64, error)
+}
+
+type bookGRPCServer struct {
+	pb.UnimplementedBookServiceServer // Embed to have forward compatible implementations.
+	bookService                       bookService
+	recordService                     borrowingRecordService
+	reviewService                     reviewService
+	listService                       readingListService
+	recommendationService             recommendationService
+}
+
+// NewBookGRPCServer creates a new instance of BookGRPCServer.
+func NewBookGRPCServer(bookService bookService, recordService borrowingRecordService, reviewService reviewService, listService readingListService, recommendationService recommendationService) *bookGRPCServer {
+	return &bookGRPCServer{bookService: bookService, recordService: recordService, reviewService: reviewService, listService: listService, recommendationService: recommendationService}
+}
+
+// GetBooks lists a page of books, searching them when q is set.
+func (s *bookGRPCServer) GetBooks(ctx context.Context, req *pb.GetBooksRequest) (*pb.BookListResponse, error) {
+	list, err := s.bookService.ListBooks(ctx, dto.ListBooksRequest{
+		Q:        req.GetQ(),
+		Cursor:   req.GetCursor(),
+		PageSize: int(req.GetPageSize()),
+	})
+	if err != nil {
+		if errors.Is(err, pagination.ErrInvalidCursor) {
+			return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to retrieve books: %v", err)
+	}
+
+	res := make([]*pb.BookResponse, 0, len(list.Books))
+	for _, book := range list.Books {
+		// Only the copies on the shelf are tracked, so total_copies stays unset
+		bookRes := &pb.BookResponse{
+			Id:              book.ID.String(),
+			Title:           book.Title,
+			Author:          book.Author,
+			CategoryName:    book.Category,
+			AvailableCopies: int32(book.Stock),
+			Rank:            book.Rank,
+		}
+		if book.Highlight != nil {
+			bookRes.TitleHighlight = book.Highlight.Title
+			bookRes.AuthorHighlight = book.Highlight.Author
+		}
+		res = append(res, bookRes)
+	}
+
+	return &pb.BookListResponse{Books: res, NextCursor: list.NextCursor, Total: list.Total}, nil
+}
+
+// ExportUserData returns the full borrowing history of a user.
+func (s *bookGRPCServer) ExportUserData(ctx context.Context, req *pb.ExportUserDataRequest) (*pb.ExportUserDataResponse, error) {
+	userID, err := uuid.Parse(req.GetUserId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user ID format: %v", err)
+	}
+
+	records, err := s.recordService.ExportUserRecords(ctx, userID)
+	if err != nil {
+		if errors.Is(err, service.ErrRecordUserRequired) {
+			return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to retrieve borrowing records: %v", err)
+	}
+
+	var data []*pb.BorrowingRecordData
+	for _, record := range records {
+		data = append(data, &pb.BorrowingRecordData{
+			Id:         record.ID.String(),
+			BookId:     record.Book.ID.String(),
+			BookTitle:  record.Book.Title,
+			BookAuthor: record.Book.Author,
+			Isbn:       record.Book.ISBN,
+			BorrowedAt: formatTime(record.BorrowedAt),
+			DueDate:    formatTime(record.DueDate),
+			ReturnedAt: formatTime(record.ReturnedAt),
+		})
+	}
+
+	return &pb.ExportUserDataResponse{Records: data}, nil
+}
+
+// EraseUserData anonymizes the borrowing history of a user and deletes their
+// reviews, reading lists, notifications and recommendations.
+func (s *bookGRPCServer) EraseUserData(ctx context.Context, req *pb.EraseUserDataRequest) (*pb.EraseUserDataResponse, error) {
+	userID, err := uuid.Parse(req.GetUserId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user ID format: %v", err)
+	}
+
+	anonymized, err := s.recordService.EraseUserRecords(ctx, userID)
+	if err != nil {
+		if errors.Is(err, service.ErrActiveLoans) {
+			return nil, status.Errorf(codes.FailedPrecondition, "%v", err)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to anonymize borrowing records: %v", err)
+	}
+
+	if _, err := s.reviewService.EraseUserReviews(ctx, userID); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to delete reviews: %v", err)
+	}
+	if _, err := s.listService.EraseUserLists(ctx, userID); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to delete reading lists: %v", err)
+	}
+	if _, err := s.recommendationService.EraseUserRecommendations(ctx, userID); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to delete recommendations: %v", err)
+	}
+
+	return &pb.EraseUserDataResponse{AnonymizedRecords: anonymized}, nil
+}
+
+// CountBooksByCategory returns how many books are in a category.
+func (s *bookGRPCServer) CountBooksByCategory(ctx context.Context, req *pb.CountBooksByCategoryRequest) (*pb.CountBooksByCategoryResponse, error) {
+	categoryID, err := uuid.Parse(req.GetCategoryId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid category ID format: %v", err)
+	}
+
+	count, err := s.bookService.CountBooksByCategory(ctx, categoryID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to count books: %v", err)
+	}
+
+	return &pb.CountBooksByCategoryResponse{Count: count}, nil
+}
+
+// ReassignBooksCategory moves every book of one category to another.
+func (s *bookGRPCServer) ReassignBooksCategory(ctx context.Context, req *pb.ReassignBooksCategoryRequest) (*pb.ReassignBooksCategoryResponse, error) {
+	fromID, err := uuid.Parse(req.GetFromCategoryId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid category ID format: %v", err)
+	}
+	toID, err := uuid.Parse(req.GetToCategoryId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid target category ID format: %v", err)
+	}
+
+	updated, err := s.bookService.ReassignBooksCategory(ctx, fromID, toID)
+	if err != nil {
+		if errors.Is(err, service.ErrCategoryNotFound) {
+			return nil, status.Errorf(codes.NotFound, "%v", err)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to reassign books: %v", err)
+	}
+
+	return &pb.ReassignBooksCategoryResponse{UpdatedBooks: updated}, nil
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
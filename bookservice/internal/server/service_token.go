@@ -0,0 +1,51 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+
+	pb "github.com/sir-shalahuddin/grpc-learn/bookservice/proto/bookservice"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ServiceTokenKey is the metadata key other services send the shared service
+// token in.
+const ServiceTokenKey = "x-service-token"
+
+// serviceMethods are the RPCs only other services may call. They act on the
+// data of any user or on the whole catalogue, so they are not for clients.
+var serviceMethods = map[string]bool{
+	pb.BookService_ExportUserData_FullMethodName:        true,
+	pb.BookService_EraseUserData_FullMethodName:         true,
+	pb.BookService_CountBooksByCategory_FullMethodName:  true,
+	pb.BookService_ReassignBooksCategory_FullMethodName: true,
+}
+
+// ServiceTokenInterceptor refuses calls to service-only RPCs that don't carry
+// token under ServiceTokenKey. When token is empty they are all refused.
+func ServiceTokenInterceptor(token string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if serviceMethods[info.FullMethod] {
+			if err := checkServiceToken(ctx, token); err != nil {
+				return nil, err
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+func checkServiceToken(ctx context.Context, token string) error {
+	if token == "" {
+		return status.Error(codes.PermissionDenied, "service calls are disabled, GRPC_SERVICE_TOKEN is not set")
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(ServiceTokenKey)
+	if len(values) == 0 || subtle.ConstantTimeCompare([]byte(values[0]), []byte(token)) != 1 {
+		return status.Error(codes.Unauthenticated, "invalid service token")
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/sir-shalahuddin/grpc-learn/bookservice/proto/bookservice"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Test ServiceTokenInterceptor: RPC khusus service butuh token yang benar,
+// RPC lain tetap terbuka
+func TestServiceTokenInterceptor(t *testing.T) {
+	tests := []struct {
+		name     string
+		token    string
+		method   string
+		metadata metadata.MD
+		want     codes.Code
+	}{
+		{"valid token", "secret", pb.BookService_EraseUserData_FullMethodName, metadata.Pairs(ServiceTokenKey, "secret"), codes.OK},
+		{"wrong token", "secret", pb.BookService_EraseUserData_FullMethodName, metadata.Pairs(ServiceTokenKey, "guess"), codes.Unauthenticated},
+		{"missing token", "secret", pb.BookService_ExportUserData_FullMethodName, metadata.Pairs("authorization", "Bearer user"), codes.Unauthenticated},
+		{"missing metadata", "secret", pb.BookService_ExportUserData_FullMethodName, nil, codes.Unauthenticated},
+		{"not configured", "", pb.BookService_ExportUserData_FullMethodName, metadata.Pairs(ServiceTokenKey, ""), codes.PermissionDenied},
+		{"category reassignment", "secret", pb.BookService_ReassignBooksCategory_FullMethodName, metadata.Pairs(ServiceTokenKey, "guess"), codes.Unauthenticated},
+		{"public method", "secret", pb.BookService_GetBooks_FullMethodName, nil, codes.OK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.metadata != nil {
+				ctx = metadata.NewIncomingContext(ctx, tt.metadata)
+			}
+
+			called := false
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				called = true
+				return nil, nil
+			}
+
+			_, err := ServiceTokenInterceptor(tt.token)(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			if got := status.Code(err); got != tt.want {
+				t.Errorf("interceptor error = %v, want %v", err, tt.want)
+			}
+			if called != (tt.want == codes.OK) {
+				t.Errorf("handler called = %v, want %v", called, tt.want == codes.OK)
+			}
+		})
+	}
+}
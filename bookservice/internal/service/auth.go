@@ -5,11 +5,14 @@ import (
 	"fmt"
 
 	"github.com/sir-shalahuddin/grpc-learn/bookservice/proto/authservice"
+	"github.com/sir-shalahuddin/grpc-learn/pkg/cache"
 )
 
 type AuthRepository interface {
 	GetUserByID(ctx context.Context, userID string) (*authservice.User, error)
-	ValidateToken(ctx context.Context, token string) (string, error)
+	GetUsersByIDs(ctx context.Context, userIDs []string) (map[string]*authservice.User, error)
+	IntrospectToken(ctx context.Context, token string) (*authservice.IntrospectTokenResponse, error)
+	CacheStats() cache.Stats
 }
 
 type authService struct {
@@ -30,12 +33,17 @@ func (s *authService) GetUserByID(ctx context.Context, userID string) (*authserv
 	return user, nil
 }
 
-// ValidateToken validates a JWT token and retrieves the associated user.
-func (s *authService) ValidateToken(ctx context.Context, token string) (string, error) {
-	userID, err := s.authRepo.ValidateToken(ctx, token)
+// IntrospectToken validates a JWT token or API key and retrieves the associated user's role and permissions.
+func (s *authService) IntrospectToken(ctx context.Context, token string) (*authservice.IntrospectTokenResponse, error) {
+	res, err := s.authRepo.IntrospectToken(ctx, token)
 	if err != nil {
-		return "", fmt.Errorf("failed to validate token: %w", err)
+		return nil, fmt.Errorf("failed to introspect token: %w", err)
 	}
 
-	return userID, nil
+	return res, nil
+}
+
+// CacheStats returns hit and miss counts of the auth cache.
+func (s *authService) CacheStats() cache.Stats {
+	return s.authRepo.CacheStats()
 }
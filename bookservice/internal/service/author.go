@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/internal/dto"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/models"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/pagination"
+)
+
+var ErrMergeSameAuthor = errors.New("an author can't be merged into itself")
+
+const (
+	defaultAuthorPageSize = 20
+	maxAuthorPageSize     = 100
+)
+
+type AuthorRepository interface {
+	GetAuthor(ctx context.Context, id uuid.UUID) (*models.Author, error)
+	ListAuthors(ctx context.Context, filter models.AuthorFilter) ([]models.Author, []string, error)
+	MergeAuthor(ctx context.Context, fromID, toID uuid.UUID) (int64, error)
+}
+
+type authorService struct {
+	authorRepo AuthorRepository
+}
+
+func NewAuthorService(authorRepo AuthorRepository) *authorService {
+	return &authorService{authorRepo: authorRepo}
+}
+
+// GetAuthor returns an author. The ID of a merged author returns the author
+// it was merged into.
+func (s *authorService) GetAuthor(ctx context.Context, id uuid.UUID) (*models.Author, error) {
+	author, err := s.authorRepo.GetAuthor(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if author == nil {
+		return nil, ErrAuthorNotFound
+	}
+	return author, nil
+}
+
+// ListAuthors lists a page of authors by name. Pages follow each other
+// through NextCursor.
+func (s *authorService) ListAuthors(ctx context.Context, req dto.ListAuthorsRequest) (*dto.ListAuthorsResponse, error) {
+	after, err := pagination.Decode(req.Cursor, "name")
+	if err != nil {
+		return nil, err
+	}
+
+	authors, nextKeys, err := s.authorRepo.ListAuthors(ctx, models.AuthorFilter{
+		Name:  req.Name,
+		After: after,
+		Limit: pagination.Limit(req.PageSize, defaultAuthorPageSize, maxAuthorPageSize),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.ListAuthorsResponse{
+		Authors:    authors,
+		NextCursor: pagination.Encode("name", nextKeys),
+	}, nil
+}
+
+// MergeAuthor folds a duplicate author into another, crediting its books to
+// the target instead.
+func (s *authorService) MergeAuthor(ctx context.Context, id, targetID uuid.UUID) (*dto.MergeAuthorResponse, error) {
+	source, err := s.GetAuthor(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.GetAuthor(ctx, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if source.ID == target.ID {
+		return nil, ErrMergeSameAuthor
+	}
+
+	moved, err := s.authorRepo.MergeAuthor(ctx, source.ID, target.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	merged, err := s.GetAuthor(ctx, target.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.MergeAuthorResponse{Author: *merged, BooksMoved: moved}, nil
+}
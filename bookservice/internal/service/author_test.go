@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/internal/dto"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/models"
+)
+
+// credit adalah satu baris book_authors.
+type credit struct {
+	bookID   uuid.UUID
+	authorID uuid.UUID
+	role     string
+}
+
+// fakeAuthorRepository adalah AuthorRepository di memori yang menggabungkan
+// author seperti repository: kredit dipindah ke target tanpa duplikat per
+// role, dan ID author yang digabung tetap menunjuk ke target.
+type fakeAuthorRepository struct {
+	authors map[uuid.UUID]string
+	aliases map[uuid.UUID]uuid.UUID
+	credits []credit
+}
+
+func newFakeAuthorRepository() *fakeAuthorRepository {
+	return &fakeAuthorRepository{
+		authors: make(map[uuid.UUID]string),
+		aliases: make(map[uuid.UUID]uuid.UUID),
+	}
+}
+
+func (r *fakeAuthorRepository) add(name string, books map[uuid.UUID]string) uuid.UUID {
+	id := uuid.New()
+	r.authors[id] = name
+	for bookID, role := range books {
+		r.credits = append(r.credits, credit{bookID: bookID, authorID: id, role: role})
+	}
+	return id
+}
+
+func (r *fakeAuthorRepository) GetAuthor(ctx context.Context, id uuid.UUID) (*models.Author, error) {
+	if target, ok := r.aliases[id]; ok {
+		id = target
+	}
+	name, ok := r.authors[id]
+	if !ok {
+		return nil, nil
+	}
+
+	books := make(map[uuid.UUID]bool)
+	for _, c := range r.credits {
+		if c.authorID == id {
+			books[c.bookID] = true
+		}
+	}
+	return &models.Author{ID: id, Name: name, BookCount: int64(len(books))}, nil
+}
+
+func (r *fakeAuthorRepository) ListAuthors(ctx context.Context, filter models.AuthorFilter) ([]models.Author, []string, error) {
+	return nil, nil, nil
+}
+
+func (r *fakeAuthorRepository) MergeAuthor(ctx context.Context, fromID, toID uuid.UUID) (int64, error) {
+	books := make(map[uuid.UUID]bool)
+	var kept []credit
+	for _, c := range r.credits {
+		if c.authorID == fromID {
+			books[c.bookID] = true
+		} else {
+			kept = append(kept, c)
+		}
+	}
+	for _, c := range r.credits {
+		if c.authorID != fromID {
+			continue
+		}
+		duplicate := false
+		for _, k := range kept {
+			if k.bookID == c.bookID && k.authorID == toID && k.role == c.role {
+				duplicate = true
+			}
+		}
+		if !duplicate {
+			kept = append(kept, credit{bookID: c.bookID, authorID: toID, role: c.role})
+		}
+	}
+	r.credits = kept
+
+	for alias, target := range r.aliases {
+		if target == fromID {
+			r.aliases[alias] = toID
+		}
+	}
+	r.aliases[fromID] = toID
+	delete(r.authors, fromID)
+	return int64(len(books)), nil
+}
+
+func (r *fakeAuthorRepository) creditsOf(bookID uuid.UUID) []credit {
+	var credits []credit
+	for _, c := range r.credits {
+		if c.bookID == bookID {
+			credits = append(credits, c)
+		}
+	}
+	return credits
+}
+
+// Test MergeAuthor: Buku author yang digabung dikreditkan ke target, buku
+// yang sudah mengkreditkan keduanya dalam role yang sama hanya punya satu
+// kredit
+func TestMergeAuthor_Recredits(t *testing.T) {
+	repo := newFakeAuthorRepository()
+	dune, messiah, anthology := uuid.New(), uuid.New(), uuid.New()
+	target := repo.add("Frank Herbert", map[uuid.UUID]string{dune: models.RoleAuthor, anthology: models.RoleAuthor})
+	source := repo.add("F. Herbert", map[uuid.UUID]string{messiah: models.RoleAuthor, anthology: models.RoleAuthor, dune: models.RoleEditor})
+	svc := NewAuthorService(repo)
+
+	res, err := svc.MergeAuthor(context.Background(), source, target)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if res.BooksMoved != 3 {
+		t.Errorf("BooksMoved = %d, want 3", res.BooksMoved)
+	}
+	if res.Author.ID != target || res.Author.BookCount != 3 {
+		t.Errorf("merged author = %+v, want %s on 3 books", res.Author, target)
+	}
+
+	tests := []struct {
+		name  string
+		book  uuid.UUID
+		roles []string
+	}{
+		{"moved", messiah, []string{models.RoleAuthor}},
+		{"credited by both", anthology, []string{models.RoleAuthor}},
+		{"other role kept", dune, []string{models.RoleAuthor, models.RoleEditor}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			credits := repo.creditsOf(tt.book)
+			if len(credits) != len(tt.roles) {
+				t.Fatalf("book has %d credits, want %d", len(credits), len(tt.roles))
+			}
+			for i, c := range credits {
+				if c.authorID != target || c.role != tt.roles[i] {
+					t.Errorf("credit %d = %s as %s, want %s as %s", i, c.authorID, c.role, target, tt.roles[i])
+				}
+			}
+		})
+	}
+
+	// ID author yang digabung tetap bisa dipakai
+	author, err := svc.GetAuthor(context.Background(), source)
+	if err != nil || author.ID != target {
+		t.Errorf("GetAuthor(source) = %v, %v, want %s", author, err, target)
+	}
+}
+
+// Test MergeAuthor: Author yang sudah digabung tidak bisa digabung ke
+// dirinya sendiri, dan ID lama mengikuti penggabungan berikutnya
+func TestMergeAuthor_Chain(t *testing.T) {
+	repo := newFakeAuthorRepository()
+	first := repo.add("F. Herbert", map[uuid.UUID]string{uuid.New(): models.RoleAuthor})
+	second := repo.add("Frank Herbert", map[uuid.UUID]string{uuid.New(): models.RoleAuthor})
+	third := repo.add("Franklin Patrick Herbert", nil)
+	svc := NewAuthorService(repo)
+
+	if _, err := svc.MergeAuthor(context.Background(), first, second); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		id       uuid.UUID
+		targetID uuid.UUID
+		wantErr  error
+	}{
+		{"into itself", second, second, ErrMergeSameAuthor},
+		{"merged ID into its target", first, second, ErrMergeSameAuthor},
+		{"missing source", uuid.New(), second, ErrAuthorNotFound},
+		{"missing target", second, uuid.New(), ErrAuthorNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.MergeAuthor(context.Background(), tt.id, tt.targetID); !errors.Is(err, tt.wantErr) {
+				t.Errorf("MergeAuthor() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Menggabung lewat ID lama memindahkan author yang sekarang dipakainya
+	res, err := svc.MergeAuthor(context.Background(), first, third)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if res.BooksMoved != 2 || res.Author.BookCount != 2 {
+		t.Errorf("merge = %+v, want 2 books moved to the third author", res)
+	}
+	for _, id := range []uuid.UUID{first, second} {
+		if author, _ := svc.GetAuthor(context.Background(), id); author == nil || author.ID != third {
+			t.Errorf("GetAuthor(%s) = %v, want %s", id, author, third)
+		}
+	}
+}
+
+// Test contributors: ID author yang digabung dikreditkan ke target dengan
+// namanya
+func TestContributors_MergedAuthor(t *testing.T) {
+	repo := newFakeAuthorRepository()
+	source := repo.add("F. Herbert", nil)
+	target := repo.add("Frank Herbert", nil)
+	if _, err := repo.MergeAuthor(context.Background(), source, target); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	svc := NewBookService(nil, nil, nil, repo)
+
+	tests := []struct {
+		name      string
+		requested []dto.ContributorRequest
+		want      []models.BookContributor
+		wantErr   error
+	}{
+		{"merged author", []dto.ContributorRequest{{AuthorID: source, Name: "Someone else"}},
+			[]models.BookContributor{{AuthorID: target, Name: "Frank Herbert", Role: models.RoleAuthor}}, nil},
+		{"by name", []dto.ContributorRequest{{Name: " Brian Herbert ", Role: "Editor"}},
+			[]models.BookContributor{{Name: "Brian Herbert", Role: models.RoleEditor}}, nil},
+		{"missing author", []dto.ContributorRequest{{AuthorID: uuid.New()}}, nil, ErrAuthorNotFound},
+		{"unknown role", []dto.ContributorRequest{{Name: "Frank Herbert", Role: "narrator"}}, nil, ErrInvalidRole},
+		{"no name", []dto.ContributorRequest{{Role: models.RoleAuthor}}, nil, ErrIncompleteBook},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := svc.contributors(context.Background(), tt.requested)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("contributors() error = %v, want %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("contributors() = %+v, want %+v", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("contributor %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
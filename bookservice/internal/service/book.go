@@ -5,13 +5,17 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-	"strconv"
+	"log"
 	"strings"
 	"sync"
 
 	"github.com/google/uuid"
 	"github.com/sir-shalahuddin/grpc-learn/bookservice/internal/dto"
 	"github.com/sir-shalahuddin/grpc-learn/bookservice/models"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/isbn"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/metadata"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/pagination"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/search"
 	pb "github.com/sir-shalahuddin/grpc-learn/bookservice/proto/categoryservice"
 )
 
@@ -19,6 +23,18 @@ var (
 	ErrBookNotFound     = errors.New("book not found")
 	ErrCategoryNotFound = errors.New("category not found")
 	ErrBookDuplicate    = errors.New("book already exists")
+	ErrInvalidISBN      = errors.New("invalid ISBN")
+	ErrMetadataNotFound = errors.New("no book details found for this ISBN")
+	ErrIncompleteBook   = errors.New("title and author are required")
+	ErrInvalidSort      = errors.New("sort must be one of title, -title, author, -author, published_date, -published_date, rating, -rating, newest or relevance")
+	ErrSortNeedsQuery   = errors.New("sorting by relevance needs a search query")
+	ErrAuthorNotFound   = errors.New("author not found")
+	ErrInvalidRole      = errors.New("role must be author, editor, translator or illustrator")
+)
+
+const (
+	defaultPageSize = 10
+	maxPageSize     = 100
 )
 
 type BookRepository interface {
@@ -26,39 +42,57 @@ type BookRepository interface {
 	AddBook(ctx context.Context, book *models.Book) error
 	UpdateBook(ctx context.Context, tx *sql.Tx, book *models.Book) error
 	DeleteBook(ctx context.Context, bookID uuid.UUID) error
-	ListBooks(ctx context.Context, title, author, category string, limit, offset int) ([]*models.Book, error)
+	ListBooks(ctx context.Context, filter models.BookFilter) ([]*models.Book, []string, error)
+	CountFacets(ctx context.Context, filter models.BookFilter) (*models.BookFacets, error)
 	GetBookByISBN(ctx context.Context, isbn string) (*models.Book, error)
+	CountByCategory(ctx context.Context, categoryID uuid.UUID) (int64, error)
+	ReassignCategory(ctx context.Context, fromID, toID uuid.UUID) (int64, error)
+	ListContributors(ctx context.Context, bookIDs []uuid.UUID) (map[uuid.UUID][]models.BookContributor, error)
+	ListCovers(ctx context.Context, bookIDs []uuid.UUID) (map[uuid.UUID]models.BookCover, error)
 }
 
 type categoryRepository interface {
 	GetCategories(ctx context.Context) ([]*pb.CategoryResponse, error)
 	GetCategoryByID(ctx context.Context, id string) (*pb.CategoryResponse, error)
+	GetDescendantIDs(ctx context.Context, id string) ([]string, error)
+}
+
+type metadataRepository interface {
+	LookupISBN(ctx context.Context, isbn13 string) (*metadata.Record, error)
 }
 
 type bookService struct {
-	bookRepo BookRepository
-	ctgRepo  categoryRepository
+	bookRepo   BookRepository
+	ctgRepo    categoryRepository
+	metaRepo   metadataRepository
+	authorRepo AuthorRepository
 }
 
-func NewBookService(bookRepo BookRepository, ctgRepo categoryRepository) *bookService {
+func NewBookService(bookRepo BookRepository, ctgRepo categoryRepository, metaRepo metadataRepository, authorRepo AuthorRepository) *bookService {
 	return &bookService{
-		bookRepo: bookRepo,
-		ctgRepo:  ctgRepo,
+		bookRepo:   bookRepo,
+		ctgRepo:    ctgRepo,
+		metaRepo:   metaRepo,
+		authorRepo: authorRepo,
 	}
 }
 
 func (s *bookService) AddBook(ctx context.Context, req dto.AddBookRequest, userID uuid.UUID) error {
+	isbn13, err := s.checkISBN(ctx, req.ISBN, uuid.Nil)
+	if err != nil {
+		return err
+	}
 
-	req.ISBN = strings.ReplaceAll(req.ISBN, "-", "")
+	if req.FillFromLookup && isbn13 != "" {
+		s.fillFromLookup(ctx, &req, isbn13)
+	}
+	if strings.TrimSpace(req.Title) == "" || (strings.TrimSpace(req.Author) == "" && len(req.Contributors) == 0) {
+		return ErrIncompleteBook
+	}
 
-	if req.ISBN != "" {
-		existingBook, err := s.bookRepo.GetBookByISBN(ctx, req.ISBN)
-		if err != nil {
-			return err
-		}
-		if existingBook != nil {
-			return fmt.Errorf("%w: %s", ErrBookDuplicate, existingBook.Title)
-		}
+	contributors, err := s.contributors(ctx, req.Contributors)
+	if err != nil {
+		return err
 	}
 
 	if req.CategoryID != uuid.Nil {
@@ -75,14 +109,23 @@ func (s *bookService) AddBook(ctx context.Context, req dto.AddBookRequest, userI
 	book := &models.Book{
 		Title:         req.Title,
 		Author:        req.Author,
-		ISBN:          req.ISBN,
+		ISBN:          isbn.Normalize(req.ISBN),
+		ISBN13:        isbn13,
 		PublishedDate: req.PublishedDate,
 		CategoryID:    req.CategoryID,
 		Stock:         req.Stock,
 		AddedBy:       userID,
+		Contributors:  contributors,
 	}
 
-	return s.bookRepo.AddBook(ctx, book)
+	// Another book may have taken the ISBN since checkISBN
+	if err := s.bookRepo.AddBook(ctx, book); err != nil {
+		if errors.Is(err, models.ErrDuplicateISBN) {
+			return ErrBookDuplicate
+		}
+		return err
+	}
+	return nil
 }
 
 func (s *bookService) GetBookByID(ctx context.Context, id uuid.UUID) (*dto.GetBookResponse, error) {
@@ -100,30 +143,50 @@ func (s *bookService) GetBookByID(ctx context.Context, id uuid.UUID) (*dto.GetBo
 		return nil, fmt.Errorf("failed to get category by ID: %w", err)
 	}
 
+	contributors, err := s.bookRepo.ListContributors(ctx, []uuid.UUID{book.ID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get contributors: %w", err)
+	}
+
+	covers, err := s.bookRepo.ListCovers(ctx, []uuid.UUID{book.ID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cover: %w", err)
+	}
+
 	response := dto.GetBookResponse{
 		ID:            book.ID,
 		Title:         book.Title,
 		Author:        book.Author,
 		ISBN:          book.ISBN,
+		ISBN13:        book.ISBN13,
 		PublishedDate: book.PublishedDate,
-		Category:      category.Name,
+		Category:      category.GetName(),
 		Stock:         book.Stock,
+		Contributors:  contributors[book.ID],
+		Rating:        dto.BookRating{Average: book.RatingAverage, Count: book.RatingCount},
+	}
+	if cover, ok := covers[book.ID]; ok {
+		response.Cover = coverURLs(cover)
 	}
 
 	return &response, nil
 }
 
+// UpdateBook updates a book. Its contributors are replaced when
+// req.Contributors is set; otherwise a changed Author credits the book to that
+// single author, and an unchanged one leaves the contributors as they are.
 func (s *bookService) UpdateBook(ctx context.Context, req dto.UpdateBookRequest, bookID uuid.UUID) error {
-	req.ISBN = strings.ReplaceAll(req.ISBN, "-", "")
+	current, err := s.bookRepo.GetBookByID(ctx, bookID)
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		return ErrBookNotFound
+	}
 
-	if req.ISBN != "" {
-		existingBook, err := s.bookRepo.GetBookByISBN(ctx, req.ISBN)
-		if err != nil {
-			return err
-		}
-		if existingBook != nil {
-			return fmt.Errorf("%w: %s", ErrBookDuplicate, existingBook.Title)
-		}
+	isbn13, err := s.checkISBN(ctx, req.ISBN, bookID)
+	if err != nil {
+		return err
 	}
 
 	if req.CategoryID != uuid.Nil {
@@ -136,56 +199,275 @@ func (s *bookService) UpdateBook(ctx context.Context, req dto.UpdateBookRequest,
 		}
 	}
 
+	requested := req.Contributors
+	if requested == nil && strings.TrimSpace(req.Author) != current.Author {
+		if strings.TrimSpace(req.Author) == "" {
+			return ErrIncompleteBook
+		}
+		requested = []dto.ContributorRequest{{Name: req.Author}}
+	}
+	if requested != nil && len(requested) == 0 {
+		return ErrIncompleteBook
+	}
+	contributors, err := s.contributors(ctx, requested)
+	if err != nil {
+		return err
+	}
+
 	book := &models.Book{
 		Title:         req.Title,
-		Author:        req.Author,
-		ISBN:          req.ISBN,
+		Author:        current.Author,
+		ISBN:          isbn.Normalize(req.ISBN),
+		ISBN13:        isbn13,
 		PublishedDate: req.PublishedDate,
 		CategoryID:    req.CategoryID,
 		Stock:         req.Stock,
+		AddedBy:       current.AddedBy,
 		ID:            bookID,
+		Version:       current.Version,
+		Contributors:  contributors,
+	}
+
+	if err := s.bookRepo.UpdateBook(ctx, nil, book); err != nil {
+		if errors.Is(err, models.ErrDuplicateISBN) {
+			return ErrBookDuplicate
+		}
+		return err
+	}
+	return nil
+}
+
+// contributors checks the contributors of a request, resolving the IDs of
+// merged authors. A contributor names an existing author by AuthorID or
+// anyone by Name, and is an author unless a Role is given. nil stays nil.
+func (s *bookService) contributors(ctx context.Context, requested []dto.ContributorRequest) ([]models.BookContributor, error) {
+	if requested == nil {
+		return nil, nil
+	}
+
+	contributors := make([]models.BookContributor, 0, len(requested))
+	for _, c := range requested {
+		role := strings.ToLower(strings.TrimSpace(c.Role))
+		switch role {
+		case "":
+			role = models.RoleAuthor
+		case models.RoleAuthor, models.RoleEditor, models.RoleTranslator, models.RoleIllustrator:
+		default:
+			return nil, ErrInvalidRole
+		}
+
+		contributor := models.BookContributor{Name: strings.TrimSpace(c.Name), Role: role}
+		if c.AuthorID != uuid.Nil {
+			author, err := s.authorRepo.GetAuthor(ctx, c.AuthorID)
+			if err != nil {
+				return nil, err
+			}
+			if author == nil {
+				return nil, fmt.Errorf("%w: %s", ErrAuthorNotFound, c.AuthorID)
+			}
+			contributor.AuthorID = author.ID
+			contributor.Name = author.Name
+		}
+		if contributor.Name == "" {
+			return nil, ErrIncompleteBook
+		}
+		if len([]rune(contributor.Name)) > maxTextLength {
+			return nil, fmt.Errorf("%w: names can't be longer than %d characters", ErrIncompleteBook, maxTextLength)
+		}
+
+		contributors = append(contributors, contributor)
+	}
+	return contributors, nil
+}
+
+// LookupBook returns a draft of the book with an ISBN, filled in from the
+// metadata providers, for a librarian to complete and add.
+func (s *bookService) LookupBook(ctx context.Context, raw string) (*dto.LookupBookResponse, error) {
+	isbn13, err := isbn.ToISBN13(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidISBN, err)
+	}
+
+	record, err := s.metaRepo.LookupISBN(ctx, isbn13)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, ErrMetadataNotFound
+	}
+
+	return &dto.LookupBookResponse{
+		Draft: dto.AddBookRequest{
+			Title:         record.Title,
+			Author:        record.Author,
+			ISBN:          isbn13,
+			PublishedDate: record.PublishedDate,
+		},
+		Source: record.Source,
+	}, nil
+}
+
+// fillFromLookup fills the title, author and published date of req the
+// librarian left empty from the metadata providers. The book is added with
+// what it has when the lookup fails.
+func (s *bookService) fillFromLookup(ctx context.Context, req *dto.AddBookRequest, isbn13 string) {
+	record, err := s.metaRepo.LookupISBN(ctx, isbn13)
+	if err != nil {
+		log.Printf("[Service - AddBook] Error looking up ISBN %s: %v", isbn13, err)
+		return
+	}
+	if record == nil {
+		return
+	}
+
+	if strings.TrimSpace(req.Title) == "" {
+		req.Title = record.Title
+	}
+	if strings.TrimSpace(req.Author) == "" {
+		req.Author = record.Author
+	}
+	if req.PublishedDate == nil {
+		req.PublishedDate = record.PublishedDate
+	}
+}
+
+// checkISBN validates an ISBN and returns its ISBN-13 form, or "" when none
+// is given. A book other than bookID already holding the ISBN in either form
+// is reported as ErrBookDuplicate.
+func (s *bookService) checkISBN(ctx context.Context, raw string, bookID uuid.UUID) (string, error) {
+	if isbn.Normalize(raw) == "" {
+		return "", nil
+	}
+
+	isbn13, err := isbn.ToISBN13(raw)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidISBN, err)
+	}
+
+	existingBook, err := s.bookRepo.GetBookByISBN(ctx, isbn13)
+	if err != nil {
+		return "", err
+	}
+	if existingBook != nil && existingBook.ID != bookID {
+		return "", fmt.Errorf("%w: %s", ErrBookDuplicate, existingBook.Title)
 	}
 
-	return s.bookRepo.UpdateBook(ctx, nil, book)
+	return isbn13, nil
 }
 
 func (s *bookService) DeleteBook(ctx context.Context, bookID uuid.UUID) error {
 	return s.bookRepo.DeleteBook(ctx, bookID)
 }
 
-// ListBooks lists books and maps categories using cache
-func (s *bookService) ListBooks(ctx context.Context, title, author, category string, page string) ([]*dto.GetBookResponse, error) {
+// ListBooks lists a page of books with category names from the cached
+// categories, along with facet counts and the total over every matching
+// book. With IncludeSubcategories, filtering by a category also matches every
+// category below it. Pages follow each other through NextCursor.
+func (s *bookService) ListBooks(ctx context.Context, req dto.ListBooksRequest) (*dto.ListBooksResponse, error) {
+	switch req.Sort {
+	case "", models.SortTitle, models.SortTitleDesc, models.SortAuthor, models.SortAuthorDesc,
+		models.SortPublishedDate, models.SortPublishedDateDesc, models.SortRating, models.SortRatingDesc, models.SortNewest:
+	case models.SortRelevance:
+		// Text without words, like "!!", searches nothing either
+		if search.PrefixQuery(req.Q) == "" {
+			return nil, ErrSortNeedsQuery
+		}
+	default:
+		return nil, ErrInvalidSort
+	}
+
+	switch req.AuthorRole {
+	case "", models.RoleAuthor, models.RoleEditor, models.RoleTranslator, models.RoleIllustrator:
+	default:
+		return nil, ErrInvalidRole
+	}
 
-	pageNum, err := strconv.Atoi(page)
-	if err != nil || pageNum < 1 {
-		pageNum = 1
+	// A cursor only continues the order it was made for. Relevance ranks
+	// against the query, so its cursors only continue the same search.
+	sort := req.Sort
+	if sort == "" && search.PrefixQuery(req.Q) != "" {
+		sort = models.SortRelevance
+	}
+	var query string
+	if sort == models.SortRelevance {
+		query = search.PrefixQuery(req.Q)
+	}
+	after, err := pagination.DecodeQuery(req.Cursor, sort, query)
+	if err != nil {
+		return nil, err
 	}
 
-	limit := 10
-	offset := (pageNum - 1) * limit
+	category := req.Category
+	var categoryIDs []string
+	if category != "" {
+		// The ID of a merged category resolves to the one it was merged into
+		resolved, err := s.ctgRepo.GetCategoryByID(ctx, category)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get category: %w", err)
+		}
+		if resolved != nil {
+			category = resolved.Id
+		}
+
+		categoryIDs = []string{category}
+		if req.IncludeSubcategories {
+			categoryIDs, err = s.ctgRepo.GetDescendantIDs(ctx, category)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get subcategories: %w", err)
+			}
+		}
+	}
+
+	filter := models.BookFilter{
+		Title:         req.Title,
+		Author:        req.Author,
+		AuthorID:      req.AuthorID,
+		AuthorRole:    req.AuthorRole,
+		Query:         req.Q,
+		CategoryIDs:   categoryIDs,
+		Available:     req.Available,
+		PublishedFrom: req.PublishedFrom,
+		PublishedTo:   req.PublishedTo,
+		HasISBN:       req.HasISBN,
+		Sort:          req.Sort,
+		After:         after,
+		Limit:         pagination.Limit(req.PageSize, defaultPageSize, maxPageSize),
+	}
 
 	var (
 		books       []*models.Book
+		nextKeys    []string
+		facets      *models.BookFacets
 		categoryMap map[string]string
 	)
 
-	// Use WaitGroup to run ListBooks and GetCategories concurrently
+	// Use WaitGroup to run ListBooks, CountFacets and GetCategories concurrently
 	var wg sync.WaitGroup
-	wg.Add(2) // We are running 2 operations in parallel
+	wg.Add(3) // We are running 3 operations in parallel
 
 	// Channel to capture errors
-	errCh := make(chan error, 2)
+	errCh := make(chan error, 3)
 
 	// Goroutine to fetch books
 	go func() {
 		defer wg.Done()
 		var bookErr error
-		books, bookErr = s.bookRepo.ListBooks(ctx, title, author, category, limit, offset)
+		books, nextKeys, bookErr = s.bookRepo.ListBooks(ctx, filter)
 		if bookErr != nil {
 			errCh <- fmt.Errorf("failed to list books from repository: %w", bookErr)
 		}
 	}()
 
+	// Goroutine to count facets
+	go func() {
+		defer wg.Done()
+		var facetErr error
+		facets, facetErr = s.bookRepo.CountFacets(ctx, filter)
+		if facetErr != nil {
+			errCh <- fmt.Errorf("failed to count book facets: %w", facetErr)
+		}
+	}()
+
 	// Goroutine to fetch categories
 	go func() {
 		defer wg.Done()
@@ -204,7 +486,7 @@ func (s *bookService) ListBooks(ctx context.Context, title, author, category str
 		}
 	}()
 
-	// Wait for both goroutines to finish
+	// Wait for all goroutines to finish
 	wg.Wait()
 
 	// Close the error channel to avoid leaking
@@ -217,25 +499,94 @@ func (s *bookService) ListBooks(ctx context.Context, title, author, category str
 		}
 	}
 
-	// Prepare response slice after both goroutines have completed
-	var responses []*dto.GetBookResponse
-	for _, book := range books {
-		categoryName := "Unknown"
-		if name, ok := categoryMap[book.CategoryID.String()]; ok {
-			categoryName = name
+	bookIDs := make([]uuid.UUID, len(books))
+	for i, book := range books {
+		bookIDs[i] = book.ID
+	}
+	contributors, err := s.bookRepo.ListContributors(ctx, bookIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list contributors: %w", err)
+	}
+	covers, err := s.bookRepo.ListCovers(ctx, bookIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list covers: %w", err)
+	}
+
+	categoryName := func(id uuid.UUID) string {
+		if name, ok := categoryMap[id.String()]; ok {
+			return name
 		}
+		return "Unknown"
+	}
 
-		response := dto.GetBookResponse{
+	// Prepare response after all goroutines have completed
+	// The availability facet counts every matching book, split by availability
+	total := facets.Available + facets.Unavailable
+	if req.Available != nil {
+		total = facets.Unavailable
+		if *req.Available {
+			total = facets.Available
+		}
+	}
+
+	res := &dto.ListBooksResponse{
+		Books:      []*dto.GetBookResponse{},
+		NextCursor: pagination.EncodeQuery(sort, query, nextKeys),
+		Total:      total,
+		Facets: dto.BookFacets{
+			Categories: make([]dto.CategoryFacet, 0, len(facets.Categories)),
+			Availability: dto.AvailabilityFacet{
+				Available:   facets.Available,
+				Unavailable: facets.Unavailable,
+			},
+		},
+	}
+	for _, book := range books {
+		var coverURL *dto.CoverURLs
+		if cover, ok := covers[book.ID]; ok {
+			coverURL = coverURLs(cover)
+		}
+		res.Books = append(res.Books, &dto.GetBookResponse{
 			ID:            book.ID,
 			Title:         book.Title,
 			Author:        book.Author,
 			ISBN:          book.ISBN,
+			ISBN13:        book.ISBN13,
 			PublishedDate: book.PublishedDate,
-			Category:      categoryName,
+			Category:      categoryName(book.CategoryID),
 			Stock:         book.Stock,
-		}
-		responses = append(responses, &response)
+			Contributors:  contributors[book.ID],
+			Cover:         coverURL,
+			Rating:        dto.BookRating{Average: book.RatingAverage, Count: book.RatingCount},
+			Rank:          book.Rank,
+			Highlight:     book.Highlight,
+		})
+	}
+	for _, facet := range facets.Categories {
+		res.Facets.Categories = append(res.Facets.Categories, dto.CategoryFacet{
+			ID:    facet.CategoryID,
+			Name:  categoryName(facet.CategoryID),
+			Count: facet.Count,
+		})
+	}
+
+	return res, nil
+}
+
+// CountBooksByCategory returns how many books are in a category.
+func (s *bookService) CountBooksByCategory(ctx context.Context, categoryID uuid.UUID) (int64, error) {
+	return s.bookRepo.CountByCategory(ctx, categoryID)
+}
+
+// ReassignBooksCategory moves every book of fromID to toID.
+func (s *bookService) ReassignBooksCategory(ctx context.Context, fromID, toID uuid.UUID) (int64, error) {
+	category, err := s.ctgRepo.GetCategoryByID(ctx, toID.String())
+	if err != nil {
+		return 0, err
+	}
+	if category == nil {
+		return 0, ErrCategoryNotFound
 	}
 
-	return responses, nil
+	return s.bookRepo.ReassignCategory(ctx, fromID, toID)
 }
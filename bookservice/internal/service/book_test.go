@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/internal/dto"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/models"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/pagination"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/search"
+	pb "github.com/sir-shalahuddin/grpc-learn/bookservice/proto/categoryservice"
+)
+
+// MockBookRepository adalah implementasi mock dari BookRepository. Method
+// tanpa Func akan panic karena interface yang di-embed nil.
+type MockBookRepository struct {
+	BookRepository
+	GetBookByIDFunc      func(ctx context.Context, bookID uuid.UUID) (*models.Book, error)
+	AddBookFunc          func(ctx context.Context, book *models.Book) error
+	UpdateBookFunc       func(ctx context.Context, tx *sql.Tx, book *models.Book) error
+	GetBookByISBNFunc    func(ctx context.Context, isbn string) (*models.Book, error)
+	ListBooksFunc        func(ctx context.Context, filter models.BookFilter) ([]*models.Book, []string, error)
+	CountFacetsFunc      func(ctx context.Context, filter models.BookFilter) (*models.BookFacets, error)
+	ListContributorsFunc func(ctx context.Context, bookIDs []uuid.UUID) (map[uuid.UUID][]models.BookContributor, error)
+	ListCoversFunc       func(ctx context.Context, bookIDs []uuid.UUID) (map[uuid.UUID]models.BookCover, error)
+}
+
+func (m *MockBookRepository) GetBookByID(ctx context.Context, bookID uuid.UUID) (*models.Book, error) {
+	return m.GetBookByIDFunc(ctx, bookID)
+}
+
+func (m *MockBookRepository) AddBook(ctx context.Context, book *models.Book) error {
+	return m.AddBookFunc(ctx, book)
+}
+
+func (m *MockBookRepository) UpdateBook(ctx context.Context, tx *sql.Tx, book *models.Book) error {
+	return m.UpdateBookFunc(ctx, tx, book)
+}
+
+func (m *MockBookRepository) GetBookByISBN(ctx context.Context, isbn string) (*models.Book, error) {
+	return m.GetBookByISBNFunc(ctx, isbn)
+}
+
+func (m *MockBookRepository) ListBooks(ctx context.Context, filter models.BookFilter) ([]*models.Book, []string, error) {
+	return m.ListBooksFunc(ctx, filter)
+}
+
+func (m *MockBookRepository) CountFacets(ctx context.Context, filter models.BookFilter) (*models.BookFacets, error) {
+	return m.CountFacetsFunc(ctx, filter)
+}
+
+func (m *MockBookRepository) ListContributors(ctx context.Context, bookIDs []uuid.UUID) (map[uuid.UUID][]models.BookContributor, error) {
+	return m.ListContributorsFunc(ctx, bookIDs)
+}
+
+func (m *MockBookRepository) ListCovers(ctx context.Context, bookIDs []uuid.UUID) (map[uuid.UUID]models.BookCover, error) {
+	return m.ListCoversFunc(ctx, bookIDs)
+}
+
+// MockCategoryRepository adalah implementasi mock dari categoryRepository.
+type MockCategoryRepository struct {
+	GetCategoriesFunc    func(ctx context.Context) ([]*pb.CategoryResponse, error)
+	GetCategoryByIDFunc  func(ctx context.Context, id string) (*pb.CategoryResponse, error)
+	GetDescendantIDsFunc func(ctx context.Context, id string) ([]string, error)
+}
+
+func (m *MockCategoryRepository) GetCategories(ctx context.Context) ([]*pb.CategoryResponse, error) {
+	return m.GetCategoriesFunc(ctx)
+}
+
+func (m *MockCategoryRepository) GetCategoryByID(ctx context.Context, id string) (*pb.CategoryResponse, error) {
+	return m.GetCategoryByIDFunc(ctx, id)
+}
+
+func (m *MockCategoryRepository) GetDescendantIDs(ctx context.Context, id string) ([]string, error) {
+	return m.GetDescendantIDsFunc(ctx, id)
+}
+
+// listingRepositories mengembalikan repository untuk ListBooks yang
+// menjawab dengan books dan facets, dan mencatat filter terakhir.
+func listingRepositories(books []*models.Book, nextKeys []string, facets *models.BookFacets, filter *models.BookFilter) (*MockBookRepository, *MockCategoryRepository) {
+	bookRepo := &MockBookRepository{
+		ListBooksFunc: func(ctx context.Context, f models.BookFilter) ([]*models.Book, []string, error) {
+			*filter = f
+			return books, nextKeys, nil
+		},
+		CountFacetsFunc: func(ctx context.Context, f models.BookFilter) (*models.BookFacets, error) {
+			return facets, nil
+		},
+		ListContributorsFunc: func(ctx context.Context, bookIDs []uuid.UUID) (map[uuid.UUID][]models.BookContributor, error) {
+			return nil, nil
+		},
+		ListCoversFunc: func(ctx context.Context, bookIDs []uuid.UUID) (map[uuid.UUID]models.BookCover, error) {
+			return nil, nil
+		},
+	}
+	ctgRepo := &MockCategoryRepository{
+		GetCategoriesFunc: func(ctx context.Context) ([]*pb.CategoryResponse, error) {
+			return []*pb.CategoryResponse{{Id: fictionID.String(), Name: "Fiction"}}, nil
+		},
+		GetCategoryByIDFunc: func(ctx context.Context, id string) (*pb.CategoryResponse, error) {
+			return &pb.CategoryResponse{Id: id}, nil
+		},
+		GetDescendantIDsFunc: func(ctx context.Context, id string) ([]string, error) {
+			return []string{id}, nil
+		},
+	}
+	return bookRepo, ctgRepo
+}
+
+var fictionID = uuid.New()
+
+// Test ListBooks: Total mengikuti filter ketersediaan, facet menghitung
+// semua buku yang cocok dan kategori tanpa nama menjadi Unknown
+func TestListBooks_Facets(t *testing.T) {
+	yes, no := true, false
+	unknownID := uuid.New()
+	facets := &models.BookFacets{
+		Categories:  []models.CategoryFacet{{CategoryID: fictionID, Count: 7}, {CategoryID: unknownID, Count: 2}},
+		Available:   6,
+		Unavailable: 3,
+	}
+
+	tests := []struct {
+		name      string
+		available *bool
+		wantTotal int64
+	}{
+		{"any availability", nil, 9},
+		{"available only", &yes, 6},
+		{"unavailable only", &no, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var filter models.BookFilter
+			bookRepo, ctgRepo := listingRepositories(nil, nil, facets, &filter)
+			svc := NewBookService(bookRepo, ctgRepo, nil, nil)
+
+			res, err := svc.ListBooks(context.Background(), dto.ListBooksRequest{Available: tt.available})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if res.Total != tt.wantTotal {
+				t.Errorf("Total = %d, want %d", res.Total, tt.wantTotal)
+			}
+			if res.Facets.Availability.Available != 6 || res.Facets.Availability.Unavailable != 3 {
+				t.Errorf("availability facet = %+v, want 6 available and 3 unavailable", res.Facets.Availability)
+			}
+			want := []dto.CategoryFacet{{ID: fictionID, Name: "Fiction", Count: 7}, {ID: unknownID, Name: "Unknown", Count: 2}}
+			if len(res.Facets.Categories) != len(want) {
+				t.Fatalf("category facets = %+v, want %+v", res.Facets.Categories, want)
+			}
+			for i := range want {
+				if res.Facets.Categories[i] != want[i] {
+					t.Errorf("category facet %d = %+v, want %+v", i, res.Facets.Categories[i], want[i])
+				}
+			}
+		})
+	}
+}
+
+// Test ListBooks: Sort dan role yang tidak dikenal ditolak, relevance butuh
+// query dengan kata yang bisa dicari
+func TestListBooks_InvalidRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		req  dto.ListBooksRequest
+		want error
+	}{
+		{"unknown sort", dto.ListBooksRequest{Sort: "price"}, ErrInvalidSort},
+		{"relevance without query", dto.ListBooksRequest{Sort: models.SortRelevance}, ErrSortNeedsQuery},
+		{"relevance without words", dto.ListBooksRequest{Sort: models.SortRelevance, Q: "!!"}, ErrSortNeedsQuery},
+		{"unknown role", dto.ListBooksRequest{AuthorRole: "narrator"}, ErrInvalidRole},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewBookService(&MockBookRepository{}, &MockCategoryRepository{}, nil, nil)
+
+			if _, err := svc.ListBooks(context.Background(), tt.req); !errors.Is(err, tt.want) {
+				t.Errorf("ListBooks() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// Test ListBooks: Cursor relevance hanya meneruskan pencarian yang sama,
+// cursor urutan lain tetap berlaku saat query berubah
+func TestListBooks_CursorQuery(t *testing.T) {
+	dune := pagination.EncodeQuery(models.SortRelevance, "dune:*", []string{"0.5", "Dune", "1"})
+	byTitle := pagination.Encode(models.SortTitle, []string{"Dune", "1"})
+
+	tests := []struct {
+		name    string
+		req     dto.ListBooksRequest
+		wantErr error
+	}{
+		{"same search", dto.ListBooksRequest{Q: "dune", Cursor: dune}, nil},
+		{"same words", dto.ListBooksRequest{Q: " DUNE ", Sort: models.SortRelevance, Cursor: dune}, nil},
+		{"other search", dto.ListBooksRequest{Q: "emma", Cursor: dune}, pagination.ErrInvalidCursor},
+		{"longer search", dto.ListBooksRequest{Q: "dune messiah", Cursor: dune}, pagination.ErrInvalidCursor},
+		{"other sort", dto.ListBooksRequest{Q: "dune", Sort: models.SortTitle, Cursor: dune}, pagination.ErrInvalidCursor},
+		{"title order", dto.ListBooksRequest{Q: "emma", Sort: models.SortTitle, Cursor: byTitle}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var filter models.BookFilter
+			bookRepo, ctgRepo := listingRepositories(nil, []string{"0.4", "Dune Messiah", "2"}, &models.BookFacets{}, &filter)
+			svc := NewBookService(bookRepo, ctgRepo, nil, nil)
+
+			res, err := svc.ListBooks(context.Background(), tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ListBooks() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if len(filter.After) == 0 {
+				t.Error("expected the cursor keys to reach the repository")
+			}
+			// Cursor berikutnya terikat ke query yang sama
+			sort, query := tt.req.Sort, ""
+			if sort == "" || sort == models.SortRelevance {
+				sort, query = models.SortRelevance, search.PrefixQuery(tt.req.Q)
+			}
+			if _, err := pagination.DecodeQuery(res.NextCursor, sort, query); err != nil {
+				t.Errorf("next cursor does not continue the request: %v", err)
+			}
+		})
+	}
+}
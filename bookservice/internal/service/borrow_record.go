@@ -4,16 +4,33 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/sir-shalahuddin/grpc-learn/bookservice/internal/dto"
 	"github.com/sir-shalahuddin/grpc-learn/bookservice/models"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/csvsafe"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/pagination"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/proto/authservice"
 )
 
 var (
 	ErrBorrowingRecordNotFound = errors.New("borrowing record not found")
 	ErrBookUnavailable         = errors.New("failed to process due to 0 stock")
+	ErrActiveLoans             = errors.New("user still has borrowed books")
+	ErrInvalidRecordOrder      = errors.New("order must be asc or desc")
+	ErrInvalidRecordStatus     = errors.New("status must be borrowed, returned or overdue")
+	ErrInvalidRecordSort       = errors.New("sort must be borrowed_at or due_date")
+	ErrRecordUserRequired      = errors.New("user ID is required")
+)
+
+const (
+	defaultRecordPageSize = 20
+	maxRecordPageSize     = 100
 )
 
 type BorrowingRecordRepository interface {
@@ -21,7 +38,16 @@ type BorrowingRecordRepository interface {
 	GetBorrowingRecordByID(ctx context.Context, id uuid.UUID) (*models.BorrowingRecord, error)
 	UpdateBorrowingRecord(ctx context.Context, tx *sql.Tx, record *models.BorrowingRecord) error
 	DeleteBorrowingRecord(ctx context.Context, id uuid.UUID) error
-	ListBorrowingRecords(ctx context.Context, userID uuid.UUID, queries map[string]string) ([]models.BorrowingRecord, error)
+	ListBorrowingRecords(ctx context.Context, filter models.BorrowingRecordFilter) ([]models.BorrowingRecord, []string, error)
+	CountBorrowingRecords(ctx context.Context, filter models.BorrowingRecordFilter) (int64, error)
+	CountActiveBorrowingRecords(ctx context.Context, userID uuid.UUID) (int, error)
+	AnonymizeBorrowingRecords(ctx context.Context, userID uuid.UUID) (int64, error)
+	HasReturnedBook(ctx context.Context, userID, bookID uuid.UUID) (bool, error)
+}
+
+// PatronRepository looks up the users behind borrowing records, keyed by ID.
+type PatronRepository interface {
+	GetUsersByIDs(ctx context.Context, userIDs []string) (map[string]*authservice.User, error)
 }
 
 type TxRepository interface {
@@ -34,13 +60,17 @@ type borrowingRecordService struct {
 	repo     BorrowingRecordRepository
 	txRepo   TxRepository
 	bookRepo BookRepository
+	notifier AvailabilityNotifier
+	patrons  PatronRepository
 }
 
-func NewBorrowingRecordService(repo BorrowingRecordRepository, txRepo TxRepository, bookRepo BookRepository) *borrowingRecordService {
+func NewBorrowingRecordService(repo BorrowingRecordRepository, txRepo TxRepository, bookRepo BookRepository, notifier AvailabilityNotifier, patrons PatronRepository) *borrowingRecordService {
 	return &borrowingRecordService{
 		repo:     repo,
 		txRepo:   txRepo,
 		bookRepo: bookRepo,
+		notifier: notifier,
+		patrons:  patrons,
 	}
 }
 
@@ -119,19 +149,282 @@ func (s *borrowingRecordService) ReturnBook(ctx context.Context, bookID, recordI
 		return err
 	}
 
+	// Whoever waits for the book hears about it when it comes back in stock;
+	// while other copies are on the shelf nobody has anything new to hear
+	if book.Stock == 1 {
+		if _, err := s.notifier.NotifyAvailable(ctx, tx, book.ID); err != nil {
+			return err
+		}
+	}
+
 	return s.txRepo.Commit(tx)
 }
 
-func (s *borrowingRecordService) ListBorrowingRecords(ctx context.Context, queries map[string]string, userID uuid.UUID) ([]models.BorrowingRecord, error) {
+// ListBorrowingRecords lists a page of the user's borrowing records, with
+// their total when asked for. Pages follow each other through NextCursor.
+func (s *borrowingRecordService) ListBorrowingRecords(ctx context.Context, req dto.ListBorrowingRecordsRequest, userID uuid.UUID) (*dto.ListBorrowingRecordsResponse, error) {
+	if userID == uuid.Nil {
+		return nil, ErrRecordUserRequired
+	}
+
+	order := strings.ToLower(req.Order)
+	if order != "" && order != "asc" && order != "desc" {
+		return nil, ErrInvalidRecordOrder
+	}
+	if order == "" {
+		order = "desc"
+	}
 
-	record, err := s.repo.ListBorrowingRecords(ctx, userID, queries)
+	after, err := pagination.Decode(req.Cursor, order)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(record) == 0 {
-		return nil, nil
+	filter := models.BorrowingRecordFilter{
+		UserID: userID,
+		Title:  req.Title,
+		Status: req.Status,
+		Order:  order,
+		After:  after,
+		Limit:  pagination.Limit(req.PageSize, defaultRecordPageSize, maxRecordPageSize),
+	}
+
+	records, nextKeys, err := s.repo.ListBorrowingRecords(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &dto.ListBorrowingRecordsResponse{
+		Records:    records,
+		NextCursor: pagination.Encode(order, nextKeys),
+	}
+	if req.IncludeTotal {
+		total, err := s.repo.CountBorrowingRecords(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		page.Total = &total
+	}
+
+	return page, nil
+}
+
+// SearchRecords lists a page of the borrowing records of every patron
+// matching req for staff, with the name and email of each patron. Pages
+// follow each other through NextCursor.
+func (s *borrowingRecordService) SearchRecords(ctx context.Context, req dto.SearchRecordsRequest) (*dto.ListBorrowingRecordsResponse, error) {
+	filter, label, err := searchFilter(req)
+	if err != nil {
+		return nil, err
+	}
+	filter.Limit = pagination.Limit(req.PageSize, defaultRecordPageSize, maxRecordPageSize)
+
+	records, nextKeys, err := s.repo.ListBorrowingRecords(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	s.addPatrons(ctx, records)
+
+	page := &dto.ListBorrowingRecordsResponse{
+		Records:    records,
+		NextCursor: pagination.Encode(label, nextKeys),
+	}
+	if req.IncludeTotal {
+		total, err := s.repo.CountBorrowingRecords(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		page.Total = &total
+	}
+
+	return page, nil
+}
+
+// CheckSearch reports whether req is a valid staff search, before its
+// records are streamed by ExportRecords.
+func (s *borrowingRecordService) CheckSearch(req dto.SearchRecordsRequest) error {
+	_, _, err := searchFilter(req)
+	return err
+}
+
+// ExportRecords writes every borrowing record matching req to w as CSV,
+// reading and enriching them a batch at a time. Titles and names that would
+// run as spreadsheet formulas are escaped. The cursor of req is ignored.
+func (s *borrowingRecordService) ExportRecords(ctx context.Context, req dto.SearchRecordsRequest, w io.Writer) error {
+	req.Cursor = ""
+	filter, _, err := searchFilter(req)
+	if err != nil {
+		return err
+	}
+	filter.Limit = exportBatchSize
+
+	writer := csvsafe.NewWriter(w)
+	err = writer.Write([]string{"id", "book_id", "title", "isbn", "user_id", "patron_name", "patron_email", "borrowed_at", "due_date", "returned_at"})
+	if err != nil {
+		return err
+	}
+
+	for {
+		records, nextKeys, err := s.repo.ListBorrowingRecords(ctx, filter)
+		if err != nil {
+			return fmt.Errorf("failed to list borrowing records: %w", err)
+		}
+		s.addPatrons(ctx, records)
+
+		for _, record := range records {
+			row := []string{record.ID.String(), record.Book.ID.String(), record.Book.Title, record.Book.ISBN, "", "", "",
+				formatTime(record.BorrowedAt), formatTime(record.DueDate), formatTime(record.ReturnedAt)}
+			if record.UserID != uuid.Nil {
+				row[4] = record.UserID.String()
+			}
+			if record.Patron != nil {
+				row[5], row[6] = record.Patron.Name, record.Patron.Email
+			}
+			if err := writer.Write(row); err != nil {
+				return fmt.Errorf("failed to write record %s: %w", record.ID, err)
+			}
+		}
+
+		if nextKeys == nil {
+			break
+		}
+		filter.After = nextKeys
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// OverdueSearch turns a staff search into one for the loans past their due
+// date, longest overdue first unless req sorts otherwise.
+func OverdueSearch(req dto.SearchRecordsRequest) dto.SearchRecordsRequest {
+	req.Status = models.RecordOverdue
+	req.ReturnedFrom, req.ReturnedTo = nil, nil
+	if req.Sort == "" {
+		req.Sort = models.RecordSortDue
+	}
+	if req.Order == "" {
+		req.Order = "asc"
+	}
+	return req
+}
+
+// searchFilter checks a staff search and turns it into a filter, along with
+// the label of its cursors. Without a patron it covers every patron. Date
+// ranges include their last day.
+func searchFilter(req dto.SearchRecordsRequest) (models.BorrowingRecordFilter, string, error) {
+	switch req.Status {
+	case "", models.RecordBorrowed, models.RecordReturned, models.RecordOverdue:
+	default:
+		return models.BorrowingRecordFilter{}, "", ErrInvalidRecordStatus
+	}
+
+	sort := strings.ToLower(req.Sort)
+	if sort == "" {
+		sort = models.RecordSortBorrowed
+	}
+	if sort != models.RecordSortBorrowed && sort != models.RecordSortDue {
+		return models.BorrowingRecordFilter{}, "", ErrInvalidRecordSort
+	}
+
+	order := strings.ToLower(req.Order)
+	if order != "" && order != "asc" && order != "desc" {
+		return models.BorrowingRecordFilter{}, "", ErrInvalidRecordOrder
+	}
+	if order == "" {
+		order = "desc"
+	}
+
+	label := sort + " " + order
+	after, err := pagination.Decode(req.Cursor, label)
+	if err != nil {
+		return models.BorrowingRecordFilter{}, "", err
+	}
+
+	return models.BorrowingRecordFilter{
+		UserID:       req.UserID,
+		AllUsers:     req.UserID == uuid.Nil,
+		BookID:       req.BookID,
+		Title:        req.Title,
+		Status:       req.Status,
+		BorrowedFrom: req.BorrowedFrom,
+		BorrowedTo:   nextDay(req.BorrowedTo),
+		DueFrom:      req.DueFrom,
+		DueTo:        nextDay(req.DueTo),
+		ReturnedFrom: req.ReturnedFrom,
+		ReturnedTo:   nextDay(req.ReturnedTo),
+		Sort:         sort,
+		Order:        order,
+		After:        after,
+	}, label, nil
+}
+
+// addPatrons sets the patron of each record in one batched lookup. Records
+// are left without when there is no patron repository or userservice can't
+// be reached.
+func (s *borrowingRecordService) addPatrons(ctx context.Context, records []models.BorrowingRecord) {
+	if s.patrons == nil {
+		return
+	}
+
+	ids := make([]string, 0, len(records))
+	for _, record := range records {
+		if record.UserID != uuid.Nil {
+			ids = append(ids, record.UserID.String())
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	users, err := s.patrons.GetUsersByIDs(ctx, ids)
+	if err != nil {
+		log.Printf("[Service - BorrowingRecords] Error looking up patrons: %v", err)
+		return
+	}
+
+	for i := range records {
+		if user, ok := users[records[i].UserID.String()]; ok {
+			records[i].Patron = &models.Patron{ID: records[i].UserID, Name: user.GetName(), Email: user.GetEmail()}
+		}
+	}
+}
+
+func nextDay(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	next := t.AddDate(0, 0, 1)
+	return &next
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// ExportUserRecords returns every borrowing record of the user, newest first.
+func (s *borrowingRecordService) ExportUserRecords(ctx context.Context, userID uuid.UUID) ([]models.BorrowingRecord, error) {
+	if userID == uuid.Nil {
+		return nil, ErrRecordUserRequired
+	}
+
+	records, _, err := s.repo.ListBorrowingRecords(ctx, models.BorrowingRecordFilter{UserID: userID})
+	return records, err
+}
+
+// EraseUserRecords anonymizes the user's borrowing history. Users with books
+// still out must return them first so the loans can be followed up.
+func (s *borrowingRecordService) EraseUserRecords(ctx context.Context, userID uuid.UUID) (int64, error) {
+	active, err := s.repo.CountActiveBorrowingRecords(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	if active > 0 {
+		return 0, ErrActiveLoans
 	}
 
-	return record, nil
+	return s.repo.AnonymizeBorrowingRecords(ctx, userID)
 }
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/internal/dto"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/models"
+)
+
+func day(s string) *time.Time {
+	t, _ := time.Parse("2006-01-02", s)
+	return &t
+}
+
+// Test searchFilter: Batas atas rentang tanggal mencakup hari terakhirnya
+func TestSearchFilter_InclusiveUpperBounds(t *testing.T) {
+	req := dto.SearchRecordsRequest{
+		BorrowedFrom: day("2024-03-01"),
+		BorrowedTo:   day("2024-03-31"),
+		DueTo:        day("2024-04-15"),
+		ReturnedTo:   day("2024-12-31"),
+	}
+
+	filter, label, err := searchFilter(req)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !filter.BorrowedFrom.Equal(*day("2024-03-01")) {
+		t.Errorf("BorrowedFrom = %v, want 2024-03-01", filter.BorrowedFrom)
+	}
+	if !filter.BorrowedTo.Equal(*day("2024-04-01")) {
+		t.Errorf("BorrowedTo = %v, want 2024-04-01", filter.BorrowedTo)
+	}
+	if !filter.DueTo.Equal(*day("2024-04-16")) {
+		t.Errorf("DueTo = %v, want 2024-04-16", filter.DueTo)
+	}
+	if !filter.ReturnedTo.Equal(*day("2025-01-01")) {
+		t.Errorf("ReturnedTo = %v, want 2025-01-01", filter.ReturnedTo)
+	}
+	if filter.DueFrom != nil || filter.ReturnedFrom != nil {
+		t.Errorf("expected missing bounds to stay nil, got %v and %v", filter.DueFrom, filter.ReturnedFrom)
+	}
+	if label != "borrowed_at desc" || filter.Sort != models.RecordSortBorrowed || filter.Order != "desc" {
+		t.Errorf("expected borrowed_at desc by default, got %q (%s %s)", label, filter.Sort, filter.Order)
+	}
+}
+
+// Test searchFilter: Tanpa patron pencarian staf mencakup semua patron
+func TestSearchFilter_AllUsers(t *testing.T) {
+	filter, _, err := searchFilter(dto.SearchRecordsRequest{})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !filter.AllUsers {
+		t.Error("expected a search without a patron to cover every patron")
+	}
+
+	userID := uuid.New()
+	filter, _, err = searchFilter(dto.SearchRecordsRequest{UserID: userID})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if filter.AllUsers || filter.UserID != userID {
+		t.Errorf("expected only the records of %s, got %+v", userID, filter)
+	}
+}
+
+// Test searchFilter: Status, sort dan order yang tidak dikenal ditolak
+func TestSearchFilter_Invalid(t *testing.T) {
+	tests := []struct {
+		req  dto.SearchRecordsRequest
+		want error
+	}{
+		{dto.SearchRecordsRequest{Status: "lost"}, ErrInvalidRecordStatus},
+		{dto.SearchRecordsRequest{Sort: "title"}, ErrInvalidRecordSort},
+		{dto.SearchRecordsRequest{Order: "up"}, ErrInvalidRecordOrder},
+	}
+
+	for _, tt := range tests {
+		if _, _, err := searchFilter(tt.req); !errors.Is(err, tt.want) {
+			t.Errorf("searchFilter(%+v) = %v, want %v", tt.req, err, tt.want)
+		}
+	}
+}
+
+// Test OverdueSearch: Yang paling lama terlambat tampil pertama secara default
+func TestOverdueSearch_DefaultSort(t *testing.T) {
+	filter, label, err := searchFilter(OverdueSearch(dto.SearchRecordsRequest{
+		ReturnedFrom: day("2024-01-01"),
+		ReturnedTo:   day("2024-01-31"),
+	}))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if filter.Status != models.RecordOverdue {
+		t.Errorf("Status = %q, want %q", filter.Status, models.RecordOverdue)
+	}
+	if label != "due_date asc" || filter.Sort != models.RecordSortDue || filter.Order != "asc" {
+		t.Errorf("expected due_date asc, got %q (%s %s)", label, filter.Sort, filter.Order)
+	}
+	if filter.ReturnedFrom != nil || filter.ReturnedTo != nil {
+		t.Errorf("expected the returned range to be dropped, got %v and %v", filter.ReturnedFrom, filter.ReturnedTo)
+	}
+
+	req := OverdueSearch(dto.SearchRecordsRequest{Sort: models.RecordSortBorrowed, Order: "desc"})
+	if req.Sort != models.RecordSortBorrowed || req.Order != "desc" {
+		t.Errorf("expected the asked sort to be kept, got %s %s", req.Sort, req.Order)
+	}
+}
+
+// Test ListBorrowingRecords dan ExportUserRecords: Tanpa user ID ditolak
+func TestUserRecords_RequireUser(t *testing.T) {
+	svc := NewBorrowingRecordService(nil, nil, nil, nil, nil)
+
+	if _, err := svc.ListBorrowingRecords(context.Background(), dto.ListBorrowingRecordsRequest{}, uuid.Nil); !errors.Is(err, ErrRecordUserRequired) {
+		t.Errorf("ListBorrowingRecords: expected ErrRecordUserRequired, got %v", err)
+	}
+	if _, err := svc.ExportUserRecords(context.Background(), uuid.Nil); !errors.Is(err, ErrRecordUserRequired) {
+		t.Errorf("ExportUserRecords: expected ErrRecordUserRequired, got %v", err)
+	}
+}
+
+// Test addPatrons: Tanpa PatronRepository record dibiarkan tanpa patron
+func TestAddPatrons_NoRepository(t *testing.T) {
+	svc := NewBorrowingRecordService(nil, nil, nil, nil, nil)
+	records := []models.BorrowingRecord{{ID: uuid.New(), UserID: uuid.New()}}
+
+	svc.addPatrons(context.Background(), records)
+
+	if records[0].Patron != nil {
+		t.Errorf("expected no patron, got %+v", records[0].Patron)
+	}
+}
@@ -0,0 +1,248 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // registers the GIF decoder
+	"image/jpeg"
+	_ "image/png" // registers the PNG decoder
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/internal/dto"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/models"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/blob"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/imaging"
+)
+
+var (
+	ErrCoverNotFound        = errors.New("cover not found")
+	ErrCoverTooLarge        = errors.New("cover file is too large")
+	ErrUnsupportedCoverType = errors.New("cover must be a JPEG, PNG or GIF image")
+	ErrInvalidCover         = errors.New("cover image can't be read")
+	ErrInvalidCoverSize     = errors.New("size must be small, medium, large or original")
+)
+
+// Cover sizes. Thumbnails are scaled down to the width given here and stored
+// as JPEG; the original is kept as uploaded.
+const (
+	CoverSmall    = "small"
+	CoverMedium   = "medium"
+	CoverLarge    = "large"
+	CoverOriginal = "original"
+)
+
+var coverWidths = map[string]int{
+	CoverSmall:  120,
+	CoverMedium: 300,
+	CoverLarge:  600,
+}
+
+// coverExtensions maps the image types accepted as covers to the extension
+// of their stored original.
+var coverExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+const (
+	// maxCoverPixels keeps a small file declaring a huge image from being
+	// decoded into gigabytes of memory
+	maxCoverPixels = 40_000_000
+	jpegQuality    = 85
+)
+
+type CoverRepository interface {
+	GetCover(ctx context.Context, bookID uuid.UUID) (*models.BookCover, error)
+	SaveCover(ctx context.Context, cover *models.BookCover) error
+	DeleteCover(ctx context.Context, bookID uuid.UUID) error
+}
+
+type coverService struct {
+	coverRepo CoverRepository
+	bookRepo  BookRepository
+	store     blob.Store
+	maxSize   int64
+}
+
+func NewCoverService(coverRepo CoverRepository, bookRepo BookRepository, store blob.Store, maxSize int64) *coverService {
+	return &coverService{
+		coverRepo: coverRepo,
+		bookRepo:  bookRepo,
+		store:     store,
+		maxSize:   maxSize,
+	}
+}
+
+// UploadCover stores an image as the cover of a book along with its
+// thumbnails, replacing any previous cover, and returns the new cover URLs.
+func (s *coverService) UploadCover(ctx context.Context, bookID uuid.UUID, data []byte) (*dto.CoverURLs, error) {
+	book, err := s.bookRepo.GetBookByID(ctx, bookID)
+	if err != nil {
+		return nil, err
+	}
+	if book == nil {
+		return nil, ErrBookNotFound
+	}
+
+	if int64(len(data)) > s.maxSize {
+		return nil, ErrCoverTooLarge
+	}
+	// Go by the bytes rather than the type the client claims
+	contentType := http.DetectContentType(data)
+	ext, ok := coverExtensions[contentType]
+	if !ok {
+		return nil, ErrUnsupportedCoverType
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCover, err)
+	}
+	if config.Width < 1 || config.Height < 1 || config.Width*config.Height > maxCoverPixels {
+		return nil, fmt.Errorf("%w: %dx%d pixels is too large", ErrInvalidCover, config.Width, config.Height)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCover, err)
+	}
+
+	previous, err := s.coverRepo.GetCover(ctx, bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	// JPEG has no transparency, so thumbnails go on white like a page
+	flat := imaging.Flatten(img, color.White)
+	for size, width := range coverWidths {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, imaging.Fit(flat, width), &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return nil, fmt.Errorf("failed to encode %s cover: %w", size, err)
+		}
+		if err := s.store.Put(ctx, coverKey(bookID, size, ""), buf.Bytes(), "image/jpeg"); err != nil {
+			return nil, fmt.Errorf("failed to store %s cover: %w", size, err)
+		}
+	}
+	if err := s.store.Put(ctx, coverKey(bookID, CoverOriginal, contentType), data, contentType); err != nil {
+		return nil, fmt.Errorf("failed to store cover: %w", err)
+	}
+
+	cover := &models.BookCover{
+		BookID:      bookID,
+		ContentType: contentType,
+		Width:       config.Width,
+		Height:      config.Height,
+		UpdatedAt:   time.Now().UTC().Truncate(time.Millisecond),
+	}
+	if err := s.coverRepo.SaveCover(ctx, cover); err != nil {
+		return nil, err
+	}
+
+	// An original of another type was stored under another name
+	if previous != nil && coverExtensions[previous.ContentType] != ext {
+		if err := s.store.Delete(ctx, coverKey(bookID, CoverOriginal, previous.ContentType)); err != nil {
+			log.Printf("[Service - UploadCover] Error deleting previous cover of %s: %v", bookID, err)
+		}
+	}
+
+	return coverURLs(*cover), nil
+}
+
+// GetCover returns the cover of a book, to check its version before opening
+// one of its sizes with OpenCover. An empty size skips checking it.
+func (s *coverService) GetCover(ctx context.Context, bookID uuid.UUID, size string) (*models.BookCover, error) {
+	if _, ok := coverWidths[size]; !ok && size != CoverOriginal && size != "" {
+		return nil, ErrInvalidCoverSize
+	}
+
+	cover, err := s.coverRepo.GetCover(ctx, bookID)
+	if err != nil {
+		return nil, err
+	}
+	if cover == nil {
+		return nil, ErrCoverNotFound
+	}
+	return cover, nil
+}
+
+// OpenCover opens a size of a cover. The caller closes it.
+func (s *coverService) OpenCover(ctx context.Context, cover *models.BookCover, size string) (io.ReadCloser, *blob.Info, error) {
+	if _, ok := coverWidths[size]; !ok && size != CoverOriginal {
+		return nil, nil, ErrInvalidCoverSize
+	}
+
+	r, info, err := s.store.Get(ctx, coverKey(cover.BookID, size, cover.ContentType))
+	if errors.Is(err, blob.ErrNotFound) {
+		return nil, nil, ErrCoverNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if size == CoverOriginal {
+		info.ContentType = cover.ContentType
+	} else {
+		info.ContentType = "image/jpeg"
+	}
+	return r, info, nil
+}
+
+// DeleteCover removes the cover of a book and its thumbnails.
+func (s *coverService) DeleteCover(ctx context.Context, bookID uuid.UUID) error {
+	cover, err := s.GetCover(ctx, bookID, "")
+	if err != nil {
+		return err
+	}
+
+	if err := s.coverRepo.DeleteCover(ctx, bookID); err != nil {
+		return err
+	}
+
+	keys := []string{coverKey(bookID, CoverOriginal, cover.ContentType)}
+	for size := range coverWidths {
+		keys = append(keys, coverKey(bookID, size, ""))
+	}
+	for _, key := range keys {
+		if err := s.store.Delete(ctx, key); err != nil {
+			log.Printf("[Service - DeleteCover] Error deleting %s: %v", key, err)
+		}
+	}
+	return nil
+}
+
+// coverKey is where a size of the cover of a book is stored. The original
+// keeps the extension of its type.
+func coverKey(bookID uuid.UUID, size, contentType string) string {
+	if size == CoverOriginal {
+		return "covers/" + bookID.String() + "/original" + coverExtensions[contentType]
+	}
+	return "covers/" + bookID.String() + "/" + size + ".jpg"
+}
+
+// coverURLs returns the URLs of every size of a cover. They carry its
+// version, so they change with every upload and can be cached for good.
+func coverURLs(cover models.BookCover) *dto.CoverURLs {
+	url := func(size string) string {
+		return "/books/" + cover.BookID.String() + "/cover?size=" + size + "&v=" + CoverVersion(cover)
+	}
+	return &dto.CoverURLs{
+		Small:    url(CoverSmall),
+		Medium:   url(CoverMedium),
+		Large:    url(CoverLarge),
+		Original: url(CoverOriginal),
+	}
+}
+
+// CoverVersion identifies an upload of a cover.
+func CoverVersion(cover models.BookCover) string {
+	return strconv.FormatInt(cover.UpdatedAt.UnixMilli(), 36)
+}
@@ -0,0 +1,392 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/internal/dto"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/models"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/catalog"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/isbn"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/pagination"
+)
+
+var (
+	ErrImportNotFound      = errors.New("import not found")
+	ErrInvalidImportFile   = errors.New("invalid import file")
+	ErrInvalidImportStatus = errors.New("status must be created, valid, invalid or duplicate")
+	ErrImportFileTooLarge  = errors.New("import file is too large")
+)
+
+const (
+	defaultImportRowPageSize = 100
+	maxImportRowPageSize     = 1000
+	exportBatchSize          = 500
+	// maxTextLength is the length of the title and author columns
+	maxTextLength = 255
+	// staleImportAfter is how long an import may go without progress before
+	// it is taken to be abandoned by an instance that stopped running it
+	staleImportAfter = 10 * time.Minute
+)
+
+type ImportRepository interface {
+	CreateImport(ctx context.Context, job *models.BookImport) error
+	GetImport(ctx context.Context, id uuid.UUID) (*models.BookImport, error)
+	SetImportStatus(ctx context.Context, id uuid.UUID, status, reason string) error
+	AddImportRow(ctx context.Context, importID uuid.UUID, row *models.BookImportRow) error
+	ListImportRows(ctx context.Context, importID uuid.UUID, filter models.BookImportRowFilter) ([]models.BookImportRow, []string, error)
+	FailStaleImports(ctx context.Context, before time.Time, reason string) (int64, error)
+}
+
+type importService struct {
+	importRepo ImportRepository
+	bookRepo   BookRepository
+	ctgRepo    categoryRepository
+}
+
+func NewImportService(importRepo ImportRepository, bookRepo BookRepository, ctgRepo categoryRepository) *importService {
+	return &importService{
+		importRepo: importRepo,
+		bookRepo:   bookRepo,
+		ctgRepo:    ctgRepo,
+	}
+}
+
+// StartImport starts importing the books of a catalogue file in the
+// background and returns the pending import to poll. The format is taken
+// from the file name unless given. A dry run checks every row without adding
+// any book.
+func (s *importService) StartImport(ctx context.Context, req dto.StartImportRequest, userID uuid.UUID) (*models.BookImport, error) {
+	format := strings.ToLower(req.Format)
+	if format == "" {
+		format = catalog.FormatOf(req.Filename)
+	}
+
+	reader, err := catalog.NewReader(format, bytes.NewReader(req.Data))
+	if err != nil {
+		if errors.Is(err, catalog.ErrUnknownFormat) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImportFile, err)
+	}
+
+	job := &models.BookImport{
+		Format:    format,
+		DryRun:    req.DryRun,
+		CreatedBy: userID,
+	}
+	if err := s.importRepo.CreateImport(ctx, job); err != nil {
+		return nil, err
+	}
+
+	// The import outlives the request that started it
+	go s.run(context.Background(), job, reader)
+
+	return job, nil
+}
+
+// GetImport returns an import with its progress so far.
+func (s *importService) GetImport(ctx context.Context, id uuid.UUID) (*models.BookImport, error) {
+	job, err := s.importRepo.GetImport(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil {
+		return nil, ErrImportNotFound
+	}
+	return job, nil
+}
+
+// ListImportRows lists a page of the rows of an import processed so far, in
+// file order. Pages follow each other through NextCursor.
+func (s *importService) ListImportRows(ctx context.Context, req dto.ListImportRowsRequest, importID uuid.UUID) (*dto.ListImportRowsResponse, error) {
+	switch req.Status {
+	case "", models.ImportRowCreated, models.ImportRowValid, models.ImportRowInvalid, models.ImportRowDuplicate:
+	default:
+		return nil, ErrInvalidImportStatus
+	}
+
+	after, err := pagination.Decode(req.Cursor, "row")
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.GetImport(ctx, importID); err != nil {
+		return nil, err
+	}
+
+	rows, nextKeys, err := s.importRepo.ListImportRows(ctx, importID, models.BookImportRowFilter{
+		Status: req.Status,
+		After:  after,
+		Limit:  pagination.Limit(req.PageSize, defaultImportRowPageSize, maxImportRowPageSize),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.ListImportRowsResponse{
+		Rows:       rows,
+		NextCursor: pagination.Encode("row", nextKeys),
+	}, nil
+}
+
+// Schedule marks imports abandoned by a stopped instance as failed, right
+// away and then every interval, until ctx is done.
+func (s *importService) Schedule(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		failed, err := s.importRepo.FailStaleImports(ctx, time.Now().Add(-staleImportAfter), "import stopped making progress")
+		if err != nil {
+			log.Printf("[Service - Import] Error failing stale imports: %v", err)
+		} else if failed > 0 {
+			log.Printf("[Service - Import] Marked %d stale imports as failed", failed)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// run reads the rows of an import one by one, recording the outcome of each
+// as it goes so progress can be followed while it runs.
+func (s *importService) run(ctx context.Context, job *models.BookImport, reader catalog.Reader) {
+	fail := func(reason string) {
+		log.Printf("[Service - Import] Import %s failed: %s", job.ID, reason)
+		if err := s.importRepo.SetImportStatus(ctx, job.ID, models.ImportFailed, reason); err != nil {
+			log.Printf("[Service - Import] Error marking import %s failed: %v", job.ID, err)
+		}
+	}
+
+	// A bad file must not take the service down; the import fails instead
+	defer func() {
+		if r := recover(); r != nil {
+			fail(fmt.Sprintf("import stopped unexpectedly: %v", r))
+		}
+	}()
+
+	if err := s.importRepo.SetImportStatus(ctx, job.ID, models.ImportRunning, ""); err != nil {
+		log.Printf("[Service - Import] Error starting import %s: %v", job.ID, err)
+		return
+	}
+
+	categories, err := s.categoryIDs(ctx)
+	if err != nil {
+		fail(err.Error())
+		return
+	}
+
+	// ISBN-13s seen so far, to catch a book listed twice in the file
+	seen := make(map[string]int)
+
+	for number := 1; ; number++ {
+		entry, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		var row *models.BookImportRow
+		switch {
+		case errors.Is(err, catalog.ErrInvalidEntry):
+			row = &models.BookImportRow{Row: number, Status: models.ImportRowInvalid, Message: err.Error()}
+		case err != nil:
+			fail(fmt.Sprintf("failed to read row %d: %v", number, err))
+			return
+		default:
+			row = s.importRow(ctx, job, entry, number, categories, seen)
+		}
+
+		if err := s.importRepo.AddImportRow(ctx, job.ID, row); err != nil {
+			fail(err.Error())
+			return
+		}
+	}
+
+	if err := s.importRepo.SetImportStatus(ctx, job.ID, models.ImportCompleted, ""); err != nil {
+		log.Printf("[Service - Import] Error completing import %s: %v", job.ID, err)
+	}
+}
+
+// importRow checks one entry the way AddBook would and, unless the import is
+// a dry run, adds it.
+func (s *importService) importRow(ctx context.Context, job *models.BookImport, entry *catalog.Entry, number int, categories map[string]uuid.UUID, seen map[string]int) *models.BookImportRow {
+	row := &models.BookImportRow{
+		Row:   number,
+		Title: truncate(entry.Title, maxTextLength),
+		ISBN:  truncate(entry.ISBN, 32),
+	}
+	invalid := func(message string) *models.BookImportRow {
+		row.Status = models.ImportRowInvalid
+		row.Message = message
+		return row
+	}
+
+	title, author := strings.TrimSpace(entry.Title), strings.TrimSpace(entry.Author)
+	switch {
+	case title == "" || author == "":
+		return invalid(ErrIncompleteBook.Error())
+	case utf8.RuneCountInString(title) > maxTextLength || utf8.RuneCountInString(author) > maxTextLength:
+		return invalid(fmt.Sprintf("title and author can't be longer than %d characters", maxTextLength))
+	case entry.Stock < 0:
+		return invalid("stock can't be negative")
+	}
+
+	var categoryID uuid.UUID
+	if category := strings.TrimSpace(entry.Category); category != "" {
+		id, ok := categories[strings.ToLower(category)]
+		if !ok {
+			return invalid(fmt.Sprintf("%s: %s", ErrCategoryNotFound, category))
+		}
+		categoryID = id
+	}
+
+	var isbn13 string
+	if isbn.Normalize(entry.ISBN) != "" {
+		var err error
+		if isbn13, err = isbn.ToISBN13(entry.ISBN); err != nil {
+			return invalid(fmt.Sprintf("%s: %v", ErrInvalidISBN, err))
+		}
+
+		if first, ok := seen[isbn13]; ok {
+			row.Status = models.ImportRowDuplicate
+			row.Message = fmt.Sprintf("same ISBN as row %d", first)
+			return row
+		}
+		seen[isbn13] = number
+
+		existingBook, err := s.bookRepo.GetBookByISBN(ctx, isbn13)
+		if err != nil {
+			log.Printf("[Service - Import] Error checking ISBN %s: %v", isbn13, err)
+			return invalid("failed to check ISBN")
+		}
+		if existingBook != nil {
+			row.Status = models.ImportRowDuplicate
+			row.Message = fmt.Sprintf("%s: %s", ErrBookDuplicate, existingBook.Title)
+			return row
+		}
+	}
+
+	if job.DryRun {
+		row.Status = models.ImportRowValid
+		return row
+	}
+
+	book := &models.Book{
+		Title:         title,
+		Author:        author,
+		ISBN:          isbn.Normalize(entry.ISBN),
+		ISBN13:        isbn13,
+		PublishedDate: entry.PublishedDate,
+		CategoryID:    categoryID,
+		Stock:         entry.Stock,
+		AddedBy:       job.CreatedBy,
+	}
+	if err := s.bookRepo.AddBook(ctx, book); err != nil {
+		if errors.Is(err, models.ErrDuplicateISBN) {
+			row.Status = models.ImportRowDuplicate
+			row.Message = ErrBookDuplicate.Error()
+			return row
+		}
+		log.Printf("[Service - Import] Error adding row %d of import %s: %v", number, job.ID, err)
+		return invalid("failed to add book")
+	}
+
+	row.Status = models.ImportRowCreated
+	row.BookID = &book.ID
+	return row
+}
+
+// categoryIDs maps the lower case name, slug and ID of every category to its
+// ID, so files can name categories either way.
+func (s *importService) categoryIDs(ctx context.Context) (map[string]uuid.UUID, error) {
+	categories, err := s.ctgRepo.GetCategories(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+
+	ids := make(map[string]uuid.UUID, 3*len(categories))
+	for _, c := range categories {
+		id, err := uuid.Parse(c.Id)
+		if err != nil {
+			continue
+		}
+		ids[strings.ToLower(c.Id)] = id
+		if c.Slug != "" {
+			ids[c.Slug] = id
+		}
+		// Names win over a slug spelled like another category's name
+		ids[strings.ToLower(c.Name)] = id
+	}
+	return ids, nil
+}
+
+// ExportBooks writes every book to w in format, in title order, reading them
+// a batch at a time so the catalogue is never held in memory.
+func (s *importService) ExportBooks(ctx context.Context, format string, w io.Writer) error {
+	writer, err := catalog.NewWriter(format, w)
+	if err != nil {
+		return err
+	}
+
+	categories, err := s.ctgRepo.GetCategories(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get categories: %w", err)
+	}
+	categoryNames := make(map[string]string, len(categories))
+	for _, c := range categories {
+		categoryNames[c.Id] = c.Name
+	}
+
+	filter := models.BookFilter{Sort: models.SortTitle, Limit: exportBatchSize}
+	for {
+		books, nextKeys, err := s.bookRepo.ListBooks(ctx, filter)
+		if err != nil {
+			return fmt.Errorf("failed to list books: %w", err)
+		}
+
+		for _, book := range books {
+			err := writer.Write(&catalog.Entry{
+				Title:         book.Title,
+				Author:        book.Author,
+				ISBN:          book.ISBN,
+				PublishedDate: book.PublishedDate,
+				Category:      categoryNames[book.CategoryID.String()],
+				Stock:         book.Stock,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to write book %s: %w", book.ID, err)
+			}
+		}
+
+		if nextKeys == nil {
+			break
+		}
+		filter.After = nextKeys
+	}
+
+	return writer.Flush()
+}
+
+// truncate shortens s to at most n characters.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
@@ -0,0 +1,316 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/internal/dto"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/models"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/catalog"
+	pb "github.com/sir-shalahuddin/grpc-learn/bookservice/proto/categoryservice"
+)
+
+// MockImportRepository adalah implementasi mock dari ImportRepository.
+type MockImportRepository struct {
+	CreateImportFunc     func(ctx context.Context, job *models.BookImport) error
+	GetImportFunc        func(ctx context.Context, id uuid.UUID) (*models.BookImport, error)
+	SetImportStatusFunc  func(ctx context.Context, id uuid.UUID, status, reason string) error
+	AddImportRowFunc     func(ctx context.Context, importID uuid.UUID, row *models.BookImportRow) error
+	ListImportRowsFunc   func(ctx context.Context, importID uuid.UUID, filter models.BookImportRowFilter) ([]models.BookImportRow, []string, error)
+	FailStaleImportsFunc func(ctx context.Context, before time.Time, reason string) (int64, error)
+}
+
+func (m *MockImportRepository) CreateImport(ctx context.Context, job *models.BookImport) error {
+	return m.CreateImportFunc(ctx, job)
+}
+
+func (m *MockImportRepository) GetImport(ctx context.Context, id uuid.UUID) (*models.BookImport, error) {
+	return m.GetImportFunc(ctx, id)
+}
+
+func (m *MockImportRepository) SetImportStatus(ctx context.Context, id uuid.UUID, status, reason string) error {
+	return m.SetImportStatusFunc(ctx, id, status, reason)
+}
+
+func (m *MockImportRepository) AddImportRow(ctx context.Context, importID uuid.UUID, row *models.BookImportRow) error {
+	return m.AddImportRowFunc(ctx, importID, row)
+}
+
+func (m *MockImportRepository) ListImportRows(ctx context.Context, importID uuid.UUID, filter models.BookImportRowFilter) ([]models.BookImportRow, []string, error) {
+	return m.ListImportRowsFunc(ctx, importID, filter)
+}
+
+func (m *MockImportRepository) FailStaleImports(ctx context.Context, before time.Time, reason string) (int64, error) {
+	return m.FailStaleImportsFunc(ctx, before, reason)
+}
+
+// importRecorder mencatat status dan row sebuah import.
+type importRecorder struct {
+	statuses []string
+	reason   string
+	rows     []*models.BookImportRow
+}
+
+func (r *importRecorder) repository() *MockImportRepository {
+	return &MockImportRepository{
+		SetImportStatusFunc: func(ctx context.Context, id uuid.UUID, status, reason string) error {
+			r.statuses = append(r.statuses, status)
+			r.reason = reason
+			return nil
+		},
+		AddImportRowFunc: func(ctx context.Context, importID uuid.UUID, row *models.BookImportRow) error {
+			r.rows = append(r.rows, row)
+			return nil
+		},
+	}
+}
+
+// catalogueBooks mengembalikan repository buku yang sudah berisi satu buku
+// dengan ISBN existingISBN, dan mencatat buku yang ditambahkan.
+func catalogueBooks(added *[]*models.Book) *MockBookRepository {
+	return &MockBookRepository{
+		GetBookByISBNFunc: func(ctx context.Context, isbn string) (*models.Book, error) {
+			if isbn == existingISBN {
+				return &models.Book{ID: uuid.New(), Title: "Dune"}, nil
+			}
+			return nil, nil
+		},
+		AddBookFunc: func(ctx context.Context, book *models.Book) error {
+			if book.ISBN13 == racedISBN {
+				return models.ErrDuplicateISBN
+			}
+			book.ID = uuid.New()
+			*added = append(*added, book)
+			return nil
+		},
+	}
+}
+
+const (
+	existingISBN = "9780306406157"
+	racedISBN    = "9781861972712"
+)
+
+var scienceID = uuid.New()
+
+func importCategories() *MockCategoryRepository {
+	return &MockCategoryRepository{
+		GetCategoriesFunc: func(ctx context.Context) ([]*pb.CategoryResponse, error) {
+			return []*pb.CategoryResponse{{Id: scienceID.String(), Name: "Science", Slug: "popular-science"}}, nil
+		},
+	}
+}
+
+// importFile adalah file CSV dengan satu row untuk setiap aturan validasi.
+const importFile = `title,author,isbn,category,stock,published_date
+Cosmos,Carl Sagan,,Science,3,
+,Nobody,,,,
+Untitled,,,,,
+Negative,Someone,,,-1,
+Lost,Someone,,Poetry,,
+Bad check digit,Someone,9780306406158,,,
+Bad date,Someone,,,,someday
+Dune,Frank Herbert,0-306-40615-2,,,
+Messiah,Frank Herbert,,popular-science,,
+Raced,Someone,978-1-86197-271-2,,,
+Pale Blue Dot,Carl Sagan,9780140177930,,,
+Pale Blue Dot again,Carl Sagan,0140177930,,,
+`
+
+// Test run: Setiap row dicek seperti AddBook, dan dry run tidak menambah
+// buku tapi melaporkan row yang akan ditambahkan sebagai valid
+func TestImportRun_Rows(t *testing.T) {
+	tests := []struct {
+		name    string
+		dryRun  bool
+		added   string
+		wantNew int
+	}{
+		{"import", false, models.ImportRowCreated, 3},
+		{"dry run", true, models.ImportRowValid, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &importRecorder{}
+			var added []*models.Book
+			svc := NewImportService(recorder.repository(), catalogueBooks(&added), importCategories())
+
+			reader, err := catalog.NewReader(catalog.FormatCSV, strings.NewReader(importFile))
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			job := &models.BookImport{ID: uuid.New(), DryRun: tt.dryRun, CreatedBy: uuid.New()}
+			svc.run(context.Background(), job, reader)
+
+			want := []struct {
+				status  string
+				message string
+			}{
+				{tt.added, ""},
+				{models.ImportRowInvalid, ErrIncompleteBook.Error()},
+				{models.ImportRowInvalid, ErrIncompleteBook.Error()},
+				{models.ImportRowInvalid, "stock can't be negative"},
+				{models.ImportRowInvalid, "category not found: Poetry"},
+				{models.ImportRowInvalid, ErrInvalidISBN.Error()},
+				{models.ImportRowInvalid, "bad published_date"},
+				{models.ImportRowDuplicate, "book already exists: Dune"},
+				{tt.added, ""},
+				{models.ImportRowDuplicate, ""},
+				{tt.added, ""},
+				{models.ImportRowDuplicate, "same ISBN as row 11"},
+			}
+			if tt.dryRun {
+				// Tanpa AddBook, konflik yang baru ketahuan saat insert tidak terlihat
+				want[9].status = models.ImportRowValid
+			}
+
+			if len(recorder.rows) != len(want) {
+				t.Fatalf("got %d rows, want %d", len(recorder.rows), len(want))
+			}
+			for i, row := range recorder.rows {
+				if row.Row != i+1 {
+					t.Errorf("row %d numbered %d", i+1, row.Row)
+				}
+				if row.Status != want[i].status || !strings.Contains(row.Message, want[i].message) {
+					t.Errorf("row %d = %s %q, want %s %q", i+1, row.Status, row.Message, want[i].status, want[i].message)
+				}
+				if (row.BookID != nil) != (row.Status == models.ImportRowCreated) {
+					t.Errorf("row %d has book ID %v with status %s", i+1, row.BookID, row.Status)
+				}
+			}
+
+			if len(added) != tt.wantNew {
+				t.Fatalf("added %d books, want %d", len(added), tt.wantNew)
+			}
+			if tt.wantNew > 0 {
+				if added[0].CategoryID != scienceID || added[0].Stock != 3 || added[0].AddedBy != job.CreatedBy {
+					t.Errorf("first book = %+v, want it in Science with 3 copies, added by the importer", added[0])
+				}
+				if added[1].CategoryID != scienceID {
+					t.Errorf("expected a category slug to resolve, got %v", added[1].CategoryID)
+				}
+				if added[2].ISBN13 != "9780140177930" {
+					t.Errorf("ISBN13 = %q, want 9780140177930", added[2].ISBN13)
+				}
+			}
+
+			wantStatuses := []string{models.ImportRunning, models.ImportCompleted}
+			if strings.Join(recorder.statuses, ",") != strings.Join(wantStatuses, ",") {
+				t.Errorf("statuses = %v, want %v", recorder.statuses, wantStatuses)
+			}
+		})
+	}
+}
+
+// failingReader adalah catalog.Reader yang gagal setelah beberapa entry.
+type failingReader struct {
+	entries int
+	err     error
+	panics  bool
+}
+
+func (r *failingReader) Read() (*catalog.Entry, error) {
+	if r.entries == 0 {
+		if r.panics {
+			panic("corrupt record")
+		}
+		return nil, r.err
+	}
+	r.entries--
+	return &catalog.Entry{Title: "Cosmos", Author: "Carl Sagan"}, nil
+}
+
+// Test run: File yang tidak bisa dibaca sampai habis menggagalkan import
+// beserta alasannya, termasuk saat reader panic
+func TestImportRun_Fails(t *testing.T) {
+	tests := []struct {
+		name     string
+		reader   *failingReader
+		wantRows int
+		reason   string
+	}{
+		{"read error", &failingReader{entries: 2, err: errors.New("connection reset")}, 2, "failed to read row 3: connection reset"},
+		{"panic", &failingReader{entries: 1, panics: true}, 1, "import stopped unexpectedly: corrupt record"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &importRecorder{}
+			var added []*models.Book
+			svc := NewImportService(recorder.repository(), catalogueBooks(&added), importCategories())
+
+			svc.run(context.Background(), &models.BookImport{ID: uuid.New()}, tt.reader)
+
+			if len(recorder.rows) != tt.wantRows {
+				t.Errorf("got %d rows, want %d", len(recorder.rows), tt.wantRows)
+			}
+			if last := recorder.statuses[len(recorder.statuses)-1]; last != models.ImportFailed || recorder.reason != tt.reason {
+				t.Errorf("import ended %s %q, want %s %q", last, recorder.reason, models.ImportFailed, tt.reason)
+			}
+		})
+	}
+}
+
+// Test StartImport: Format diambil dari nama file, dan file yang tidak bisa
+// dibaca ditolak sebelum import dibuat
+func TestStartImport_Format(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     dto.StartImportRequest
+		wantErr error
+	}{
+		{"unknown format", dto.StartImportRequest{Filename: "books.xlsx", Data: []byte("title\n")}, catalog.ErrUnknownFormat},
+		{"no header", dto.StartImportRequest{Filename: "books.csv"}, ErrInvalidImportFile},
+		{"no title column", dto.StartImportRequest{Format: "CSV", Data: []byte("name,author\n")}, ErrInvalidImportFile},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			importRepo := &MockImportRepository{
+				CreateImportFunc: func(ctx context.Context, job *models.BookImport) error {
+					t.Error("expected no import to be created")
+					return nil
+				},
+			}
+			svc := NewImportService(importRepo, &MockBookRepository{}, &MockCategoryRepository{})
+
+			if _, err := svc.StartImport(context.Background(), tt.req, uuid.New()); !errors.Is(err, tt.wantErr) {
+				t.Errorf("StartImport() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// Test ListImportRows: Status yang tidak dikenal ditolak dan import yang
+// tidak ada dilaporkan
+func TestListImportRows_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     dto.ListImportRowsRequest
+		wantErr error
+	}{
+		{"unknown status", dto.ListImportRowsRequest{Status: "skipped"}, ErrInvalidImportStatus},
+		{"missing import", dto.ListImportRowsRequest{Status: models.ImportRowInvalid}, ErrImportNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			importRepo := &MockImportRepository{
+				GetImportFunc: func(ctx context.Context, id uuid.UUID) (*models.BookImport, error) {
+					return nil, nil
+				},
+			}
+			svc := NewImportService(importRepo, &MockBookRepository{}, &MockCategoryRepository{})
+
+			if _, err := svc.ListImportRows(context.Background(), tt.req, uuid.New()); !errors.Is(err, tt.wantErr) {
+				t.Errorf("ListImportRows() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,354 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/internal/dto"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/models"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/pagination"
+)
+
+var (
+	ErrListNotFound         = errors.New("reading list not found")
+	ErrListNameRequired     = errors.New("list name is required")
+	ErrListNameTooLong      = fmt.Errorf("list name can't be longer than %d characters", maxListNameLength)
+	ErrTooManyLists         = fmt.Errorf("a patron can have at most %d reading lists", maxListsPerUser)
+	ErrListFull             = fmt.Errorf("a reading list can hold at most %d books", maxListItems)
+	ErrBookAlreadyListed    = errors.New("book is already on this list")
+	ErrBookNotListed        = errors.New("book is not on this list")
+	ErrInvalidListOrder     = errors.New("book_ids must hold every book on the list once")
+	ErrNotificationNotFound = errors.New("notification not found")
+)
+
+const (
+	maxListNameLength           = 100
+	maxListsPerUser             = 50
+	maxListItems                = 500
+	defaultNotificationPageSize = 20
+	maxNotificationPageSize     = 100
+)
+
+type ReadingListRepository interface {
+	CreateList(ctx context.Context, list *models.ReadingList) error
+	GetList(ctx context.Context, id uuid.UUID) (*models.ReadingList, error)
+	GetPublicList(ctx context.Context, slug string) (*models.ReadingList, error)
+	ListLists(ctx context.Context, userID uuid.UUID) ([]models.ReadingList, error)
+	UpdateList(ctx context.Context, list *models.ReadingList) error
+	DeleteList(ctx context.Context, id uuid.UUID) error
+	ListItems(ctx context.Context, listID uuid.UUID) ([]models.ReadingListItem, error)
+	AddItem(ctx context.Context, listID, bookID uuid.UUID, notify bool) (bool, error)
+	SetItemNotify(ctx context.Context, listID, bookID uuid.UUID, notify bool) (bool, error)
+	RemoveItem(ctx context.Context, listID, bookID uuid.UUID) (bool, error)
+	ReorderItems(ctx context.Context, listID uuid.UUID, bookIDs []uuid.UUID) error
+	ListNotifications(ctx context.Context, userID uuid.UUID, filter models.NotificationFilter) ([]models.Notification, []string, error)
+	MarkNotificationRead(ctx context.Context, userID, id uuid.UUID) (bool, error)
+	DeleteUserLists(ctx context.Context, userID uuid.UUID) (int64, error)
+}
+
+// AvailabilityNotifier notifies the patrons waiting for a book once a copy
+// is back on the shelf.
+type AvailabilityNotifier interface {
+	NotifyAvailable(ctx context.Context, tx *sql.Tx, bookID uuid.UUID) (int64, error)
+}
+
+type readingListService struct {
+	listRepo ReadingListRepository
+	bookRepo BookRepository
+}
+
+func NewReadingListService(listRepo ReadingListRepository, bookRepo BookRepository) *readingListService {
+	return &readingListService{
+		listRepo: listRepo,
+		bookRepo: bookRepo,
+	}
+}
+
+// CreateList creates a reading list for the user.
+func (s *readingListService) CreateList(ctx context.Context, userID uuid.UUID, req dto.ReadingListRequest) (*models.ReadingList, error) {
+	name, err := checkListName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	lists, err := s.listRepo.ListLists(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(lists) >= maxListsPerUser {
+		return nil, ErrTooManyLists
+	}
+
+	list := &models.ReadingList{
+		UserID:      userID,
+		Name:        name,
+		Description: strings.TrimSpace(req.Description),
+		Public:      req.Public,
+		Items:       []models.ReadingListItem{},
+	}
+	if list.Public {
+		if list.Slug, err = newSlug(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.listRepo.CreateList(ctx, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// ListLists returns the reading lists of the user, without their books.
+func (s *readingListService) ListLists(ctx context.Context, userID uuid.UUID) (*dto.ListReadingListsResponse, error) {
+	lists, err := s.listRepo.ListLists(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.ListReadingListsResponse{Lists: lists}, nil
+}
+
+// GetList returns one of the user's lists with its books and their current
+// availability.
+func (s *readingListService) GetList(ctx context.Context, userID, listID uuid.UUID) (*models.ReadingList, error) {
+	list, err := s.ownList(ctx, userID, listID)
+	if err != nil {
+		return nil, err
+	}
+
+	if list.Items, err = s.listRepo.ListItems(ctx, list.ID); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// GetPublicList returns the public list shared under slug with its books.
+// Who owns it and which books they wait for are left out.
+func (s *readingListService) GetPublicList(ctx context.Context, slug string) (*dto.SharedListResponse, error) {
+	list, err := s.listRepo.GetPublicList(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	if list == nil {
+		return nil, ErrListNotFound
+	}
+
+	items, err := s.listRepo.ListItems(ctx, list.ID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		items[i].Notify = false
+	}
+
+	return &dto.SharedListResponse{
+		Name:        list.Name,
+		Description: list.Description,
+		UpdatedAt:   list.UpdatedAt,
+		Items:       items,
+	}, nil
+}
+
+// UpdateList replaces the name, description and sharing of one of the
+// user's lists. A list keeps its slug when it is made private, so sharing it
+// again restores the same link.
+func (s *readingListService) UpdateList(ctx context.Context, userID, listID uuid.UUID, req dto.ReadingListRequest) (*models.ReadingList, error) {
+	name, err := checkListName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := s.ownList(ctx, userID, listID)
+	if err != nil {
+		return nil, err
+	}
+
+	list.Name = name
+	list.Description = strings.TrimSpace(req.Description)
+	list.Public = req.Public
+	if list.Public && list.Slug == "" {
+		if list.Slug, err = newSlug(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.listRepo.UpdateList(ctx, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (s *readingListService) DeleteList(ctx context.Context, userID, listID uuid.UUID) error {
+	if _, err := s.ownList(ctx, userID, listID); err != nil {
+		return err
+	}
+	return s.listRepo.DeleteList(ctx, listID)
+}
+
+// AddItem puts a book at the end of one of the user's lists.
+func (s *readingListService) AddItem(ctx context.Context, userID, listID uuid.UUID, req dto.AddListItemRequest) error {
+	list, err := s.ownList(ctx, userID, listID)
+	if err != nil {
+		return err
+	}
+	if list.ItemCount >= maxListItems {
+		return ErrListFull
+	}
+
+	book, err := s.bookRepo.GetBookByID(ctx, req.BookID)
+	if err != nil {
+		return err
+	}
+	if book == nil {
+		return ErrBookNotFound
+	}
+
+	added, err := s.listRepo.AddItem(ctx, listID, req.BookID, req.Notify)
+	if err != nil {
+		return err
+	}
+	if !added {
+		return ErrBookAlreadyListed
+	}
+	return nil
+}
+
+// SetItemNotify turns the availability notification of a book on one of the
+// user's lists on or off. It turns itself off once the patron is notified.
+func (s *readingListService) SetItemNotify(ctx context.Context, userID, listID, bookID uuid.UUID, notify bool) error {
+	if _, err := s.ownList(ctx, userID, listID); err != nil {
+		return err
+	}
+
+	updated, err := s.listRepo.SetItemNotify(ctx, listID, bookID, notify)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return ErrBookNotListed
+	}
+	return nil
+}
+
+// RemoveItem takes a book off one of the user's lists.
+func (s *readingListService) RemoveItem(ctx context.Context, userID, listID, bookID uuid.UUID) error {
+	if _, err := s.ownList(ctx, userID, listID); err != nil {
+		return err
+	}
+
+	removed, err := s.listRepo.RemoveItem(ctx, listID, bookID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrBookNotListed
+	}
+	return nil
+}
+
+// ReorderItems puts the books of one of the user's lists in the order of
+// req.BookIDs, which must hold each of them once.
+func (s *readingListService) ReorderItems(ctx context.Context, userID, listID uuid.UUID, req dto.ReorderListRequest) (*models.ReadingList, error) {
+	list, err := s.GetList(ctx, userID, listID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(req.BookIDs) != len(list.Items) {
+		return nil, ErrInvalidListOrder
+	}
+	listed := make(map[uuid.UUID]bool, len(list.Items))
+	for _, item := range list.Items {
+		listed[item.BookID] = true
+	}
+	for _, id := range req.BookIDs {
+		if !listed[id] {
+			return nil, ErrInvalidListOrder
+		}
+		// Seen once only, so a repeated ID fails
+		delete(listed, id)
+	}
+
+	if err := s.listRepo.ReorderItems(ctx, listID, req.BookIDs); err != nil {
+		return nil, err
+	}
+	return s.GetList(ctx, userID, listID)
+}
+
+// ListNotifications lists a page of the user's notifications, newest first.
+// Pages follow each other through NextCursor.
+func (s *readingListService) ListNotifications(ctx context.Context, userID uuid.UUID, req dto.ListNotificationsRequest) (*dto.ListNotificationsResponse, error) {
+	after, err := pagination.Decode(req.Cursor, "newest")
+	if err != nil {
+		return nil, err
+	}
+
+	notifications, nextKeys, err := s.listRepo.ListNotifications(ctx, userID, models.NotificationFilter{
+		Unread: req.Unread,
+		After:  after,
+		Limit:  pagination.Limit(req.PageSize, defaultNotificationPageSize, maxNotificationPageSize),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.ListNotificationsResponse{
+		Notifications: notifications,
+		NextCursor:    pagination.Encode("newest", nextKeys),
+	}, nil
+}
+
+func (s *readingListService) MarkNotificationRead(ctx context.Context, userID, id uuid.UUID) error {
+	marked, err := s.listRepo.MarkNotificationRead(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if !marked {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
+
+// EraseUserLists deletes the reading lists and notifications of the user.
+func (s *readingListService) EraseUserLists(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return s.listRepo.DeleteUserLists(ctx, userID)
+}
+
+// ownList returns a list of the user. Lists of other users are reported as
+// not found, so their IDs give nothing away.
+func (s *readingListService) ownList(ctx context.Context, userID, listID uuid.UUID) (*models.ReadingList, error) {
+	list, err := s.listRepo.GetList(ctx, listID)
+	if err != nil {
+		return nil, err
+	}
+	if list == nil || list.UserID != userID {
+		return nil, ErrListNotFound
+	}
+	return list, nil
+}
+
+func checkListName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", ErrListNameRequired
+	}
+	if len([]rune(name)) > maxListNameLength {
+		return "", ErrListNameTooLong
+	}
+	return name, nil
+}
+
+// newSlug returns a random slug to share a list under, long enough not to be
+// guessed.
+func newSlug() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate slug: %w", err)
+	}
+	return strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b)), nil
+}
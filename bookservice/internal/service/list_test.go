@@ -0,0 +1,351 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/internal/dto"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/models"
+)
+
+// fakeReadingListRepository adalah ReadingListRepository di memori untuk
+// list dan urutan bukunya. Method lain akan panic karena interface yang
+// di-embed nil.
+type fakeReadingListRepository struct {
+	ReadingListRepository
+	lists    map[uuid.UUID]*models.ReadingList
+	items    map[uuid.UUID][]models.ReadingListItem
+	reorders int
+}
+
+func newFakeReadingListRepository() *fakeReadingListRepository {
+	return &fakeReadingListRepository{
+		lists: make(map[uuid.UUID]*models.ReadingList),
+		items: make(map[uuid.UUID][]models.ReadingListItem),
+	}
+}
+
+// add membuat list milik userID berisi buku-buku dengan urutan itu.
+func (r *fakeReadingListRepository) add(userID uuid.UUID, public bool, slug string, books ...uuid.UUID) uuid.UUID {
+	list := &models.ReadingList{ID: uuid.New(), UserID: userID, Name: "Wishlist", Public: public, Slug: slug, ItemCount: len(books)}
+	r.lists[list.ID] = list
+	for i, bookID := range books {
+		r.items[list.ID] = append(r.items[list.ID], models.ReadingListItem{BookID: bookID, Position: i + 1, Notify: true})
+	}
+	return list.ID
+}
+
+func (r *fakeReadingListRepository) CreateList(ctx context.Context, list *models.ReadingList) error {
+	list.ID = uuid.New()
+	r.lists[list.ID] = list
+	return nil
+}
+
+func (r *fakeReadingListRepository) GetList(ctx context.Context, id uuid.UUID) (*models.ReadingList, error) {
+	list, ok := r.lists[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *list
+	return &copied, nil
+}
+
+func (r *fakeReadingListRepository) GetPublicList(ctx context.Context, slug string) (*models.ReadingList, error) {
+	for _, list := range r.lists {
+		if list.Public && list.Slug == slug {
+			copied := *list
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeReadingListRepository) ListLists(ctx context.Context, userID uuid.UUID) ([]models.ReadingList, error) {
+	var lists []models.ReadingList
+	for _, list := range r.lists {
+		if list.UserID == userID {
+			lists = append(lists, *list)
+		}
+	}
+	return lists, nil
+}
+
+func (r *fakeReadingListRepository) UpdateList(ctx context.Context, list *models.ReadingList) error {
+	copied := *list
+	r.lists[list.ID] = &copied
+	return nil
+}
+
+func (r *fakeReadingListRepository) ListItems(ctx context.Context, listID uuid.UUID) ([]models.ReadingListItem, error) {
+	return append([]models.ReadingListItem(nil), r.items[listID]...), nil
+}
+
+func (r *fakeReadingListRepository) ReorderItems(ctx context.Context, listID uuid.UUID, bookIDs []uuid.UUID) error {
+	r.reorders++
+	byBook := make(map[uuid.UUID]models.ReadingListItem)
+	for _, item := range r.items[listID] {
+		byBook[item.BookID] = item
+	}
+	items := make([]models.ReadingListItem, len(bookIDs))
+	for i, bookID := range bookIDs {
+		items[i] = byBook[bookID]
+		items[i].Position = i + 1
+	}
+	r.items[listID] = items
+	return nil
+}
+
+// Test ReorderItems: Urutan baru harus memuat setiap buku di list tepat
+// sekali, dan hanya pemilik yang bisa mengubahnya
+func TestReorderItems(t *testing.T) {
+	ownerID := uuid.New()
+	a, b, c := uuid.New(), uuid.New(), uuid.New()
+
+	tests := []struct {
+		name    string
+		userID  uuid.UUID
+		bookIDs []uuid.UUID
+		wantErr error
+	}{
+		{"new order", ownerID, []uuid.UUID{c, a, b}, nil},
+		{"same order", ownerID, []uuid.UUID{a, b, c}, nil},
+		{"missing book", ownerID, []uuid.UUID{c, a}, ErrInvalidListOrder},
+		{"repeated book", ownerID, []uuid.UUID{c, a, a}, ErrInvalidListOrder},
+		{"book not on the list", ownerID, []uuid.UUID{c, a, uuid.New()}, ErrInvalidListOrder},
+		{"extra book", ownerID, []uuid.UUID{c, a, b, uuid.New()}, ErrInvalidListOrder},
+		{"someone else's list", uuid.New(), []uuid.UUID{c, a, b}, ErrListNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeReadingListRepository()
+			listID := repo.add(ownerID, false, "", a, b, c)
+			svc := NewReadingListService(repo, nil)
+
+			list, err := svc.ReorderItems(context.Background(), tt.userID, listID, dto.ReorderListRequest{BookIDs: tt.bookIDs})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ReorderItems() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if repo.reorders != 0 {
+					t.Error("expected the list to keep its order")
+				}
+				return
+			}
+
+			for i, item := range list.Items {
+				if item.BookID != tt.bookIDs[i] || item.Position != i+1 {
+					t.Errorf("item %d = %s at %d, want %s at %d", i, item.BookID, item.Position, tt.bookIDs[i], i+1)
+				}
+			}
+		})
+	}
+}
+
+// Test GetPublicList: List hanya bisa dibaca lewat slug selama publik, tanpa
+// pemilik dan notifikasinya
+func TestGetPublicList(t *testing.T) {
+	repo := newFakeReadingListRepository()
+	ownerID := uuid.New()
+	repo.add(ownerID, true, "shared", uuid.New(), uuid.New())
+	repo.add(ownerID, false, "unshared", uuid.New())
+	svc := NewReadingListService(repo, nil)
+
+	tests := []struct {
+		name      string
+		slug      string
+		wantItems int
+		wantErr   error
+	}{
+		{"public", "shared", 2, nil},
+		{"made private", "unshared", 0, ErrListNotFound},
+		{"unknown slug", "guessed", 0, ErrListNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := svc.GetPublicList(context.Background(), tt.slug)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetPublicList() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if len(res.Items) != tt.wantItems {
+				t.Errorf("got %d items, want %d", len(res.Items), tt.wantItems)
+			}
+			for _, item := range res.Items {
+				if item.Notify {
+					t.Errorf("item %s shows which books the owner waits for", item.BookID)
+				}
+			}
+		})
+	}
+}
+
+// Test UpdateList: Slug dibuat saat list pertama kali dibagikan dan tetap
+// sama saat list dijadikan private lalu dibagikan lagi
+func TestUpdateList_KeepsSlug(t *testing.T) {
+	repo := newFakeReadingListRepository()
+	ownerID := uuid.New()
+	svc := NewReadingListService(repo, nil)
+
+	list, err := svc.CreateList(context.Background(), ownerID, dto.ReadingListRequest{Name: " To read "})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if list.Name != "To read" || list.Slug != "" {
+		t.Fatalf("list = %+v, want a private list without a slug", list)
+	}
+
+	shared, err := svc.UpdateList(context.Background(), ownerID, list.ID, dto.ReadingListRequest{Name: "To read", Public: true})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(shared.Slug) != 16 {
+		t.Fatalf("slug = %q, want 16 random characters", shared.Slug)
+	}
+
+	for _, public := range []bool{false, true} {
+		updated, err := svc.UpdateList(context.Background(), ownerID, list.ID, dto.ReadingListRequest{Name: "To read", Public: public})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if updated.Slug != shared.Slug {
+			t.Errorf("slug = %q after public = %v, want %q", updated.Slug, public, shared.Slug)
+		}
+	}
+
+	if _, err := svc.GetPublicList(context.Background(), shared.Slug); err != nil {
+		t.Errorf("expected the list to be shared again under its slug, got %v", err)
+	}
+	if _, err := svc.UpdateList(context.Background(), uuid.New(), list.ID, dto.ReadingListRequest{Name: "Mine now"}); !errors.Is(err, ErrListNotFound) {
+		t.Errorf("expected ErrListNotFound for another user, got %v", err)
+	}
+}
+
+// Test CreateList: Nama wajib dan dibatasi, begitu juga jumlah list per
+// patron
+func TestCreateList_Limits(t *testing.T) {
+	ownerID := uuid.New()
+
+	tests := []struct {
+		name     string
+		existing int
+		listName string
+		wantErr  error
+	}{
+		{"first list", 0, "Wishlist", nil},
+		{"last list", maxListsPerUser - 1, "Wishlist", nil},
+		{"too many", maxListsPerUser, "Wishlist", ErrTooManyLists},
+		{"blank name", 0, "   ", ErrListNameRequired},
+		{"long name", 0, strings.Repeat("é", maxListNameLength+1), ErrListNameTooLong},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeReadingListRepository()
+			for i := 0; i < tt.existing; i++ {
+				repo.add(ownerID, false, "")
+			}
+			svc := NewReadingListService(repo, nil)
+
+			if _, err := svc.CreateList(context.Background(), ownerID, dto.ReadingListRequest{Name: tt.listName}); !errors.Is(err, tt.wantErr) {
+				t.Errorf("CreateList() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// MockTxRepository adalah implementasi mock dari TxRepository yang mencatat
+// commit.
+type MockTxRepository struct {
+	committed bool
+}
+
+func (m *MockTxRepository) BeginTx(ctx context.Context) (*sql.Tx, error) {
+	return nil, nil
+}
+
+func (m *MockTxRepository) Commit(tx *sql.Tx) error {
+	m.committed = true
+	return nil
+}
+
+func (m *MockTxRepository) Rollback(tx *sql.Tx) error {
+	return nil
+}
+
+// MockAvailabilityNotifier adalah implementasi mock dari AvailabilityNotifier.
+type MockAvailabilityNotifier struct {
+	NotifyAvailableFunc func(ctx context.Context, tx *sql.Tx, bookID uuid.UUID) (int64, error)
+}
+
+func (m *MockAvailabilityNotifier) NotifyAvailable(ctx context.Context, tx *sql.Tx, bookID uuid.UUID) (int64, error) {
+	return m.NotifyAvailableFunc(ctx, tx, bookID)
+}
+
+// Test ReturnBook: Patron yang menunggu hanya diberi tahu saat pengembalian
+// membuat buku tersedia lagi, dalam transaksi yang sama
+func TestReturnBook_NotifiesWhenBackInStock(t *testing.T) {
+	tests := []struct {
+		name       string
+		stock      int
+		wantNotify bool
+	}{
+		{"last copy back", 0, true},
+		{"other copies on the shelf", 2, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bookID, recordID := uuid.New(), uuid.New()
+			recordRepo := &MockBorrowingRecordRepository{
+				GetBorrowingRecordByIDFunc: func(ctx context.Context, id uuid.UUID) (*models.BorrowingRecord, error) {
+					return &models.BorrowingRecord{ID: id, Book: models.Book{ID: bookID}}, nil
+				},
+				UpdateBorrowingRecordFunc: func(ctx context.Context, tx *sql.Tx, record *models.BorrowingRecord) error {
+					if record.ReturnedAt == nil {
+						t.Error("expected the record to be returned")
+					}
+					return nil
+				},
+			}
+			bookRepo := bookWithID(bookID, &models.Book{ID: bookID, Stock: tt.stock})
+			bookRepo.UpdateBookFunc = func(ctx context.Context, tx *sql.Tx, book *models.Book) error {
+				if book.Stock != tt.stock+1 {
+					t.Errorf("stock = %d, want %d", book.Stock, tt.stock+1)
+				}
+				return nil
+			}
+			txRepo := &MockTxRepository{}
+			notified := false
+			notifier := &MockAvailabilityNotifier{
+				NotifyAvailableFunc: func(ctx context.Context, tx *sql.Tx, id uuid.UUID) (int64, error) {
+					notified = id == bookID
+					if txRepo.committed {
+						t.Error("expected the notifications to be written before the commit")
+					}
+					return 1, nil
+				},
+			}
+			svc := NewBorrowingRecordService(recordRepo, txRepo, bookRepo, notifier, nil)
+
+			if err := svc.ReturnBook(context.Background(), bookID, recordID); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if notified != tt.wantNotify {
+				t.Errorf("notified = %v, want %v", notified, tt.wantNotify)
+			}
+			if !txRepo.committed {
+				t.Error("expected the return to be committed")
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/internal/dto"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/models"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/pagination"
+)
+
+var ErrRecomputeRunning = errors.New("recommendations are already being recomputed")
+
+const defaultRecommendationCount = 10
+
+type RecommendationRepository interface {
+	Recompute(ctx context.Context, run *models.RecommendationRun, size, minCoBorrowers int) (bool, error)
+	SimilarBooks(ctx context.Context, bookID uuid.UUID, limit int) ([]models.RecommendedBook, error)
+	RecommendedBooks(ctx context.Context, userID uuid.UUID, limit int) ([]models.RecommendedBook, error)
+	CreateRun(ctx context.Context, run *models.RecommendationRun) error
+	FinishRun(ctx context.Context, run *models.RecommendationRun) error
+	LatestRun(ctx context.Context) (*models.RecommendationRun, error)
+	DeleteUserRecommendations(ctx context.Context, userID uuid.UUID) (int64, error)
+}
+
+// recommendationService serves recommendations from tables recomputed from
+// the borrowing history on a schedule or when a librarian asks. size books
+// are kept per book and per patron; minCoBorrowers is how many patrons must
+// have borrowed two books for them to count as similar.
+type recommendationService struct {
+	recommendationRepo RecommendationRepository
+	bookRepo           BookRepository
+	size               int
+	minCoBorrowers     int
+
+	running atomic.Bool
+}
+
+func NewRecommendationService(recommendationRepo RecommendationRepository, bookRepo BookRepository, size, minCoBorrowers int) *recommendationService {
+	return &recommendationService{
+		recommendationRepo: recommendationRepo,
+		bookRepo:           bookRepo,
+		size:               size,
+		minCoBorrowers:     minCoBorrowers,
+	}
+}
+
+// SimilarBooks returns up to limit books for readers of a book, those
+// borrowed by the same patrons first, then popular ones of its category.
+func (s *recommendationService) SimilarBooks(ctx context.Context, bookID uuid.UUID, limit int) (*dto.RecommendationsResponse, error) {
+	book, err := s.bookRepo.GetBookByID(ctx, bookID)
+	if err != nil {
+		return nil, err
+	}
+	if book == nil {
+		return nil, ErrBookNotFound
+	}
+
+	books, err := s.recommendationRepo.SimilarBooks(ctx, bookID, pagination.Limit(limit, min(defaultRecommendationCount, s.size), s.size))
+	if err != nil {
+		return nil, err
+	}
+	return &dto.RecommendationsResponse{Books: books}, nil
+}
+
+// RecommendedBooks returns up to limit books the patron hasn't borrowed yet.
+// Patrons without enough history get the popular books of their favourite
+// categories, or of the whole library.
+func (s *recommendationService) RecommendedBooks(ctx context.Context, userID uuid.UUID, limit int) (*dto.RecommendationsResponse, error) {
+	books, err := s.recommendationRepo.RecommendedBooks(ctx, userID, pagination.Limit(limit, min(defaultRecommendationCount, s.size), s.size))
+	if err != nil {
+		return nil, err
+	}
+	return &dto.RecommendationsResponse{Books: books}, nil
+}
+
+// Recompute starts recomputing the recommendations in the background on
+// behalf of a librarian and returns the run to follow.
+func (s *recommendationService) Recompute(ctx context.Context, userID uuid.UUID) (*models.RecommendationRun, error) {
+	return s.start(ctx, models.RunManual, &userID)
+}
+
+// LatestRun returns the last recommendation run, or nil before the first.
+func (s *recommendationService) LatestRun(ctx context.Context) (*models.RecommendationRun, error) {
+	return s.recommendationRepo.LatestRun(ctx)
+}
+
+// EraseUserRecommendations deletes the books recommended to the user. Their
+// anonymized borrowings still count towards the similarity of books.
+func (s *recommendationService) EraseUserRecommendations(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return s.recommendationRepo.DeleteUserRecommendations(ctx, userID)
+}
+
+// Schedule recomputes the recommendations right away and then every
+// interval until ctx is done. A zero interval leaves it to librarians.
+func (s *recommendationService) Schedule(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.start(ctx, models.RunScheduled, nil); err != nil && !errors.Is(err, ErrRecomputeRunning) {
+			log.Printf("[Service - Recommendations] Error starting scheduled run: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// start records a run and recomputes in the background, one run at a time.
+func (s *recommendationService) start(ctx context.Context, trigger string, userID *uuid.UUID) (*models.RecommendationRun, error) {
+	if !s.running.CompareAndSwap(false, true) {
+		return nil, ErrRecomputeRunning
+	}
+
+	run := &models.RecommendationRun{
+		Trigger:     trigger,
+		Status:      models.RunRunning,
+		RequestedBy: userID,
+	}
+	if err := s.recommendationRepo.CreateRun(ctx, run); err != nil {
+		s.running.Store(false)
+		return nil, err
+	}
+
+	// Detached from the request, which ends before the run does
+	started := *run
+	go s.run(context.Background(), run)
+	return &started, nil
+}
+
+func (s *recommendationService) run(ctx context.Context, run *models.RecommendationRun) {
+	defer s.running.Store(false)
+
+	ran, err := s.recommendationRepo.Recompute(ctx, run, s.size, s.minCoBorrowers)
+	switch {
+	case err != nil:
+		log.Printf("[Service - Recommendations] Error recomputing: %v", err)
+		run.Status = models.RunFailed
+		run.Error = err.Error()
+	case !ran:
+		run.Status = models.RunSkipped
+	default:
+		run.Status = models.RunCompleted
+	}
+
+	if err := s.recommendationRepo.FinishRun(ctx, run); err != nil {
+		log.Printf("[Service - Recommendations] Error finishing run %s: %v", run.ID, err)
+	}
+}
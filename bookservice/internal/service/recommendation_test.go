@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/models"
+)
+
+// MockRecommendationRepository adalah implementasi mock dari
+// RecommendationRepository. Method tanpa Func akan panic karena interface
+// yang di-embed nil.
+type MockRecommendationRepository struct {
+	RecommendationRepository
+	RecomputeFunc        func(ctx context.Context, run *models.RecommendationRun, size, minCoBorrowers int) (bool, error)
+	SimilarBooksFunc     func(ctx context.Context, bookID uuid.UUID, limit int) ([]models.RecommendedBook, error)
+	RecommendedBooksFunc func(ctx context.Context, userID uuid.UUID, limit int) ([]models.RecommendedBook, error)
+	CreateRunFunc        func(ctx context.Context, run *models.RecommendationRun) error
+	FinishRunFunc        func(ctx context.Context, run *models.RecommendationRun) error
+}
+
+func (m *MockRecommendationRepository) Recompute(ctx context.Context, run *models.RecommendationRun, size, minCoBorrowers int) (bool, error) {
+	return m.RecomputeFunc(ctx, run, size, minCoBorrowers)
+}
+
+func (m *MockRecommendationRepository) SimilarBooks(ctx context.Context, bookID uuid.UUID, limit int) ([]models.RecommendedBook, error) {
+	return m.SimilarBooksFunc(ctx, bookID, limit)
+}
+
+func (m *MockRecommendationRepository) RecommendedBooks(ctx context.Context, userID uuid.UUID, limit int) ([]models.RecommendedBook, error) {
+	return m.RecommendedBooksFunc(ctx, userID, limit)
+}
+
+func (m *MockRecommendationRepository) CreateRun(ctx context.Context, run *models.RecommendationRun) error {
+	return m.CreateRunFunc(ctx, run)
+}
+
+func (m *MockRecommendationRepository) FinishRun(ctx context.Context, run *models.RecommendationRun) error {
+	return m.FinishRunFunc(ctx, run)
+}
+
+// Test SimilarBooks dan RecommendedBooks: Jumlah buku default 10 dan
+// dibatasi oleh ukuran tabel rekomendasi
+func TestRecommendations_Limit(t *testing.T) {
+	bookID := uuid.New()
+
+	tests := []struct {
+		name      string
+		size      int
+		requested int
+		want      int
+	}{
+		{"default", 20, 0, 10},
+		{"default above size", 5, 0, 5},
+		{"requested", 20, 15, 15},
+		{"above size", 20, 50, 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var limits []int
+			repo := &MockRecommendationRepository{
+				SimilarBooksFunc: func(ctx context.Context, id uuid.UUID, limit int) ([]models.RecommendedBook, error) {
+					limits = append(limits, limit)
+					return []models.RecommendedBook{}, nil
+				},
+				RecommendedBooksFunc: func(ctx context.Context, userID uuid.UUID, limit int) ([]models.RecommendedBook, error) {
+					limits = append(limits, limit)
+					return []models.RecommendedBook{}, nil
+				},
+			}
+			svc := NewRecommendationService(repo, bookWithID(bookID, &models.Book{ID: bookID}), tt.size, 2)
+
+			if _, err := svc.SimilarBooks(context.Background(), bookID, tt.requested); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if _, err := svc.RecommendedBooks(context.Background(), uuid.New(), tt.requested); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			for _, limit := range limits {
+				if limit != tt.want {
+					t.Errorf("limit = %d, want %d", limit, tt.want)
+				}
+			}
+		})
+	}
+}
+
+// Test SimilarBooks: Buku yang tidak ada dilaporkan
+func TestSimilarBooks_MissingBook(t *testing.T) {
+	svc := NewRecommendationService(&MockRecommendationRepository{}, bookWithID(uuid.New(), nil), 20, 2)
+
+	if _, err := svc.SimilarBooks(context.Background(), uuid.New(), 0); !errors.Is(err, ErrBookNotFound) {
+		t.Errorf("expected ErrBookNotFound, got %v", err)
+	}
+}
+
+// Test Recompute: Run dicatat dengan hasilnya, dan hanya satu run berjalan
+// dalam satu waktu
+func TestRecompute_Runs(t *testing.T) {
+	tests := []struct {
+		name       string
+		ran        bool
+		err        error
+		wantStatus string
+	}{
+		{"completed", true, nil, models.RunCompleted},
+		{"other instance running", false, nil, models.RunSkipped},
+		{"failed", false, errors.New("connection reset"), models.RunFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			release := make(chan struct{})
+			finished := make(chan *models.RecommendationRun, 1)
+			var mu sync.Mutex
+			var size, minCoBorrowers int
+			repo := &MockRecommendationRepository{
+				CreateRunFunc: func(ctx context.Context, run *models.RecommendationRun) error {
+					run.ID = uuid.New()
+					return nil
+				},
+				RecomputeFunc: func(ctx context.Context, run *models.RecommendationRun, s, m int) (bool, error) {
+					<-release
+					mu.Lock()
+					size, minCoBorrowers = s, m
+					mu.Unlock()
+					return tt.ran, tt.err
+				},
+				FinishRunFunc: func(ctx context.Context, run *models.RecommendationRun) error {
+					finished <- run
+					return nil
+				},
+			}
+			svc := NewRecommendationService(repo, nil, 20, 3)
+			librarian := uuid.New()
+
+			run, err := svc.Recompute(context.Background(), librarian)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if run.Status != models.RunRunning || run.Trigger != models.RunManual || *run.RequestedBy != librarian {
+				t.Errorf("run = %+v, want a running manual run by the librarian", run)
+			}
+
+			if _, err := svc.Recompute(context.Background(), librarian); !errors.Is(err, ErrRecomputeRunning) {
+				t.Errorf("expected ErrRecomputeRunning while a run is going, got %v", err)
+			}
+			close(release)
+
+			select {
+			case done := <-finished:
+				if done.Status != tt.wantStatus {
+					t.Errorf("status = %s, want %s", done.Status, tt.wantStatus)
+				}
+				if tt.err != nil && done.Error != tt.err.Error() {
+					t.Errorf("error = %q, want %q", done.Error, tt.err.Error())
+				}
+			case <-time.After(time.Second):
+				t.Fatal("expected the run to finish")
+			}
+			mu.Lock()
+			if size != 20 || minCoBorrowers != 3 {
+				t.Errorf("recomputed with size %d and %d co-borrowers, want 20 and 3", size, minCoBorrowers)
+			}
+			mu.Unlock()
+
+			// Run berikutnya bisa dimulai setelah run selesai
+			for deadline := time.Now().Add(time.Second); svc.running.Load(); {
+				if time.Now().After(deadline) {
+					t.Fatal("expected the run to be released")
+				}
+				time.Sleep(time.Millisecond)
+			}
+		})
+	}
+}
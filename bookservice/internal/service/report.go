@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/internal/dto"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/models"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/pagination"
+)
+
+var (
+	ErrInvalidReportPeriod = errors.New("from must not be after to")
+	ErrInvalidReportOrder  = errors.New("order must be asc or desc")
+)
+
+const (
+	defaultReportDays  = 30
+	defaultReportLimit = 10
+	maxReportLimit     = 1000
+)
+
+type ReportRepository interface {
+	Refresh(ctx context.Context) (bool, error)
+	RefreshedAt(ctx context.Context) (time.Time, error)
+	CirculationSummary(ctx context.Context, from, to time.Time, days int) (*models.CirculationSummary, error)
+	TopBooks(ctx context.Context, from, to time.Time, limit int) ([]models.BookLoans, error)
+	CategoryLoans(ctx context.Context, from, to time.Time) ([]models.CategoryLoans, error)
+	Utilisation(ctx context.Context, from, to time.Time, days int, ascending bool, limit int) ([]models.BookUtilisation, error)
+}
+
+type reportService struct {
+	reportRepo ReportRepository
+	ctgRepo    categoryRepository
+}
+
+func NewReportService(reportRepo ReportRepository, ctgRepo categoryRepository) *reportService {
+	return &reportService{
+		reportRepo: reportRepo,
+		ctgRepo:    ctgRepo,
+	}
+}
+
+// period is a report period of whole days, from up to but not including to.
+type period struct {
+	from, to time.Time
+	days     int
+}
+
+// CirculationReport sums up the loans started in the period of req.
+func (s *reportService) CirculationReport(ctx context.Context, req dto.ReportRequest) (*dto.CirculationReportResponse, error) {
+	p, header, err := s.period(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	summary, err := s.reportRepo.CirculationSummary(ctx, p.from, p.to, p.days)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.CirculationReportResponse{ReportPeriod: header, Summary: *summary}, nil
+}
+
+// TopBooksReport returns the books borrowed most in the period of req.
+func (s *reportService) TopBooksReport(ctx context.Context, req dto.ReportRequest) (*dto.TopBooksReportResponse, error) {
+	p, header, err := s.period(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	books, err := s.reportRepo.TopBooks(ctx, p.from, p.to, pagination.Limit(req.Limit, defaultReportLimit, maxReportLimit))
+	if err != nil {
+		return nil, err
+	}
+	return &dto.TopBooksReportResponse{ReportPeriod: header, Books: books}, nil
+}
+
+// CategoryReport returns the loans of the period of req per category, named
+// after bookcategoryservice. Names are left empty when it can't be reached
+// and nothing is cached, or the category was deleted.
+func (s *reportService) CategoryReport(ctx context.Context, req dto.ReportRequest) (*dto.CategoryReportResponse, error) {
+	p, header, err := s.period(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := s.reportRepo.CategoryLoans(ctx, p.from, p.to)
+	if err != nil {
+		return nil, err
+	}
+
+	all, err := s.ctgRepo.GetCategories(ctx)
+	if err != nil {
+		log.Printf("[Service - Reports] Error getting category names: %v", err)
+	}
+	names := make(map[string]string, len(all))
+	for _, c := range all {
+		names[c.Id] = c.Name
+	}
+	for i := range categories {
+		categories[i].Name = names[categories[i].CategoryID.String()]
+	}
+
+	return &dto.CategoryReportResponse{ReportPeriod: header, Categories: categories}, nil
+}
+
+// UtilisationReport returns the books whose copies spent the most of the
+// period of req on loan, or the least with order asc.
+func (s *reportService) UtilisationReport(ctx context.Context, req dto.ReportRequest) (*dto.UtilisationReportResponse, error) {
+	order := strings.ToLower(req.Order)
+	if order != "" && order != "asc" && order != "desc" {
+		return nil, ErrInvalidReportOrder
+	}
+
+	p, header, err := s.period(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	books, err := s.reportRepo.Utilisation(ctx, p.from, p.to, p.days, order == "asc", pagination.Limit(req.Limit, defaultReportLimit, maxReportLimit))
+	if err != nil {
+		return nil, err
+	}
+	return &dto.UtilisationReportResponse{ReportPeriod: header, Books: books}, nil
+}
+
+// Schedule refreshes the report views right away and then every interval
+// until ctx is done. A zero interval leaves the views as the migration
+// created them.
+func (s *reportService) Schedule(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.reportRepo.Refresh(ctx); err != nil {
+			log.Printf("[Service - Reports] Error refreshing reports: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// period resolves the period of req, the last 30 days up to today by
+// default, and the header of reports over it.
+func (s *reportService) period(ctx context.Context, req dto.ReportRequest) (period, dto.ReportPeriod, error) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	to := today
+	if req.To != nil {
+		to = *req.To
+	}
+	from := to.AddDate(0, 0, 1-defaultReportDays)
+	if req.From != nil {
+		from = *req.From
+	}
+	if from.After(to) {
+		return period{}, dto.ReportPeriod{}, ErrInvalidReportPeriod
+	}
+
+	refreshedAt, err := s.reportRepo.RefreshedAt(ctx)
+	if err != nil {
+		return period{}, dto.ReportPeriod{}, err
+	}
+
+	end := to.AddDate(0, 0, 1)
+	p := period{from: from, to: end, days: int(end.Sub(from) / (24 * time.Hour))}
+	return p, dto.ReportPeriod{
+		From:        from.Format(time.DateOnly),
+		To:          to.Format(time.DateOnly),
+		RefreshedAt: refreshedAt,
+	}, nil
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/internal/dto"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/models"
+	pb "github.com/sir-shalahuddin/grpc-learn/bookservice/proto/categoryservice"
+)
+
+// MockReportRepository adalah implementasi mock dari ReportRepository.
+// Method tanpa Func akan panic karena interface yang di-embed nil.
+type MockReportRepository struct {
+	ReportRepository
+	RefreshedAtFunc        func(ctx context.Context) (time.Time, error)
+	CirculationSummaryFunc func(ctx context.Context, from, to time.Time, days int) (*models.CirculationSummary, error)
+	TopBooksFunc           func(ctx context.Context, from, to time.Time, limit int) ([]models.BookLoans, error)
+	CategoryLoansFunc      func(ctx context.Context, from, to time.Time) ([]models.CategoryLoans, error)
+	UtilisationFunc        func(ctx context.Context, from, to time.Time, days int, ascending bool, limit int) ([]models.BookUtilisation, error)
+}
+
+func (m *MockReportRepository) RefreshedAt(ctx context.Context) (time.Time, error) {
+	return m.RefreshedAtFunc(ctx)
+}
+
+func (m *MockReportRepository) CirculationSummary(ctx context.Context, from, to time.Time, days int) (*models.CirculationSummary, error) {
+	return m.CirculationSummaryFunc(ctx, from, to, days)
+}
+
+func (m *MockReportRepository) TopBooks(ctx context.Context, from, to time.Time, limit int) ([]models.BookLoans, error) {
+	return m.TopBooksFunc(ctx, from, to, limit)
+}
+
+func (m *MockReportRepository) CategoryLoans(ctx context.Context, from, to time.Time) ([]models.CategoryLoans, error) {
+	return m.CategoryLoansFunc(ctx, from, to)
+}
+
+func (m *MockReportRepository) Utilisation(ctx context.Context, from, to time.Time, days int, ascending bool, limit int) ([]models.BookUtilisation, error) {
+	return m.UtilisationFunc(ctx, from, to, days, ascending, limit)
+}
+
+var reportRefreshedAt = time.Date(2024, 3, 31, 2, 0, 0, 0, time.UTC)
+
+// Test period: Periode mencakup kedua tanggal, dengan batas atas eksklusif
+// sehari setelah To, dan default 30 hari sampai hari ini
+func TestReportPeriod(t *testing.T) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	tests := []struct {
+		name     string
+		req      dto.ReportRequest
+		wantFrom time.Time
+		wantTo   time.Time
+		wantDays int
+		wantErr  error
+	}{
+		{"default", dto.ReportRequest{}, today.AddDate(0, 0, -29), today.AddDate(0, 0, 1), 30, nil},
+		{"month", dto.ReportRequest{From: day("2024-03-01"), To: day("2024-03-31")}, *day("2024-03-01"), *day("2024-04-01"), 31, nil},
+		{"single day", dto.ReportRequest{From: day("2024-02-29"), To: day("2024-02-29")}, *day("2024-02-29"), *day("2024-03-01"), 1, nil},
+		{"only to", dto.ReportRequest{To: day("2024-03-31")}, *day("2024-03-02"), *day("2024-04-01"), 30, nil},
+		{"only from", dto.ReportRequest{From: ptr(today.AddDate(0, 0, -6))}, today.AddDate(0, 0, -6), today.AddDate(0, 0, 1), 7, nil},
+		{"from after to", dto.ReportRequest{From: day("2024-04-01"), To: day("2024-03-31")}, time.Time{}, time.Time{}, 0, ErrInvalidReportPeriod},
+		{"only from in the future", dto.ReportRequest{From: ptr(today.AddDate(0, 0, 1))}, time.Time{}, time.Time{}, 0, ErrInvalidReportPeriod},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var from, to time.Time
+			var days int
+			repo := &MockReportRepository{
+				RefreshedAtFunc: func(ctx context.Context) (time.Time, error) {
+					return reportRefreshedAt, nil
+				},
+				CirculationSummaryFunc: func(ctx context.Context, f, e time.Time, d int) (*models.CirculationSummary, error) {
+					from, to, days = f, e, d
+					return &models.CirculationSummary{}, nil
+				},
+			}
+			svc := NewReportService(repo, nil)
+
+			res, err := svc.CirculationReport(context.Background(), tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CirculationReport() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if !from.Equal(tt.wantFrom) || !to.Equal(tt.wantTo) || days != tt.wantDays {
+				t.Errorf("period = %s to %s (%d days), want %s to %s (%d days)", from, to, days, tt.wantFrom, tt.wantTo, tt.wantDays)
+			}
+			// Header menampilkan hari terakhir yang termasuk
+			wantHeader := dto.ReportPeriod{
+				From:        tt.wantFrom.Format(time.DateOnly),
+				To:          tt.wantTo.AddDate(0, 0, -1).Format(time.DateOnly),
+				RefreshedAt: reportRefreshedAt,
+			}
+			if res.ReportPeriod != wantHeader {
+				t.Errorf("header = %+v, want %+v", res.ReportPeriod, wantHeader)
+			}
+		})
+	}
+}
+
+func ptr(t time.Time) *time.Time {
+	return &t
+}
+
+// Test UtilisationReport: Urutan dan jumlah buku yang diminta diteruskan,
+// urutan yang tidak dikenal ditolak
+func TestUtilisationReport_Order(t *testing.T) {
+	tests := []struct {
+		name          string
+		req           dto.ReportRequest
+		wantAscending bool
+		wantLimit     int
+		wantErr       error
+	}{
+		{"default", dto.ReportRequest{}, false, defaultReportLimit, nil},
+		{"least used", dto.ReportRequest{Order: "ASC", Limit: 50}, true, 50, nil},
+		{"most used", dto.ReportRequest{Order: "desc", Limit: 5000}, false, maxReportLimit, nil},
+		{"unknown order", dto.ReportRequest{Order: "random"}, false, 0, ErrInvalidReportOrder},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ascending bool
+			var limit int
+			repo := &MockReportRepository{
+				RefreshedAtFunc: func(ctx context.Context) (time.Time, error) {
+					return reportRefreshedAt, nil
+				},
+				UtilisationFunc: func(ctx context.Context, from, to time.Time, days int, asc bool, l int) ([]models.BookUtilisation, error) {
+					ascending, limit = asc, l
+					return nil, nil
+				},
+			}
+			svc := NewReportService(repo, nil)
+
+			_, err := svc.UtilisationReport(context.Background(), tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UtilisationReport() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (ascending != tt.wantAscending || limit != tt.wantLimit) {
+				t.Errorf("ascending = %v, limit = %d, want %v, %d", ascending, limit, tt.wantAscending, tt.wantLimit)
+			}
+		})
+	}
+}
+
+// Test CategoryReport: Kategori diberi nama dari bookcategoryservice, dan
+// tetap dilaporkan tanpa nama saat service itu tidak bisa dihubungi
+func TestCategoryReport_Names(t *testing.T) {
+	fiction, deleted := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name      string
+		ctgErr    error
+		wantNames []string
+	}{
+		{"named", nil, []string{"Fiction", ""}},
+		{"category service down", errors.New("unavailable"), []string{"", ""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &MockReportRepository{
+				RefreshedAtFunc: func(ctx context.Context) (time.Time, error) {
+					return reportRefreshedAt, nil
+				},
+				CategoryLoansFunc: func(ctx context.Context, from, to time.Time) ([]models.CategoryLoans, error) {
+					return []models.CategoryLoans{{CategoryID: fiction}, {CategoryID: deleted}}, nil
+				},
+			}
+			ctgRepo := &MockCategoryRepository{
+				GetCategoriesFunc: func(ctx context.Context) ([]*pb.CategoryResponse, error) {
+					if tt.ctgErr != nil {
+						return nil, tt.ctgErr
+					}
+					return []*pb.CategoryResponse{{Id: fiction.String(), Name: "Fiction"}}, nil
+				},
+			}
+			svc := NewReportService(repo, ctgRepo)
+
+			res, err := svc.CategoryReport(context.Background(), dto.ReportRequest{})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			for i, category := range res.Categories {
+				if category.Name != tt.wantNames[i] {
+					t.Errorf("category %d name = %q, want %q", i, category.Name, tt.wantNames[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/internal/dto"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/models"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/pagination"
+)
+
+var (
+	ErrReviewNotFound      = errors.New("review not found")
+	ErrReviewExists        = errors.New("you have already reviewed this book")
+	ErrReviewNotAllowed    = errors.New("only patrons who borrowed and returned this book can review it")
+	ErrNotReviewAuthor     = errors.New("only the author of a review can change it")
+	ErrInvalidRating       = errors.New("rating must be between 1 and 5")
+	ErrReviewTooLong       = fmt.Errorf("review text can't be longer than %d characters", maxReviewLength)
+	ErrInvalidReviewStatus = errors.New("status must be visible, flagged or hidden")
+)
+
+const (
+	maxReviewLength       = 5000
+	defaultReviewPageSize = 20
+	maxReviewPageSize     = 100
+)
+
+type ReviewRepository interface {
+	GetReview(ctx context.Context, id uuid.UUID) (*models.Review, error)
+	AddReview(ctx context.Context, review *models.Review) (bool, error)
+	UpdateReview(ctx context.Context, review *models.Review) error
+	ModerateReview(ctx context.Context, review *models.Review) error
+	DeleteReview(ctx context.Context, review *models.Review) error
+	DeleteUserReviews(ctx context.Context, userID uuid.UUID) (int64, error)
+	ListReviews(ctx context.Context, filter models.ReviewFilter) ([]models.Review, []string, error)
+}
+
+type reviewService struct {
+	reviewRepo ReviewRepository
+	bookRepo   BookRepository
+	recordRepo BorrowingRecordRepository
+}
+
+func NewReviewService(reviewRepo ReviewRepository, bookRepo BookRepository, recordRepo BorrowingRecordRepository) *reviewService {
+	return &reviewService{
+		reviewRepo: reviewRepo,
+		bookRepo:   bookRepo,
+		recordRepo: recordRepo,
+	}
+}
+
+// AddReview adds the user's review of a book. Only patrons who have returned
+// the book may review it, once.
+func (s *reviewService) AddReview(ctx context.Context, bookID, userID uuid.UUID, req dto.ReviewRequest) (*models.Review, error) {
+	text, err := checkReview(req)
+	if err != nil {
+		return nil, err
+	}
+
+	book, err := s.bookRepo.GetBookByID(ctx, bookID)
+	if err != nil {
+		return nil, err
+	}
+	if book == nil {
+		return nil, ErrBookNotFound
+	}
+
+	returned, err := s.recordRepo.HasReturnedBook(ctx, userID, bookID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check borrowing history: %w", err)
+	}
+	if !returned {
+		return nil, ErrReviewNotAllowed
+	}
+
+	review := &models.Review{
+		BookID: bookID,
+		UserID: userID,
+		Rating: req.Rating,
+		Text:   text,
+		Status: models.ReviewVisible,
+	}
+	added, err := s.reviewRepo.AddReview(ctx, review)
+	if err != nil {
+		return nil, err
+	}
+	if !added {
+		return nil, ErrReviewExists
+	}
+	return review, nil
+}
+
+// UpdateReview changes the rating and text of the user's own review. A
+// review keeps its moderation status.
+func (s *reviewService) UpdateReview(ctx context.Context, bookID, reviewID, userID uuid.UUID, req dto.ReviewRequest) (*models.Review, error) {
+	text, err := checkReview(req)
+	if err != nil {
+		return nil, err
+	}
+
+	review, err := s.getReview(ctx, bookID, reviewID)
+	if err != nil {
+		return nil, err
+	}
+	if review.UserID != userID {
+		return nil, ErrNotReviewAuthor
+	}
+
+	review.Rating = req.Rating
+	review.Text = text
+	if err := s.reviewRepo.UpdateReview(ctx, review); err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+// DeleteReview deletes the user's own review.
+func (s *reviewService) DeleteReview(ctx context.Context, bookID, reviewID, userID uuid.UUID) error {
+	review, err := s.getReview(ctx, bookID, reviewID)
+	if err != nil {
+		return err
+	}
+	if review.UserID != userID {
+		return ErrNotReviewAuthor
+	}
+	return s.reviewRepo.DeleteReview(ctx, review)
+}
+
+// ModerateReview sets the status of a review on behalf of a librarian.
+// Hiding a review takes it out of the book's rating.
+func (s *reviewService) ModerateReview(ctx context.Context, reviewID, moderatorID uuid.UUID, req dto.ModerateReviewRequest) (*models.Review, error) {
+	status := strings.ToLower(strings.TrimSpace(req.Status))
+	switch status {
+	case models.ReviewVisible, models.ReviewFlagged, models.ReviewHidden:
+	default:
+		return nil, ErrInvalidReviewStatus
+	}
+
+	review, err := s.getReview(ctx, uuid.Nil, reviewID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	review.Status = status
+	review.ModerationNote = strings.TrimSpace(req.Note)
+	review.ModeratedBy = &moderatorID
+	review.ModeratedAt = &now
+	if err := s.reviewRepo.ModerateReview(ctx, review); err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+// ListBookReviews lists a page of the reviews of a book for everyone, with
+// its rating. Hidden reviews and moderation notes are left out.
+func (s *reviewService) ListBookReviews(ctx context.Context, bookID uuid.UUID, req dto.ListReviewsRequest) (*dto.ListReviewsResponse, error) {
+	book, err := s.bookRepo.GetBookByID(ctx, bookID)
+	if err != nil {
+		return nil, err
+	}
+	if book == nil {
+		return nil, ErrBookNotFound
+	}
+
+	page, err := s.listReviews(ctx, models.ReviewFilter{
+		BookID:   bookID,
+		Statuses: []string{models.ReviewVisible, models.ReviewFlagged},
+	}, req)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range page.Reviews {
+		page.Reviews[i].Status = ""
+		page.Reviews[i].ModerationNote = ""
+		page.Reviews[i].ModeratedBy = nil
+		page.Reviews[i].ModeratedAt = nil
+	}
+	page.Rating = &dto.BookRating{Average: book.RatingAverage, Count: book.RatingCount}
+	return page, nil
+}
+
+// ListReviews lists a page of reviews of any status for librarians, such as
+// the flagged ones waiting for a decision.
+func (s *reviewService) ListReviews(ctx context.Context, req dto.ListReviewsRequest) (*dto.ListReviewsResponse, error) {
+	filter := models.ReviewFilter{BookID: req.BookID}
+	if req.Status != "" {
+		switch req.Status {
+		case models.ReviewVisible, models.ReviewFlagged, models.ReviewHidden:
+		default:
+			return nil, ErrInvalidReviewStatus
+		}
+		filter.Statuses = []string{req.Status}
+	}
+	return s.listReviews(ctx, filter, req)
+}
+
+func (s *reviewService) listReviews(ctx context.Context, filter models.ReviewFilter, req dto.ListReviewsRequest) (*dto.ListReviewsResponse, error) {
+	after, err := pagination.Decode(req.Cursor, "newest")
+	if err != nil {
+		return nil, err
+	}
+	filter.After = after
+	filter.Limit = pagination.Limit(req.PageSize, defaultReviewPageSize, maxReviewPageSize)
+
+	reviews, nextKeys, err := s.reviewRepo.ListReviews(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.ListReviewsResponse{
+		Reviews:    reviews,
+		NextCursor: pagination.Encode("newest", nextKeys),
+	}, nil
+}
+
+// EraseUserReviews deletes every review of the user, updating the ratings
+// of the books they reviewed.
+func (s *reviewService) EraseUserReviews(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return s.reviewRepo.DeleteUserReviews(ctx, userID)
+}
+
+// getReview returns a review, which must be of the book unless bookID is
+// uuid.Nil.
+func (s *reviewService) getReview(ctx context.Context, bookID, reviewID uuid.UUID) (*models.Review, error) {
+	review, err := s.reviewRepo.GetReview(ctx, reviewID)
+	if err != nil {
+		return nil, err
+	}
+	if review == nil || (bookID != uuid.Nil && review.BookID != bookID) {
+		return nil, ErrReviewNotFound
+	}
+	return review, nil
+}
+
+// checkReview validates the rating and text of a review and returns the
+// trimmed text.
+func checkReview(req dto.ReviewRequest) (string, error) {
+	if req.Rating < 1 || req.Rating > 5 {
+		return "", ErrInvalidRating
+	}
+	text := strings.TrimSpace(req.Text)
+	if len([]rune(text)) > maxReviewLength {
+		return "", ErrReviewTooLong
+	}
+	return text, nil
+}
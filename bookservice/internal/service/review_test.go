@@ -0,0 +1,292 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/internal/dto"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/models"
+)
+
+// MockReviewRepository adalah implementasi mock dari ReviewRepository.
+// Method tanpa Func akan panic karena interface yang di-embed nil.
+type MockReviewRepository struct {
+	ReviewRepository
+	GetReviewFunc      func(ctx context.Context, id uuid.UUID) (*models.Review, error)
+	AddReviewFunc      func(ctx context.Context, review *models.Review) (bool, error)
+	UpdateReviewFunc   func(ctx context.Context, review *models.Review) error
+	ModerateReviewFunc func(ctx context.Context, review *models.Review) error
+	DeleteReviewFunc   func(ctx context.Context, review *models.Review) error
+	ListReviewsFunc    func(ctx context.Context, filter models.ReviewFilter) ([]models.Review, []string, error)
+}
+
+func (m *MockReviewRepository) GetReview(ctx context.Context, id uuid.UUID) (*models.Review, error) {
+	return m.GetReviewFunc(ctx, id)
+}
+
+func (m *MockReviewRepository) AddReview(ctx context.Context, review *models.Review) (bool, error) {
+	return m.AddReviewFunc(ctx, review)
+}
+
+func (m *MockReviewRepository) UpdateReview(ctx context.Context, review *models.Review) error {
+	return m.UpdateReviewFunc(ctx, review)
+}
+
+func (m *MockReviewRepository) ModerateReview(ctx context.Context, review *models.Review) error {
+	return m.ModerateReviewFunc(ctx, review)
+}
+
+func (m *MockReviewRepository) DeleteReview(ctx context.Context, review *models.Review) error {
+	return m.DeleteReviewFunc(ctx, review)
+}
+
+func (m *MockReviewRepository) ListReviews(ctx context.Context, filter models.ReviewFilter) ([]models.Review, []string, error) {
+	return m.ListReviewsFunc(ctx, filter)
+}
+
+// MockBorrowingRecordRepository adalah implementasi mock dari
+// BorrowingRecordRepository. Method tanpa Func akan panic karena interface
+// yang di-embed nil.
+type MockBorrowingRecordRepository struct {
+	BorrowingRecordRepository
+	GetBorrowingRecordByIDFunc func(ctx context.Context, id uuid.UUID) (*models.BorrowingRecord, error)
+	UpdateBorrowingRecordFunc  func(ctx context.Context, tx *sql.Tx, record *models.BorrowingRecord) error
+	HasReturnedBookFunc        func(ctx context.Context, userID, bookID uuid.UUID) (bool, error)
+}
+
+func (m *MockBorrowingRecordRepository) GetBorrowingRecordByID(ctx context.Context, id uuid.UUID) (*models.BorrowingRecord, error) {
+	return m.GetBorrowingRecordByIDFunc(ctx, id)
+}
+
+func (m *MockBorrowingRecordRepository) UpdateBorrowingRecord(ctx context.Context, tx *sql.Tx, record *models.BorrowingRecord) error {
+	return m.UpdateBorrowingRecordFunc(ctx, tx, record)
+}
+
+func (m *MockBorrowingRecordRepository) HasReturnedBook(ctx context.Context, userID, bookID uuid.UUID) (bool, error) {
+	return m.HasReturnedBookFunc(ctx, userID, bookID)
+}
+
+// bookWithID mengembalikan repository buku yang hanya berisi buku id.
+func bookWithID(id uuid.UUID, book *models.Book) *MockBookRepository {
+	return &MockBookRepository{
+		GetBookByIDFunc: func(ctx context.Context, bookID uuid.UUID) (*models.Book, error) {
+			if bookID != id {
+				return nil, nil
+			}
+			return book, nil
+		},
+	}
+}
+
+// Test AddReview: Hanya patron yang sudah mengembalikan buku yang bisa
+// mereview, sekali per buku
+func TestAddReview(t *testing.T) {
+	bookID, userID := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name     string
+		bookID   uuid.UUID
+		req      dto.ReviewRequest
+		returned bool
+		exists   bool
+		wantErr  error
+		wantAdd  bool
+	}{
+		{"returned", bookID, dto.ReviewRequest{Rating: 5, Text: "  Loved it  "}, true, false, nil, true},
+		{"still borrowed or never borrowed", bookID, dto.ReviewRequest{Rating: 5}, false, false, ErrReviewNotAllowed, false},
+		{"second review", bookID, dto.ReviewRequest{Rating: 3}, true, true, ErrReviewExists, true},
+		{"missing book", uuid.New(), dto.ReviewRequest{Rating: 3}, true, false, ErrBookNotFound, false},
+		{"rating too low", bookID, dto.ReviewRequest{Rating: 0}, true, false, ErrInvalidRating, false},
+		{"rating too high", bookID, dto.ReviewRequest{Rating: 6}, true, false, ErrInvalidRating, false},
+		{"text too long", bookID, dto.ReviewRequest{Rating: 4, Text: strings.Repeat("é", maxReviewLength+1)}, true, false, ErrReviewTooLong, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var added *models.Review
+			reviewRepo := &MockReviewRepository{
+				AddReviewFunc: func(ctx context.Context, review *models.Review) (bool, error) {
+					added = review
+					return !tt.exists, nil
+				},
+			}
+			recordRepo := &MockBorrowingRecordRepository{
+				HasReturnedBookFunc: func(ctx context.Context, u, b uuid.UUID) (bool, error) {
+					if u != userID || b != bookID {
+						t.Errorf("checked the history of %s for %s", u, b)
+					}
+					return tt.returned, nil
+				},
+			}
+			svc := NewReviewService(reviewRepo, bookWithID(bookID, &models.Book{ID: bookID}), recordRepo)
+
+			review, err := svc.AddReview(context.Background(), tt.bookID, userID, tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("AddReview() error = %v, want %v", err, tt.wantErr)
+			}
+			if (added != nil) != tt.wantAdd {
+				t.Errorf("review added = %v, want %v", added != nil, tt.wantAdd)
+			}
+			if err == nil && (review.Text != "Loved it" || review.Status != models.ReviewVisible || review.UserID != userID) {
+				t.Errorf("review = %+v, want a visible review with trimmed text by the user", review)
+			}
+		})
+	}
+}
+
+// Test UpdateReview dan DeleteReview: Hanya penulis yang bisa mengubah
+// reviewnya, dan review harus milik buku di path
+func TestReview_OwnerOnly(t *testing.T) {
+	bookID, ownerID := uuid.New(), uuid.New()
+	reviewID := uuid.New()
+
+	tests := []struct {
+		name     string
+		bookID   uuid.UUID
+		reviewID uuid.UUID
+		userID   uuid.UUID
+		wantErr  error
+	}{
+		{"owner", bookID, reviewID, ownerID, nil},
+		{"someone else", bookID, reviewID, uuid.New(), ErrNotReviewAuthor},
+		{"other book", uuid.New(), reviewID, ownerID, ErrReviewNotFound},
+		{"missing review", bookID, uuid.New(), ownerID, ErrReviewNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changed := 0
+			reviewRepo := &MockReviewRepository{
+				GetReviewFunc: func(ctx context.Context, id uuid.UUID) (*models.Review, error) {
+					if id != reviewID {
+						return nil, nil
+					}
+					return &models.Review{ID: reviewID, BookID: bookID, UserID: ownerID, Rating: 2, Status: models.ReviewFlagged}, nil
+				},
+				UpdateReviewFunc: func(ctx context.Context, review *models.Review) error {
+					changed++
+					if review.Status != models.ReviewFlagged {
+						t.Errorf("status = %s, want the review to stay flagged", review.Status)
+					}
+					return nil
+				},
+				DeleteReviewFunc: func(ctx context.Context, review *models.Review) error {
+					changed++
+					return nil
+				},
+			}
+			svc := NewReviewService(reviewRepo, nil, nil)
+
+			if _, err := svc.UpdateReview(context.Background(), tt.bookID, tt.reviewID, tt.userID, dto.ReviewRequest{Rating: 4}); !errors.Is(err, tt.wantErr) {
+				t.Errorf("UpdateReview() error = %v, want %v", err, tt.wantErr)
+			}
+			if err := svc.DeleteReview(context.Background(), tt.bookID, tt.reviewID, tt.userID); !errors.Is(err, tt.wantErr) {
+				t.Errorf("DeleteReview() error = %v, want %v", err, tt.wantErr)
+			}
+			want := 0
+			if tt.wantErr == nil {
+				want = 2
+			}
+			if changed != want {
+				t.Errorf("changed %d times, want %d", changed, want)
+			}
+		})
+	}
+}
+
+// Test ModerateReview: Status dicatat bersama moderator, status yang tidak
+// dikenal ditolak
+func TestModerateReview(t *testing.T) {
+	reviewID, moderatorID := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name    string
+		req     dto.ModerateReviewRequest
+		want    string
+		wantErr error
+	}{
+		{"hide", dto.ModerateReviewRequest{Status: " Hidden ", Note: " spoilers "}, models.ReviewHidden, nil},
+		{"unhide", dto.ModerateReviewRequest{Status: "visible"}, models.ReviewVisible, nil},
+		{"flag", dto.ModerateReviewRequest{Status: "flagged"}, models.ReviewFlagged, nil},
+		{"unknown status", dto.ModerateReviewRequest{Status: "deleted"}, "", ErrInvalidReviewStatus},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var moderated *models.Review
+			reviewRepo := &MockReviewRepository{
+				GetReviewFunc: func(ctx context.Context, id uuid.UUID) (*models.Review, error) {
+					return &models.Review{ID: id, BookID: uuid.New(), Status: models.ReviewFlagged}, nil
+				},
+				ModerateReviewFunc: func(ctx context.Context, review *models.Review) error {
+					moderated = review
+					return nil
+				},
+			}
+			svc := NewReviewService(reviewRepo, nil, nil)
+
+			_, err := svc.ModerateReview(context.Background(), reviewID, moderatorID, tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ModerateReview() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if moderated != nil {
+					t.Error("expected the review to be left alone")
+				}
+				return
+			}
+
+			if moderated.Status != tt.want || moderated.ModerationNote != strings.TrimSpace(tt.req.Note) {
+				t.Errorf("review = %s %q, want %s %q", moderated.Status, moderated.ModerationNote, tt.want, strings.TrimSpace(tt.req.Note))
+			}
+			if moderated.ModeratedBy == nil || *moderated.ModeratedBy != moderatorID || moderated.ModeratedAt == nil {
+				t.Errorf("expected the moderator and time to be recorded, got %v at %v", moderated.ModeratedBy, moderated.ModeratedAt)
+			}
+		})
+	}
+}
+
+// Test ListBookReviews: Review tersembunyi dan catatan moderasi tidak
+// ditampilkan ke publik, bersama rating buku
+func TestListBookReviews_Public(t *testing.T) {
+	bookID, moderatorID := uuid.New(), uuid.New()
+	book := &models.Book{ID: bookID, RatingAverage: 4.5, RatingCount: 2}
+
+	var filter models.ReviewFilter
+	reviewRepo := &MockReviewRepository{
+		ListReviewsFunc: func(ctx context.Context, f models.ReviewFilter) ([]models.Review, []string, error) {
+			filter = f
+			return []models.Review{
+				{ID: uuid.New(), BookID: bookID, Rating: 5, Status: models.ReviewVisible},
+				{ID: uuid.New(), BookID: bookID, Rating: 4, Status: models.ReviewFlagged, ModerationNote: "checking", ModeratedBy: &moderatorID},
+			}, nil, nil
+		},
+	}
+	svc := NewReviewService(reviewRepo, bookWithID(bookID, book), nil)
+
+	res, err := svc.ListBookReviews(context.Background(), bookID, dto.ListReviewsRequest{})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if filter.BookID != bookID || strings.Join(filter.Statuses, ",") != "visible,flagged" {
+		t.Errorf("filter = %+v, want the visible and flagged reviews of the book", filter)
+	}
+	for _, review := range res.Reviews {
+		if review.Status != "" || review.ModerationNote != "" || review.ModeratedBy != nil {
+			t.Errorf("review %s shows moderation details: %+v", review.ID, review)
+		}
+	}
+	if res.Rating == nil || res.Rating.Average != 4.5 || res.Rating.Count != 2 {
+		t.Errorf("rating = %+v, want 4.5 from 2 reviews", res.Rating)
+	}
+
+	if _, err := svc.ListBookReviews(context.Background(), uuid.New(), dto.ListReviewsRequest{}); !errors.Is(err, ErrBookNotFound) {
+		t.Errorf("expected ErrBookNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,40 @@
+package setup
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/config"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/internal/repository"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/internal/server"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/internal/service"
+	pb "github.com/sir-shalahuddin/grpc-learn/bookservice/proto/bookservice"
+	categoryservice "github.com/sir-shalahuddin/grpc-learn/bookservice/proto/categoryservice"
+	"google.golang.org/grpc"
+)
+
+func GRPCServer(grpc *grpc.Server, db *sql.DB, ctgSvc categoryservice.BookCategoryServiceClient, cacheConfig config.CacheConfig) {
+	// Initialize repositories, services, and servers
+	bookRepo := repository.NewBookRepository(db)
+	ctgRepo := repository.NewCategoryRepository(ctgSvc, cacheConfig.CategoryTTL)
+	go ctgRepo.Watch(context.Background())
+	// Books are only added over REST, so metadata lookups stay off
+	metadataRepo := repository.NewMetadataRepository(nil, nil, 0, 0)
+	authorRepo := repository.NewAuthorRepository(db)
+	bookService := service.NewBookService(bookRepo, ctgRepo, metadataRepo, authorRepo)
+	recordRepo := repository.NewBorrowingRecordRepository(db)
+	txRepo := repository.NewTxRepository(db)
+	listRepo := repository.NewReadingListRepository(db)
+	// Staff record searches are served over REST only; without an auth client
+	// records are left without their patrons
+	recordService := service.NewBorrowingRecordService(recordRepo, txRepo, bookRepo, listRepo, nil)
+	reviewService := service.NewReviewService(repository.NewReviewRepository(db), bookRepo, recordRepo)
+	listService := service.NewReadingListService(listRepo, bookRepo)
+	// Recomputing is left to the REST instances
+	recommendationService := service.NewRecommendationService(repository.NewRecommendationRepository(db), bookRepo, 0, 0)
+	bookServer := server.NewBookGRPCServer(bookService, recordService, reviewService, listService, recommendationService)
+
+	// Register BookService routes
+	pb.RegisterBookServiceServer(grpc, bookServer)
+
+}
@@ -0,0 +1,9 @@
+-- 'simple' keeps words as written, so names and ISBNs match exactly and
+-- prefix queries work in any language
+ALTER TABLE books ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', coalesce(title, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(author, '')), 'B') ||
+    setweight(to_tsvector('simple', coalesce(isbn, '')), 'C')
+) STORED;
+
+CREATE INDEX idx_books_search_vector ON books USING GIN (search_vector);
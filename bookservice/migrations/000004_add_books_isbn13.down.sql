@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS idx_books_search_vector;
+ALTER TABLE books DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE books ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', coalesce(title, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(author, '')), 'B') ||
+    setweight(to_tsvector('simple', coalesce(isbn, '')), 'C')
+) STORED;
+CREATE INDEX idx_books_search_vector ON books USING GIN (search_vector);
+
+DROP INDEX IF EXISTS idx_books_isbn13;
+ALTER TABLE books DROP COLUMN IF EXISTS isbn13;
+DROP FUNCTION IF EXISTS isbn_to_13(TEXT);
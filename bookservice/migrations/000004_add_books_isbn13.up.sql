@@ -0,0 +1,67 @@
+-- isbn_to_13 returns the ISBN-13 form of a valid ISBN-10 or ISBN-13, without
+-- hyphens or spaces, and NULL for anything else. It mirrors pkg/isbn so rows
+-- written before the canonical column existed can be backfilled.
+CREATE FUNCTION isbn_to_13(raw TEXT) RETURNS TEXT AS $$
+DECLARE
+    s TEXT := upper(regexp_replace(coalesce(raw, ''), '[-\s]', '', 'g'));
+    body TEXT;
+    total INT := 0;
+BEGIN
+    IF s ~ '^[0-9]{9}[0-9X]$' THEN
+        FOR i IN 1..10 LOOP
+            total := total + (11 - i) * CASE WHEN substr(s, i, 1) = 'X' THEN 10 ELSE substr(s, i, 1)::INT END;
+        END LOOP;
+        IF total % 11 <> 0 THEN
+            RETURN NULL;
+        END IF;
+        body := '978' || substr(s, 1, 9);
+    ELSIF s ~ '^[0-9]{13}$' THEN
+        body := substr(s, 1, 12);
+    ELSE
+        RETURN NULL;
+    END IF;
+
+    total := 0;
+    FOR i IN 1..12 LOOP
+        total := total + CASE WHEN i % 2 = 0 THEN 3 ELSE 1 END * substr(body, i, 1)::INT;
+    END LOOP;
+    body := body || ((10 - total % 10) % 10)::TEXT;
+
+    IF length(s) = 13 AND body <> s THEN
+        RETURN NULL;
+    END IF;
+    RETURN body;
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+ALTER TABLE books ADD COLUMN isbn13 VARCHAR(13);
+
+UPDATE books SET isbn13 = isbn_to_13(isbn) WHERE COALESCE(isbn, '') <> '';
+
+-- Books sharing an ISBN have to be merged or corrected before the index can
+-- be built; list them instead of failing on the first one.
+DO $$
+DECLARE
+    duplicates TEXT;
+BEGIN
+    SELECT string_agg(isbn13, ', ') INTO duplicates
+    FROM (SELECT isbn13 FROM books WHERE isbn13 IS NOT NULL GROUP BY isbn13 HAVING count(*) > 1) d;
+    IF duplicates IS NOT NULL THEN
+        RAISE EXCEPTION 'books share these ISBNs, fix them before migrating: %', duplicates;
+    END IF;
+END;
+$$;
+
+-- One book per ISBN, in either form, even when two are saved at once
+CREATE UNIQUE INDEX idx_books_isbn13 ON books (isbn13) WHERE isbn13 IS NOT NULL;
+
+-- Search by either form of a book's ISBN
+DROP INDEX idx_books_search_vector;
+ALTER TABLE books DROP COLUMN search_vector;
+ALTER TABLE books ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', coalesce(title, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(author, '')), 'B') ||
+    setweight(to_tsvector('simple', coalesce(isbn, '') || ' ' || coalesce(isbn13, '')), 'C')
+) STORED;
+
+CREATE INDEX idx_books_search_vector ON books USING GIN (search_vector);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS book_import_rows;
+DROP TABLE IF EXISTS book_imports;
@@ -0,0 +1,27 @@
+CREATE TABLE book_imports (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    format VARCHAR(8) NOT NULL,
+    dry_run BOOLEAN NOT NULL DEFAULT FALSE,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    processed INT NOT NULL DEFAULT 0,
+    succeeded INT NOT NULL DEFAULT 0,
+    failed INT NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    created_by UUID,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP,
+    -- Last progress of an import. Imports left pending or running without
+    -- progress for a while were abandoned by an instance that stopped.
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE book_import_rows (
+    import_id UUID REFERENCES book_imports(id) ON DELETE CASCADE,
+    row_number INT NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    book_id UUID,
+    title VARCHAR(255) NOT NULL DEFAULT '',
+    isbn VARCHAR(32) NOT NULL DEFAULT '',
+    message TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (import_id, row_number)
+);
@@ -0,0 +1,35 @@
+CREATE TABLE authors (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_authors_lower_name ON authors (lower(name));
+
+CREATE TABLE book_authors (
+    book_id UUID NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    author_id UUID NOT NULL REFERENCES authors(id) ON DELETE RESTRICT,
+    role VARCHAR(16) NOT NULL DEFAULT 'author',
+    position INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (book_id, author_id, role)
+);
+
+CREATE INDEX idx_book_authors_author_id ON book_authors (author_id);
+
+CREATE TABLE author_aliases (
+    alias_id UUID PRIMARY KEY,
+    target_id UUID NOT NULL REFERENCES authors(id) ON DELETE CASCADE,
+    merged_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Every distinct author name becomes an author, spelled as first seen
+INSERT INTO authors (name)
+SELECT DISTINCT ON (lower(trim(author))) trim(author)
+FROM books
+WHERE trim(COALESCE(author, '')) <> ''
+ORDER BY lower(trim(author)), created_at;
+
+INSERT INTO book_authors (book_id, author_id, role, position)
+SELECT b.id, a.id, 'author', 0
+FROM books b
+JOIN authors a ON lower(a.name) = lower(trim(b.author));
@@ -0,0 +1,7 @@
+CREATE TABLE book_covers (
+    book_id UUID PRIMARY KEY REFERENCES books(id) ON DELETE CASCADE,
+    content_type VARCHAR(32) NOT NULL,
+    width INT NOT NULL,
+    height INT NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
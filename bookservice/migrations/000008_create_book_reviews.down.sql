@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS book_reviews;
+
+DROP INDEX IF EXISTS idx_books_rating;
+ALTER TABLE books DROP COLUMN IF EXISTS rating_average, DROP COLUMN IF EXISTS rating_count;
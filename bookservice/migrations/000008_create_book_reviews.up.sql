@@ -0,0 +1,25 @@
+-- Kept up to date with every review written, so books can be sorted by rating
+ALTER TABLE books
+    ADD COLUMN rating_count INT NOT NULL DEFAULT 0,
+    ADD COLUMN rating_average NUMERIC(3, 2) NOT NULL DEFAULT 0;
+
+CREATE INDEX idx_books_rating ON books (rating_average, rating_count, id);
+
+CREATE TABLE book_reviews (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    book_id UUID NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    body TEXT NOT NULL DEFAULT '',
+    status VARCHAR(16) NOT NULL DEFAULT 'visible',
+    moderation_note TEXT NOT NULL DEFAULT '',
+    moderated_by UUID,
+    moderated_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (book_id, user_id)
+);
+
+CREATE INDEX idx_book_reviews_book ON book_reviews (book_id, created_at, id);
+CREATE INDEX idx_book_reviews_status ON book_reviews (status, created_at, id);
+CREATE INDEX idx_book_reviews_user ON book_reviews (user_id);
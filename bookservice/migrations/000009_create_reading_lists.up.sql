@@ -0,0 +1,35 @@
+CREATE TABLE reading_lists (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    is_public BOOLEAN NOT NULL DEFAULT FALSE,
+    slug VARCHAR(32) UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_reading_lists_user ON reading_lists (user_id);
+
+CREATE TABLE reading_list_items (
+    list_id UUID NOT NULL REFERENCES reading_lists(id) ON DELETE CASCADE,
+    book_id UUID NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    position INT NOT NULL DEFAULT 0,
+    notify BOOLEAN NOT NULL DEFAULT FALSE,
+    added_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (list_id, book_id)
+);
+
+-- Finds who is waiting for a book when a copy comes back
+CREATE INDEX idx_reading_list_items_notify ON reading_list_items (book_id) WHERE notify;
+
+CREATE TABLE book_notifications (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL,
+    book_id UUID NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    kind VARCHAR(32) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    read_at TIMESTAMP
+);
+
+CREATE INDEX idx_book_notifications_user ON book_notifications (user_id, created_at, id);
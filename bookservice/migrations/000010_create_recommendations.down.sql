@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS recommendation_runs;
+DROP TABLE IF EXISTS popular_books;
+DROP TABLE IF EXISTS user_recommendations;
+DROP TABLE IF EXISTS book_similarities;
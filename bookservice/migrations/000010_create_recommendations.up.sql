@@ -0,0 +1,44 @@
+-- Rebuilt by every recommendation run; requests only read them
+CREATE TABLE book_similarities (
+    book_id UUID NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    similar_book_id UUID NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    score REAL NOT NULL,
+    co_borrowers INT NOT NULL,
+    PRIMARY KEY (book_id, similar_book_id)
+);
+
+CREATE INDEX idx_book_similarities_score ON book_similarities (book_id, score DESC);
+
+CREATE TABLE user_recommendations (
+    user_id UUID NOT NULL,
+    book_id UUID NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    score REAL NOT NULL,
+    PRIMARY KEY (user_id, book_id)
+);
+
+CREATE INDEX idx_user_recommendations_score ON user_recommendations (user_id, score DESC);
+
+-- Rank of every book within its category by borrowings, for cold starts
+CREATE TABLE popular_books (
+    book_id UUID PRIMARY KEY REFERENCES books(id) ON DELETE CASCADE,
+    category_id UUID,
+    borrows INT NOT NULL,
+    category_rank INT NOT NULL
+);
+
+CREATE INDEX idx_popular_books_category ON popular_books (category_id, category_rank);
+CREATE INDEX idx_popular_books_borrows ON popular_books (borrows DESC);
+
+CREATE TABLE recommendation_runs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    trigger VARCHAR(16) NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'running',
+    requested_by UUID,
+    similarities INT NOT NULL DEFAULT 0,
+    recommendations INT NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    started_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP
+);
+
+CREATE INDEX idx_recommendation_runs_started ON recommendation_runs (started_at DESC);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS report_refreshes;
+DROP MATERIALIZED VIEW IF EXISTS book_loan_days;
+DROP MATERIALIZED VIEW IF EXISTS circulation_daily;
+DROP INDEX IF EXISTS idx_borrowing_records_overdue;
+DROP INDEX IF EXISTS idx_borrowing_records_borrowed_at;
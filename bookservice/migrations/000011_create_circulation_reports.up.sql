@@ -0,0 +1,32 @@
+CREATE INDEX idx_borrowing_records_borrowed_at ON borrowing_records (borrowed_at);
+CREATE INDEX idx_borrowing_records_overdue ON borrowing_records (due_date) WHERE returned_at IS NULL;
+
+-- Loans per book by the day they started, with what became of them
+CREATE MATERIALIZED VIEW circulation_daily AS
+SELECT borrowed_at::date AS day, book_id,
+    COUNT(*) AS loans,
+    COUNT(returned_at) AS returned,
+    COUNT(*) FILTER (WHERE returned_at > due_date) AS returned_late,
+    COALESCE(SUM(EXTRACT(EPOCH FROM returned_at - borrowed_at)), 0)::float8 AS returned_seconds
+FROM borrowing_records
+WHERE book_id IS NOT NULL AND borrowed_at IS NOT NULL
+GROUP BY 1, 2;
+
+-- Copies of each book out on loan each day, for stock utilisation
+CREATE MATERIALIZED VIEW book_loan_days AS
+SELECT d::date AS day, r.book_id, COUNT(*) AS copies_out
+FROM borrowing_records r
+CROSS JOIN LATERAL generate_series(r.borrowed_at::date, COALESCE(r.returned_at, CURRENT_TIMESTAMP)::date, interval '1 day') AS d
+WHERE r.book_id IS NOT NULL AND r.borrowed_at IS NOT NULL
+GROUP BY 1, 2;
+
+-- Unique indexes let the views be refreshed concurrently
+CREATE UNIQUE INDEX idx_circulation_daily ON circulation_daily (day, book_id);
+CREATE UNIQUE INDEX idx_book_loan_days ON book_loan_days (day, book_id);
+
+CREATE TABLE report_refreshes (
+    name VARCHAR(64) PRIMARY KEY,
+    refreshed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO report_refreshes (name) VALUES ('circulation');
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Roles a contributor can have on a book.
+const (
+	RoleAuthor      = "author"
+	RoleEditor      = "editor"
+	RoleTranslator  = "translator"
+	RoleIllustrator = "illustrator"
+)
+
+// Author is a person credited on books in any role.
+type Author struct {
+	ID        uuid.UUID  `json:"id"`
+	Name      string     `json:"name"`
+	BookCount int64      `json:"book_count"` // Number of books the author is credited on
+	CreatedAt *time.Time `json:"created_at"`
+}
+
+// BookContributor credits an author on a book in a role. Contributors are
+// listed by Position; a book's Author field holds the names of those with the
+// author role, in that order.
+type BookContributor struct {
+	AuthorID uuid.UUID `json:"author_id"`
+	Name     string    `json:"name"`
+	Role     string    `json:"role"`
+	Position int       `json:"position"`
+}
+
+// AuthorFilter selects the authors AuthorRepository.ListAuthors returns.
+// After holds the sort keys of the author the page starts after, from a
+// cursor.
+type AuthorFilter struct {
+	Name  string
+	After []string
+	Limit int
+}
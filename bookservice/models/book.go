@@ -1,16 +1,22 @@
 package models
 
 import (
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// ErrDuplicateISBN is returned when a book is saved with the ISBN of another
+// book, in either ISBN form.
+var ErrDuplicateISBN = errors.New("another book has this ISBN")
+
 type Book struct {
 	ID            uuid.UUID  `json:"id"`             // Unique identifier for the book
 	Title         string     `json:"title"`          // Title of the book
 	Author        string     `json:"author"`         // Author of the book
-	ISBN          string     `json:"isbn"`           // ISBN number of the book
+	ISBN          string     `json:"isbn"`           // ISBN number of the book, as entered
+	ISBN13        string     `json:"isbn13"`         // ISBN-13 form of ISBN, empty when the book has none
 	PublishedDate *time.Time `json:"published_date"` // Date when the book was published
 	CategoryID    uuid.UUID  `json:"category_id"`    // ID of the category the book belongs to
 	Stock         int        `json:"stock"`          // Number of copies available
@@ -18,6 +24,43 @@ type Book struct {
 	CreatedAt     *time.Time `json:"created_at"`     // Timestamp when the book was created
 	UpdatedAt     *time.Time `json:"updated_at"`     // Timestamp when the book was last updated
 	Version       int        `json:"version"`
+	RatingAverage float64    `json:"rating_average"` // Average rating of its reviews, hidden ones left out
+	RatingCount   int        `json:"rating_count"`   // Number of reviews counted in RatingAverage
+	// Only set where contributors are loaded or written; nil leaves them as they are
+	Contributors []BookContributor `json:"contributors,omitempty"`
+	// Set by full-text searches only
+	Rank      float32        `json:"rank,omitempty"`      // Relevance to the search query
+	Highlight *BookHighlight `json:"highlight,omitempty"` // Matches marked in the title and author
+}
+
+// BookHighlight holds the HTML-escaped title and author of a book with the
+// words matching a search wrapped in <mark> tags.
+type BookHighlight struct {
+	Title  string `json:"title"`
+	Author string `json:"author"`
+}
+
+// BookFilter selects the books BookRepository.ListBooks returns. A book
+// matches CategoryIDs if it is in any of them; an empty list matches every
+// category. Query is a full-text search that also orders books by relevance
+// when Sort is empty. Author matches the name of any contributor, while
+// AuthorID selects the books of one author, in AuthorRole when given. Nil
+// pointers leave their filter out. After holds the sort keys of the book the
+// page starts after, from a cursor.
+type BookFilter struct {
+	Title         string
+	Author        string
+	AuthorID      uuid.UUID
+	AuthorRole    string
+	Query         string
+	CategoryIDs   []string
+	Available     *bool // stock > 0
+	PublishedFrom *time.Time
+	PublishedTo   *time.Time
+	HasISBN       *bool
+	Sort          string
+	After         []string
+	Limit         int
 }
 
 // BorrowingRecord represents a record of a book borrowed by a user.
@@ -28,9 +71,93 @@ type BorrowingRecord struct {
 	BorrowedAt *time.Time `json:"borrowed_at"` // Timestamp when the book was borrowed
 	DueDate    *time.Time `json:"due_date"`    // Due date for returning the borrowed book
 	ReturnedAt *time.Time `json:"returned_at"` // Timestamp when the book was returned (if applicable)
+	// Staff listings only
+	Patron *Patron `json:"patron,omitempty"` // Who borrowed the book, nil once they erased their data
+}
+
+// Patron is the user behind a borrowing record, as userservice knows them.
+type Patron struct {
+	ID    uuid.UUID `json:"id"`
+	Name  string    `json:"name"`
+	Email string    `json:"email"`
+}
+
+// Statuses a borrowing record can be filtered on.
+const (
+	RecordBorrowed = "borrowed"
+	RecordReturned = "returned"
+	RecordOverdue  = "overdue" // borrowed and past its due date
+)
+
+// Orders borrowing records can be sorted by.
+const (
+	RecordSortBorrowed = "borrowed_at"
+	RecordSortDue      = "due_date"
+)
+
+// BorrowingRecordFilter selects borrowing records. Only the records of UserID
+// match unless AllUsers is set for staff, so a zero UserID matches nothing. A
+// zero BookID matches every book. Status is "borrowed", "returned" or "overdue".
+// The date ranges include From and everything before To, and nil leaves them
+// out. Records are sorted by Sort, borrowing time by default, in Order, "asc"
+// or "desc". After holds the sort keys of the record the page starts after,
+// from a cursor. A zero Limit lists every record.
+type BorrowingRecordFilter struct {
+	UserID       uuid.UUID
+	AllUsers     bool
+	BookID       uuid.UUID
+	Title        string
+	Status       string
+	BorrowedFrom *time.Time
+	BorrowedTo   *time.Time
+	DueFrom      *time.Time
+	DueTo        *time.Time
+	ReturnedFrom *time.Time
+	ReturnedTo   *time.Time
+	Sort         string
+	Order        string
+	After        []string
+	Limit        int
 }
 
 type BookCategory struct {
 	ID   uuid.UUID `db:"id"`
 	Name string    `db:"name"`
 }
+
+// Orders a book listing can be sorted in. A leading "-" sorts descending.
+const (
+	SortTitle             = "title"
+	SortTitleDesc         = "-title"
+	SortAuthor            = "author"
+	SortAuthorDesc        = "-author"
+	SortPublishedDate     = "published_date"
+	SortPublishedDateDesc = "-published_date"
+	SortRating            = "rating" // books without reviews first
+	SortRatingDesc        = "-rating"
+	SortNewest            = "newest"
+	SortRelevance         = "relevance" // only with a search query
+)
+
+// BookFacets counts the books of a listing per category and availability.
+type BookFacets struct {
+	Categories  []CategoryFacet
+	Available   int64
+	Unavailable int64
+}
+
+// CategoryFacet is the number of books in one category.
+type CategoryFacet struct {
+	CategoryID uuid.UUID
+	Count      int64
+}
+
+// BookCover describes the cover uploaded for a book. UpdatedAt changes with
+// every upload, so it versions the cover URLs.
+type BookCover struct {
+	BookID      uuid.UUID
+	ContentType string // of the original upload; thumbnails are JPEG
+	Width       int
+	Height      int
+	UpdatedAt   time.Time
+}
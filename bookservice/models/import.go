@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Statuses of a book import.
+const (
+	ImportPending   = "pending"
+	ImportRunning   = "running"
+	ImportCompleted = "completed"
+	ImportFailed    = "failed" // the file could not be read to the end
+)
+
+// Statuses of a row of a book import. A dry run marks the rows that would be
+// added as valid.
+const (
+	ImportRowCreated   = "created"
+	ImportRowValid     = "valid"
+	ImportRowInvalid   = "invalid"
+	ImportRowDuplicate = "duplicate"
+)
+
+// BookImport is a bulk import of books from a catalogue file. Processed
+// counts the rows read so far, of which Succeeded were added (or would be, in
+// a dry run) and Failed were not.
+type BookImport struct {
+	ID         uuid.UUID  `json:"id"`
+	Format     string     `json:"format"`
+	DryRun     bool       `json:"dry_run"`
+	Status     string     `json:"status"`
+	Processed  int        `json:"processed"`
+	Succeeded  int        `json:"succeeded"`
+	Failed     int        `json:"failed"`
+	Error      string     `json:"error,omitempty"` // why a failed import stopped
+	CreatedBy  uuid.UUID  `json:"created_by"`
+	CreatedAt  *time.Time `json:"created_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// BookImportRow is the outcome of one row of an import, numbered from 1 in
+// file order. BookID is set for created rows.
+type BookImportRow struct {
+	Row     int        `json:"row"`
+	Status  string     `json:"status"`
+	BookID  *uuid.UUID `json:"book_id,omitempty"`
+	Title   string     `json:"title"`
+	ISBN    string     `json:"isbn,omitempty"`
+	Message string     `json:"message,omitempty"`
+}
+
+// BookImportRowFilter selects the rows of an import. An empty Status matches
+// every row. After holds the row number the page starts after, from a cursor.
+type BookImportRowFilter struct {
+	Status string
+	After  []string
+	Limit  int
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReadingList is a patron's named list of books, such as a wishlist. A
+// public list can be read by anyone through its Slug.
+type ReadingList struct {
+	ID          uuid.UUID `json:"id"`
+	UserID      uuid.UUID `json:"user_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Public      bool      `json:"public"`
+	Slug        string    `json:"slug,omitempty"` // set once the list was first made public
+	ItemCount   int       `json:"item_count"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	// Only set where the books of the list are loaded
+	Items []ReadingListItem `json:"items,omitempty"`
+}
+
+// ReadingListItem is a book on a reading list, with its stock as it is now.
+// Notify asks for a notification when a copy of the book is returned.
+type ReadingListItem struct {
+	BookID    uuid.UUID `json:"book_id"`
+	Title     string    `json:"title"`
+	Author    string    `json:"author"`
+	Stock     int       `json:"stock"`
+	Available bool      `json:"available"`
+	Notify    bool      `json:"notify"`
+	Position  int       `json:"position"`
+	AddedAt   time.Time `json:"added_at"`
+}
+
+// Kinds of notifications.
+const (
+	NotificationAvailable = "available" // a book the user waits for was returned
+)
+
+// Notification tells a user about one of their books.
+type Notification struct {
+	ID        uuid.UUID  `json:"id"`
+	BookID    uuid.UUID  `json:"book_id"`
+	Title     string     `json:"title"`
+	Kind      string     `json:"kind"`
+	CreatedAt time.Time  `json:"created_at"`
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+}
+
+// NotificationFilter selects the notifications of a user, newest first.
+// After holds the sort keys of the notification the page starts after, from
+// a cursor.
+type NotificationFilter struct {
+	Unread bool
+	After  []string
+	Limit  int
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Why a book is recommended.
+const (
+	ReasonCoBorrowed = "co_borrowed" // borrowed by the same patrons
+	ReasonCategory   = "category"    // popular in the same category
+	ReasonPopular    = "popular"     // popular across the library
+)
+
+// RecommendedBook is a book suggested next to another one or to a patron.
+// Score ranks co-borrowed books; fallbacks score 0.
+type RecommendedBook struct {
+	ID        uuid.UUID `json:"id"`
+	Title     string    `json:"title"`
+	Author    string    `json:"author"`
+	Stock     int       `json:"stock"`
+	Available bool      `json:"available"`
+	Score     float64   `json:"score"`
+	Reason    string    `json:"reason"`
+}
+
+// What started a recommendation run.
+const (
+	RunScheduled = "schedule"
+	RunManual    = "manual"
+)
+
+// Statuses of a recommendation run.
+const (
+	RunRunning   = "running"
+	RunCompleted = "completed"
+	RunFailed    = "failed"
+	RunSkipped   = "skipped" // another instance was recomputing
+)
+
+// RecommendationRun is one recomputation of the recommendation tables from
+// the borrowing history. Similarities counts the pairs of similar books and
+// Recommendations the books suggested to patrons.
+type RecommendationRun struct {
+	ID              uuid.UUID  `json:"id"`
+	Trigger         string     `json:"trigger"`
+	Status          string     `json:"status"`
+	RequestedBy     *uuid.UUID `json:"requested_by,omitempty"`
+	Similarities    int64      `json:"similarities"`
+	Recommendations int64      `json:"recommendations"`
+	Error           string     `json:"error,omitempty"`
+	StartedAt       time.Time  `json:"started_at"`
+	FinishedAt      *time.Time `json:"finished_at,omitempty"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import "github.com/google/uuid"
+
+// CirculationSummary sums up the loans started in a period.
+type CirculationSummary struct {
+	Loans            int64   `json:"loans"`             // Loans started in the period
+	Returned         int64   `json:"returned"`          // Of those, returned since
+	ReturnedLate     int64   `json:"returned_late"`     // Of those, returned after their due date
+	Overdue          int64   `json:"overdue"`           // Of those, still out past their due date
+	AverageLoanDays  float64 `json:"average_loan_days"` // Average length of the returned ones
+	ActiveBorrowers  int64   `json:"active_borrowers"`  // Patrons with a book out at some point of the period
+	StockUtilisation float64 `json:"stock_utilisation"` // Share of the copy-days of the period spent on loan
+}
+
+// BookLoans counts the loans of a book started in a period.
+type BookLoans struct {
+	ID     uuid.UUID `json:"id"`
+	Title  string    `json:"title"`
+	Author string    `json:"author"`
+	Loans  int64     `json:"loans"`
+}
+
+// CategoryLoans counts the loans started in a period of the books of a
+// category, and how many of its books they took out.
+type CategoryLoans struct {
+	CategoryID uuid.UUID `json:"category_id"`
+	Name       string    `json:"name"`
+	Loans      int64     `json:"loans"`
+	Books      int64     `json:"books"`
+}
+
+// BookUtilisation is how much of a period the copies of a book spent on
+// loan. Copies counts those in stock and out now; LoanDays the days each copy
+// was out, a day for each started; Utilisation is LoanDays over the copy-days
+// of the period.
+type BookUtilisation struct {
+	ID          uuid.UUID `json:"id"`
+	Title       string    `json:"title"`
+	Author      string    `json:"author"`
+	Copies      int64     `json:"copies"`
+	LoanDays    int64     `json:"loan_days"`
+	Utilisation float64   `json:"utilisation"`
+}
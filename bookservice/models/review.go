@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Moderation statuses of a review. Flagged reviews stay listed and counted
+// until a librarian decides on them; hidden ones are neither.
+const (
+	ReviewVisible = "visible"
+	ReviewFlagged = "flagged"
+	ReviewHidden  = "hidden"
+)
+
+// Review is a patron's rating of a book from 1 to 5, with an optional text.
+// A patron reviews a book at most once.
+type Review struct {
+	ID     uuid.UUID `json:"id"`
+	BookID uuid.UUID `json:"book_id"`
+	UserID uuid.UUID `json:"user_id"`
+	Rating int       `json:"rating"`
+	Text   string    `json:"text"`
+	// Moderation, only shown to librarians
+	Status         string     `json:"status,omitempty"`
+	ModerationNote string     `json:"moderation_note,omitempty"`
+	ModeratedBy    *uuid.UUID `json:"moderated_by,omitempty"`
+	ModeratedAt    *time.Time `json:"moderated_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// ReviewFilter selects the reviews ReviewRepository.ListReviews returns,
+// newest first. A nil BookID matches every book and an empty Statuses every
+// status. After holds the sort keys of the review the page starts after,
+// from a cursor.
+type ReviewFilter struct {
+	BookID   uuid.UUID
+	Statuses []string
+	After    []string
+	Limit    int
+}
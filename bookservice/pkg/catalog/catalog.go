@@ -0,0 +1,120 @@
+// Package catalog reads and writes books in the file formats catalogues are
+// moved between systems in: CSV, JSON Lines and MARC 21.
+package catalog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Formats a catalogue can be read and written in.
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+	FormatMARC  = "marc"
+)
+
+var (
+	ErrUnknownFormat = errors.New("format must be csv, jsonl or marc")
+	// ErrInvalidEntry is returned by Reader.Read for an entry that can't be
+	// read. Reading can go on with the next entry.
+	ErrInvalidEntry = errors.New("invalid entry")
+)
+
+// Entry is one book of a catalogue file. Category is a category name.
+type Entry struct {
+	Title         string
+	Author        string
+	ISBN          string
+	PublishedDate *time.Time
+	Category      string
+	Stock         int
+}
+
+// Reader reads the entries of a catalogue file in order.
+type Reader interface {
+	// Read returns the next entry, or io.EOF when there are no more. Errors
+	// wrapping ErrInvalidEntry only concern that entry.
+	Read() (*Entry, error)
+}
+
+// Writer writes entries to a catalogue file.
+type Writer interface {
+	Write(entry *Entry) error
+	// Flush writes out anything buffered, and must be called when done.
+	Flush() error
+}
+
+// NewReader returns a Reader for a file in format.
+func NewReader(format string, r io.Reader) (Reader, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVReader(r)
+	case FormatJSONL:
+		return newJSONLReader(r), nil
+	case FormatMARC:
+		return newMARCReader(r), nil
+	}
+	return nil, ErrUnknownFormat
+}
+
+// NewWriter returns a Writer writing a file in format to w.
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w), nil
+	case FormatJSONL:
+		return newJSONLWriter(w), nil
+	case FormatMARC:
+		return newMARCWriter(w), nil
+	}
+	return nil, ErrUnknownFormat
+}
+
+// IsFormat reports whether format is one of the known formats.
+func IsFormat(format string) bool {
+	return format == FormatCSV || format == FormatJSONL || format == FormatMARC
+}
+
+// FormatOf returns the format of a file by its name, or "" when the
+// extension is not known.
+func FormatOf(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return FormatCSV
+	case ".jsonl", ".ndjson", ".json":
+		return FormatJSONL
+	case ".mrc", ".marc":
+		return FormatMARC
+	}
+	return ""
+}
+
+// ContentType returns the MIME type of files in format.
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatJSONL:
+		return "application/x-ndjson"
+	case FormatMARC:
+		return "application/marc"
+	}
+	return "application/octet-stream"
+}
+
+// Extension returns the file extension of format, with its dot.
+func Extension(format string) string {
+	if format == FormatMARC {
+		return ".mrc"
+	}
+	return "." + format
+}
+
+func invalidEntry(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidEntry, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,127 @@
+package catalog
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/csvsafe"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/metadata"
+)
+
+// columns are the columns of a CSV catalogue, in the order they are written.
+// Files are read by their header, so columns can come in any order and
+// unknown ones are ignored.
+var columns = []string{"title", "author", "isbn", "published_date", "category", "stock"}
+
+type csvReader struct {
+	r       *csv.Reader
+	columns map[string]int
+}
+
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("missing CSV header")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	indexes := make(map[string]int, len(header))
+	for i, name := range header {
+		// Spreadsheets save UTF-8 files with a byte order mark
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		indexes[name] = i
+	}
+	if _, ok := indexes["title"]; !ok {
+		return nil, fmt.Errorf("CSV header has no title column")
+	}
+
+	return &csvReader{r: cr, columns: indexes}, nil
+}
+
+func (r *csvReader) Read() (*Entry, error) {
+	record, err := r.r.Read()
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return nil, invalidEntry("%v", parseErr.Err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	value := func(column string) string {
+		i, ok := r.columns[column]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return csvsafe.Unescape(strings.TrimSpace(record[i]))
+	}
+
+	entry := &Entry{
+		Title:    value("title"),
+		Author:   value("author"),
+		ISBN:     value("isbn"),
+		Category: value("category"),
+	}
+	if date := value("published_date"); date != "" {
+		if entry.PublishedDate = metadata.ParseDate(date); entry.PublishedDate == nil {
+			return nil, invalidEntry("bad published_date %q", date)
+		}
+	}
+	if stock := value("stock"); stock != "" {
+		if entry.Stock, err = strconv.Atoi(stock); err != nil {
+			return nil, invalidEntry("bad stock %q", stock)
+		}
+	}
+
+	return entry, nil
+}
+
+type csvWriter struct {
+	w             *csvsafe.Writer
+	headerWritten bool
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{w: csvsafe.NewWriter(w)}
+}
+
+func (w *csvWriter) Write(entry *Entry) error {
+	if !w.headerWritten {
+		if err := w.w.Write(columns); err != nil {
+			return err
+		}
+		w.headerWritten = true
+	}
+
+	var date string
+	if entry.PublishedDate != nil {
+		date = entry.PublishedDate.Format(time.DateOnly)
+	}
+	return w.w.Write([]string{entry.Title, entry.Author, entry.ISBN, date, entry.Category, strconv.Itoa(entry.Stock)})
+}
+
+func (w *csvWriter) Flush() error {
+	// An empty export still gets its header
+	if !w.headerWritten {
+		if err := w.w.Write(columns); err != nil {
+			return err
+		}
+		w.headerWritten = true
+	}
+	w.w.Flush()
+	return w.w.Error()
+}
@@ -0,0 +1,96 @@
+package catalog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/metadata"
+)
+
+// maxLineLength caps a line of a JSON Lines file.
+const maxLineLength = 1 << 20
+
+// jsonEntry is an entry as a line of a JSON Lines file.
+type jsonEntry struct {
+	Title         string `json:"title"`
+	Author        string `json:"author"`
+	ISBN          string `json:"isbn,omitempty"`
+	PublishedDate string `json:"published_date,omitempty"`
+	Category      string `json:"category,omitempty"`
+	Stock         int    `json:"stock"`
+}
+
+type jsonlReader struct {
+	scanner *bufio.Scanner
+}
+
+func newJSONLReader(r io.Reader) *jsonlReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineLength)
+	return &jsonlReader{scanner: scanner}
+}
+
+func (r *jsonlReader) Read() (*Entry, error) {
+	for r.scanner.Scan() {
+		line := bytes.TrimSpace(r.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var raw jsonEntry
+		if err := json.Unmarshal(line, &raw); err != nil {
+			return nil, invalidEntry("%v", err)
+		}
+
+		entry := &Entry{
+			Title:    raw.Title,
+			Author:   raw.Author,
+			ISBN:     raw.ISBN,
+			Category: raw.Category,
+			Stock:    raw.Stock,
+		}
+		if raw.PublishedDate != "" {
+			if entry.PublishedDate = metadata.ParseDate(raw.PublishedDate); entry.PublishedDate == nil {
+				return nil, invalidEntry("bad published_date %q", raw.PublishedDate)
+			}
+		}
+		return entry, nil
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+type jsonlWriter struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func newJSONLWriter(w io.Writer) *jsonlWriter {
+	buffered := bufio.NewWriter(w)
+	return &jsonlWriter{w: buffered, enc: json.NewEncoder(buffered)}
+}
+
+func (w *jsonlWriter) Write(entry *Entry) error {
+	raw := jsonEntry{
+		Title:    entry.Title,
+		Author:   entry.Author,
+		ISBN:     entry.ISBN,
+		Category: entry.Category,
+		Stock:    entry.Stock,
+	}
+	if entry.PublishedDate != nil {
+		raw.PublishedDate = entry.PublishedDate.Format(time.DateOnly)
+	}
+	// Encode ends every entry with a newline
+	return w.enc.Encode(raw)
+}
+
+func (w *jsonlWriter) Flush() error {
+	return w.w.Flush()
+}
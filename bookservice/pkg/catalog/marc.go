@@ -0,0 +1,110 @@
+package catalog
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/marc"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/metadata"
+)
+
+// Besides the standard bibliographic fields, the category of a book goes in
+// the first 650 (topical subject) $a and its stock in the local field 999 $s.
+const (
+	categoryTag = "650"
+	stockTag    = "999"
+)
+
+type marcReader struct {
+	r *marc.Reader
+}
+
+func newMARCReader(r io.Reader) *marcReader {
+	return &marcReader{r: marc.NewReader(r)}
+}
+
+func (r *marcReader) Read() (*Entry, error) {
+	rec, err := r.r.Read()
+	if err != nil {
+		// The reader moves on to the next record after a malformed one
+		if errors.Is(err, marc.ErrInvalidRecord) {
+			return nil, invalidEntry("%v", err)
+		}
+		return nil, err
+	}
+
+	record, _ := metadata.FromMARC(rec)
+	entry := &Entry{
+		Title:         record.Title,
+		Author:        record.Author,
+		ISBN:          record.ISBN,
+		PublishedDate: record.PublishedDate,
+		Category:      strings.TrimSpace(strings.TrimRight(rec.Subfield(categoryTag, 'a'), ".")),
+	}
+	if entry.ISBN == "" {
+		// Keep an invalid ISBN so it is reported rather than dropped
+		entry.ISBN, _, _ = strings.Cut(strings.TrimSpace(rec.Subfield("020", 'a')), " ")
+	}
+	if stock := strings.TrimSpace(rec.Subfield(stockTag, 's')); stock != "" {
+		if entry.Stock, err = strconv.Atoi(stock); err != nil {
+			return nil, invalidEntry("bad stock %q", stock)
+		}
+	}
+
+	return entry, nil
+}
+
+type marcWriter struct {
+	buf *bufio.Writer
+	w   *marc.Writer
+}
+
+func newMARCWriter(w io.Writer) *marcWriter {
+	buffered := bufio.NewWriter(w)
+	return &marcWriter{buf: buffered, w: marc.NewWriter(buffered)}
+}
+
+func (w *marcWriter) Write(entry *Entry) error {
+	rec := &marc.Record{}
+
+	var year string
+	if entry.PublishedDate != nil {
+		year = entry.PublishedDate.Format("2006")
+		// 008/06 "s" marks a single known date, which 008/07-10 holds
+		rec.Fields = append(rec.Fields, marc.Field{Tag: "008", Value: padRight("      s"+year, 40)})
+	}
+	if entry.ISBN != "" {
+		rec.Fields = append(rec.Fields, dataField("020", ' ', ' ', 'a', entry.ISBN))
+	}
+	if entry.Author != "" {
+		rec.Fields = append(rec.Fields, dataField("100", '1', ' ', 'a', entry.Author))
+	}
+	rec.Fields = append(rec.Fields, dataField("245", '1', '0', 'a', entry.Title))
+	if year != "" {
+		rec.Fields = append(rec.Fields, dataField("264", ' ', '1', 'c', year))
+	}
+	if entry.Category != "" {
+		rec.Fields = append(rec.Fields, dataField(categoryTag, ' ', '4', 'a', entry.Category))
+	}
+	rec.Fields = append(rec.Fields, dataField(stockTag, ' ', ' ', 's', strconv.Itoa(entry.Stock)))
+
+	return w.w.Write(rec)
+}
+
+func (w *marcWriter) Flush() error {
+	return w.buf.Flush()
+}
+
+func dataField(tag string, ind1, ind2, code byte, value string) marc.Field {
+	return marc.Field{Tag: tag, Ind1: ind1, Ind2: ind2, Subfields: []marc.Subfield{{Code: code, Value: value}}}
+}
+
+func padRight(s string, length int) string {
+	if len(s) >= length {
+		return s
+	}
+	return s + strings.Repeat(" ", length-len(s))
+}
@@ -0,0 +1,70 @@
+// Package csvsafe writes CSV files that spreadsheets open as plain text.
+// Cells that Excel, LibreOffice or Google Sheets would run as a formula are
+// prefixed with a single quote, so a book titled "=HYPERLINK(...)" stays a
+// title.
+package csvsafe
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+)
+
+// formulaStarts are the first characters that make a spreadsheet read a cell
+// as a formula.
+const formulaStarts = "=+-@\t\r"
+
+// Escape prefixes cell with a single quote when a spreadsheet would run it as
+// a formula.
+func Escape(cell string) string {
+	if cell != "" && strings.ContainsRune(formulaStarts, rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+// Unescape undoes Escape, so files written by this package read back as they
+// were written.
+func Unescape(cell string) string {
+	if len(cell) > 1 && cell[0] == '\'' && strings.ContainsRune(formulaStarts, rune(cell[1])) {
+		return cell[1:]
+	}
+	return cell
+}
+
+// Writer is a csv.Writer that escapes every cell it writes.
+type Writer struct {
+	w *csv.Writer
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: csv.NewWriter(w)}
+}
+
+// Write writes a record with its cells escaped.
+func (w *Writer) Write(record []string) error {
+	escaped := make([]string, len(record))
+	for i, cell := range record {
+		escaped[i] = Escape(cell)
+	}
+	return w.w.Write(escaped)
+}
+
+// WriteAll writes the records with their cells escaped and flushes them.
+func (w *Writer) WriteAll(records [][]string) error {
+	for _, record := range records {
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.w.Flush()
+	return w.w.Error()
+}
+
+func (w *Writer) Flush() {
+	w.w.Flush()
+}
+
+func (w *Writer) Error() error {
+	return w.w.Error()
+}
@@ -0,0 +1,58 @@
+package csvsafe
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"testing"
+)
+
+// Test Escape dan Unescape: Sel yang akan dijalankan sebagai formula diberi
+// tanda kutip, lalu dikembalikan seperti semula
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"=HYPERLINK(\"http://evil\")", "'=HYPERLINK(\"http://evil\")"},
+		{"+1", "'+1"},
+		{"-2+3", "'-2+3"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"\tcmd", "'\tcmd"},
+		{"\rcmd", "'\rcmd"},
+		{"Harry Potter", "Harry Potter"},
+		{"'quoted", "'quoted"},
+		{"a=b", "a=b"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		got := Escape(tt.in)
+		if got != tt.want {
+			t.Errorf("Escape(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+		if back := Unescape(got); back != tt.in {
+			t.Errorf("Unescape(%q) = %q, want %q", got, back, tt.in)
+		}
+	}
+}
+
+// Test Writer: Write dan WriteAll meng-escape setiap sel
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	if err := w.Write([]string{"title", "stock"}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := w.WriteAll([][]string{{"=1+1", "3"}, {"@me", "0"}}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("expected no error reading back, got %v", err)
+	}
+	want := [][]string{{"title", "stock"}, {"'=1+1", "3"}, {"'@me", "0"}}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("records = %q, want %q", records, want)
+	}
+}
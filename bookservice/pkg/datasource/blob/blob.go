@@ -0,0 +1,30 @@
+package blobclient
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/config"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/blob"
+)
+
+// NewCoverStore returns the store book covers are kept in.
+func NewCoverStore(cfg config.CoverConfig) (blob.Store, error) {
+	switch strings.ToLower(cfg.Storage) {
+	case "", "local":
+		log.Printf("Storing covers in %s", cfg.Dir)
+		return blob.NewLocal(cfg.Dir)
+	case "s3":
+		log.Printf("Storing covers in bucket %s at %s", cfg.S3Bucket, cfg.S3Endpoint)
+		return blob.NewS3(blob.S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			Timeout:   cfg.Timeout,
+		})
+	}
+	return nil, fmt.Errorf("unknown cover storage %q", cfg.Storage)
+}
@@ -0,0 +1,36 @@
+package metadataclient
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/config"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/metadata"
+)
+
+// NewProvider builds the chain of book metadata providers named in
+// cfg.Providers, asked in that order. An empty chain disables lookups.
+func NewProvider(cfg config.MetadataConfig) (metadata.Provider, error) {
+	var chain metadata.Chain
+	for _, name := range strings.Split(cfg.Providers, ",") {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "", "none":
+		case "openlibrary":
+			chain = append(chain, metadata.NewOpenLibrary(cfg.OpenLibraryURL, cfg.Timeout))
+		case "file":
+			file, err := metadata.NewFile(cfg.File)
+			if err != nil {
+				return nil, err
+			}
+			chain = append(chain, file)
+		default:
+			return nil, fmt.Errorf("unknown book metadata provider %q", name)
+		}
+	}
+
+	if len(chain) > 0 {
+		log.Printf("Looking up book metadata from %s", chain.Name())
+	}
+	return chain, nil
+}
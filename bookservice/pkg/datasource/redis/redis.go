@@ -0,0 +1,38 @@
+package redisclient
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/config"
+	"github.com/sir-shalahuddin/grpc-learn/pkg/cache"
+)
+
+// NewAuthCache builds the cache used for token introspection and user
+// lookups. It returns nil when caching is disabled.
+func NewAuthCache(cfg config.CacheConfig) (cache.Cache, error) {
+	switch strings.ToLower(cfg.Backend) {
+	case "none", "off":
+		return nil, nil
+	case "redis":
+		client := redis.NewClient(&redis.Options{
+			Addr:     cfg.RedisAddress,
+			Password: cfg.RedisPassword,
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := client.Ping(ctx).Err(); err != nil {
+			return nil, fmt.Errorf("failed to connect to redis at %s: %w", cfg.RedisAddress, err)
+		}
+		log.Printf("Connected to redis at %s", cfg.RedisAddress)
+
+		return cache.NewRedis(client, "bookservice:auth:"), nil
+	default:
+		return cache.NewLRU(cfg.Size), nil
+	}
+}
@@ -0,0 +1,114 @@
+// Package isbn validates ISBN-10 and ISBN-13 numbers and converts between
+// the two forms. ISBN-13 is the canonical form, so a book can be recognized
+// whichever form it was entered in.
+package isbn
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrInvalidFormat   = errors.New("isbn must be 10 or 13 digits, an ISBN-10 may end in X")
+	ErrInvalidChecksum = errors.New("isbn check digit does not match")
+	ErrNoISBN10        = errors.New("only 978 ISBN-13s have an ISBN-10 form")
+)
+
+// Normalize strips the hyphens and spaces ISBNs are often written with and
+// upper-cases a trailing x. It does not validate s.
+func Normalize(s string) string {
+	s = strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(s))
+	return strings.ToUpper(s)
+}
+
+// Validate reports whether s, after Normalize, is a well-formed ISBN-10 or
+// ISBN-13 with a correct check digit.
+func Validate(s string) error {
+	_, err := ToISBN13(s)
+	return err
+}
+
+// ToISBN13 returns the ISBN-13 form of an ISBN-10 or ISBN-13, validating it
+// first. ISBN-10s get the 978 prefix and a recomputed check digit.
+func ToISBN13(s string) (string, error) {
+	s = Normalize(s)
+
+	switch len(s) {
+	case 10:
+		if !digits(s[:9]) || !(isDigit(s[9]) || s[9] == 'X') {
+			return "", ErrInvalidFormat
+		}
+		if checkDigit10(s[:9]) != s[9] {
+			return "", ErrInvalidChecksum
+		}
+		body := "978" + s[:9]
+		return body + string(checkDigit13(body)), nil
+	case 13:
+		if !digits(s) {
+			return "", ErrInvalidFormat
+		}
+		if checkDigit13(s[:12]) != s[12] {
+			return "", ErrInvalidChecksum
+		}
+		return s, nil
+	default:
+		return "", ErrInvalidFormat
+	}
+}
+
+// ToISBN10 returns the ISBN-10 form of an ISBN-10 or ISBN-13, validating it
+// first. ISBN-13s outside the 978 prefix have none and return ErrNoISBN10.
+func ToISBN10(s string) (string, error) {
+	isbn13, err := ToISBN13(s)
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(isbn13, "978") {
+		return "", ErrNoISBN10
+	}
+
+	body := isbn13[3:12]
+	return body + string(checkDigit10(body)), nil
+}
+
+// checkDigit10 computes the ISBN-10 check digit of the first nine digits:
+// the weighted sum 10..2 plus the check digit must be divisible by 11, and a
+// check value of 10 is written X.
+func checkDigit10(body string) byte {
+	sum := 0
+	for i := 0; i < 9; i++ {
+		sum += (10 - i) * int(body[i]-'0')
+	}
+	check := (11 - sum%11) % 11
+	if check == 10 {
+		return 'X'
+	}
+	return byte('0' + check)
+}
+
+// checkDigit13 computes the ISBN-13 check digit of the first twelve digits,
+// weighted alternately 1 and 3.
+func checkDigit13(body string) byte {
+	sum := 0
+	for i := 0; i < 12; i++ {
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += weight * int(body[i]-'0')
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+func digits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isDigit(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
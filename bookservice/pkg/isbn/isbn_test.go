@@ -0,0 +1,102 @@
+package isbn
+
+import (
+	"errors"
+	"testing"
+)
+
+// Test Normalize: Tanda hubung dan spasi dibuang, x menjadi X
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"0-306-40615-2", "0306406152"},
+		{" 978 0 306 40615 7 ", "9780306406157"},
+		{"0-8044-2957-x", "080442957X"},
+		{"not an isbn", "NOTANISBN"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := Normalize(tt.in); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// Test Validate: Format dan check digit diperiksa
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		in   string
+		want error
+	}{
+		{"0306406152", nil},
+		{"0-306-40615-2", nil},
+		{"080442957X", nil},
+		{"080442957x", nil},
+		{"9780306406157", nil},
+		{"978-0-306-40615-7", nil},
+		{"9791090636071", nil},
+		{"0306406153", ErrInvalidChecksum},
+		{"9780306406158", ErrInvalidChecksum},
+		{"0804429570", ErrInvalidChecksum},
+		{"030640615", ErrInvalidFormat},
+		{"X306406152", ErrInvalidFormat},
+		{"978030640615X", ErrInvalidFormat},
+		{"97803064061571", ErrInvalidFormat},
+		{"", ErrInvalidFormat},
+	}
+
+	for _, tt := range tests {
+		if err := Validate(tt.in); !errors.Is(err, tt.want) {
+			t.Errorf("Validate(%q) = %v, want %v", tt.in, err, tt.want)
+		}
+	}
+}
+
+// Test ToISBN13: ISBN-10 mendapat awalan 978 dan check digit baru
+func TestToISBN13(t *testing.T) {
+	tests := []struct {
+		in, want string
+		err      error
+	}{
+		{"0306406152", "9780306406157", nil},
+		{"0-306-40615-2", "9780306406157", nil},
+		{"080442957X", "9780804429573", nil},
+		{"0 439 42089 x", "9780439420891", nil},
+		{"9780306406157", "9780306406157", nil},
+		{"979-10-90636-07-1", "9791090636071", nil},
+		{"0306406153", "", ErrInvalidChecksum},
+		{"12345", "", ErrInvalidFormat},
+	}
+
+	for _, tt := range tests {
+		got, err := ToISBN13(tt.in)
+		if got != tt.want || !errors.Is(err, tt.err) {
+			t.Errorf("ToISBN13(%q) = %q, %v, want %q, %v", tt.in, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+// Test ToISBN10: Hanya ISBN-13 berawalan 978 yang punya bentuk ISBN-10
+func TestToISBN10(t *testing.T) {
+	tests := []struct {
+		in, want string
+		err      error
+	}{
+		{"9780306406157", "0306406152", nil},
+		{"978-0-8044-2957-3", "080442957X", nil},
+		{"9780975229804", "097522980X", nil},
+		{"0306406152", "0306406152", nil},
+		{"9791090636071", "", ErrNoISBN10},
+		{"9780306406158", "", ErrInvalidChecksum},
+		{"abc", "", ErrInvalidFormat},
+	}
+
+	for _, tt := range tests {
+		got, err := ToISBN10(tt.in)
+		if got != tt.want || !errors.Is(err, tt.err) {
+			t.Errorf("ToISBN10(%q) = %q, %v, want %q, %v", tt.in, got, err, tt.want, tt.err)
+		}
+	}
+}
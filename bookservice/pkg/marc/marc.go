@@ -0,0 +1,247 @@
+// Package marc reads and writes MARC 21 bibliographic records in their ISO 2709
+// transmission format, the format library systems exchange .mrc files in.
+package marc
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	fieldTerminator  = 0x1E
+	recordTerminator = 0x1D
+	subfieldDelim    = 0x1F
+
+	leaderLength   = 24
+	directoryEntry = 12
+)
+
+var ErrInvalidRecord = errors.New("invalid MARC record")
+
+// Record is one MARC record. Control fields (tags 001 to 009) carry only a
+// Value; data fields carry indicators and subfields.
+type Record struct {
+	Leader string
+	Fields []Field
+}
+
+// Field is a control or data field of a record.
+type Field struct {
+	Tag       string
+	Value     string
+	Ind1      byte
+	Ind2      byte
+	Subfields []Subfield
+}
+
+// Subfield is a coded part of a data field, such as $a.
+type Subfield struct {
+	Code  byte
+	Value string
+}
+
+// IsControl reports whether f is a control field.
+func (f Field) IsControl() bool {
+	return strings.HasPrefix(f.Tag, "00")
+}
+
+// Subfield returns the first subfield of f with code, or "".
+func (f Field) Subfield(code byte) string {
+	for _, sf := range f.Subfields {
+		if sf.Code == code {
+			return sf.Value
+		}
+	}
+	return ""
+}
+
+// FieldsByTag returns the fields of r with tag, in record order.
+func (r *Record) FieldsByTag(tag string) []Field {
+	var fields []Field
+	for _, f := range r.Fields {
+		if f.Tag == tag {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// Subfield returns the first subfield with code of the first field with tag
+// that has one, or "".
+func (r *Record) Subfield(tag string, code byte) string {
+	for _, f := range r.FieldsByTag(tag) {
+		if value := f.Subfield(code); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// ControlField returns the value of the control field with tag, or "".
+func (r *Record) ControlField(tag string) string {
+	for _, f := range r.FieldsByTag(tag) {
+		return f.Value
+	}
+	return ""
+}
+
+// Reader reads consecutive records from a MARC file.
+type Reader struct {
+	r *bufio.Reader
+}
+
+// NewReader returns a Reader reading records from r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Read returns the next record, or io.EOF when there are no more.
+func (r *Reader) Read() (*Record, error) {
+	data, err := r.r.ReadBytes(recordTerminator)
+	if err == io.EOF {
+		// Files often end with a newline after the last terminator
+		if strings.TrimSpace(string(data)) == "" {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("%w: missing record terminator", ErrInvalidRecord)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Skip line breaks some tools put between records
+	data = []byte(strings.TrimLeft(string(data), "\r\n"))
+	return parse(data)
+}
+
+func parse(data []byte) (*Record, error) {
+	if len(data) < leaderLength+1 {
+		return nil, fmt.Errorf("%w: too short", ErrInvalidRecord)
+	}
+
+	leader := string(data[:leaderLength])
+	base, ok := number(leader[12:17])
+	if !ok || base <= leaderLength || base > len(data) {
+		return nil, fmt.Errorf("%w: bad base address of data", ErrInvalidRecord)
+	}
+
+	directory := data[leaderLength : base-1]
+	if len(directory)%directoryEntry != 0 {
+		return nil, fmt.Errorf("%w: bad directory length", ErrInvalidRecord)
+	}
+
+	record := &Record{Leader: leader}
+	for i := 0; i < len(directory); i += directoryEntry {
+		entry := string(directory[i : i+directoryEntry])
+		length, ok1 := number(entry[3:7])
+		start, ok2 := number(entry[7:12])
+		if !ok1 || !ok2 || length < 1 || start > len(data)-base || length > len(data)-base-start {
+			return nil, fmt.Errorf("%w: bad directory entry %q", ErrInvalidRecord, entry)
+		}
+
+		// Drop the field terminator
+		value := data[base+start : base+start+length-1]
+		field := Field{Tag: entry[:3]}
+		if field.IsControl() {
+			field.Value = string(value)
+		} else {
+			field = parseDataField(field, value)
+		}
+		record.Fields = append(record.Fields, field)
+	}
+
+	return record, nil
+}
+
+// number parses a fixed-width numeric part of a leader or directory entry.
+// Unlike strconv.Atoi it accepts digits only, no sign.
+func number(s string) (int, bool) {
+	n := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return 0, false
+		}
+		n = n*10 + int(s[i]-'0')
+	}
+	return n, s != ""
+}
+
+func parseDataField(field Field, value []byte) Field {
+	if len(value) >= 2 {
+		field.Ind1, field.Ind2 = value[0], value[1]
+		value = value[2:]
+	}
+
+	for _, part := range strings.Split(string(value), string(rune(subfieldDelim))) {
+		if part == "" {
+			continue
+		}
+		field.Subfields = append(field.Subfields, Subfield{Code: part[0], Value: part[1:]})
+	}
+	return field
+}
+
+// Writer writes records to a MARC file.
+type Writer struct {
+	w io.Writer
+}
+
+// NewWriter returns a Writer writing records to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Write writes rec in ISO 2709 format. The record length, base address and
+// directory are computed; the rest of the leader is taken from rec.Leader, or
+// describes a book when it is empty.
+func (w *Writer) Write(rec *Record) error {
+	var directory, data strings.Builder
+	for _, f := range rec.Fields {
+		if len(f.Tag) != 3 {
+			return fmt.Errorf("%w: bad tag %q", ErrInvalidRecord, f.Tag)
+		}
+
+		start := data.Len()
+		if f.IsControl() {
+			data.WriteString(f.Value)
+		} else {
+			data.WriteByte(indicator(f.Ind1))
+			data.WriteByte(indicator(f.Ind2))
+			for _, sf := range f.Subfields {
+				data.WriteByte(subfieldDelim)
+				data.WriteByte(sf.Code)
+				data.WriteString(sf.Value)
+			}
+		}
+		data.WriteByte(fieldTerminator)
+		fmt.Fprintf(&directory, "%s%04d%05d", f.Tag, data.Len()-start, start)
+	}
+	directory.WriteByte(fieldTerminator)
+	data.WriteByte(recordTerminator)
+
+	base := leaderLength + directory.Len()
+	length := base + data.Len()
+	if length > 99999 {
+		return fmt.Errorf("%w: longer than 99999 bytes", ErrInvalidRecord)
+	}
+
+	leader := rec.Leader
+	if len(leader) != leaderLength {
+		leader = "00000nam a2200000 a 4500"
+	}
+	leader = fmt.Sprintf("%05d%s%05d%s", length, leader[5:12], base, leader[17:])
+
+	_, err := io.WriteString(w.w, leader+directory.String()+data.String())
+	return err
+}
+
+// indicator returns ind, or a blank for an unset indicator.
+func indicator(ind byte) byte {
+	if ind == 0 {
+		return ' '
+	}
+	return ind
+}
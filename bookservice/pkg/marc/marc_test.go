@@ -0,0 +1,105 @@
+package marc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func sampleRecord() *Record {
+	return &Record{
+		Leader: "00000nam a2200000 a 4500",
+		Fields: []Field{
+			{Tag: "001", Value: "12345"},
+			{Tag: "020", Ind1: ' ', Ind2: ' ', Subfields: []Subfield{{Code: 'a', Value: "9780306406157"}}},
+			{Tag: "100", Ind1: '1', Ind2: ' ', Subfields: []Subfield{{Code: 'a', Value: "Doe, Jane"}}},
+			{Tag: "245", Ind1: '1', Ind2: '0', Subfields: []Subfield{{Code: 'a', Value: "Buku Contoh :"}, {Code: 'b', Value: "édition spéciale"}}},
+		},
+	}
+}
+
+func encode(t *testing.T, rec *Record) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := NewWriter(&buf).Write(rec); err != nil {
+		t.Fatalf("expected no error writing, got %v", err)
+	}
+	return buf.Bytes()
+}
+
+// Test Write dan Read: Record yang ditulis terbaca kembali sama persis
+func TestRoundTrip(t *testing.T) {
+	rec := sampleRecord()
+	data := encode(t, rec)
+
+	reader := NewReader(bytes.NewReader(append(append(data, '\n'), data...)))
+	for i := 0; i < 2; i++ {
+		got, err := reader.Read()
+		if err != nil {
+			t.Fatalf("record %d: expected no error, got %v", i, err)
+		}
+		if !reflect.DeepEqual(got.Fields, rec.Fields) {
+			t.Errorf("record %d: fields = %+v, want %+v", i, got.Fields, rec.Fields)
+		}
+		if got.Leader[5:12] != rec.Leader[5:12] || got.Leader[17:] != rec.Leader[17:] {
+			t.Errorf("record %d: leader = %q, want it to keep %q", i, got.Leader, rec.Leader)
+		}
+		if got.Subfield("245", 'b') != "édition spéciale" || got.ControlField("001") != "12345" {
+			t.Errorf("record %d: lookups failed on %+v", i, got)
+		}
+	}
+
+	if _, err := reader.Read(); err != io.EOF {
+		t.Errorf("expected io.EOF after the last record, got %v", err)
+	}
+}
+
+// Test Read: Record rusak ditolak dengan ErrInvalidRecord, tanpa panic
+func TestReadInvalid(t *testing.T) {
+	valid := string(encode(t, sampleRecord()))
+	base, _ := strconv.Atoi(valid[12:17])
+	entry := valid[leaderLength : leaderLength+directoryEntry]
+
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"truncated", valid[:len(valid)/2]},
+		{"truncated with terminator", valid[:len(valid)/2] + string(rune(recordTerminator))},
+		{"too short", "00024nam" + string(rune(recordTerminator))},
+		{"non-digit base address", valid[:12] + "00a49" + valid[17:]},
+		{"negative base address", valid[:12] + "-0049" + valid[17:]},
+		{"base address inside leader", valid[:12] + "00010" + valid[17:]},
+		{"base address past the end", valid[:12] + "99999" + valid[17:]},
+		{"field start before the record", strings.Replace(valid, entry, entry[:7]+"-9999", 1)},
+		{"directory not whole entries", valid[:12] + fmt.Sprintf("%05d", base+1) + valid[17:]},
+		{"negative field start", strings.Replace(valid, entry, entry[:7]+"-0001", 1)},
+		{"signed field length", strings.Replace(valid, entry, entry[:3]+"+006"+entry[7:], 1)},
+		{"non-digit field length", strings.Replace(valid, entry, entry[:3]+"00x6"+entry[7:], 1)},
+		{"zero field length", strings.Replace(valid, entry, entry[:3]+"0000"+entry[7:], 1)},
+		{"field past the end", strings.Replace(valid, entry, entry[:3]+"9999"+entry[7:], 1)},
+		{"field start past the end", strings.Replace(valid, entry, entry[:7]+"99999", 1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewReader(strings.NewReader(tt.data)).Read()
+			if !errors.Is(err, ErrInvalidRecord) {
+				t.Errorf("expected ErrInvalidRecord, got %v", err)
+			}
+		})
+	}
+}
+
+// Test Write: Tag harus tiga karakter
+func TestWriteBadTag(t *testing.T) {
+	err := NewWriter(io.Discard).Write(&Record{Fields: []Field{{Tag: "24", Value: "x"}}})
+	if !errors.Is(err, ErrInvalidRecord) {
+		t.Errorf("expected ErrInvalidRecord, got %v", err)
+	}
+}
@@ -0,0 +1,131 @@
+package metadata
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/isbn"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/marc"
+)
+
+// File looks books up in a catalogue file loaded into memory, so lookups
+// work offline. Files ending in .mrc or .marc hold MARC 21 records; any other
+// file holds JSON, either an array of records or one record per line.
+type File struct {
+	books map[string]*Record
+}
+
+// fileRecord is a book in a JSON catalogue file.
+type fileRecord struct {
+	ISBN          string `json:"isbn"`
+	Title         string `json:"title"`
+	Author        string `json:"author"`
+	PublishedDate string `json:"published_date"`
+}
+
+// NewFile loads the catalogue file at path. Records without a valid ISBN are
+// skipped.
+func NewFile(path string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open catalogue file: %w", err)
+	}
+	defer f.Close()
+
+	p := &File{books: map[string]*Record{}}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".mrc", ".marc":
+		err = p.loadMARC(f)
+	default:
+		err = p.loadJSON(f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load catalogue file %s: %w", path, err)
+	}
+
+	return p, nil
+}
+
+func (p *File) Name() string {
+	return "file"
+}
+
+func (p *File) Lookup(ctx context.Context, isbn13 string) (*Record, error) {
+	record, ok := p.books[isbn13]
+	if !ok {
+		return nil, nil
+	}
+	copied := *record
+	copied.ISBN = isbn13
+	return &copied, nil
+}
+
+func (p *File) loadMARC(r io.Reader) error {
+	reader := marc.NewReader(r)
+	for {
+		rec, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		record, isbns := FromMARC(rec)
+		record.Source = p.Name()
+		for _, isbn13 := range isbns {
+			p.books[isbn13] = record
+		}
+	}
+}
+
+func (p *File) loadJSON(r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	var records []fileRecord
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &records); err != nil {
+			return err
+		}
+	} else {
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for line := 1; scanner.Scan(); line++ {
+			if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+				continue
+			}
+			var record fileRecord
+			if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+				return fmt.Errorf("line %d: %w", line, err)
+			}
+			records = append(records, record)
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+	}
+
+	for _, record := range records {
+		isbn13, err := isbn.ToISBN13(record.ISBN)
+		if err != nil {
+			continue
+		}
+		p.books[isbn13] = &Record{
+			Title:         record.Title,
+			Author:        record.Author,
+			PublishedDate: ParseDate(record.PublishedDate),
+			Source:        p.Name(),
+		}
+	}
+	return nil
+}
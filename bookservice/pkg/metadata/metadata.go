@@ -0,0 +1,149 @@
+// Package metadata looks up bibliographic details of books by ISBN from
+// pluggable providers, such as an Open Library style web service or a local
+// catalogue file for offline use.
+package metadata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/isbn"
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/marc"
+)
+
+var (
+	ErrNoProviders    = errors.New("no book metadata providers are configured")
+	ErrProviderFailed = errors.New("book metadata provider failed")
+)
+
+// Record holds what a provider knows about a book. ISBN is in its ISBN-13
+// form and Source names the provider it came from.
+type Record struct {
+	ISBN          string     `json:"isbn"`
+	Title         string     `json:"title"`
+	Author        string     `json:"author"`
+	PublishedDate *time.Time `json:"published_date"`
+	Source        string     `json:"source"`
+}
+
+// Provider looks up books by the ISBN-13 form of their ISBN. Lookup returns
+// nil, nil when the provider doesn't know the book.
+type Provider interface {
+	Name() string
+	Lookup(ctx context.Context, isbn13 string) (*Record, error)
+}
+
+// Chain asks its providers in order and returns the first record found.
+// A failing provider is skipped; its error is only returned when no other
+// provider knows the book.
+type Chain []Provider
+
+func (c Chain) Name() string {
+	names := make([]string, len(c))
+	for i, p := range c {
+		names[i] = p.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+func (c Chain) Lookup(ctx context.Context, isbn13 string) (*Record, error) {
+	if len(c) == 0 {
+		return nil, ErrNoProviders
+	}
+
+	var lastErr error
+	for _, p := range c {
+		record, err := p.Lookup(ctx, isbn13)
+		if err != nil {
+			log.Printf("[Metadata - %s] Error looking up ISBN %s: %v", p.Name(), isbn13, err)
+			lastErr = err
+			continue
+		}
+		if record != nil {
+			return record, nil
+		}
+	}
+
+	if lastErr != nil {
+		return nil, fmt.Errorf("%w: %v", ErrProviderFailed, lastErr)
+	}
+	return nil, nil
+}
+
+var (
+	yearPattern = regexp.MustCompile(`\b(1[5-9]|20)\d{2}\b`)
+	dateLayouts = []string{time.DateOnly, "January 2, 2006", "Jan 2, 2006", "2 January 2006", "January 2006", "Jan 2006", "2006-01", "2006"}
+)
+
+// ParseDate reads the many ways publication dates are written, from
+// "2005-03-01" and "March 1, 2005" to "c2005." in MARC records. Dates with
+// only a year or month fall on the first day of it. It returns nil when no
+// date can be found.
+func ParseDate(s string) *time.Time {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return &t
+		}
+	}
+
+	if year := yearPattern.FindString(s); year != "" {
+		t, _ := time.Parse("2006", year)
+		return &t
+	}
+	return nil
+}
+
+// FromMARC reads a MARC 21 bibliographic record into a Record, along with the
+// ISBN-13 form of every valid ISBN in it. The record's ISBN is the first of
+// them.
+func FromMARC(rec *marc.Record) (*Record, []string) {
+	var isbns []string
+	for _, f := range rec.FieldsByTag("020") {
+		// $a is often followed by a qualifier such as "(pbk.)"
+		raw, _, _ := strings.Cut(strings.TrimSpace(f.Subfield('a')), " ")
+		if isbn13, err := isbn.ToISBN13(raw); err == nil {
+			isbns = append(isbns, isbn13)
+		}
+	}
+
+	title := trimISBD(rec.Subfield("245", 'a'))
+	if subtitle := trimISBD(rec.Subfield("245", 'b')); subtitle != "" {
+		title += ": " + subtitle
+	}
+
+	author := trimISBD(rec.Subfield("100", 'a'))
+	if author == "" {
+		author = trimISBD(rec.Subfield("700", 'a'))
+	}
+
+	date := ParseDate(rec.Subfield("264", 'c'))
+	if date == nil {
+		date = ParseDate(rec.Subfield("260", 'c'))
+	}
+	// 008/07-10 holds the year of publication
+	if fixed := rec.ControlField("008"); date == nil && len(fixed) >= 11 {
+		date = ParseDate(fixed[7:11])
+	}
+
+	record := &Record{Title: title, Author: author, PublishedDate: date}
+	if len(isbns) > 0 {
+		record.ISBN = isbns[0]
+	}
+	return record, isbns
+}
+
+// trimISBD drops the punctuation cataloguing rules leave at the end of MARC
+// subfields, as in "Dune /".
+func trimISBD(s string) string {
+	return strings.TrimSpace(strings.TrimRight(strings.TrimSpace(s), " /:;,="))
+}
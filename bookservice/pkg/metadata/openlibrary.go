@@ -0,0 +1,88 @@
+package metadata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// OpenLibrary looks books up through the Open Library books API, or any
+// service answering the same /api/books requests.
+type OpenLibrary struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewOpenLibrary returns a provider calling the API at baseURL, such as
+// https://openlibrary.org, giving up on a request after timeout.
+func NewOpenLibrary(baseURL string, timeout time.Duration) *OpenLibrary {
+	return &OpenLibrary{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: timeout},
+	}
+}
+
+type openLibraryBook struct {
+	Title    string `json:"title"`
+	Subtitle string `json:"subtitle"`
+	Authors  []struct {
+		Name string `json:"name"`
+	} `json:"authors"`
+	PublishDate string `json:"publish_date"`
+}
+
+func (p *OpenLibrary) Name() string {
+	return "openlibrary"
+}
+
+func (p *OpenLibrary) Lookup(ctx context.Context, isbn13 string) (*Record, error) {
+	key := "ISBN:" + isbn13
+	query := url.Values{"bibkeys": {key}, "format": {"json"}, "jscmd": {"data"}}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"/api/books?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call open library: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("open library answered %s", resp.Status)
+	}
+
+	var books map[string]openLibraryBook
+	if err := json.NewDecoder(resp.Body).Decode(&books); err != nil {
+		return nil, fmt.Errorf("failed to decode open library response: %w", err)
+	}
+
+	book, ok := books[key]
+	if !ok {
+		return nil, nil
+	}
+
+	record := &Record{
+		ISBN:          isbn13,
+		Title:         book.Title,
+		PublishedDate: ParseDate(book.PublishDate),
+		Source:        p.Name(),
+	}
+	if book.Subtitle != "" {
+		record.Title += ": " + book.Subtitle
+	}
+	names := make([]string, 0, len(book.Authors))
+	for _, author := range book.Authors {
+		names = append(names, author.Name)
+	}
+	record.Author = strings.Join(names, ", ")
+
+	return record, nil
+}
@@ -0,0 +1,76 @@
+// Package pagination handles the opaque cursors of keyset paginated lists.
+// A cursor holds the sort keys of the last row of a page as text, so the next
+// page starts right after that row however rows were added or removed since.
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+type cursor struct {
+	Sort  string   `json:"s,omitempty"`
+	Query string   `json:"q,omitempty"`
+	Keys  []string `json:"k"`
+}
+
+// Encode returns the cursor pointing after a row with the given sort keys.
+// sort names the order the keys belong to.
+func Encode(sort string, keys []string) string {
+	return EncodeQuery(sort, "", keys)
+}
+
+// EncodeQuery is Encode for an order whose keys depend on a search query,
+// such as relevance, binding the cursor to that query.
+func EncodeQuery(sort, query string, keys []string) string {
+	if len(keys) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(cursor{Sort: sort, Query: query, Keys: keys})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Decode returns the sort keys of a cursor made by Encode, or nil for an
+// empty token. A cursor made for another sort order is rejected with
+// ErrInvalidCursor.
+func Decode(token, sort string) ([]string, error) {
+	return DecodeQuery(token, sort, "")
+}
+
+// DecodeQuery returns the sort keys of a cursor made by EncodeQuery. A cursor
+// made for another sort order or another query is rejected with
+// ErrInvalidCursor.
+func DecodeQuery(token, sort, query string) ([]string, error) {
+	if token == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if c.Sort != sort || c.Query != query || len(c.Keys) == 0 {
+		return nil, ErrInvalidCursor
+	}
+	return c.Keys, nil
+}
+
+// Limit returns the page size to use for a requested one: def when none was
+// requested and at most max.
+func Limit(requested, def, max int) int {
+	if requested < 1 {
+		return def
+	}
+	if requested > max {
+		return max
+	}
+	return requested
+}
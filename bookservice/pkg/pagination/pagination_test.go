@@ -0,0 +1,107 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// Test Encode dan Decode: Cursor kembali ke sort key yang sama
+func TestEncodeDecode_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		sort  string
+		query string
+		keys  []string
+	}{
+		{"single key", "name", "", []string{"42"}},
+		{"with sort", "-title", "", []string{"Dune", "7d0f3c0e-1a2b-4c3d-8e9f-0a1b2c3d4e5f"}},
+		{"bound to query", "relevance", "dune:*", []string{"0.0607927", "Dune", "7d0f3c0e-1a2b-4c3d-8e9f-0a1b2c3d4e5f"}},
+		{"empty key", "author", "", []string{"", "7d0f3c0e-1a2b-4c3d-8e9f-0a1b2c3d4e5f"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := EncodeQuery(tt.sort, tt.query, tt.keys)
+			if token == "" {
+				t.Fatal("expected a cursor")
+			}
+
+			keys, err := DecodeQuery(token, tt.sort, tt.query)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if !reflect.DeepEqual(keys, tt.keys) {
+				t.Errorf("keys = %v, want %v", keys, tt.keys)
+			}
+		})
+	}
+}
+
+// Test Encode: Tanpa key (halaman terakhir) tidak ada cursor, dan cursor
+// kosong berarti halaman pertama
+func TestEncode_LastPage(t *testing.T) {
+	if token := Encode("name", nil); token != "" {
+		t.Errorf("Encode() = %q, want no cursor", token)
+	}
+
+	keys, err := Decode("", "name")
+	if err != nil || keys != nil {
+		t.Errorf("Decode() = %v, %v, want nil, nil", keys, err)
+	}
+}
+
+// Test Decode: Cursor yang diubah atau dibuat untuk sort atau query lain
+// ditolak
+func TestDecode_Invalid(t *testing.T) {
+	raw := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+
+	tests := []struct {
+		name  string
+		token string
+		sort  string
+		query string
+	}{
+		{"not base64", "not a cursor!", "name", ""},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte(`{"s":"name","k":["a"]}`)), "name", ""},
+		{"not json", raw("name:a"), "name", ""},
+		{"wrong key type", raw(`{"s":"name","k":[1]}`), "name", ""},
+		{"no keys", raw(`{"s":"name","k":[]}`), "name", ""},
+		{"other sort", Encode("title", []string{"Dune", "1"}), "-title", ""},
+		{"sort removed", Encode("title", []string{"Dune", "1"}), "", ""},
+		{"other query", EncodeQuery("relevance", "dune:*", []string{"0.1", "Dune", "1"}), "relevance", "emma:*"},
+		{"query dropped", EncodeQuery("relevance", "dune:*", []string{"0.1", "Dune", "1"}), "relevance", ""},
+		{"query added", Encode("relevance", []string{"0.1", "Dune", "1"}), "relevance", "dune:*"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := DecodeQuery(tt.token, tt.sort, tt.query)
+			if !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("DecodeQuery() = %v, %v, want ErrInvalidCursor", keys, err)
+			}
+		})
+	}
+}
+
+// Test Limit: Default saat tidak diminta, dibatasi max
+func TestLimit(t *testing.T) {
+	tests := []struct {
+		requested int
+		want      int
+	}{
+		{0, 20},
+		{-5, 20},
+		{1, 1},
+		{50, 50},
+		{100, 100},
+		{101, 100},
+	}
+
+	for _, tt := range tests {
+		if got := Limit(tt.requested, 20, 100); got != tt.want {
+			t.Errorf("Limit(%d) = %d, want %d", tt.requested, got, tt.want)
+		}
+	}
+}
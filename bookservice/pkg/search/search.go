@@ -0,0 +1,36 @@
+// Package search turns free text into PostgreSQL full-text queries.
+package search
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/sir-shalahuddin/grpc-learn/bookservice/pkg/isbn"
+)
+
+var (
+	searchWord = regexp.MustCompile(`[\p{L}\p{N}]+`)
+	isbnLike   = regexp.MustCompile(`^[0-9Xx]+(-[0-9Xx]+)+$`)
+)
+
+// PrefixQuery turns free text into a to_tsquery expression matching books
+// that contain every word, each also as a prefix so "harr pot" finds "Harry
+// Potter". Hyphenated ISBNs are joined to match how they are stored, and
+// complete ones are searched in their ISBN-13 form so either form finds the
+// book. It returns "" when the text has no words, in which case nothing is
+// searched.
+func PrefixQuery(text string) string {
+	var terms []string
+	for _, field := range strings.Fields(strings.ToLower(text)) {
+		if isbnLike.MatchString(field) {
+			field = strings.ReplaceAll(field, "-", "")
+		}
+		if isbn13, err := isbn.ToISBN13(field); err == nil {
+			field = isbn13
+		}
+		for _, word := range searchWord.FindAllString(field, -1) {
+			terms = append(terms, word+":*")
+		}
+	}
+	return strings.Join(terms, " & ")
+}
@@ -2,7 +2,7 @@
 // versions:
 // - protoc-gen-go-grpc v1.5.1
 // - protoc             v5.28.0
-// source: proto/book.proto
+// source: proto/bookservice/book.proto
 
 package proto
 
@@ -19,8 +19,12 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	BookService_GetBooks_FullMethodName   = "/BookService/GetBooks"
-	BookService_BorrowBook_FullMethodName = "/BookService/BorrowBook"
+	BookService_GetBooks_FullMethodName              = "/BookService/GetBooks"
+	BookService_BorrowBook_FullMethodName            = "/BookService/BorrowBook"
+	BookService_ExportUserData_FullMethodName        = "/BookService/ExportUserData"
+	BookService_EraseUserData_FullMethodName         = "/BookService/EraseUserData"
+	BookService_CountBooksByCategory_FullMethodName  = "/BookService/CountBooksByCategory"
+	BookService_ReassignBooksCategory_FullMethodName = "/BookService/ReassignBooksCategory"
 )
 
 // BookServiceClient is the client API for BookService service.
@@ -29,6 +33,12 @@ const (
 type BookServiceClient interface {
 	GetBooks(ctx context.Context, in *GetBooksRequest, opts ...grpc.CallOption) (*BookListResponse, error)
 	BorrowBook(ctx context.Context, in *BorrowBookRequest, opts ...grpc.CallOption) (*BorrowBookResponse, error)
+	ExportUserData(ctx context.Context, in *ExportUserDataRequest, opts ...grpc.CallOption) (*ExportUserDataResponse, error)
+	EraseUserData(ctx context.Context, in *EraseUserDataRequest, opts ...grpc.CallOption) (*EraseUserDataResponse, error)
+	CountBooksByCategory(ctx context.Context, in *CountBooksByCategoryRequest, opts ...grpc.CallOption) (*CountBooksByCategoryResponse, error)
+	// ReassignBooksCategory moves every book of from_category_id to
+	// to_category_id.
+	ReassignBooksCategory(ctx context.Context, in *ReassignBooksCategoryRequest, opts ...grpc.CallOption) (*ReassignBooksCategoryResponse, error)
 }
 
 type bookServiceClient struct {
@@ -59,12 +69,58 @@ func (c *bookServiceClient) BorrowBook(ctx context.Context, in *BorrowBookReques
 	return out, nil
 }
 
+func (c *bookServiceClient) ExportUserData(ctx context.Context, in *ExportUserDataRequest, opts ...grpc.CallOption) (*ExportUserDataResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExportUserDataResponse)
+	err := c.cc.Invoke(ctx, BookService_ExportUserData_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookServiceClient) EraseUserData(ctx context.Context, in *EraseUserDataRequest, opts ...grpc.CallOption) (*EraseUserDataResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EraseUserDataResponse)
+	err := c.cc.Invoke(ctx, BookService_EraseUserData_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookServiceClient) CountBooksByCategory(ctx context.Context, in *CountBooksByCategoryRequest, opts ...grpc.CallOption) (*CountBooksByCategoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CountBooksByCategoryResponse)
+	err := c.cc.Invoke(ctx, BookService_CountBooksByCategory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookServiceClient) ReassignBooksCategory(ctx context.Context, in *ReassignBooksCategoryRequest, opts ...grpc.CallOption) (*ReassignBooksCategoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReassignBooksCategoryResponse)
+	err := c.cc.Invoke(ctx, BookService_ReassignBooksCategory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // BookServiceServer is the server API for BookService service.
 // All implementations must embed UnimplementedBookServiceServer
 // for forward compatibility.
 type BookServiceServer interface {
 	GetBooks(context.Context, *GetBooksRequest) (*BookListResponse, error)
 	BorrowBook(context.Context, *BorrowBookRequest) (*BorrowBookResponse, error)
+	ExportUserData(context.Context, *ExportUserDataRequest) (*ExportUserDataResponse, error)
+	EraseUserData(context.Context, *EraseUserDataRequest) (*EraseUserDataResponse, error)
+	CountBooksByCategory(context.Context, *CountBooksByCategoryRequest) (*CountBooksByCategoryResponse, error)
+	// ReassignBooksCategory moves every book of from_category_id to
+	// to_category_id.
+	ReassignBooksCategory(context.Context, *ReassignBooksCategoryRequest) (*ReassignBooksCategoryResponse, error)
 	mustEmbedUnimplementedBookServiceServer()
 }
 
@@ -81,6 +137,18 @@ func (UnimplementedBookServiceServer) GetBooks(context.Context, *GetBooksRequest
 func (UnimplementedBookServiceServer) BorrowBook(context.Context, *BorrowBookRequest) (*BorrowBookResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method BorrowBook not implemented")
 }
+func (UnimplementedBookServiceServer) ExportUserData(context.Context, *ExportUserDataRequest) (*ExportUserDataResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExportUserData not implemented")
+}
+func (UnimplementedBookServiceServer) EraseUserData(context.Context, *EraseUserDataRequest) (*EraseUserDataResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EraseUserData not implemented")
+}
+func (UnimplementedBookServiceServer) CountBooksByCategory(context.Context, *CountBooksByCategoryRequest) (*CountBooksByCategoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CountBooksByCategory not implemented")
+}
+func (UnimplementedBookServiceServer) ReassignBooksCategory(context.Context, *ReassignBooksCategoryRequest) (*ReassignBooksCategoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReassignBooksCategory not implemented")
+}
 func (UnimplementedBookServiceServer) mustEmbedUnimplementedBookServiceServer() {}
 func (UnimplementedBookServiceServer) testEmbeddedByValue()                     {}
 
@@ -138,6 +206,78 @@ func _BookService_BorrowBook_Handler(srv interface{}, ctx context.Context, dec f
 	return interceptor(ctx, in, info, handler)
 }
 
+func _BookService_ExportUserData_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExportUserDataRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookServiceServer).ExportUserData(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BookService_ExportUserData_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookServiceServer).ExportUserData(ctx, req.(*ExportUserDataRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookService_EraseUserData_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EraseUserDataRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookServiceServer).EraseUserData(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BookService_EraseUserData_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookServiceServer).EraseUserData(ctx, req.(*EraseUserDataRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookService_CountBooksByCategory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CountBooksByCategoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookServiceServer).CountBooksByCategory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BookService_CountBooksByCategory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookServiceServer).CountBooksByCategory(ctx, req.(*CountBooksByCategoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookService_ReassignBooksCategory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReassignBooksCategoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookServiceServer).ReassignBooksCategory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BookService_ReassignBooksCategory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookServiceServer).ReassignBooksCategory(ctx, req.(*ReassignBooksCategoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // BookService_ServiceDesc is the grpc.ServiceDesc for BookService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -153,7 +293,23 @@ var BookService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "BorrowBook",
 			Handler:    _BookService_BorrowBook_Handler,
 		},
+		{
+			MethodName: "ExportUserData",
+			Handler:    _BookService_ExportUserData_Handler,
+		},
+		{
+			MethodName: "EraseUserData",
+			Handler:    _BookService_EraseUserData_Handler,
+		},
+		{
+			MethodName: "CountBooksByCategory",
+			Handler:    _BookService_CountBooksByCategory_Handler,
+		},
+		{
+			MethodName: "ReassignBooksCategory",
+			Handler:    _BookService_ReassignBooksCategory_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
-	Metadata: "proto/book.proto",
+	Metadata: "proto/bookservice/book.proto",
 }
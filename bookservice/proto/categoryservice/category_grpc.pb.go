@@ -19,8 +19,14 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	BookCategoryService_GetCategories_FullMethodName   = "/BookCategoryService/GetCategories"
-	BookCategoryService_GetCategoryByID_FullMethodName = "/BookCategoryService/GetCategoryByID"
+	BookCategoryService_GetCategories_FullMethodName        = "/BookCategoryService/GetCategories"
+	BookCategoryService_GetCategoryByID_FullMethodName      = "/BookCategoryService/GetCategoryByID"
+	BookCategoryService_GetCategoryBySlug_FullMethodName    = "/BookCategoryService/GetCategoryBySlug"
+	BookCategoryService_GetCategorySubtree_FullMethodName   = "/BookCategoryService/GetCategorySubtree"
+	BookCategoryService_GetCategoryAncestors_FullMethodName = "/BookCategoryService/GetCategoryAncestors"
+	BookCategoryService_MoveCategory_FullMethodName         = "/BookCategoryService/MoveCategory"
+	BookCategoryService_MergeCategory_FullMethodName        = "/BookCategoryService/MergeCategory"
+	BookCategoryService_WatchCategories_FullMethodName      = "/BookCategoryService/WatchCategories"
 )
 
 // BookCategoryServiceClient is the client API for BookCategoryService service.
@@ -29,6 +35,24 @@ const (
 type BookCategoryServiceClient interface {
 	GetCategories(ctx context.Context, in *GetCategoriesRequest, opts ...grpc.CallOption) (*CategoryListResponse, error)
 	GetCategoryByID(ctx context.Context, in *GetCategoryByIDRequest, opts ...grpc.CallOption) (*CategoryResponse, error)
+	GetCategoryBySlug(ctx context.Context, in *GetCategoryBySlugRequest, opts ...grpc.CallOption) (*CategoryResponse, error)
+	// GetCategorySubtree returns a category and all of its descendants,
+	// parents before their children.
+	GetCategorySubtree(ctx context.Context, in *GetCategoryByIDRequest, opts ...grpc.CallOption) (*CategoryListResponse, error)
+	// GetCategoryAncestors returns the path from the top level down to and
+	// including the category.
+	GetCategoryAncestors(ctx context.Context, in *GetCategoryByIDRequest, opts ...grpc.CallOption) (*CategoryListResponse, error)
+	// MoveCategory places a category under parent_id, or at the top level
+	// when it is empty. Requires a librarian token in the authorization
+	// metadata.
+	MoveCategory(ctx context.Context, in *MoveCategoryRequest, opts ...grpc.CallOption) (*CategoryResponse, error)
+	// MergeCategory folds source_id into target_id, moving its books and
+	// subcategories. Requires a librarian token in the authorization metadata.
+	MergeCategory(ctx context.Context, in *MergeCategoryRequest, opts ...grpc.CallOption) (*MergeCategoryResponse, error)
+	// WatchCategories streams every category change after from_revision,
+	// oldest first. Take the starting revision from GetCategories and resume
+	// with the last revision received after reconnecting.
+	WatchCategories(ctx context.Context, in *WatchCategoriesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CategoryEvent], error)
 }
 
 type bookCategoryServiceClient struct {
@@ -59,12 +83,99 @@ func (c *bookCategoryServiceClient) GetCategoryByID(ctx context.Context, in *Get
 	return out, nil
 }
 
+func (c *bookCategoryServiceClient) GetCategoryBySlug(ctx context.Context, in *GetCategoryBySlugRequest, opts ...grpc.CallOption) (*CategoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CategoryResponse)
+	err := c.cc.Invoke(ctx, BookCategoryService_GetCategoryBySlug_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookCategoryServiceClient) GetCategorySubtree(ctx context.Context, in *GetCategoryByIDRequest, opts ...grpc.CallOption) (*CategoryListResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CategoryListResponse)
+	err := c.cc.Invoke(ctx, BookCategoryService_GetCategorySubtree_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookCategoryServiceClient) GetCategoryAncestors(ctx context.Context, in *GetCategoryByIDRequest, opts ...grpc.CallOption) (*CategoryListResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CategoryListResponse)
+	err := c.cc.Invoke(ctx, BookCategoryService_GetCategoryAncestors_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookCategoryServiceClient) MoveCategory(ctx context.Context, in *MoveCategoryRequest, opts ...grpc.CallOption) (*CategoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CategoryResponse)
+	err := c.cc.Invoke(ctx, BookCategoryService_MoveCategory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookCategoryServiceClient) MergeCategory(ctx context.Context, in *MergeCategoryRequest, opts ...grpc.CallOption) (*MergeCategoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MergeCategoryResponse)
+	err := c.cc.Invoke(ctx, BookCategoryService_MergeCategory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookCategoryServiceClient) WatchCategories(ctx context.Context, in *WatchCategoriesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CategoryEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &BookCategoryService_ServiceDesc.Streams[0], BookCategoryService_WatchCategories_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchCategoriesRequest, CategoryEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type BookCategoryService_WatchCategoriesClient = grpc.ServerStreamingClient[CategoryEvent]
+
 // BookCategoryServiceServer is the server API for BookCategoryService service.
 // All implementations must embed UnimplementedBookCategoryServiceServer
 // for forward compatibility.
 type BookCategoryServiceServer interface {
 	GetCategories(context.Context, *GetCategoriesRequest) (*CategoryListResponse, error)
 	GetCategoryByID(context.Context, *GetCategoryByIDRequest) (*CategoryResponse, error)
+	GetCategoryBySlug(context.Context, *GetCategoryBySlugRequest) (*CategoryResponse, error)
+	// GetCategorySubtree returns a category and all of its descendants,
+	// parents before their children.
+	GetCategorySubtree(context.Context, *GetCategoryByIDRequest) (*CategoryListResponse, error)
+	// GetCategoryAncestors returns the path from the top level down to and
+	// including the category.
+	GetCategoryAncestors(context.Context, *GetCategoryByIDRequest) (*CategoryListResponse, error)
+	// MoveCategory places a category under parent_id, or at the top level
+	// when it is empty. Requires a librarian token in the authorization
+	// metadata.
+	MoveCategory(context.Context, *MoveCategoryRequest) (*CategoryResponse, error)
+	// MergeCategory folds source_id into target_id, moving its books and
+	// subcategories. Requires a librarian token in the authorization metadata.
+	MergeCategory(context.Context, *MergeCategoryRequest) (*MergeCategoryResponse, error)
+	// WatchCategories streams every category change after from_revision,
+	// oldest first. Take the starting revision from GetCategories and resume
+	// with the last revision received after reconnecting.
+	WatchCategories(*WatchCategoriesRequest, grpc.ServerStreamingServer[CategoryEvent]) error
 	mustEmbedUnimplementedBookCategoryServiceServer()
 }
 
@@ -81,6 +192,24 @@ func (UnimplementedBookCategoryServiceServer) GetCategories(context.Context, *Ge
 func (UnimplementedBookCategoryServiceServer) GetCategoryByID(context.Context, *GetCategoryByIDRequest) (*CategoryResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetCategoryByID not implemented")
 }
+func (UnimplementedBookCategoryServiceServer) GetCategoryBySlug(context.Context, *GetCategoryBySlugRequest) (*CategoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCategoryBySlug not implemented")
+}
+func (UnimplementedBookCategoryServiceServer) GetCategorySubtree(context.Context, *GetCategoryByIDRequest) (*CategoryListResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCategorySubtree not implemented")
+}
+func (UnimplementedBookCategoryServiceServer) GetCategoryAncestors(context.Context, *GetCategoryByIDRequest) (*CategoryListResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCategoryAncestors not implemented")
+}
+func (UnimplementedBookCategoryServiceServer) MoveCategory(context.Context, *MoveCategoryRequest) (*CategoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MoveCategory not implemented")
+}
+func (UnimplementedBookCategoryServiceServer) MergeCategory(context.Context, *MergeCategoryRequest) (*MergeCategoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MergeCategory not implemented")
+}
+func (UnimplementedBookCategoryServiceServer) WatchCategories(*WatchCategoriesRequest, grpc.ServerStreamingServer[CategoryEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchCategories not implemented")
+}
 func (UnimplementedBookCategoryServiceServer) mustEmbedUnimplementedBookCategoryServiceServer() {}
 func (UnimplementedBookCategoryServiceServer) testEmbeddedByValue()                             {}
 
@@ -138,6 +267,107 @@ func _BookCategoryService_GetCategoryByID_Handler(srv interface{}, ctx context.C
 	return interceptor(ctx, in, info, handler)
 }
 
+func _BookCategoryService_GetCategoryBySlug_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCategoryBySlugRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookCategoryServiceServer).GetCategoryBySlug(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BookCategoryService_GetCategoryBySlug_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookCategoryServiceServer).GetCategoryBySlug(ctx, req.(*GetCategoryBySlugRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookCategoryService_GetCategorySubtree_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCategoryByIDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookCategoryServiceServer).GetCategorySubtree(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BookCategoryService_GetCategorySubtree_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookCategoryServiceServer).GetCategorySubtree(ctx, req.(*GetCategoryByIDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookCategoryService_GetCategoryAncestors_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCategoryByIDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookCategoryServiceServer).GetCategoryAncestors(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BookCategoryService_GetCategoryAncestors_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookCategoryServiceServer).GetCategoryAncestors(ctx, req.(*GetCategoryByIDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookCategoryService_MoveCategory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MoveCategoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookCategoryServiceServer).MoveCategory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BookCategoryService_MoveCategory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookCategoryServiceServer).MoveCategory(ctx, req.(*MoveCategoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookCategoryService_MergeCategory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MergeCategoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookCategoryServiceServer).MergeCategory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BookCategoryService_MergeCategory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookCategoryServiceServer).MergeCategory(ctx, req.(*MergeCategoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookCategoryService_WatchCategories_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchCategoriesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BookCategoryServiceServer).WatchCategories(m, &grpc.GenericServerStream[WatchCategoriesRequest, CategoryEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type BookCategoryService_WatchCategoriesServer = grpc.ServerStreamingServer[CategoryEvent]
+
 // BookCategoryService_ServiceDesc is the grpc.ServiceDesc for BookCategoryService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -153,7 +383,33 @@ var BookCategoryService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetCategoryByID",
 			Handler:    _BookCategoryService_GetCategoryByID_Handler,
 		},
+		{
+			MethodName: "GetCategoryBySlug",
+			Handler:    _BookCategoryService_GetCategoryBySlug_Handler,
+		},
+		{
+			MethodName: "GetCategorySubtree",
+			Handler:    _BookCategoryService_GetCategorySubtree_Handler,
+		},
+		{
+			MethodName: "GetCategoryAncestors",
+			Handler:    _BookCategoryService_GetCategoryAncestors_Handler,
+		},
+		{
+			MethodName: "MoveCategory",
+			Handler:    _BookCategoryService_MoveCategory_Handler,
+		},
+		{
+			MethodName: "MergeCategory",
+			Handler:    _BookCategoryService_MergeCategory_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchCategories",
+			Handler:       _BookCategoryService_WatchCategories_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/categoryservice/category.proto",
 }
@@ -0,0 +1,18 @@
+// Package cache provides small key/value caches with per-entry TTLs. Cache
+// failures are never fatal: a backend that can't be reached behaves like an
+// empty cache.
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Cache stores opaque values under string keys until their TTL expires.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, bool)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration)
+	Delete(ctx context.Context, keys ...string)
+	// Name identifies the backend in stats.
+	Name() string
+}
@@ -0,0 +1,105 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// Test LRU: Entry yang paling lama tidak dipakai dibuang lebih dulu
+func TestLRU_Eviction(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRU(2)
+
+	c.Set(ctx, "a", []byte("1"), time.Minute)
+	c.Set(ctx, "b", []byte("2"), time.Minute)
+	// Reading a makes b the least recently used
+	if _, ok := c.Get(ctx, "a"); !ok {
+		t.Fatal("expected a to be cached")
+	}
+	c.Set(ctx, "c", []byte("3"), time.Minute)
+
+	if _, ok := c.Get(ctx, "b"); ok {
+		t.Error("expected b to be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := c.Get(ctx, key); !ok {
+			t.Errorf("expected %s to be kept", key)
+		}
+	}
+	if c.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", c.Len())
+	}
+}
+
+// Test LRU: Menulis ulang key mengganti nilainya tanpa menambah entry
+func TestLRU_Overwrite(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRU(2)
+
+	c.Set(ctx, "a", []byte("1"), time.Minute)
+	c.Set(ctx, "b", []byte("2"), time.Minute)
+	c.Set(ctx, "a", []byte("3"), time.Minute)
+	c.Set(ctx, "c", []byte("4"), time.Minute)
+
+	if value, ok := c.Get(ctx, "a"); !ok || string(value) != "3" {
+		t.Errorf("Get(a) = %q, %v, want 3, true", value, ok)
+	}
+	if _, ok := c.Get(ctx, "b"); ok {
+		t.Error("expected b to be evicted after a was written again")
+	}
+}
+
+// Test LRU: Entry kedaluwarsa tidak dikembalikan dan dibuang saat dibaca
+func TestLRU_Expiry(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRU(10)
+
+	c.Set(ctx, "short", []byte("1"), 20*time.Millisecond)
+	c.Set(ctx, "long", []byte("2"), time.Minute)
+	time.Sleep(40 * time.Millisecond)
+
+	if _, ok := c.Get(ctx, "short"); ok {
+		t.Error("expected short to have expired")
+	}
+	if _, ok := c.Get(ctx, "long"); !ok {
+		t.Error("expected long to be cached")
+	}
+	if c.Len() != 1 {
+		t.Errorf("Len() = %d, want 1 once the expired entry was read", c.Len())
+	}
+}
+
+// Test LRU: TTL nol atau negatif tidak menyimpan apa pun
+func TestLRU_NoTTL(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRU(10)
+
+	c.Set(ctx, "zero", []byte("1"), 0)
+	c.Set(ctx, "negative", []byte("1"), -time.Second)
+
+	if c.Len() != 0 {
+		t.Errorf("Len() = %d, want 0", c.Len())
+	}
+}
+
+// Test LRU: Delete membuang beberapa key sekaligus dan mengabaikan yang tidak ada
+func TestLRU_Delete(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRU(0)
+	if c.capacity != 1 {
+		t.Errorf("capacity = %d, want 1 for a non-positive capacity", c.capacity)
+	}
+
+	c = NewLRU(10)
+	c.Set(ctx, "a", []byte("1"), time.Minute)
+	c.Set(ctx, "b", []byte("2"), time.Minute)
+	c.Delete(ctx, "a", "b", "missing")
+
+	if c.Len() != 0 {
+		t.Errorf("Len() = %d, want 0", c.Len())
+	}
+	if _, ok := c.Get(ctx, "a"); ok {
+		t.Error("expected a to be deleted")
+	}
+}
@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis is a cache shared by every instance of a service. Keys are
+// namespaced with prefix.
+type Redis struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedis creates a cache on top of an existing Redis client.
+func NewRedis(client *redis.Client, prefix string) *Redis {
+	return &Redis{client: client, prefix: prefix}
+}
+
+func (c *Redis) Get(ctx context.Context, key string) ([]byte, bool) {
+	value, err := c.client.Get(ctx, c.prefix+key).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			log.Printf("[Cache - Redis] Error getting %s: %v", key, err)
+		}
+		return nil, false
+	}
+	return value, true
+}
+
+func (c *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+	if err := c.client.Set(ctx, c.prefix+key, value, ttl).Err(); err != nil {
+		log.Printf("[Cache - Redis] Error setting %s: %v", key, err)
+	}
+}
+
+func (c *Redis) Delete(ctx context.Context, keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+	prefixed := make([]string, 0, len(keys))
+	for _, key := range keys {
+		prefixed = append(prefixed, c.prefix+key)
+	}
+	if err := c.client.Del(ctx, prefixed...).Err(); err != nil {
+		log.Printf("[Cache - Redis] Error deleting keys: %v", err)
+	}
+}
+
+func (c *Redis) Name() string {
+	return "redis"
+}
+
+// Subscribe calls handle with every message published on channel until ctx
+// is done.
+func (c *Redis) Subscribe(ctx context.Context, channel string, handle func(payload string)) {
+	sub := c.client.Subscribe(ctx, channel)
+	go func() {
+		defer sub.Close()
+		ch := sub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-ch:
+				if !ok {
+					return
+				}
+				handle(msg.Payload)
+			}
+		}
+	}()
+}
@@ -0,0 +1,42 @@
+package cache
+
+import "sync/atomic"
+
+// Stats is a snapshot of how a cache has been used since the process started.
+type Stats struct {
+	Backend       string  `json:"backend"`
+	Hits          uint64  `json:"hits"`
+	Misses        uint64  `json:"misses"`
+	NegativeHits  uint64  `json:"negative_hits"`
+	Invalidations uint64  `json:"invalidations"`
+	HitRatio      float64 `json:"hit_ratio"`
+}
+
+// Counters records cache usage. The zero value is ready to use.
+type Counters struct {
+	hits          atomic.Uint64
+	misses        atomic.Uint64
+	negativeHits  atomic.Uint64
+	invalidations atomic.Uint64
+}
+
+func (c *Counters) Hit()          { c.hits.Add(1) }
+func (c *Counters) Miss()         { c.misses.Add(1) }
+func (c *Counters) NegativeHit()  { c.negativeHits.Add(1) }
+func (c *Counters) Invalidation() { c.invalidations.Add(1) }
+
+// Snapshot returns the current counters. Negative hits count as hits in the
+// ratio since they also save a round trip.
+func (c *Counters) Snapshot(backend string) Stats {
+	stats := Stats{
+		Backend:       backend,
+		Hits:          c.hits.Load(),
+		Misses:        c.misses.Load(),
+		NegativeHits:  c.negativeHits.Load(),
+		Invalidations: c.invalidations.Load(),
+	}
+	if total := stats.Hits + stats.NegativeHits + stats.Misses; total > 0 {
+		stats.HitRatio = float64(stats.Hits+stats.NegativeHits) / float64(total)
+	}
+	return stats
+}
@@ -0,0 +1,22 @@
+package cache
+
+import "testing"
+
+// Test Counters: Negative hit dihitung sebagai hit dalam rasio
+func TestCounters_Snapshot(t *testing.T) {
+	var c Counters
+	if stats := c.Snapshot("memory"); stats.HitRatio != 0 || stats.Backend != "memory" {
+		t.Errorf("expected an empty snapshot, got %+v", stats)
+	}
+
+	c.Hit()
+	c.Hit()
+	c.NegativeHit()
+	c.Miss()
+	c.Invalidation()
+
+	want := Stats{Backend: "redis", Hits: 2, Misses: 1, NegativeHits: 1, Invalidations: 1, HitRatio: 0.75}
+	if got := c.Snapshot("redis"); got != want {
+		t.Errorf("Snapshot() = %+v, want %+v", got, want)
+	}
+}
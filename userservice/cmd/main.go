@@ -8,6 +8,7 @@ import (
 	"github.com/sir-shalahuddin/grpc-learn/userservice/config"
 	_ "github.com/sir-shalahuddin/grpc-learn/userservice/docs"
 	db "github.com/sir-shalahuddin/grpc-learn/userservice/pkg/database"
+	grpcclient "github.com/sir-shalahuddin/grpc-learn/userservice/pkg/grpcclient"
 )
 
 // @title User Service API
@@ -59,15 +60,40 @@ func main() {
 		Secret: config.GetEnv("JWT_SECRET"),
 	}
 
+	OIDCConfig := config.OIDCConfig{
+		Issuer:     config.GetEnvOrDefault("OIDC_ISSUER", "http://localhost:"+AppConfig.RESTPort),
+		SigningKey: config.GetEnvOrDefault("OIDC_SIGNING_KEY", ""),
+	}
+
+	RedisConfig := config.RedisConfig{
+		Address:  config.GetEnvOrDefault("REDIS_ADDR", ""),
+		Password: config.GetEnvOrDefault("REDIS_PASSWORD", ""),
+	}
+
+	GRPCConfig := config.GRPCConfig{
+		BookAddress:  config.GetEnv("BOOK_ADDRESS"),
+		ServiceToken: config.GetEnv("GRPC_SERVICE_TOKEN"),
+	}
+
+	redisClient, err := db.NewRedis(RedisConfig)
+	if err != nil {
+		panic(err)
+	}
+
 	db, err := db.NewDB(DBConfig)
 	if err != nil {
 		panic(err)
 	}
 
+	bookClients, err := grpcclient.NewBookClients(GRPCConfig.BookAddress, GRPCConfig.ServiceToken)
+	if err != nil {
+		panic(err)
+	}
+
 	mode := strings.ToLower(AppConfig.Mode)
 	// Start REST server in a separate goroutine
 	if mode == "rest" {
-		StartRESTServer(db, AppConfig.RESTPort, JWTConfig.Secret)
+		StartRESTServer(db, redisClient, bookClients, AppConfig.RESTPort, JWTConfig.Secret, OIDCConfig)
 	}
 
 	// Start gRPC server in a separate goroutine
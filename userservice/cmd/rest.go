@@ -7,17 +7,20 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/redis/go-redis/v9"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/config"
 	router "github.com/sir-shalahuddin/grpc-learn/userservice/internal/routes"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/proto/bookservice"
 )
 
-func StartRESTServer(db *sql.DB, port string, jwtSecret string) {
+func StartRESTServer(db *sql.DB, redisClient *redis.Client, bookSvc bookservice.BookServiceClient, port string, jwtSecret string, oidcConfig config.OIDCConfig) {
 	app := fiber.New()
 
 	app.Use(cors.New())
 
 	app.Use(logger.New())
 
-	router.RegisterRoutes(app, db, jwtSecret)
+	router.RegisterRoutes(app, db, redisClient, bookSvc, jwtSecret, oidcConfig)
 	err := (app.Listen(":" + port))
 	if err != nil {
 		log.Fatalf("failed to start REST server: %v", err)
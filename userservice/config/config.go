@@ -26,6 +26,25 @@ type JWTConfig struct {
 	Secret string
 }
 
+type GRPCConfig struct {
+	BookAddress string
+	// ServiceToken is the secret bookservice expects for the gRPC methods
+	// meant only for other services
+	ServiceToken string
+}
+
+type OIDCConfig struct {
+	Issuer     string
+	SigningKey string
+}
+
+// RedisConfig points at the Redis revocation events are published on. An
+// empty address turns publishing off.
+type RedisConfig struct {
+	Address  string
+	Password string
+}
+
 func GetEnv(key string) string {
 	value := os.Getenv(key)
 	if value == "" {
@@ -34,6 +53,14 @@ func GetEnv(key string) string {
 	return value
 }
 
+func GetEnvOrDefault(key, defaultValue string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
+
 func GetEnvAsBool(key string) bool {
 	value := os.Getenv(key)
 	if value == "" {
@@ -13,8 +13,30 @@ type GetUser struct {
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 	Role      string    `json:"role"`
+	Status    string    `json:"status"`
+}
+
+// ListUsersRequest selects a page of users. Cursor is the NextCursor of the
+// previous page.
+type ListUsersRequest struct {
+	Cursor       string
+	PageSize     int
+	IncludeTotal bool
+}
+
+// ListUsersResponse is a page of users. NextCursor is empty on the last page
+// and Total is only set when it was asked for.
+type ListUsersResponse struct {
+	Users      []GetUser `json:"users"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+	Total      *int64    `json:"total,omitempty"`
 }
 
 type UpdateUserRoles struct {
 	Role string `json:"role" validate:"required"`
 }
+
+// UpdateUserStatus suspends or locks an account, or makes it active again.
+type UpdateUserStatus struct {
+	Status string `json:"status" validate:"required,oneof=active suspended locked"`
+}
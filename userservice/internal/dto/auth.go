@@ -9,6 +9,9 @@ type RegisterRequest struct {
 type LoginRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required,password"`
+
+	// Device is filled from the request headers by the handler.
+	Device SessionDevice `json:"-"`
 }
 
 type LoginResponse struct {
@@ -0,0 +1,51 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type GetDataExport struct {
+	ID          uuid.UUID  `json:"id"`
+	Status      string     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+}
+
+// DataArchive is the downloadable document produced by a data export.
+type DataArchive struct {
+	GeneratedAt      time.Time                `json:"generated_at"`
+	Profile          ArchiveProfile           `json:"profile"`
+	BorrowingRecords []ArchiveBorrowingRecord `json:"borrowing_records"`
+}
+
+type ArchiveProfile struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ArchiveBorrowingRecord struct {
+	ID         uuid.UUID  `json:"id"`
+	BookID     uuid.UUID  `json:"book_id"`
+	BookTitle  string     `json:"book_title"`
+	BookAuthor string     `json:"book_author"`
+	ISBN       string     `json:"isbn"`
+	BorrowedAt *time.Time `json:"borrowed_at"`
+	DueDate    *time.Time `json:"due_date"`
+	ReturnedAt *time.Time `json:"returned_at"`
+}
+
+type EraseAccountRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+type EraseAccountResponse struct {
+	AnonymizedRecords int64 `json:"anonymized_records"`
+}
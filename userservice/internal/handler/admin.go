@@ -5,39 +5,59 @@ import (
 	"errors"
 	"log"
 
+	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"github.com/sir-shalahuddin/grpc-learn/userservice/internal/dto"
 	"github.com/sir-shalahuddin/grpc-learn/userservice/internal/service"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/pkg/pagination"
 	"github.com/sir-shalahuddin/grpc-learn/userservice/pkg/response"
 )
 
 type AdminService interface {
-	ListUsers(ctx context.Context) ([]dto.GetUser, error)
+	ListUsers(ctx context.Context, req dto.ListUsersRequest) (*dto.ListUsersResponse, error)
 	UpdateUserRoles(ctx context.Context, userID uuid.UUID, req dto.UpdateUserRoles) error
+	UpdateUserStatus(ctx context.Context, userID uuid.UUID, req dto.UpdateUserStatus) error
 	DeleteUser(ctx context.Context, userID uuid.UUID) error
 }
 
 type adminHandler struct {
 	adminService AdminService
+	validate     *validator.Validate
 }
 
 func NewAdminHandler(adminService AdminService) *adminHandler {
-	return &adminHandler{adminService: adminService}
+	return &adminHandler{
+		adminService: adminService,
+		validate:     validator.New(),
+	}
 }
 
 // ListUsers godoc
-// @Summary List all users
-// @Description Retrieve all users from the database
+// @Summary List users
+// @Description Retrieve a page of users in sign-up order
 // @Tags users
 // @Accept json
 // @Produce json
-// @Success 200 {object} response.Response{data=[]models.User}
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param page_size query int false "Users per page, at most 200 (default 50)"
+// @Param include_total query bool false "Also count every user"
+// @Success 200 {object} response.Response{data=dto.ListUsersResponse}
+// @Failure 400 {object} response.ErrorMessage
 // @Failure 500 {object} response.ErrorMessage
 // @Router /admin/users [get]
 func (h *adminHandler) ListUsers(c *fiber.Ctx) error {
-	users, err := h.adminService.ListUsers(context.Background())
+	req := dto.ListUsersRequest{
+		Cursor:       c.Query("cursor"),
+		PageSize:     c.QueryInt("page_size"),
+		IncludeTotal: c.QueryBool("include_total"),
+	}
+
+	users, err := h.adminService.ListUsers(context.Background(), req)
 	if err != nil {
+		if errors.Is(err, pagination.ErrInvalidCursor) {
+			return response.HandleError(c, err, "", fiber.StatusBadRequest)
+		}
 		log.Printf("internal error: failed to list users: %v", err)
 		return response.HandleError(c, err, "Failed to list users", fiber.StatusInternalServerError)
 	}
@@ -84,6 +104,49 @@ func (h *adminHandler) UpdateUserRoles(c *fiber.Ctx) error {
 	return response.HandleSuccess(c, "Update user role successful", nil, fiber.StatusOK)
 }
 
+// UpdateUserStatus godoc
+// @Summary Update user status
+// @Description Suspends or locks a user's account, or makes it active again. Tokens of accounts that aren't active are rejected.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param data body dto.UpdateUserStatus true "New status"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.ErrorMessage
+// @Failure 403 {object} response.ErrorMessage
+// @Failure 404 {object} response.ErrorMessage
+// @Router /admin/users/{id}/status [put]
+func (h *adminHandler) UpdateUserStatus(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.HandleError(c, err, "Invalid request parameters", fiber.StatusBadRequest)
+	}
+
+	var req dto.UpdateUserStatus
+
+	if err := c.BodyParser(&req); err != nil {
+		return response.HandleError(c, err, "Invalid request payload", fiber.StatusBadRequest)
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		return response.HandleError(c, err, "status must be active, suspended or locked", fiber.StatusBadRequest)
+	}
+
+	err = h.adminService.UpdateUserStatus(context.Background(), userID, req)
+	if err != nil {
+		if errors.Is(err, service.ErrInsufficientPermissions) {
+			return response.HandleError(c, err, "", fiber.StatusForbidden)
+		}
+		if errors.Is(err, service.ErrUserNotFound) {
+			return response.HandleError(c, err, "", fiber.StatusNotFound)
+		}
+		log.Printf("internal error: failed to update user status: %v", err)
+		return response.HandleError(c, err, "Failed to update user status", fiber.StatusInternalServerError)
+	}
+	return response.HandleSuccess(c, "Update user status successful", nil, fiber.StatusOK)
+}
+
 // DeleteUser godoc
 // @Summary Delete a user
 // @Description Delete a specific user from the database
@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/internal/dto"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/internal/models"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/internal/service"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/pkg/response"
+)
+
+type APIKeyService interface {
+	CreateAPIKey(ctx context.Context, userID uuid.UUID, req dto.CreateAPIKeyRequest) (dto.CreateAPIKeyResponse, error)
+	ListAPIKeys(ctx context.Context, userID uuid.UUID) ([]dto.GetAPIKey, error)
+	RevokeAPIKey(ctx context.Context, userID, keyID uuid.UUID) error
+}
+
+type apiKeyHandler struct {
+	apiKeyService APIKeyService
+	validate      *validator.Validate
+}
+
+func NewAPIKeyHandler(apiKeyService APIKeyService) *apiKeyHandler {
+	return &apiKeyHandler{
+		apiKeyService: apiKeyService,
+		validate:      validator.New(),
+	}
+}
+
+// CreateAPIKey issues a new API key for the authenticated user.
+// @Summary Create API key
+// @Description Creates a named, scoped API key for machine clients. The key is only shown once.
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param createAPIKeyRequest body dto.CreateAPIKeyRequest true "Create API Key Request"
+// @Success 201 {object} response.Response{data=dto.CreateAPIKeyResponse} "API key created"
+// @Failure 400 {object} response.ErrorMessage "Invalid request payload or scope"
+// @Failure 403 {object} response.ErrorMessage "API keys cannot manage API keys"
+// @Failure 500 {object} response.ErrorMessage "Failed to create API key"
+// @Router /profile/api-keys [post]
+// @Security BearerAuth
+func (h *apiKeyHandler) CreateAPIKey(c *fiber.Ctx) error {
+	userID, ok := c.Locals("id").(uuid.UUID)
+	if !ok {
+		return response.HandleError(c, fmt.Errorf("invalid user ID"), "failed to create api key", fiber.StatusInternalServerError)
+	}
+	if c.Locals("token_type") == models.TokenTypeAPIKey {
+		return response.HandleError(c, nil, "api keys cannot manage api keys", fiber.StatusForbidden)
+	}
+
+	var req dto.CreateAPIKeyRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return response.HandleError(c, err, "invalid request payload", fiber.StatusBadRequest)
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		return response.HandleError(c, err, "invalid payload", fiber.StatusBadRequest)
+	}
+
+	res, err := h.apiKeyService.CreateAPIKey(c.Context(), userID, req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidScope) {
+			return response.HandleError(c, err, "", fiber.StatusBadRequest)
+		}
+		log.Printf("internal error: failed to create api key: %v", err)
+		return response.HandleError(c, err, "failed to create api key", fiber.StatusInternalServerError)
+	}
+
+	return response.HandleSuccess(c, "api key created", res, fiber.StatusCreated)
+}
+
+// ListAPIKeys lists the API keys of the authenticated user.
+// @Summary List API keys
+// @Description Lists the authenticated user's API keys without their secrets
+// @Tags user
+// @Produce json
+// @Success 200 {object} response.Response{data=[]dto.GetAPIKey} "API keys retrieved"
+// @Failure 500 {object} response.ErrorMessage "Failed to list API keys"
+// @Router /profile/api-keys [get]
+// @Security BearerAuth
+func (h *apiKeyHandler) ListAPIKeys(c *fiber.Ctx) error {
+	userID, ok := c.Locals("id").(uuid.UUID)
+	if !ok {
+		return response.HandleError(c, fmt.Errorf("invalid user ID"), "failed to list api keys", fiber.StatusInternalServerError)
+	}
+
+	keys, err := h.apiKeyService.ListAPIKeys(c.Context(), userID)
+	if err != nil {
+		log.Printf("internal error: failed to list api keys: %v", err)
+		return response.HandleError(c, err, "failed to list api keys", fiber.StatusInternalServerError)
+	}
+
+	return response.HandleSuccess(c, "api keys retrieved", keys, fiber.StatusOK)
+}
+
+// RevokeAPIKey revokes an API key of the authenticated user.
+// @Summary Revoke API key
+// @Description Revokes one of the authenticated user's API keys
+// @Tags user
+// @Produce json
+// @Param id path string true "API Key ID"
+// @Success 200 {object} response.Response "API key revoked"
+// @Failure 400 {object} response.ErrorMessage "Invalid API key ID"
+// @Failure 403 {object} response.ErrorMessage "API keys cannot manage API keys"
+// @Failure 404 {object} response.ErrorMessage "API key not found"
+// @Failure 500 {object} response.ErrorMessage "Failed to revoke API key"
+// @Router /profile/api-keys/{id} [delete]
+// @Security BearerAuth
+func (h *apiKeyHandler) RevokeAPIKey(c *fiber.Ctx) error {
+	userID, ok := c.Locals("id").(uuid.UUID)
+	if !ok {
+		return response.HandleError(c, fmt.Errorf("invalid user ID"), "failed to revoke api key", fiber.StatusInternalServerError)
+	}
+	if c.Locals("token_type") == models.TokenTypeAPIKey {
+		return response.HandleError(c, nil, "api keys cannot manage api keys", fiber.StatusForbidden)
+	}
+
+	keyID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.HandleError(c, err, "invalid api key ID", fiber.StatusBadRequest)
+	}
+
+	if err := h.apiKeyService.RevokeAPIKey(c.Context(), userID, keyID); err != nil {
+		if errors.Is(err, service.ErrAPIKeyNotFound) {
+			return response.HandleError(c, err, "", fiber.StatusNotFound)
+		}
+		log.Printf("internal error: failed to revoke api key: %v", err)
+		return response.HandleError(c, err, "failed to revoke api key", fiber.StatusInternalServerError)
+	}
+
+	return response.HandleSuccess(c, "api key revoked", nil, fiber.StatusOK)
+}
@@ -90,6 +90,7 @@ func (h *authHandler) Register(c *fiber.Ctx) error {
 // @Success 200 {object} response.Response "Login successful"
 // @Failure 400 {object} response.ErrorMessage "Invalid request payload"
 // @Failure 401 {object} response.ErrorMessage "Invalid credentials"
+// @Failure 403 {object} response.ErrorMessage "Account is suspended or locked"
 // @Failure 500 {object} response.ErrorMessage "Failed to login user"
 // @Router /auth/login [post]
 func (h *authHandler) Login(c *fiber.Ctx) error {
@@ -113,11 +114,16 @@ func (h *authHandler) Login(c *fiber.Ctx) error {
 		}
 	}
 
+	req.Device = dto.SessionDevice{UserAgent: c.Get(fiber.HeaderUserAgent), IPAddress: c.IP()}
+
 	res, err := h.authService.Login(context.Background(), req)
 	if err != nil {
 		if errors.Is(err, service.ErrInvalidCredentials) {
 			return response.HandleError(c, err, "", fiber.StatusUnauthorized)
 		}
+		if errors.Is(err, service.ErrAccountNotActive) {
+			return response.HandleError(c, err, "", fiber.StatusForbidden)
+		}
 		log.Printf("internal error: failed to login: %v", err)
 		return response.HandleError(c, err, "failed to login", fiber.StatusInternalServerError)
 	}
@@ -5,15 +5,13 @@ import (
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/google/uuid"
 	"github.com/sir-shalahuddin/grpc-learn/userservice/internal/models"
 	"github.com/sir-shalahuddin/grpc-learn/userservice/pkg/response"
 )
 
 // AuthService interface defines methods for authentication and authorization
 type AuthMiddlewareService interface {
-	ValidateToken(tokenStr string) (uuid.UUID, error)
-	GetUserByID(ctx context.Context, id uuid.UUID) (*models.User, error)
+	IntrospectToken(ctx context.Context, tokenStr string) (*models.TokenIntrospection, error)
 }
 
 // authMiddleware struct holds the AuthService instance
@@ -26,7 +24,7 @@ func NewAuthMiddleware(service AuthMiddlewareService) *authMiddleware {
 	return &authMiddleware{service: service}
 }
 
-// Protected provides JWT validation and role-based access control
+// Protected provides JWT and API key validation and role-based access control
 func (h *authMiddleware) Protected(allowedRoles ...string) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		authHeader := c.Get("Authorization")
@@ -40,25 +38,26 @@ func (h *authMiddleware) Protected(allowedRoles ...string) fiber.Handler {
 			return response.HandleError(c, nil, "Missing or malformed JWT", fiber.StatusUnauthorized)
 		}
 
-		// Parse and validate the JWT token
-		userID, err := h.service.ValidateToken(tokenString)
+		// Parse and validate the JWT token or API key and resolve its user
+		info, err := h.service.IntrospectToken(context.Background(), tokenString)
 		if err != nil {
 			return response.HandleError(c, err, "Invalid or expired JWT", fiber.StatusUnauthorized)
 		}
 
-		// Retrieve the user
-		user, err := h.service.GetUserByID(context.Background(), userID)
-		if err != nil {
-			return response.HandleError(c, err, "Failed to fetch user details", fiber.StatusInternalServerError)
+		if info.AccountStatus != models.AccountStatusActive {
+			return response.HandleError(c, nil, "Access forbidden: account is "+info.AccountStatus, fiber.StatusForbidden)
 		}
 
-		// Check if the user has the required role
-		if !hasAccess(user.Role, allowedRoles) {
+		// Check if the token may act as one of the required roles
+		if !hasPermission(info.Permissions, allowedRoles) {
 			return response.HandleError(c, nil, "Access forbidden: insufficient permissions", fiber.StatusForbidden)
 		}
 
 		// Pass the user ID to the next handler
-		c.Locals("id", userID)
+		c.Locals("id", info.UserID)
+		c.Locals("token_type", info.TokenType)
+		c.Locals("session_id", info.SessionID)
+		c.Locals("role", info.Role)
 		return c.Next()
 	}
 }
@@ -79,3 +78,13 @@ func hasAccess(userRole string, allowedRoles []string) bool {
 	}
 	return false
 }
+
+// hasPermission checks if any of the token's permissions is in the allowedRoles list
+func hasPermission(permissions []string, allowedRoles []string) bool {
+	for _, permission := range permissions {
+		if hasAccess(permission, allowedRoles) {
+			return true
+		}
+	}
+	return false
+}
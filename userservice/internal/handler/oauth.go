@@ -0,0 +1,403 @@
+package handler
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/internal/dto"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/internal/service"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/pkg/auth"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/pkg/response"
+)
+
+type OAuthService interface {
+	RegisterClient(ctx context.Context, req dto.RegisterClientRequest, createdBy uuid.UUID) (dto.RegisterClientResponse, error)
+	ListClients(ctx context.Context) ([]dto.GetOAuthClient, error)
+	CheckAuthorize(ctx context.Context, req dto.AuthorizeRequest) (dto.GetOAuthClient, error)
+	SignIn(ctx context.Context, req dto.LoginRequest) (string, error)
+	SignedInUser(ctx context.Context, token string) (uuid.UUID, error)
+	Authorize(ctx context.Context, userID uuid.UUID, req dto.AuthorizeRequest) (string, error)
+	Exchange(ctx context.Context, req dto.TokenRequest) (dto.TokenResponse, error)
+	UserInfo(ctx context.Context, token string) (dto.UserInfoResponse, error)
+	Discovery() dto.DiscoveryDocument
+	JWKS() map[string]interface{}
+}
+
+type oauthHandler struct {
+	oauthService OAuthService
+	validate     *validator.Validate
+}
+
+func NewOAuthHandler(oauthService OAuthService) *oauthHandler {
+	return &oauthHandler{
+		oauthService: oauthService,
+		validate:     validator.New(),
+	}
+}
+
+// RegisterClient godoc
+// @Summary Register an OAuth client
+// @Description Registers an application that can sign users in with their Library account. The client secret is only shown once.
+// @Tags oauth
+// @Accept json
+// @Produce json
+// @Param data body dto.RegisterClientRequest true "Client data"
+// @Success 201 {object} response.Response{data=dto.RegisterClientResponse}
+// @Failure 400 {object} response.ErrorMessage
+// @Failure 500 {object} response.ErrorMessage
+// @Router /admin/oauth/clients [post]
+// @Security BearerAuth
+func (h *oauthHandler) RegisterClient(c *fiber.Ctx) error {
+	userID, ok := c.Locals("id").(uuid.UUID)
+	if !ok {
+		return response.HandleError(c, fmt.Errorf("invalid user ID"), "failed to register client", fiber.StatusInternalServerError)
+	}
+
+	var req dto.RegisterClientRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return response.HandleError(c, err, "invalid request payload", fiber.StatusBadRequest)
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		return response.HandleError(c, err, "invalid payload", fiber.StatusBadRequest)
+	}
+
+	res, err := h.oauthService.RegisterClient(c.Context(), req, userID)
+	if err != nil {
+		log.Printf("internal error: failed to register client: %v", err)
+		return response.HandleError(c, err, "failed to register client", fiber.StatusInternalServerError)
+	}
+
+	return response.HandleSuccess(c, "client registered", res, fiber.StatusCreated)
+}
+
+// ListClients godoc
+// @Summary List OAuth clients
+// @Description Lists the registered OAuth clients
+// @Tags oauth
+// @Produce json
+// @Success 200 {object} response.Response{data=[]dto.GetOAuthClient}
+// @Failure 500 {object} response.ErrorMessage
+// @Router /admin/oauth/clients [get]
+// @Security BearerAuth
+func (h *oauthHandler) ListClients(c *fiber.Ctx) error {
+	clients, err := h.oauthService.ListClients(c.Context())
+	if err != nil {
+		log.Printf("internal error: failed to list clients: %v", err)
+		return response.HandleError(c, err, "failed to list clients", fiber.StatusInternalServerError)
+	}
+
+	return response.HandleSuccess(c, "retrieve list of clients successful", clients, fiber.StatusOK)
+}
+
+// Authorize godoc
+// @Summary OAuth2 authorization endpoint
+// @Description Opened in the user's browser. Shows a sign in form, then asks the signed in user to consent to the client. PKCE (S256) is required.
+// @Tags oauth
+// @Produce html
+// @Param response_type query string true "Must be code"
+// @Param client_id query string true "Client ID"
+// @Param redirect_uri query string true "Registered redirect URI"
+// @Param scope query string false "Space separated scopes, e.g. openid profile email"
+// @Param state query string false "Opaque client state"
+// @Param nonce query string false "Nonce copied into the ID token"
+// @Param code_challenge query string true "PKCE code challenge"
+// @Param code_challenge_method query string true "Must be S256"
+// @Success 200 {string} string "Sign in or consent page"
+// @Success 302
+// @Failure 400 {object} response.ErrorMessage
+// @Router /oauth/authorize [get]
+func (h *oauthHandler) Authorize(c *fiber.Ctx) error {
+	var req dto.AuthorizeRequest
+
+	if err := c.QueryParser(&req); err != nil {
+		return response.HandleError(c, err, "invalid request parameters", fiber.StatusBadRequest)
+	}
+
+	client, err := h.oauthService.CheckAuthorize(c.Context(), req)
+	if err != nil {
+		return authorizeError(c, req, err)
+	}
+
+	session := c.Cookies(oauthSessionCookie)
+	if _, err := h.oauthService.SignedInUser(c.Context(), session); err != nil {
+		return renderPage(c, fiber.StatusOK, signInPage, oauthPage{Client: client.Name, SignInURL: signInURL(req)})
+	}
+
+	return renderPage(c, fiber.StatusOK, consentPage, oauthPage{
+		Client:    client.Name,
+		Scopes:    strings.Fields(req.Scope),
+		Fields:    authorizeQuery(req),
+		CSRFToken: csrfToken(session),
+	})
+}
+
+// Consent godoc
+// @Summary OAuth2 consent
+// @Description Posted by the consent page. Issues an authorization code and redirects back to the client when the user approves, or redirects back with access_denied.
+// @Tags oauth
+// @Accept x-www-form-urlencoded
+// @Param decision formData string true "approve or deny"
+// @Param csrf_token formData string true "Token of the consent page"
+// @Success 303
+// @Failure 400 {object} response.ErrorMessage
+// @Failure 403 {object} response.ErrorMessage
+// @Router /oauth/authorize [post]
+func (h *oauthHandler) Consent(c *fiber.Ctx) error {
+	var req dto.AuthorizeRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return response.HandleError(c, err, "invalid request payload", fiber.StatusBadRequest)
+	}
+
+	if _, err := h.oauthService.CheckAuthorize(c.Context(), req); err != nil {
+		return authorizeError(c, req, err)
+	}
+
+	// A sign in that expired while the page was open starts over
+	session := c.Cookies(oauthSessionCookie)
+	userID, err := h.oauthService.SignedInUser(c.Context(), session)
+	if err != nil {
+		return c.Redirect("/oauth/authorize?"+authorizeQuery(req).Encode(), fiber.StatusSeeOther)
+	}
+	if subtle.ConstantTimeCompare([]byte(c.FormValue("csrf_token")), []byte(csrfToken(session))) != 1 {
+		return response.HandleError(c, nil, "invalid consent form", fiber.StatusForbidden)
+	}
+
+	params := url.Values{}
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+
+	if c.FormValue("decision") != "approve" {
+		params.Set("error", "access_denied")
+		return c.Redirect(withQuery(req.RedirectURI, params), fiber.StatusSeeOther)
+	}
+
+	code, err := h.oauthService.Authorize(c.Context(), userID, req)
+	if err != nil {
+		log.Printf("internal error: failed to authorize: %v", err)
+		params.Set("error", "server_error")
+		return c.Redirect(withQuery(req.RedirectURI, params), fiber.StatusSeeOther)
+	}
+
+	params.Set("code", code)
+	return c.Redirect(withQuery(req.RedirectURI, params), fiber.StatusSeeOther)
+}
+
+// SignIn godoc
+// @Summary OAuth2 sign in
+// @Description Posted by the sign in form of the authorization page, with the authorization request in the query. Signs the user in to the authorization page with a short lived cookie, without starting a session, and returns to it to ask for consent.
+// @Tags oauth
+// @Accept x-www-form-urlencoded
+// @Produce html
+// @Param email formData string true "Email"
+// @Param password formData string true "Password"
+// @Success 303
+// @Failure 400 {object} response.ErrorMessage
+// @Failure 401 {string} string "Sign in page with the error"
+// @Router /oauth/login [post]
+func (h *oauthHandler) SignIn(c *fiber.Ctx) error {
+	var req dto.AuthorizeRequest
+
+	if err := c.QueryParser(&req); err != nil {
+		return response.HandleError(c, err, "invalid request parameters", fiber.StatusBadRequest)
+	}
+
+	client, err := h.oauthService.CheckAuthorize(c.Context(), req)
+	if err != nil {
+		return authorizeError(c, req, err)
+	}
+
+	token, err := h.oauthService.SignIn(c.Context(), dto.LoginRequest{
+		Email:    c.FormValue("email"),
+		Password: c.FormValue("password"),
+		Device:   dto.SessionDevice{UserAgent: c.Get(fiber.HeaderUserAgent), IPAddress: c.IP()},
+	})
+	if err != nil {
+		page := oauthPage{Client: client.Name, SignInURL: signInURL(req), Error: "Invalid email or password."}
+		if errors.Is(err, service.ErrInvalidCredentials) {
+			return renderPage(c, fiber.StatusUnauthorized, signInPage, page)
+		}
+		if errors.Is(err, service.ErrAccountNotActive) {
+			page.Error = "Your account is suspended or locked."
+			return renderPage(c, fiber.StatusForbidden, signInPage, page)
+		}
+		log.Printf("internal error: failed to sign in: %v", err)
+		page.Error = "Signing in failed, please try again."
+		return renderPage(c, fiber.StatusInternalServerError, signInPage, page)
+	}
+
+	c.Cookie(&fiber.Cookie{
+		Name:     oauthSessionCookie,
+		Value:    token,
+		Path:     "/oauth",
+		MaxAge:   int(service.SignInExpiry.Seconds()),
+		Secure:   c.Protocol() == "https",
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+	return c.Redirect("/oauth/authorize?"+authorizeQuery(req).Encode(), fiber.StatusSeeOther)
+}
+
+// authorizeError answers an invalid authorization request. Errors about the
+// client or redirect URI must not be sent to the redirect URI.
+func authorizeError(c *fiber.Ctx, req dto.AuthorizeRequest, err error) error {
+	switch {
+	case errors.Is(err, service.ErrInvalidClient) || errors.Is(err, service.ErrInvalidRedirectURI):
+		return response.HandleError(c, err, "", fiber.StatusBadRequest)
+	case errors.Is(err, service.ErrInvalidOAuthRequest):
+		params := url.Values{}
+		if req.State != "" {
+			params.Set("state", req.State)
+		}
+		params.Set("error", service.ErrInvalidOAuthRequest.Error())
+		params.Set("error_description", err.Error())
+		return c.Redirect(withQuery(req.RedirectURI, params), fiber.StatusFound)
+	}
+	log.Printf("internal error: failed to authorize: %v", err)
+	return response.HandleError(c, err, "failed to authorize", fiber.StatusInternalServerError)
+}
+
+// Token godoc
+// @Summary OAuth2 token endpoint
+// @Description Exchanges an authorization code (with PKCE verifier) or a refresh token for tokens. Responds with RFC 6749 bodies.
+// @Tags oauth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param grant_type formData string true "authorization_code or refresh_token"
+// @Param code formData string false "Authorization code"
+// @Param redirect_uri formData string false "Redirect URI used in the authorization request"
+// @Param client_id formData string false "Client ID, unless sent with HTTP Basic"
+// @Param client_secret formData string false "Client secret, unless sent with HTTP Basic"
+// @Param code_verifier formData string false "PKCE code verifier"
+// @Param refresh_token formData string false "Refresh token"
+// @Success 200 {object} dto.TokenResponse
+// @Failure 400 {object} dto.OAuthError
+// @Failure 401 {object} dto.OAuthError
+// @Router /oauth/token [post]
+func (h *oauthHandler) Token(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "no-store")
+
+	var req dto.TokenRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.OAuthError{Error: "invalid_request"})
+	}
+	if id, secret, ok := basicAuth(c.Get(fiber.HeaderAuthorization)); ok {
+		req.ClientID, req.ClientSecret = id, secret
+	}
+	req.Device = dto.SessionDevice{UserAgent: c.Get(fiber.HeaderUserAgent), IPAddress: c.IP()}
+
+	res, err := h.oauthService.Exchange(c.Context(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidClient):
+			return c.Status(fiber.StatusUnauthorized).JSON(dto.OAuthError{Error: service.ErrInvalidClient.Error()})
+		case errors.Is(err, service.ErrInvalidGrant):
+			return c.Status(fiber.StatusBadRequest).JSON(dto.OAuthError{Error: service.ErrInvalidGrant.Error(), ErrorDescription: err.Error()})
+		case errors.Is(err, service.ErrUnsupportedGrantType):
+			return c.Status(fiber.StatusBadRequest).JSON(dto.OAuthError{Error: service.ErrUnsupportedGrantType.Error()})
+		}
+		log.Printf("internal error: failed to exchange token: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(dto.OAuthError{Error: "server_error"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(res)
+}
+
+// UserInfo godoc
+// @Summary OpenID Connect userinfo endpoint
+// @Description Returns the claims of the user behind an access token issued to an OAuth client with the openid scope: name and role with profile, email with email. Password login tokens and API keys are refused.
+// @Tags oauth
+// @Produce json
+// @Success 200 {object} dto.UserInfoResponse
+// @Failure 401 {object} dto.OAuthError
+// @Failure 403 {object} dto.OAuthError
+// @Failure 404 {object} response.ErrorMessage
+// @Router /oauth/userinfo [get]
+// @Security BearerAuth
+func (h *oauthHandler) UserInfo(c *fiber.Ctx) error {
+	token, found := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+	if !found || token == "" {
+		c.Set(fiber.HeaderWWWAuthenticate, "Bearer")
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.OAuthError{Error: "invalid_request"})
+	}
+
+	res, err := h.oauthService.UserInfo(c.Context(), token)
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrInvalidToken):
+			c.Set(fiber.HeaderWWWAuthenticate, `Bearer error="invalid_token"`)
+			return c.Status(fiber.StatusUnauthorized).JSON(dto.OAuthError{Error: "invalid_token"})
+		case errors.Is(err, service.ErrInsufficientScope):
+			c.Set(fiber.HeaderWWWAuthenticate, `Bearer error="insufficient_scope", scope="openid"`)
+			return c.Status(fiber.StatusForbidden).JSON(dto.OAuthError{Error: service.ErrInsufficientScope.Error()})
+		case errors.Is(err, service.ErrAccountNotActive):
+			return c.Status(fiber.StatusForbidden).JSON(dto.OAuthError{Error: "access_denied", ErrorDescription: err.Error()})
+		case errors.Is(err, service.ErrUserNotFound):
+			return response.HandleError(c, err, "user not found", fiber.StatusNotFound)
+		}
+		log.Printf("internal error: failed to retrieve user info: %v", err)
+		return response.HandleError(c, err, "failed to retrieve user", fiber.StatusInternalServerError)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(res)
+}
+
+// Discovery godoc
+// @Summary OpenID Connect discovery document
+// @Tags oauth
+// @Produce json
+// @Success 200 {object} dto.DiscoveryDocument
+// @Router /.well-known/openid-configuration [get]
+func (h *oauthHandler) Discovery(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(h.oauthService.Discovery())
+}
+
+// JWKS godoc
+// @Summary JSON Web Key Set used to sign ID tokens
+// @Tags oauth
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /.well-known/jwks.json [get]
+func (h *oauthHandler) JWKS(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(h.oauthService.JWKS())
+}
+
+// basicAuth parses client credentials sent with HTTP Basic authentication.
+func basicAuth(header string) (string, string, bool) {
+	encoded, found := strings.CutPrefix(header, "Basic ")
+	if !found {
+		return "", "", false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", "", false
+	}
+	id, secret, found := strings.Cut(string(decoded), ":")
+	if !found {
+		return "", "", false
+	}
+	id, _ = url.QueryUnescape(id)
+	secret, _ = url.QueryUnescape(secret)
+	return id, secret, true
+}
+
+func withQuery(redirectURI string, params url.Values) string {
+	sep := "?"
+	if strings.Contains(redirectURI, "?") {
+		sep = "&"
+	}
+	return redirectURI + sep + params.Encode()
+}
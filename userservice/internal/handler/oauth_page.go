@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"bytes"
+	"html/template"
+	"net/url"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/internal/dto"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/pkg/oidc"
+)
+
+// oauthSessionCookie keeps the user signed in to the authorization page.
+const oauthSessionCookie = "oauth_session"
+
+// oauthPage is the data of the sign in and consent pages.
+type oauthPage struct {
+	Client    string
+	SignInURL template.URL
+	Error     string
+	Scopes    []string
+	Fields    url.Values
+	CSRFToken string
+}
+
+var signInPage = template.Must(template.New("signin").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head><meta charset="utf-8"><title>Sign in</title></head>
+<body>
+<h1>Sign in to continue to {{.Client}}</h1>
+{{if .Error}}<p role="alert">{{.Error}}</p>{{end}}
+<form method="post" action="{{.SignInURL}}">
+<label>Email <input type="email" name="email" autocomplete="username" required></label>
+<label>Password <input type="password" name="password" autocomplete="current-password" required></label>
+<button type="submit">Sign in</button>
+</form>
+</body>
+</html>
+`))
+
+var consentPage = template.Must(template.New("consent").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head><meta charset="utf-8"><title>Authorize {{.Client}}</title></head>
+<body>
+<h1>{{.Client}} wants to access your Library account</h1>
+{{if .Scopes}}<p>It asks for:</p>
+<ul>{{range .Scopes}}<li>{{.}}</li>{{end}}</ul>{{end}}
+<form method="post" action="/oauth/authorize">
+{{range $name, $values := .Fields}}{{range $values}}<input type="hidden" name="{{$name}}" value="{{.}}">
+{{end}}{{end}}<input type="hidden" name="csrf_token" value="{{.CSRFToken}}">
+<button type="submit" name="decision" value="approve">Allow</button>
+<button type="submit" name="decision" value="deny">Deny</button>
+</form>
+</body>
+</html>
+`))
+
+// renderPage sends an authorization page. It can't be framed or cached.
+func renderPage(c *fiber.Ctx, status int, page *template.Template, data oauthPage) error {
+	var buf bytes.Buffer
+	if err := page.Execute(&buf, data); err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	c.Set(fiber.HeaderXFrameOptions, "DENY")
+	c.Set(fiber.HeaderContentSecurityPolicy, "default-src 'none'; frame-ancestors 'none'")
+	return c.Status(status).Send(buf.Bytes())
+}
+
+// signInURL is where the sign in form posts the credentials for req. The
+// query is encoded by url.Values and safe to use as is.
+func signInURL(req dto.AuthorizeRequest) template.URL {
+	return template.URL("/oauth/login?" + authorizeQuery(req).Encode())
+}
+
+// authorizeQuery encodes an authorization request to pass it on between the
+// authorization pages.
+func authorizeQuery(req dto.AuthorizeRequest) url.Values {
+	params := url.Values{}
+	for name, value := range map[string]string{
+		"response_type":         req.ResponseType,
+		"client_id":             req.ClientID,
+		"redirect_uri":          req.RedirectURI,
+		"scope":                 req.Scope,
+		"state":                 req.State,
+		"nonce":                 req.Nonce,
+		"code_challenge":        req.CodeChallenge,
+		"code_challenge_method": req.CodeChallengeMethod,
+	} {
+		if value != "" {
+			params.Set(name, value)
+		}
+	}
+	return params
+}
+
+// csrfToken ties the consent form to the sign in cookie, which other sites
+// can't read.
+func csrfToken(session string) string {
+	return oidc.HashSecret("consent:" + session)
+}
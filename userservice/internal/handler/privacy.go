@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/internal/dto"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/internal/models"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/internal/service"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/pkg/response"
+)
+
+type PrivacyService interface {
+	RequestExport(ctx context.Context, userID uuid.UUID) (dto.GetDataExport, error)
+	GetExport(ctx context.Context, userID, exportID uuid.UUID) (dto.GetDataExport, error)
+	DownloadExport(ctx context.Context, userID, exportID uuid.UUID) ([]byte, error)
+	EraseAccount(ctx context.Context, userID uuid.UUID, req dto.EraseAccountRequest) (dto.EraseAccountResponse, error)
+}
+
+type privacyHandler struct {
+	privacyService PrivacyService
+	validate       *validator.Validate
+}
+
+func NewPrivacyHandler(privacyService PrivacyService) *privacyHandler {
+	return &privacyHandler{
+		privacyService: privacyService,
+		validate:       validator.New(),
+	}
+}
+
+// RequestExport starts an export of the authenticated user's personal data.
+// @Summary Export my data
+// @Description Starts a job gathering the profile and borrowing history into a downloadable JSON archive
+// @Tags user
+// @Produce json
+// @Success 202 {object} response.Response{data=dto.GetDataExport} "Data export started"
+// @Failure 403 {object} response.ErrorMessage "API keys cannot export personal data"
+// @Failure 500 {object} response.ErrorMessage "Failed to start data export"
+// @Router /profile/exports [post]
+// @Security BearerAuth
+func (h *privacyHandler) RequestExport(c *fiber.Ctx) error {
+	userID, ok := c.Locals("id").(uuid.UUID)
+	if !ok {
+		return response.HandleError(c, fmt.Errorf("invalid user ID"), "failed to start data export", fiber.StatusInternalServerError)
+	}
+	if c.Locals("token_type") == models.TokenTypeAPIKey {
+		return response.HandleError(c, nil, "api keys cannot export personal data", fiber.StatusForbidden)
+	}
+
+	res, err := h.privacyService.RequestExport(c.Context(), userID)
+	if err != nil {
+		log.Printf("internal error: failed to start data export: %v", err)
+		return response.HandleError(c, err, "failed to start data export", fiber.StatusInternalServerError)
+	}
+
+	return response.HandleSuccess(c, "data export started", res, fiber.StatusAccepted)
+}
+
+// GetExport returns the status of a data export.
+// @Summary Get data export status
+// @Description Returns whether a data export is pending, completed or failed
+// @Tags user
+// @Produce json
+// @Param id path string true "Export ID"
+// @Success 200 {object} response.Response{data=dto.GetDataExport} "Data export retrieved"
+// @Failure 400 {object} response.ErrorMessage "Invalid export ID"
+// @Failure 404 {object} response.ErrorMessage "Data export not found"
+// @Failure 500 {object} response.ErrorMessage "Failed to get data export"
+// @Router /profile/exports/{id} [get]
+// @Security BearerAuth
+func (h *privacyHandler) GetExport(c *fiber.Ctx) error {
+	userID, ok := c.Locals("id").(uuid.UUID)
+	if !ok {
+		return response.HandleError(c, fmt.Errorf("invalid user ID"), "failed to get data export", fiber.StatusInternalServerError)
+	}
+
+	exportID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.HandleError(c, err, "invalid export ID", fiber.StatusBadRequest)
+	}
+
+	res, err := h.privacyService.GetExport(c.Context(), userID, exportID)
+	if err != nil {
+		if errors.Is(err, service.ErrExportNotFound) {
+			return response.HandleError(c, err, "", fiber.StatusNotFound)
+		}
+		log.Printf("internal error: failed to get data export: %v", err)
+		return response.HandleError(c, err, "failed to get data export", fiber.StatusInternalServerError)
+	}
+
+	return response.HandleSuccess(c, "data export retrieved", res, fiber.StatusOK)
+}
+
+// DownloadExport downloads the archive of a completed data export.
+// @Summary Download data export
+// @Description Downloads the JSON archive of a completed data export
+// @Tags user
+// @Produce json
+// @Param id path string true "Export ID"
+// @Success 200 {object} dto.DataArchive "Data archive"
+// @Failure 400 {object} response.ErrorMessage "Invalid export ID"
+// @Failure 403 {object} response.ErrorMessage "API keys cannot export personal data"
+// @Failure 404 {object} response.ErrorMessage "Data export not found"
+// @Failure 409 {object} response.ErrorMessage "Data export is not ready"
+// @Failure 500 {object} response.ErrorMessage "Failed to download data export"
+// @Router /profile/exports/{id}/download [get]
+// @Security BearerAuth
+func (h *privacyHandler) DownloadExport(c *fiber.Ctx) error {
+	userID, ok := c.Locals("id").(uuid.UUID)
+	if !ok {
+		return response.HandleError(c, fmt.Errorf("invalid user ID"), "failed to download data export", fiber.StatusInternalServerError)
+	}
+	if c.Locals("token_type") == models.TokenTypeAPIKey {
+		return response.HandleError(c, nil, "api keys cannot export personal data", fiber.StatusForbidden)
+	}
+
+	exportID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.HandleError(c, err, "invalid export ID", fiber.StatusBadRequest)
+	}
+
+	archive, err := h.privacyService.DownloadExport(c.Context(), userID, exportID)
+	if err != nil {
+		if errors.Is(err, service.ErrExportNotFound) {
+			return response.HandleError(c, err, "", fiber.StatusNotFound)
+		}
+		if errors.Is(err, service.ErrExportNotReady) {
+			return response.HandleError(c, err, "", fiber.StatusConflict)
+		}
+		log.Printf("internal error: failed to download data export: %v", err)
+		return response.HandleError(c, err, "failed to download data export", fiber.StatusInternalServerError)
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	c.Attachment(fmt.Sprintf("library-data-%s.json", time.Now().Format("2006-01-02")))
+	return c.Status(fiber.StatusOK).Send(archive)
+}
+
+// EraseAccount erases the authenticated user's account.
+// @Summary Erase my account
+// @Description Anonymizes the borrowing history and deletes the account. All borrowed books must be returned first.
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param eraseAccountRequest body dto.EraseAccountRequest true "Erase Account Request"
+// @Success 200 {object} response.Response{data=dto.EraseAccountResponse} "Account erased"
+// @Failure 400 {object} response.ErrorMessage "Invalid request payload"
+// @Failure 401 {object} response.ErrorMessage "Invalid credentials"
+// @Failure 403 {object} response.ErrorMessage "Account cannot be erased with an API key or as super admin"
+// @Failure 409 {object} response.ErrorMessage "Borrowed books must be returned first"
+// @Failure 500 {object} response.ErrorMessage "Failed to erase account"
+// @Router /profile [delete]
+// @Security BearerAuth
+func (h *privacyHandler) EraseAccount(c *fiber.Ctx) error {
+	userID, ok := c.Locals("id").(uuid.UUID)
+	if !ok {
+		return response.HandleError(c, fmt.Errorf("invalid user ID"), "failed to erase account", fiber.StatusInternalServerError)
+	}
+	if c.Locals("token_type") == models.TokenTypeAPIKey {
+		return response.HandleError(c, nil, "api keys cannot erase accounts", fiber.StatusForbidden)
+	}
+
+	var req dto.EraseAccountRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return response.HandleError(c, err, "invalid request payload", fiber.StatusBadRequest)
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		return response.HandleError(c, err, "invalid payload", fiber.StatusBadRequest)
+	}
+
+	res, err := h.privacyService.EraseAccount(c.Context(), userID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidCredentials):
+			return response.HandleError(c, err, "", fiber.StatusUnauthorized)
+		case errors.Is(err, service.ErrInsufficientPermissions):
+			return response.HandleError(c, err, "", fiber.StatusForbidden)
+		case errors.Is(err, service.ErrActiveLoans):
+			return response.HandleError(c, err, "", fiber.StatusConflict)
+		case errors.Is(err, service.ErrUserNotFound):
+			return response.HandleError(c, err, "", fiber.StatusNotFound)
+		}
+		log.Printf("internal error: failed to erase account: %v", err)
+		return response.HandleError(c, err, "failed to erase account", fiber.StatusInternalServerError)
+	}
+
+	return response.HandleSuccess(c, "account erased", res, fiber.StatusOK)
+}
@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/internal/dto"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/internal/service"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/pkg/response"
+)
+
+type SessionService interface {
+	ListSessions(ctx context.Context, userID, currentSessionID uuid.UUID) ([]dto.GetSession, error)
+	RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error
+	ListUserSessions(ctx context.Context, userID uuid.UUID) ([]dto.GetSession, error)
+	RevokeUserSession(ctx context.Context, userID, sessionID uuid.UUID) error
+	RevokeUserSessions(ctx context.Context, userID uuid.UUID) (dto.RevokeSessionsResponse, error)
+}
+
+type sessionHandler struct {
+	sessionService SessionService
+}
+
+func NewSessionHandler(sessionService SessionService) *sessionHandler {
+	return &sessionHandler{sessionService: sessionService}
+}
+
+// ListSessions lists the active sessions of the authenticated user.
+// @Summary List sessions
+// @Description Lists the devices the authenticated user is signed in on
+// @Tags user
+// @Produce json
+// @Success 200 {object} response.Response{data=[]dto.GetSession} "Sessions retrieved"
+// @Failure 500 {object} response.ErrorMessage "Failed to list sessions"
+// @Router /profile/sessions [get]
+// @Security BearerAuth
+func (h *sessionHandler) ListSessions(c *fiber.Ctx) error {
+	userID, ok := c.Locals("id").(uuid.UUID)
+	if !ok {
+		return response.HandleError(c, fmt.Errorf("invalid user ID"), "failed to list sessions", fiber.StatusInternalServerError)
+	}
+	sessionID, _ := c.Locals("session_id").(uuid.UUID)
+
+	sessions, err := h.sessionService.ListSessions(c.Context(), userID, sessionID)
+	if err != nil {
+		log.Printf("internal error: failed to list sessions: %v", err)
+		return response.HandleError(c, err, "failed to list sessions", fiber.StatusInternalServerError)
+	}
+
+	return response.HandleSuccess(c, "sessions retrieved", sessions, fiber.StatusOK)
+}
+
+// RevokeSession revokes a session of the authenticated user.
+// @Summary Revoke session
+// @Description Signs the authenticated user out of one of their sessions
+// @Tags user
+// @Produce json
+// @Param id path string true "Session ID"
+// @Success 200 {object} response.Response "Session revoked"
+// @Failure 400 {object} response.ErrorMessage "Invalid session ID"
+// @Failure 404 {object} response.ErrorMessage "Session not found"
+// @Failure 500 {object} response.ErrorMessage "Failed to revoke session"
+// @Router /profile/sessions/{id} [delete]
+// @Security BearerAuth
+func (h *sessionHandler) RevokeSession(c *fiber.Ctx) error {
+	userID, ok := c.Locals("id").(uuid.UUID)
+	if !ok {
+		return response.HandleError(c, fmt.Errorf("invalid user ID"), "failed to revoke session", fiber.StatusInternalServerError)
+	}
+
+	sessionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.HandleError(c, err, "invalid session ID", fiber.StatusBadRequest)
+	}
+
+	if err := h.sessionService.RevokeSession(c.Context(), userID, sessionID); err != nil {
+		if errors.Is(err, service.ErrSessionNotFound) {
+			return response.HandleError(c, err, "", fiber.StatusNotFound)
+		}
+		log.Printf("internal error: failed to revoke session: %v", err)
+		return response.HandleError(c, err, "failed to revoke session", fiber.StatusInternalServerError)
+	}
+
+	return response.HandleSuccess(c, "session revoked", nil, fiber.StatusOK)
+}
+
+// ListUserSessions godoc
+// @Summary List a user's sessions
+// @Description Lists the active sessions of a specific user
+// @Tags users
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} response.Response{data=[]dto.GetSession}
+// @Failure 400 {object} response.ErrorMessage
+// @Failure 404 {object} response.ErrorMessage
+// @Failure 500 {object} response.ErrorMessage
+// @Router /admin/users/{id}/sessions [get]
+// @Security BearerAuth
+func (h *sessionHandler) ListUserSessions(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.HandleError(c, err, "Invalid request parameters", fiber.StatusBadRequest)
+	}
+
+	sessions, err := h.sessionService.ListUserSessions(c.Context(), userID)
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			return response.HandleError(c, err, "", fiber.StatusNotFound)
+		}
+		log.Printf("internal error: failed to list user sessions: %v", err)
+		return response.HandleError(c, err, "Failed to list sessions", fiber.StatusInternalServerError)
+	}
+
+	return response.HandleSuccess(c, "Sessions retrieved", sessions, fiber.StatusOK)
+}
+
+// RevokeUserSession godoc
+// @Summary Kill a user's session
+// @Description Revokes one session of a specific user
+// @Tags users
+// @Produce json
+// @Param id path string true "User ID"
+// @Param session_id path string true "Session ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.ErrorMessage
+// @Failure 403 {object} response.ErrorMessage
+// @Failure 404 {object} response.ErrorMessage
+// @Failure 500 {object} response.ErrorMessage
+// @Router /admin/users/{id}/sessions/{session_id} [delete]
+// @Security BearerAuth
+func (h *sessionHandler) RevokeUserSession(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.HandleError(c, err, "Invalid request parameters", fiber.StatusBadRequest)
+	}
+	sessionID, err := uuid.Parse(c.Params("session_id"))
+	if err != nil {
+		return response.HandleError(c, err, "Invalid request parameters", fiber.StatusBadRequest)
+	}
+
+	if err := h.sessionService.RevokeUserSession(c.Context(), userID, sessionID); err != nil {
+		if errors.Is(err, service.ErrInsufficientPermissions) {
+			return response.HandleError(c, err, "", fiber.StatusForbidden)
+		}
+		if errors.Is(err, service.ErrUserNotFound) || errors.Is(err, service.ErrSessionNotFound) {
+			return response.HandleError(c, err, "", fiber.StatusNotFound)
+		}
+		log.Printf("internal error: failed to revoke user session: %v", err)
+		return response.HandleError(c, err, "Failed to revoke session", fiber.StatusInternalServerError)
+	}
+
+	return response.HandleSuccess(c, "Session revoked", nil, fiber.StatusOK)
+}
+
+// RevokeUserSessions godoc
+// @Summary Kill all of a user's sessions
+// @Description Revokes every active session of a specific user, signing them out everywhere
+// @Tags users
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} response.Response{data=dto.RevokeSessionsResponse}
+// @Failure 400 {object} response.ErrorMessage
+// @Failure 403 {object} response.ErrorMessage
+// @Failure 404 {object} response.ErrorMessage
+// @Failure 500 {object} response.ErrorMessage
+// @Router /admin/users/{id}/sessions [delete]
+// @Security BearerAuth
+func (h *sessionHandler) RevokeUserSessions(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.HandleError(c, err, "Invalid request parameters", fiber.StatusBadRequest)
+	}
+
+	res, err := h.sessionService.RevokeUserSessions(c.Context(), userID)
+	if err != nil {
+		if errors.Is(err, service.ErrInsufficientPermissions) {
+			return response.HandleError(c, err, "", fiber.StatusForbidden)
+		}
+		if errors.Is(err, service.ErrUserNotFound) {
+			return response.HandleError(c, err, "", fiber.StatusNotFound)
+		}
+		log.Printf("internal error: failed to revoke user sessions: %v", err)
+		return response.HandleError(c, err, "Failed to revoke sessions", fiber.StatusInternalServerError)
+	}
+
+	return response.HandleSuccess(c, "Sessions revoked", res, fiber.StatusOK)
+}
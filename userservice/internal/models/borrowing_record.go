@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BorrowingRecord is a loan as reported by bookservice.
+type BorrowingRecord struct {
+	ID         uuid.UUID
+	BookID     uuid.UUID
+	BookTitle  string
+	BookAuthor string
+	ISBN       string
+	BorrowedAt *time.Time
+	DueDate    *time.Time
+	ReturnedAt *time.Time
+}
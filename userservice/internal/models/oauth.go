@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OAuthClient is an application allowed to sign users in through userservice.
+// Public clients have no secret and rely on PKCE alone.
+type OAuthClient struct {
+	ID               uuid.UUID
+	ClientID         string
+	ClientSecretHash string
+	Name             string
+	RedirectURIs     []string
+	CreatedBy        uuid.UUID
+	CreatedAt        time.Time
+}
+
+type AuthorizationCode struct {
+	CodeHash      string
+	ClientID      string
+	UserID        uuid.UUID
+	RedirectURI   string
+	Scope         string
+	Nonce         string
+	CodeChallenge string
+	ExpiresAt     time.Time
+	UsedAt        *time.Time
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session is a login on one device, identified by the sid claim of the
+// tokens issued for it. ClientID is the OAuth client the session was
+// started for, empty for password logins, and Scope the space separated
+// scopes the user granted it.
+type Session struct {
+	ID               uuid.UUID
+	UserID           uuid.UUID
+	ClientID         string
+	Scope            string
+	RefreshTokenHash string
+	UserAgent        string
+	IPAddress        string
+	CreatedAt        time.Time
+	LastSeenAt       time.Time
+	ExpiresAt        time.Time
+	RevokedAt        *time.Time
+}
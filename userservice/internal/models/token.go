@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	TokenTypeJWT    = "jwt"
+	TokenTypeAPIKey = "api_key"
+
+	// Statuses of an account. Only active accounts may be used.
+	AccountStatusActive    = "active"
+	AccountStatusSuspended = "suspended"
+	AccountStatusLocked    = "locked"
+)
+
+// TokenInfo describes the principal behind a validated bearer token.
+// Scopes is empty for JWTs, which are limited by the user's role only.
+// ClientID is the OAuth client a JWT was issued to, with GrantedScopes the
+// OpenID scopes the user consented to; both are empty for password logins
+// and API keys. SessionID is uuid.Nil for API keys.
+type TokenInfo struct {
+	UserID        uuid.UUID
+	TokenType     string
+	Scopes        []string
+	ClientID      string
+	GrantedScopes []string
+	SessionID     uuid.UUID
+	ExpiresAt     time.Time
+}
+
+// TokenIntrospection extends TokenInfo with the user's current role and the
+// roles the token may act as.
+type TokenIntrospection struct {
+	TokenInfo
+	Role          string
+	Permissions   []string
+	AccountStatus string
+}
+
+// RevocationEvent tells other services to forget cached answers about a
+// token, identified by its SHA-256 hash, or about every token of a user.
+type RevocationEvent struct {
+	UserID    string `json:"user_id,omitempty"`
+	TokenHash string `json:"token_hash,omitempty"`
+}
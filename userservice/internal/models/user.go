@@ -14,4 +14,5 @@ type User struct {
 	CreatedAt time.Time 
 	UpdatedAt time.Time 
 	Role      string    
+	Status    string
 }
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/internal/models"
+)
+
+type apiKeyRepository struct {
+	db *sql.DB
+}
+
+func NewAPIKeyRepository(db *sql.DB) *apiKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+func (r *apiKeyRepository) CreateAPIKey(ctx context.Context, key *models.APIKey) error {
+	query := `INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, expires_at) 
+            VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at`
+
+	if err := r.db.QueryRowContext(ctx, query, key.UserID, key.Name, key.Prefix, key.KeyHash, pq.Array(key.Scopes), key.ExpiresAt).
+		Scan(&key.ID, &key.CreatedAt); err != nil {
+		log.Printf("[Repository - CreateAPIKey] Error executing query: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *apiKeyRepository) GetAPIKeyByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	query := `SELECT id, user_id, name, prefix, key_hash, scopes, expires_at, last_used_at, revoked_at, created_at 
+            FROM api_keys WHERE prefix = $1`
+
+	var key models.APIKey
+
+	if err := r.db.QueryRowContext(ctx, query, prefix).
+		Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, &key.KeyHash, pq.Array(&key.Scopes), &key.ExpiresAt, &key.LastUsedAt, &key.RevokedAt, &key.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		log.Printf("[Repository - GetAPIKeyByPrefix] Error scanning row: %v", err)
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+func (r *apiKeyRepository) ListAPIKeysByUser(ctx context.Context, userID uuid.UUID) ([]models.APIKey, error) {
+	query := `SELECT id, user_id, name, prefix, key_hash, scopes, expires_at, last_used_at, revoked_at, created_at 
+            FROM api_keys WHERE user_id = $1 ORDER BY created_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		log.Printf("[Repository - ListAPIKeysByUser] Error executing query: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []models.APIKey
+
+	for rows.Next() {
+		var key models.APIKey
+		if err := rows.
+			Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, &key.KeyHash, pq.Array(&key.Scopes), &key.ExpiresAt, &key.LastUsedAt, &key.RevokedAt, &key.CreatedAt); err != nil {
+			log.Printf("[Repository - ListAPIKeysByUser] Error scanning row: %v", err)
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+// RevokeAPIKey marks the user's key as revoked. It reports whether a key was found.
+func (r *apiKeyRepository) RevokeAPIKey(ctx context.Context, userID, keyID uuid.UUID) (bool, error) {
+	query := `UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`
+
+	res, err := r.db.ExecContext(ctx, query, keyID, userID)
+	if err != nil {
+		log.Printf("[Repository - RevokeAPIKey] Error executing query: %v", err)
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+// TouchAPIKey records key usage, writing at most once a minute per key.
+func (r *apiKeyRepository) TouchAPIKey(ctx context.Context, keyID uuid.UUID) error {
+	query := `UPDATE api_keys SET last_used_at = NOW() 
+            WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')`
+
+	_, err := r.db.ExecContext(ctx, query, keyID)
+	if err != nil {
+		log.Printf("[Repository - TouchAPIKey] Error executing query: %v", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/internal/models"
+	pb "github.com/sir-shalahuddin/grpc-learn/userservice/proto/bookservice"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type bookRepository struct {
+	client pb.BookServiceClient
+}
+
+// NewBookRepository creates a new instance of bookRepository.
+func NewBookRepository(client pb.BookServiceClient) *bookRepository {
+	return &bookRepository{client: client}
+}
+
+// ExportBorrowingRecords retrieves the user's borrowing history using the BookService gRPC client.
+func (r *bookRepository) ExportBorrowingRecords(ctx context.Context, userID uuid.UUID) ([]models.BorrowingRecord, error) {
+	resp, err := r.client.ExportUserData(ctx, &pb.ExportUserDataRequest{UserId: userID.String()})
+	if err != nil {
+		log.Printf("[Repository - ExportBorrowingRecords] Error calling book service: %v", err)
+		return nil, fmt.Errorf("failed to export borrowing records: %w", err)
+	}
+
+	records := make([]models.BorrowingRecord, 0, len(resp.GetRecords()))
+	for _, data := range resp.GetRecords() {
+		record := models.BorrowingRecord{
+			BookTitle:  data.GetBookTitle(),
+			BookAuthor: data.GetBookAuthor(),
+			ISBN:       data.GetIsbn(),
+			BorrowedAt: parseTime(data.GetBorrowedAt()),
+			DueDate:    parseTime(data.GetDueDate()),
+			ReturnedAt: parseTime(data.GetReturnedAt()),
+		}
+		record.ID, _ = uuid.Parse(data.GetId())
+		record.BookID, _ = uuid.Parse(data.GetBookId())
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// EraseBorrowingRecords asks bookservice to anonymize the user's borrowing
+// history. It reports false when bookservice refuses because the user still
+// has books out.
+func (r *bookRepository) EraseBorrowingRecords(ctx context.Context, userID uuid.UUID) (int64, bool, error) {
+	resp, err := r.client.EraseUserData(ctx, &pb.EraseUserDataRequest{UserId: userID.String()})
+	if err != nil {
+		if st, ok := status.FromError(err); ok && st.Code() == codes.FailedPrecondition {
+			return 0, false, nil
+		}
+		log.Printf("[Repository - EraseBorrowingRecords] Error calling book service: %v", err)
+		return 0, false, fmt.Errorf("failed to erase borrowing records: %w", err)
+	}
+
+	return resp.GetAnonymizedRecords(), true, nil
+}
+
+func parseTime(value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/internal/models"
+)
+
+type dataExportRepository struct {
+	db *sql.DB
+}
+
+func NewDataExportRepository(db *sql.DB) *dataExportRepository {
+	return &dataExportRepository{db: db}
+}
+
+func (r *dataExportRepository) CreateExport(ctx context.Context, export *models.DataExport) error {
+	query := `INSERT INTO data_exports (user_id, status, expires_at) 
+            VALUES ($1, $2, $3) RETURNING id, created_at`
+
+	if err := r.db.QueryRowContext(ctx, query, export.UserID, export.Status, export.ExpiresAt).
+		Scan(&export.ID, &export.CreatedAt); err != nil {
+		log.Printf("[Repository - CreateExport] Error executing query: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetExport returns one of the user's exports, including its archive.
+func (r *dataExportRepository) GetExport(ctx context.Context, userID, exportID uuid.UUID) (*models.DataExport, error) {
+	query := `SELECT id, user_id, status, archive, COALESCE(error, ''), created_at, completed_at, expires_at 
+            FROM data_exports WHERE id = $1 AND user_id = $2`
+
+	var export models.DataExport
+
+	if err := r.db.QueryRowContext(ctx, query, exportID, userID).
+		Scan(&export.ID, &export.UserID, &export.Status, &export.Archive, &export.Error, &export.CreatedAt, &export.CompletedAt, &export.ExpiresAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		log.Printf("[Repository - GetExport] Error scanning row: %v", err)
+		return nil, err
+	}
+
+	return &export, nil
+}
+
+// CompleteExport stores the generated archive and marks the export completed.
+func (r *dataExportRepository) CompleteExport(ctx context.Context, exportID uuid.UUID, archive []byte) error {
+	query := `UPDATE data_exports SET status = 'completed', archive = $1, completed_at = NOW() WHERE id = $2`
+
+	_, err := r.db.ExecContext(ctx, query, string(archive), exportID)
+	if err != nil {
+		log.Printf("[Repository - CompleteExport] Error executing query: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// FailExport marks the export failed with the given reason, unless it has
+// already ended.
+func (r *dataExportRepository) FailExport(ctx context.Context, exportID uuid.UUID, reason string) error {
+	query := `UPDATE data_exports SET status = 'failed', error = $1, completed_at = NOW() WHERE id = $2 AND status = 'pending'`
+
+	_, err := r.db.ExecContext(ctx, query, reason, exportID)
+	if err != nil {
+		log.Printf("[Repository - FailExport] Error executing query: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// FailStaleExports marks the exports still pending that were requested before
+// the given time as failed with the given reason, returning how many it failed.
+func (r *dataExportRepository) FailStaleExports(ctx context.Context, before time.Time, reason string) (int64, error) {
+	query := `UPDATE data_exports SET status = 'failed', error = $1, completed_at = NOW() 
+            WHERE status = 'pending' AND created_at < $2`
+
+	res, err := r.db.ExecContext(ctx, query, reason, before)
+	if err != nil {
+		log.Printf("[Repository - FailStaleExports] Error executing query: %v", err)
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
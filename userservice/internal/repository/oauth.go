@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"github.com/lib/pq"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/internal/models"
+)
+
+type oauthRepository struct {
+	db *sql.DB
+}
+
+func NewOAuthRepository(db *sql.DB) *oauthRepository {
+	return &oauthRepository{db: db}
+}
+
+func (r *oauthRepository) CreateClient(ctx context.Context, client *models.OAuthClient) error {
+	query := `INSERT INTO oauth_clients (client_id, client_secret_hash, name, redirect_uris, created_by) 
+            VALUES ($1, NULLIF($2, ''), $3, $4, $5) RETURNING id, created_at`
+
+	if err := r.db.QueryRowContext(ctx, query, client.ClientID, client.ClientSecretHash, client.Name, pq.Array(client.RedirectURIs), client.CreatedBy).
+		Scan(&client.ID, &client.CreatedAt); err != nil {
+		log.Printf("[Repository - CreateClient] Error executing query: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *oauthRepository) GetClientByClientID(ctx context.Context, clientID string) (*models.OAuthClient, error) {
+	query := `SELECT id, client_id, COALESCE(client_secret_hash, ''), name, redirect_uris, created_at 
+            FROM oauth_clients WHERE client_id = $1`
+
+	var client models.OAuthClient
+
+	if err := r.db.QueryRowContext(ctx, query, clientID).
+		Scan(&client.ID, &client.ClientID, &client.ClientSecretHash, &client.Name, pq.Array(&client.RedirectURIs), &client.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		log.Printf("[Repository - GetClientByClientID] Error scanning row: %v", err)
+		return nil, err
+	}
+
+	return &client, nil
+}
+
+func (r *oauthRepository) ListClients(ctx context.Context) ([]models.OAuthClient, error) {
+	query := `SELECT id, client_id, COALESCE(client_secret_hash, ''), name, redirect_uris, created_at 
+            FROM oauth_clients ORDER BY created_at`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		log.Printf("[Repository - ListClients] Error executing query: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var clients []models.OAuthClient
+
+	for rows.Next() {
+		var client models.OAuthClient
+		if err := rows.
+			Scan(&client.ID, &client.ClientID, &client.ClientSecretHash, &client.Name, pq.Array(&client.RedirectURIs), &client.CreatedAt); err != nil {
+			log.Printf("[Repository - ListClients] Error scanning row: %v", err)
+			return nil, err
+		}
+		clients = append(clients, client)
+	}
+
+	return clients, rows.Err()
+}
+
+func (r *oauthRepository) CreateAuthorizationCode(ctx context.Context, code *models.AuthorizationCode) error {
+	query := `INSERT INTO oauth_authorization_codes 
+            (code_hash, client_id, user_id, redirect_uri, scope, nonce, code_challenge, expires_at) 
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	_, err := r.db.ExecContext(ctx, query, code.CodeHash, code.ClientID, code.UserID, code.RedirectURI, code.Scope, code.Nonce, code.CodeChallenge, code.ExpiresAt)
+	if err != nil {
+		log.Printf("[Repository - CreateAuthorizationCode] Error executing query: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// ConsumeAuthorizationCode marks a code issued to the client for the redirect
+// URI as used and returns it. A code can only be consumed once; later calls,
+// and calls for another client or redirect URI, return nil and leave it be.
+func (r *oauthRepository) ConsumeAuthorizationCode(ctx context.Context, codeHash, clientID, redirectURI string) (*models.AuthorizationCode, error) {
+	query := `UPDATE oauth_authorization_codes SET used_at = NOW() 
+            WHERE code_hash = $1 AND client_id = $2 AND redirect_uri = $3 AND used_at IS NULL 
+            RETURNING code_hash, client_id, user_id, redirect_uri, scope, nonce, code_challenge, expires_at, used_at`
+
+	var code models.AuthorizationCode
+
+	if err := r.db.QueryRowContext(ctx, query, codeHash, clientID, redirectURI).
+		Scan(&code.CodeHash, &code.ClientID, &code.UserID, &code.RedirectURI, &code.Scope, &code.Nonce, &code.CodeChallenge, &code.ExpiresAt, &code.UsedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		log.Printf("[Repository - ConsumeAuthorizationCode] Error scanning row: %v", err)
+		return nil, err
+	}
+
+	return &code, nil
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/internal/models"
+)
+
+// RevocationChannel is the Redis channel bookservice and bookcategoryservice
+// read revocation events from.
+const RevocationChannel = "auth:revocations"
+
+type revocationRepository struct {
+	client *redis.Client
+}
+
+// NewRevocationRepository creates a revocation repository. A nil client
+// drops every event.
+func NewRevocationRepository(client *redis.Client) *revocationRepository {
+	return &revocationRepository{client: client}
+}
+
+// PublishRevocation tells the other services to stop trusting what they
+// cached about a token or user.
+func (r *revocationRepository) PublishRevocation(ctx context.Context, event models.RevocationEvent) error {
+	if r.client == nil {
+		return nil
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode revocation event: %w", err)
+	}
+	if err := r.client.Publish(ctx, RevocationChannel, payload).Err(); err != nil {
+		return fmt.Errorf("failed to publish revocation event: %w", err)
+	}
+
+	return nil
+}
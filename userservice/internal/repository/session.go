@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/internal/models"
+)
+
+type sessionRepository struct {
+	db *sql.DB
+}
+
+func NewSessionRepository(db *sql.DB) *sessionRepository {
+	return &sessionRepository{db: db}
+}
+
+func (r *sessionRepository) CreateSession(ctx context.Context, session *models.Session) error {
+	query := `INSERT INTO sessions (id, user_id, client_id, scope, refresh_token_hash, user_agent, ip_address, expires_at) 
+            VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, $8) RETURNING created_at, last_seen_at`
+
+	if err := r.db.QueryRowContext(ctx, query, session.ID, session.UserID, session.ClientID, session.Scope, session.RefreshTokenHash, session.UserAgent, session.IPAddress, session.ExpiresAt).
+		Scan(&session.CreatedAt, &session.LastSeenAt); err != nil {
+		log.Printf("[Repository - CreateSession] Error executing query: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *sessionRepository) GetSessionByID(ctx context.Context, sessionID uuid.UUID) (*models.Session, error) {
+	query := `SELECT id, user_id, COALESCE(client_id, ''), scope, refresh_token_hash, user_agent, ip_address, created_at, last_seen_at, expires_at, revoked_at 
+            FROM sessions WHERE id = $1`
+
+	var session models.Session
+
+	if err := r.db.QueryRowContext(ctx, query, sessionID).
+		Scan(&session.ID, &session.UserID, &session.ClientID, &session.Scope, &session.RefreshTokenHash, &session.UserAgent, &session.IPAddress, &session.CreatedAt, &session.LastSeenAt, &session.ExpiresAt, &session.RevokedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		log.Printf("[Repository - GetSessionByID] Error scanning row: %v", err)
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// ListActiveSessions returns the user's sessions that are neither revoked nor expired.
+func (r *sessionRepository) ListActiveSessions(ctx context.Context, userID uuid.UUID) ([]models.Session, error) {
+	query := `SELECT id, user_id, COALESCE(client_id, ''), scope, refresh_token_hash, user_agent, ip_address, created_at, last_seen_at, expires_at, revoked_at 
+            FROM sessions WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW() 
+            ORDER BY last_seen_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		log.Printf("[Repository - ListActiveSessions] Error executing query: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []models.Session
+
+	for rows.Next() {
+		var session models.Session
+		if err := rows.
+			Scan(&session.ID, &session.UserID, &session.ClientID, &session.Scope, &session.RefreshTokenHash, &session.UserAgent, &session.IPAddress, &session.CreatedAt, &session.LastSeenAt, &session.ExpiresAt, &session.RevokedAt); err != nil {
+			log.Printf("[Repository - ListActiveSessions] Error scanning row: %v", err)
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+// RevokeSession revokes one of the user's sessions. It reports whether an
+// active session was found.
+func (r *sessionRepository) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) (bool, error) {
+	query := `UPDATE sessions SET revoked_at = NOW() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`
+
+	res, err := r.db.ExecContext(ctx, query, sessionID, userID)
+	if err != nil {
+		log.Printf("[Repository - RevokeSession] Error executing query: %v", err)
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+// RevokeAllSessions revokes every active session of the user.
+func (r *sessionRepository) RevokeAllSessions(ctx context.Context, userID uuid.UUID) (int64, error) {
+	query := `UPDATE sessions SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`
+
+	res, err := r.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		log.Printf("[Repository - RevokeAllSessions] Error executing query: %v", err)
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// TouchSession records session activity, writing at most once a minute per session.
+func (r *sessionRepository) TouchSession(ctx context.Context, sessionID uuid.UUID) error {
+	query := `UPDATE sessions SET last_seen_at = NOW() 
+            WHERE id = $1 AND last_seen_at < NOW() - INTERVAL '1 minute'`
+
+	_, err := r.db.ExecContext(ctx, query, sessionID)
+	if err != nil {
+		log.Printf("[Repository - TouchSession] Error executing query: %v", err)
+		return err
+	}
+
+	return nil
+}
@@ -3,9 +3,12 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"log"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/sir-shalahuddin/grpc-learn/userservice/internal/dto"
 	"github.com/sir-shalahuddin/grpc-learn/userservice/internal/models"
 )
@@ -32,12 +35,12 @@ func (r *userRepository) CreateUser(ctx context.Context, user *models.User) erro
 }
 
 func (r *userRepository) GetUserByID(ctx context.Context, userID uuid.UUID) (*models.User, error) {
-	query := `SELECT id, name, email, created_at, updated_at, role FROM users WHERE id = $1`
+	query := `SELECT id, name, email, created_at, updated_at, role, status FROM users WHERE id = $1`
 
 	var user models.User
 
 	if err := r.db.QueryRowContext(ctx, query, userID).
-		Scan(&user.UserID, &user.Name, &user.Email, &user.CreatedAt, &user.UpdatedAt, &user.Role); err != nil {
+		Scan(&user.UserID, &user.Name, &user.Email, &user.CreatedAt, &user.UpdatedAt, &user.Role, &user.Status); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil // User not found
 		}
@@ -48,6 +51,31 @@ func (r *userRepository) GetUserByID(ctx context.Context, userID uuid.UUID) (*mo
 	return &user, nil
 }
 
+// GetUsersByIDs retrieves the users with the given IDs, leaving out those
+// that don't exist.
+func (r *userRepository) GetUsersByIDs(ctx context.Context, userIDs []uuid.UUID) ([]models.User, error) {
+	query := `SELECT id, name, email, created_at, updated_at, role, status FROM users WHERE id = ANY($1)`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(userIDs))
+	if err != nil {
+		log.Printf("[Repository - GetUsersByIDs] Error executing query: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(&user.UserID, &user.Name, &user.Email, &user.CreatedAt, &user.UpdatedAt, &user.Role, &user.Status); err != nil {
+			log.Printf("[Repository - GetUsersByIDs] Error scanning row: %v", err)
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
 // UpdateUser updates the user details in the database
 func (r *userRepository) UpdateUser(ctx context.Context, user *models.User) error {
 	query := `UPDATE users SET name = $1, email = $2, updated_at = NOW() 
@@ -75,39 +103,81 @@ func (r *userRepository) DeleteUser(ctx context.Context, userID uuid.UUID) error
 	return nil
 }
 
-func (r *userRepository) ListUsers(ctx context.Context) ([]dto.GetUser, error) {
-	query := `SELECT id, name, email, role, created_at, updated_at FROM users where role <> 'super admin'`
+// userListKeys orders users by sign-up as keyset sort keys.
+var userListKeys = []sortKey{{expr: "created_at"}, {expr: "id"}}
+
+// ListUsers returns a page of users other than super admins in sign-up order,
+// starting after the user whose sort keys are after. It also returns the sort
+// keys of the last user when more users follow.
+func (r *userRepository) ListUsers(ctx context.Context, after []string, limit int) ([]dto.GetUser, []string, error) {
+	if err := checkCursor(userListKeys, after); err != nil {
+		return nil, nil, err
+	}
+
+	query := `SELECT id, name, email, role, status, created_at, updated_at FROM users where role <> 'super admin'`
+	var args []interface{}
+	if after != nil {
+		condition, afterArgs := keysetCondition(userListKeys, after, 1)
+		query += ` AND ` + condition
+		args = append(args, afterArgs...)
+	}
+
+	// One user more than asked for tells whether another page follows
+	query += fmt.Sprintf(` ORDER BY %s LIMIT $%d`, orderBy(userListKeys), len(args)+1)
+	args = append(args, limit+1)
 
-	rows, err := r.db.QueryContext(ctx, query)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		log.Printf("[Repository - ListUsers] Error executing query: %v", err)
-		return nil, err
+		return nil, nil, err
 	}
 
 	defer rows.Close()
 
-	var users []dto.GetUser
+	users := []dto.GetUser{}
 
 	for rows.Next() {
 		var user dto.GetUser
 		if err := rows.
-			Scan(&user.UserID, &user.Name, &user.Email, &user.Role, &user.CreatedAt, &user.UpdatedAt); err != nil {
+			Scan(&user.UserID, &user.Name, &user.Email, &user.Role, &user.Status, &user.CreatedAt, &user.UpdatedAt); err != nil {
 			log.Printf("[Repository - ListUsers] Error scanning row: %v", err)
-			return nil, err
+			return nil, nil, err
 		}
 		users = append(users, user)
 	}
 
-	return users, nil
+	if err := rows.Err(); err != nil {
+		log.Printf("[Repository - ListUsers] Error during rows iteration: %v", err)
+		return nil, nil, err
+	}
+
+	if len(users) <= limit {
+		return users, nil, nil
+	}
+
+	users = users[:limit]
+	last := users[limit-1]
+	return users, []string{last.CreatedAt.Format(time.RFC3339Nano), last.UserID.String()}, nil
+}
+
+// CountUsers returns how many users other than super admins there are.
+func (r *userRepository) CountUsers(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM users WHERE role <> 'super admin'`).Scan(&count)
+	if err != nil {
+		log.Printf("[Repository - CountUsers] Error executing query: %v", err)
+		return 0, err
+	}
+	return count, nil
 }
 
 func (r *userRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
-	query := `SELECT id, name, email, password_hash FROM users WHERE email = $1`
+	query := `SELECT id, name, email, password_hash, status FROM users WHERE email = $1`
 
 	var user models.User
 
 	if err := r.db.QueryRowContext(ctx, query, email).
-		Scan(&user.UserID, &user.Name, &user.Email, &user.Password); err != nil {
+		Scan(&user.UserID, &user.Name, &user.Email, &user.Password, &user.Status); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
@@ -129,3 +199,16 @@ func (r *userRepository) UpdateUserRoles(ctx context.Context, userID uuid.UUID,
 
 	return nil
 }
+
+// UpdateUserStatus sets the status of the user's account.
+func (r *userRepository) UpdateUserStatus(ctx context.Context, userID uuid.UUID, status string) error {
+	query := `UPDATE users SET status = $1, updated_at = NOW() WHERE id = $2`
+
+	_, err := r.db.ExecContext(ctx, query, status, userID)
+	if err != nil {
+		log.Printf("[Repository - UpdateUserStatus] Error executing query: %v", err)
+		return err
+	}
+
+	return nil
+}
@@ -1,27 +1,60 @@
 package router
 
 import (
+	"context"
 	"database/sql"
+	"log"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/swagger"
+	"github.com/redis/go-redis/v9"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/config"
 	"github.com/sir-shalahuddin/grpc-learn/userservice/internal/handler"
 	"github.com/sir-shalahuddin/grpc-learn/userservice/internal/repository"
 	"github.com/sir-shalahuddin/grpc-learn/userservice/internal/service"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/pkg/oidc"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/proto/bookservice"
 )
 
 // RegisterRoutes sets up the Fiber routes for user management
-func RegisterRoutes(app *fiber.App, db *sql.DB, jwtSecret string) {
+func RegisterRoutes(app *fiber.App, db *sql.DB, redisClient *redis.Client, bookSvc bookservice.BookServiceClient, jwtSecret string, oidcConfig config.OIDCConfig) {
 	userRepo := repository.NewUserRepository(db)
 	userService := service.NewUserService(userRepo)
 	userHandler := handler.NewUserHandler(userService)
 
-	authService := service.NewAuthService(userRepo, jwtSecret)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+	authService := service.NewAuthService(userRepo, apiKeyRepo, sessionRepo, jwtSecret)
 	authHandler := handler.NewAuthHandler(authService)
 
-	adminService := service.NewAdminService(userRepo)
+	// Tell bookservice and bookcategoryservice about revoked tokens right away
+	revocationRepo := repository.NewRevocationRepository(redisClient)
+
+	sessionService := service.NewSessionService(sessionRepo, userRepo, revocationRepo)
+	sessionHandler := handler.NewSessionHandler(sessionService)
+
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo, userRepo, revocationRepo)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
+
+	bookRepo := repository.NewBookRepository(bookSvc)
+	dataExportRepo := repository.NewDataExportRepository(db)
+	privacyService := service.NewPrivacyService(dataExportRepo, userRepo, bookRepo, revocationRepo)
+	// Exports left pending by a stopped instance are failed within minutes
+	go privacyService.Schedule(context.Background(), time.Minute)
+	privacyHandler := handler.NewPrivacyHandler(privacyService)
+
+	adminService := service.NewAdminService(userRepo, revocationRepo)
 	adminHandler := handler.NewAdminHandler(adminService)
 
+	signer, err := oidc.NewSigner(oidcConfig.SigningKey)
+	if err != nil {
+		log.Fatalf("failed to load OIDC signing key: %v", err)
+	}
+	oauthRepo := repository.NewOAuthRepository(db)
+	oauthService := service.NewOAuthService(oauthRepo, userRepo, authService, signer, oidcConfig.Issuer)
+	oauthHandler := handler.NewOAuthHandler(oauthService)
+
 	authMiddleware := handler.NewAuthMiddleware(authService)
 
 	// documentation
@@ -33,14 +66,40 @@ func RegisterRoutes(app *fiber.App, db *sql.DB, jwtSecret string) {
 	auth.Post("/login", authHandler.Login)
 	auth.Post("/refresh-token", authHandler.RefreshToken)
 
+	// OpenID Connect provider routes
+	app.Get("/.well-known/openid-configuration", oauthHandler.Discovery)
+	app.Get("/.well-known/jwks.json", oauthHandler.JWKS)
+	oauth := app.Group("/oauth")
+	oauth.Get("/authorize", oauthHandler.Authorize)
+	oauth.Post("/authorize", oauthHandler.Consent)
+	oauth.Post("/login", oauthHandler.SignIn)
+	oauth.Post("/token", oauthHandler.Token)
+	// Only access tokens issued to OAuth clients, checked by the handler
+	oauth.Get("/userinfo", oauthHandler.UserInfo)
+
 	// User routes
 	profile := app.Group("/profile", authMiddleware.Protected())
 	profile.Get("/", userHandler.GetProfile)
 	profile.Put("/", userHandler.UpdateProfile)
+	profile.Delete("/", privacyHandler.EraseAccount)
+	profile.Get("/api-keys", apiKeyHandler.ListAPIKeys)
+	profile.Post("/api-keys", apiKeyHandler.CreateAPIKey)
+	profile.Delete("/api-keys/:id", apiKeyHandler.RevokeAPIKey)
+	profile.Get("/sessions", sessionHandler.ListSessions)
+	profile.Delete("/sessions/:id", sessionHandler.RevokeSession)
+	profile.Post("/exports", privacyHandler.RequestExport)
+	profile.Get("/exports/:id", privacyHandler.GetExport)
+	profile.Get("/exports/:id/download", privacyHandler.DownloadExport)
 
 	// Admin routes
 	admin := app.Group("/admin", authMiddleware.Protected("super admin"))
 	admin.Get("/users", adminHandler.ListUsers)
 	admin.Put("/users/:id/roles", adminHandler.UpdateUserRoles)
+	admin.Put("/users/:id/status", adminHandler.UpdateUserStatus)
 	admin.Delete("/users/:id", adminHandler.DeleteUser)
+	admin.Get("/users/:id/sessions", sessionHandler.ListUserSessions)
+	admin.Delete("/users/:id/sessions", sessionHandler.RevokeUserSessions)
+	admin.Delete("/users/:id/sessions/:session_id", sessionHandler.RevokeUserSession)
+	admin.Get("/oauth/clients", oauthHandler.ListClients)
+	admin.Post("/oauth/clients", oauthHandler.RegisterClient)
 }
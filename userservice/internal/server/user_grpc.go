@@ -14,7 +14,9 @@ import (
 
 type AuthService interface {
 	GetUserByID(ctx context.Context, id uuid.UUID) (*models.User, error)
-	ValidateToken(tokenStr string) (uuid.UUID, error)
+	GetUsersByIDs(ctx context.Context, ids []uuid.UUID) ([]models.User, error)
+	ValidateToken(ctx context.Context, tokenStr string) (*models.TokenInfo, error)
+	IntrospectToken(ctx context.Context, tokenStr string) (*models.TokenIntrospection, error)
 }
 
 type authServiceServer struct {
@@ -52,11 +54,67 @@ func (s *authServiceServer) GetUserByID(ctx context.Context, in *pb.GetUserByIDR
 	}, nil
 }
 
+// GetUsersByIDs looks up several users at once, leaving out unknown IDs.
+func (s *authServiceServer) GetUsersByIDs(ctx context.Context, in *pb.GetUsersByIDsRequest) (*pb.GetUsersByIDsResponse, error) {
+	ids := make([]uuid.UUID, 0, len(in.GetUserIds()))
+	for _, id := range in.GetUserIds() {
+		userID, err := uuid.Parse(id)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "Invalid user ID format: %v", err)
+		}
+		ids = append(ids, userID)
+	}
+
+	users, err := s.authService.GetUsersByIDs(ctx, ids)
+	if err != nil {
+		if errors.Is(err, service.ErrTooManyUsers) {
+			return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		return nil, status.Errorf(codes.Internal, "Failed to get users by ID: %v", err)
+	}
+
+	resp := &pb.GetUsersByIDsResponse{Users: make([]*pb.User, 0, len(users))}
+	for _, user := range users {
+		resp.Users = append(resp.Users, &pb.User{
+			UserId: user.UserID.String(),
+			Email:  user.Email,
+			Name:   user.Name,
+			Role:   user.Role,
+		})
+	}
+	return resp, nil
+}
+
 func (s *authServiceServer) ValidateToken(ctx context.Context, in *pb.ValidateTokenRequest) (*pb.ValidateTokenResponse, error) {
-	userID, err := s.authService.ValidateToken(in.GetToken())
+	info, err := s.authService.ValidateToken(ctx, in.GetToken())
 	if err != nil {
 		return nil, status.Errorf(codes.Unauthenticated, "Invalid or expired token: %v", err)
 	}
 
-	return &pb.ValidateTokenResponse{UserId: userID.String()}, nil
+	return &pb.ValidateTokenResponse{
+		UserId: info.UserID.String(),
+		Scopes: info.Scopes,
+	}, nil
+}
+
+func (s *authServiceServer) IntrospectToken(ctx context.Context, in *pb.IntrospectTokenRequest) (*pb.IntrospectTokenResponse, error) {
+	info, err := s.authService.IntrospectToken(ctx, in.GetToken())
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "Invalid or expired token: %v", err)
+	}
+
+	var sessionID string
+	if info.SessionID != uuid.Nil {
+		sessionID = info.SessionID.String()
+	}
+
+	return &pb.IntrospectTokenResponse{
+		UserId:        info.UserID.String(),
+		Role:          info.Role,
+		Permissions:   info.Permissions,
+		TokenType:     info.TokenType,
+		ExpiresAt:     info.ExpiresAt.Unix(),
+		SessionId:     sessionID,
+		AccountStatus: info.AccountStatus,
+	}, nil
 }
@@ -7,6 +7,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/sir-shalahuddin/grpc-learn/userservice/internal/dto"
 	"github.com/sir-shalahuddin/grpc-learn/userservice/internal/models"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/pkg/pagination"
 )
 
 var (
@@ -14,30 +15,61 @@ var (
 	ErrInsufficientPermissions = errors.New("insufficient permissions to action to super admin")
 )
 
+const (
+	defaultUserPageSize = 50
+	maxUserPageSize     = 200
+
+	// userListSort names the sign-up order in list cursors
+	userListSort = "created"
+)
+
 type AdminRepository interface {
 	GetUserByID(ctx context.Context, userID uuid.UUID) (*models.User, error)
 	DeleteUser(ctx context.Context, userID uuid.UUID) error
-	ListUsers(ctx context.Context) ([]dto.GetUser, error)
+	ListUsers(ctx context.Context, after []string, limit int) ([]dto.GetUser, []string, error)
+	CountUsers(ctx context.Context) (int64, error)
 	UpdateUserRoles(ctx context.Context, userID uuid.UUID, roles string) error
+	UpdateUserStatus(ctx context.Context, userID uuid.UUID, status string) error
 }
 
 type adminService struct {
-	repo AdminRepository
+	repo        AdminRepository
+	revocations RevocationPublisher
 }
 
-func NewAdminService(repo AdminRepository) *adminService {
+func NewAdminService(repo AdminRepository, revocations RevocationPublisher) *adminService {
 	return &adminService{
-		repo: repo,
+		repo:        repo,
+		revocations: revocations,
 	}
 }
 
-// ListUsers retrieves all users from the repository.
-func (s *adminService) ListUsers(ctx context.Context) ([]dto.GetUser, error) {
-	users, err := s.repo.ListUsers(ctx)
+// ListUsers retrieves a page of users in sign-up order, with their total
+// when asked for. Pages follow each other through NextCursor.
+func (s *adminService) ListUsers(ctx context.Context, req dto.ListUsersRequest) (*dto.ListUsersResponse, error) {
+	after, err := pagination.Decode(req.Cursor, userListSort)
+	if err != nil {
+		return nil, err
+	}
+
+	users, nextKeys, err := s.repo.ListUsers(ctx, after, pagination.Limit(req.PageSize, defaultUserPageSize, maxUserPageSize))
 	if err != nil {
 		return nil, err
 	}
-	return users, nil
+
+	page := &dto.ListUsersResponse{
+		Users:      users,
+		NextCursor: pagination.Encode(userListSort, nextKeys),
+	}
+	if req.IncludeTotal {
+		total, err := s.repo.CountUsers(ctx)
+		if err != nil {
+			return nil, err
+		}
+		page.Total = &total
+	}
+
+	return page, nil
 }
 
 // UpdateUserRoles updates the roles of a user, ensuring the user is found and
@@ -63,6 +95,29 @@ func (s *adminService) UpdateUserRoles(ctx context.Context, userID uuid.UUID, re
 		return err
 	}
 
+	publishUserRevocation(ctx, s.revocations, userID)
+	return nil
+}
+
+// UpdateUserStatus suspends or locks a user's account, or makes it active
+// again. Super admin accounts are left to their owners.
+func (s *adminService) UpdateUserStatus(ctx context.Context, userID uuid.UUID, req dto.UpdateUserStatus) error {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+	if user.Role == "super admin" {
+		return ErrInsufficientPermissions
+	}
+
+	if err := s.repo.UpdateUserStatus(ctx, userID, req.Status); err != nil {
+		return err
+	}
+
+	publishUserRevocation(ctx, s.revocations, userID)
 	return nil
 }
 
@@ -87,5 +142,6 @@ func (s *adminService) DeleteUser(ctx context.Context, userID uuid.UUID) error {
 		return err
 	}
 
+	publishUserRevocation(ctx, s.revocations, userID)
 	return nil
 }
@@ -8,14 +8,17 @@ import (
 	"github.com/google/uuid"
 	"github.com/sir-shalahuddin/grpc-learn/userservice/internal/dto"
 	"github.com/sir-shalahuddin/grpc-learn/userservice/internal/models"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/pkg/pagination"
 )
 
 // Mock AdminRepository untuk pengujian
 type MockAdminRepository struct {
-	GetUserByIDFunc    func(ctx context.Context, userID uuid.UUID) (*models.User, error)
-	DeleteUserFunc     func(ctx context.Context, userID uuid.UUID) error
-	ListUsersFunc      func(ctx context.Context) ([]dto.GetUser, error)
-	UpdateUserRolesFunc func(ctx context.Context, userID uuid.UUID, roles string) error
+	GetUserByIDFunc      func(ctx context.Context, userID uuid.UUID) (*models.User, error)
+	DeleteUserFunc       func(ctx context.Context, userID uuid.UUID) error
+	ListUsersFunc        func(ctx context.Context, after []string, limit int) ([]dto.GetUser, []string, error)
+	CountUsersFunc       func(ctx context.Context) (int64, error)
+	UpdateUserRolesFunc  func(ctx context.Context, userID uuid.UUID, roles string) error
+	UpdateUserStatusFunc func(ctx context.Context, userID uuid.UUID, status string) error
 }
 
 func (m *MockAdminRepository) GetUserByID(ctx context.Context, userID uuid.UUID) (*models.User, error) {
@@ -26,33 +29,95 @@ func (m *MockAdminRepository) DeleteUser(ctx context.Context, userID uuid.UUID)
 	return m.DeleteUserFunc(ctx, userID)
 }
 
-func (m *MockAdminRepository) ListUsers(ctx context.Context) ([]dto.GetUser, error) {
-	return m.ListUsersFunc(ctx)
+func (m *MockAdminRepository) ListUsers(ctx context.Context, after []string, limit int) ([]dto.GetUser, []string, error) {
+	return m.ListUsersFunc(ctx, after, limit)
+}
+
+func (m *MockAdminRepository) CountUsers(ctx context.Context) (int64, error) {
+	return m.CountUsersFunc(ctx)
 }
 
 func (m *MockAdminRepository) UpdateUserRoles(ctx context.Context, userID uuid.UUID, roles string) error {
 	return m.UpdateUserRolesFunc(ctx, userID, roles)
 }
 
+func (m *MockAdminRepository) UpdateUserStatus(ctx context.Context, userID uuid.UUID, status string) error {
+	return m.UpdateUserStatusFunc(ctx, userID, status)
+}
+
 // Test ListUsers: Berhasil mendapatkan daftar pengguna
 func TestListUsers_Success(t *testing.T) {
 	mockRepo := &MockAdminRepository{
-		ListUsersFunc: func(ctx context.Context) ([]dto.GetUser, error) {
+		ListUsersFunc: func(ctx context.Context, after []string, limit int) ([]dto.GetUser, []string, error) {
 			return []dto.GetUser{
 				{UserID: uuid.New(), Name: "User1", Email: "user1@example.com"},
 				{UserID: uuid.New(), Name: "User2", Email: "user2@example.com"},
-			}, nil
+			}, nil, nil
 		},
 	}
-	adminService := NewAdminService(mockRepo)
+	adminService := NewAdminService(mockRepo, &MockRevocationPublisher{})
 
-	users, err := adminService.ListUsers(context.Background())
+	page, err := adminService.ListUsers(context.Background(), dto.ListUsersRequest{})
 
 	if err != nil {
 		t.Errorf("expected no error, got %v", err)
 	}
-	if len(users) != 2 {
-		t.Errorf("expected 2 users, got %d", len(users))
+	if len(page.Users) != 2 {
+		t.Errorf("expected 2 users, got %d", len(page.Users))
+	}
+	if page.NextCursor != "" {
+		t.Errorf("expected no next cursor on the last page, got %q", page.NextCursor)
+	}
+	if page.Total != nil {
+		t.Errorf("expected no total when not asked for, got %d", *page.Total)
+	}
+}
+
+// Test ListUsers: Cursor halaman berikutnya melanjutkan dari pengguna terakhir
+func TestListUsers_NextCursor(t *testing.T) {
+	lastKeys := []string{"2024-01-02T03:04:05.123456Z", uuid.NewString()}
+	var gotAfter []string
+	mockRepo := &MockAdminRepository{
+		ListUsersFunc: func(ctx context.Context, after []string, limit int) ([]dto.GetUser, []string, error) {
+			gotAfter = after
+			if limit != 1 {
+				t.Errorf("expected limit 1, got %d", limit)
+			}
+			return []dto.GetUser{{UserID: uuid.New(), Name: "User1"}}, lastKeys, nil
+		},
+		CountUsersFunc: func(ctx context.Context) (int64, error) {
+			return 3, nil
+		},
+	}
+	adminService := NewAdminService(mockRepo, &MockRevocationPublisher{})
+
+	page, err := adminService.ListUsers(context.Background(), dto.ListUsersRequest{PageSize: 1, IncludeTotal: true})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if page.NextCursor == "" {
+		t.Fatal("expected a next cursor")
+	}
+	if page.Total == nil || *page.Total != 3 {
+		t.Errorf("expected total 3, got %v", page.Total)
+	}
+
+	if _, err := adminService.ListUsers(context.Background(), dto.ListUsersRequest{Cursor: page.NextCursor, PageSize: 1}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(gotAfter) != 2 || gotAfter[0] != lastKeys[0] || gotAfter[1] != lastKeys[1] {
+		t.Errorf("expected the next page to start after %v, got %v", lastKeys, gotAfter)
+	}
+}
+
+// Test ListUsers: Cursor tidak valid
+func TestListUsers_InvalidCursor(t *testing.T) {
+	adminService := NewAdminService(&MockAdminRepository{}, &MockRevocationPublisher{})
+
+	_, err := adminService.ListUsers(context.Background(), dto.ListUsersRequest{Cursor: "not-a-cursor"})
+
+	if !errors.Is(err, pagination.ErrInvalidCursor) {
+		t.Errorf("expected ErrInvalidCursor, got %v", err)
 	}
 }
 
@@ -69,7 +134,7 @@ func TestUpdateUserRoles_Success(t *testing.T) {
 			return nil
 		},
 	}
-	adminService := NewAdminService(mockRepo)
+	adminService := NewAdminService(mockRepo, &MockRevocationPublisher{})
 
 	err := adminService.UpdateUserRoles(context.Background(), uuid.New(), dto.UpdateUserRoles{Role: "admin"})
 
@@ -88,7 +153,7 @@ func TestUpdateUserRoles_InsufficientPermissions(t *testing.T) {
 			}, nil
 		},
 	}
-	adminService := NewAdminService(mockRepo)
+	adminService := NewAdminService(mockRepo, &MockRevocationPublisher{})
 
 	err := adminService.UpdateUserRoles(context.Background(), uuid.New(), dto.UpdateUserRoles{Role: "super admin"})
 
@@ -97,6 +162,51 @@ func TestUpdateUserRoles_InsufficientPermissions(t *testing.T) {
 	}
 }
 
+// Test UpdateUserStatus: Berhasil menangguhkan akun
+func TestUpdateUserStatus_Success(t *testing.T) {
+	var updated string
+	mockRepo := &MockAdminRepository{
+		GetUserByIDFunc: func(ctx context.Context, userID uuid.UUID) (*models.User, error) {
+			return &models.User{UserID: userID, Role: "user"}, nil
+		},
+		UpdateUserStatusFunc: func(ctx context.Context, userID uuid.UUID, status string) error {
+			updated = status
+			return nil
+		},
+	}
+	revocations := &MockRevocationPublisher{}
+	adminService := NewAdminService(mockRepo, revocations)
+
+	userID := uuid.New()
+	err := adminService.UpdateUserStatus(context.Background(), userID, dto.UpdateUserStatus{Status: models.AccountStatusSuspended})
+
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if updated != models.AccountStatusSuspended {
+		t.Errorf("expected the account to be suspended, got %q", updated)
+	}
+	if len(revocations.Events) != 1 || revocations.Events[0].UserID != userID.String() {
+		t.Errorf("expected a revocation event for the user, got %+v", revocations.Events)
+	}
+}
+
+// Test UpdateUserStatus: Akun super admin tidak bisa diubah
+func TestUpdateUserStatus_SuperAdmin(t *testing.T) {
+	mockRepo := &MockAdminRepository{
+		GetUserByIDFunc: func(ctx context.Context, userID uuid.UUID) (*models.User, error) {
+			return &models.User{UserID: userID, Role: "super admin"}, nil
+		},
+	}
+	adminService := NewAdminService(mockRepo, &MockRevocationPublisher{})
+
+	err := adminService.UpdateUserStatus(context.Background(), uuid.New(), dto.UpdateUserStatus{Status: models.AccountStatusLocked})
+
+	if !errors.Is(err, ErrInsufficientPermissions) {
+		t.Errorf("expected ErrInsufficientPermissions, got %v", err)
+	}
+}
+
 // Test DeleteUser: Berhasil menghapus pengguna
 func TestDeleteUser_Success(t *testing.T) {
 	mockRepo := &MockAdminRepository{
@@ -110,7 +220,7 @@ func TestDeleteUser_Success(t *testing.T) {
 			return nil
 		},
 	}
-	adminService := NewAdminService(mockRepo)
+	adminService := NewAdminService(mockRepo, &MockRevocationPublisher{})
 
 	err := adminService.DeleteUser(context.Background(), uuid.New())
 
@@ -129,7 +239,7 @@ func TestDeleteUser_InsufficientPermissions(t *testing.T) {
 			}, nil
 		},
 	}
-	adminService := NewAdminService(mockRepo)
+	adminService := NewAdminService(mockRepo, &MockRevocationPublisher{})
 
 	err := adminService.DeleteUser(context.Background(), uuid.New())
 
@@ -145,7 +255,7 @@ func TestDeleteUser_UserNotFound(t *testing.T) {
 			return nil, nil
 		},
 	}
-	adminService := NewAdminService(mockRepo)
+	adminService := NewAdminService(mockRepo, &MockRevocationPublisher{})
 
 	err := adminService.DeleteUser(context.Background(), uuid.New())
 
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/internal/dto"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/internal/models"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/pkg/auth"
+)
+
+var (
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	ErrInvalidScope   = errors.New("invalid api key scope")
+)
+
+// grantableScopes lists the scopes each role may put on its own API keys.
+var grantableScopes = map[string][]string{
+	"user":        {"user"},
+	"librarian":   {"user", "librarian"},
+	"super admin": {"user", "librarian", "super admin"},
+}
+
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, key *models.APIKey) error
+	ListAPIKeysByUser(ctx context.Context, userID uuid.UUID) ([]models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, userID, keyID uuid.UUID) (bool, error)
+}
+
+type APIKeyUserRepository interface {
+	GetUserByID(ctx context.Context, userID uuid.UUID) (*models.User, error)
+}
+
+type apiKeyService struct {
+	repo        APIKeyRepository
+	userRepo    APIKeyUserRepository
+	revocations RevocationPublisher
+}
+
+func NewAPIKeyService(repo APIKeyRepository, userRepo APIKeyUserRepository, revocations RevocationPublisher) *apiKeyService {
+	return &apiKeyService{
+		repo:        repo,
+		userRepo:    userRepo,
+		revocations: revocations,
+	}
+}
+
+// CreateAPIKey issues a new named key for the user. The plain key is only
+// returned here; scopes default to the user's current role.
+func (s *apiKeyService) CreateAPIKey(ctx context.Context, userID uuid.UUID, req dto.CreateAPIKeyRequest) (dto.CreateAPIKeyResponse, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return dto.CreateAPIKeyResponse{}, err
+	}
+	if user == nil {
+		return dto.CreateAPIKeyResponse{}, ErrUserNotFound
+	}
+
+	scopes := req.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{user.Role}
+	}
+	for _, scope := range scopes {
+		if !canGrantScope(user.Role, scope) {
+			return dto.CreateAPIKeyResponse{}, fmt.Errorf("%w: %s", ErrInvalidScope, scope)
+		}
+	}
+
+	plain, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		log.Printf("[Service - CreateAPIKey] Error generating key: %v", err)
+		return dto.CreateAPIKeyResponse{}, err
+	}
+
+	key := models.APIKey{
+		UserID:    userID,
+		Name:      req.Name,
+		Prefix:    prefix,
+		KeyHash:   hash,
+		Scopes:    scopes,
+		ExpiresAt: time.Now().Add(time.Duration(req.ExpiresInDays) * 24 * time.Hour),
+	}
+
+	if err := s.repo.CreateAPIKey(ctx, &key); err != nil {
+		return dto.CreateAPIKeyResponse{}, fmt.Errorf("service: failed to create api key: %w", err)
+	}
+
+	return dto.CreateAPIKeyResponse{
+		ID:        key.ID,
+		Name:      key.Name,
+		Key:       plain,
+		Prefix:    key.Prefix,
+		Scopes:    key.Scopes,
+		ExpiresAt: key.ExpiresAt,
+	}, nil
+}
+
+// ListAPIKeys returns the user's keys without their secrets.
+func (s *apiKeyService) ListAPIKeys(ctx context.Context, userID uuid.UUID) ([]dto.GetAPIKey, error) {
+	keys, err := s.repo.ListAPIKeysByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("service: failed to list api keys: %w", err)
+	}
+
+	res := make([]dto.GetAPIKey, 0, len(keys))
+	for _, key := range keys {
+		res = append(res, dto.GetAPIKey{
+			ID:         key.ID,
+			Name:       key.Name,
+			Prefix:     key.Prefix,
+			Scopes:     key.Scopes,
+			ExpiresAt:  key.ExpiresAt,
+			LastUsedAt: key.LastUsedAt,
+			RevokedAt:  key.RevokedAt,
+			CreatedAt:  key.CreatedAt,
+		})
+	}
+
+	return res, nil
+}
+
+// RevokeAPIKey revokes one of the user's active keys.
+func (s *apiKeyService) RevokeAPIKey(ctx context.Context, userID, keyID uuid.UUID) error {
+	found, err := s.repo.RevokeAPIKey(ctx, userID, keyID)
+	if err != nil {
+		return fmt.Errorf("service: failed to revoke api key: %w", err)
+	}
+	if !found {
+		return ErrAPIKeyNotFound
+	}
+
+	publishUserRevocation(ctx, s.revocations, userID)
+	return nil
+}
+
+func canGrantScope(role, scope string) bool {
+	for _, s := range grantableScopes[role] {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
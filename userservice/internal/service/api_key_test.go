@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sir-shalahuddin/grpc-learn/userservice/internal/dto"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/internal/models"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/pkg/auth"
+)
+
+// MockAPIKeyRepository adalah implementasi mock dari APIKeyRepository dan AuthAPIKeyRepository.
+type MockAPIKeyRepository struct {
+	CreateAPIKeyFunc      func(ctx context.Context, key *models.APIKey) error
+	GetAPIKeyByPrefixFunc func(ctx context.Context, prefix string) (*models.APIKey, error)
+	ListAPIKeysByUserFunc func(ctx context.Context, userID uuid.UUID) ([]models.APIKey, error)
+	RevokeAPIKeyFunc      func(ctx context.Context, userID, keyID uuid.UUID) (bool, error)
+	TouchAPIKeyFunc       func(ctx context.Context, keyID uuid.UUID) error
+}
+
+func (m *MockAPIKeyRepository) CreateAPIKey(ctx context.Context, key *models.APIKey) error {
+	return m.CreateAPIKeyFunc(ctx, key)
+}
+
+func (m *MockAPIKeyRepository) GetAPIKeyByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	return m.GetAPIKeyByPrefixFunc(ctx, prefix)
+}
+
+func (m *MockAPIKeyRepository) ListAPIKeysByUser(ctx context.Context, userID uuid.UUID) ([]models.APIKey, error) {
+	return m.ListAPIKeysByUserFunc(ctx, userID)
+}
+
+func (m *MockAPIKeyRepository) RevokeAPIKey(ctx context.Context, userID, keyID uuid.UUID) (bool, error) {
+	return m.RevokeAPIKeyFunc(ctx, userID, keyID)
+}
+
+func (m *MockAPIKeyRepository) TouchAPIKey(ctx context.Context, keyID uuid.UUID) error {
+	return m.TouchAPIKeyFunc(ctx, keyID)
+}
+
+// Test CreateAPIKey: Berhasil membuat key, hanya hash yang disimpan
+func TestCreateAPIKey_Success(t *testing.T) {
+	var stored models.APIKey
+	mockRepo := &MockAPIKeyRepository{
+		CreateAPIKeyFunc: func(ctx context.Context, key *models.APIKey) error {
+			stored = *key
+			key.ID = uuid.New()
+			return nil
+		},
+	}
+	mockUserRepo := &MockUserRepository{
+		GetUserByIDFunc: func(ctx context.Context, userID uuid.UUID) (*models.User, error) {
+			return &models.User{UserID: userID, Role: "librarian"}, nil
+		},
+	}
+	apiKeyService := NewAPIKeyService(mockRepo, mockUserRepo, &MockRevocationPublisher{})
+
+	resp, err := apiKeyService.CreateAPIKey(context.Background(), uuid.New(), dto.CreateAPIKeyRequest{
+		Name:          "nightly import",
+		ExpiresInDays: 30,
+	})
+
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !auth.IsAPIKey(resp.Key) {
+		t.Errorf("expected an API key, got %q", resp.Key)
+	}
+	if stored.KeyHash == resp.Key || !auth.CompareAPIKey(resp.Key, stored.KeyHash) {
+		t.Error("expected only the key hash to be stored")
+	}
+	if len(stored.Scopes) != 1 || stored.Scopes[0] != "librarian" {
+		t.Errorf("expected scopes to default to the user's role, got %v", stored.Scopes)
+	}
+}
+
+// Test CreateAPIKey: Scope melebihi role user
+func TestCreateAPIKey_ScopeExceedsRole(t *testing.T) {
+	mockUserRepo := &MockUserRepository{
+		GetUserByIDFunc: func(ctx context.Context, userID uuid.UUID) (*models.User, error) {
+			return &models.User{UserID: userID, Role: "user"}, nil
+		},
+	}
+	apiKeyService := NewAPIKeyService(&MockAPIKeyRepository{}, mockUserRepo, &MockRevocationPublisher{})
+
+	_, err := apiKeyService.CreateAPIKey(context.Background(), uuid.New(), dto.CreateAPIKeyRequest{
+		Name:          "escalate",
+		Scopes:        []string{"librarian"},
+		ExpiresInDays: 30,
+	})
+
+	if !errors.Is(err, ErrInvalidScope) {
+		t.Errorf("expected ErrInvalidScope, got %v", err)
+	}
+}
+
+// Test RevokeAPIKey: Key tidak ditemukan
+func TestRevokeAPIKey_NotFound(t *testing.T) {
+	mockRepo := &MockAPIKeyRepository{
+		RevokeAPIKeyFunc: func(ctx context.Context, userID, keyID uuid.UUID) (bool, error) {
+			return false, nil
+		},
+	}
+	apiKeyService := NewAPIKeyService(mockRepo, &MockUserRepository{}, &MockRevocationPublisher{})
+
+	err := apiKeyService.RevokeAPIKey(context.Background(), uuid.New(), uuid.New())
+
+	if !errors.Is(err, ErrAPIKeyNotFound) {
+		t.Errorf("expected ErrAPIKeyNotFound, got %v", err)
+	}
+}
+
+// Test RevokeAPIKey: Layanan lain langsung diberi tahu agar key ditolak
+func TestRevokeAPIKey_PublishesRevocation(t *testing.T) {
+	mockRepo := &MockAPIKeyRepository{
+		RevokeAPIKeyFunc: func(ctx context.Context, userID, keyID uuid.UUID) (bool, error) {
+			return true, nil
+		},
+	}
+	revocations := &MockRevocationPublisher{}
+	apiKeyService := NewAPIKeyService(mockRepo, &MockUserRepository{}, revocations)
+
+	userID := uuid.New()
+	if err := apiKeyService.RevokeAPIKey(context.Background(), userID, uuid.New()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(revocations.Events) != 1 || revocations.Events[0].UserID != userID.String() {
+		t.Errorf("expected a revocation event for the user, got %+v", revocations.Events)
+	}
+}
+
+// Test ValidateToken: API key valid
+func TestValidateToken_APIKeySuccess(t *testing.T) {
+	key, prefix, hash, _ := auth.GenerateAPIKey()
+	userID := uuid.New()
+	touched := false
+
+	mockKeyRepo := &MockAPIKeyRepository{
+		GetAPIKeyByPrefixFunc: func(ctx context.Context, p string) (*models.APIKey, error) {
+			if p != prefix {
+				return nil, nil
+			}
+			return &models.APIKey{UserID: userID, Prefix: prefix, KeyHash: hash, Scopes: []string{"user"}, ExpiresAt: time.Now().Add(time.Hour)}, nil
+		},
+		TouchAPIKeyFunc: func(ctx context.Context, keyID uuid.UUID) error {
+			touched = true
+			return nil
+		},
+	}
+	authService := NewAuthService(&MockAuthRepository{}, mockKeyRepo, &MockSessionRepository{}, "jwt-secret")
+
+	info, err := authService.ValidateToken(context.Background(), key)
+
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if info.UserID != userID || info.TokenType != models.TokenTypeAPIKey {
+		t.Errorf("unexpected token info %+v", info)
+	}
+	if !touched {
+		t.Error("expected last used timestamp to be recorded")
+	}
+}
+
+// Test ValidateToken: API key sudah dicabut atau kedaluwarsa
+func TestValidateToken_APIKeyRevokedOrExpired(t *testing.T) {
+	key, prefix, hash, _ := auth.GenerateAPIKey()
+	revokedAt := time.Now()
+
+	cases := map[string]*models.APIKey{
+		"revoked": {Prefix: prefix, KeyHash: hash, ExpiresAt: time.Now().Add(time.Hour), RevokedAt: &revokedAt},
+		"expired": {Prefix: prefix, KeyHash: hash, ExpiresAt: time.Now().Add(-time.Hour)},
+	}
+
+	for name, stored := range cases {
+		mockKeyRepo := &MockAPIKeyRepository{
+			GetAPIKeyByPrefixFunc: func(ctx context.Context, p string) (*models.APIKey, error) {
+				return stored, nil
+			},
+		}
+		authService := NewAuthService(&MockAuthRepository{}, mockKeyRepo, &MockSessionRepository{}, "jwt-secret")
+
+		_, err := authService.ValidateToken(context.Background(), key)
+
+		if err != auth.ErrInvalidToken {
+			t.Errorf("%s: expected ErrInvalidToken, got %v", name, err)
+		}
+	}
+}
@@ -3,7 +3,9 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
@@ -17,28 +19,47 @@ import (
 const (
 	AccessTokenExpiry  = time.Minute * 10   // 10 minutes for access tokens
 	RefreshTokenExpiry = time.Hour * 24 * 7 // 7 days for refresh tokens
+	MaxUserLookup      = 500                // users GetUsersByIDs looks up at once
 )
 
 var (
 	ErrDuplicateEmail     = errors.New("email already registered")
 	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrAccountNotActive   = errors.New("account is suspended or locked")
+	ErrTooManyUsers       = fmt.Errorf("at most %d users can be looked up at once", MaxUserLookup)
 )
 
 type AuthRepository interface {
 	CreateUser(ctx context.Context, user *models.User) error
 	GetUserByID(ctx context.Context, userID uuid.UUID) (*models.User, error)
+	GetUsersByIDs(ctx context.Context, userIDs []uuid.UUID) ([]models.User, error)
 	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
 }
 
+type AuthAPIKeyRepository interface {
+	GetAPIKeyByPrefix(ctx context.Context, prefix string) (*models.APIKey, error)
+	TouchAPIKey(ctx context.Context, keyID uuid.UUID) error
+}
+
+type AuthSessionRepository interface {
+	CreateSession(ctx context.Context, session *models.Session) error
+	GetSessionByID(ctx context.Context, sessionID uuid.UUID) (*models.Session, error)
+	TouchSession(ctx context.Context, sessionID uuid.UUID) error
+}
+
 type authService struct {
-	repo      AuthRepository
-	jwtSecret string
+	repo        AuthRepository
+	apiKeyRepo  AuthAPIKeyRepository
+	sessionRepo AuthSessionRepository
+	jwtSecret   string
 }
 
-func NewAuthService(repo AuthRepository, jwtSecret string) *authService {
+func NewAuthService(repo AuthRepository, apiKeyRepo AuthAPIKeyRepository, sessionRepo AuthSessionRepository, jwtSecret string) *authService {
 	return &authService{
-		repo:      repo,
-		jwtSecret: jwtSecret,
+		repo:        repo,
+		apiKeyRepo:  apiKeyRepo,
+		sessionRepo: sessionRepo,
+		jwtSecret:   jwtSecret,
 	}
 }
 
@@ -74,32 +95,79 @@ func (s *authService) Register(ctx context.Context, req dto.RegisterRequest) err
 
 // Login user with email and password, returning access and refresh tokens.
 func (s *authService) Login(ctx context.Context, req dto.LoginRequest) (dto.LoginResponse, error) {
+	userID, err := s.Authenticate(ctx, req)
+	if err != nil {
+		return dto.LoginResponse{}, err
+	}
+
+	return s.IssueTokens(ctx, userID, req.Device)
+}
+
+// Authenticate checks the email and password of an active account without
+// starting a session.
+func (s *authService) Authenticate(ctx context.Context, req dto.LoginRequest) (uuid.UUID, error) {
 	// Retrieve the user
 	user, err := s.repo.GetUserByEmail(ctx, req.Email)
 	if err != nil {
-		return dto.LoginResponse{}, err
+		return uuid.Nil, err
 	}
 	if user == nil {
-		return dto.LoginResponse{}, ErrInvalidCredentials
+		return uuid.Nil, ErrInvalidCredentials
 	}
 
 	// Check the password
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password))
 	if err != nil {
 		log.Printf("[Service - Login] Error comparing password: %v", err)
-		return dto.LoginResponse{}, ErrInvalidCredentials
+		return uuid.Nil, ErrInvalidCredentials
+	}
+	if user.Status != models.AccountStatusActive {
+		return uuid.Nil, ErrAccountNotActive
 	}
 
+	return user.UserID, nil
+}
+
+// IssueTokens starts a new session for the user on the given device and
+// returns its access and refresh token pair.
+func (s *authService) IssueTokens(ctx context.Context, userID uuid.UUID, device dto.SessionDevice) (dto.LoginResponse, error) {
+	return s.issueTokens(ctx, userID, "", "", device)
+}
+
+// IssueClientTokens starts a session for the user through an OAuth client,
+// limited to the scopes the user granted it. Its refresh token can only be
+// redeemed by that client.
+func (s *authService) IssueClientTokens(ctx context.Context, userID uuid.UUID, clientID, scope string, device dto.SessionDevice) (dto.LoginResponse, error) {
+	return s.issueTokens(ctx, userID, clientID, scope, device)
+}
+
+func (s *authService) issueTokens(ctx context.Context, userID uuid.UUID, clientID, scope string, device dto.SessionDevice) (dto.LoginResponse, error) {
+	sessionID := uuid.New()
+
 	// Generate Access Token
-	accessToken, err := s.generateToken(user.UserID, "access")
+	accessToken, err := s.generateToken(userID, sessionID, "access")
 	if err != nil {
-		log.Printf("[Service - Login] Error generate token: %v", err)
+		log.Printf("[Service - IssueTokens] Error generate token: %v", err)
 		return dto.LoginResponse{}, err
 	}
 	// Generate Refresh Token
-	refreshToken, err := s.generateToken(user.UserID, "refresh")
+	refreshToken, err := s.generateToken(userID, sessionID, "refresh")
 	if err != nil {
-		log.Printf("[Service - Login] Error generate token: %v", err)
+		log.Printf("[Service - IssueTokens] Error generate token: %v", err)
+		return dto.LoginResponse{}, err
+	}
+
+	session := models.Session{
+		ID:               sessionID,
+		UserID:           userID,
+		ClientID:         clientID,
+		Scope:            scope,
+		RefreshTokenHash: auth.HashToken(refreshToken),
+		UserAgent:        device.UserAgent,
+		IPAddress:        device.IPAddress,
+		ExpiresAt:        time.Now().Add(RefreshTokenExpiry),
+	}
+	if err := s.sessionRepo.CreateSession(ctx, &session); err != nil {
 		return dto.LoginResponse{}, err
 	}
 
@@ -112,15 +180,48 @@ func (s *authService) Login(ctx context.Context, req dto.LoginRequest) (dto.Logi
 }
 
 // RefreshToken generates a new access token using the provided refresh token.
+// Refresh tokens issued to OAuth clients are refused here.
 func (s *authService) RefreshToken(ctx context.Context, req dto.RefreshTokenRequest) (dto.RefreshTokenResponse, error) {
+	return s.refreshToken(ctx, req, "")
+}
+
+// RefreshClientToken generates a new access token for the OAuth client the
+// refresh token was issued to.
+func (s *authService) RefreshClientToken(ctx context.Context, req dto.RefreshTokenRequest, clientID string) (dto.RefreshTokenResponse, error) {
+	if clientID == "" {
+		return dto.RefreshTokenResponse{}, auth.ErrInvalidToken
+	}
+	return s.refreshToken(ctx, req, clientID)
+}
+
+func (s *authService) refreshToken(ctx context.Context, req dto.RefreshTokenRequest, clientID string) (dto.RefreshTokenResponse, error) {
 	// Parse and validate the refresh token
-	userID, err := auth.ValidateToken(req.RefreshToken, s.jwtSecret)
+	claims, err := auth.ParseToken(req.RefreshToken, s.jwtSecret)
 	if err != nil {
 		return dto.RefreshTokenResponse{}, auth.ErrInvalidToken
 	}
 
+	// Refresh tokens issued before sessions existed carry no session ID and
+	// can't be revoked, so they are no longer accepted
+	if claims.SessionID == uuid.Nil {
+		log.Printf("[Service - RefreshToken] Refresh token of user %s has no session", claims.UserID)
+		return dto.RefreshTokenResponse{}, auth.ErrInvalidToken
+	}
+
+	session, err := s.activeSession(ctx, claims)
+	if err != nil {
+		return dto.RefreshTokenResponse{}, err
+	}
+	if !auth.CompareToken(req.RefreshToken, session.RefreshTokenHash) {
+		return dto.RefreshTokenResponse{}, auth.ErrInvalidToken
+	}
+	if session.ClientID != clientID {
+		log.Printf("[Service - RefreshToken] Session %s belongs to another client", session.ID)
+		return dto.RefreshTokenResponse{}, auth.ErrInvalidToken
+	}
+
 	// Generate a new access token
-	newAccessToken, err := s.generateToken(userID, "access")
+	newAccessToken, err := s.generateToken(claims.UserID, claims.SessionID, "access")
 	if err != nil {
 		log.Printf("[Service - RefreshToken] Error generate token: %v", err)
 		return dto.RefreshTokenResponse{}, err
@@ -138,8 +239,20 @@ func (s *authService) GetUserByID(ctx context.Context, id uuid.UUID) (*models.Us
 	return user, nil
 }
 
-// generateToken creates a JWT token with the specified userID and tokenType.
-func (s *authService) generateToken(userID uuid.UUID, tokenType string) (string, error) {
+// GetUsersByIDs retrieves the users with the given IDs in one query, at most
+// MaxUserLookup of them. Unknown IDs are left out.
+func (s *authService) GetUsersByIDs(ctx context.Context, ids []uuid.UUID) ([]models.User, error) {
+	if len(ids) > MaxUserLookup {
+		return nil, ErrTooManyUsers
+	}
+	if len(ids) == 0 {
+		return []models.User{}, nil
+	}
+	return s.repo.GetUsersByIDs(ctx, ids)
+}
+
+// generateToken creates a JWT token with the specified userID, sessionID and tokenType.
+func (s *authService) generateToken(userID, sessionID uuid.UUID, tokenType string) (string, error) {
 	expiry := AccessTokenExpiry
 	if tokenType == "refresh" {
 		expiry = RefreshTokenExpiry
@@ -147,6 +260,7 @@ func (s *authService) generateToken(userID uuid.UUID, tokenType string) (string,
 
 	claims := jwt.MapClaims{
 		"user_id": userID.String(),
+		"sid":     sessionID.String(),
 		"exp":     time.Now().Add(expiry).Unix(),
 	}
 
@@ -160,7 +274,130 @@ func (s *authService) generateToken(userID uuid.UUID, tokenType string) (string,
 	return signedToken, nil
 }
 
-// // ValidateToken parses and validates the JWT token, returning the userID if valid.
-func (s *authService) ValidateToken(tokenStr string) (uuid.UUID, error) {
-	return auth.ValidateToken(tokenStr, s.jwtSecret)
+// ValidateToken validates a JWT or an API key, returning the principal behind it.
+func (s *authService) ValidateToken(ctx context.Context, tokenStr string) (*models.TokenInfo, error) {
+	if auth.IsAPIKey(tokenStr) {
+		return s.validateAPIKey(ctx, tokenStr)
+	}
+
+	claims, err := auth.ParseToken(tokenStr, s.jwtSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tokens without a session predate sessions and can't be revoked. Access
+	// tokens of that time have expired; refresh tokens must not pass as them.
+	if claims.SessionID == uuid.Nil {
+		return nil, auth.ErrInvalidToken
+	}
+	session, err := s.activeSession(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TokenInfo{
+		UserID:        claims.UserID,
+		TokenType:     models.TokenTypeJWT,
+		ClientID:      session.ClientID,
+		GrantedScopes: strings.Fields(session.Scope),
+		SessionID:     claims.SessionID,
+		ExpiresAt:     claims.ExpiresAt,
+	}, nil
+}
+
+// activeSession loads the session behind a token, rejecting it once the
+// session is revoked or expired, and records the activity.
+func (s *authService) activeSession(ctx context.Context, claims *auth.Claims) (*models.Session, error) {
+	session, err := s.sessionRepo.GetSessionByID(ctx, claims.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil || session.UserID != claims.UserID {
+		return nil, auth.ErrInvalidToken
+	}
+	if session.RevokedAt != nil {
+		log.Printf("[Service - ValidateToken] Session %s is revoked", session.ID)
+		return nil, auth.ErrInvalidToken
+	}
+	if time.Now().After(session.ExpiresAt) {
+		log.Printf("[Service - ValidateToken] Session %s is expired", session.ID)
+		return nil, auth.ErrInvalidToken
+	}
+
+	if err := s.sessionRepo.TouchSession(ctx, session.ID); err != nil {
+		log.Printf("[Service - ValidateToken] Error recording session activity: %v", err)
+	}
+
+	return session, nil
+}
+
+// validateAPIKey looks the key up by prefix and checks its hash, expiry and revocation.
+func (s *authService) validateAPIKey(ctx context.Context, key string) (*models.TokenInfo, error) {
+	prefix, ok := auth.ParseAPIKey(key)
+	if !ok {
+		return nil, auth.ErrInvalidToken
+	}
+
+	apiKey, err := s.apiKeyRepo.GetAPIKeyByPrefix(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	if apiKey == nil || !auth.CompareAPIKey(key, apiKey.KeyHash) {
+		return nil, auth.ErrInvalidToken
+	}
+	if apiKey.RevokedAt != nil {
+		log.Printf("[Service - ValidateToken] API key %s is revoked", apiKey.Prefix)
+		return nil, auth.ErrInvalidToken
+	}
+	if time.Now().After(apiKey.ExpiresAt) {
+		log.Printf("[Service - ValidateToken] API key %s is expired", apiKey.Prefix)
+		return nil, auth.ErrInvalidToken
+	}
+
+	if err := s.apiKeyRepo.TouchAPIKey(ctx, apiKey.ID); err != nil {
+		log.Printf("[Service - ValidateToken] Error recording API key usage: %v", err)
+	}
+
+	return &models.TokenInfo{
+		UserID:    apiKey.UserID,
+		TokenType: models.TokenTypeAPIKey,
+		Scopes:    apiKey.Scopes,
+		ExpiresAt: apiKey.ExpiresAt,
+	}, nil
+}
+
+// IntrospectToken validates a token and resolves the user behind it, so
+// callers learn the role and permissions in a single call.
+func (s *authService) IntrospectToken(ctx context.Context, tokenStr string) (*models.TokenIntrospection, error) {
+	info, err := s.ValidateToken(ctx, tokenStr)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.repo.GetUserByID(ctx, info.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		log.Printf("[Service - IntrospectToken] User %s no longer exists", info.UserID)
+		return nil, auth.ErrInvalidToken
+	}
+
+	// JWTs act with the user's role; API keys with the scopes that role still allows
+	permissions := []string{user.Role}
+	if info.TokenType == models.TokenTypeAPIKey {
+		permissions = []string{}
+		for _, scope := range info.Scopes {
+			if canGrantScope(user.Role, scope) {
+				permissions = append(permissions, scope)
+			}
+		}
+	}
+
+	return &models.TokenIntrospection{
+		TokenInfo:     *info,
+		Role:          user.Role,
+		Permissions:   permissions,
+		AccountStatus: user.Status,
+	}, nil
 }
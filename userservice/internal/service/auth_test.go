@@ -18,6 +18,7 @@ import (
 type MockAuthRepository struct {
 	CreateUserFunc     func(ctx context.Context, user *models.User) error
 	GetUserByIDFunc    func(ctx context.Context, userID uuid.UUID) (*models.User, error)
+	GetUsersByIDsFunc  func(ctx context.Context, userIDs []uuid.UUID) ([]models.User, error)
 	GetUserByEmailFunc func(ctx context.Context, email string) (*models.User, error)
 }
 
@@ -29,6 +30,10 @@ func (m *MockAuthRepository) GetUserByID(ctx context.Context, userID uuid.UUID)
 	return m.GetUserByIDFunc(ctx, userID)
 }
 
+func (m *MockAuthRepository) GetUsersByIDs(ctx context.Context, userIDs []uuid.UUID) ([]models.User, error) {
+	return m.GetUsersByIDsFunc(ctx, userIDs)
+}
+
 func (m *MockAuthRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
 	return m.GetUserByEmailFunc(ctx, email)
 }
@@ -40,7 +45,7 @@ func TestRegister_EmailAlreadyTaken(t *testing.T) {
 			return &models.User{Email: "test@example.com"}, nil
 		},
 	}
-	authService := NewAuthService(mockRepo, "jwt-secret")
+	authService := NewAuthService(mockRepo, &MockAPIKeyRepository{}, &MockSessionRepository{}, "jwt-secret")
 
 	err := authService.Register(context.Background(), dto.RegisterRequest{
 		Email:    "test@example.com",
@@ -63,7 +68,7 @@ func TestRegister_Success(t *testing.T) {
 			return nil
 		},
 	}
-	authService := NewAuthService(mockRepo, "jwt-secret")
+	authService := NewAuthService(mockRepo, &MockAPIKeyRepository{}, &MockSessionRepository{}, "jwt-secret")
 
 	err := authService.Register(context.Background(), dto.RegisterRequest{
 		Email:    "newuser@example.com",
@@ -83,7 +88,7 @@ func TestLogin_InvalidCredentials(t *testing.T) {
 			return nil, nil
 		},
 	}
-	authService := NewAuthService(mockRepo, "jwt-secret")
+	authService := NewAuthService(mockRepo, &MockAPIKeyRepository{}, &MockSessionRepository{}, "jwt-secret")
 
 	_, err := authService.Login(context.Background(), dto.LoginRequest{
 		Email:    "wrong@example.com",
@@ -106,10 +111,16 @@ func TestLogin_Success(t *testing.T) {
 				Email:    "user@example.com",
 				Password: string(hashedPassword),
 				UserID:   uuid.New(),
+				Status:   models.AccountStatusActive,
 			}, nil
 		},
 	}
-	authService := NewAuthService(mockRepo, "jwt-secret")
+	mockSessionRepo := &MockSessionRepository{
+		CreateSessionFunc: func(ctx context.Context, session *models.Session) error {
+			return nil
+		},
+	}
+	authService := NewAuthService(mockRepo, &MockAPIKeyRepository{}, mockSessionRepo, "jwt-secret")
 
 	resp, err := authService.Login(context.Background(), dto.LoginRequest{
 		Email:    "user@example.com",
@@ -128,7 +139,7 @@ func TestLogin_Success(t *testing.T) {
 // Test Refresh Token: Token tidak valid
 func TestRefreshToken_InvalidToken(t *testing.T) {
 	mockRepo := &MockAuthRepository{}
-	authService := NewAuthService(mockRepo, "jwt-secret")
+	authService := NewAuthService(mockRepo, &MockAPIKeyRepository{}, &MockSessionRepository{}, "jwt-secret")
 
 	_, err := authService.RefreshToken(context.Background(), dto.RefreshTokenRequest{
 		RefreshToken: "invalid-token",
@@ -141,18 +152,14 @@ func TestRefreshToken_InvalidToken(t *testing.T) {
 
 // Test Refresh Token: Berhasil refresh token
 func TestRefreshToken_Success(t *testing.T) {
-	userID := uuid.New()
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": userID.String(),
-		"exp":     time.Now().Add(RefreshTokenExpiry).Unix(),
-	})
-	refreshToken, _ := token.SignedString([]byte("jwt-secret"))
-
 	mockRepo := &MockAuthRepository{}
-	authService := NewAuthService(mockRepo, "jwt-secret")
+	mockSessionRepo, _ := newStoringSessionRepository()
+	authService := NewAuthService(mockRepo, &MockAPIKeyRepository{}, mockSessionRepo, "jwt-secret")
+
+	tokens, _ := authService.IssueTokens(context.Background(), uuid.New(), dto.SessionDevice{})
 
 	resp, err := authService.RefreshToken(context.Background(), dto.RefreshTokenRequest{
-		RefreshToken: refreshToken,
+		RefreshToken: tokens.RefreshToken,
 	})
 
 	if err != nil {
@@ -163,3 +170,237 @@ func TestRefreshToken_Success(t *testing.T) {
 		t.Error("expected a new access token")
 	}
 }
+
+// Test Refresh Token: Token lama tanpa sesi ditolak
+func TestRefreshToken_WithoutSession(t *testing.T) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": uuid.New().String(),
+		"exp":     time.Now().Add(RefreshTokenExpiry).Unix(),
+	})
+	refreshToken, _ := token.SignedString([]byte("jwt-secret"))
+
+	authService := NewAuthService(&MockAuthRepository{}, &MockAPIKeyRepository{}, &MockSessionRepository{}, "jwt-secret")
+
+	if _, err := authService.RefreshToken(context.Background(), dto.RefreshTokenRequest{RefreshToken: refreshToken}); err != auth.ErrInvalidToken {
+		t.Errorf("expected ErrInvalidToken, got %v", err)
+	}
+	if _, err := authService.ValidateToken(context.Background(), refreshToken); err != auth.ErrInvalidToken {
+		t.Errorf("expected the token to be rejected as bearer too, got %v", err)
+	}
+}
+
+// Test IntrospectToken: JWT membawa role pengguna sebagai permission
+func TestIntrospectToken_JWT(t *testing.T) {
+	mockRepo := &MockAuthRepository{
+		GetUserByIDFunc: func(ctx context.Context, userID uuid.UUID) (*models.User, error) {
+			return &models.User{UserID: userID, Role: "librarian", Status: models.AccountStatusActive}, nil
+		},
+	}
+	mockSessionRepo, _ := newStoringSessionRepository()
+	authService := NewAuthService(mockRepo, &MockAPIKeyRepository{}, mockSessionRepo, "jwt-secret")
+
+	userID := uuid.New()
+	tokens, _ := authService.IssueTokens(context.Background(), userID, dto.SessionDevice{})
+
+	info, err := authService.IntrospectToken(context.Background(), tokens.AccessToken)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if info.UserID != userID || info.Role != "librarian" || info.TokenType != models.TokenTypeJWT {
+		t.Errorf("unexpected introspection %+v", info)
+	}
+	if len(info.Permissions) != 1 || info.Permissions[0] != "librarian" {
+		t.Errorf("expected permissions [librarian], got %v", info.Permissions)
+	}
+	if info.SessionID == uuid.Nil || info.ExpiresAt.Before(time.Now()) {
+		t.Errorf("expected session ID and future expiry, got %+v", info)
+	}
+	if info.AccountStatus != models.AccountStatusActive {
+		t.Errorf("expected active account, got %q", info.AccountStatus)
+	}
+}
+
+// Test Authenticate: Kredensial diperiksa tanpa membuat sesi
+func TestAuthenticate_NoSession(t *testing.T) {
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+	userID := uuid.New()
+	mockRepo := &MockAuthRepository{
+		GetUserByEmailFunc: func(ctx context.Context, email string) (*models.User, error) {
+			return &models.User{UserID: userID, Password: string(hashedPassword), Status: models.AccountStatusActive}, nil
+		},
+	}
+	// CreateSessionFunc nil: membuat sesi akan panic
+	authService := NewAuthService(mockRepo, &MockAPIKeyRepository{}, &MockSessionRepository{}, "jwt-secret")
+
+	got, err := authService.Authenticate(context.Background(), dto.LoginRequest{Email: "user@example.com", Password: "password123"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got != userID {
+		t.Errorf("expected user %s, got %s", userID, got)
+	}
+
+	if _, err := authService.Authenticate(context.Background(), dto.LoginRequest{Email: "user@example.com", Password: "wrong"}); err != ErrInvalidCredentials {
+		t.Errorf("expected ErrInvalidCredentials, got %v", err)
+	}
+}
+
+// Test ValidateToken: Token client OAuth membawa client dan scope sesinya,
+// token login password tidak
+func TestValidateToken_ClientSession(t *testing.T) {
+	mockSessionRepo, _ := newStoringSessionRepository()
+	authService := NewAuthService(&MockAuthRepository{}, &MockAPIKeyRepository{}, mockSessionRepo, "jwt-secret")
+
+	clientTokens, _ := authService.IssueClientTokens(context.Background(), uuid.New(), "app", "openid email", dto.SessionDevice{})
+	info, err := authService.ValidateToken(context.Background(), clientTokens.AccessToken)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if info.ClientID != "app" || len(info.GrantedScopes) != 2 || info.GrantedScopes[1] != "email" || len(info.Scopes) != 0 {
+		t.Errorf("expected client app with scopes [openid email], got %+v", info)
+	}
+
+	loginTokens, _ := authService.IssueTokens(context.Background(), uuid.New(), dto.SessionDevice{})
+	info, err = authService.ValidateToken(context.Background(), loginTokens.AccessToken)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if info.ClientID != "" || len(info.GrantedScopes) != 0 {
+		t.Errorf("expected no client for a password login, got %+v", info)
+	}
+}
+
+// Test IntrospectToken: Status akun yang ditangguhkan dilaporkan
+func TestIntrospectToken_SuspendedAccount(t *testing.T) {
+	mockRepo := &MockAuthRepository{
+		GetUserByIDFunc: func(ctx context.Context, userID uuid.UUID) (*models.User, error) {
+			return &models.User{UserID: userID, Role: "user", Status: models.AccountStatusSuspended}, nil
+		},
+	}
+	mockSessionRepo, _ := newStoringSessionRepository()
+	authService := NewAuthService(mockRepo, &MockAPIKeyRepository{}, mockSessionRepo, "jwt-secret")
+
+	tokens, _ := authService.IssueTokens(context.Background(), uuid.New(), dto.SessionDevice{})
+
+	info, err := authService.IntrospectToken(context.Background(), tokens.AccessToken)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if info.AccountStatus != models.AccountStatusSuspended {
+		t.Errorf("expected suspended account, got %q", info.AccountStatus)
+	}
+}
+
+// Test Login: Akun yang dikunci tidak bisa login
+func TestLogin_LockedAccount(t *testing.T) {
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+
+	mockRepo := &MockAuthRepository{
+		GetUserByEmailFunc: func(ctx context.Context, email string) (*models.User, error) {
+			return &models.User{Email: email, Password: string(hashedPassword), UserID: uuid.New(), Status: models.AccountStatusLocked}, nil
+		},
+	}
+	authService := NewAuthService(mockRepo, &MockAPIKeyRepository{}, &MockSessionRepository{}, "jwt-secret")
+
+	_, err := authService.Login(context.Background(), dto.LoginRequest{Email: "user@example.com", Password: "password123"})
+
+	if err != ErrAccountNotActive {
+		t.Errorf("expected ErrAccountNotActive, got %v", err)
+	}
+}
+
+// Test IntrospectToken: Scope API key dibatasi role pengguna saat ini
+func TestIntrospectToken_APIKeyScopesLimitedByRole(t *testing.T) {
+	key, prefix, hash, _ := auth.GenerateAPIKey()
+	mockKeyRepo := &MockAPIKeyRepository{
+		GetAPIKeyByPrefixFunc: func(ctx context.Context, p string) (*models.APIKey, error) {
+			return &models.APIKey{Prefix: prefix, KeyHash: hash, Scopes: []string{"user", "librarian"}, ExpiresAt: time.Now().Add(time.Hour)}, nil
+		},
+		TouchAPIKeyFunc: func(ctx context.Context, keyID uuid.UUID) error {
+			return nil
+		},
+	}
+	// Pengguna sudah diturunkan dari librarian menjadi user
+	mockRepo := &MockAuthRepository{
+		GetUserByIDFunc: func(ctx context.Context, userID uuid.UUID) (*models.User, error) {
+			return &models.User{UserID: userID, Role: "user"}, nil
+		},
+	}
+	authService := NewAuthService(mockRepo, mockKeyRepo, &MockSessionRepository{}, "jwt-secret")
+
+	info, err := authService.IntrospectToken(context.Background(), key)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(info.Permissions) != 1 || info.Permissions[0] != "user" {
+		t.Errorf("expected permissions [user], got %v", info.Permissions)
+	}
+}
+
+// Test IntrospectToken: Pengguna sudah dihapus
+func TestIntrospectToken_UserDeleted(t *testing.T) {
+	mockRepo := &MockAuthRepository{
+		GetUserByIDFunc: func(ctx context.Context, userID uuid.UUID) (*models.User, error) {
+			return nil, nil
+		},
+	}
+	mockSessionRepo, _ := newStoringSessionRepository()
+	authService := NewAuthService(mockRepo, &MockAPIKeyRepository{}, mockSessionRepo, "jwt-secret")
+
+	tokens, _ := authService.IssueTokens(context.Background(), uuid.New(), dto.SessionDevice{})
+
+	_, err := authService.IntrospectToken(context.Background(), tokens.AccessToken)
+
+	if err != auth.ErrInvalidToken {
+		t.Errorf("expected ErrInvalidToken, got %v", err)
+	}
+}
+
+// Test GetUsersByIDs: Terlalu banyak ID sekaligus
+func TestGetUsersByIDs_TooMany(t *testing.T) {
+	mockRepo := &MockAuthRepository{
+		GetUsersByIDsFunc: func(ctx context.Context, userIDs []uuid.UUID) ([]models.User, error) {
+			t.Fatal("repository should not be called")
+			return nil, nil
+		},
+	}
+	authService := NewAuthService(mockRepo, &MockAPIKeyRepository{}, &MockSessionRepository{}, "jwt-secret")
+
+	ids := make([]uuid.UUID, MaxUserLookup+1)
+	for i := range ids {
+		ids[i] = uuid.New()
+	}
+
+	_, err := authService.GetUsersByIDs(context.Background(), ids)
+
+	if err != ErrTooManyUsers {
+		t.Errorf("expected ErrTooManyUsers, got %v", err)
+	}
+}
+
+// Test GetUsersByIDs: Berhasil dalam satu query
+func TestGetUsersByIDs_Success(t *testing.T) {
+	ids := []uuid.UUID{uuid.New(), uuid.New()}
+	calls := 0
+	mockRepo := &MockAuthRepository{
+		GetUsersByIDsFunc: func(ctx context.Context, userIDs []uuid.UUID) ([]models.User, error) {
+			calls++
+			return []models.User{{UserID: userIDs[0], Name: "Test User"}}, nil
+		},
+	}
+	authService := NewAuthService(mockRepo, &MockAPIKeyRepository{}, &MockSessionRepository{}, "jwt-secret")
+
+	users, err := authService.GetUsersByIDs(context.Background(), ids)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if calls != 1 {
+		t.Errorf("expected one repository call, got %d", calls)
+	}
+	if len(users) != 1 || users[0].UserID != ids[0] {
+		t.Errorf("expected the one known user, got %v", users)
+	}
+}
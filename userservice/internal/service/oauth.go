@@ -0,0 +1,424 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/internal/dto"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/internal/models"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/pkg/auth"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/pkg/oidc"
+)
+
+const (
+	AuthorizationCodeExpiry = time.Minute * 5
+	SignInExpiry            = time.Minute * 10 // how long the authorization page keeps a user signed in
+)
+
+// OAuth errors map to the error codes of RFC 6749.
+var (
+	ErrInvalidClient        = errors.New("invalid_client")
+	ErrInvalidRedirectURI   = errors.New("invalid redirect_uri")
+	ErrInvalidOAuthRequest  = errors.New("invalid_request")
+	ErrInvalidGrant         = errors.New("invalid_grant")
+	ErrUnsupportedGrantType = errors.New("unsupported_grant_type")
+	ErrInsufficientScope    = errors.New("insufficient_scope")
+)
+
+type OAuthRepository interface {
+	CreateClient(ctx context.Context, client *models.OAuthClient) error
+	GetClientByClientID(ctx context.Context, clientID string) (*models.OAuthClient, error)
+	ListClients(ctx context.Context) ([]models.OAuthClient, error)
+	CreateAuthorizationCode(ctx context.Context, code *models.AuthorizationCode) error
+	ConsumeAuthorizationCode(ctx context.Context, codeHash, clientID, redirectURI string) (*models.AuthorizationCode, error)
+}
+
+// OAuthTokenIssuer issues the same access and refresh tokens as a password
+// login, with the session bound to the client and its granted scopes, and
+// checks the credentials entered on the authorization page.
+type OAuthTokenIssuer interface {
+	Authenticate(ctx context.Context, req dto.LoginRequest) (uuid.UUID, error)
+	ValidateToken(ctx context.Context, tokenStr string) (*models.TokenInfo, error)
+	IssueClientTokens(ctx context.Context, userID uuid.UUID, clientID, scope string, device dto.SessionDevice) (dto.LoginResponse, error)
+	RefreshClientToken(ctx context.Context, req dto.RefreshTokenRequest, clientID string) (dto.RefreshTokenResponse, error)
+}
+
+type oauthService struct {
+	repo     OAuthRepository
+	userRepo APIKeyUserRepository
+	tokens   OAuthTokenIssuer
+	signer   *oidc.Signer
+	issuer   string
+}
+
+func NewOAuthService(repo OAuthRepository, userRepo APIKeyUserRepository, tokens OAuthTokenIssuer, signer *oidc.Signer, issuer string) *oauthService {
+	return &oauthService{
+		repo:     repo,
+		userRepo: userRepo,
+		tokens:   tokens,
+		signer:   signer,
+		issuer:   strings.TrimSuffix(issuer, "/"),
+	}
+}
+
+// RegisterClient registers an application. Confidential clients get a secret
+// which is only returned here.
+func (s *oauthService) RegisterClient(ctx context.Context, req dto.RegisterClientRequest, createdBy uuid.UUID) (dto.RegisterClientResponse, error) {
+	client := models.OAuthClient{
+		ClientID:     uuid.NewString(),
+		Name:         req.Name,
+		RedirectURIs: req.RedirectURIs,
+		CreatedBy:    createdBy,
+	}
+
+	var secret string
+	if !req.Public {
+		var err error
+		secret, client.ClientSecretHash, err = oidc.GenerateSecret()
+		if err != nil {
+			log.Printf("[Service - RegisterClient] Error generating secret: %v", err)
+			return dto.RegisterClientResponse{}, err
+		}
+	}
+
+	if err := s.repo.CreateClient(ctx, &client); err != nil {
+		return dto.RegisterClientResponse{}, fmt.Errorf("service: failed to register client: %w", err)
+	}
+
+	return dto.RegisterClientResponse{
+		ClientID:     client.ClientID,
+		ClientSecret: secret,
+		Name:         client.Name,
+		RedirectURIs: client.RedirectURIs,
+	}, nil
+}
+
+// ListClients returns the registered clients without their secrets.
+func (s *oauthService) ListClients(ctx context.Context) ([]dto.GetOAuthClient, error) {
+	clients, err := s.repo.ListClients(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]dto.GetOAuthClient, 0, len(clients))
+	for _, client := range clients {
+		res = append(res, dto.GetOAuthClient{
+			ClientID:     client.ClientID,
+			Name:         client.Name,
+			RedirectURIs: client.RedirectURIs,
+			Public:       client.ClientSecretHash == "",
+			CreatedAt:    client.CreatedAt,
+		})
+	}
+
+	return res, nil
+}
+
+// CheckAuthorize validates an authorization request and returns the client
+// to ask the user's consent for. ErrInvalidClient and ErrInvalidRedirectURI
+// mean the request must not be redirected back; ErrInvalidOAuthRequest is
+// reported to the redirect URI. PKCE with S256 is required for every client.
+func (s *oauthService) CheckAuthorize(ctx context.Context, req dto.AuthorizeRequest) (dto.GetOAuthClient, error) {
+	client, err := s.repo.GetClientByClientID(ctx, req.ClientID)
+	if err != nil {
+		return dto.GetOAuthClient{}, err
+	}
+	if client == nil {
+		return dto.GetOAuthClient{}, ErrInvalidClient
+	}
+	if !containsString(client.RedirectURIs, req.RedirectURI) {
+		return dto.GetOAuthClient{}, ErrInvalidRedirectURI
+	}
+
+	if req.ResponseType != "code" {
+		return dto.GetOAuthClient{}, fmt.Errorf("%w: response_type must be code", ErrInvalidOAuthRequest)
+	}
+	if req.CodeChallenge == "" || req.CodeChallengeMethod != "S256" {
+		return dto.GetOAuthClient{}, fmt.Errorf("%w: PKCE with S256 is required", ErrInvalidOAuthRequest)
+	}
+
+	return dto.GetOAuthClient{
+		ClientID:     client.ClientID,
+		Name:         client.Name,
+		RedirectURIs: client.RedirectURIs,
+		Public:       client.ClientSecretHash == "",
+		CreatedAt:    client.CreatedAt,
+	}, nil
+}
+
+// SignIn checks the user's credentials on the authorization page and returns
+// a short lived token, kept in a cookie while the user consents. No session
+// is started until the client exchanges its authorization code.
+func (s *oauthService) SignIn(ctx context.Context, req dto.LoginRequest) (string, error) {
+	userID, err := s.tokens.Authenticate(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	return s.signer.Sign(jwt.MapClaims{
+		"iss": s.issuer,
+		"sub": userID.String(),
+		"aud": s.signInAudience(),
+		"iat": now.Unix(),
+		"exp": now.Add(SignInExpiry).Unix(),
+	})
+}
+
+// SignedInUser returns the user behind the authorization page cookie, as
+// long as their account is still active.
+func (s *oauthService) SignedInUser(ctx context.Context, token string) (uuid.UUID, error) {
+	claims, err := s.signer.Verify(token)
+	if err != nil {
+		return uuid.Nil, auth.ErrInvalidToken
+	}
+	// ID tokens are signed with the same key but issued to clients
+	if !claims.VerifyAudience(s.signInAudience(), true) || !claims.VerifyIssuer(s.issuer, true) {
+		return uuid.Nil, auth.ErrInvalidToken
+	}
+	sub, _ := claims["sub"].(string)
+	userID, err := uuid.Parse(sub)
+	if err != nil {
+		return uuid.Nil, auth.ErrInvalidToken
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if user == nil || user.Status != models.AccountStatusActive {
+		return uuid.Nil, auth.ErrInvalidToken
+	}
+	return user.UserID, nil
+}
+
+func (s *oauthService) signInAudience() string {
+	return s.issuer + "/oauth/authorize"
+}
+
+// Authorize issues an authorization code for the signed in user once they
+// consented to the request.
+func (s *oauthService) Authorize(ctx context.Context, userID uuid.UUID, req dto.AuthorizeRequest) (string, error) {
+	if _, err := s.CheckAuthorize(ctx, req); err != nil {
+		return "", err
+	}
+
+	code, codeHash, err := oidc.GenerateSecret()
+	if err != nil {
+		log.Printf("[Service - Authorize] Error generating code: %v", err)
+		return "", err
+	}
+
+	authCode := models.AuthorizationCode{
+		CodeHash:      codeHash,
+		ClientID:      req.ClientID,
+		UserID:        userID,
+		RedirectURI:   req.RedirectURI,
+		Scope:         req.Scope,
+		Nonce:         req.Nonce,
+		CodeChallenge: req.CodeChallenge,
+		ExpiresAt:     time.Now().Add(AuthorizationCodeExpiry),
+	}
+
+	if err := s.repo.CreateAuthorizationCode(ctx, &authCode); err != nil {
+		return "", fmt.Errorf("service: failed to store authorization code: %w", err)
+	}
+
+	return code, nil
+}
+
+// Exchange implements the token endpoint for the authorization_code and
+// refresh_token grants.
+func (s *oauthService) Exchange(ctx context.Context, req dto.TokenRequest) (dto.TokenResponse, error) {
+	switch req.GrantType {
+	case "authorization_code":
+		return s.exchangeCode(ctx, req)
+	case "refresh_token":
+		client, err := s.authenticateClient(ctx, req.ClientID, req.ClientSecret)
+		if err != nil {
+			return dto.TokenResponse{}, err
+		}
+		res, err := s.tokens.RefreshClientToken(ctx, dto.RefreshTokenRequest{RefreshToken: req.RefreshToken}, client.ClientID)
+		if err != nil {
+			return dto.TokenResponse{}, ErrInvalidGrant
+		}
+		return dto.TokenResponse{
+			AccessToken: res.AccessToken,
+			TokenType:   "Bearer",
+			ExpiresIn:   int(AccessTokenExpiry.Seconds()),
+		}, nil
+	default:
+		return dto.TokenResponse{}, ErrUnsupportedGrantType
+	}
+}
+
+func (s *oauthService) exchangeCode(ctx context.Context, req dto.TokenRequest) (dto.TokenResponse, error) {
+	client, err := s.authenticateClient(ctx, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return dto.TokenResponse{}, err
+	}
+
+	// Only the client the code was issued to, with the same redirect URI, can
+	// use it up; anyone else leaves it to its client
+	code, err := s.repo.ConsumeAuthorizationCode(ctx, oidc.HashSecret(req.Code), client.ClientID, req.RedirectURI)
+	if err != nil {
+		return dto.TokenResponse{}, err
+	}
+	if code == nil || time.Now().After(code.ExpiresAt) {
+		return dto.TokenResponse{}, ErrInvalidGrant
+	}
+	if !oidc.VerifyPKCE(req.CodeVerifier, code.CodeChallenge) {
+		return dto.TokenResponse{}, fmt.Errorf("%w: code_verifier does not match", ErrInvalidGrant)
+	}
+
+	tokens, err := s.tokens.IssueClientTokens(ctx, code.UserID, client.ClientID, code.Scope, req.Device)
+	if err != nil {
+		return dto.TokenResponse{}, err
+	}
+
+	res := dto.TokenResponse{
+		AccessToken:  tokens.AccessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(AccessTokenExpiry.Seconds()),
+		RefreshToken: tokens.RefreshToken,
+		Scope:        code.Scope,
+	}
+
+	if containsString(strings.Fields(code.Scope), "openid") {
+		res.IDToken, err = s.idToken(ctx, code)
+		if err != nil {
+			return dto.TokenResponse{}, err
+		}
+	}
+
+	return res, nil
+}
+
+// authenticateClient checks the client secret of confidential clients.
+func (s *oauthService) authenticateClient(ctx context.Context, clientID, clientSecret string) (*models.OAuthClient, error) {
+	client, err := s.repo.GetClientByClientID(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		return nil, ErrInvalidClient
+	}
+	if client.ClientSecretHash != "" && !oidc.CompareSecret(clientSecret, client.ClientSecretHash) {
+		return nil, ErrInvalidClient
+	}
+	return client, nil
+}
+
+func (s *oauthService) idToken(ctx context.Context, code *models.AuthorizationCode) (string, error) {
+	user, err := s.userRepo.GetUserByID(ctx, code.UserID)
+	if err != nil {
+		return "", err
+	}
+	if user == nil {
+		return "", ErrInvalidGrant
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss": s.issuer,
+		"sub": user.UserID.String(),
+		"aud": code.ClientID,
+		"iat": now.Unix(),
+		"exp": now.Add(AccessTokenExpiry).Unix(),
+	}
+	info := userClaims(user, strings.Fields(code.Scope))
+	if info.Name != "" {
+		claims["name"] = info.Name
+	}
+	if info.Email != "" {
+		claims["email"] = info.Email
+	}
+	if code.Nonce != "" {
+		claims["nonce"] = code.Nonce
+	}
+
+	return s.signer.Sign(claims)
+}
+
+// UserInfo returns the OpenID claims of the user behind an access token
+// issued to an OAuth client, limited to the scopes the user granted it.
+// Password login tokens and API keys are refused.
+func (s *oauthService) UserInfo(ctx context.Context, token string) (dto.UserInfoResponse, error) {
+	info, err := s.tokens.ValidateToken(ctx, token)
+	if err != nil {
+		log.Printf("[Service - UserInfo] Error validating token: %v", err)
+		return dto.UserInfoResponse{}, auth.ErrInvalidToken
+	}
+	if info.TokenType != models.TokenTypeJWT || info.ClientID == "" {
+		return dto.UserInfoResponse{}, auth.ErrInvalidToken
+	}
+	if !containsString(info.GrantedScopes, "openid") {
+		return dto.UserInfoResponse{}, ErrInsufficientScope
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, info.UserID)
+	if err != nil {
+		return dto.UserInfoResponse{}, err
+	}
+	if user == nil {
+		return dto.UserInfoResponse{}, ErrUserNotFound
+	}
+	if user.Status != models.AccountStatusActive {
+		return dto.UserInfoResponse{}, ErrAccountNotActive
+	}
+
+	return userClaims(user, info.GrantedScopes), nil
+}
+
+// userClaims returns the claims the scopes allow: profile adds the name and
+// role, email the email address. sub is always included.
+func userClaims(user *models.User, scopes []string) dto.UserInfoResponse {
+	res := dto.UserInfoResponse{Sub: user.UserID.String()}
+	if containsString(scopes, "profile") {
+		res.Name = user.Name
+		res.Role = user.Role
+	}
+	if containsString(scopes, "email") {
+		res.Email = user.Email
+	}
+	return res
+}
+
+// Discovery returns the OpenID provider metadata.
+func (s *oauthService) Discovery() dto.DiscoveryDocument {
+	return dto.DiscoveryDocument{
+		Issuer:                            s.issuer,
+		AuthorizationEndpoint:             s.issuer + "/oauth/authorize",
+		TokenEndpoint:                     s.issuer + "/oauth/token",
+		UserinfoEndpoint:                  s.issuer + "/oauth/userinfo",
+		JwksURI:                           s.issuer + "/.well-known/jwks.json",
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{"authorization_code", "refresh_token"},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{"RS256"},
+		ScopesSupported:                   []string{"openid", "profile", "email"},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{"S256"},
+		ClaimsSupported:                   []string{"sub", "name", "email", "role"},
+	}
+}
+
+// JWKS returns the keys used to sign ID tokens.
+func (s *oauthService) JWKS() map[string]interface{} {
+	return s.signer.JWKS()
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,434 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+
+	"github.com/sir-shalahuddin/grpc-learn/userservice/internal/dto"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/internal/models"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/pkg/auth"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/pkg/oidc"
+)
+
+// MockOAuthRepository menyimpan client dan authorization code di memori.
+type MockOAuthRepository struct {
+	clients map[string]*models.OAuthClient
+	codes   map[string]*models.AuthorizationCode
+}
+
+func newMockOAuthRepository(clients ...*models.OAuthClient) *MockOAuthRepository {
+	m := &MockOAuthRepository{
+		clients: map[string]*models.OAuthClient{},
+		codes:   map[string]*models.AuthorizationCode{},
+	}
+	for _, c := range clients {
+		m.clients[c.ClientID] = c
+	}
+	return m
+}
+
+func (m *MockOAuthRepository) CreateClient(ctx context.Context, client *models.OAuthClient) error {
+	m.clients[client.ClientID] = client
+	return nil
+}
+
+func (m *MockOAuthRepository) GetClientByClientID(ctx context.Context, clientID string) (*models.OAuthClient, error) {
+	return m.clients[clientID], nil
+}
+
+func (m *MockOAuthRepository) ListClients(ctx context.Context) ([]models.OAuthClient, error) {
+	var res []models.OAuthClient
+	for _, c := range m.clients {
+		res = append(res, *c)
+	}
+	return res, nil
+}
+
+func (m *MockOAuthRepository) CreateAuthorizationCode(ctx context.Context, code *models.AuthorizationCode) error {
+	m.codes[code.CodeHash] = code
+	return nil
+}
+
+func (m *MockOAuthRepository) ConsumeAuthorizationCode(ctx context.Context, codeHash, clientID, redirectURI string) (*models.AuthorizationCode, error) {
+	code, ok := m.codes[codeHash]
+	if !ok || code.ClientID != clientID || code.RedirectURI != redirectURI {
+		return nil, nil
+	}
+	delete(m.codes, codeHash)
+	return code, nil
+}
+
+// MockTokenIssuer mengembalikan token statis, mencatat sesi yang dibuat
+// beserta scope-nya, dan mengenali token dari tokens.
+type MockTokenIssuer struct {
+	refreshClientID string
+	sessions        int
+	issuedScope     string
+	tokens          map[string]*models.TokenInfo
+}
+
+func (m *MockTokenIssuer) Authenticate(ctx context.Context, req dto.LoginRequest) (uuid.UUID, error) {
+	if req.Password != "password123" {
+		return uuid.Nil, ErrInvalidCredentials
+	}
+	return signedInUserID, nil
+}
+
+func (m *MockTokenIssuer) ValidateToken(ctx context.Context, tokenStr string) (*models.TokenInfo, error) {
+	info, ok := m.tokens[tokenStr]
+	if !ok {
+		return nil, auth.ErrInvalidToken
+	}
+	return info, nil
+}
+
+func (m *MockTokenIssuer) IssueClientTokens(ctx context.Context, userID uuid.UUID, clientID, scope string, device dto.SessionDevice) (dto.LoginResponse, error) {
+	m.sessions++
+	m.issuedScope = scope
+	return dto.LoginResponse{AccessToken: "access", RefreshToken: "refresh"}, nil
+}
+
+func (m *MockTokenIssuer) RefreshClientToken(ctx context.Context, req dto.RefreshTokenRequest, clientID string) (dto.RefreshTokenResponse, error) {
+	m.refreshClientID = clientID
+	return dto.RefreshTokenResponse{AccessToken: "access"}, nil
+}
+
+var signedInUserID = uuid.New()
+
+func newTestOAuthService(t *testing.T, repo *MockOAuthRepository) *oauthService {
+	return newTestOAuthServiceWithIssuer(t, repo, &MockTokenIssuer{})
+}
+
+func newTestOAuthServiceWithIssuer(t *testing.T, repo *MockOAuthRepository, issuer *MockTokenIssuer) *oauthService {
+	signer, err := oidc.NewSigner("")
+	if err != nil {
+		t.Fatalf("failed to create signer: %v", err)
+	}
+	userRepo := &MockUserRepository{
+		GetUserByIDFunc: func(ctx context.Context, userID uuid.UUID) (*models.User, error) {
+			return &models.User{UserID: userID, Name: "Test User", Email: "test@example.com", Role: "user", Status: models.AccountStatusActive}, nil
+		},
+	}
+	return NewOAuthService(repo, userRepo, issuer, signer, "http://localhost:3000")
+}
+
+func pkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// Test Authorize: PKCE wajib
+func TestAuthorize_RequiresPKCE(t *testing.T) {
+	client := &models.OAuthClient{ClientID: "app", RedirectURIs: []string{"https://app.example.com/cb"}}
+	oauthService := newTestOAuthService(t, newMockOAuthRepository(client))
+
+	_, err := oauthService.Authorize(context.Background(), uuid.New(), dto.AuthorizeRequest{
+		ResponseType: "code",
+		ClientID:     "app",
+		RedirectURI:  "https://app.example.com/cb",
+	})
+
+	if !errors.Is(err, ErrInvalidOAuthRequest) {
+		t.Errorf("expected ErrInvalidOAuthRequest, got %v", err)
+	}
+}
+
+// Test Authorize: Redirect URI tidak terdaftar
+func TestAuthorize_UnregisteredRedirect(t *testing.T) {
+	client := &models.OAuthClient{ClientID: "app", RedirectURIs: []string{"https://app.example.com/cb"}}
+	oauthService := newTestOAuthService(t, newMockOAuthRepository(client))
+
+	_, err := oauthService.Authorize(context.Background(), uuid.New(), dto.AuthorizeRequest{
+		ResponseType:        "code",
+		ClientID:            "app",
+		RedirectURI:         "https://evil.example.com/cb",
+		CodeChallenge:       pkceChallenge("verifier"),
+		CodeChallengeMethod: "S256",
+	})
+
+	if !errors.Is(err, ErrInvalidRedirectURI) {
+		t.Errorf("expected ErrInvalidRedirectURI, got %v", err)
+	}
+}
+
+// Test Exchange: Authorization code berhasil ditukar dan hanya sekali, dengan
+// sesi dan ID token yang dibatasi scope yang disetujui
+func TestExchange_AuthorizationCodeSuccess(t *testing.T) {
+	client := &models.OAuthClient{ClientID: "app", RedirectURIs: []string{"https://app.example.com/cb"}}
+	issuer := &MockTokenIssuer{}
+	oauthService := newTestOAuthServiceWithIssuer(t, newMockOAuthRepository(client), issuer)
+	verifier := "a-long-random-code-verifier-value"
+
+	code, err := oauthService.Authorize(context.Background(), uuid.New(), dto.AuthorizeRequest{
+		ResponseType:        "code",
+		ClientID:            "app",
+		RedirectURI:         "https://app.example.com/cb",
+		Scope:               "openid profile",
+		Nonce:               "n-0S6",
+		CodeChallenge:       pkceChallenge(verifier),
+		CodeChallengeMethod: "S256",
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	req := dto.TokenRequest{
+		GrantType:    "authorization_code",
+		Code:         code,
+		RedirectURI:  "https://app.example.com/cb",
+		ClientID:     "app",
+		CodeVerifier: verifier,
+	}
+	resp, err := oauthService.Exchange(context.Background(), req)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if resp.AccessToken == "" || resp.IDToken == "" {
+		t.Error("expected access token and ID token")
+	}
+	if issuer.issuedScope != "openid profile" {
+		t.Errorf("expected the session to keep scope %q, got %q", "openid profile", issuer.issuedScope)
+	}
+	claims, err := oauthService.signer.Verify(resp.IDToken)
+	if err != nil {
+		t.Fatalf("expected a valid ID token, got %v", err)
+	}
+	if _, ok := claims["email"]; ok || claims["name"] != "Test User" {
+		t.Errorf("expected name without email in the ID token, got %v", claims)
+	}
+
+	if _, err := oauthService.Exchange(context.Background(), req); !errors.Is(err, ErrInvalidGrant) {
+		t.Errorf("expected ErrInvalidGrant on reuse, got %v", err)
+	}
+}
+
+// Test Exchange: Code verifier salah
+func TestExchange_WrongVerifier(t *testing.T) {
+	client := &models.OAuthClient{ClientID: "app", RedirectURIs: []string{"https://app.example.com/cb"}}
+	oauthService := newTestOAuthService(t, newMockOAuthRepository(client))
+
+	code, _ := oauthService.Authorize(context.Background(), uuid.New(), dto.AuthorizeRequest{
+		ResponseType:        "code",
+		ClientID:            "app",
+		RedirectURI:         "https://app.example.com/cb",
+		CodeChallenge:       pkceChallenge("right-verifier"),
+		CodeChallengeMethod: "S256",
+	})
+
+	_, err := oauthService.Exchange(context.Background(), dto.TokenRequest{
+		GrantType:    "authorization_code",
+		Code:         code,
+		RedirectURI:  "https://app.example.com/cb",
+		ClientID:     "app",
+		CodeVerifier: "wrong-verifier",
+	})
+
+	if !errors.Is(err, ErrInvalidGrant) {
+		t.Errorf("expected ErrInvalidGrant, got %v", err)
+	}
+}
+
+// Test Exchange: Client secret salah
+func TestExchange_InvalidClientSecret(t *testing.T) {
+	_, hash, _ := oidc.GenerateSecret()
+	client := &models.OAuthClient{ClientID: "app", ClientSecretHash: hash, RedirectURIs: []string{"https://app.example.com/cb"}}
+	oauthService := newTestOAuthService(t, newMockOAuthRepository(client))
+
+	_, err := oauthService.Exchange(context.Background(), dto.TokenRequest{
+		GrantType:    "authorization_code",
+		Code:         "whatever",
+		ClientID:     "app",
+		ClientSecret: "wrong",
+	})
+
+	if !errors.Is(err, ErrInvalidClient) {
+		t.Errorf("expected ErrInvalidClient, got %v", err)
+	}
+}
+
+// Test Exchange: Client lain tidak menghabiskan authorization code
+func TestExchange_CodeOfAnotherClient(t *testing.T) {
+	client := &models.OAuthClient{ClientID: "app", RedirectURIs: []string{"https://app.example.com/cb"}}
+	other := &models.OAuthClient{ClientID: "other", RedirectURIs: []string{"https://app.example.com/cb"}}
+	oauthService := newTestOAuthService(t, newMockOAuthRepository(client, other))
+	verifier := "a-long-random-code-verifier-value"
+
+	code, err := oauthService.Authorize(context.Background(), uuid.New(), dto.AuthorizeRequest{
+		ResponseType:        "code",
+		ClientID:            "app",
+		RedirectURI:         "https://app.example.com/cb",
+		CodeChallenge:       pkceChallenge(verifier),
+		CodeChallengeMethod: "S256",
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	req := dto.TokenRequest{
+		GrantType:    "authorization_code",
+		Code:         code,
+		RedirectURI:  "https://app.example.com/cb",
+		ClientID:     "other",
+		CodeVerifier: verifier,
+	}
+	if _, err := oauthService.Exchange(context.Background(), req); !errors.Is(err, ErrInvalidGrant) {
+		t.Fatalf("expected ErrInvalidGrant for another client, got %v", err)
+	}
+
+	req.ClientID = "app"
+	req.RedirectURI = "https://app.example.com/other"
+	if _, err := oauthService.Exchange(context.Background(), req); !errors.Is(err, ErrInvalidGrant) {
+		t.Fatalf("expected ErrInvalidGrant for another redirect URI, got %v", err)
+	}
+
+	req.RedirectURI = "https://app.example.com/cb"
+	if _, err := oauthService.Exchange(context.Background(), req); err != nil {
+		t.Errorf("expected the code to still be usable by its client, got %v", err)
+	}
+}
+
+// Test Exchange: Refresh token ditukar atas nama client yang terautentikasi
+func TestExchange_RefreshTokenBoundToClient(t *testing.T) {
+	client := &models.OAuthClient{ClientID: "app", RedirectURIs: []string{"https://app.example.com/cb"}}
+	signer, _ := oidc.NewSigner("")
+	issuer := &MockTokenIssuer{}
+	oauthService := NewOAuthService(newMockOAuthRepository(client), &MockUserRepository{}, issuer, signer, "http://localhost:3000")
+
+	_, err := oauthService.Exchange(context.Background(), dto.TokenRequest{
+		GrantType:    "refresh_token",
+		ClientID:     "app",
+		RefreshToken: "refresh",
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if issuer.refreshClientID != "app" {
+		t.Errorf("expected the refresh token to be checked against client app, got %q", issuer.refreshClientID)
+	}
+}
+
+// Test SignIn: Kredensial diperiksa tanpa membuat sesi, dan cookie yang
+// dikembalikan mengenali pengguna di halaman otorisasi
+func TestSignIn_WithoutSession(t *testing.T) {
+	issuer := &MockTokenIssuer{}
+	oauthService := newTestOAuthServiceWithIssuer(t, newMockOAuthRepository(), issuer)
+
+	if _, err := oauthService.SignIn(context.Background(), dto.LoginRequest{Email: "test@example.com", Password: "wrong"}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("expected ErrInvalidCredentials, got %v", err)
+	}
+
+	cookie, err := oauthService.SignIn(context.Background(), dto.LoginRequest{Email: "test@example.com", Password: "password123"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	userID, err := oauthService.SignedInUser(context.Background(), cookie)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if userID != signedInUserID {
+		t.Errorf("expected user %s, got %s", signedInUserID, userID)
+	}
+	if issuer.sessions != 0 {
+		t.Errorf("expected no session to be started, got %d", issuer.sessions)
+	}
+}
+
+// Test SignedInUser: Hanya cookie sign in yang masih berlaku dan milik akun
+// aktif yang diterima
+func TestSignedInUser(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		status string
+	}{
+		{"ID token of a client", jwt.MapClaims{"iss": "http://localhost:3000", "sub": signedInUserID.String(), "aud": "app", "exp": now.Add(time.Minute).Unix()}, models.AccountStatusActive},
+		{"expired", jwt.MapClaims{"iss": "http://localhost:3000", "sub": signedInUserID.String(), "aud": "http://localhost:3000/oauth/authorize", "exp": now.Add(-time.Minute).Unix()}, models.AccountStatusActive},
+		{"suspended account", jwt.MapClaims{"iss": "http://localhost:3000", "sub": signedInUserID.String(), "aud": "http://localhost:3000/oauth/authorize", "exp": now.Add(time.Minute).Unix()}, models.AccountStatusSuspended},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer, _ := oidc.NewSigner("")
+			userRepo := &MockUserRepository{
+				GetUserByIDFunc: func(ctx context.Context, userID uuid.UUID) (*models.User, error) {
+					return &models.User{UserID: userID, Status: tt.status}, nil
+				},
+			}
+			oauthService := NewOAuthService(newMockOAuthRepository(), userRepo, &MockTokenIssuer{}, signer, "http://localhost:3000")
+			cookie, _ := signer.Sign(tt.claims)
+
+			if _, err := oauthService.SignedInUser(context.Background(), cookie); !errors.Is(err, auth.ErrInvalidToken) {
+				t.Errorf("expected ErrInvalidToken, got %v", err)
+			}
+		})
+	}
+}
+
+// Test UserInfo: Klaim dibatasi scope yang disetujui pengguna
+func TestUserInfo_Scopes(t *testing.T) {
+	tests := []struct {
+		name   string
+		scopes []string
+		want   dto.UserInfoResponse
+	}{
+		{"openid", []string{"openid"}, dto.UserInfoResponse{Sub: signedInUserID.String()}},
+		{"profile", []string{"openid", "profile"}, dto.UserInfoResponse{Sub: signedInUserID.String(), Name: "Test User", Role: "user"}},
+		{"email", []string{"openid", "email"}, dto.UserInfoResponse{Sub: signedInUserID.String(), Email: "test@example.com"}},
+		{"profile and email", []string{"openid", "profile", "email"}, dto.UserInfoResponse{Sub: signedInUserID.String(), Name: "Test User", Email: "test@example.com", Role: "user"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issuer := &MockTokenIssuer{tokens: map[string]*models.TokenInfo{
+				"access": {UserID: signedInUserID, TokenType: models.TokenTypeJWT, ClientID: "app", GrantedScopes: tt.scopes},
+			}}
+			oauthService := newTestOAuthServiceWithIssuer(t, newMockOAuthRepository(), issuer)
+
+			res, err := oauthService.UserInfo(context.Background(), "access")
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if res != tt.want {
+				t.Errorf("expected %+v, got %+v", tt.want, res)
+			}
+		})
+	}
+}
+
+// Test UserInfo: Token login password, API key dan token tanpa scope openid
+// ditolak
+func TestUserInfo_OAuthTokensOnly(t *testing.T) {
+	issuer := &MockTokenIssuer{tokens: map[string]*models.TokenInfo{
+		"password-login": {UserID: signedInUserID, TokenType: models.TokenTypeJWT},
+		"api-key":        {UserID: signedInUserID, TokenType: models.TokenTypeAPIKey, Scopes: []string{"user"}},
+		"no-openid":      {UserID: signedInUserID, TokenType: models.TokenTypeJWT, ClientID: "app", GrantedScopes: []string{"profile"}},
+	}}
+	oauthService := newTestOAuthServiceWithIssuer(t, newMockOAuthRepository(), issuer)
+
+	tests := []struct {
+		token   string
+		wantErr error
+	}{
+		{"password-login", auth.ErrInvalidToken},
+		{"api-key", auth.ErrInvalidToken},
+		{"unknown", auth.ErrInvalidToken},
+		{"no-openid", ErrInsufficientScope},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.token, func(t *testing.T) {
+			if _, err := oauthService.UserInfo(context.Background(), tt.token); !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/internal/dto"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/internal/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	ExportExpiry  = time.Hour * 24 * 7 // exports can be downloaded for 7 days
+	ExportTimeout = time.Minute * 2    // time allowed to gather an export
+
+	exportUpdateTimeout = time.Second * 10 // time allowed to record how an export ended
+)
+
+var (
+	ErrExportNotFound = errors.New("data export not found")
+	ErrExportNotReady = errors.New("data export is not ready")
+	ErrActiveLoans    = errors.New("return all borrowed books before erasing the account")
+)
+
+type DataExportRepository interface {
+	CreateExport(ctx context.Context, export *models.DataExport) error
+	GetExport(ctx context.Context, userID, exportID uuid.UUID) (*models.DataExport, error)
+	CompleteExport(ctx context.Context, exportID uuid.UUID, archive []byte) error
+	FailExport(ctx context.Context, exportID uuid.UUID, reason string) error
+	FailStaleExports(ctx context.Context, before time.Time, reason string) (int64, error)
+}
+
+type PrivacyUserRepository interface {
+	GetUserByID(ctx context.Context, userID uuid.UUID) (*models.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	DeleteUser(ctx context.Context, userID uuid.UUID) error
+}
+
+type BorrowingRecordRepository interface {
+	ExportBorrowingRecords(ctx context.Context, userID uuid.UUID) ([]models.BorrowingRecord, error)
+	EraseBorrowingRecords(ctx context.Context, userID uuid.UUID) (int64, bool, error)
+}
+
+type privacyService struct {
+	repo        DataExportRepository
+	userRepo    PrivacyUserRepository
+	bookRepo    BorrowingRecordRepository
+	revocations RevocationPublisher
+	// exportTimeout is ExportTimeout, shortened in tests.
+	exportTimeout time.Duration
+}
+
+func NewPrivacyService(repo DataExportRepository, userRepo PrivacyUserRepository, bookRepo BorrowingRecordRepository, revocations RevocationPublisher) *privacyService {
+	return &privacyService{
+		repo:          repo,
+		userRepo:      userRepo,
+		bookRepo:      bookRepo,
+		revocations:   revocations,
+		exportTimeout: ExportTimeout,
+	}
+}
+
+// RequestExport starts a background job gathering the user's profile and
+// borrowing history into a JSON archive.
+func (s *privacyService) RequestExport(ctx context.Context, userID uuid.UUID) (dto.GetDataExport, error) {
+	export := models.DataExport{
+		UserID:    userID,
+		Status:    models.ExportStatusPending,
+		ExpiresAt: time.Now().Add(ExportExpiry),
+	}
+
+	if err := s.repo.CreateExport(ctx, &export); err != nil {
+		return dto.GetDataExport{}, fmt.Errorf("service: failed to create data export: %w", err)
+	}
+
+	go s.runExport(export.ID, userID)
+
+	return toDataExport(export), nil
+}
+
+// GetExport returns the status of one of the user's exports.
+func (s *privacyService) GetExport(ctx context.Context, userID, exportID uuid.UUID) (dto.GetDataExport, error) {
+	export, err := s.getExport(ctx, userID, exportID)
+	if err != nil {
+		return dto.GetDataExport{}, err
+	}
+
+	return toDataExport(*export), nil
+}
+
+// DownloadExport returns the archive of a completed export.
+func (s *privacyService) DownloadExport(ctx context.Context, userID, exportID uuid.UUID) ([]byte, error) {
+	export, err := s.getExport(ctx, userID, exportID)
+	if err != nil {
+		return nil, err
+	}
+	if export.Status != models.ExportStatusCompleted {
+		return nil, ErrExportNotReady
+	}
+
+	return export.Archive, nil
+}
+
+// EraseAccount anonymizes the user's borrowing records in bookservice and
+// then deletes the user. The password is asked again to confirm.
+func (s *privacyService) EraseAccount(ctx context.Context, userID uuid.UUID, req dto.EraseAccountRequest) (dto.EraseAccountResponse, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return dto.EraseAccountResponse{}, fmt.Errorf("service: failed to get user by ID: %w", err)
+	}
+	if user == nil {
+		return dto.EraseAccountResponse{}, ErrUserNotFound
+	}
+	if user.Role == "super admin" {
+		return dto.EraseAccountResponse{}, ErrInsufficientPermissions
+	}
+
+	credentials, err := s.userRepo.GetUserByEmail(ctx, user.Email)
+	if err != nil {
+		return dto.EraseAccountResponse{}, err
+	}
+	if credentials == nil || bcrypt.CompareHashAndPassword([]byte(credentials.Password), []byte(req.Password)) != nil {
+		return dto.EraseAccountResponse{}, ErrInvalidCredentials
+	}
+
+	// Anonymize first so a failed delete can simply be retried
+	anonymized, erased, err := s.bookRepo.EraseBorrowingRecords(ctx, userID)
+	if err != nil {
+		return dto.EraseAccountResponse{}, fmt.Errorf("service: failed to erase borrowing records: %w", err)
+	}
+	if !erased {
+		return dto.EraseAccountResponse{}, ErrActiveLoans
+	}
+
+	if err := s.userRepo.DeleteUser(ctx, userID); err != nil {
+		return dto.EraseAccountResponse{}, fmt.Errorf("service: failed to delete user: %w", err)
+	}
+	publishUserRevocation(ctx, s.revocations, userID)
+
+	return dto.EraseAccountResponse{AnonymizedRecords: anonymized}, nil
+}
+
+// Schedule fails the exports left pending by an instance that stopped while
+// gathering them, right away and then every interval until ctx is done.
+func (s *privacyService) Schedule(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		// Exports still pending past their timeout are no longer being gathered
+		before := time.Now().Add(-s.exportTimeout - exportUpdateTimeout)
+		failed, err := s.repo.FailStaleExports(ctx, before, "export stopped before it finished")
+		if err != nil {
+			log.Printf("[Service - Privacy] Error failing stale exports: %v", err)
+		} else if failed > 0 {
+			log.Printf("[Service - Privacy] Marked %d stale exports as failed", failed)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runExport builds the archive for an export and records the outcome.
+func (s *privacyService) runExport(exportID, userID uuid.UUID) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.exportTimeout)
+	defer cancel()
+
+	archive, err := s.buildArchive(ctx, userID)
+
+	// Gathering may have used up its whole timeout, so the outcome is
+	// recorded on a context of its own
+	ctx, cancel = context.WithTimeout(context.Background(), exportUpdateTimeout)
+	defer cancel()
+
+	if err != nil {
+		log.Printf("[Service - RequestExport] Error building archive for export %s: %v", exportID, err)
+		if err := s.repo.FailExport(ctx, exportID, "failed to gather personal data"); err != nil {
+			log.Printf("[Service - RequestExport] Error marking export %s failed: %v", exportID, err)
+		}
+		return
+	}
+
+	if err := s.repo.CompleteExport(ctx, exportID, archive); err != nil {
+		log.Printf("[Service - RequestExport] Error storing export %s: %v", exportID, err)
+	}
+}
+
+func (s *privacyService) buildArchive(ctx context.Context, userID uuid.UUID) ([]byte, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	records, err := s.bookRepo.ExportBorrowingRecords(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	archive := dto.DataArchive{
+		GeneratedAt: time.Now().UTC(),
+		Profile: dto.ArchiveProfile{
+			UserID:    user.UserID,
+			Name:      user.Name,
+			Email:     user.Email,
+			Role:      user.Role,
+			CreatedAt: user.CreatedAt,
+			UpdatedAt: user.UpdatedAt,
+		},
+		BorrowingRecords: make([]dto.ArchiveBorrowingRecord, 0, len(records)),
+	}
+	for _, record := range records {
+		archive.BorrowingRecords = append(archive.BorrowingRecords, dto.ArchiveBorrowingRecord{
+			ID:         record.ID,
+			BookID:     record.BookID,
+			BookTitle:  record.BookTitle,
+			BookAuthor: record.BookAuthor,
+			ISBN:       record.ISBN,
+			BorrowedAt: record.BorrowedAt,
+			DueDate:    record.DueDate,
+			ReturnedAt: record.ReturnedAt,
+		})
+	}
+
+	return json.MarshalIndent(archive, "", "  ")
+}
+
+func (s *privacyService) getExport(ctx context.Context, userID, exportID uuid.UUID) (*models.DataExport, error) {
+	export, err := s.repo.GetExport(ctx, userID, exportID)
+	if err != nil {
+		return nil, fmt.Errorf("service: failed to get data export: %w", err)
+	}
+	if export == nil || time.Now().After(export.ExpiresAt) {
+		return nil, ErrExportNotFound
+	}
+
+	return export, nil
+}
+
+func toDataExport(export models.DataExport) dto.GetDataExport {
+	return dto.GetDataExport{
+		ID:          export.ID,
+		Status:      export.Status,
+		Error:       export.Error,
+		CreatedAt:   export.CreatedAt,
+		CompletedAt: export.CompletedAt,
+		ExpiresAt:   export.ExpiresAt,
+	}
+}
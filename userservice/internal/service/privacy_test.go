@@ -0,0 +1,267 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/sir-shalahuddin/grpc-learn/userservice/internal/dto"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/internal/models"
+)
+
+// MockDataExportRepository adalah implementasi mock dari DataExportRepository.
+type MockDataExportRepository struct {
+	CreateExportFunc   func(ctx context.Context, export *models.DataExport) error
+	GetExportFunc      func(ctx context.Context, userID, exportID uuid.UUID) (*models.DataExport, error)
+	CompleteExportFunc func(ctx context.Context, exportID uuid.UUID, archive []byte) error
+	FailExportFunc     func(ctx context.Context, exportID uuid.UUID, reason string) error
+	FailStaleFunc      func(ctx context.Context, before time.Time, reason string) (int64, error)
+}
+
+func (m *MockDataExportRepository) CreateExport(ctx context.Context, export *models.DataExport) error {
+	return m.CreateExportFunc(ctx, export)
+}
+
+func (m *MockDataExportRepository) GetExport(ctx context.Context, userID, exportID uuid.UUID) (*models.DataExport, error) {
+	return m.GetExportFunc(ctx, userID, exportID)
+}
+
+func (m *MockDataExportRepository) CompleteExport(ctx context.Context, exportID uuid.UUID, archive []byte) error {
+	return m.CompleteExportFunc(ctx, exportID, archive)
+}
+
+func (m *MockDataExportRepository) FailExport(ctx context.Context, exportID uuid.UUID, reason string) error {
+	return m.FailExportFunc(ctx, exportID, reason)
+}
+
+func (m *MockDataExportRepository) FailStaleExports(ctx context.Context, before time.Time, reason string) (int64, error) {
+	return m.FailStaleFunc(ctx, before, reason)
+}
+
+// MockPrivacyUserRepository adalah implementasi mock dari PrivacyUserRepository.
+type MockPrivacyUserRepository struct {
+	GetUserByIDFunc    func(ctx context.Context, userID uuid.UUID) (*models.User, error)
+	GetUserByEmailFunc func(ctx context.Context, email string) (*models.User, error)
+	DeleteUserFunc     func(ctx context.Context, userID uuid.UUID) error
+}
+
+func (m *MockPrivacyUserRepository) GetUserByID(ctx context.Context, userID uuid.UUID) (*models.User, error) {
+	return m.GetUserByIDFunc(ctx, userID)
+}
+
+func (m *MockPrivacyUserRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	return m.GetUserByEmailFunc(ctx, email)
+}
+
+func (m *MockPrivacyUserRepository) DeleteUser(ctx context.Context, userID uuid.UUID) error {
+	return m.DeleteUserFunc(ctx, userID)
+}
+
+// MockBorrowingRecordRepository adalah implementasi mock dari BorrowingRecordRepository.
+type MockBorrowingRecordRepository struct {
+	ExportBorrowingRecordsFunc func(ctx context.Context, userID uuid.UUID) ([]models.BorrowingRecord, error)
+	EraseBorrowingRecordsFunc  func(ctx context.Context, userID uuid.UUID) (int64, bool, error)
+}
+
+func (m *MockBorrowingRecordRepository) ExportBorrowingRecords(ctx context.Context, userID uuid.UUID) ([]models.BorrowingRecord, error) {
+	return m.ExportBorrowingRecordsFunc(ctx, userID)
+}
+
+func (m *MockBorrowingRecordRepository) EraseBorrowingRecords(ctx context.Context, userID uuid.UUID) (int64, bool, error) {
+	return m.EraseBorrowingRecordsFunc(ctx, userID)
+}
+
+func newMockPrivacyUserRepository(role, password string) *MockPrivacyUserRepository {
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return &MockPrivacyUserRepository{
+		GetUserByIDFunc: func(ctx context.Context, userID uuid.UUID) (*models.User, error) {
+			return &models.User{UserID: userID, Name: "Test User", Email: "test@example.com", Role: role}, nil
+		},
+		GetUserByEmailFunc: func(ctx context.Context, email string) (*models.User, error) {
+			return &models.User{Email: email, Password: string(hashedPassword)}, nil
+		},
+	}
+}
+
+// Test runExport: Arsip berisi profil dan riwayat peminjaman
+func TestRunExport_Success(t *testing.T) {
+	var archive []byte
+	mockRepo := &MockDataExportRepository{
+		CompleteExportFunc: func(ctx context.Context, exportID uuid.UUID, data []byte) error {
+			archive = data
+			return nil
+		},
+	}
+	mockBookRepo := &MockBorrowingRecordRepository{
+		ExportBorrowingRecordsFunc: func(ctx context.Context, userID uuid.UUID) ([]models.BorrowingRecord, error) {
+			return []models.BorrowingRecord{{ID: uuid.New(), BookTitle: "Laskar Pelangi"}}, nil
+		},
+	}
+	privacyService := NewPrivacyService(mockRepo, newMockPrivacyUserRepository("user", "Password123!"), mockBookRepo, &MockRevocationPublisher{})
+
+	userID := uuid.New()
+	privacyService.runExport(uuid.New(), userID)
+
+	var res dto.DataArchive
+	if err := json.Unmarshal(archive, &res); err != nil {
+		t.Fatalf("expected a JSON archive, got %v", err)
+	}
+	if res.Profile.UserID != userID || res.Profile.Email != "test@example.com" {
+		t.Errorf("unexpected profile %+v", res.Profile)
+	}
+	if len(res.BorrowingRecords) != 1 || res.BorrowingRecords[0].BookTitle != "Laskar Pelangi" {
+		t.Errorf("unexpected borrowing records %+v", res.BorrowingRecords)
+	}
+}
+
+// Test runExport: Export yang melewati batas waktu tetap ditandai gagal
+func TestRunExport_TimeoutMarksFailed(t *testing.T) {
+	var failed bool
+	mockRepo := &MockDataExportRepository{
+		FailExportFunc: func(ctx context.Context, exportID uuid.UUID, reason string) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			failed = true
+			return nil
+		},
+	}
+	mockBookRepo := &MockBorrowingRecordRepository{
+		ExportBorrowingRecordsFunc: func(ctx context.Context, userID uuid.UUID) ([]models.BorrowingRecord, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	}
+	privacyService := NewPrivacyService(mockRepo, newMockPrivacyUserRepository("user", "Password123!"), mockBookRepo, &MockRevocationPublisher{})
+	privacyService.exportTimeout = 10 * time.Millisecond
+
+	privacyService.runExport(uuid.New(), uuid.New())
+
+	if !failed {
+		t.Error("expected the export to be marked failed after the timeout")
+	}
+}
+
+// Test Schedule: Export pending yang melewati batas waktu ditandai gagal saat mulai
+func TestSchedule_FailsStaleExports(t *testing.T) {
+	var before time.Time
+	mockRepo := &MockDataExportRepository{
+		FailStaleFunc: func(ctx context.Context, cutoff time.Time, reason string) (int64, error) {
+			before = cutoff
+			return 1, nil
+		},
+	}
+	privacyService := NewPrivacyService(mockRepo, nil, nil, &MockRevocationPublisher{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	privacyService.Schedule(ctx, time.Hour)
+
+	if before.IsZero() {
+		t.Fatal("expected stale exports to be failed right away")
+	}
+	if age := time.Since(before); age < ExportTimeout {
+		t.Errorf("expected only exports older than ExportTimeout to be failed, got a cutoff %v ago", age)
+	}
+}
+
+// Test DownloadExport: Ekspor belum selesai
+func TestDownloadExport_NotReady(t *testing.T) {
+	mockRepo := &MockDataExportRepository{
+		GetExportFunc: func(ctx context.Context, userID, exportID uuid.UUID) (*models.DataExport, error) {
+			return &models.DataExport{ID: exportID, Status: models.ExportStatusPending, ExpiresAt: time.Now().Add(time.Hour)}, nil
+		},
+	}
+	privacyService := NewPrivacyService(mockRepo, &MockPrivacyUserRepository{}, &MockBorrowingRecordRepository{}, &MockRevocationPublisher{})
+
+	_, err := privacyService.DownloadExport(context.Background(), uuid.New(), uuid.New())
+
+	if err != ErrExportNotReady {
+		t.Errorf("expected ErrExportNotReady, got %v", err)
+	}
+}
+
+// Test DownloadExport: Ekspor kedaluwarsa
+func TestDownloadExport_Expired(t *testing.T) {
+	mockRepo := &MockDataExportRepository{
+		GetExportFunc: func(ctx context.Context, userID, exportID uuid.UUID) (*models.DataExport, error) {
+			return &models.DataExport{ID: exportID, Status: models.ExportStatusCompleted, ExpiresAt: time.Now().Add(-time.Hour)}, nil
+		},
+	}
+	privacyService := NewPrivacyService(mockRepo, &MockPrivacyUserRepository{}, &MockBorrowingRecordRepository{}, &MockRevocationPublisher{})
+
+	_, err := privacyService.DownloadExport(context.Background(), uuid.New(), uuid.New())
+
+	if err != ErrExportNotFound {
+		t.Errorf("expected ErrExportNotFound, got %v", err)
+	}
+}
+
+// Test EraseAccount: Password salah
+func TestEraseAccount_InvalidPassword(t *testing.T) {
+	privacyService := NewPrivacyService(&MockDataExportRepository{}, newMockPrivacyUserRepository("user", "Password123!"), &MockBorrowingRecordRepository{}, &MockRevocationPublisher{})
+
+	_, err := privacyService.EraseAccount(context.Background(), uuid.New(), dto.EraseAccountRequest{Password: "wrong"})
+
+	if err != ErrInvalidCredentials {
+		t.Errorf("expected ErrInvalidCredentials, got %v", err)
+	}
+}
+
+// Test EraseAccount: Masih ada buku yang dipinjam
+func TestEraseAccount_ActiveLoans(t *testing.T) {
+	deleted := false
+	mockUserRepo := newMockPrivacyUserRepository("user", "Password123!")
+	mockUserRepo.DeleteUserFunc = func(ctx context.Context, userID uuid.UUID) error {
+		deleted = true
+		return nil
+	}
+	mockBookRepo := &MockBorrowingRecordRepository{
+		EraseBorrowingRecordsFunc: func(ctx context.Context, userID uuid.UUID) (int64, bool, error) {
+			return 0, false, nil
+		},
+	}
+	privacyService := NewPrivacyService(&MockDataExportRepository{}, mockUserRepo, mockBookRepo, &MockRevocationPublisher{})
+
+	_, err := privacyService.EraseAccount(context.Background(), uuid.New(), dto.EraseAccountRequest{Password: "Password123!"})
+
+	if err != ErrActiveLoans {
+		t.Errorf("expected ErrActiveLoans, got %v", err)
+	}
+	if deleted {
+		t.Error("expected the user to be kept")
+	}
+}
+
+// Test EraseAccount: Berhasil menghapus akun
+func TestEraseAccount_Success(t *testing.T) {
+	var deletedID uuid.UUID
+	mockUserRepo := newMockPrivacyUserRepository("user", "Password123!")
+	mockUserRepo.DeleteUserFunc = func(ctx context.Context, userID uuid.UUID) error {
+		deletedID = userID
+		return nil
+	}
+	mockBookRepo := &MockBorrowingRecordRepository{
+		EraseBorrowingRecordsFunc: func(ctx context.Context, userID uuid.UUID) (int64, bool, error) {
+			return 4, true, nil
+		},
+	}
+	privacyService := NewPrivacyService(&MockDataExportRepository{}, mockUserRepo, mockBookRepo, &MockRevocationPublisher{})
+
+	userID := uuid.New()
+	res, err := privacyService.EraseAccount(context.Background(), userID, dto.EraseAccountRequest{Password: "Password123!"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if res.AnonymizedRecords != 4 {
+		t.Errorf("expected 4 anonymized records, got %d", res.AnonymizedRecords)
+	}
+	if deletedID != userID {
+		t.Error("expected the user to be deleted")
+	}
+}
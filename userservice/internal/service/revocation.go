@@ -0,0 +1,23 @@
+package service
+
+import (
+	"context"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/internal/models"
+)
+
+type RevocationPublisher interface {
+	PublishRevocation(ctx context.Context, event models.RevocationEvent) error
+}
+
+// publishUserRevocation makes bookservice and bookcategoryservice drop what
+// they cached about the user's tokens. The change it follows is already
+// committed, so a failure is only logged and the caches expire on their own.
+func publishUserRevocation(ctx context.Context, revocations RevocationPublisher, userID uuid.UUID) {
+	event := models.RevocationEvent{UserID: userID.String()}
+	if err := revocations.PublishRevocation(ctx, event); err != nil {
+		log.Printf("[Service - PublishRevocation] Error publishing revocation of user %s: %v", userID, err)
+	}
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/internal/dto"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/internal/models"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+type SessionRepository interface {
+	ListActiveSessions(ctx context.Context, userID uuid.UUID) ([]models.Session, error)
+	RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) (bool, error)
+	RevokeAllSessions(ctx context.Context, userID uuid.UUID) (int64, error)
+}
+
+type sessionService struct {
+	repo        SessionRepository
+	userRepo    APIKeyUserRepository
+	revocations RevocationPublisher
+}
+
+func NewSessionService(repo SessionRepository, userRepo APIKeyUserRepository, revocations RevocationPublisher) *sessionService {
+	return &sessionService{
+		repo:        repo,
+		userRepo:    userRepo,
+		revocations: revocations,
+	}
+}
+
+// ListSessions returns the user's active sessions, flagging the one the
+// request was made from.
+func (s *sessionService) ListSessions(ctx context.Context, userID, currentSessionID uuid.UUID) ([]dto.GetSession, error) {
+	sessions, err := s.repo.ListActiveSessions(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("service: failed to list sessions: %w", err)
+	}
+
+	res := make([]dto.GetSession, 0, len(sessions))
+	for _, session := range sessions {
+		res = append(res, dto.GetSession{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IPAddress:  session.IPAddress,
+			Current:    currentSessionID != uuid.Nil && session.ID == currentSessionID,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			ExpiresAt:  session.ExpiresAt,
+		})
+	}
+
+	return res, nil
+}
+
+// RevokeSession signs the user out of one of their sessions.
+func (s *sessionService) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	found, err := s.repo.RevokeSession(ctx, userID, sessionID)
+	if err != nil {
+		return fmt.Errorf("service: failed to revoke session: %w", err)
+	}
+	if !found {
+		return ErrSessionNotFound
+	}
+
+	publishUserRevocation(ctx, s.revocations, userID)
+	return nil
+}
+
+// ListUserSessions returns the active sessions of any user.
+func (s *sessionService) ListUserSessions(ctx context.Context, userID uuid.UUID) ([]dto.GetSession, error) {
+	if _, err := s.getUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	return s.ListSessions(ctx, userID, uuid.Nil)
+}
+
+// RevokeUserSession kills one session of a user. Super admin sessions are
+// left to their owners.
+func (s *sessionService) RevokeUserSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.Role == "super admin" {
+		return ErrInsufficientPermissions
+	}
+
+	return s.RevokeSession(ctx, userID, sessionID)
+}
+
+// RevokeUserSessions kills every active session of a user.
+func (s *sessionService) RevokeUserSessions(ctx context.Context, userID uuid.UUID) (dto.RevokeSessionsResponse, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return dto.RevokeSessionsResponse{}, err
+	}
+	if user.Role == "super admin" {
+		return dto.RevokeSessionsResponse{}, ErrInsufficientPermissions
+	}
+
+	revoked, err := s.repo.RevokeAllSessions(ctx, userID)
+	if err != nil {
+		return dto.RevokeSessionsResponse{}, fmt.Errorf("service: failed to revoke sessions: %w", err)
+	}
+	publishUserRevocation(ctx, s.revocations, userID)
+
+	return dto.RevokeSessionsResponse{Revoked: revoked}, nil
+}
+
+func (s *sessionService) getUser(ctx context.Context, userID uuid.UUID) (*models.User, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("service: failed to get user by ID: %w", err)
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	return user, nil
+}
@@ -0,0 +1,265 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sir-shalahuddin/grpc-learn/userservice/internal/dto"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/internal/models"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/pkg/auth"
+)
+
+// MockSessionRepository adalah implementasi mock dari SessionRepository dan AuthSessionRepository.
+type MockSessionRepository struct {
+	CreateSessionFunc      func(ctx context.Context, session *models.Session) error
+	GetSessionByIDFunc     func(ctx context.Context, sessionID uuid.UUID) (*models.Session, error)
+	ListActiveSessionsFunc func(ctx context.Context, userID uuid.UUID) ([]models.Session, error)
+	RevokeSessionFunc      func(ctx context.Context, userID, sessionID uuid.UUID) (bool, error)
+	RevokeAllSessionsFunc  func(ctx context.Context, userID uuid.UUID) (int64, error)
+	TouchSessionFunc       func(ctx context.Context, sessionID uuid.UUID) error
+}
+
+func (m *MockSessionRepository) CreateSession(ctx context.Context, session *models.Session) error {
+	return m.CreateSessionFunc(ctx, session)
+}
+
+func (m *MockSessionRepository) GetSessionByID(ctx context.Context, sessionID uuid.UUID) (*models.Session, error) {
+	return m.GetSessionByIDFunc(ctx, sessionID)
+}
+
+func (m *MockSessionRepository) ListActiveSessions(ctx context.Context, userID uuid.UUID) ([]models.Session, error) {
+	return m.ListActiveSessionsFunc(ctx, userID)
+}
+
+func (m *MockSessionRepository) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) (bool, error) {
+	return m.RevokeSessionFunc(ctx, userID, sessionID)
+}
+
+func (m *MockSessionRepository) RevokeAllSessions(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return m.RevokeAllSessionsFunc(ctx, userID)
+}
+
+func (m *MockSessionRepository) TouchSession(ctx context.Context, sessionID uuid.UUID) error {
+	return m.TouchSessionFunc(ctx, sessionID)
+}
+
+// MockRevocationPublisher mencatat event pencabutan yang dipublikasikan.
+type MockRevocationPublisher struct {
+	Events []models.RevocationEvent
+}
+
+func (m *MockRevocationPublisher) PublishRevocation(ctx context.Context, event models.RevocationEvent) error {
+	m.Events = append(m.Events, event)
+	return nil
+}
+
+// newStoringSessionRepository menyimpan sesi yang dibuat di memori.
+func newStoringSessionRepository() (*MockSessionRepository, map[uuid.UUID]*models.Session) {
+	sessions := map[uuid.UUID]*models.Session{}
+	return &MockSessionRepository{
+		CreateSessionFunc: func(ctx context.Context, session *models.Session) error {
+			sessions[session.ID] = session
+			return nil
+		},
+		GetSessionByIDFunc: func(ctx context.Context, sessionID uuid.UUID) (*models.Session, error) {
+			return sessions[sessionID], nil
+		},
+		TouchSessionFunc: func(ctx context.Context, sessionID uuid.UUID) error {
+			return nil
+		},
+	}, sessions
+}
+
+// Test IssueTokens: Sesi dibuat dengan perangkat dan hash refresh token
+func TestIssueTokens_CreatesSession(t *testing.T) {
+	mockSessionRepo, sessions := newStoringSessionRepository()
+	authService := NewAuthService(&MockAuthRepository{}, &MockAPIKeyRepository{}, mockSessionRepo, "jwt-secret")
+
+	userID := uuid.New()
+	resp, err := authService.IssueTokens(context.Background(), userID, dto.SessionDevice{UserAgent: "curl/8.0", IPAddress: "10.0.0.1"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(sessions) != 1 {
+		t.Fatalf("expected one session, got %d", len(sessions))
+	}
+	for _, session := range sessions {
+		if session.UserID != userID || session.UserAgent != "curl/8.0" || session.IPAddress != "10.0.0.1" {
+			t.Errorf("unexpected session %+v", session)
+		}
+		if !auth.CompareToken(resp.RefreshToken, session.RefreshTokenHash) {
+			t.Error("expected the refresh token hash to be stored")
+		}
+	}
+
+	info, err := authService.ValidateToken(context.Background(), resp.AccessToken)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if info.SessionID == uuid.Nil {
+		t.Error("expected the access token to carry the session ID")
+	}
+}
+
+// Test RefreshToken: Sesi yang dicabut tidak bisa refresh
+func TestRefreshToken_RevokedSession(t *testing.T) {
+	mockSessionRepo, sessions := newStoringSessionRepository()
+	authService := NewAuthService(&MockAuthRepository{}, &MockAPIKeyRepository{}, mockSessionRepo, "jwt-secret")
+
+	resp, _ := authService.IssueTokens(context.Background(), uuid.New(), dto.SessionDevice{})
+	if _, err := authService.RefreshToken(context.Background(), dto.RefreshTokenRequest{RefreshToken: resp.RefreshToken}); err != nil {
+		t.Fatalf("expected no error before revocation, got %v", err)
+	}
+
+	now := time.Now()
+	for _, session := range sessions {
+		session.RevokedAt = &now
+	}
+
+	_, err := authService.RefreshToken(context.Background(), dto.RefreshTokenRequest{RefreshToken: resp.RefreshToken})
+	if err != auth.ErrInvalidToken {
+		t.Errorf("expected ErrInvalidToken, got %v", err)
+	}
+	if _, err := authService.ValidateToken(context.Background(), resp.AccessToken); err != auth.ErrInvalidToken {
+		t.Errorf("expected access token to be rejected, got %v", err)
+	}
+}
+
+// Test RefreshClientToken: Refresh token hanya untuk client yang menerimanya
+func TestRefreshClientToken_BoundToClient(t *testing.T) {
+	mockSessionRepo, _ := newStoringSessionRepository()
+	authService := NewAuthService(&MockAuthRepository{}, &MockAPIKeyRepository{}, mockSessionRepo, "jwt-secret")
+
+	resp, err := authService.IssueClientTokens(context.Background(), uuid.New(), "app", "openid", dto.SessionDevice{})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	req := dto.RefreshTokenRequest{RefreshToken: resp.RefreshToken}
+
+	if _, err := authService.RefreshClientToken(context.Background(), req, "other"); err != auth.ErrInvalidToken {
+		t.Errorf("expected ErrInvalidToken for another client, got %v", err)
+	}
+	if _, err := authService.RefreshToken(context.Background(), req); err != auth.ErrInvalidToken {
+		t.Errorf("expected ErrInvalidToken without a client, got %v", err)
+	}
+	if _, err := authService.RefreshClientToken(context.Background(), req, "app"); err != nil {
+		t.Errorf("expected no error for its client, got %v", err)
+	}
+}
+
+// Test ListSessions: Sesi saat ini ditandai
+func TestListSessions_MarksCurrent(t *testing.T) {
+	current, other := uuid.New(), uuid.New()
+	mockRepo := &MockSessionRepository{
+		ListActiveSessionsFunc: func(ctx context.Context, userID uuid.UUID) ([]models.Session, error) {
+			return []models.Session{{ID: current}, {ID: other}}, nil
+		},
+	}
+	sessionService := NewSessionService(mockRepo, &MockUserRepository{}, &MockRevocationPublisher{})
+
+	sessions, err := sessionService.ListSessions(context.Background(), uuid.New(), current)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(sessions) != 2 || !sessions[0].Current || sessions[1].Current {
+		t.Errorf("expected only the first session to be current, got %+v", sessions)
+	}
+}
+
+// Test RevokeSession: Sesi tidak ditemukan
+func TestRevokeSession_NotFound(t *testing.T) {
+	mockRepo := &MockSessionRepository{
+		RevokeSessionFunc: func(ctx context.Context, userID, sessionID uuid.UUID) (bool, error) {
+			return false, nil
+		},
+	}
+	sessionService := NewSessionService(mockRepo, &MockUserRepository{}, &MockRevocationPublisher{})
+
+	err := sessionService.RevokeSession(context.Background(), uuid.New(), uuid.New())
+
+	if err != ErrSessionNotFound {
+		t.Errorf("expected ErrSessionNotFound, got %v", err)
+	}
+}
+
+// Test RevokeSession: Layanan lain langsung diberi tahu agar token sesi ditolak
+func TestRevokeSession_PublishesRevocation(t *testing.T) {
+	mockRepo := &MockSessionRepository{
+		RevokeSessionFunc: func(ctx context.Context, userID, sessionID uuid.UUID) (bool, error) {
+			return true, nil
+		},
+	}
+	revocations := &MockRevocationPublisher{}
+	sessionService := NewSessionService(mockRepo, &MockUserRepository{}, revocations)
+
+	userID := uuid.New()
+	if err := sessionService.RevokeSession(context.Background(), userID, uuid.New()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(revocations.Events) != 1 || revocations.Events[0].UserID != userID.String() {
+		t.Errorf("expected a revocation event for the user, got %+v", revocations.Events)
+	}
+}
+
+// Test RevokeSession: Tidak ada event bila sesi tidak ditemukan
+func TestRevokeSession_NotFoundPublishesNothing(t *testing.T) {
+	mockRepo := &MockSessionRepository{
+		RevokeSessionFunc: func(ctx context.Context, userID, sessionID uuid.UUID) (bool, error) {
+			return false, nil
+		},
+	}
+	revocations := &MockRevocationPublisher{}
+	sessionService := NewSessionService(mockRepo, &MockUserRepository{}, revocations)
+
+	sessionService.RevokeSession(context.Background(), uuid.New(), uuid.New())
+
+	if len(revocations.Events) != 0 {
+		t.Errorf("expected no revocation event, got %+v", revocations.Events)
+	}
+}
+
+// Test RevokeUserSessions: Tidak bisa mematikan sesi super admin
+func TestRevokeUserSessions_SuperAdmin(t *testing.T) {
+	mockUserRepo := &MockUserRepository{
+		GetUserByIDFunc: func(ctx context.Context, userID uuid.UUID) (*models.User, error) {
+			return &models.User{UserID: userID, Role: "super admin"}, nil
+		},
+	}
+	sessionService := NewSessionService(&MockSessionRepository{}, mockUserRepo, &MockRevocationPublisher{})
+
+	_, err := sessionService.RevokeUserSessions(context.Background(), uuid.New())
+
+	if err != ErrInsufficientPermissions {
+		t.Errorf("expected ErrInsufficientPermissions, got %v", err)
+	}
+}
+
+// Test RevokeUserSessions: Berhasil mematikan semua sesi
+func TestRevokeUserSessions_Success(t *testing.T) {
+	mockUserRepo := &MockUserRepository{
+		GetUserByIDFunc: func(ctx context.Context, userID uuid.UUID) (*models.User, error) {
+			return &models.User{UserID: userID, Role: "user"}, nil
+		},
+	}
+	mockRepo := &MockSessionRepository{
+		RevokeAllSessionsFunc: func(ctx context.Context, userID uuid.UUID) (int64, error) {
+			return 3, nil
+		},
+	}
+	sessionService := NewSessionService(mockRepo, mockUserRepo, &MockRevocationPublisher{})
+
+	res, err := sessionService.RevokeUserSessions(context.Background(), uuid.New())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if res.Revoked != 3 {
+		t.Errorf("expected 3 revoked sessions, got %d", res.Revoked)
+	}
+}
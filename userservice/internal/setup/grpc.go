@@ -13,7 +13,9 @@ import (
 func GRPCServer(grpc *grpc.Server, db *sql.DB, jwtSecret string) {
 	// Initialize repositories, services, and servers
 	userRepo := repository.NewUserRepository(db)
-	authService := service.NewAuthService(userRepo, jwtSecret)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+	authService := service.NewAuthService(userRepo, apiKeyRepo, sessionRepo, jwtSecret)
 	authServer := server.NewAuthServiceServer(authService)
 
 	// Register AuthService routes
@@ -0,0 +1,3 @@
+ALTER TABLE sessions ADD COLUMN client_id VARCHAR(64) REFERENCES oauth_clients(client_id) ON DELETE CASCADE;
+-- Scopes the user granted to the client, limiting what /oauth/userinfo returns
+ALTER TABLE sessions ADD COLUMN scope VARCHAR(255) NOT NULL DEFAULT '';
@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+// APIKeyPrefix marks a bearer token as an API key rather than a JWT.
+const APIKeyPrefix = "lib_"
+
+// GenerateAPIKey creates a new API key of the form lib_<prefix>_<secret>.
+// Only the returned prefix and hash should be persisted; the key itself is
+// shown to the owner once.
+func GenerateAPIKey() (key, prefix, hash string, err error) {
+	idBytes := make([]byte, 4)
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", "", "", err
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", "", err
+	}
+
+	prefix = APIKeyPrefix + hex.EncodeToString(idBytes)
+	key = prefix + "_" + base64.RawURLEncoding.EncodeToString(secret)
+
+	return key, prefix, HashAPIKey(key), nil
+}
+
+// IsAPIKey reports whether the token looks like an API key.
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, APIKeyPrefix)
+}
+
+// ParseAPIKey returns the lookup prefix of an API key.
+func ParseAPIKey(key string) (string, bool) {
+	if !IsAPIKey(key) {
+		return "", false
+	}
+	idx := strings.Index(key[len(APIKeyPrefix):], "_")
+	if idx <= 0 {
+		return "", false
+	}
+	return key[:len(APIKeyPrefix)+idx], true
+}
+
+// HashAPIKey returns the hex encoded SHA-256 of the key.
+func HashAPIKey(key string) string {
+	return HashToken(key)
+}
+
+// CompareAPIKey checks a key against a stored hash in constant time.
+func CompareAPIKey(key, hash string) bool {
+	return CompareToken(key, hash)
+}
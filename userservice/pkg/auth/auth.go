@@ -1,9 +1,13 @@
 package auth
 
 import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/golang-jwt/jwt"
 	"github.com/google/uuid"
@@ -11,7 +15,25 @@ import (
 
 var ErrInvalidToken = errors.New("invalid or expired token")
 
+// Claims holds the identifiers carried by a JWT. SessionID is uuid.Nil for
+// tokens issued before sessions were introduced.
+type Claims struct {
+	UserID    uuid.UUID
+	SessionID uuid.UUID
+	ExpiresAt time.Time
+}
+
 func ValidateToken(tokenStr, jwtSecret string) (uuid.UUID, error) {
+	claims, err := ParseToken(tokenStr, jwtSecret)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	return claims.UserID, nil
+}
+
+// ParseToken validates a JWT and returns its user and session IDs.
+func ParseToken(tokenStr, jwtSecret string) (*Claims, error) {
 	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
 		// Validate the signing method and return the secret
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
@@ -23,24 +45,50 @@ func ValidateToken(tokenStr, jwtSecret string) (uuid.UUID, error) {
 
 	if err != nil {
 		log.Printf("[ValidateToken] Parsing Token : %v", err)
-		return uuid.UUID{}, err
+		return nil, err
 	}
 
 	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
 		userID, ok := claims["user_id"].(string)
 		if !ok || userID == "" {
 			log.Printf("[ValidateToken] Invalid user ID in token claims")
-			return uuid.UUID{}, errors.New("invalid user ID in token claims")
+			return nil, errors.New("invalid user ID in token claims")
 		}
 
 		id, err := uuid.Parse(userID)
 		if err != nil {
 			log.Printf("[ValidateToken] Error parsing uuid : %v", err)
-			return uuid.UUID{}, err
+			return nil, err
 		}
 
-		return id, nil
+		var sessionID uuid.UUID
+		if sid, ok := claims["sid"].(string); ok {
+			sessionID, err = uuid.Parse(sid)
+			if err != nil {
+				log.Printf("[ValidateToken] Error parsing session id : %v", err)
+				return nil, ErrInvalidToken
+			}
+		}
+
+		var expiresAt time.Time
+		if exp, ok := claims["exp"].(float64); ok {
+			expiresAt = time.Unix(int64(exp), 0)
+		}
+
+		return &Claims{UserID: id, SessionID: sessionID, ExpiresAt: expiresAt}, nil
 	}
 
-	return uuid.UUID{}, ErrInvalidToken
+	return nil, ErrInvalidToken
+}
+
+// HashToken returns the hex encoded SHA-256 of a token, for storing refresh
+// tokens without keeping them in plain text.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CompareToken checks a token against a stored hash in constant time.
+func CompareToken(token, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(HashToken(token)), []byte(hash)) == 1
 }
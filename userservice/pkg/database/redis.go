@@ -0,0 +1,34 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sir-shalahuddin/grpc-learn/userservice/config"
+)
+
+// NewRedis connects to the Redis other services read revocation events
+// from. It returns nil when no address is configured.
+func NewRedis(config config.RedisConfig) (*redis.Client, error) {
+	if config.Address == "" {
+		log.Println("REDIS_ADDR not provided, revocations are not published")
+		return nil, nil
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     config.Address,
+		Password: config.Password,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("failed to connect to redis at %s: %w", config.Address, err)
+	}
+	log.Printf("Connected to redis at %s", config.Address)
+
+	return client, nil
+}
@@ -0,0 +1,39 @@
+package grpcclient
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+
+	pb "github.com/sir-shalahuddin/grpc-learn/userservice/proto/bookservice"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// serviceToken puts the token bookservice expects from other services in the
+// metadata of every call.
+type serviceToken string
+
+func (t serviceToken) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"x-service-token": string(t)}, nil
+}
+
+func (t serviceToken) RequireTransportSecurity() bool {
+	return true
+}
+
+func NewBookClients(address, token string) (pb.BookServiceClient, error) {
+	conn, err := grpc.NewClient(address,
+		grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{})),
+		grpc.WithPerRPCCredentials(serviceToken(token)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to gRPC server at %s: %w", address, err)
+	}
+
+	// Create a new BookService client for the server.
+	client := pb.NewBookServiceClient(conn)
+	log.Printf("Connected to gRPC server at %s", address)
+
+	return client, nil
+}